@@ -0,0 +1,37 @@
+-- =============================================================================
+-- Migration: 000011_escape_folder_paths (ROLLBACK)
+-- Description: Restore unescaped folder paths
+-- =============================================================================
+
+CREATE OR REPLACE FUNCTION update_folder_path()
+RETURNS TRIGGER AS $$
+DECLARE
+    parent_path TEXT;
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        NEW.path := '/' || NEW.name;
+    ELSE
+        SELECT path INTO parent_path FROM folders WHERE id = NEW.parent_id;
+        NEW.path := parent_path || '/' || NEW.name;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+WITH RECURSIVE tree AS (
+    SELECT id, '/' || name AS path
+    FROM folders
+    WHERE parent_id IS NULL
+
+    UNION ALL
+
+    SELECT f.id, t.path || '/' || f.name
+    FROM folders f
+    JOIN tree t ON f.parent_id = t.id
+)
+UPDATE folders f
+SET path = tree.path
+FROM tree
+WHERE f.id = tree.id AND f.path IS DISTINCT FROM tree.path;
+
+DROP FUNCTION IF EXISTS escape_folder_path_segment(TEXT);
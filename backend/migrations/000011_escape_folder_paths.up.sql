@@ -0,0 +1,43 @@
+-- =============================================================================
+-- Migration: 000011_escape_folder_paths
+-- Description: Escape folder names inside materialized paths so names
+--              containing '/' keep their level boundaries unambiguous
+-- =============================================================================
+
+-- Segments encode '%' as '%25' and '/' as '%2F' (see models.EscapeFolderSegment)
+CREATE OR REPLACE FUNCTION escape_folder_path_segment(name TEXT)
+RETURNS TEXT AS $$
+    SELECT replace(replace(name, '%', '%25'), '/', '%2F');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION update_folder_path()
+RETURNS TRIGGER AS $$
+DECLARE
+    parent_path TEXT;
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        NEW.path := '/' || escape_folder_path_segment(NEW.name);
+    ELSE
+        SELECT path INTO parent_path FROM folders WHERE id = NEW.parent_id;
+        NEW.path := parent_path || '/' || escape_folder_path_segment(NEW.name);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Rebuild every existing path from the stored display names
+WITH RECURSIVE tree AS (
+    SELECT id, '/' || escape_folder_path_segment(name) AS path
+    FROM folders
+    WHERE parent_id IS NULL
+
+    UNION ALL
+
+    SELECT f.id, t.path || '/' || escape_folder_path_segment(f.name)
+    FROM folders f
+    JOIN tree t ON f.parent_id = t.id
+)
+UPDATE folders f
+SET path = tree.path
+FROM tree
+WHERE f.id = tree.id AND f.path IS DISTINCT FROM tree.path;
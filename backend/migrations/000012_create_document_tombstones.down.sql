@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Migration: 000012_create_document_tombstones (ROLLBACK)
+-- Description: Drop document tombstones
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_documents_tenant_updated_at;
+DROP TABLE IF EXISTS document_tombstones;
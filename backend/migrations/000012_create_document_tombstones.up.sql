@@ -0,0 +1,16 @@
+-- =============================================================================
+-- Migration: 000012_create_document_tombstones
+-- Description: Record deleted documents so the changes feed can report them
+-- =============================================================================
+
+CREATE TABLE document_tombstones (
+    document_id UUID PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    folder_id UUID, -- folder at deletion time, used to authorize the entry
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_document_tombstones_tenant_deleted_at ON document_tombstones(tenant_id, deleted_at);
+
+-- Changes feed scans documents by tenant and modification time
+CREATE INDEX idx_documents_tenant_updated_at ON documents(tenant_id, updated_at);
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000013_add_tenant_departed_user_shares (ROLLBACK)
+-- Description: Drop the departed member share policy
+-- =============================================================================
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS departed_user_shares;
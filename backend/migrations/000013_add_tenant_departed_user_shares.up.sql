@@ -0,0 +1,10 @@
+-- =============================================================================
+-- Migration: 000013_add_tenant_departed_user_shares
+-- Description: Per-tenant policy for the shares of a removed member
+-- =============================================================================
+
+-- reassign: shares move to the successor, else the removing admin
+-- revoke:   share links are deactivated
+ALTER TABLE tenants
+    ADD COLUMN departed_user_shares VARCHAR(20) NOT NULL DEFAULT 'reassign'
+    CHECK (departed_user_shares IN ('reassign', 'revoke'));
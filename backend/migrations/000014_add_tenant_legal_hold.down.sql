@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000014_add_tenant_legal_hold (ROLLBACK)
+-- Description: Drop the tenant legal hold flag
+-- =============================================================================
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS legal_hold;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- Migration: 000014_add_tenant_legal_hold
+-- Description: Legal hold flag that blocks tenant deletion
+-- =============================================================================
+
+-- Set only through the internal PUT /api/tenants/{id}/legal-hold endpoint
+ALTER TABLE tenants ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN tenants.legal_hold IS 'Blocks tenant deletion while set';
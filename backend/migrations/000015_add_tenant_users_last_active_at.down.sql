@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Migration: 000015_add_tenant_users_last_active_at (ROLLBACK)
+-- Description: Drop member activity tracking
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_tenant_users_tenant_last_active_at;
+ALTER TABLE tenant_users DROP COLUMN IF EXISTS last_active_at;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- Migration: 000015_add_tenant_users_last_active_at
+-- Description: Track member activity for the inactive members report
+-- =============================================================================
+
+-- NULL until the member's first recorded activity
+ALTER TABLE tenant_users ADD COLUMN last_active_at TIMESTAMPTZ;
+
+CREATE INDEX idx_tenant_users_tenant_last_active_at ON tenant_users(tenant_id, last_active_at);
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Migration: 000016_create_acls (ROLLBACK)
+-- Description: Drop folder and document access lists
+-- =============================================================================
+
+DROP TABLE IF EXISTS document_acls;
+DROP TABLE IF EXISTS folder_acls;
@@ -0,0 +1,41 @@
+-- =============================================================================
+-- Migration: 000016_create_acls
+-- Description: Folder and document access lists
+-- =============================================================================
+
+-- Access is decided by the document ACL when the document has one, else by
+-- the folder's own ACL, else by the nearest ancestor ACL with inherit set,
+-- else by the tenant-wide RBAC permission
+
+-- One row per user entry; inherit is the same on every row of a folder
+CREATE TABLE folder_acls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    folder_id UUID NOT NULL REFERENCES folders(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL, -- Identity ID from Kratos
+    actions TEXT[] NOT NULL CHECK (actions <@ ARRAY['read', 'update', 'delete']::TEXT[]),
+    inherit BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(folder_id, user_id)
+);
+
+CREATE INDEX idx_folder_acls_tenant_folder ON folder_acls(tenant_id, folder_id);
+
+CREATE TABLE document_acls (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    document_id UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL, -- Identity ID from Kratos
+    actions TEXT[] NOT NULL CHECK (actions <@ ARRAY['read', 'update', 'delete']::TEXT[]),
+    created_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(document_id, user_id)
+);
+
+CREATE INDEX idx_document_acls_tenant_document ON document_acls(tenant_id, document_id);
+
+COMMENT ON TABLE folder_acls IS 'Per-user document access granted at folder level';
+COMMENT ON TABLE document_acls IS 'Per-user access to a single document, overriding folder ACLs';
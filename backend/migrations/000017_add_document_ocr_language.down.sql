@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000017_add_document_ocr_language (ROLLBACK)
+-- Description: Drop the OCR language hint
+-- =============================================================================
+
+ALTER TABLE documents DROP COLUMN IF EXISTS ocr_language;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- Migration: 000017_add_document_ocr_language
+-- Description: OCR language hint on documents
+-- =============================================================================
+
+-- NULL lets the OCR service detect the language itself
+ALTER TABLE documents ADD COLUMN ocr_language VARCHAR(8);
+
+COMMENT ON COLUMN documents.ocr_language IS 'ISO 639-1 language hint passed to the OCR service';
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000018_add_folder_depth (ROLLBACK)
+-- Description: Drop the stored folder nesting level
+-- =============================================================================
+
+ALTER TABLE folders DROP COLUMN IF EXISTS depth;
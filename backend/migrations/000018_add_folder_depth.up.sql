@@ -0,0 +1,14 @@
+-- =============================================================================
+-- Migration: 000018_add_folder_depth
+-- Description: Stored folder nesting level
+-- =============================================================================
+
+-- Root folders are level 1; the service enforces the configured maximum
+ALTER TABLE folders ADD COLUMN depth INTEGER NOT NULL DEFAULT 1 CHECK (depth >= 1);
+
+-- Backfill existing rows from the materialized path, one level per segment
+UPDATE folders
+SET depth = GREATEST(length(path) - length(replace(path, '/', '')), 1)
+WHERE path IS NOT NULL;
+
+COMMENT ON COLUMN folders.depth IS 'Nesting level, 1 for root folders';
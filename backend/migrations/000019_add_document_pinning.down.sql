@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Migration: 000019_add_document_pinning (ROLLBACK)
+-- Description: Drop document pinning
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_documents_pinned;
+ALTER TABLE documents DROP COLUMN IF EXISTS is_pinned;
@@ -0,0 +1,11 @@
+-- =============================================================================
+-- Migration: 000019_add_document_pinning
+-- Description: Pin documents to the top of their folder
+-- =============================================================================
+
+ALTER TABLE documents ADD COLUMN is_pinned BOOLEAN NOT NULL DEFAULT false;
+
+-- Partial index for the per-folder pin limit count
+CREATE INDEX idx_documents_pinned ON documents(tenant_id, folder_id) WHERE is_pinned;
+
+COMMENT ON COLUMN documents.is_pinned IS 'Listed first in its folder for every user of the tenant';
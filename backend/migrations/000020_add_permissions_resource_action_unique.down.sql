@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000020_add_permissions_resource_action_unique (ROLLBACK)
+-- Description: Drop the resource/action uniqueness on permissions
+-- =============================================================================
+
+ALTER TABLE permissions DROP CONSTRAINT IF EXISTS permissions_resource_action_key;
@@ -0,0 +1,8 @@
+-- =============================================================================
+-- Migration: 000020_add_permissions_resource_action_unique
+-- Description: One permission per resource/action pair
+-- =============================================================================
+
+-- Bulk permission creation skips existing pairs with ON CONFLICT (resource, action)
+ALTER TABLE permissions
+    ADD CONSTRAINT permissions_resource_action_key UNIQUE (resource, action);
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000021_create_dead_letter_jobs (ROLLBACK)
+-- Description: Drop the dead-letter job table
+-- =============================================================================
+
+DROP TABLE IF EXISTS dead_letter_jobs;
@@ -0,0 +1,26 @@
+-- =============================================================================
+-- Migration: 000021_create_dead_letter_jobs
+-- Description: Async jobs that failed every retry attempt
+-- =============================================================================
+
+-- Shared by every service; each service only reads rows with its own name.
+-- tenant_id has no foreign key so failures outlive a deleted tenant.
+CREATE TABLE dead_letter_jobs (
+    id UUID PRIMARY KEY,
+    service VARCHAR(100) NOT NULL,
+    job_type VARCHAR(100) NOT NULL,
+    tenant_id UUID,
+    request_id VARCHAR(100),
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL CHECK (attempts >= 1),
+    last_error TEXT NOT NULL,
+    failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    requeued_at TIMESTAMPTZ
+);
+
+-- Pending view of a service, newest first
+CREATE INDEX idx_dead_letter_jobs_service ON dead_letter_jobs(service, failed_at DESC) WHERE requeued_at IS NULL;
+CREATE INDEX idx_dead_letter_jobs_tenant ON dead_letter_jobs(tenant_id) WHERE tenant_id IS NOT NULL;
+
+COMMENT ON TABLE dead_letter_jobs IS 'Exhausted worker jobs kept for inspection and requeue';
+COMMENT ON COLUMN dead_letter_jobs.requeued_at IS 'Set when the job was resubmitted; NULL while pending';
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000022_create_permission_decisions (ROLLBACK)
+-- Description: Drop the permission decision log
+-- =============================================================================
+
+DROP TABLE IF EXISTS permission_decisions;
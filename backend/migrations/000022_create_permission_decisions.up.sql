@@ -0,0 +1,26 @@
+-- =============================================================================
+-- Migration: 000022_create_permission_decisions
+-- Description: Optional log of permission check outcomes
+-- =============================================================================
+
+-- Written by rbac-service when decision logging is enabled and purged by
+-- the audit retention job
+CREATE TABLE permission_decisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL, -- Identity ID from Kratos
+    resource VARCHAR(100) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    matched_role VARCHAR(100), -- NULL when denied
+    cached BOOLEAN NOT NULL DEFAULT false,
+    checked_by VARCHAR(255) NOT NULL DEFAULT '', -- caller that asked, empty for internal checks
+    request_id VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_permission_decisions_tenant_created ON permission_decisions(tenant_id, created_at DESC);
+CREATE INDEX idx_permission_decisions_user ON permission_decisions(tenant_id, user_id);
+CREATE INDEX idx_permission_decisions_created_at ON permission_decisions(created_at);
+
+COMMENT ON TABLE permission_decisions IS 'Permission check outcomes, kept for the audit retention window';
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000023_add_file_metadata_deleted_at (ROLLBACK)
+-- Description: Drop the file metadata soft-delete marker
+-- =============================================================================
+
+ALTER TABLE IF EXISTS file_metadata DROP COLUMN IF EXISTS deleted_at;
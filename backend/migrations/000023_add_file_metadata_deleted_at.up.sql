@@ -0,0 +1,8 @@
+-- =============================================================================
+-- Migration: 000023_add_file_metadata_deleted_at
+-- Description: Soft-delete marker on file metadata
+-- =============================================================================
+
+-- file_metadata is not created by these migrations; IF EXISTS keeps the
+-- chain runnable where the table is absent. Live rows have deleted_at NULL.
+ALTER TABLE IF EXISTS file_metadata ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
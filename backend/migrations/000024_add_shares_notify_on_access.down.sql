@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000024_add_shares_notify_on_access (ROLLBACK)
+-- Description: Drop the share access notification flag
+-- =============================================================================
+
+ALTER TABLE IF EXISTS shares DROP COLUMN IF EXISTS notify_on_access;
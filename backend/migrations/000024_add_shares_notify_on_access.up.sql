@@ -0,0 +1,8 @@
+-- =============================================================================
+-- Migration: 000024_add_shares_notify_on_access
+-- Description: Opt-in access notifications to the share creator
+-- =============================================================================
+
+-- shares is not created by these migrations; IF EXISTS keeps the chain
+-- runnable where the table is absent. Existing shares stay silent.
+ALTER TABLE IF EXISTS shares ADD COLUMN IF NOT EXISTS notify_on_access BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Migration: 000025_add_shares_dedupe_key (ROLLBACK)
+-- Description: Drop the share dedupe key and its index
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_shares_dedupe_key;
+ALTER TABLE IF EXISTS shares DROP COLUMN IF EXISTS dedupe_key;
@@ -0,0 +1,19 @@
+-- =============================================================================
+-- Migration: 000025_add_shares_dedupe_key
+-- Description: Idempotent share creation keyed per document and recipient
+-- =============================================================================
+
+-- shares is not created by these migrations; IF EXISTS keeps the chain
+-- runnable where the table is absent
+ALTER TABLE IF EXISTS shares ADD COLUMN IF NOT EXISTS dedupe_key VARCHAR(128);
+
+-- One share per key and scope. A key is moved off revoked or expired shares
+-- when a new share is created with it, so this never blocks a recreate.
+DO $$
+BEGIN
+    IF to_regclass('shares') IS NOT NULL THEN
+        CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_dedupe_key
+            ON shares(tenant_id, document_id, (COALESCE(shared_with, '')), dedupe_key)
+            WHERE dedupe_key IS NOT NULL;
+    END IF;
+END $$;
@@ -0,0 +1,11 @@
+-- =============================================================================
+-- Migration: 000026_add_user_roles_validity_window (ROLLBACK)
+-- Description: Drop the role assignment validity window
+-- =============================================================================
+
+DROP INDEX IF EXISTS idx_user_roles_expires_at;
+DROP INDEX IF EXISTS idx_user_roles_active_from;
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_validity_window_check;
+ALTER TABLE user_roles
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS active_from;
@@ -0,0 +1,23 @@
+-- =============================================================================
+-- Migration: 000026_add_user_roles_validity_window
+-- Description: Scheduled and expiring role assignments
+-- =============================================================================
+
+-- An assignment is in effect from active_from (inclusive) until expires_at
+-- (exclusive); NULL leaves that side open. Existing assignments stay in effect.
+ALTER TABLE user_roles
+    ADD COLUMN IF NOT EXISTS active_from TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE user_roles
+    ADD CONSTRAINT user_roles_validity_window_check
+    CHECK (active_from IS NULL OR expires_at IS NULL OR active_from < expires_at);
+
+-- Scheduled listings and cache TTL boundaries look up future times per user
+CREATE INDEX idx_user_roles_active_from ON user_roles(tenant_id, user_id, active_from)
+    WHERE active_from IS NOT NULL;
+CREATE INDEX idx_user_roles_expires_at ON user_roles(tenant_id, user_id, expires_at)
+    WHERE expires_at IS NOT NULL;
+
+COMMENT ON COLUMN user_roles.active_from IS 'Assignment is ignored before this time';
+COMMENT ON COLUMN user_roles.expires_at IS 'Assignment is ignored from this time on';
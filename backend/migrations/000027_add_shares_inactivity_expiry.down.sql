@@ -0,0 +1,8 @@
+-- =============================================================================
+-- Migration: 000027_add_shares_inactivity_expiry (ROLLBACK)
+-- Description: Drop share inactivity expiry
+-- =============================================================================
+
+ALTER TABLE IF EXISTS shares
+    DROP COLUMN IF EXISTS last_accessed_at,
+    DROP COLUMN IF EXISTS expire_after_inactivity;
@@ -0,0 +1,12 @@
+-- =============================================================================
+-- Migration: 000027_add_shares_inactivity_expiry
+-- Description: Expire shares after a window without access
+-- =============================================================================
+
+-- shares is not created by these migrations; IF EXISTS keeps the chain
+-- runnable where the table is absent. expire_after_inactivity is in seconds;
+-- NULL never expires for inactivity. A share never accessed is measured from
+-- created_at.
+ALTER TABLE IF EXISTS shares
+    ADD COLUMN IF NOT EXISTS expire_after_inactivity BIGINT,
+    ADD COLUMN IF NOT EXISTS last_accessed_at TIMESTAMPTZ;
@@ -0,0 +1,6 @@
+-- =============================================================================
+-- Migration: 000028_add_file_metadata_etag (ROLLBACK)
+-- Description: Drop the recorded object ETag
+-- =============================================================================
+
+ALTER TABLE IF EXISTS file_metadata DROP COLUMN IF EXISTS etag;
@@ -0,0 +1,9 @@
+-- =============================================================================
+-- Migration: 000028_add_file_metadata_etag
+-- Description: Object ETag recorded at upload for integrity checks
+-- =============================================================================
+
+-- file_metadata is not created by these migrations; IF EXISTS keeps the
+-- chain runnable where the table is absent. Files uploaded before this have
+-- etag NULL and are served without the check.
+ALTER TABLE IF EXISTS file_metadata ADD COLUMN IF NOT EXISTS etag VARCHAR(255);
@@ -0,0 +1,76 @@
+package bulk
+
+import (
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+// DefaultChunkSize is the number of items processed per chunk (and per
+// transaction) by chunked bulk operations
+const DefaultChunkSize = 100
+
+// Result is the outcome of a single item in a bulk operation
+type Result struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Response is the envelope shared by all bulk endpoints. T is Result or a
+// type embedding it.
+type Response[T any] struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Results   []T `json:"results"`
+
+	// Progress for chunked operations; Processed counts items handled before
+	// the operation finished or stopped
+	Processed int `json:"processed"`
+	Chunks    int `json:"chunks,omitempty"`
+}
+
+// NewResponse creates an empty bulk response sized for n items
+func NewResponse[T any](n int) *Response[T] {
+	return &Response[T]{
+		Results: make([]T, 0, n),
+	}
+}
+
+// Add records the outcome of one item
+func (r *Response[T]) Add(result T, success bool) {
+	if success {
+		r.Succeeded++
+	} else {
+		r.Failed++
+	}
+	r.Processed++
+	r.Results = append(r.Results, result)
+}
+
+// CheckSize returns a validation error when a batch is empty or exceeds max items
+func CheckSize(field string, n, max int) error {
+	if n == 0 {
+		return errors.Validationf("%s must contain at least one item", field).WithField(field, "required")
+	}
+	if n > max {
+		return errors.Validationf("%s contains %d items; the maximum per request is %d", field, n, max).
+			WithField(field, "too many items").
+			WithMeta("max_items", max)
+	}
+	return nil
+}
+
+// Chunks splits items into consecutive slices of at most size items
+func Chunks[T any](items []T, size int) [][]T {
+	if size < 1 {
+		size = DefaultChunkSize
+	}
+	chunks := make([][]T, 0, (len(items)+size-1)/size)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end])
+	}
+	return chunks
+}
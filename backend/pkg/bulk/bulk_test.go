@@ -0,0 +1,65 @@
+package bulk
+
+import (
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+func TestCheckSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		wantField string
+	}{
+		{"empty batch is rejected", 0, "required"},
+		{"batch at the limit is accepted", 500, ""},
+		{"over-limit batch is rejected", 501, "too many items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSize("document_ids", tt.n, 500)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("CheckSize(%d) = %v, want nil", tt.n, err)
+				}
+				return
+			}
+
+			appErr := errors.FromError(err)
+			if appErr == nil || appErr.Code != errors.ErrCodeValidation {
+				t.Fatalf("CheckSize(%d) = %v, want a validation error", tt.n, err)
+			}
+			if got := appErr.Fields["document_ids"]; got != tt.wantField {
+				t.Errorf("field error = %q, want %q", got, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestChunks(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name string
+		size int
+		want []int
+	}{
+		{"splits into full chunks and a remainder", 2, []int{2, 2, 1}},
+		{"one chunk when size covers all items", 10, []int{5}},
+		{"non-positive size uses the default", 0, []int{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Chunks(items, tt.size)
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+			for i, chunk := range chunks {
+				if len(chunk) != tt.want[i] {
+					t.Errorf("chunk %d has %d items, want %d", i, len(chunk), tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+// apiCallKeyTTL keeps a day's counter past midnight so its final count can
+// still be read
+const apiCallKeyTTL = 48 * time.Hour
+
+// APICallDay formats the UTC day of t as used in API call counter keys
+func APICallDay(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+// APICallKey builds the tenant's API call counter key for a day
+// (tenant:<id>:api_calls:<yyyymmdd>)
+func APICallKey(tenantID, day string) string {
+	return TenantKey(tenantID, "api_calls", day)
+}
+
+// apiCallTenantsKey is the set of tenants with API calls counted on a day
+func apiCallTenantsKey(day string) string {
+	return BuildKey("api_calls", day, "tenants")
+}
+
+// IncrementAPICalls atomically adds n to the tenant's API call count for
+// today and returns the new count
+func (c *Cache) IncrementAPICalls(ctx context.Context, tenantID string, n int64) (int64, error) {
+	day := APICallDay(time.Now())
+	key := APICallKey(tenantID, day)
+	tenantsKey := apiCallTenantsKey(day)
+
+	pipe := c.client.TxPipeline()
+	count := pipe.IncrBy(ctx, key, n)
+	pipe.Expire(ctx, key, apiCallKeyTTL)
+	pipe.SAdd(ctx, tenantsKey, tenantID)
+	pipe.Expire(ctx, tenantsKey, apiCallKeyTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errors.Wrap(errors.ErrCodeCache, "failed to count api call", err)
+	}
+	return count.Val(), nil
+}
+
+// APICalls returns the tenant's API call count for a day; it is zero when
+// nothing was counted
+func (c *Cache) APICalls(ctx context.Context, tenantID, day string) (int64, error) {
+	count, err := c.client.Get(ctx, APICallKey(tenantID, day)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrCodeCache, "failed to read api call count", err)
+	}
+	return count, nil
+}
+
+// APICallTenants lists the tenants with API calls counted on a day
+func (c *Cache) APICallTenants(ctx context.Context, day string) ([]string, error) {
+	return c.SMembers(ctx, apiCallTenantsKey(day))
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+// RateLimitKey caches a tenant's resolved requests-per-minute limit
+// (tenant:<id>:rate_limit). The quota service deletes it when the plan changes.
+func RateLimitKey(tenantID string) string {
+	return TenantKey(tenantID, "rate_limit")
+}
+
+// rateWindowKey is the tenant's request counter for one window
+// (tenant:<id>:rate:<window start, unix seconds>)
+func rateWindowKey(tenantID string, windowStart time.Time) string {
+	return TenantKey(tenantID, "rate", strconv.FormatInt(windowStart.Unix(), 10))
+}
+
+// RateLimitResolver wraps a limit loader so each tenant's limit is loaded at
+// most once per ttl across all services. load runs with the request context,
+// which carries the tenant.
+func (c *Cache) RateLimitResolver(load func(ctx context.Context) (int, error), ttl time.Duration) func(ctx context.Context, tenantID string) (int, error) {
+	return func(ctx context.Context, tenantID string) (int, error) {
+		key := RateLimitKey(tenantID)
+
+		var limit int
+		if err := c.Get(ctx, key, &limit); err == nil {
+			return limit, nil
+		}
+
+		limit, err := load(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		_ = c.Set(ctx, key, limit, ttl)
+
+		return limit, nil
+	}
+}
+
+// IncrementRateWindow counts a request against the tenant's current fixed
+// window and returns the window's count and when it resets
+func (c *Cache) IncrementRateWindow(ctx context.Context, tenantID string, window time.Duration) (int64, time.Time, error) {
+	start := time.Now().Truncate(window)
+	key := rateWindowKey(tenantID, start)
+
+	pipe := c.client.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, time.Time{}, errors.Wrap(errors.ErrCodeCache, "failed to count request", err)
+	}
+	return count.Val(), start.Add(window), nil
+}
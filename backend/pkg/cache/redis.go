@@ -4,18 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/startup"
 	"go.uber.org/zap"
 )
 
 // Cache wraps Redis client with helper methods
 type Cache struct {
-	client *redis.Client
-	logger *zap.Logger
+	client            *redis.Client
+	logger            *zap.Logger
+	enforceTenantKeys bool // see checkTenantKey
 }
 
 // NewRedisCache creates a new Redis cache client
@@ -36,6 +39,7 @@ func NewRedisCache(cfg config.RedisConfig, logger *zap.Logger) (*Cache, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
 		return nil, errors.Wrap(errors.ErrCodeCache, "failed to connect to Redis", err)
 	}
 
@@ -47,11 +51,34 @@ func NewRedisCache(cfg config.RedisConfig, logger *zap.Logger) (*Cache, error) {
 	}
 
 	return &Cache{
-		client: client,
-		logger: logger,
+		client:            client,
+		logger:            logger,
+		enforceTenantKeys: cfg.EnforceTenantKeys,
 	}, nil
 }
 
+// ConnectRedisCache connects to Redis and runs a health check, retrying both
+// with backoff so a service can start before Redis is ready
+func ConnectRedisCache(cfg config.RedisConfig, retry config.StartupConfig, logger *zap.Logger) (*Cache, error) {
+	var cache *Cache
+	err := startup.Retry(context.Background(), retry, logger, "redis", func(ctx context.Context) error {
+		conn, err := NewRedisCache(cfg, logger)
+		if err != nil {
+			return err
+		}
+		if err := conn.HealthCheck(ctx); err != nil {
+			_ = conn.client.Close()
+			return err
+		}
+		cache = conn
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
 // Close closes the Redis connection
 func (c *Cache) Close() error {
 	if c.logger != nil {
@@ -79,6 +106,10 @@ func (c *Cache) HealthCheck(ctx context.Context) error {
 
 // Set stores a value with TTL
 func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return err
+	}
+
 	data, err := json.Marshal(value)
 	if err != nil {
 		return errors.Wrap(errors.ErrCodeCache, "failed to marshal value", err)
@@ -134,12 +165,30 @@ func (c *Cache) GetString(ctx context.Context, key string) (string, error) {
 
 // SetString stores a string value
 func (c *Cache) SetString(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return err
+	}
+
 	if err := c.client.Set(ctx, key, value, ttl).Err(); err != nil {
 		return errors.Wrap(errors.ErrCodeCache, "failed to set string value", err)
 	}
 	return nil
 }
 
+// SetNX stores a string value only if the key does not exist and reports
+// whether it was stored
+func (c *Cache) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return false, err
+	}
+
+	ok, err := c.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, errors.Wrap(errors.ErrCodeCache, "failed to set value", err)
+	}
+	return ok, nil
+}
+
 // Delete removes a key
 func (c *Cache) Delete(ctx context.Context, keys ...string) error {
 	if err := c.client.Del(ctx, keys...).Err(); err != nil {
@@ -154,6 +203,43 @@ func (c *Cache) Delete(ctx context.Context, keys ...string) error {
 	return nil
 }
 
+// DeleteByPattern removes all keys matching a glob pattern. Keys are found
+// with SCAN so large keyspaces do not block Redis.
+func (c *Cache) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	iter := c.client.Scan(ctx, 0, pattern, 500).Iterator()
+	batch := make([]string, 0, 500)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := c.client.Del(ctx, batch...).Result()
+		if err != nil {
+			return err
+		}
+		deleted += n
+		batch = batch[:0]
+		return nil
+	}
+
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return deleted, errors.Wrap(errors.ErrCodeCache, "failed to delete cache keys", err)
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, errors.Wrap(errors.ErrCodeCache, "failed to scan cache keys", err)
+	}
+	if err := flush(); err != nil {
+		return deleted, errors.Wrap(errors.ErrCodeCache, "failed to delete cache keys", err)
+	}
+
+	return deleted, nil
+}
+
 // Exists checks if a key exists
 func (c *Cache) Exists(ctx context.Context, keys ...string) (bool, error) {
 	count, err := c.client.Exists(ctx, keys...).Result()
@@ -165,6 +251,10 @@ func (c *Cache) Exists(ctx context.Context, keys ...string) (bool, error) {
 
 // Expire sets a TTL on a key
 func (c *Cache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return err
+	}
+
 	if err := c.client.Expire(ctx, key, ttl).Err(); err != nil {
 		return errors.Wrap(errors.ErrCodeCache, "failed to set expiration", err)
 	}
@@ -182,6 +272,10 @@ func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
 
 // Incr increments a counter
 func (c *Cache) Incr(ctx context.Context, key string) (int64, error) {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return 0, err
+	}
+
 	val, err := c.client.Incr(ctx, key).Result()
 	if err != nil {
 		return 0, errors.Wrap(errors.ErrCodeCache, "failed to increment counter", err)
@@ -191,6 +285,10 @@ func (c *Cache) Incr(ctx context.Context, key string) (int64, error) {
 
 // Decr decrements a counter
 func (c *Cache) Decr(ctx context.Context, key string) (int64, error) {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return 0, err
+	}
+
 	val, err := c.client.Decr(ctx, key).Result()
 	if err != nil {
 		return 0, errors.Wrap(errors.ErrCodeCache, "failed to decrement counter", err)
@@ -200,6 +298,10 @@ func (c *Cache) Decr(ctx context.Context, key string) (int64, error) {
 
 // IncrBy increments a counter by a specific amount
 func (c *Cache) IncrBy(ctx context.Context, key string, value int64) (int64, error) {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return 0, err
+	}
+
 	val, err := c.client.IncrBy(ctx, key, value).Result()
 	if err != nil {
 		return 0, errors.Wrap(errors.ErrCodeCache, "failed to increment counter", err)
@@ -209,6 +311,10 @@ func (c *Cache) IncrBy(ctx context.Context, key string, value int64) (int64, err
 
 // HSet sets a hash field
 func (c *Cache) HSet(ctx context.Context, key, field string, value interface{}) error {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return err
+	}
+
 	data, err := json.Marshal(value)
 	if err != nil {
 		return errors.Wrap(errors.ErrCodeCache, "failed to marshal value", err)
@@ -255,6 +361,10 @@ func (c *Cache) HDel(ctx context.Context, key string, fields ...string) error {
 
 // SAdd adds members to a set
 func (c *Cache) SAdd(ctx context.Context, key string, members ...interface{}) error {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return err
+	}
+
 	if err := c.client.SAdd(ctx, key, members...).Err(); err != nil {
 		return errors.Wrap(errors.ErrCodeCache, "failed to add to set", err)
 	}
@@ -287,6 +397,42 @@ func (c *Cache) SIsMember(ctx context.Context, key string, member interface{}) (
 	return isMember, nil
 }
 
+// ZAdd adds a member to a sorted set with the given score
+func (c *Cache) ZAdd(ctx context.Context, key string, score float64, member string) error {
+	if err := c.checkTenantKey(ctx, key); err != nil {
+		return err
+	}
+
+	if err := c.client.ZAdd(ctx, key, redis.Z{Score: score, Member: member}).Err(); err != nil {
+		return errors.Wrap(errors.ErrCodeCache, "failed to add to sorted set", err)
+	}
+	return nil
+}
+
+// ZRangeByScore returns up to limit members of a sorted set scored at most
+// max, lowest score first
+func (c *Cache) ZRangeByScore(ctx context.Context, key string, max float64, limit int64) ([]string, error) {
+	members, err := c.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatFloat(max, 'f', -1, 64),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrCodeCache, "failed to get sorted set range", err)
+	}
+	return members, nil
+}
+
+// ZRem removes a member from a sorted set and reports whether it was
+// present, so concurrent callers can use it to claim the member
+func (c *Cache) ZRem(ctx context.Context, key, member string) (bool, error) {
+	removed, err := c.client.ZRem(ctx, key, member).Result()
+	if err != nil {
+		return false, errors.Wrap(errors.ErrCodeCache, "failed to remove from sorted set", err)
+	}
+	return removed > 0, nil
+}
+
 // FlushDB flushes the current database (use with caution!)
 func (c *Cache) FlushDB(ctx context.Context) error {
 	if err := c.client.FlushDB(ctx).Err(); err != nil {
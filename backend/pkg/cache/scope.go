@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+// globalPrefix marks keys deliberately shared across tenants (see GlobalKey)
+const globalPrefix = "global"
+
+// GlobalKey builds a key that is not owned by the request's tenant, such as
+// slug lookups or state that must outlive a tenant's own keys. Such keys pass
+// the tenant key check.
+func GlobalKey(parts ...string) string {
+	return BuildKey(append([]string{globalPrefix}, parts...)...)
+}
+
+// ScopedKey builds a key for the tenant in ctx and fails when ctx carries no
+// tenant, so a tenant-scoped operation can never fall back to a shared key
+func ScopedKey(ctx context.Context, parts ...string) (string, error) {
+	tenantID := middleware.GetTenantID(ctx)
+	if tenantID == "" {
+		return "", errors.New(errors.ErrCodeCache, "tenant-scoped cache key requires a tenant")
+	}
+	return TenantKey(tenantID, parts...), nil
+}
+
+// checkTenantKey rejects writes, when enabled (REDIS_ENFORCE_TENANT_KEYS),
+// of keys outside the tenant of the request in ctx. Requests without a
+// tenant and GlobalKey keys are not checked.
+func (c *Cache) checkTenantKey(ctx context.Context, key string) error {
+	if !c.enforceTenantKeys {
+		return nil
+	}
+	tenantID := middleware.GetTenantID(ctx)
+	if tenantID == "" {
+		return nil
+	}
+
+	prefix := TenantKey(tenantID)
+	if key == prefix || strings.HasPrefix(key, prefix+":") || strings.HasPrefix(key, globalPrefix+":") {
+		return nil
+	}
+
+	if c.logger != nil {
+		c.logger.Error("cache key outside request tenant",
+			zap.String("key", key),
+			zap.String("tenant_id", tenantID),
+			zap.Stack("stack"),
+		)
+	}
+	return errors.New(errors.ErrCodeCache, "cache key is not scoped to the request tenant").
+		WithMeta("key", key)
+}
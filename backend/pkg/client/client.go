@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"go.uber.org/zap"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Client performs HTTP calls to other internal services, forwarding the
+// caller's auth headers so the downstream service sees the same identity
+type Client struct {
+	name       string
+	baseURL    string
+	httpClient *http.Client
+	logger     *zap.Logger
+	secret     string // shared internal secret; requests are signed when set
+}
+
+// envelope mirrors response.Response for decoding downstream replies
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   *struct {
+		Code    errors.ErrorCode `json:"code"`
+		Message string           `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// New creates a new internal service client
+func New(name, baseURL string, logger *zap.Logger) *Client {
+	return &Client{
+		name:       name,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		logger:     logger,
+	}
+}
+
+// WithTimeout returns a copy of the client using the given request timeout
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	clone := *c
+	clone.httpClient = &http.Client{Timeout: timeout}
+	return &clone
+}
+
+// WithSigningSecret returns a copy of the client that signs every request with
+// the shared internal secret (see middleware.InternalAuth)
+func (c *Client) WithSigningSecret(secret string) *Client {
+	clone := *c
+	clone.secret = secret
+	return &clone
+}
+
+// Do sends a request to the downstream service and decodes the response data into out
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return errors.Internalf(err, "failed to encode %s request", c.name)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Internalf(err, "failed to build %s request", c.name)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setAuthHeaders(ctx, req)
+	if c.secret != "" {
+		middleware.SetInternalSignature(req, c.secret, payload)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Error("internal service call failed",
+			zap.String("service", c.name),
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.Error(err),
+		)
+		return errors.Wrap(errors.ErrCodeExternal, fmt.Sprintf("%s unavailable", c.name), err)
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return errors.Wrap(errors.ErrCodeExternal, fmt.Sprintf("invalid response from %s", c.name), err)
+	}
+
+	if !env.Success {
+		if env.Error != nil {
+			return errors.New(env.Error.Code, env.Error.Message)
+		}
+		return errors.New(errors.ErrCodeExternal, fmt.Sprintf("%s returned status %d", c.name, resp.StatusCode))
+	}
+
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return errors.Wrap(errors.ErrCodeExternal, fmt.Sprintf("invalid response from %s", c.name), err)
+		}
+	}
+
+	return nil
+}
+
+// setAuthHeaders copies the caller's identity onto an outgoing request
+func setAuthHeaders(ctx context.Context, req *http.Request) {
+	authCtx := middleware.GetAuthContext(ctx)
+	if authCtx.UserID != "" {
+		req.Header.Set(middleware.HeaderUserID, authCtx.UserID)
+	}
+	if authCtx.UserEmail != "" {
+		req.Header.Set(middleware.HeaderUserEmail, authCtx.UserEmail)
+	}
+	if authCtx.UserName != "" {
+		req.Header.Set(middleware.HeaderUserName, authCtx.UserName)
+	}
+	if authCtx.TenantID != "" {
+		req.Header.Set(middleware.HeaderTenantID, authCtx.TenantID)
+	}
+	if requestID := logger.GetRequestID(ctx); requestID != "" {
+		req.Header.Set(middleware.HeaderRequestID, requestID)
+	}
+}
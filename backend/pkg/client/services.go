@@ -0,0 +1,407 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+)
+
+// purgeResponse reports how many records a service removed for a tenant
+type purgeResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// purgeTenant asks a service to delete all data of the tenant in ctx
+func (c *Client) purgeTenant(ctx context.Context, path string) (int64, error) {
+	var resp purgeResponse
+	if err := c.Do(ctx, http.MethodDelete, path, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Deleted, nil
+}
+
+// TenantClient calls the tenant service
+type TenantClient struct {
+	*Client
+}
+
+// NewTenantClient creates a tenant service client
+func NewTenantClient(c *Client) *TenantClient {
+	return &TenantClient{Client: c}
+}
+
+// MembershipResponse represents a tenant membership lookup result
+type MembershipResponse struct {
+	IsMember bool   `json:"is_member"`
+	Role     string `json:"role,omitempty"`
+}
+
+// IsMember checks whether a user belongs to a tenant
+func (c *TenantClient) IsMember(ctx context.Context, tenantID, userID string) (bool, error) {
+	var resp MembershipResponse
+	path := fmt.Sprintf("/api/tenants/%s/users/%s/membership", tenantID, userID)
+	if err := c.Do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return false, err
+	}
+	return resp.IsMember, nil
+}
+
+// ResolveSlug maps a tenant slug to its ID (see middleware.ResolveTenant)
+func (c *TenantClient) ResolveSlug(ctx context.Context, slug string) (string, error) {
+	var resp struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/api/tenants/resolve?slug="+url.QueryEscape(slug), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.TenantID, nil
+}
+
+// RBACClient calls the rbac service
+type RBACClient struct {
+	*Client
+}
+
+// NewRBACClient creates an rbac service client
+func NewRBACClient(c *Client) *RBACClient {
+	return &RBACClient{Client: c}
+}
+
+// CheckPermission checks whether a user may perform an action on a resource
+func (c *RBACClient) CheckPermission(ctx context.Context, userID, resource, action string) (bool, error) {
+	req := map[string]string{
+		"user_id":  userID,
+		"resource": resource,
+		"action":   action,
+	}
+	var resp struct {
+		Allowed bool `json:"allowed"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/permissions/check", req, &resp); err != nil {
+		return false, err
+	}
+	return resp.Allowed, nil
+}
+
+// PurgeTenant deletes every role and assignment of the tenant in ctx
+func (c *RBACClient) PurgeTenant(ctx context.Context) (int64, error) {
+	return c.purgeTenant(ctx, "/api/roles/tenant-data")
+}
+
+// DocumentClient calls the document service
+type DocumentClient struct {
+	*Client
+}
+
+// NewDocumentClient creates a document service client
+func NewDocumentClient(c *Client) *DocumentClient {
+	return &DocumentClient{Client: c}
+}
+
+// ReassignDocuments transfers all documents uploaded by one user to another
+func (c *DocumentClient) ReassignDocuments(ctx context.Context, fromUserID, toUserID string) (int64, error) {
+	req := map[string]string{
+		"from_user_id": fromUserID,
+		"to_user_id":   toUserID,
+	}
+	var resp struct {
+		Succeeded int64 `json:"succeeded"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/documents/bulk/reassign", req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Succeeded, nil
+}
+
+// DocumentExists reports whether a document exists in the tenant in ctx
+func (c *DocumentClient) DocumentExists(ctx context.Context, documentID string) (bool, error) {
+	var result struct {
+		Exists bool `json:"exists"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/api/documents/"+url.PathEscape(documentID)+"/exists", nil, &result); err != nil {
+		return false, err
+	}
+	return result.Exists, nil
+}
+
+// RequireDocument rejects a document_id that does not reference a document of
+// the tenant in ctx with a validation error on that field
+func (c *DocumentClient) RequireDocument(ctx context.Context, documentID string) error {
+	exists, err := c.DocumentExists(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.Validationf("document not found").WithField("document_id", "does not reference an existing document")
+	}
+	return nil
+}
+
+// PurgeTenant deletes every document, folder, tag and category of the tenant in ctx
+func (c *DocumentClient) PurgeTenant(ctx context.Context) (int64, error) {
+	return c.purgeTenant(ctx, "/api/documents/tenant-data")
+}
+
+// StorageClient calls the storage service
+type StorageClient struct {
+	*Client
+}
+
+// NewStorageClient creates a storage service client
+func NewStorageClient(c *Client) *StorageClient {
+	return &StorageClient{Client: c}
+}
+
+// PurgeTenant deletes every stored object and file record of the tenant in ctx
+func (c *StorageClient) PurgeTenant(ctx context.Context) (int64, error) {
+	return c.purgeTenant(ctx, "/api/storage/tenant-data")
+}
+
+// RelocateFile moves a file's object under path (e.g. a document's new folder
+// path) in the tenant in ctx
+func (c *StorageClient) RelocateFile(ctx context.Context, fileID, path string) error {
+	req := map[string]string{"path": path}
+	return c.Do(ctx, http.MethodPost, "/api/files/"+url.PathEscape(fileID)+"/relocate", req, nil)
+}
+
+// CopiedObject describes an object created by StorageClient.CopyObject
+type CopiedObject struct {
+	ObjectKey string `json:"object_key"`
+	Size      int64  `json:"size"`
+	MimeType  string `json:"mime_type"`
+}
+
+// CopyObject server-side copies a stored object of the tenant in ctx to a new
+// key under documentID. It returns a not-found error when the source object
+// no longer exists.
+func (c *StorageClient) CopyObject(ctx context.Context, sourceKey, documentID string) (*CopiedObject, error) {
+	req := map[string]string{
+		"source_key":  sourceKey,
+		"document_id": documentID,
+	}
+	var copied CopiedObject
+	if err := c.Do(ctx, http.MethodPost, "/api/storage/objects/copy", req, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+// DocumentDownload is a short-lived download URL returned by
+// StorageClient.DocumentDownloadURL
+type DocumentDownload struct {
+	DownloadURL string `json:"download_url"`
+	FileName    string `json:"file_name"`
+	FileSize    int64  `json:"file_size"`
+	MimeType    string `json:"mime_type"`
+}
+
+// DocumentDownloadURL presigns a download of a document's file in the tenant
+// in ctx, valid for expiry
+func (c *StorageClient) DocumentDownloadURL(ctx context.Context, documentID string, expiry time.Duration) (*DocumentDownload, error) {
+	path := fmt.Sprintf("/api/storage/documents/%s/download?expiry=%d", url.PathEscape(documentID), int(expiry.Seconds()))
+	var download DocumentDownload
+	if err := c.Do(ctx, http.MethodGet, path, nil, &download); err != nil {
+		return nil, err
+	}
+	return &download, nil
+}
+
+// ShareClient calls the share service
+type ShareClient struct {
+	*Client
+}
+
+// NewShareClient creates a share service client
+func NewShareClient(c *Client) *ShareClient {
+	return &ShareClient{Client: c}
+}
+
+// PurgeTenant deletes every share and access log of the tenant in ctx
+func (c *ShareClient) PurgeTenant(ctx context.Context) (int64, error) {
+	return c.purgeTenant(ctx, "/api/shares/tenant-data")
+}
+
+// ReassignOwner reassigns (action "reassign", to toUserID) or revokes (action
+// "revoke") every share fromUserID created in the tenant in ctx
+func (c *ShareClient) ReassignOwner(ctx context.Context, fromUserID, toUserID, action string) (int64, error) {
+	req := map[string]string{
+		"from_user_id": fromUserID,
+		"to_user_id":   toUserID,
+		"action":       action,
+	}
+	var resp struct {
+		Succeeded int64 `json:"succeeded"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/shares/reassign-owner", req, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Succeeded, nil
+}
+
+// QuotaClient calls the quota service
+type QuotaClient struct {
+	*Client
+}
+
+// NewQuotaClient creates a quota service client
+func NewQuotaClient(c *Client) *QuotaClient {
+	return &QuotaClient{Client: c}
+}
+
+// HasFeature checks whether a feature is enabled on the current tenant's plan
+func (c *QuotaClient) HasFeature(ctx context.Context, feature string) (bool, error) {
+	req := map[string]string{"feature": feature}
+	var resp struct {
+		Allowed bool `json:"allowed"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/quotas/feature-check", req, &resp); err != nil {
+		return false, err
+	}
+	return resp.Allowed, nil
+}
+
+// CheckFeature returns a forbidden error when the feature is not on the tenant's plan
+func (c *QuotaClient) CheckFeature(ctx context.Context, feature string) error {
+	allowed, err := c.HasFeature(ctx, feature)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.Forbiddenf("feature '%s' is not available on your plan", feature).
+			WithMeta("feature", feature)
+	}
+	return nil
+}
+
+// RateLimit returns the requests-per-minute limit of the current tenant's
+// plan. It returns a not-found error when the tenant has no plan limit.
+func (c *QuotaClient) RateLimit(ctx context.Context) (int, error) {
+	var resp struct {
+		RequestsPerMinute int `json:"requests_per_minute"`
+	}
+	if err := c.Do(ctx, http.MethodGet, "/api/quotas/rate-limit", nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.RequestsPerMinute, nil
+}
+
+// IncrementUsage adds amount to the current tenant's usage of a resource
+func (c *QuotaClient) IncrementUsage(ctx context.Context, resource string, amount int64) error {
+	req := map[string]interface{}{
+		"resource": resource,
+		"amount":   amount,
+	}
+	return c.Do(ctx, http.MethodPost, "/api/quotas/usage/increment", req, nil)
+}
+
+// ReserveQuota checks every resource amount against the current tenant's
+// quota as one operation and, if all fit, adds them to usage. Nothing is
+// added when any resource would exceed its limit; that returns a forbidden
+// error listing the exceeded resources.
+func (c *QuotaClient) ReserveQuota(ctx context.Context, amounts map[string]int64) error {
+	type resourceAmount struct {
+		Resource string `json:"resource"`
+		Amount   int64  `json:"amount"`
+	}
+	resources := make([]resourceAmount, 0, len(amounts))
+	for resource, amount := range amounts {
+		resources = append(resources, resourceAmount{Resource: resource, Amount: amount})
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Resource < resources[j].Resource })
+
+	req := map[string]interface{}{
+		"resources": resources,
+		"reserve":   true,
+	}
+	var resp struct {
+		Allowed   bool `json:"allowed"`
+		Resources []struct {
+			Resource string `json:"resource"`
+			Allowed  bool   `json:"allowed"`
+		} `json:"resources"`
+	}
+	if err := c.Do(ctx, http.MethodPost, "/api/quotas/check-multi", req, &resp); err != nil {
+		return err
+	}
+	if !resp.Allowed {
+		var exceeded []string
+		for _, resource := range resp.Resources {
+			if !resource.Allowed {
+				exceeded = append(exceeded, resource.Resource)
+			}
+		}
+		return errors.Forbiddenf("quota exceeded for %s", strings.Join(exceeded, ", ")).
+			WithMeta("resources", exceeded)
+	}
+	return nil
+}
+
+// AdjustReservation settles an earlier reservation of reserved at the final
+// actual amount. Shrinking always succeeds; growing is checked against the
+// quota and returns a forbidden error when it does not fit.
+func (c *QuotaClient) AdjustReservation(ctx context.Context, resource string, reserved, actual int64) error {
+	req := map[string]interface{}{
+		"resource": resource,
+		"reserved": reserved,
+		"actual":   actual,
+	}
+	return c.Do(ctx, http.MethodPost, "/api/quotas/usage/adjust", req, nil)
+}
+
+// ProvisionQuota creates the default quota of plan for the tenant in ctx;
+// it is a no-op when the tenant already has a quota
+func (c *QuotaClient) ProvisionQuota(ctx context.Context, plan string) error {
+	req := map[string]string{"plan_name": plan}
+	return c.Do(ctx, http.MethodPost, "/api/quotas/provision", req, nil)
+}
+
+// PurgeTenant deletes the quota, usage and usage logs of the tenant in ctx
+func (c *QuotaClient) PurgeTenant(ctx context.Context) (int64, error) {
+	return c.purgeTenant(ctx, "/api/quotas/tenant-data")
+}
+
+// RequireFeature blocks requests from tenants whose plan lacks the feature
+func (c *QuotaClient) RequireFeature(feature string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := c.CheckFeature(r.Context(), feature); err != nil {
+				response.Error(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NotificationClient calls the notification service
+type NotificationClient struct {
+	*Client
+}
+
+// NewNotificationClient creates a notification service client
+func NewNotificationClient(c *Client) *NotificationClient {
+	return &NotificationClient{Client: c}
+}
+
+// Notification is a message to one user of the tenant in ctx
+type Notification struct {
+	UserID string            `json:"user_id"`
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+// Send hands a notification to the notification service for delivery. The
+// service receives it as a signed POST /api/notifications acting as the
+// caller, or as the sending service from background jobs.
+func (c *NotificationClient) Send(ctx context.Context, n *Notification) error {
+	return c.Do(ctx, http.MethodPost, "/api/notifications", n, nil)
+}
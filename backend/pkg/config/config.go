@@ -10,16 +10,28 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Environment string         `mapstructure:"ENVIRONMENT"`
-	AppName     string         `mapstructure:"APP_NAME"`
-	AppVersion  string         `mapstructure:"APP_VERSION"`
-	Server      ServerConfig   `mapstructure:",squash"`
-	Database    DatabaseConfig `mapstructure:",squash"`
-	Redis       RedisConfig    `mapstructure:",squash"`
-	MinIO       MinIOConfig    `mapstructure:",squash"`
-	Auth        AuthConfig     `mapstructure:",squash"`
-	Logger      LoggerConfig   `mapstructure:",squash"`
-	Services    ServicesConfig `mapstructure:",squash"`
+	Environment string            `mapstructure:"ENVIRONMENT"`
+	AppName     string            `mapstructure:"APP_NAME"`
+	AppVersion  string            `mapstructure:"APP_VERSION"`
+	Server      ServerConfig      `mapstructure:",squash"`
+	Database    DatabaseConfig    `mapstructure:",squash"`
+	Redis       RedisConfig       `mapstructure:",squash"`
+	MinIO       MinIOConfig       `mapstructure:",squash"`
+	Auth        AuthConfig        `mapstructure:",squash"`
+	Logger      LoggerConfig      `mapstructure:",squash"`
+	Services    ServicesConfig    `mapstructure:",squash"`
+	Health      HealthConfig      `mapstructure:",squash"`
+	DecisionLog DecisionLogConfig `mapstructure:",squash"`
+	Startup     StartupConfig     `mapstructure:",squash"`
+	Documents   DocumentsConfig   `mapstructure:",squash"`
+	Tenancy     TenancyConfig     `mapstructure:",squash"`
+	Shares      SharesConfig      `mapstructure:",squash"`
+	Worker      WorkerConfig      `mapstructure:",squash"`
+	RateLimit   RateLimitConfig   `mapstructure:",squash"`
+	Processing  ProcessingConfig  `mapstructure:",squash"`
+	Roles       RolesConfig       `mapstructure:",squash"`
+	Retention   RetentionConfig   `mapstructure:",squash"`
+	CORS        CORSConfig        `mapstructure:",squash"`
 }
 
 // ServerConfig holds HTTP server configuration
@@ -29,6 +41,10 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"SERVER_READ_TIMEOUT"`
 	WriteTimeout time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"`
 	IdleTimeout  time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT"`
+
+	StrictFieldSelection bool  `mapstructure:"SERVER_STRICT_FIELD_SELECTION"` // reject unknown names in ?fields= instead of ignoring them
+	StrictJSON           bool  `mapstructure:"SERVER_STRICT_JSON"`            // reject unknown request body fields instead of ignoring them
+	MaxBodySize          int64 `mapstructure:"SERVER_MAX_BODY_SIZE"`          // bytes; multipart uploads use their own limits
 }
 
 // DatabaseConfig holds PostgreSQL configuration
@@ -52,6 +68,8 @@ type RedisConfig struct {
 	DB         int    `mapstructure:"REDIS_DB"`
 	MaxRetries int    `mapstructure:"REDIS_MAX_RETRIES"`
 	PoolSize   int    `mapstructure:"REDIS_POOL_SIZE"`
+
+	EnforceTenantKeys bool `mapstructure:"REDIS_ENFORCE_TENANT_KEYS"` // reject writes outside the request tenant's keys; for development and tests
 }
 
 // MinIOConfig holds MinIO configuration
@@ -62,6 +80,7 @@ type MinIOConfig struct {
 	UseSSL          bool   `mapstructure:"MINIO_USE_SSL"`
 	BucketName      string `mapstructure:"MINIO_BUCKET_NAME"`
 	Region          string `mapstructure:"MINIO_REGION"`
+	PathStyle       bool   `mapstructure:"MINIO_PATH_STYLE"` // force path-style bucket addressing; otherwise chosen per endpoint
 }
 
 // AuthConfig holds authentication configuration
@@ -76,12 +95,17 @@ type AuthConfig struct {
 	JWTAudience      string `mapstructure:"JWT_AUDIENCE"`
 	HydraJWKSURL     string `mapstructure:"HYDRA_JWKS_URL"`
 	InternalAPISecret string `mapstructure:"INTERNAL_API_SECRET"`
+
+	InternalMaxSkew time.Duration `mapstructure:"INTERNAL_AUTH_MAX_SKEW"` // allowed clock difference for signed internal requests
 }
 
 // LoggerConfig holds logging configuration
 type LoggerConfig struct {
-	Level  string `mapstructure:"LOG_LEVEL"`
-	Format string `mapstructure:"LOG_FORMAT"`
+	Level                string        `mapstructure:"LOG_LEVEL"`
+	Format               string        `mapstructure:"LOG_FORMAT"`
+	SlowRequestThreshold time.Duration `mapstructure:"LOG_SLOW_REQUEST_THRESHOLD"` // 0 disables slow-request warnings
+	SamplingInitial      int           `mapstructure:"LOG_SAMPLING_INITIAL"`       // info/debug entries logged per second before sampling
+	SamplingThereafter   int           `mapstructure:"LOG_SAMPLING_THEREAFTER"`    // then every Nth entry; 0 disables sampling
 }
 
 // ServicesConfig holds microservice URLs
@@ -99,6 +123,105 @@ type ServicesConfig struct {
 	AuditServiceURL         string `mapstructure:"AUDIT_SERVICE_URL"`
 }
 
+// HealthConfig holds readiness dependency configuration. Each service sets its
+// own lists so that only true hard dependencies can fail its readiness probe.
+type HealthConfig struct {
+	CriticalDependencies []string      `mapstructure:"READY_CRITICAL_DEPENDENCIES"` // name=url entries; down means not ready
+	OptionalDependencies []string      `mapstructure:"READY_OPTIONAL_DEPENDENCIES"` // name=url entries; down means degraded
+	DependencyTimeout    time.Duration `mapstructure:"READY_DEPENDENCY_TIMEOUT"`
+}
+
+// DecisionLogConfig holds authorization decision logging configuration (rbac-service)
+type DecisionLogConfig struct {
+	Enabled    bool    `mapstructure:"DECISION_LOG_ENABLED"`
+	SampleRate float64 `mapstructure:"DECISION_LOG_SAMPLE_RATE"` // fraction of allow decisions recorded; denies are always recorded
+	BufferSize int     `mapstructure:"DECISION_LOG_BUFFER_SIZE"` // pending decisions before new ones are dropped
+}
+
+// StartupConfig holds dependency connection retry settings used at boot
+type StartupConfig struct {
+	ConnectMaxAttempts int           `mapstructure:"STARTUP_CONNECT_MAX_ATTEMPTS"` // attempts per dependency before giving up
+	ConnectInterval    time.Duration `mapstructure:"STARTUP_CONNECT_INTERVAL"`     // wait after the first failure; doubles each retry
+	ConnectMaxInterval time.Duration `mapstructure:"STARTUP_CONNECT_MAX_INTERVAL"` // upper bound for the wait between attempts
+}
+
+// DocumentsConfig holds document-service settings
+type DocumentsConfig struct {
+	MaxFolderDepth   int `mapstructure:"DOCUMENTS_MAX_FOLDER_DEPTH"`    // deepest allowed folder level; root folders are level 1
+	MaxPinsPerFolder int `mapstructure:"DOCUMENTS_MAX_PINS_PER_FOLDER"` // pinned documents per folder (tenant-wide); the root counts as one folder
+}
+
+// TenancyConfig holds per-request tenant resolution settings. With the
+// default "header" source the gateway-injected X-Tenant-ID is used as is.
+type TenancyConfig struct {
+	ResolutionSource string        `mapstructure:"TENANT_RESOLUTION_SOURCE"`    // header, slug (X-Tenant-Slug), host (subdomain of TENANT_BASE_DOMAIN) or path (/t/{slug}/...)
+	BaseDomain       string        `mapstructure:"TENANT_BASE_DOMAIN"`          // e.g. app.example.com, so acme.app.example.com resolves "acme"
+	CacheTTL         time.Duration `mapstructure:"TENANT_RESOLUTION_CACHE_TTL"` // in-process slug to ID cache; 0 disables it
+}
+
+// SharesConfig holds share-service expiry defaults; tenant settings can
+// override both. Zero disables the default or the maximum.
+type SharesConfig struct {
+	DefaultExpiry time.Duration `mapstructure:"SHARES_DEFAULT_EXPIRY"` // applied when a share is created without expires_at
+	MaxExpiry     time.Duration `mapstructure:"SHARES_MAX_EXPIRY"`     // furthest allowed expires_at, measured from now
+
+	AccessNotifyIncludeIP bool `mapstructure:"SHARES_ACCESS_NOTIFY_INCLUDE_IP"` // put the visitor's IP in notify_on_access notifications
+	EnforceBandwidthQuota bool `mapstructure:"SHARES_ENFORCE_BANDWIDTH_QUOTA"`  // refuse share downloads once the owner's monthly bandwidth is used up
+}
+
+// WorkerConfig holds the in-process background job queue settings (pkg/worker)
+type WorkerConfig struct {
+	Concurrency     int           `mapstructure:"WORKER_CONCURRENCY"`      // jobs run at the same time
+	QueueSize       int           `mapstructure:"WORKER_QUEUE_SIZE"`       // pending jobs before Submit is rejected
+	MaxAttempts     int           `mapstructure:"WORKER_MAX_ATTEMPTS"`     // attempts per job before it is dead-lettered
+	InitialBackoff  time.Duration `mapstructure:"WORKER_INITIAL_BACKOFF"`  // wait after the first failure; doubles each retry
+	MaxBackoff      time.Duration `mapstructure:"WORKER_MAX_BACKOFF"`      // upper bound for the wait between attempts
+	ShutdownTimeout time.Duration `mapstructure:"WORKER_SHUTDOWN_TIMEOUT"` // time allowed to drain queued jobs on shutdown
+}
+
+// RateLimitConfig holds per-tenant request rate limiting. Each tenant's limit
+// comes from its quota plan; RequestsPerMinute applies when it cannot be resolved.
+type RateLimitConfig struct {
+	Enabled           bool          `mapstructure:"RATE_LIMIT_ENABLED"`
+	RequestsPerMinute int           `mapstructure:"RATE_LIMIT_REQUESTS_PER_MINUTE"` // fallback limit; 0 disables the fallback
+	CacheTTL          time.Duration `mapstructure:"RATE_LIMIT_CACHE_TTL"`           // how long a resolved plan limit is reused; plan changes clear it
+}
+
+// ProcessingConfig bounds storage-service file processing (thumbnails).
+// Decoding cost grows with pixel count, so files over either limit are
+// skipped and marked on their metadata instead of being decoded.
+type ProcessingConfig struct {
+	Concurrency int           `mapstructure:"PROCESSING_CONCURRENCY"`   // storage-service worker pool size; overrides WORKER_CONCURRENCY there
+	MaxFileSize int64         `mapstructure:"PROCESSING_MAX_FILE_SIZE"` // bytes
+	MaxPixels   int64         `mapstructure:"PROCESSING_MAX_PIXELS"`    // width x height, read from the image header before decoding
+	Timeout     time.Duration `mapstructure:"PROCESSING_TIMEOUT"`       // per file processed
+}
+
+// RolesConfig holds rbac-service role defaults
+type RolesConfig struct {
+	DefaultPermissions []string `mapstructure:"RBAC_DEFAULT_ROLE_PERMISSIONS"` // resource:action entries a role created with apply_defaults gets
+}
+
+// RetentionConfig holds how long audit rows are kept (pkg/retention). Tenant
+// settings can extend or shorten each window in days; zero keeps rows forever
+// unless a tenant sets a window.
+type RetentionConfig struct {
+	UsageLogs           time.Duration `mapstructure:"RETENTION_USAGE_LOGS"`           // quota-service usage_logs
+	ShareAccess         time.Duration `mapstructure:"RETENTION_SHARE_ACCESS"`         // share-service share_access
+	PermissionDecisions time.Duration `mapstructure:"RETENTION_PERMISSION_DECISIONS"` // rbac-service permission_decisions
+	PurgeInterval       time.Duration `mapstructure:"RETENTION_PURGE_INTERVAL"`       // time between scheduled purges; 0 disables them
+	BatchSize           int           `mapstructure:"RETENTION_PURGE_BATCH_SIZE"`     // rows deleted per statement
+}
+
+// CORSConfig holds the origins browsers may call the API from
+// (middleware.CORS). Credentials are only allowed for origins listed in
+// CredentialedOrigins, never for "*".
+type CORSConfig struct {
+	AllowedOrigins      []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`      // exact origins, or "*" for any origin without credentials
+	CredentialedOrigins []string      `mapstructure:"CORS_CREDENTIALED_ORIGINS"` // exact origins allowed with cookies and Authorization
+	MaxAge              time.Duration `mapstructure:"CORS_MAX_AGE"`              // preflight cache lifetime; 0 omits Access-Control-Max-Age
+}
+
 // GetDSN returns the PostgreSQL connection string
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf(
@@ -112,6 +235,18 @@ func (c *RedisConfig) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// UseDefaultPort sets the service's default port unless SERVER_PORT was set,
+// and validates the resulting port
+func (c *ServerConfig) UseDefaultPort(defaultPort int) error {
+	if c.Port == 0 {
+		c.Port = defaultPort
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("SERVER_PORT must be between 1 and 65535, got %d", c.Port)
+	}
+	return nil
+}
+
 // GetServerAddr returns the server address
 func (c *ServerConfig) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
@@ -137,6 +272,7 @@ func Load() (*Config, error) {
 	// Environment variables
 	v.AutomaticEnv()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	bindEnv(v)
 
 	// Unmarshal into Config struct
 	var cfg Config
@@ -167,6 +303,7 @@ func LoadFromFile(configPath string) (*Config, error) {
 	// Environment variables override file config
 	v.AutomaticEnv()
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	bindEnv(v)
 
 	// Set defaults
 	setDefaults(v)
@@ -194,10 +331,13 @@ func setDefaults(v *viper.Viper) {
 
 	// Server
 	v.SetDefault("SERVER_HOST", "0.0.0.0")
-	v.SetDefault("SERVER_PORT", 8080)
+	// SERVER_PORT has no default here; each service applies its own via UseDefaultPort (see bindEnv)
 	v.SetDefault("SERVER_READ_TIMEOUT", 30*time.Second)
 	v.SetDefault("SERVER_WRITE_TIMEOUT", 30*time.Second)
 	v.SetDefault("SERVER_IDLE_TIMEOUT", 120*time.Second)
+	v.SetDefault("SERVER_STRICT_FIELD_SELECTION", false)
+	v.SetDefault("SERVER_STRICT_JSON", false)
+	v.SetDefault("SERVER_MAX_BODY_SIZE", 1<<20) // 1 MB
 
 	// Database
 	v.SetDefault("DB_HOST", "localhost")
@@ -215,20 +355,126 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("REDIS_DB", 0)
 	v.SetDefault("REDIS_MAX_RETRIES", 3)
 	v.SetDefault("REDIS_POOL_SIZE", 10)
+	v.SetDefault("REDIS_ENFORCE_TENANT_KEYS", false)
 
 	// MinIO
 	v.SetDefault("MINIO_ENDPOINT", "localhost:19000")
 	v.SetDefault("MINIO_USE_SSL", false)
 	v.SetDefault("MINIO_BUCKET_NAME", "documents")
 	v.SetDefault("MINIO_REGION", "us-east-1")
+	v.SetDefault("MINIO_PATH_STYLE", false)
 
 	// Logger
 	v.SetDefault("LOG_LEVEL", "info")
 	v.SetDefault("LOG_FORMAT", "json")
+	v.SetDefault("LOG_SLOW_REQUEST_THRESHOLD", 1*time.Second)
+	v.SetDefault("LOG_SAMPLING_INITIAL", 100)
+	v.SetDefault("LOG_SAMPLING_THEREAFTER", 100)
+
+	// Internal services
+	v.SetDefault("TENANT_SERVICE_URL", "http://localhost:10001")
+	v.SetDefault("DOCUMENT_SERVICE_URL", "http://localhost:10002")
+	v.SetDefault("STORAGE_SERVICE_URL", "http://localhost:10003")
+	v.SetDefault("SHARE_SERVICE_URL", "http://localhost:10004")
+	v.SetDefault("RBAC_SERVICE_URL", "http://localhost:10005")
+	v.SetDefault("QUOTA_SERVICE_URL", "http://localhost:10006")
+
+	// Readiness
+	v.SetDefault("READY_CRITICAL_DEPENDENCIES", []string{})
+	v.SetDefault("READY_OPTIONAL_DEPENDENCIES", []string{})
+	v.SetDefault("READY_DEPENDENCY_TIMEOUT", 2*time.Second)
+
+	// Decision log
+	v.SetDefault("DECISION_LOG_ENABLED", false)
+	v.SetDefault("DECISION_LOG_SAMPLE_RATE", 1.0)
+	v.SetDefault("DECISION_LOG_BUFFER_SIZE", 1000)
+
+	// Startup
+	v.SetDefault("STARTUP_CONNECT_MAX_ATTEMPTS", 10)
+	v.SetDefault("STARTUP_CONNECT_INTERVAL", 1*time.Second)
+	v.SetDefault("STARTUP_CONNECT_MAX_INTERVAL", 15*time.Second)
+
+	// Documents
+	v.SetDefault("DOCUMENTS_MAX_FOLDER_DEPTH", 32)
+	v.SetDefault("DOCUMENTS_MAX_PINS_PER_FOLDER", 10)
+
+	// Tenancy
+	v.SetDefault("TENANT_RESOLUTION_SOURCE", "header")
+	v.SetDefault("TENANT_RESOLUTION_CACHE_TTL", 1*time.Minute)
+
+	// Shares
+	v.SetDefault("SHARES_DEFAULT_EXPIRY", 30*24*time.Hour)
+	v.SetDefault("SHARES_MAX_EXPIRY", 365*24*time.Hour)
+	v.SetDefault("SHARES_ACCESS_NOTIFY_INCLUDE_IP", false)
+	v.SetDefault("SHARES_ENFORCE_BANDWIDTH_QUOTA", true)
+
+	// Worker
+	v.SetDefault("WORKER_CONCURRENCY", 4)
+	v.SetDefault("WORKER_QUEUE_SIZE", 1000)
+	v.SetDefault("WORKER_MAX_ATTEMPTS", 5)
+	v.SetDefault("WORKER_INITIAL_BACKOFF", 1*time.Second)
+	v.SetDefault("WORKER_MAX_BACKOFF", 1*time.Minute)
+	v.SetDefault("WORKER_SHUTDOWN_TIMEOUT", 20*time.Second)
+
+	// Processing
+	v.SetDefault("PROCESSING_CONCURRENCY", 2)
+	v.SetDefault("PROCESSING_MAX_FILE_SIZE", 50*1024*1024) // 50MB
+	v.SetDefault("PROCESSING_MAX_PIXELS", 50_000_000)      // 50 megapixels, ~200MB decoded
+	v.SetDefault("PROCESSING_TIMEOUT", 1*time.Minute)
+
+	// Roles
+	v.SetDefault("RBAC_DEFAULT_ROLE_PERMISSIONS", []string{"document:read", "folder:read"})
+
+	// Retention
+	v.SetDefault("RETENTION_USAGE_LOGS", 365*24*time.Hour)
+	v.SetDefault("RETENTION_SHARE_ACCESS", 365*24*time.Hour)
+	v.SetDefault("RETENTION_PERMISSION_DECISIONS", 90*24*time.Hour)
+	v.SetDefault("RETENTION_PURGE_INTERVAL", 24*time.Hour)
+	v.SetDefault("RETENTION_PURGE_BATCH_SIZE", 1000)
+
+	// CORS
+	v.SetDefault("CORS_ALLOWED_ORIGINS", []string{})
+	v.SetDefault("CORS_CREDENTIALED_ORIGINS", []string{})
+	v.SetDefault("CORS_MAX_AGE", 1*time.Hour)
+
+	// Rate limiting
+	v.SetDefault("RATE_LIMIT_ENABLED", true)
+	v.SetDefault("RATE_LIMIT_REQUESTS_PER_MINUTE", 120)
+	v.SetDefault("RATE_LIMIT_CACHE_TTL", 5*time.Minute)
 
 	// Auth
 	v.SetDefault("JWT_ISSUER", "http://shared-hydra:14444")
 	v.SetDefault("JWT_AUDIENCE", "document-manager-client")
+	v.SetDefault("INTERNAL_AUTH_MAX_SKEW", 5*time.Minute)
+}
+
+// bindEnv binds the keys that have no default. Unmarshal only reads keys
+// viper knows about, so without a default or binding AutomaticEnv would
+// ignore their environment variables.
+func bindEnv(v *viper.Viper) {
+	for _, key := range []string{
+		"SERVER_PORT",
+		"DB_PASSWORD",
+		"REDIS_PASSWORD",
+		"MINIO_ACCESS_KEY_ID",
+		"MINIO_SECRET_ACCESS_KEY",
+		"INTERNAL_API_SECRET",
+		"HYDRA_JWKS_URL",
+		"OAUTH2_CLIENT_ID",
+		"OAUTH2_CLIENT_SECRET",
+		"SHARED_HYDRA_ADMIN_URL",
+		"SHARED_HYDRA_PUBLIC_URL",
+		"SHARED_KRATOS_ADMIN_URL",
+		"SHARED_KRATOS_PUBLIC_URL",
+		"AUDIT_SERVICE_URL",
+		"CATEGORIZATION_SERVICE_URL",
+		"NOTIFICATION_SERVICE_URL",
+		"OCR_SERVICE_URL",
+		"SEARCH_SERVICE_URL",
+		"TENANT_BASE_DOMAIN",
+	} {
+		_ = v.BindEnv(key)
+	}
 }
 
 // validate validates the configuration
@@ -272,5 +518,69 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("LOG_LEVEL must be one of: %v", validLevels)
 	}
 
+	if cfg.DecisionLog.SampleRate < 0 || cfg.DecisionLog.SampleRate > 1 {
+		return fmt.Errorf("DECISION_LOG_SAMPLE_RATE must be between 0 and 1")
+	}
+
+	if cfg.Auth.InternalMaxSkew <= 0 {
+		return fmt.Errorf("INTERNAL_AUTH_MAX_SKEW must be positive")
+	}
+
+	if cfg.Server.Port < 0 || cfg.Server.Port > 65535 {
+		return fmt.Errorf("SERVER_PORT must be between 1 and 65535")
+	}
+
+	if cfg.Startup.ConnectMaxAttempts < 1 {
+		return fmt.Errorf("STARTUP_CONNECT_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if cfg.Worker.Concurrency < 1 {
+		return fmt.Errorf("WORKER_CONCURRENCY must be at least 1")
+	}
+
+	if cfg.Worker.MaxAttempts < 1 {
+		return fmt.Errorf("WORKER_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if cfg.Processing.Concurrency < 1 {
+		return fmt.Errorf("PROCESSING_CONCURRENCY must be at least 1")
+	}
+
+	if cfg.Processing.MaxFileSize < 1 || cfg.Processing.MaxPixels < 1 {
+		return fmt.Errorf("PROCESSING_MAX_FILE_SIZE and PROCESSING_MAX_PIXELS must be positive")
+	}
+
+	for _, entry := range cfg.Roles.DefaultPermissions {
+		if resource, action, ok := strings.Cut(entry, ":"); !ok || resource == "" || action == "" {
+			return fmt.Errorf("RBAC_DEFAULT_ROLE_PERMISSIONS entries must be resource:action, got %q", entry)
+		}
+	}
+
+	if cfg.RateLimit.RequestsPerMinute < 0 {
+		return fmt.Errorf("RATE_LIMIT_REQUESTS_PER_MINUTE must not be negative")
+	}
+
+	if cfg.Retention.UsageLogs < 0 || cfg.Retention.ShareAccess < 0 || cfg.Retention.PermissionDecisions < 0 || cfg.Retention.PurgeInterval < 0 {
+		return fmt.Errorf("RETENTION_* durations must not be negative")
+	}
+
+	if cfg.Retention.BatchSize < 1 {
+		return fmt.Errorf("RETENTION_PURGE_BATCH_SIZE must be at least 1")
+	}
+
+	if cfg.Documents.MaxPinsPerFolder < 0 {
+		return fmt.Errorf("DOCUMENTS_MAX_PINS_PER_FOLDER must not be negative")
+	}
+
+	if cfg.CORS.MaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+
+	for _, origin := range cfg.CORS.CredentialedOrigins {
+		if origin == "*" {
+			return fmt.Errorf("CORS_CREDENTIALED_ORIGINS must list exact origins, not *")
+		}
+	}
+
 	return nil
 }
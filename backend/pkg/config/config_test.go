@@ -0,0 +1,52 @@
+package config
+
+import "testing"
+
+// setRequiredEnv sets the variables validate insists on
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DB_PASSWORD", "db-secret")
+	t.Setenv("REDIS_PASSWORD", "redis-secret")
+	t.Setenv("INTERNAL_API_SECRET", "internal-secret")
+}
+
+func TestLoadServerPortFromEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SERVER_PORT", "12345")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := cfg.Server.UseDefaultPort(10002); err != nil {
+		t.Fatalf("UseDefaultPort: %v", err)
+	}
+	if cfg.Server.Port != 12345 {
+		t.Errorf("Port = %d, want 12345 from SERVER_PORT", cfg.Server.Port)
+	}
+}
+
+func TestLoadServerPortUnset(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != 0 {
+		t.Fatalf("Port = %d before UseDefaultPort, want 0", cfg.Server.Port)
+	}
+	if err := cfg.Server.UseDefaultPort(10002); err != nil {
+		t.Fatalf("UseDefaultPort: %v", err)
+	}
+	if cfg.Server.Port != 10002 {
+		t.Errorf("Port = %d, want the service default 10002", cfg.Server.Port)
+	}
+}
+
+func TestUseDefaultPortRejectsOutOfRange(t *testing.T) {
+	cfg := &ServerConfig{Port: 70000}
+	if err := cfg.UseDefaultPort(10002); err == nil {
+		t.Error("UseDefaultPort accepted port 70000")
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+)
+
+// redactedValue replaces a secret that is set; unset secrets stay empty so a
+// missing value is still visible
+const redactedValue = "[REDACTED]"
+
+// secretSettings are never logged or served in clear
+var secretSettings = map[string]bool{
+	"DB_PASSWORD":             true,
+	"REDIS_PASSWORD":          true,
+	"MINIO_SECRET_ACCESS_KEY": true,
+	"OAUTH2_CLIENT_SECRET":    true,
+	"INTERNAL_API_SECRET":     true,
+}
+
+// Redacted returns the effective settings keyed by environment variable, with
+// secrets masked and durations in Go syntax (e.g. "5m0s"), for logging at
+// startup and for GET /api/config
+func (c *Config) Redacted() map[string]interface{} {
+	settings := make(map[string]interface{})
+	collectSettings(reflect.ValueOf(*c), settings)
+	return settings
+}
+
+// collectSettings walks the mapstructure tags of v, descending into squashed
+// structs
+func collectSettings(v reflect.Value, settings map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		value := v.Field(i)
+
+		if name == "" {
+			if value.Kind() == reflect.Struct {
+				collectSettings(value, settings)
+			}
+			continue
+		}
+
+		switch {
+		case secretSettings[name]:
+			if value.String() != "" {
+				settings[name] = redactedValue
+			} else {
+				settings[name] = ""
+			}
+		case value.Type() == reflect.TypeOf(time.Duration(0)):
+			settings[name] = time.Duration(value.Int()).String()
+		default:
+			settings[name] = value.Interface()
+		}
+	}
+}
+
+// Handler serves a service's redacted configuration
+type Handler struct {
+	cfg *Config
+}
+
+// NewHandler creates the configuration endpoint for cfg
+func NewHandler(cfg *Config) *Handler {
+	return &Handler{cfg: cfg}
+}
+
+// Get handles GET /api/config
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	response.Success(w, h.cfg.Redacted())
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// secrets are the clear values the redaction tests must never see again
+var secrets = []string{"db-secret", "redis-secret", "minio-secret", "oauth-secret", "internal-secret"}
+
+func secretConfig() *Config {
+	cfg := &Config{}
+	cfg.Database.Password = "db-secret"
+	cfg.Redis.Password = "redis-secret"
+	cfg.MinIO.SecretAccessKey = "minio-secret"
+	cfg.Auth.OAuth2ClientSecret = "oauth-secret"
+	cfg.Auth.InternalAPISecret = "internal-secret"
+	cfg.Database.User = "docs"
+	cfg.Server.Port = 10002
+	cfg.Server.ReadTimeout = 15 * time.Second
+	return cfg
+}
+
+func TestRedacted(t *testing.T) {
+	settings := secretConfig().Redacted()
+
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"DB_PASSWORD", redactedValue},
+		{"REDIS_PASSWORD", redactedValue},
+		{"MINIO_SECRET_ACCESS_KEY", redactedValue},
+		{"OAUTH2_CLIENT_SECRET", redactedValue},
+		{"INTERNAL_API_SECRET", redactedValue},
+		{"DB_USER", "docs"},
+		{"SERVER_PORT", 10002},
+		{"SERVER_READ_TIMEOUT", "15s"},
+		{"SERVER_WRITE_TIMEOUT", "0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := settings[tt.key]
+			if !ok {
+				t.Fatalf("Redacted() has no %s", tt.key)
+			}
+			if got != tt.want {
+				t.Errorf("Redacted()[%s] = %#v, want %#v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactedUnsetSecretsStayEmpty(t *testing.T) {
+	settings := (&Config{}).Redacted()
+	for name := range secretSettings {
+		if got := settings[name]; got != "" {
+			t.Errorf("Redacted()[%s] = %#v for an unset secret, want empty", name, got)
+		}
+	}
+}
+
+// Every setting that looks like a credential must be listed in secretSettings
+func TestSecretSettingsCoverCredentials(t *testing.T) {
+	for name := range (&Config{}).Redacted() {
+		for _, marker := range []string{"PASSWORD", "SECRET"} {
+			if strings.Contains(name, marker) && !secretSettings[name] {
+				t.Errorf("%s looks like a credential but is not redacted", name)
+			}
+		}
+	}
+}
+
+func TestRedactedOutputsContainNoSecrets(t *testing.T) {
+	cfg := secretConfig()
+
+	// Startup log line, as every service writes it
+	var logged bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&logged), zapcore.InfoLevel)
+	zap.New(core).Info("effective configuration", zap.Any("config", cfg.Redacted()))
+
+	// GET /api/config
+	rec := httptest.NewRecorder()
+	NewHandler(cfg).Get(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/config status = %d, want 200", rec.Code)
+	}
+	var served struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatalf("decode GET /api/config: %v", err)
+	}
+	if served.Data["INTERNAL_API_SECRET"] != redactedValue {
+		t.Errorf("served INTERNAL_API_SECRET = %#v, want %q", served.Data["INTERNAL_API_SECRET"], redactedValue)
+	}
+
+	outputs := map[string]string{"startup log": logged.String(), "GET /api/config": rec.Body.String()}
+	for output, text := range outputs {
+		for _, secret := range secrets {
+			if strings.Contains(text, secret) {
+				t.Errorf("%s contains the secret %q", output, secret)
+			}
+		}
+	}
+
+	// Redacting must not touch the configuration itself
+	if !reflect.DeepEqual(cfg, secretConfig()) {
+		t.Error("Redacted() modified the configuration")
+	}
+}
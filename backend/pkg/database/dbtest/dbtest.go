@@ -0,0 +1,292 @@
+// Package dbtest provides a scripted database/sql driver for repository
+// tests. Each statement a test expects is matched in order against a
+// fragment of its SQL and answered with the scripted rows or result, so a
+// repository's queries, arguments and transaction boundaries can be checked
+// without a database.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+)
+
+const driverName = "dbtest"
+
+var (
+	registerOnce sync.Once
+	mocks        sync.Map // dsn -> *Mock
+	nextDSN      atomic.Int64
+)
+
+// Any matches any argument value in WithArgs
+var Any = anyArg{}
+
+type anyArg struct{}
+
+// Mock is the script of one test's database. Expectations are consumed in
+// the order they were added.
+type Mock struct {
+	t            testing.TB
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// Expectation is one expected statement, transaction begin, commit or
+// rollback
+type Expectation struct {
+	kind     string // begin, commit, rollback, exec or query
+	fragment string
+	args     []interface{}
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+	err      error
+	met      bool
+}
+
+// New returns a database backed by a fresh script. The test fails if an
+// expectation is left unmet when it ends.
+func New(t testing.TB) (*database.DB, *Mock) {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register(driverName, fakeDriver{}) })
+
+	m := &Mock{t: t}
+	dsn := strconv.FormatInt(nextDSN.Add(1), 10)
+	mocks.Store(dsn, m)
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		t.Fatalf("dbtest: open: %v", err)
+	}
+	// One connection keeps a transaction's statements on the script in order
+	db.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		db.Close()
+		mocks.Delete(dsn)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for _, e := range m.expectations {
+			if !e.met {
+				t.Errorf("dbtest: unmet expectation: %s", e)
+			}
+		}
+	})
+
+	return &database.DB{DB: db}, m
+}
+
+// ExpectBegin expects a transaction to start
+func (m *Mock) ExpectBegin() *Expectation { return m.expect("begin", "") }
+
+// ExpectCommit expects the transaction to commit
+func (m *Mock) ExpectCommit() *Expectation { return m.expect("commit", "") }
+
+// ExpectRollback expects the transaction to roll back
+func (m *Mock) ExpectRollback() *Expectation { return m.expect("rollback", "") }
+
+// ExpectExec expects a statement whose SQL contains fragment
+func (m *Mock) ExpectExec(fragment string) *Expectation { return m.expect("exec", fragment) }
+
+// ExpectQuery expects a query whose SQL contains fragment
+func (m *Mock) ExpectQuery(fragment string) *Expectation { return m.expect("query", fragment) }
+
+func (m *Mock) expect(kind, fragment string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &Expectation{kind: kind, fragment: fragment}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// WithArgs requires the statement's arguments to equal args; Any matches
+// any value
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	return e
+}
+
+// WillReturnRows answers a query with rows of the given columns
+func (e *Expectation) WillReturnRows(columns []string, rows ...[]driver.Value) *Expectation {
+	e.columns = columns
+	e.rows = rows
+	return e
+}
+
+// WillReturnResult answers a statement with the number of affected rows
+func (e *Expectation) WillReturnResult(affected int64) *Expectation {
+	e.affected = affected
+	return e
+}
+
+// WillReturnError makes the statement or transaction step fail with err
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) String() string {
+	if e.fragment == "" {
+		return e.kind
+	}
+	return fmt.Sprintf("%s containing %q", e.kind, e.fragment)
+}
+
+// next consumes the next expectation, which must be of kind and match query
+// and args
+func (m *Mock) next(kind, query string, args []driver.NamedValue) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range m.expectations {
+		if e.met {
+			continue
+		}
+		if e.kind != kind || !strings.Contains(query, e.fragment) {
+			m.t.Errorf("dbtest: got %s %q, want %s", kind, strings.Join(strings.Fields(query), " "), e)
+			return nil, fmt.Errorf("dbtest: unexpected %s", kind)
+		}
+		if e.args != nil {
+			if err := matchArgs(e.args, args); err != nil {
+				m.t.Errorf("dbtest: %s: %v", e, err)
+				return nil, err
+			}
+		}
+		e.met = true
+		return e, e.err
+	}
+
+	m.t.Errorf("dbtest: got unexpected %s %q", kind, strings.Join(strings.Fields(query), " "))
+	return nil, fmt.Errorf("dbtest: unexpected %s", kind)
+}
+
+func matchArgs(want []interface{}, got []driver.NamedValue) error {
+	if len(want) != len(got) {
+		return fmt.Errorf("got %d arguments, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if _, ok := w.(anyArg); ok {
+			continue
+		}
+		w, err := convert(w)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(w, got[i].Value) {
+			return fmt.Errorf("argument %d = %#v, want %#v", i+1, got[i].Value, w)
+		}
+	}
+	return nil
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	m, ok := mocks.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("dbtest: unknown database %q", dsn)
+	}
+	return &conn{mock: m.(*Mock)}, nil
+}
+
+type conn struct {
+	mock *Mock
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("dbtest: prepared statements are not supported")
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.mock.next("begin", "", nil); err != nil {
+		return nil, err
+	}
+	return &tx{mock: c.mock}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.mock.next("exec", query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(e.affected), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.mock.next("query", query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{columns: e.columns, values: e.rows}, nil
+}
+
+// CheckNamedValue converts arguments like database/sql does by default but
+// passes types the default converter rejects through as is, so slices and
+// other driver-specific types reach the script
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := convert(nv.Value)
+	if err != nil {
+		return err
+	}
+	nv.Value = value
+	return nil
+}
+
+func convert(v interface{}) (driver.Value, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		return valuer.Value()
+	}
+	if value, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		return value, nil
+	}
+	return v, nil
+}
+
+type tx struct {
+	mock *Mock
+}
+
+func (t *tx) Commit() error {
+	_, err := t.mock.next("commit", "", nil)
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, err := t.mock.next("rollback", "", nil)
+	return err
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.columns }
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	stderrors "errors"
+	"strings"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/lib/pq"
+)
+
+// PostgreSQL integrity constraint violation codes
+const (
+	NotNullViolation    = "23502"
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+)
+
+// WrapError wraps a query error as a database error, except that constraint
+// violations caused by the request become client errors: a duplicate key is
+// a conflict, and a missing reference or required value is a validation
+// error. message describes the failed operation, e.g. "failed to create tag".
+func WrapError(message string, err error) *errors.AppError {
+	var pqErr *pq.Error
+	if !stderrors.As(err, &pqErr) {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code != errors.ErrCodeDatabase {
+			return appErr // already translated by DB.ExecContext or DB.QueryContext
+		}
+		return errors.Wrap(errors.ErrCodeDatabase, message, err)
+	}
+
+	var appErr *errors.AppError
+	switch string(pqErr.Code) {
+	case UniqueViolation:
+		appErr = errors.Conflictf("%s: a record with the same %s already exists", message, keyColumns(pqErr.Detail, "values"))
+	case ForeignKeyViolation:
+		if strings.Contains(pqErr.Detail, "is still referenced") {
+			appErr = errors.Conflictf("%s: the record is still in use", message)
+			break
+		}
+		column := keyColumns(pqErr.Detail, "reference")
+		appErr = errors.Validationf("%s: %s does not exist", message, column).WithField(column, "does not exist")
+	case NotNullViolation:
+		appErr = errors.Validationf("%s: %s is required", message, pqErr.Column).WithField(pqErr.Column, "is required")
+	default:
+		return errors.Wrap(errors.ErrCodeDatabase, message, err)
+	}
+
+	appErr.Internal = err
+	if pqErr.Constraint != "" {
+		appErr = appErr.WithMeta("constraint", pqErr.Constraint)
+	}
+	return appErr
+}
+
+// keyColumns extracts the column list from a violation detail such as
+// "Key (tenant_id, slug)=(...) already exists.", leaving out tenant_id since
+// every tenant-scoped key includes it
+func keyColumns(detail, fallback string) string {
+	rest, ok := strings.CutPrefix(detail, "Key (")
+	if !ok {
+		return fallback
+	}
+	list, _, ok := strings.Cut(rest, ")=")
+	if !ok {
+		return fallback
+	}
+
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		if column = strings.TrimSpace(column); column != "tenant_id" {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		return fallback
+	}
+	return strings.Join(columns, ", ")
+}
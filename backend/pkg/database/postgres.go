@@ -9,6 +9,7 @@ import (
 	_ "github.com/lib/pq" // PostgreSQL driver
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/startup"
 	"go.uber.org/zap"
 )
 
@@ -37,6 +38,7 @@ func NewPostgresDB(cfg config.DatabaseConfig, logger *zap.Logger) (*DB, error) {
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
 		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to ping database", err)
 	}
 
@@ -54,6 +56,28 @@ func NewPostgresDB(cfg config.DatabaseConfig, logger *zap.Logger) (*DB, error) {
 	}, nil
 }
 
+// ConnectPostgresDB connects to PostgreSQL and runs a health check, retrying
+// both with backoff so a service can start before the database is ready
+func ConnectPostgresDB(cfg config.DatabaseConfig, retry config.StartupConfig, logger *zap.Logger) (*DB, error) {
+	var db *DB
+	err := startup.Retry(context.Background(), retry, logger, "database", func(ctx context.Context) error {
+		conn, err := NewPostgresDB(cfg, logger)
+		if err != nil {
+			return err
+		}
+		if err := conn.HealthCheck(ctx); err != nil {
+			_ = conn.DB.Close()
+			return err
+		}
+		db = conn
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	if db.logger != nil {
@@ -116,7 +140,7 @@ func (db *DB) WithTransaction(ctx context.Context, fn TxFunc) error {
 	}
 
 	if err := tx.Commit(); err != nil {
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to commit transaction", err)
+		return WrapError("failed to commit transaction", err)
 	}
 
 	return nil
@@ -132,7 +156,7 @@ func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}
 				zap.Error(err),
 			)
 		}
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "query execution failed", err)
+		return nil, WrapError("query execution failed", err)
 	}
 	return result, nil
 }
@@ -147,7 +171,7 @@ func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{
 				zap.Error(err),
 			)
 		}
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "query failed", err)
+		return nil, WrapError("query failed", err)
 	}
 	return rows, nil
 }
@@ -244,3 +268,16 @@ func BuildWhereClause(conditions map[string]interface{}) (string, []interface{})
 
 	return whereClause, args
 }
+
+// NotDeleted appends the soft-delete predicate to a list of WHERE conditions
+// unless includeTrashed is set. alias qualifies the column for joined
+// queries and may be empty. Tables opt in by adding a nullable deleted_at.
+func NotDeleted(where []string, alias string, includeTrashed bool) []string {
+	if includeTrashed {
+		return where
+	}
+	if alias != "" {
+		return append(where, alias+".deleted_at IS NULL")
+	}
+	return append(where, "deleted_at IS NULL")
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotDeleted(t *testing.T) {
+	tests := []struct {
+		name           string
+		alias          string
+		includeTrashed bool
+		want           []string
+	}{
+		{"excludes trashed rows by default", "", false, []string{"tenant_id = $1", "deleted_at IS NULL"}},
+		{"qualifies the column with the alias", "d", false, []string{"tenant_id = $1", "d.deleted_at IS NULL"}},
+		{"includes trashed rows when requested", "", true, []string{"tenant_id = $1"}},
+		{"includes trashed rows with an alias", "d", true, []string{"tenant_id = $1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NotDeleted([]string{"tenant_id = $1"}, tt.alias, tt.includeTrashed)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NotDeleted(alias %q, includeTrashed %v) = %q, want %q", tt.alias, tt.includeTrashed, got, tt.want)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ const (
 	ErrCodeConflict      ErrorCode = "CONFLICT"
 	ErrCodeBadRequest    ErrorCode = "BAD_REQUEST"
 	ErrCodeRateLimited   ErrorCode = "RATE_LIMITED"
+	ErrCodeTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"
+	ErrCodeNotAcceptable ErrorCode = "NOT_ACCEPTABLE"
 
 	// Server errors (5xx)
 	ErrCodeInternal      ErrorCode = "INTERNAL_ERROR"
@@ -101,6 +103,10 @@ func codeToStatus(code ErrorCode) int {
 		return http.StatusConflict
 	case ErrCodeRateLimited:
 		return http.StatusTooManyRequests
+	case ErrCodeTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrCodeNotAcceptable:
+		return http.StatusNotAcceptable
 	case ErrCodeUnavailable:
 		return http.StatusServiceUnavailable
 	default:
@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/version"
+	"go.uber.org/zap"
+)
+
+const defaultDependencyTimeout = 2 * time.Second
+
+// Readiness statuses
+const (
+	StatusReady       = "ready"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// dependency is a downstream service whose health contributes to readiness
+type dependency struct {
+	name     string
+	critical bool
+	client   *client.Client
+}
+
+// DependencyStatus represents the health of a single downstream service
+type DependencyStatus struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+	Healthy  bool   `json:"healthy"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report represents the readiness of a service and its dependencies
+type Report struct {
+	Status       string             `json:"status"`
+	Service      string             `json:"service"`
+	Degraded     bool               `json:"degraded"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// Liveness represents a service's liveness and build information, served by GET /health
+type Liveness struct {
+	Status        string    `json:"status"`
+	Service       string    `json:"service"`
+	Version       string    `json:"version"`
+	Commit        string    `json:"commit"`
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+}
+
+// Ready reports whether all critical dependencies are healthy
+func (r *Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+// Checker checks downstream dependencies for readiness probes
+type Checker struct {
+	service      string
+	appVersion   string
+	timeout      time.Duration
+	dependencies []dependency
+}
+
+// NewChecker creates a readiness checker from configuration. Dependencies are
+// given as "name=url" (or just "url") entries.
+func NewChecker(service string, cfg config.HealthConfig, logger *zap.Logger) *Checker {
+	timeout := cfg.DependencyTimeout
+	if timeout <= 0 {
+		timeout = defaultDependencyTimeout
+	}
+
+	c := &Checker{service: service, timeout: timeout}
+	c.add(cfg.CriticalDependencies, true, logger)
+	c.add(cfg.OptionalDependencies, false, logger)
+
+	return c
+}
+
+// WithVersion sets the application version reported by Live
+func (c *Checker) WithVersion(appVersion string) *Checker {
+	c.appVersion = appVersion
+	return c
+}
+
+// Live reports the service as alive along with its build and uptime; it does
+// not check dependencies
+func (c *Checker) Live() *Liveness {
+	return &Liveness{
+		Status:        "healthy",
+		Service:       c.service,
+		Version:       c.appVersion,
+		Commit:        version.Commit,
+		StartedAt:     version.StartedAt().UTC(),
+		UptimeSeconds: int64(version.Uptime().Seconds()),
+	}
+}
+
+func (c *Checker) add(entries []string, critical bool, logger *zap.Logger) {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, url := entry, entry
+		if idx := strings.Index(entry, "="); idx > 0 {
+			name, url = entry[:idx], entry[idx+1:]
+		}
+
+		c.dependencies = append(c.dependencies, dependency{
+			name:     name,
+			critical: critical,
+			client:   client.New(name, url, logger).WithTimeout(c.timeout),
+		})
+	}
+}
+
+// Check probes every dependency's /health endpoint concurrently
+func (c *Checker) Check(ctx context.Context) *Report {
+	report := &Report{
+		Status:       StatusReady,
+		Service:      c.service,
+		Dependencies: make([]DependencyStatus, len(c.dependencies)),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, dep := range c.dependencies {
+		wg.Add(1)
+		go func(i int, dep dependency) {
+			defer wg.Done()
+
+			status := DependencyStatus{Name: dep.name, Critical: dep.critical, Healthy: true}
+			if err := dep.client.Do(ctx, "GET", "/health", nil, nil); err != nil {
+				status.Healthy = false
+				status.Error = errors.FromError(err).Message
+			}
+			report.Dependencies[i] = status
+		}(i, dep)
+	}
+	wg.Wait()
+
+	for _, dep := range report.Dependencies {
+		if dep.Healthy {
+			continue
+		}
+		if dep.Critical {
+			report.Status = StatusUnavailable
+		} else {
+			report.Degraded = true
+		}
+	}
+	if report.Status == StatusReady && report.Degraded {
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
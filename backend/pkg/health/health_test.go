@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/version"
+	"go.uber.org/zap"
+)
+
+func TestLive(t *testing.T) {
+	tests := []struct {
+		name        string
+		appVersion  string
+		commit      string
+		wantVersion string
+		wantCommit  string
+	}{
+		{"release build", "1.4.2", "a1b2c3d", "1.4.2", "a1b2c3d"},
+		{"build without ldflags", "1.4.2", "unknown", "1.4.2", "unknown"},
+		{"no version configured", "", "a1b2c3d", "", "a1b2c3d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := version.Commit
+			version.Commit = tt.commit
+			defer func() { version.Commit = prev }()
+
+			checker := NewChecker("document-service", config.HealthConfig{}, zap.NewNop()).WithVersion(tt.appVersion)
+			live := checker.Live()
+
+			if live.Status != "healthy" || live.Service != "document-service" {
+				t.Errorf("Live() = %s/%s, want healthy/document-service", live.Status, live.Service)
+			}
+			if live.Version != tt.wantVersion {
+				t.Errorf("Version = %q, want %q", live.Version, tt.wantVersion)
+			}
+			if live.Commit != tt.wantCommit {
+				t.Errorf("Commit = %q, want %q", live.Commit, tt.wantCommit)
+			}
+			if !live.StartedAt.Equal(version.StartedAt()) || live.StartedAt.Location() != time.UTC {
+				t.Errorf("StartedAt = %v, want process start %v in UTC", live.StartedAt, version.StartedAt())
+			}
+			if maxUptime := int64(time.Since(version.StartedAt()).Seconds()); live.UptimeSeconds < 0 || live.UptimeSeconds > maxUptime {
+				t.Errorf("UptimeSeconds = %d, want 0..%d", live.UptimeSeconds, maxUptime)
+			}
+		})
+	}
+}
+
+func TestLiveJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	response.Success(rec, NewChecker("quota-service", config.HealthConfig{}, zap.NewNop()).WithVersion("1.4.2").Live())
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode GET /health: %v", err)
+	}
+	for _, key := range []string{"status", "service", "version", "commit", "started_at", "uptime_seconds"} {
+		if _, ok := body.Data[key]; !ok {
+			t.Errorf("GET /health has no %s: %s", key, rec.Body.String())
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.Success(w, nil)
+	}))
+	defer healthy.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.JSON(w, http.StatusServiceUnavailable, nil)
+	}))
+	defer down.Close()
+
+	tests := []struct {
+		name         string
+		critical     []string
+		optional     []string
+		wantStatus   string
+		wantDegraded bool
+	}{
+		{"no dependencies", nil, nil, StatusReady, false},
+		{"all healthy", []string{"rbac=" + healthy.URL}, []string{"quota=" + healthy.URL}, StatusReady, false},
+		{"optional dependency down", []string{"rbac=" + healthy.URL}, []string{"quota=" + down.URL}, StatusDegraded, true},
+		{"critical dependency down", []string{"rbac=" + down.URL}, []string{"quota=" + healthy.URL}, StatusUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.HealthConfig{CriticalDependencies: tt.critical, OptionalDependencies: tt.optional, DependencyTimeout: time.Second}
+			report := NewChecker("share-service", cfg, zap.NewNop()).Check(context.Background())
+
+			if report.Status != tt.wantStatus || report.Degraded != tt.wantDegraded {
+				t.Errorf("Check() = %s (degraded %v), want %s (degraded %v)", report.Status, report.Degraded, tt.wantStatus, tt.wantDegraded)
+			}
+			if report.Ready() != (tt.wantStatus != StatusUnavailable) {
+				t.Errorf("Ready() = %v with status %s", report.Ready(), report.Status)
+			}
+		})
+	}
+}
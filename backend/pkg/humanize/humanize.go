@@ -0,0 +1,58 @@
+package humanize
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Binary size units. Byte counts stay the source of truth in storage and
+// APIs; these exist for readable literals and display strings.
+const (
+	Byte int64 = 1
+	KB         = 1024 * Byte
+	MB         = 1024 * KB
+	GB         = 1024 * MB
+	TB         = 1024 * GB
+	PB         = 1024 * TB
+)
+
+var units = []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+// Bytes formats a byte count with binary units and one decimal place,
+// e.g. 1536 -> "1.5 KB". Whole values drop the decimal ("1 GB") and counts
+// below 1 KB are shown exactly ("1023 B").
+func Bytes(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	if n < KB {
+		return sign + strconv.FormatInt(n, 10) + " B"
+	}
+
+	value := float64(n)
+	unit := 0
+	for unit < len(units)-1 && value >= 1024 {
+		value /= 1024
+		unit++
+	}
+
+	// Rounding can push a value like 1023.96 KB up to "1024.0"; carry it
+	// into the next unit instead
+	formatted := strconv.FormatFloat(value, 'f', 1, 64)
+	if formatted == "1024.0" && unit < len(units)-1 {
+		formatted = "1.0"
+		unit++
+	}
+
+	return sign + strings.TrimSuffix(formatted, ".0") + " " + units[unit]
+}
+
+// Requested reports whether the caller asked for formatted sizes with
+// ?humanize=true
+func Requested(r *http.Request) bool {
+	requested, _ := strconv.ParseBool(r.URL.Query().Get("humanize"))
+	return requested
+}
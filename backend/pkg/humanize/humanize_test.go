@@ -0,0 +1,49 @@
+package humanize
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1 KB"},
+		{1536, "1.5 KB"},
+		{MB - 1, "1 MB"},
+		{3*GB/2 + 1, "1.5 GB"},
+		{TB, "1 TB"},
+		{5*TB + 512*GB, "5.5 TB"},
+		{2 * PB, "2 PB"},
+		{2048 * PB, "2048 PB"},
+		{-1536, "-1.5 KB"},
+	}
+	for _, tt := range tests {
+		if got := Bytes(tt.n); got != tt.want {
+			t.Errorf("Bytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", false},
+		{"?humanize=true", true},
+		{"?humanize=1", true},
+		{"?humanize=false", false},
+		{"?humanize=yes", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/quotas/overview"+tt.query, nil)
+		if got := Requested(r); got != tt.want {
+			t.Errorf("Requested(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
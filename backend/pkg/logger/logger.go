@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -24,10 +25,35 @@ const (
 // Logger wraps zap.Logger with additional context methods
 type Logger struct {
 	*zap.Logger
+	sampling bool
+}
+
+// options holds optional logger settings
+type options struct {
+	samplingInitial    int
+	samplingThereafter int
+}
+
+// Option configures optional logger behaviour
+type Option func(*options)
+
+// WithSampling samples info and debug logs: per second, the first `initial`
+// entries with the same message are logged, then every `thereafter`-th one.
+// Warn and above are never sampled. Zero for either value disables sampling.
+func WithSampling(initial, thereafter int) Option {
+	return func(o *options) {
+		o.samplingInitial = initial
+		o.samplingThereafter = thereafter
+	}
 }
 
 // New creates a new logger instance
-func New(environment, level, format string) (*Logger, error) {
+func New(environment, level, format string, opts ...Option) (*Logger, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var zapConfig zap.Config
 
 	if environment == "production" {
@@ -53,15 +79,64 @@ func New(environment, level, format string) (*Logger, error) {
 		zapConfig.Encoding = "console"
 	}
 
-	// Build logger
-	zapLogger, err := zapConfig.Build(
+	// Sampling is applied below so that warn and error logs are never dropped
+	zapConfig.Sampling = nil
+
+	buildOpts := []zap.Option{
 		zap.AddCallerSkip(1), // Skip one caller to get correct line numbers
-	)
+	}
+
+	sampling := o.samplingInitial > 0 && o.samplingThereafter > 0
+	if sampling {
+		buildOpts = append(buildOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return sampledCore(core, o.samplingInitial, o.samplingThereafter)
+		}))
+	}
+
+	// Build logger
+	zapLogger, err := zapConfig.Build(buildOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build logger: %w", err)
 	}
 
-	return &Logger{Logger: zapLogger}, nil
+	return &Logger{Logger: zapLogger, sampling: sampling}, nil
+}
+
+// SamplingEnabled reports whether info/debug log sampling is active
+func (l *Logger) SamplingEnabled() bool {
+	return l.sampling
+}
+
+// sampledCore samples the info and debug entries of core (see WithSampling)
+// and passes warn and above through unsampled
+func sampledCore(core zapcore.Core, initial, thereafter int) zapcore.Core {
+	low := &levelFilterCore{Core: core, enabled: func(l zapcore.Level) bool { return l < zapcore.WarnLevel }}
+	high := &levelFilterCore{Core: core, enabled: func(l zapcore.Level) bool { return l >= zapcore.WarnLevel }}
+	return zapcore.NewTee(
+		zapcore.NewSamplerWithOptions(low, time.Second, initial, thereafter),
+		high,
+	)
+}
+
+// levelFilterCore restricts a core to a subset of levels
+type levelFilterCore struct {
+	zapcore.Core
+	enabled func(zapcore.Level) bool
+}
+
+func (c *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return c.enabled(level) && c.Core.Enabled(level)
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), enabled: c.enabled}
+}
+
+func (c *levelFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
 }
 
 // NewDefault creates a logger with default settings
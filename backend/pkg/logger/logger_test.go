@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSampledCore(t *testing.T) {
+	const initial, thereafter, repeats = 2, 5, 12
+
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		want  int
+	}{
+		// 2 logged, then the 7th and the 12th
+		{"debug is sampled", zapcore.DebugLevel, 4},
+		{"info is sampled", zapcore.InfoLevel, 4},
+		{"warn is never dropped", zapcore.WarnLevel, repeats},
+		{"error is never dropped", zapcore.ErrorLevel, repeats},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			log := zap.New(sampledCore(core, initial, thereafter))
+
+			for i := 0; i < repeats; i++ {
+				if ce := log.Check(tt.level, "same message"); ce != nil {
+					ce.Write()
+				}
+			}
+
+			if got := logs.Len(); got != tt.want {
+				t.Errorf("%d of %d %s entries logged, want %d", got, repeats, tt.level, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampledCoreKeepsLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	log := zap.New(sampledCore(core, 1, 1))
+
+	log.Info("below the core's level")
+	log.Warn("at the core's level")
+
+	if entries := logs.All(); len(entries) != 1 || entries[0].Message != "at the core's level" {
+		t.Errorf("logged %v, want only the warn entry", entries)
+	}
+}
+
+func TestNewSampling(t *testing.T) {
+	tests := []struct {
+		name       string
+		initial    int
+		thereafter int
+		want       bool
+	}{
+		{"both set", 100, 100, true},
+		{"no initial", 0, 100, false},
+		{"no thereafter", 100, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, err := New("production", "info", "json", WithSampling(tt.initial, tt.thereafter))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := log.SamplingEnabled(); got != tt.want {
+				t.Errorf("SamplingEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
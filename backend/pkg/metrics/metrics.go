@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+)
+
+// Counters published via expvar
+var (
+	// SlowRequests counts HTTP requests that exceeded the slow-request threshold
+	SlowRequests = expvar.NewInt("http_slow_requests_total")
+
+	// QuotaUnderflows counts usage decrements clamped at zero, keyed by resource
+	QuotaUnderflows = expvar.NewMap("quota_usage_underflows_total")
+)
+
+// Handler returns an HTTP handler exposing all published metrics as JSON
+func Handler() http.Handler {
+	return expvar.Handler()
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// APICallCounterFunc adds n to a tenant's API call count for today and
+// returns the new count (see cache.Cache.IncrementAPICalls)
+type APICallCounterFunc func(ctx context.Context, tenantID string, n int64) (int64, error)
+
+// CountAPICalls counts each tenant request towards the daily API call quota.
+// Health checks and signed service-to-service requests are not counted.
+// Counting fails open: a counter error is logged and the request proceeds.
+// Must run after ResolveTenant and VerifyInternalRequest.
+func CountAPICalls(count APICallCounterFunc, log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := GetTenantID(r.Context())
+			if tenantID != "" && !isHealthPath(r.URL.Path) && !IsInternalRequest(r.Context()) {
+				if _, err := count(r.Context(), tenantID, 1); err != nil {
+					log.WarnContext(r.Context(), "failed to count api call",
+						zap.String("tenant_id", tenantID),
+						zap.Error(err),
+					)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func TestInternalAuth(t *testing.T) {
+	const secret = "test-secret"
+	const body = `{"tenant_id":"t1","amount":10}`
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name       string
+		timestamp  int64
+		signBody   string
+		sendBody   string
+		sendTenant string
+		sendUser   string
+		secret     string
+		unsigned   bool
+		wantStatus int
+	}{
+		{"valid signed request", now, body, body, "t1", "u1", secret, false, http.StatusOK},
+		{"timestamp within skew", now - 30, body, body, "t1", "u1", secret, false, http.StatusOK},
+		{"expired timestamp", now - 600, body, body, "t1", "u1", secret, false, http.StatusUnauthorized},
+		{"timestamp in the future", now + 600, body, body, "t1", "u1", secret, false, http.StatusUnauthorized},
+		{"tampered body", now, body, `{"tenant_id":"t2","amount":10}`, "t1", "u1", secret, false, http.StatusUnauthorized},
+		{"tampered tenant header", now, body, body, "t2", "u1", secret, false, http.StatusUnauthorized},
+		{"tampered user header", now, body, body, "t1", "u2", secret, false, http.StatusUnauthorized},
+		{"signed with another secret", now, body, body, "t1", "u1", "other-secret", false, http.StatusUnauthorized},
+		{"unsigned request", now, body, body, "t1", "u1", secret, true, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody string
+			var internal bool
+			handler := InternalAuth(secret, time.Minute, &logger.Logger{Logger: zap.NewNop()})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					data, _ := io.ReadAll(r.Body)
+					gotBody = string(data)
+					internal = IsInternalRequest(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/quotas/usage/adjust?dry_run=true", strings.NewReader(tt.sendBody))
+			if !tt.unsigned {
+				timestamp := strconv.FormatInt(tt.timestamp, 10)
+				req.Header.Set(HeaderInternalTimestamp, timestamp)
+				req.Header.Set(HeaderInternalSignature, SignInternalRequest(tt.secret, req.Method, req.URL.RequestURI(), "u1", "t1", timestamp, []byte(tt.signBody)))
+			}
+			req.Header.Set(HeaderUserID, tt.sendUser)
+			req.Header.Set(HeaderTenantID, tt.sendTenant)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if gotBody != tt.sendBody {
+				t.Errorf("handler read body %q, want %q", gotBody, tt.sendBody)
+			}
+			if !internal {
+				t.Error("IsInternalRequest = false for a verified request")
+			}
+		})
+	}
+}
+
+func TestSetInternalSignature(t *testing.T) {
+	body := []byte(`{"resource":"storage"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/quotas/check", nil)
+	req.Header.Set(HeaderUserID, "u1")
+	req.Header.Set(HeaderTenantID, "t1")
+	SetInternalSignature(req, "test-secret", body)
+
+	if err := verifyInternalSignature(withBody(req, body), "test-secret", time.Minute); err != nil {
+		t.Fatalf("request signed by SetInternalSignature failed verification: %v", err)
+	}
+
+	req.Header.Set(HeaderTenantID, "t2")
+	if err := verifyInternalSignature(withBody(req, body), "test-secret", time.Minute); err == nil {
+		t.Fatal("request with a rewritten X-Tenant-ID passed verification")
+	}
+	req.Header.Set(HeaderTenantID, "t1")
+
+	req.Header.Set(HeaderInternalSignature, strings.Repeat("0", 64))
+	if err := verifyInternalSignature(withBody(req, body), "test-secret", time.Minute); err == nil {
+		t.Fatal("tampered signature passed verification")
+	}
+}
+
+// withBody resets the body of r so it can be verified again
+func withBody(r *http.Request, body []byte) *http.Request {
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+	return r
+}
+
+func TestServiceIdentity(t *testing.T) {
+	const secret = "test-secret"
+	log := &logger.Logger{Logger: zap.NewNop()}
+
+	tests := []struct {
+		name       string
+		userID     string
+		signed     bool
+		wantStatus int
+	}{
+		{"user on an unsigned request", "u1", false, http.StatusOK},
+		{"service on a signed request", ServiceUserPrefix + "storage-service", true, http.StatusOK},
+		{"service on an unsigned request", ServiceUserPrefix + "storage-service", false, http.StatusUnauthorized},
+		{"no identity on a signed request", "", true, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser string
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUser = GetUserID(r.Context())
+			})
+			handler = ExtractAuthHeaders(log)(handler)
+			handler = VerifyInternalRequest(secret, time.Minute, log)(handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/quotas", nil)
+			req.Header.Set(HeaderTenantID, "t1")
+			if tt.userID != "" {
+				req.Header.Set(HeaderUserID, tt.userID)
+			}
+			if tt.signed {
+				SetInternalSignature(req, secret, nil)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && gotUser != tt.userID {
+				t.Errorf("handler saw user %q, want %q", gotUser, tt.userID)
+			}
+		})
+	}
+
+	ctx := WithServiceIdentity(WithTenantID(context.Background(), "t1"), "share-service")
+	if GetUserID(ctx) != "service:share-service" || GetTenantID(ctx) != "t1" {
+		t.Errorf("WithServiceIdentity gave user %q and tenant %q", GetUserID(ctx), GetTenantID(ctx))
+	}
+}
@@ -3,11 +3,15 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"go.uber.org/zap"
 )
@@ -40,6 +44,12 @@ const (
 func ExtractAuthHeaders(log *logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Health probes are unauthenticated
+			if isHealthPath(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			userID := r.Header.Get(HeaderUserID)
 			userEmail := r.Header.Get(HeaderUserEmail)
 			userName := r.Header.Get(HeaderUserName)
@@ -51,6 +61,13 @@ func ExtractAuthHeaders(log *logger.Logger) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Service identities are only trusted on signed internal calls,
+			// so VerifyInternalRequest must run first
+			if IsServiceUser(userID) && !IsInternalRequest(r.Context()) {
+				response.Error(w, errors.ErrUnauthorized)
+				return
+			}
+
 			// Create auth context
 			authCtx := &AuthContext{
 				UserID:    userID,
@@ -125,7 +142,22 @@ func RequestID() func(http.Handler) http.Handler {
 	}
 }
 
-// Logging logs HTTP requests
+// slowRequestThreshold holds the slow-request threshold in nanoseconds (0 disables)
+var slowRequestThreshold atomic.Int64
+
+// SetSlowRequestThreshold sets the duration above which requests are logged at
+// warn level. It is safe to call while serving, so the threshold can be reloaded.
+func SetSlowRequestThreshold(threshold time.Duration) {
+	slowRequestThreshold.Store(int64(threshold))
+}
+
+// SlowRequestThreshold returns the current slow-request threshold
+func SlowRequestThreshold() time.Duration {
+	return time.Duration(slowRequestThreshold.Load())
+}
+
+// Logging logs HTTP requests. Requests slower than the slow-request threshold
+// are additionally logged at warn level and counted.
 func Logging(log *logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -147,6 +179,18 @@ func Logging(log *logger.Logger) func(http.Handler) http.Handler {
 				zap.Duration("duration", duration),
 				zap.String("user_agent", r.UserAgent()),
 			)
+
+			// Flag slow requests
+			if threshold := SlowRequestThreshold(); threshold > 0 && duration > threshold {
+				metrics.SlowRequests.Add(1)
+				log.WarnContext(r.Context(), "slow http request",
+					zap.Duration("duration", duration),
+					zap.Duration("threshold", threshold),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Int("status", wrapped.statusCode),
+				)
+			}
 		})
 	}
 }
@@ -172,27 +216,63 @@ func Recovery(log *logger.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-// CORS adds CORS headers
-func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+// CORSOptions configures CORS (see config.CORSConfig)
+type CORSOptions struct {
+	AllowedOrigins      []string      // exact origins, or "*" for any origin without credentials
+	CredentialedOrigins []string      // exact origins allowed with credentials; implicitly allowed
+	MaxAge              time.Duration // preflight cache lifetime; 0 omits Access-Control-Max-Age
+}
+
+// CORS adds CORS headers for allowed origins. A listed origin is echoed
+// exactly; an origin matched only by "*" gets a literal "*". Credentials are
+// allowed for credentialed origins only, so never alongside "*". Other
+// origins get no CORS headers.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	listed := make(map[string]bool, len(opts.AllowedOrigins)+len(opts.CredentialedOrigins))
+	anyOrigin := false
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		listed[origin] = false
+	}
+	for _, origin := range opts.CredentialedOrigins {
+		if origin != "*" {
+			listed[origin] = true
+		}
+	}
+
+	maxAge := ""
+	if opts.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(opts.MaxAge / time.Second))
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
 
-			// Check if origin is allowed
-			allowed := false
-			for _, allowedOrigin := range allowedOrigins {
-				if allowedOrigin == "*" || allowedOrigin == origin {
-					allowed = true
-					break
-				}
+			// The response depends on the origin whenever origins are listed
+			if len(listed) > 0 {
+				w.Header().Add("Vary", "Origin")
+			}
+
+			credentialed, ok := listed[origin]
+			allowOrigin := origin
+			if !ok && anyOrigin {
+				allowOrigin, ok = "*", true
 			}
 
-			if allowed {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
+			if ok && origin != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
 				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
 				w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-Request-ID")
-				w.Header().Set("Access-Control-Allow-Credentials", "true")
-				w.Header().Set("Access-Control-Max-Age", "3600")
+				if credentialed {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				if maxAge != "" {
+					w.Header().Set("Access-Control-Max-Age", maxAge)
+				}
 			}
 
 			// Handle preflight
@@ -206,6 +286,20 @@ func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
 	}
 }
 
+// MaxBodySize limits request bodies to the given number of bytes so decoders
+// cannot buffer unbounded input. Multipart uploads are left to the handlers,
+// which apply their own limits. A limit of 0 disables the check.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit > 0 && r.Body != nil && !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // Timeout adds a timeout to the request context
 func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -245,6 +339,14 @@ func GetTenantID(ctx context.Context) string {
 	return authCtx.TenantID
 }
 
+// WithTenantID returns a copy of ctx whose auth context targets the given tenant
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	authCtx := *GetAuthContext(ctx)
+	authCtx.TenantID = tenantID
+	ctx = context.WithValue(ctx, authContextKey, &authCtx)
+	return logger.WithTenantID(ctx, tenantID)
+}
+
 // RequireTenant middleware ensures tenant ID is present
 func RequireTenant() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -259,6 +361,11 @@ func RequireTenant() func(http.Handler) http.Handler {
 	}
 }
 
+// isHealthPath reports whether the path is a health or readiness probe
+func isHealthPath(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/health/")
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
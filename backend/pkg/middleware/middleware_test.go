@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggingSlowRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		sleep     time.Duration
+		wantWarn  bool
+	}{
+		{"fast request logs at info only", time.Second, 0, false},
+		{"slow request also logs at warn", 10 * time.Millisecond, 30 * time.Millisecond, true},
+		{"zero threshold disables the check", 0, 30 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := SlowRequestThreshold()
+			SetSlowRequestThreshold(tt.threshold)
+			defer SetSlowRequestThreshold(prev)
+
+			core, logs := observer.New(zapcore.InfoLevel)
+			log := &logger.Logger{Logger: zap.New(core)}
+			handler := Logging(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.sleep)
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			before := metrics.SlowRequests.Value()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/documents", nil))
+
+			if got := logs.FilterMessage("http request").FilterLevelExact(zapcore.InfoLevel).Len(); got != 1 {
+				t.Errorf("info entries = %d, want 1", got)
+			}
+			warns := logs.FilterMessage("slow http request").FilterLevelExact(zapcore.WarnLevel).All()
+			if tt.wantWarn != (len(warns) == 1) {
+				t.Fatalf("warn entries = %d, want warn %v", len(warns), tt.wantWarn)
+			}
+			wantCount := before
+			if tt.wantWarn {
+				wantCount++
+				if status := warns[0].ContextMap()["status"]; status != int64(http.StatusTeapot) {
+					t.Errorf("warn status = %v, want %d", status, http.StatusTeapot)
+				}
+			}
+			if got := metrics.SlowRequests.Value(); got != wantCount {
+				t.Errorf("slow request counter = %d, want %d", got, wantCount)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins:      []string{"https://app.example.com"},
+		CredentialedOrigins: []string{"https://admin.example.com"},
+		MaxAge:              time.Hour,
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		origin          string
+		user            string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials bool
+	}{
+		{"allowed origin", http.MethodGet, "https://app.example.com", "user-1", http.StatusTeapot, "https://app.example.com", false},
+		{"credentialed origin", http.MethodGet, "https://admin.example.com", "user-1", http.StatusTeapot, "https://admin.example.com", true},
+		{"disallowed origin gets no cors headers", http.MethodGet, "https://evil.example.com", "user-1", http.StatusTeapot, "", false},
+		{"preflight is answered before auth", http.MethodOptions, "https://app.example.com", "", http.StatusNoContent, "https://app.example.com", false},
+		{"preflight from a disallowed origin", http.MethodOptions, "https://evil.example.com", "", http.StatusNoContent, "", false},
+		{"unauthenticated request still needs auth", http.MethodGet, "https://app.example.com", "", http.StatusUnauthorized, "https://app.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The services' order: CORS wraps the auth middleware
+			log := &logger.Logger{Logger: zap.NewNop()}
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})
+			handler = ExtractAuthHeaders(log)(handler)
+			handler = VerifyInternalRequest("test-secret", time.Minute, log)(handler)
+			handler = CORS(opts)(handler)
+
+			req := httptest.NewRequest(tt.method, "/api/documents", nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.method == http.MethodOptions {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			if tt.user != "" {
+				req.Header.Set(HeaderUserID, tt.user)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("credentials allowed = %v, want %v", got, tt.wantCredentials)
+			}
+			if got := rec.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+			if tt.wantOrigin != "" && rec.Header().Get("Access-Control-Max-Age") != "3600" {
+				t.Errorf("Access-Control-Max-Age = %q, want 3600", rec.Header().Get("Access-Control-Max-Age"))
+			}
+		})
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	handler := CORS(CORSOptions{AllowedOrigins: []string{"*"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/documents", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Access-Control-Allow-Credentials = %q with *, want none", got)
+	}
+}
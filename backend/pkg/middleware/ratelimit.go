@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"go.uber.org/zap"
+)
+
+// rateLimitWindow is the fixed window limits are counted over
+const rateLimitWindow = time.Minute
+
+// RateLimitResolverFunc returns a tenant's requests-per-minute limit; 0
+// means unlimited (see cache.Cache.RateLimitResolver)
+type RateLimitResolverFunc func(ctx context.Context, tenantID string) (int, error)
+
+// RateWindowCounterFunc counts a request against the tenant's current window
+// and returns the count and when the window resets (see
+// cache.Cache.IncrementRateWindow)
+type RateWindowCounterFunc func(ctx context.Context, tenantID string, window time.Duration) (int64, time.Time, error)
+
+// RateLimit rejects tenant requests above the tenant's per-minute limit with
+// 429 and Retry-After. The limit is resolved per tenant, typically from its
+// quota plan; when that fails cfg.RequestsPerMinute applies. Health checks
+// and signed service-to-service requests are not limited, and counter
+// errors fail open. Must run after ResolveTenant and VerifyInternalRequest.
+func RateLimit(cfg config.RateLimitConfig, resolve RateLimitResolverFunc, count RateWindowCounterFunc, log *logger.Logger) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := GetTenantID(r.Context())
+			if tenantID == "" || isHealthPath(r.URL.Path) || IsInternalRequest(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit, err := resolve(r.Context(), tenantID)
+			if err != nil {
+				if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+					log.WarnContext(r.Context(), "failed to resolve rate limit, using default",
+						zap.String("tenant_id", tenantID),
+						zap.Error(err),
+					)
+				}
+				limit = cfg.RequestsPerMinute
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			used, resetAt, err := count(r.Context(), tenantID, rateLimitWindow)
+			if err != nil {
+				log.WarnContext(r.Context(), "failed to count request for rate limit",
+					zap.String("tenant_id", tenantID),
+					zap.Error(err),
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			remaining := max(int64(limit)-used, 0)
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+			if used > int64(limit) {
+				retryAfter := int(math.Ceil(time.Until(resetAt).Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+				response.Error(w, errors.New(errors.ErrCodeRateLimited, "rate limit exceeded").
+					WithMeta("limit", limit).
+					WithMeta("window", "1m"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func TestRateLimit(t *testing.T) {
+	// Plan limits as the quota service reports them
+	planLimits := map[string]int{"free-tenant": 60, "pro-tenant": 1200, "unlimited-tenant": 0}
+	resolve := func(ctx context.Context, tenantID string) (int, error) {
+		switch tenantID {
+		case "broken-tenant":
+			return 0, stderrors.New("quota service unavailable")
+		case "custom-tenant":
+			return 0, errors.NotFoundf("plan 'custom' has no rate limit")
+		}
+		return planLimits[tenantID], nil
+	}
+
+	tests := []struct {
+		name       string
+		disabled   bool
+		tenantID   string
+		internal   bool
+		used       int64
+		countErr   error
+		wantStatus int
+		wantLimit  string // X-RateLimit-Limit; empty when not limited
+		wantRemain string
+	}{
+		{"free plan under its limit", false, "free-tenant", false, 10, nil, http.StatusOK, "60", "50"},
+		{"free plan at its limit", false, "free-tenant", false, 60, nil, http.StatusOK, "60", "0"},
+		{"free plan over its limit", false, "free-tenant", false, 61, nil, http.StatusTooManyRequests, "60", "0"},
+		{"pro plan allows what free rejects", false, "pro-tenant", false, 61, nil, http.StatusOK, "1200", "1139"},
+		{"plan without a limit falls back to the default", false, "custom-tenant", false, 101, nil, http.StatusTooManyRequests, "100", "0"},
+		{"resolver failure falls back to the default", false, "broken-tenant", false, 50, nil, http.StatusOK, "100", "50"},
+		{"zero plan limit is unlimited", false, "unlimited-tenant", false, 1000000, nil, http.StatusOK, "", ""},
+		{"signed internal requests are exempt", false, "free-tenant", true, 1000, nil, http.StatusOK, "", ""},
+		{"requests without a tenant are exempt", false, "", false, 1000, nil, http.StatusOK, "", ""},
+		{"counter failure fails open", false, "free-tenant", false, 0, stderrors.New("redis down"), http.StatusOK, "", ""},
+		{"disabled rate limiting", true, "free-tenant", false, 1000, nil, http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetAt := time.Now().Add(30 * time.Second)
+			count := func(ctx context.Context, tenantID string, window time.Duration) (int64, time.Time, error) {
+				if window != time.Minute {
+					t.Errorf("window = %v, want 1m", window)
+				}
+				return tt.used, resetAt, tt.countErr
+			}
+
+			cfg := config.RateLimitConfig{Enabled: !tt.disabled, RequestsPerMinute: 100}
+			log := &logger.Logger{Logger: zap.NewNop()}
+			handler := RateLimit(cfg, resolve, count, log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			ctx := context.Background()
+			if tt.tenantID != "" {
+				ctx = WithTenantID(ctx, tt.tenantID)
+			}
+			if tt.internal {
+				ctx = context.WithValue(ctx, internalContextKey, true)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/documents", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("X-RateLimit-Limit"); got != tt.wantLimit {
+				t.Errorf("X-RateLimit-Limit = %q, want %q", got, tt.wantLimit)
+			}
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemain {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.wantRemain)
+			}
+			if tt.wantLimit != "" {
+				if got := rec.Header().Get("X-RateLimit-Reset"); got != strconv.FormatInt(resetAt.Unix(), 10) {
+					t.Errorf("X-RateLimit-Reset = %q, want %d", got, resetAt.Unix())
+				}
+			}
+
+			retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After"))
+			if tt.wantStatus == http.StatusTooManyRequests {
+				if retryAfter < 1 || retryAfter > 30 {
+					t.Errorf("Retry-After = %d, want 1..30 seconds", retryAfter)
+				}
+			} else if retryAfter != 0 {
+				t.Errorf("Retry-After = %d on an allowed request", retryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+)
+
+// Tenant resolution sources (TENANT_RESOLUTION_SOURCE)
+const (
+	TenantSourceHeader = "header" // X-Tenant-ID injected by the gateway; no resolution
+	TenantSourceSlug   = "slug"   // X-Tenant-Slug header
+	TenantSourceHost   = "host"   // subdomain of TENANT_BASE_DOMAIN
+	TenantSourcePath   = "path"   // /t/{slug}/... prefix, stripped before routing
+
+	HeaderTenantSlug = "X-Tenant-Slug"
+)
+
+// TenantResolverFunc maps a tenant slug to its ID. It returns a not-found
+// error for unknown slugs and a forbidden error for suspended tenants.
+type TenantResolverFunc func(ctx context.Context, slug string) (string, error)
+
+// TenantMemberFunc reports whether a user belongs to a tenant
+type TenantMemberFunc func(ctx context.Context, tenantID, userID string) (bool, error)
+
+// ResolveTenant resolves the tenant of each request from a slug taken from
+// the configured source and injects its ID as X-Tenant-ID and into the auth
+// context, replacing any ID the client sent. The authenticated user must be a
+// member of the resolved tenant, otherwise the request is forbidden. Requests
+// without a slug pass through unchanged. Resolutions and memberships are
+// cached in process for cfg.CacheTTL, so a suspension or removal can take
+// that long to apply. Signed internal requests keep the X-Tenant-ID they were
+// signed with. Must run after ExtractAuthHeaders.
+func ResolveTenant(cfg config.TenancyConfig, resolve TenantResolverFunc, isMember TenantMemberFunc) func(http.Handler) http.Handler {
+	if cfg.ResolutionSource == "" || cfg.ResolutionSource == TenantSourceHeader {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	cache := newSlugCache(cfg.CacheTTL, slugCacheMaxEntries)
+	members := newSlugCache(cfg.CacheTTL, slugCacheMaxEntries)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isHealthPath(r.URL.Path) || IsInternalRequest(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			slug, r := tenantSlug(r, cfg)
+			if slug == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tenantID, ok := cache.get(slug)
+			if !ok {
+				var err error
+				tenantID, err = resolve(r.Context(), slug)
+				if err != nil {
+					response.Error(w, err)
+					return
+				}
+				cache.set(slug, tenantID)
+			}
+
+			// Only positive answers are cached so a new member gets in at once
+			userID := GetUserID(r.Context())
+			memberKey := tenantID + "/" + userID
+			if _, ok := members.get(memberKey); !ok {
+				member, err := isMember(r.Context(), tenantID, userID)
+				if err != nil {
+					response.Error(w, err)
+					return
+				}
+				if !member {
+					response.Error(w, errors.Forbiddenf("you are not a member of this tenant"))
+					return
+				}
+				members.set(memberKey, tenantID)
+			}
+
+			r.Header.Set(HeaderTenantID, tenantID)
+			next.ServeHTTP(w, r.WithContext(WithTenantID(r.Context(), tenantID)))
+		})
+	}
+}
+
+// tenantSlug extracts the slug for the configured source. For the path source
+// the returned request has the /t/{slug} prefix removed.
+func tenantSlug(r *http.Request, cfg config.TenancyConfig) (string, *http.Request) {
+	switch cfg.ResolutionSource {
+	case TenantSourceSlug:
+		return strings.ToLower(strings.TrimSpace(r.Header.Get(HeaderTenantSlug))), r
+
+	case TenantSourceHost:
+		if cfg.BaseDomain == "" {
+			return "", r
+		}
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+		sub, ok := strings.CutSuffix(host, "."+strings.ToLower(cfg.BaseDomain))
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return "", r
+		}
+		return sub, r
+
+	case TenantSourcePath:
+		rest, ok := strings.CutPrefix(r.URL.Path, "/t/")
+		if !ok {
+			return "", r
+		}
+		slug, path, _ := strings.Cut(rest, "/")
+		if slug == "" {
+			return "", r
+		}
+		rewritten := r.Clone(r.Context())
+		rewritten.URL.Path = "/" + path
+		rewritten.URL.RawPath = ""
+		return strings.ToLower(slug), rewritten
+	}
+
+	return "", r
+}
+
+// slugCacheMaxEntries bounds each in-process cache; the least recently used
+// entry is evicted beyond it
+const slugCacheMaxEntries = 10000
+
+// slugCache is a small in-process slug to tenant ID cache, also used for
+// membership keys. It is a bounded LRU: expired entries are dropped when read
+// and the least recently used entry when full, so every operation is O(1).
+type slugCache struct {
+	ttl        time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	order      *list.List // of *slugCacheEntry, most recently used first
+	entries    map[string]*list.Element
+}
+
+type slugCacheEntry struct {
+	key       string
+	tenantID  string
+	expiresAt time.Time
+}
+
+func newSlugCache(ttl time.Duration, maxEntries int) *slugCache {
+	return &slugCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (c *slugCache) get(slug string) (string, bool) {
+	if c.ttl <= 0 {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[slug]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*slugCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, slug)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return entry.tenantID, true
+}
+
+func (c *slugCache) set(slug, tenantID string) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.entries[slug]; ok {
+		entry := elem.Value.(*slugCacheEntry)
+		entry.tenantID, entry.expiresAt = tenantID, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.maxEntries > 0 && c.order.Len() >= c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*slugCacheEntry).key)
+	}
+	c.entries[slug] = c.order.PushFront(&slugCacheEntry{key: slug, tenantID: tenantID, expiresAt: expiresAt})
+}
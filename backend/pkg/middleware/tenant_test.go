@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSlugCache(t *testing.T) {
+	t.Run("hit until expiry", func(t *testing.T) {
+		c := newSlugCache(20*time.Millisecond, 10)
+		c.set("acme", "tenant-1")
+
+		if got, ok := c.get("acme"); !ok || got != "tenant-1" {
+			t.Fatalf("get(acme) = %q, %v, want tenant-1", got, ok)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if _, ok := c.get("acme"); ok {
+			t.Error("get(acme) hit after the TTL")
+		}
+	})
+
+	t.Run("expired entries are evicted on read", func(t *testing.T) {
+		c := newSlugCache(10*time.Millisecond, 10)
+		c.set("acme", "tenant-1")
+		time.Sleep(20 * time.Millisecond)
+
+		c.get("acme")
+		if len(c.entries) != 0 || c.order.Len() != 0 {
+			t.Errorf("cache holds %d entries after reading an expired one, want 0", len(c.entries))
+		}
+	})
+
+	t.Run("least recently used entry is evicted when full", func(t *testing.T) {
+		c := newSlugCache(time.Minute, 3)
+		c.set("a", "tenant-a")
+		c.set("b", "tenant-b")
+		c.set("c", "tenant-c")
+		c.get("a") // b is now the least recently used
+		c.set("d", "tenant-d")
+
+		if _, ok := c.get("b"); ok {
+			t.Error("b survived eviction")
+		}
+		for _, slug := range []string{"a", "c", "d"} {
+			if _, ok := c.get(slug); !ok {
+				t.Errorf("%s was evicted", slug)
+			}
+		}
+	})
+
+	t.Run("size stays bounded", func(t *testing.T) {
+		c := newSlugCache(time.Minute, 100)
+		for i := 0; i < 1000; i++ {
+			c.set("slug-"+strconv.Itoa(i), "tenant")
+		}
+		if len(c.entries) != 100 || c.order.Len() != 100 {
+			t.Errorf("cache holds %d entries (%d ordered), want 100", len(c.entries), c.order.Len())
+		}
+	})
+
+	t.Run("setting a cached slug replaces it", func(t *testing.T) {
+		c := newSlugCache(time.Minute, 10)
+		c.set("acme", "tenant-1")
+		c.set("acme", "tenant-2")
+
+		if got, _ := c.get("acme"); got != "tenant-2" {
+			t.Errorf("get(acme) = %q, want tenant-2", got)
+		}
+		if len(c.entries) != 1 {
+			t.Errorf("cache holds %d entries, want 1", len(c.entries))
+		}
+	})
+
+	t.Run("zero TTL disables the cache", func(t *testing.T) {
+		c := newSlugCache(0, 10)
+		c.set("acme", "tenant-1")
+
+		if _, ok := c.get("acme"); ok || len(c.entries) != 0 {
+			t.Error("cache with a zero TTL stored an entry")
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+)
+
+// API versions. Unversioned /api/... routes are served as DefaultAPIVersion.
+const (
+	APIVersion1       = "v1"
+	APIVersion2       = "v2"
+	DefaultAPIVersion = APIVersion1
+
+	// HeaderAPIVersion reports the version that served a response
+	HeaderAPIVersion = "API-Version"
+)
+
+const apiVersionContextKey contextKey = "api_version"
+
+var (
+	// versionPathPattern matches the version segment of /api/vN/...
+	versionPathPattern = regexp.MustCompile(`^/api/(v[0-9]+)(/.*)?$`)
+	// versionMediaPattern matches Accept: application/vnd.docmanager.vN+json
+	versionMediaPattern = regexp.MustCompile(`application/vnd\.docmanager\.(v[0-9]+)\+json`)
+)
+
+// Versioning resolves the API version of each request and stores it in the
+// context. The version comes from the path (/api/v2/documents, which is
+// rewritten to /api/documents so routes are registered once), then from the
+// Accept header (application/vnd.docmanager.v2+json), and defaults to
+// DefaultAPIVersion. Versions outside supported are rejected with 404 (path)
+// or 406 (header).
+func Versioning(supported ...string) func(http.Handler) http.Handler {
+	known := make(map[string]bool, len(supported))
+	for _, version := range supported {
+		known[version] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			version := DefaultAPIVersion
+
+			if match := versionPathPattern.FindStringSubmatch(r.URL.Path); match != nil {
+				if !known[match[1]] {
+					response.Error(w, errors.NotFoundf("API version %s is not supported", match[1]))
+					return
+				}
+				version = match[1]
+
+				rewritten := r.Clone(r.Context())
+				rewritten.URL.Path = "/api" + match[2]
+				rewritten.URL.RawPath = ""
+				r = rewritten
+			} else if match := versionMediaPattern.FindStringSubmatch(r.Header.Get("Accept")); match != nil {
+				if !known[match[1]] {
+					response.Error(w, errors.New(errors.ErrCodeNotAcceptable, fmt.Sprintf("API version %s is not supported", match[1])))
+					return
+				}
+				version = match[1]
+			}
+
+			w.Header().Set(HeaderAPIVersion, version)
+			ctx := context.WithValue(r.Context(), apiVersionContextKey, version)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetAPIVersion returns the API version resolved by Versioning
+func GetAPIVersion(ctx context.Context) string {
+	if version, ok := ctx.Value(apiVersionContextKey).(string); ok {
+		return version
+	}
+	return DefaultAPIVersion
+}
+
+// Versions dispatches a route to the handler registered for the request's API
+// version. A resource that did not change in a newer version keeps being
+// served by its latest older handler, so only breaking changes need a new
+// entry:
+//
+//	mux.Handle("GET /api/documents", middleware.Versions(map[string]http.HandlerFunc{
+//		middleware.APIVersion1: h.ListDocuments,
+//		middleware.APIVersion2: h.ListDocumentsV2,
+//	}))
+func Versions(handlers map[string]http.HandlerFunc) http.Handler {
+	versions := make([]string, 0, len(handlers))
+	for version := range handlers {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versionNumber(versions[i]) < versionNumber(versions[j])
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested := versionNumber(GetAPIVersion(r.Context()))
+
+		var handler http.HandlerFunc
+		for _, version := range versions {
+			if versionNumber(version) > requested {
+				break
+			}
+			handler = handlers[version]
+		}
+		if handler == nil {
+			response.Error(w, errors.NotFoundf("resource is not available in API version %s", GetAPIVersion(r.Context())))
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Deprecated marks responses of a handler as deprecated (RFC 8594 / RFC 9745
+// headers). sunset is when the handler will be removed; successor, when set,
+// is linked as the replacement version.
+func Deprecated(next http.HandlerFunc, sunset time.Time, successor string) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// versionNumber returns N for "vN", or 0 when version is malformed
+func versionNumber(version string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package response
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// maxCollectionDepth bounds how far nested values are walked when
+// normalizing collections, guarding against cyclic pointers
+const maxCollectionDepth = 32
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// normalizeCollections returns data with nil slices and maps replaced by empty
+// ones, so list responses encode as [] and {} instead of null. Exported fields
+// of nested structs are normalized too; values reached through pointers and
+// slice elements are updated in place. Byte slices and types with their own
+// JSON marshaler are left untouched.
+func normalizeCollections(data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	value := reflect.ValueOf(data)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		if value.IsNil() {
+			if normalizable(value.Type()) {
+				return emptyCollection(value.Type()).Interface()
+			}
+			return data
+		}
+	case reflect.Struct:
+		// Copy so the fields are settable
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		normalizeValue(copied, 0)
+		return copied.Interface()
+	}
+
+	normalizeValue(value, 0)
+	return data
+}
+
+func normalizeValue(value reflect.Value, depth int) {
+	if depth > maxCollectionDepth {
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !value.IsNil() {
+			normalizeValue(value.Elem(), depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			normalizeValue(value.Index(i), depth+1)
+		}
+	case reflect.Struct:
+		if hasMarshaler(value.Type()) {
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			if !value.Type().Field(i).IsExported() {
+				continue
+			}
+			field := value.Field(i)
+			kind := field.Kind()
+			if (kind == reflect.Slice || kind == reflect.Map) && field.IsNil() {
+				if field.CanSet() && normalizable(field.Type()) {
+					field.Set(emptyCollection(field.Type()))
+				}
+				continue
+			}
+			normalizeValue(field, depth+1)
+		}
+	}
+}
+
+// normalizable reports whether a nil value of type t should be replaced
+func normalizable(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	return !hasMarshaler(t)
+}
+
+func hasMarshaler(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)
+}
+
+func emptyCollection(t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Map {
+		return reflect.MakeMap(t)
+	}
+	return reflect.MakeSlice(t, 0, 0)
+}
@@ -0,0 +1,53 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+// strictJSON controls whether DecodeJSON rejects unknown body fields (true)
+// or silently ignores them (false)
+var strictJSON bool
+
+// SetStrictJSON configures how DecodeJSON handles unknown request body fields
+func SetStrictJSON(strict bool) {
+	strictJSON = strict
+}
+
+// DecodeJSON decodes a JSON request body into dst. When strict decoding is
+// enabled (SERVER_STRICT_JSON), a field dst does not declare is a validation
+// error naming it, so client typos are not silently dropped. Pass any error
+// to InvalidBody.
+func DecodeJSON(r *http.Request, dst interface{}) error {
+	return decodeJSON(r, dst, strictJSON)
+}
+
+// DecodeJSONStrict is DecodeJSON with unknown fields always rejected, for
+// routes that opt in regardless of configuration
+func DecodeJSONStrict(r *http.Request, dst interface{}) error {
+	return decodeJSON(r, dst, true)
+}
+
+func decodeJSON(r *http.Request, dst interface{}, strict bool) error {
+	decoder := json.NewDecoder(r.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(dst)
+	if err == nil {
+		return nil
+	}
+
+	// encoding/json has no typed error for this case; the message is stable
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return errors.Validationf("unknown field %q in request body", field).
+			WithField(field, "unknown field")
+	}
+
+	return err
+}
@@ -0,0 +1,94 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+type decodeTarget struct {
+	Name string `json:"name"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		body       string
+		wantName   string
+		wantField  string
+		wantStatus int
+	}{
+		{"known fields decode", false, `{"name":"report"}`, "report", "", 0},
+		{"unknown fields ignored when lenient", false, `{"name":"report","nmae":"x"}`, "report", "", 0},
+		{"unknown field rejected when strict", true, `{"name":"report","nmae":"x"}`, "", "nmae", http.StatusBadRequest},
+		{"malformed body", false, `{"name":`, "", "", http.StatusBadRequest},
+		{"wrong type", true, `{"name":42}`, "", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := strictJSON
+			SetStrictJSON(tt.strict)
+			defer SetStrictJSON(prev)
+
+			var dst decodeTarget
+			err := DecodeJSON(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)), &dst)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("DecodeJSON(%s) error = %v", tt.body, err)
+				}
+				if dst.Name != tt.wantName {
+					t.Errorf("name = %q, want %q", dst.Name, tt.wantName)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("DecodeJSON(%s) succeeded, want an error", tt.body)
+			}
+			if tt.wantField != "" {
+				appErr := errors.FromError(err)
+				if appErr == nil || appErr.Fields[tt.wantField] == "" {
+					t.Errorf("error = %v, want a field error on %q", err, tt.wantField)
+				}
+			}
+			rec := httptest.NewRecorder()
+			InvalidBody(rec, err)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("InvalidBody status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONStrict(t *testing.T) {
+	prev := strictJSON
+	SetStrictJSON(false)
+	defer SetStrictJSON(prev)
+
+	var dst decodeTarget
+	err := DecodeJSONStrict(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"extra":true}`)), &dst)
+	if appErr := errors.FromError(err); appErr == nil || appErr.Fields["extra"] == "" {
+		t.Errorf("DecodeJSONStrict error = %v, want a field error on extra regardless of configuration", err)
+	}
+}
+
+func TestDecodeJSONBodyLimit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"`+strings.Repeat("a", 100)+`"}`))
+	rec := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(rec, req.Body, 16)
+
+	var dst decodeTarget
+	err := DecodeJSON(req, &dst)
+	if err == nil {
+		t.Fatal("DecodeJSON read past the body limit")
+	}
+
+	InvalidBody(rec, err)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("InvalidBody status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
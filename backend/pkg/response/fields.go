@@ -0,0 +1,163 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+// strictFields controls whether unknown names in ?fields= are rejected
+// (true) or silently ignored (false)
+var strictFields bool
+
+// selectableFields caches the JSON field allowlist per struct type
+var selectableFields sync.Map // reflect.Type -> map[string]bool
+
+// SetStrictFields configures how unknown names in ?fields= are handled
+func SetStrictFields(strict bool) {
+	strictFields = strict
+}
+
+// ParseFields reads the comma-separated ?fields= query parameter. It returns
+// nil when the parameter is absent, meaning all fields are returned.
+func ParseFields(r *http.Request) []string {
+	raw := r.URL.Query().Get("fields")
+	if raw == "" {
+		return nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ProjectFields applies the request's ?fields= selection to data, which must
+// be a struct, a pointer to a struct or a slice of either. Data is returned
+// unchanged when no fields are requested.
+func ProjectFields(r *http.Request, data interface{}) (interface{}, error) {
+	return Project(data, ParseFields(r), strictFields)
+}
+
+// Project reduces data to the given JSON fields. The allowlist is the set of
+// JSON names of the element struct, including embedded structs; fields tagged
+// json:"-" are never selectable. Unknown fields return a validation error when
+// strict is set and are ignored otherwise.
+func Project(data interface{}, fields []string, strict bool) (interface{}, error) {
+	if len(fields) == 0 || data == nil {
+		return data, nil
+	}
+	data = normalizeCollections(data)
+
+	value := reflect.ValueOf(data)
+	elemType := value.Type()
+	isList := elemType.Kind() == reflect.Slice
+	if isList {
+		elemType = elemType.Elem()
+	}
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return data, nil
+	}
+
+	allowed := jsonFieldNames(elemType)
+	selected := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !allowed[field] {
+			if strict {
+				return nil, errors.Validationf("unknown field in fields: %s", field)
+			}
+			continue
+		}
+		selected[field] = true
+	}
+
+	if !isList {
+		return projectOne(data, selected)
+	}
+
+	projected := make([]map[string]json.RawMessage, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		item, err := projectOne(value.Index(i).Interface(), selected)
+		if err != nil {
+			return nil, err
+		}
+		projected = append(projected, item)
+	}
+	return projected, nil
+}
+
+// projectOne marshals a single value and keeps only the selected keys, so
+// custom JSON marshalers and omitempty behave as they do in full responses
+func projectOne(item interface{}, selected map[string]bool) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return nil, errors.Internalf(err, "failed to encode response")
+	}
+
+	var full map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &full); err != nil {
+		return nil, errors.Internalf(err, "failed to encode response")
+	}
+
+	projected := make(map[string]json.RawMessage, len(selected))
+	for key, raw := range full {
+		if selected[key] {
+			projected[key] = raw
+		}
+	}
+	return projected, nil
+}
+
+// jsonFieldNames returns the JSON names a struct type exposes
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	if cached, ok := selectableFields.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+
+	names := make(map[string]bool)
+	collectJSONFieldNames(t, names)
+	selectableFields.Store(t, names)
+	return names
+}
+
+func collectJSONFieldNames(t reflect.Type, names map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened by encoding/json
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectJSONFieldNames(embedded, names)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+}
@@ -0,0 +1,99 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+type fieldsAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type fieldsItem struct {
+	fieldsAudit
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Tags     []string `json:"tags,omitempty"`
+	Password string   `json:"-"`
+	Size     int64
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"?fields=", nil},
+		{"?fields=id", []string{"id"}},
+		{"?fields=id,name", []string{"id", "name"}},
+		{"?fields=%20id%20,,name,", []string{"id", "name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := ParseFields(httptest.NewRequest(http.MethodGet, "/api/documents"+tt.query, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFields() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	item := fieldsItem{
+		fieldsAudit: fieldsAudit{CreatedBy: "user-1"},
+		ID:          "doc-1",
+		Name:        "report.pdf",
+		Password:    "secret",
+		Size:        42,
+	}
+
+	tests := []struct {
+		name     string
+		data     interface{}
+		fields   []string
+		strict   bool
+		want     string // JSON of the projection
+		wantCode errors.ErrorCode
+	}{
+		{"no fields returns everything", item, nil, false, `{"created_by":"user-1","id":"doc-1","name":"report.pdf","Size":42}`, ""},
+		{"selected fields of a struct", item, []string{"id", "name"}, false, `{"id":"doc-1","name":"report.pdf"}`, ""},
+		{"pointer to a struct", &item, []string{"id"}, false, `{"id":"doc-1"}`, ""},
+		{"embedded struct fields are selectable", item, []string{"created_by"}, false, `{"created_by":"user-1"}`, ""},
+		{"untagged fields use the Go name", item, []string{"Size"}, false, `{"Size":42}`, ""},
+		{"omitted empty field stays omitted", item, []string{"id", "tags"}, false, `{"id":"doc-1"}`, ""},
+		{"each element of a list", []fieldsItem{item, {ID: "doc-2"}}, []string{"id"}, false, `[{"id":"doc-1"},{"id":"doc-2"}]`, ""},
+		{"nil list becomes an empty list", []fieldsItem(nil), []string{"id"}, false, `[]`, ""},
+		{"unknown field ignored when lenient", item, []string{"id", "nope"}, false, `{"id":"doc-1"}`, ""},
+		{"unknown field rejected when strict", item, []string{"id", "nope"}, true, "", errors.ErrCodeValidation},
+		{"json:\"-\" field is never selectable", item, []string{"Password"}, true, "", errors.ErrCodeValidation},
+		{"non-struct data is returned unchanged", map[string]int{"total": 3}, []string{"id"}, true, `{"total":3}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Project(tt.data, tt.fields, tt.strict)
+			if tt.wantCode != "" {
+				if appErr := errors.FromError(err); appErr == nil || appErr.Code != tt.wantCode {
+					t.Fatalf("Project() error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Project() error = %v", err)
+			}
+
+			encoded, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("marshal projection: %v", err)
+			}
+			if string(encoded) != tt.want {
+				t.Errorf("Project() = %s, want %s", encoded, tt.want)
+			}
+		})
+	}
+}
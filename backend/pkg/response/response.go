@@ -2,12 +2,16 @@ package response
 
 import (
 	"encoding/json"
+	stderrors "errors"
+	"fmt"
 	"net/http"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 )
 
-// Response represents a standardized API response
+// Response represents a standardized API response. Every helper writes this
+// envelope: success responses always carry data (null when empty) and never
+// error; error responses carry error and never data. Pagination goes in meta.
 type Response struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
@@ -15,6 +19,32 @@ type Response struct {
 	Meta    *Meta       `json:"meta,omitempty"`
 }
 
+// successEnvelope and errorEnvelope are the two wire shapes of Response
+type successEnvelope struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+	Meta    *Meta       `json:"meta,omitempty"`
+}
+
+type errorEnvelope struct {
+	Success bool       `json:"success"`
+	Error   *ErrorData `json:"error"`
+	Meta    *Meta      `json:"meta,omitempty"`
+}
+
+// MarshalJSON encodes the response in the shape matching its outcome
+func (r Response) MarshalJSON() ([]byte, error) {
+	if r.Success {
+		return json.Marshal(successEnvelope{Success: true, Data: r.Data, Meta: r.Meta})
+	}
+
+	errData := r.Error
+	if errData == nil {
+		errData = &ErrorData{Code: errors.ErrCodeInternal, Message: "Internal server error"}
+	}
+	return json.Marshal(errorEnvelope{Success: false, Error: errData, Meta: r.Meta})
+}
+
 // ErrorData represents error information in the response
 type ErrorData struct {
 	Code    errors.ErrorCode   `json:"code"`
@@ -23,25 +53,33 @@ type ErrorData struct {
 	Meta    map[string]interface{} `json:"meta,omitempty"`
 }
 
-// Meta represents pagination and other metadata
+// Meta represents pagination metadata. All fields are always present so an
+// empty page reports total 0 rather than omitting it.
 type Meta struct {
-	Page       int   `json:"page,omitempty"`
-	Limit      int   `json:"limit,omitempty"`
-	Total      int64 `json:"total,omitempty"`
-	TotalPages int   `json:"total_pages,omitempty"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
 }
 
-// JSON writes a JSON response
+// JSON writes a JSON response. A non-2xx status is written as an error
+// envelope whose code matches the status, with data moved to
+// error.meta.details (e.g. a failed readiness report).
 func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	response := Response{
-		Success: statusCode >= 200 && statusCode < 300,
-		Data:    data,
+	if statusCode < 200 || statusCode >= 300 {
+		appErr := errors.New(codeForStatus(statusCode), http.StatusText(statusCode))
+		appErr.StatusCode = statusCode
+		if data != nil {
+			appErr.WithMeta("details", normalizeCollections(data))
+		}
+		Error(w, appErr)
+		return
 	}
 
-	_ = json.NewEncoder(w).Encode(response)
+	write(w, statusCode, Response{
+		Success: true,
+		Data:    normalizeCollections(data),
+	})
 }
 
 // Success writes a successful response
@@ -54,7 +92,8 @@ func Created(w http.ResponseWriter, data interface{}) {
 	JSON(w, http.StatusCreated, data)
 }
 
-// NoContent writes a 204 No Content response
+// NoContent writes a 204 No Content response. It is the only helper without
+// an envelope, as a 204 cannot carry a body.
 func NoContent(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -63,10 +102,7 @@ func NoContent(w http.ResponseWriter) {
 func Error(w http.ResponseWriter, err error) {
 	appErr := errors.FromError(err)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(appErr.StatusCode)
-
-	response := Response{
+	write(w, appErr.StatusCode, Response{
 		Success: false,
 		Error: &ErrorData{
 			Code:    appErr.Code,
@@ -74,25 +110,54 @@ func Error(w http.ResponseWriter, err error) {
 			Fields:  appErr.Fields,
 			Meta:    appErr.Meta,
 		},
-	}
-
-	_ = json.NewEncoder(w).Encode(response)
+	})
 }
 
 // WithMeta writes a response with pagination metadata
 func WithMeta(w http.ResponseWriter, data interface{}, meta *Meta) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-
-	response := Response{
+	write(w, http.StatusOK, Response{
 		Success: true,
-		Data:    data,
+		Data:    normalizeCollections(data),
 		Meta:    meta,
-	}
+	})
+}
+
+// write encodes the envelope with the given status
+func write(w http.ResponseWriter, statusCode int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 
 	_ = json.NewEncoder(w).Encode(response)
 }
 
+// codeForStatus maps an HTTP status to the error code reported for it
+func codeForStatus(statusCode int) errors.ErrorCode {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return errors.ErrCodeBadRequest
+	case http.StatusNotFound:
+		return errors.ErrCodeNotFound
+	case http.StatusUnauthorized:
+		return errors.ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return errors.ErrCodeForbidden
+	case http.StatusConflict:
+		return errors.ErrCodeConflict
+	case http.StatusTooManyRequests:
+		return errors.ErrCodeRateLimited
+	case http.StatusRequestEntityTooLarge:
+		return errors.ErrCodeTooLarge
+	case http.StatusNotAcceptable:
+		return errors.ErrCodeNotAcceptable
+	case http.StatusServiceUnavailable:
+		return errors.ErrCodeUnavailable
+	}
+	if statusCode >= 400 && statusCode < 500 {
+		return errors.ErrCodeBadRequest
+	}
+	return errors.ErrCodeInternal
+}
+
 // Paginated writes a paginated response
 func Paginated(w http.ResponseWriter, data interface{}, page, limit int, total int64) {
 	totalPages := int(total) / limit
@@ -115,12 +180,29 @@ func BadRequest(w http.ResponseWriter, message string) {
 	Error(w, errors.New(errors.ErrCodeBadRequest, message))
 }
 
+// InvalidBody writes the response for a request body that failed to decode:
+// 413 when the body exceeded the size limit, the error itself when DecodeJSON
+// rejected an unknown field, 400 otherwise
+func InvalidBody(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if stderrors.As(err, &maxBytesErr) {
+		Error(w, errors.New(errors.ErrCodeTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit)))
+		return
+	}
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		Error(w, appErr)
+		return
+	}
+	BadRequest(w, "invalid request body")
+}
+
 // NotFound writes a 404 Not Found response
 func NotFound(w http.ResponseWriter, message string) {
 	if message == "" {
 		message = "Resource not found"
 	}
-	Error(w, errors.NotFoundf(message))
+	Error(w, errors.NotFoundf("%s", message))
 }
 
 // Unauthorized writes a 401 Unauthorized response
@@ -128,7 +210,7 @@ func Unauthorized(w http.ResponseWriter, message string) {
 	if message == "" {
 		message = "Authentication required"
 	}
-	Error(w, errors.Unauthorizedf(message))
+	Error(w, errors.Unauthorizedf("%s", message))
 }
 
 // Forbidden writes a 403 Forbidden response
@@ -136,12 +218,12 @@ func Forbidden(w http.ResponseWriter, message string) {
 	if message == "" {
 		message = "Access denied"
 	}
-	Error(w, errors.Forbiddenf(message))
+	Error(w, errors.Forbiddenf("%s", message))
 }
 
 // Conflict writes a 409 Conflict response
 func Conflict(w http.ResponseWriter, message string) {
-	Error(w, errors.Conflictf(message))
+	Error(w, errors.Conflictf("%s", message))
 }
 
 // InternalServerError writes a 500 Internal Server Error response
@@ -149,7 +231,7 @@ func InternalServerError(w http.ResponseWriter, message string) {
 	if message == "" {
 		message = "Internal server error"
 	}
-	Error(w, errors.Internalf(nil, message))
+	Error(w, errors.Internalf(nil, "%s", message))
 }
 
 // ValidationError writes a validation error response
@@ -157,7 +239,7 @@ func ValidationError(w http.ResponseWriter, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
 		Error(w, appErr)
 	} else {
-		Error(w, errors.Validationf(err.Error()))
+		Error(w, errors.Validationf("%s", err.Error()))
 	}
 }
 
@@ -0,0 +1,162 @@
+package response
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+func TestEnvelope(t *testing.T) {
+	type item struct {
+		ID string `json:"id"`
+	}
+
+	tests := []struct {
+		name       string
+		write      func(w http.ResponseWriter)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "success with data",
+			write:      func(w http.ResponseWriter) { Success(w, item{ID: "doc-1"}) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"success":true,"data":{"id":"doc-1"}}`,
+		},
+		{
+			name:       "success without data keeps a null data",
+			write:      func(w http.ResponseWriter) { Success(w, nil) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"success":true,"data":null}`,
+		},
+		{
+			name:       "nil list encodes as an empty list",
+			write:      func(w http.ResponseWriter) { Success(w, []item(nil)) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"success":true,"data":[]}`,
+		},
+		{
+			name:       "created",
+			write:      func(w http.ResponseWriter) { Created(w, item{ID: "doc-1"}) },
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"success":true,"data":{"id":"doc-1"}}`,
+		},
+		{
+			name:       "paginated carries all meta fields",
+			write:      func(w http.ResponseWriter) { Paginated(w, []item{{ID: "doc-1"}}, 3, 10, 25) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"success":true,"data":[{"id":"doc-1"}],"meta":{"page":3,"limit":10,"total":25,"total_pages":3}}`,
+		},
+		{
+			name:       "empty page reports total 0",
+			write:      func(w http.ResponseWriter) { Paginated(w, []item(nil), 1, 20, 0) },
+			wantStatus: http.StatusOK,
+			wantBody:   `{"success":true,"data":[],"meta":{"page":1,"limit":20,"total":0,"total_pages":0}}`,
+		},
+		{
+			name: "error carries fields and meta but no data",
+			write: func(w http.ResponseWriter) {
+				Error(w, errors.Validationf("invalid request").WithField("name", "name is required").WithMeta("limit", 5))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"success":false,"error":{"code":"VALIDATION_ERROR","message":"invalid request","fields":{"name":"name is required"},"meta":{"limit":5}}}`,
+		},
+		{
+			name:       "plain errors are internal errors",
+			write:      func(w http.ResponseWriter) { Error(w, stderrors.New("boom")) },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"success":false,"error":{"code":"INTERNAL_ERROR","message":"An unexpected error occurred"}}`,
+		},
+		{
+			name: "non-2xx JSON becomes an error with details",
+			write: func(w http.ResponseWriter) {
+				JSON(w, http.StatusServiceUnavailable, map[string]string{"database": "down"})
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"success":false,"error":{"code":"SERVICE_UNAVAILABLE","message":"Service Unavailable","meta":{"details":{"database":"down"}}}}`,
+		},
+		{
+			name:       "not found default message",
+			write:      func(w http.ResponseWriter) { NotFound(w, "") },
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"success":false,"error":{"code":"NOT_FOUND","message":"Resource not found"}}`,
+		},
+		{
+			name:       "messages are not format strings",
+			write:      func(w http.ResponseWriter) { Conflict(w, "100% taken") },
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"success":false,"error":{"code":"CONFLICT","message":"100% taken"}}`,
+		},
+		{
+			name:       "validation error from a plain error",
+			write:      func(w http.ResponseWriter) { ValidationError(w, stderrors.New("bad input")) },
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"success":false,"error":{"code":"VALIDATION_ERROR","message":"bad input"}}`,
+		},
+		{
+			name:       "oversized body",
+			write:      func(w http.ResponseWriter) { InvalidBody(w, &http.MaxBytesError{Limit: 1024}) },
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantBody:   `{"success":false,"error":{"code":"PAYLOAD_TOO_LARGE","message":"request body exceeds 1024 bytes"}}`,
+		},
+		{
+			name:       "malformed body",
+			write:      func(w http.ResponseWriter) { InvalidBody(w, stderrors.New("unexpected EOF")) },
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"success":false,"error":{"code":"BAD_REQUEST","message":"invalid request body"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.write(rec)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s\nwant %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNoContentHasNoEnvelope(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NoContent(rec)
+
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("NoContent() = %d with %d body bytes, want 204 and no body", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestResponseMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response Response
+		want     string
+	}{
+		{"success drops error", Response{Success: true, Data: 1, Error: &ErrorData{Code: errors.ErrCodeConflict}}, `{"success":true,"data":1}`},
+		{"error drops data", Response{Data: 1, Error: &ErrorData{Code: errors.ErrCodeConflict, Message: "taken"}}, `{"success":false,"error":{"code":"CONFLICT","message":"taken"}}`},
+		{"error without details is internal", Response{}, `{"success":false,"error":{"code":"INTERNAL_ERROR","message":"Internal server error"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.response)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
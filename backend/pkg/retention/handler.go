@@ -0,0 +1,29 @@
+package retention
+
+import (
+	"net/http"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+)
+
+// Handler serves the manual purge endpoint of a service's purger
+type Handler struct {
+	purger *Purger
+}
+
+// NewHandler creates the purge endpoint for a purger
+func NewHandler(purger *Purger) *Handler {
+	return &Handler{purger: purger}
+}
+
+// Purge handles POST /api/retention/purge. A purge cut short by the request
+// deadline reports what it deleted with complete=false.
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	result, err := h.purger.Run(r.Context())
+	if err != nil && (result == nil || r.Context().Err() == nil) {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
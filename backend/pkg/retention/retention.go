@@ -0,0 +1,170 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// purgeRunTimeout bounds a scheduled purge; the next run picks up the rest
+const purgeRunTimeout = 10 * time.Minute
+
+// PurgeFunc deletes at most limit expired rows and returns how many it deleted
+type PurgeFunc func(ctx context.Context, limit int) (int64, error)
+
+// Table is an audit table purged by a Purger
+type Table struct {
+	Name  string // e.g. "usage_logs"
+	Purge PurgeFunc
+}
+
+// Result reports the rows deleted per table by one purge. Complete is false
+// when the run stopped early (deadline, shutdown or error); the next run
+// continues where it left off.
+type Result struct {
+	Deleted  map[string]int64 `json:"deleted"`
+	Complete bool             `json:"complete"`
+}
+
+// Purger deletes expired audit rows in batches, on a schedule and on demand.
+// Each batch is its own statement so no purge holds locks for long.
+type Purger struct {
+	tables    []Table
+	batchSize int
+	logger    *zap.Logger
+	running   sync.Mutex // one purge at a time
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// New creates a purger for tables and starts the scheduled purge every
+// cfg.PurgeInterval until Close is called
+func New(cfg config.RetentionConfig, logger *zap.Logger, tables ...Table) *Purger {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Purger{
+		tables:    tables,
+		batchSize: max(cfg.BatchSize, 1),
+		logger:    logger,
+		ctx:       ctx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	if cfg.PurgeInterval > 0 {
+		go p.schedule(cfg.PurgeInterval)
+	} else {
+		close(p.done)
+	}
+
+	return p
+}
+
+// Close stops the scheduled purge, interrupting a run in progress between
+// batches, and waits for it to return; call after the server stops
+// accepting requests
+func (p *Purger) Close() {
+	p.cancel()
+	<-p.done
+}
+
+// Run purges every table until no expired rows are left or ctx ends. It
+// returns a conflict error without a result while another purge is running.
+func (p *Purger) Run(ctx context.Context) (*Result, error) {
+	if !p.running.TryLock() {
+		return nil, errors.Conflictf("a retention purge is already running")
+	}
+	defer p.running.Unlock()
+
+	result := &Result{Deleted: make(map[string]int64, len(p.tables))}
+	for _, table := range p.tables {
+		deleted, err := p.purgeTable(ctx, table)
+		result.Deleted[table.Name] = deleted
+		if err != nil {
+			return result, fmt.Errorf("purge %s: %w", table.Name, err)
+		}
+	}
+
+	result.Complete = true
+	return result, nil
+}
+
+// purgeTable deletes batches until one comes back short
+func (p *Purger) purgeTable(ctx context.Context, table Table) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		deleted, err := table.Purge(ctx, p.batchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < int64(p.batchSize) {
+			return total, nil
+		}
+	}
+}
+
+func (p *Purger) schedule(interval time.Duration) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.runScheduled()
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Purger) runScheduled() {
+	ctx, cancel := context.WithTimeout(p.ctx, purgeRunTimeout)
+	defer cancel()
+
+	result, err := p.Run(ctx)
+	if result == nil {
+		p.logger.Info("audit retention purge skipped", zap.Error(err))
+		return
+	}
+	if err != nil {
+		p.logger.Warn("audit retention purge stopped early", zap.Any("deleted", result.Deleted), zap.Error(err))
+		return
+	}
+	p.logger.Info("audit retention purge completed", zap.Any("deleted", result.Deleted))
+}
+
+// Window converts a default retention window to the seconds parameter of
+// CutoffSQL; zero (keep forever) becomes NULL
+func Window(d time.Duration) sql.NullInt64 {
+	if d <= 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+}
+
+// CutoffSQL returns the SQL timestamp before which a row has expired: the
+// default window passed as the seconds parameter $param (see Window), or the
+// tenant's setting when it is longer. The setting is the settingKey row of
+// tenant_settings, in whole days, for the tenant in tenantColumn; a tenant
+// can lengthen its retention but never shorten it. A NULL default (keep
+// forever) gives a NULL cutoff, which matches no rows.
+func CutoffSQL(tenantColumn, settingKey string, param int) string {
+	setting := fmt.Sprintf("(SELECT value #>> '{}' FROM tenant_settings WHERE tenant_id = %s AND key = '%s')", tenantColumn, settingKey)
+	return fmt.Sprintf(
+		"NOW() - CASE WHEN $%[2]d::bigint IS NOT NULL THEN GREATEST($%[2]d::bigint * INTERVAL '1 second', CASE WHEN %[1]s ~ '^[1-9][0-9]{0,5}$' THEN make_interval(days => (%[1]s)::int) END) END",
+		setting, param,
+	)
+}
@@ -0,0 +1,80 @@
+package retention
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want sql.NullInt64
+	}{
+		{"zero keeps forever", 0, sql.NullInt64{}},
+		{"negative keeps forever", -time.Hour, sql.NullInt64{}},
+		{"days in seconds", 90 * 24 * time.Hour, sql.NullInt64{Int64: 90 * 24 * 3600, Valid: true}},
+		{"sub-second part is dropped", 1500 * time.Millisecond, sql.NullInt64{Int64: 1, Valid: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Window(tt.d); got != tt.want {
+				t.Errorf("Window(%v) = %+v, want %+v", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCutoffSQL(t *testing.T) {
+	tests := []struct {
+		name         string
+		tenantColumn string
+		settingKey   string
+		param        int
+		want         []string
+	}{
+		{
+			name:         "reads the tenant's setting row",
+			tenantColumn: "s.tenant_id",
+			settingKey:   "share_access_retention_days",
+			param:        1,
+			want: []string{
+				"FROM tenant_settings WHERE tenant_id = s.tenant_id AND key = 'share_access_retention_days'",
+				"value #>> '{}'",
+			},
+		},
+		{
+			name:         "uses the given parameter for the default window",
+			tenantColumn: "l.tenant_id",
+			settingKey:   "usage_logs_retention_days",
+			param:        3,
+			want:         []string{"WHEN $3::bigint IS NOT NULL", "GREATEST($3::bigint * INTERVAL '1 second'"},
+		},
+		{
+			name:         "only whole positive days are honoured",
+			tenantColumn: "d.tenant_id",
+			settingKey:   "permission_decisions_retention_days",
+			param:        1,
+			want:         []string{"~ '^[1-9][0-9]{0,5}$' THEN make_interval(days =>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CutoffSQL(tt.tenantColumn, tt.settingKey, tt.param)
+			if !strings.HasPrefix(got, "NOW() - CASE WHEN ") {
+				t.Errorf("CutoffSQL() = %q, want a cutoff relative to NOW()", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("CutoffSQL() = %q, missing %q", got, want)
+				}
+			}
+			// A tenant setting may lengthen the window but never shorten it
+			if strings.Contains(got, "LEAST(") || strings.Contains(got, "COALESCE(") {
+				t.Errorf("CutoffSQL() = %q lets the tenant setting replace the default", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package startup
+
+import (
+	"context"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"go.uber.org/zap"
+)
+
+// AttemptTimeout bounds each attempt so a hung dependency cannot block startup
+const AttemptTimeout = 5 * time.Second
+
+// Retry calls fn until it succeeds, the configured attempts are used up or ctx
+// is done. Each attempt runs with its own AttemptTimeout deadline. The wait
+// between attempts doubles from ConnectInterval up to ConnectMaxInterval. The
+// last error is returned when all attempts fail.
+func Retry(ctx context.Context, cfg config.StartupConfig, logger *zap.Logger, name string, fn func(context.Context) error) error {
+	attempts := cfg.ConnectMaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	interval := cfg.ConnectInterval
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, AttemptTimeout)
+		err = fn(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		if logger != nil {
+			logger.Warn("dependency not ready, retrying",
+				zap.String("dependency", name),
+				zap.Int("attempt", attempt),
+				zap.Int("max_attempts", attempts),
+				zap.Duration("retry_in", interval),
+				zap.Error(err),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if cfg.ConnectMaxInterval > 0 && interval > cfg.ConnectMaxInterval {
+			interval = cfg.ConnectMaxInterval
+		}
+	}
+
+	return err
+}
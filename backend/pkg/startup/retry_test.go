@@ -0,0 +1,52 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+)
+
+func TestRetry(t *testing.T) {
+	errNotReady := errors.New("not ready")
+	cfg := config.StartupConfig{
+		ConnectMaxAttempts: 3,
+		ConnectInterval:    time.Millisecond,
+		ConnectMaxInterval: 2 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantErr      error
+		wantAttempts int
+	}{
+		{"succeeds first time", 0, nil, 1},
+		{"succeeds after failures", 2, nil, 3},
+		{"returns last error when attempts are used up", 5, errNotReady, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Retry(context.Background(), cfg, nil, "test", func(ctx context.Context) error {
+				attempts++
+				deadline, ok := ctx.Deadline()
+				if !ok || time.Until(deadline) > AttemptTimeout {
+					t.Errorf("attempt %d deadline = %v (set %v), want within %v", attempts, deadline, ok, AttemptTimeout)
+				}
+				if attempts <= tt.failures {
+					return errNotReady
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Retry() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package timeutil
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Layout is the wire format for all API timestamps: RFC3339 in UTC with
+// second precision (e.g. 2024-01-02T15:04:05Z)
+const Layout = time.RFC3339
+
+// Time is a time.Time that serializes to JSON in UTC with second precision
+type Time struct {
+	time.Time
+}
+
+// NullTime is a nullable Time; it serializes to JSON null when not valid
+type NullTime struct {
+	sql.NullTime
+}
+
+// Now returns the current time as a Time
+func Now() Time {
+	return From(time.Now())
+}
+
+// From wraps a time.Time
+func From(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// NullFrom wraps a time.Time as a valid NullTime
+func NullFrom(t time.Time) NullTime {
+	return NullTime{NullTime: sql.NullTime{Time: t, Valid: true}}
+}
+
+// Format renders a time in the API wire format
+func Format(t time.Time) string {
+	return t.UTC().Truncate(time.Second).Format(Layout)
+}
+
+// Parse parses an RFC3339 timestamp and normalizes it to UTC
+func Parse(value string) (time.Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.UTC(), nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Format(t.Time))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("timestamp must be a string: %w", err)
+	}
+	parsed, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner
+func (t *Time) Scan(src interface{}) error {
+	var nt sql.NullTime
+	if err := nt.Scan(src); err != nil {
+		return err
+	}
+	t.Time = nt.Time
+	return nil
+}
+
+// Value implements driver.Valuer
+func (t Time) Value() (driver.Value, error) {
+	return t.Time, nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (t NullTime) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(Format(t.Time))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *NullTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		t.NullTime = sql.NullTime{}
+		return nil
+	}
+	var value Time
+	if err := value.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	t.NullTime = sql.NullTime{Time: value.Time, Valid: true}
+	return nil
+}
@@ -0,0 +1,139 @@
+package timeutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimeMarshalJSON(t *testing.T) {
+	paris := time.FixedZone("CET", 3600)
+
+	tests := []struct {
+		name string
+		in   time.Time
+		want string
+	}{
+		{"UTC", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), `"2024-01-02T15:04:05Z"`},
+		{"sub-second part is truncated", time.Date(2024, 1, 2, 15, 4, 5, 999999999, time.UTC), `"2024-01-02T15:04:05Z"`},
+		{"non-UTC input is normalized", time.Date(2024, 1, 2, 16, 4, 5, 0, paris), `"2024-01-02T15:04:05Z"`},
+		{"normalization crosses midnight", time.Date(2024, 1, 1, 0, 30, 0, 0, paris), `"2023-12-31T23:30:00Z"`},
+		{"zero time", time.Time{}, `"0001-01-01T00:00:00Z"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(From(tt.in))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal(%v) = %s, want %s", tt.in, got, tt.want)
+			}
+
+			nullGot, err := json.Marshal(NullFrom(tt.in))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(nullGot) != tt.want {
+				t.Errorf("Marshal(NullFrom(%v)) = %s, want %s", tt.in, nullGot, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"UTC", `"2024-01-02T15:04:05Z"`, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), false},
+		{"offset is normalized to UTC", `"2024-01-02T17:04:05+02:00"`, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), false},
+		{"fractional seconds are kept", `"2024-01-02T15:04:05.25Z"`, time.Date(2024, 1, 2, 15, 4, 5, 250000000, time.UTC), false},
+		{"missing zone", `"2024-01-02T15:04:05"`, time.Time{}, true},
+		{"unix seconds", `1704207845`, time.Time{}, true},
+		{"null is not a Time", `null`, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Time
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %v, want an error", tt.in, got.Time)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("Unmarshal(%s) = %v, want %v in UTC", tt.in, got.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullTimeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   NullTime
+		json string
+	}{
+		{"null", NullTime{}, `null`},
+		{"valid", NullFrom(time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)), `"2024-01-02T15:04:05Z"`},
+		{"valid non-UTC", NullFrom(time.Date(2024, 1, 2, 10, 4, 5, 0, time.FixedZone("EST", -5*3600))), `"2024-01-02T15:04:05Z"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.in)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.json {
+				t.Fatalf("Marshal(%v) = %s, want %s", tt.in, data, tt.json)
+			}
+
+			// Decoding into a set value must clear it for null
+			got := NullFrom(time.Now())
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", data, err)
+			}
+			if got.Valid != tt.in.Valid || (got.Valid && !got.Time.Equal(tt.in.Time)) {
+				t.Errorf("round trip of %s = %+v, want %+v", data, got, tt.in)
+			}
+		})
+	}
+}
+
+func TestTimeScan(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	var got Time
+	if err := got.Scan(at); err != nil || !got.Equal(at) {
+		t.Errorf("Scan(%v) = (%v, %v), want %v", at, got.Time, err, at)
+	}
+	if err := got.Scan(nil); err != nil || !got.IsZero() {
+		t.Errorf("Scan(nil) = (%v, %v), want the zero time", got.Time, err)
+	}
+	if value, err := From(at).Value(); err != nil || value != at {
+		t.Errorf("Value() = (%v, %v), want %v", value, err, at)
+	}
+}
+
+func TestFormatAndParse(t *testing.T) {
+	in := time.Date(2024, 6, 30, 23, 59, 59, 500000000, time.FixedZone("UTC+2", 2*3600))
+
+	formatted := Format(in)
+	if formatted != "2024-06-30T21:59:59Z" {
+		t.Fatalf("Format(%v) = %q, want 2024-06-30T21:59:59Z", in, formatted)
+	}
+	parsed, err := Parse(formatted)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", formatted, err)
+	}
+	if !parsed.Equal(in.Truncate(time.Second)) || parsed.Location() != time.UTC {
+		t.Errorf("Parse(%q) = %v, want %v in UTC", formatted, parsed, in.Truncate(time.Second))
+	}
+}
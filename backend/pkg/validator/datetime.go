@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+)
+
+// ParseTimeParam parses an optional RFC3339 timestamp from a query parameter
+// or request field, normalized to UTC. An empty value is not an error and
+// reports ok=false; a malformed one is a validation error on field.
+func ParseTimeParam(field, value string) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	parsed, err := timeutil.Parse(value)
+	if err != nil {
+		return time.Time{}, false, errors.Validationf("invalid %s", field).WithField(field, rfc3339Message(field))
+	}
+
+	return parsed, true, nil
+}
+
+// validateRFC3339 backs the rfc3339 tag, accepting exactly what
+// ParseTimeParam accepts
+func validateRFC3339(fl validator.FieldLevel) bool {
+	value := fl.Field().String()
+	if value == "" {
+		return true // Allow empty, use 'required' tag for non-empty
+	}
+	_, err := timeutil.Parse(value)
+	return err == nil
+}
+
+func rfc3339Message(field string) string {
+	return field + " must be an RFC3339 timestamp, e.g. 2024-01-02T15:04:05Z"
+}
@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+func TestParseTimeParam(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantOK  bool
+		wantErr bool
+	}{
+		{"empty is not an error", "", time.Time{}, false, false},
+		{"UTC timestamp", "2024-01-02T15:04:05Z", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), true, false},
+		{"offset is normalized to UTC", "2024-01-02T17:04:05+02:00", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), true, false},
+		{"fractional seconds", "2024-01-02T15:04:05.5Z", time.Date(2024, 1, 2, 15, 4, 5, 500000000, time.UTC), true, false},
+		{"date only", "2024-01-02", time.Time{}, false, true},
+		{"missing zone", "2024-01-02T15:04:05", time.Time{}, false, true},
+		{"unix seconds", "1704207845", time.Time{}, false, true},
+		{"out of range day", "2024-02-30T00:00:00Z", time.Time{}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := ParseTimeParam("since", tt.value)
+			if tt.wantErr {
+				appErr := errors.FromError(err)
+				if appErr == nil || appErr.Code != errors.ErrCodeValidation || appErr.Fields["since"] == "" {
+					t.Fatalf("ParseTimeParam(%q) error = %v, want a validation error on since", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTimeParam(%q) error = %v", tt.value, err)
+			}
+			if ok != tt.wantOK || !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("ParseTimeParam(%q) = (%v, %v), want (%v, %v) in UTC", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+
+			// The rfc3339 tag accepts exactly what ParseTimeParam accepts
+			if err := New().ValidateVar(tt.value, "rfc3339"); err != nil {
+				t.Errorf("rfc3339 tag rejected %q", tt.value)
+			}
+		})
+	}
+
+	if err := New().ValidateVar("2024-01-02", "rfc3339"); err == nil {
+		t.Error("rfc3339 tag accepted a date without a time")
+	}
+}
@@ -0,0 +1,79 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Joiners are invisible but meaningful inside emoji sequences and scripts
+// such as Persian and Devanagari, so they survive between visible characters
+const (
+	zeroWidthNonJoiner = '\u200c'
+	zeroWidthJoiner    = '\u200d'
+)
+
+// SanitizeName normalizes a user-supplied display name: it applies NFC
+// normalization, drops control and invisible format characters (zero-width
+// spaces, BOMs, bidi overrides), collapses whitespace runs to a single space
+// and trims the result. Non-ASCII letters are preserved.
+func SanitizeName(name string) string {
+	runes := []rune(norm.NFC.String(name))
+
+	var b strings.Builder
+	b.Grow(len(name))
+	pendingSpace := false
+	for i, r := range runes {
+		switch {
+		case unicode.IsSpace(r):
+			pendingSpace = b.Len() > 0
+			continue
+		case r == zeroWidthNonJoiner || r == zeroWidthJoiner:
+			if !joinsVisible(runes, i) {
+				continue
+			}
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			continue
+		}
+
+		if pendingSpace {
+			b.WriteByte(' ')
+			pendingSpace = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// CleanName sanitizes a name and checks it is non-empty and at most maxLen
+// characters, mirroring the min=1,max=N validation tags
+func CleanName(field, name string, maxLen int) (string, error) {
+	cleaned := SanitizeName(name)
+	if cleaned == "" {
+		return "", errors.Validationf("%s is required", field).
+			WithField(field, field+" is required")
+	}
+	if utf8.RuneCountInString(cleaned) > maxLen {
+		message := fmt.Sprintf("%s must be at most %d characters", field, maxLen)
+		return "", errors.Validationf("%s", message).WithField(field, message)
+	}
+	return cleaned, nil
+}
+
+// joinsVisible reports whether the joiner at i sits between two visible
+// characters
+func joinsVisible(runes []rune, i int) bool {
+	if i == 0 || i == len(runes)-1 {
+		return false
+	}
+	return isVisible(runes[i-1]) && isVisible(runes[i+1])
+}
+
+func isVisible(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsControl(r) && !unicode.Is(unicode.Cf, r)
+}
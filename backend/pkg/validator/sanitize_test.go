@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain name is unchanged", "Quarterly report", "Quarterly report"},
+		{"surrounding whitespace is trimmed", "  report \t", "report"},
+		{"whitespace runs collapse", "annual\t\n  report", "annual report"},
+		{"decomposed accents are composed", "Cafe\u0301", "Caf\u00e9"},
+		{"non-ASCII letters are kept", "R\u00e9sum\u00e9 履歴書 Отчёт", "R\u00e9sum\u00e9 履歴書 Отчёт"},
+		{"control characters are dropped", "re\x00po\x07rt", "report"},
+		{"zero-width space is dropped", "re\u200bport", "report"},
+		{"byte order mark is dropped", "\ufeffreport", "report"},
+		{"bidi override is dropped", "invoice\u202egpj.exe", "invoicegpj.exe"},
+		{"joiner inside an emoji sequence is kept", "team \U0001F468\u200d\U0001F469", "team \U0001F468\u200d\U0001F469"},
+		{"non-joiner inside a word is kept", "می\u200cخواهم", "می\u200cخواهم"},
+		{"leading and trailing joiners are dropped", "\u200dreport\u200c", "report"},
+		{"joiner next to a space is dropped", "report \u200dfinal", "report final"},
+		{"only invisible characters become empty", " \u200b\ufeff\t", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeName(tt.input); got != tt.want {
+				t.Errorf("SanitizeName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		maxLen  int
+		want    string
+		wantErr string // field error message; empty when valid
+	}{
+		{"valid name is sanitized", "  Contracts\u200b 2024 ", 20, "Contracts 2024", ""},
+		{"length counts characters, not bytes", "履歴書", 3, "履歴書", ""},
+		{"length is checked after sanitizing", "  abc  ", 3, "abc", ""},
+		{"empty name is required", "", 10, "", "name is required"},
+		{"invisible-only name is required", "\u200b \ufeff", 10, "", "name is required"},
+		{"too long name is rejected", "abcd", 3, "", "name must be at most 3 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanName("name", tt.input, tt.maxLen)
+			if tt.wantErr == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("CleanName(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+				}
+				return
+			}
+
+			appErr := errors.FromError(err)
+			if appErr == nil || appErr.Code != errors.ErrCodeValidation {
+				t.Fatalf("CleanName(%q) error = %v, want a validation error", tt.input, err)
+			}
+			if appErr.Fields["name"] != tt.wantErr {
+				t.Errorf("CleanName(%q) field error = %q, want %q", tt.input, appErr.Fields["name"], tt.wantErr)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ func New() *Validator {
 	_ = v.RegisterValidation("uuid", validateUUID)
 	_ = v.RegisterValidation("file_type", validateFileType)
 	_ = v.RegisterValidation("alpha_space", validateAlphaSpace)
+	_ = v.RegisterValidation("rfc3339", validateRFC3339)
 
 	return &Validator{
 		validate: v,
@@ -103,6 +104,8 @@ func formatFieldError(field, tag, param string) string {
 		return fmt.Sprintf("%s must be numeric", field)
 	case "alphanum":
 		return fmt.Sprintf("%s can only contain letters and numbers", field)
+	case "rfc3339":
+		return rfc3339Message(field)
 	default:
 		return fmt.Sprintf("%s failed validation: %s", field, tag)
 	}
@@ -0,0 +1,21 @@
+package version
+
+import "time"
+
+// Commit is the git commit the binary was built from, injected at build time:
+//
+//	go build -ldflags "-X github.com/SidahmedSeg/document-manager/backend/pkg/version.Commit=$(git rev-parse --short HEAD)"
+var Commit = "unknown"
+
+// startedAt approximates process start; the package is initialized before main runs
+var startedAt = time.Now()
+
+// StartedAt returns when the process started
+func StartedAt() time.Time {
+	return startedAt
+}
+
+// Uptime returns how long the process has been running
+func Uptime() time.Duration {
+	return time.Since(startedAt)
+}
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"go.uber.org/zap"
+)
+
+// DeadLetter is a job that failed on every attempt. It keeps the original
+// payload so it can be requeued once the cause is fixed.
+type DeadLetter struct {
+	ID         uuid.UUID         `json:"id" db:"id"`
+	Service    string            `json:"service" db:"service"`
+	JobType    string            `json:"job_type" db:"job_type"`
+	TenantID   string            `json:"tenant_id,omitempty" db:"tenant_id"`
+	RequestID  string            `json:"request_id,omitempty" db:"request_id"`
+	Payload    json.RawMessage   `json:"payload" db:"payload"`
+	Attempts   int               `json:"attempts" db:"attempts"`
+	LastError  string            `json:"last_error" db:"last_error"`
+	FailedAt   timeutil.Time     `json:"failed_at" db:"failed_at"`
+	RequeuedAt timeutil.NullTime `json:"requeued_at,omitempty" db:"requeued_at"`
+}
+
+// ListDeadLettersParams filters the dead-letter view
+type ListDeadLettersParams struct {
+	JobType  string `json:"job_type"`
+	TenantID string `json:"tenant_id" validate:"omitempty,uuid"`
+	Requeued bool   `json:"requeued"` // include jobs that were already requeued
+	Page     int    `json:"page" validate:"omitempty,min=1"`
+	Limit    int    `json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// Normalize sets default values for list parameters
+func (p *ListDeadLettersParams) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = 20
+	}
+	if p.Limit > 100 {
+		p.Limit = 100
+	}
+}
+
+// Store persists dead-lettered jobs in the shared dead_letter_jobs table.
+// Each service only sees its own rows.
+type Store struct {
+	db      *database.DB
+	service string
+	logger  *zap.Logger
+}
+
+// NewStore creates a dead-letter store for the named service
+func NewStore(db *database.DB, service string, logger *zap.Logger) *Store {
+	return &Store{
+		db:      db,
+		service: service,
+		logger:  logger,
+	}
+}
+
+const deadLetterColumns = `id, service, job_type, COALESCE(tenant_id::text, ''), COALESCE(request_id, ''),
+	payload, attempts, last_error, failed_at, requeued_at`
+
+// Save records an exhausted job
+func (s *Store) Save(ctx context.Context, dl *DeadLetter) error {
+	query := `
+		INSERT INTO dead_letter_jobs (id, service, job_type, tenant_id, request_id, payload, attempts, last_error, failed_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, ''), $6, $7, $8, $9)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		dl.ID, s.service, dl.JobType, dl.TenantID, dl.RequestID,
+		[]byte(dl.Payload), dl.Attempts, dl.LastError, dl.FailedAt,
+	)
+	if err != nil {
+		s.logger.Error("failed to save dead-letter job", zap.String("job_type", dl.JobType), zap.Error(err))
+		return errors.New(errors.ErrCodeInternal, "failed to save dead-letter job")
+	}
+
+	return nil
+}
+
+// List returns the service's dead-lettered jobs, newest first
+func (s *Store) List(ctx context.Context, params *ListDeadLettersParams) ([]DeadLetter, int64, error) {
+	where := "WHERE service = $1"
+	args := []interface{}{s.service}
+
+	if params.JobType != "" {
+		args = append(args, params.JobType)
+		where += fmt.Sprintf(" AND job_type = $%d", len(args))
+	}
+	if params.TenantID != "" {
+		args = append(args, params.TenantID)
+		where += fmt.Sprintf(" AND tenant_id::text = $%d", len(args))
+	}
+	if !params.Requeued {
+		where += " AND requeued_at IS NULL"
+	}
+
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM dead_letter_jobs "+where, args...).Scan(&total); err != nil {
+		s.logger.Error("failed to count dead-letter jobs", zap.Error(err))
+		return nil, 0, errors.New(errors.ErrCodeInternal, "failed to list dead-letter jobs")
+	}
+
+	args = append(args, params.Limit, (params.Page-1)*params.Limit)
+	query := fmt.Sprintf("SELECT %s FROM dead_letter_jobs %s ORDER BY failed_at DESC LIMIT $%d OFFSET $%d",
+		deadLetterColumns, where, len(args)-1, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.Error("failed to list dead-letter jobs", zap.Error(err))
+		return nil, 0, errors.New(errors.ErrCodeInternal, "failed to list dead-letter jobs")
+	}
+	defer rows.Close()
+
+	var jobs []DeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			s.logger.Error("failed to scan dead-letter job", zap.Error(err))
+			return nil, 0, errors.New(errors.ErrCodeInternal, "failed to list dead-letter jobs")
+		}
+		jobs = append(jobs, *dl)
+	}
+
+	return jobs, total, rows.Err()
+}
+
+// MarkRequeued flags a dead-lettered job as requeued and returns it. A job
+// can only be requeued once; requeuing it again is a conflict.
+func (s *Store) MarkRequeued(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	query := `
+		UPDATE dead_letter_jobs SET requeued_at = NOW()
+		WHERE id = $1 AND service = $2 AND requeued_at IS NULL
+		RETURNING ` + deadLetterColumns
+
+	dl, err := scanDeadLetter(s.db.QueryRowContext(ctx, query, id, s.service))
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := s.db.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM dead_letter_jobs WHERE id = $1 AND service = $2)", id, s.service,
+		).Scan(&exists); err == nil && exists {
+			return nil, errors.Conflictf("dead-letter job has already been requeued")
+		}
+		return nil, errors.NotFoundf("dead-letter job not found")
+	}
+	if err != nil {
+		s.logger.Error("failed to requeue dead-letter job", zap.String("id", id.String()), zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to requeue dead-letter job")
+	}
+
+	return dl, nil
+}
+
+// ClearRequeued undoes MarkRequeued when the job could not be queued again
+func (s *Store) ClearRequeued(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE dead_letter_jobs SET requeued_at = NULL WHERE id = $1 AND service = $2", id, s.service)
+	if err != nil {
+		s.logger.Error("failed to reset requeued dead-letter job", zap.String("id", id.String()), zap.Error(err))
+		return errors.New(errors.ErrCodeInternal, "failed to reset dead-letter job")
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDeadLetter(row scanner) (*DeadLetter, error) {
+	var dl DeadLetter
+	var payload []byte
+	err := row.Scan(&dl.ID, &dl.Service, &dl.JobType, &dl.TenantID, &dl.RequestID,
+		&payload, &dl.Attempts, &dl.LastError, &dl.FailedAt, &dl.RequeuedAt)
+	if err != nil {
+		return nil, err
+	}
+	dl.Payload = payload
+	return &dl, nil
+}
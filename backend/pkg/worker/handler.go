@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
+)
+
+// Handler serves the dead-letter admin endpoints of a service's pool
+type Handler struct {
+	pool *Pool
+}
+
+// NewHandler creates dead-letter endpoints for a pool
+func NewHandler(pool *Pool) *Handler {
+	return &Handler{pool: pool}
+}
+
+// ListDeadLetters handles GET /api/jobs/dead-letter
+func (h *Handler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := &ListDeadLettersParams{
+		JobType:  query.Get("job_type"),
+		TenantID: query.Get("tenant_id"),
+		Requeued: query.Get("requeued") == "true",
+	}
+
+	// Parse page and limit
+	if pageStr := query.Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil {
+			params.Page = page
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = limit
+		}
+	}
+
+	// Validate params
+	if err := validator.Validate(params); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	params.Normalize()
+
+	if h.pool.store == nil {
+		response.Paginated(w, []DeadLetter{}, params.Page, params.Limit, 0)
+		return
+	}
+
+	jobs, total, err := h.pool.store.List(r.Context(), params)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Paginated(w, jobs, params.Page, params.Limit, total)
+}
+
+// RequeueDeadLetter handles POST /api/jobs/dead-letter/:id/requeue
+func (h *Handler) RequeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid dead-letter job ID")
+		return
+	}
+
+	dl, err := h.pool.Requeue(r.Context(), id)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, dl)
+}
@@ -0,0 +1,281 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"go.uber.org/zap"
+)
+
+// deadLetterWriteTimeout bounds the write of an exhausted job to the store
+const deadLetterWriteTimeout = 5 * time.Second
+
+// HandlerFunc runs one attempt of a job. Returning an error schedules a
+// retry until the attempts are exhausted, so handlers must be idempotent.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// job is a queued unit of work. The tenant and request IDs of the
+// submitting request are restored on the handler's context, which acts as
+// the pool's service identity.
+type job struct {
+	id        uuid.UUID
+	jobType   string
+	tenantID  string
+	requestID string
+	payload   json.RawMessage
+}
+
+// Pool runs submitted jobs on a bounded set of goroutines, retrying failures
+// with exponential backoff. Jobs that fail every attempt are written to the
+// dead-letter store. Close drains the queue before returning.
+type Pool struct {
+	service  string // identity jobs act as in signed downstream calls
+	cfg      config.WorkerConfig
+	store    *Store // nil logs exhausted jobs without persisting them
+	logger   *zap.Logger
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	closed   bool
+	queue    chan *job
+	stopping chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New starts a pool with cfg.Concurrency workers whose jobs act as the named
+// service
+func New(service string, cfg config.WorkerConfig, store *Store, logger *zap.Logger) *Pool {
+	p := &Pool{
+		service:  service,
+		cfg:      cfg,
+		store:    store,
+		logger:   logger,
+		handlers: make(map[string]HandlerFunc),
+		queue:    make(chan *job, max(cfg.QueueSize, 1)),
+		stopping: make(chan struct{}),
+	}
+
+	for i := 0; i < max(cfg.Concurrency, 1); i++ {
+		p.wg.Add(1)
+		go p.run()
+	}
+
+	return p
+}
+
+// Register sets the handler for a job type. Handlers are registered at
+// startup, before the first Submit of that type.
+func (p *Pool) Register(jobType string, handler HandlerFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers[jobType] = handler
+}
+
+// Submit queues a job. The payload is marshaled to JSON so it can be stored
+// and requeued if the job is dead-lettered. It fails when the queue is full
+// or the pool is shutting down; it never waits for the job to run.
+func (p *Pool) Submit(ctx context.Context, jobType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return errors.New(errors.ErrCodeInternal, fmt.Sprintf("failed to encode %s job", jobType))
+	}
+
+	return p.enqueue(&job{
+		id:        uuid.New(),
+		jobType:   jobType,
+		tenantID:  middleware.GetTenantID(ctx),
+		requestID: logger.GetRequestID(ctx),
+		payload:   data,
+	})
+}
+
+func (p *Pool) enqueue(j *job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return errors.New(errors.ErrCodeUnavailable, "job queue is shutting down")
+	}
+	if _, ok := p.handlers[j.jobType]; !ok {
+		return errors.New(errors.ErrCodeInternal, fmt.Sprintf("no handler registered for %s jobs", j.jobType))
+	}
+
+	select {
+	case p.queue <- j:
+		return nil
+	default:
+		p.logger.Warn("job queue full, rejecting job", zap.String("job_type", j.jobType))
+		return errors.New(errors.ErrCodeUnavailable, "job queue is full")
+	}
+}
+
+// Requeue marks a dead-lettered job as requeued and submits it again with
+// its original payload, tenant and request ID
+func (p *Pool) Requeue(ctx context.Context, id uuid.UUID) (*DeadLetter, error) {
+	if p.store == nil {
+		return nil, errors.NotFoundf("dead-letter job not found")
+	}
+
+	dl, err := p.store.MarkRequeued(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.enqueue(&job{
+		id:        uuid.New(),
+		jobType:   dl.JobType,
+		tenantID:  dl.TenantID,
+		requestID: dl.RequestID,
+		payload:   dl.Payload,
+	}); err != nil {
+		// Leave the job in the dead-letter view so it can be requeued later
+		_ = p.store.ClearRequeued(ctx, id)
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "dead-letter job requeued",
+		zap.String("id", id.String()),
+		zap.String("job_type", dl.JobType),
+	)
+
+	return dl, nil
+}
+
+// Close stops accepting jobs and waits for queued ones to finish, up to
+// WORKER_SHUTDOWN_TIMEOUT. Jobs waiting to retry are dead-lettered rather
+// than retried, so nothing is silently dropped.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.stopping)
+	close(p.queue)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	if p.cfg.ShutdownTimeout <= 0 {
+		<-done
+		return
+	}
+
+	select {
+	case <-done:
+	case <-time.After(p.cfg.ShutdownTimeout):
+		p.logger.Warn("job queue did not drain before shutdown timeout",
+			zap.Int("pending", len(p.queue)),
+			zap.Duration("timeout", p.cfg.ShutdownTimeout),
+		)
+	}
+}
+
+func (p *Pool) run() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		p.process(j)
+	}
+}
+
+// process runs a job until it succeeds or its attempts are exhausted
+func (p *Pool) process(j *job) {
+	ctx := middleware.WithTenantID(context.Background(), j.tenantID)
+	ctx = middleware.WithServiceIdentity(ctx, p.service)
+	ctx = logger.WithRequestID(ctx, j.requestID)
+
+	p.mu.RLock()
+	handler := p.handlers[j.jobType]
+	p.mu.RUnlock()
+
+	attempts := max(p.cfg.MaxAttempts, 1)
+	backoff := p.cfg.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := p.attempt(ctx, handler, j)
+		if err == nil {
+			if attempt > 1 {
+				logger.InfoContext(ctx, "job succeeded after retry",
+					zap.String("job_type", j.jobType),
+					zap.Int("attempt", attempt),
+				)
+			}
+			return
+		}
+
+		if attempt >= attempts {
+			p.deadLetter(ctx, j, attempt, err)
+			return
+		}
+
+		logger.WarnContext(ctx, "job failed, retrying",
+			zap.String("job_type", j.jobType),
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("retry_in", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-p.stopping:
+			p.deadLetter(ctx, j, attempt, fmt.Errorf("shut down before retry: %w", err))
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if p.cfg.MaxBackoff > 0 && backoff > p.cfg.MaxBackoff {
+			backoff = p.cfg.MaxBackoff
+		}
+	}
+}
+
+// attempt runs the handler once, turning a panic into an error
+func (p *Pool) attempt(ctx context.Context, handler HandlerFunc, j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, j.payload)
+}
+
+// deadLetter records a job that will not be retried
+func (p *Pool) deadLetter(ctx context.Context, j *job, attempts int, cause error) {
+	logger.ErrorContext(ctx, "job failed, moving to dead-letter queue",
+		zap.String("job_type", j.jobType),
+		zap.Int("attempts", attempts),
+		zap.Error(cause),
+	)
+
+	if p.store == nil {
+		return
+	}
+
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterWriteTimeout)
+	defer cancel()
+
+	_ = p.store.Save(writeCtx, &DeadLetter{
+		ID:        j.id,
+		JobType:   j.jobType,
+		TenantID:  j.tenantID,
+		RequestID: j.requestID,
+		Payload:   j.payload,
+		Attempts:  attempts,
+		LastError: cause.Error(),
+		FailedAt:  timeutil.Now(),
+	})
+}
@@ -0,0 +1,267 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testJobType = "test.job"
+
+var testConfig = config.WorkerConfig{
+	Concurrency:    1,
+	QueueSize:      4,
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     2 * time.Millisecond,
+}
+
+// observeDeadLetters routes the global logger to an observer for the test
+// and returns the dead-letter entries logged so far when called
+func observeDeadLetters(t *testing.T) func() []observer.LoggedEntry {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	prev := logger.Global()
+	logger.SetGlobal(&logger.Logger{Logger: zap.New(core)})
+	t.Cleanup(func() { logger.SetGlobal(prev) })
+	return func() []observer.LoggedEntry {
+		return logs.FilterMessage("job failed, moving to dead-letter queue").All()
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the pool")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolRetries(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int
+		panics         bool
+		wantCalls      int32
+		wantDeadLetter bool
+	}{
+		{"succeeds first time", 0, false, 1, false},
+		{"succeeds after retries", 2, false, 3, false},
+		{"dead-lettered after max attempts", 10, false, 3, true},
+		{"panics are retried then dead-lettered", 10, true, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadLetters := observeDeadLetters(t)
+
+			var calls atomic.Int32
+			pool := New("test-service", testConfig, nil, zap.NewNop())
+			pool.Register(testJobType, func(ctx context.Context, payload json.RawMessage) error {
+				if int(calls.Add(1)) > tt.failures {
+					return nil
+				}
+				if tt.panics {
+					panic("boom")
+				}
+				return errors.New("temporary failure")
+			})
+
+			if err := pool.Submit(context.Background(), testJobType, map[string]string{"id": "1"}); err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			// Close stops retries, so let every attempt run first
+			waitFor(t, func() bool { return calls.Load() >= tt.wantCalls })
+			pool.Close()
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", got, tt.wantCalls)
+			}
+			entries := deadLetters()
+			if tt.wantDeadLetter != (len(entries) == 1) {
+				t.Fatalf("dead-letter entries = %d, want dead letter %v", len(entries), tt.wantDeadLetter)
+			}
+			if tt.wantDeadLetter {
+				if attempts := entries[0].ContextMap()["attempts"]; attempts != int64(tt.wantCalls) {
+					t.Errorf("dead-letter attempts = %v, want %d", attempts, tt.wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolRestoresRequestContext(t *testing.T) {
+	pool := New("test-service", testConfig, nil, zap.NewNop())
+
+	var tenantID, requestID string
+	var payload json.RawMessage
+	pool.Register(testJobType, func(ctx context.Context, p json.RawMessage) error {
+		tenantID = middleware.GetTenantID(ctx)
+		requestID = logger.GetRequestID(ctx)
+		payload = p
+		return nil
+	})
+
+	ctx := logger.WithRequestID(middleware.WithTenantID(context.Background(), "tenant-1"), "req-1")
+	if err := pool.Submit(ctx, testJobType, map[string]int{"n": 7}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	pool.Close()
+
+	if tenantID != "tenant-1" || requestID != "req-1" {
+		t.Errorf("handler saw tenant %q and request %q, want tenant-1 and req-1", tenantID, requestID)
+	}
+	if string(payload) != `{"n":7}` {
+		t.Errorf("payload = %s, want {\"n\":7}", payload)
+	}
+}
+
+func TestPoolSubmitRejects(t *testing.T) {
+	release := make(chan struct{})
+	cfg := testConfig
+	cfg.QueueSize = 1
+	pool := New("test-service", cfg, nil, zap.NewNop())
+	pool.Register(testJobType, func(ctx context.Context, payload json.RawMessage) error {
+		<-release
+		return nil
+	})
+
+	if err := pool.Submit(context.Background(), "unknown.job", nil); err == nil {
+		t.Error("Submit() accepted a job type with no handler")
+	}
+
+	// One job runs and blocks, one fills the queue, the next is rejected
+	if err := pool.Submit(context.Background(), testJobType, nil); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	waitFor(t, func() bool { return len(pool.queue) == 0 })
+	if err := pool.Submit(context.Background(), testJobType, nil); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if err := pool.Submit(context.Background(), testJobType, nil); err == nil {
+		t.Error("Submit() accepted a job with the queue full")
+	}
+
+	close(release)
+	pool.Close()
+
+	if err := pool.Submit(context.Background(), testJobType, nil); err == nil {
+		t.Error("Submit() accepted a job after Close")
+	}
+}
+
+func TestPoolCloseDeadLettersPendingRetries(t *testing.T) {
+	deadLetters := observeDeadLetters(t)
+
+	cfg := testConfig
+	cfg.InitialBackoff = time.Hour
+	pool := New("test-service", cfg, nil, zap.NewNop())
+
+	failed := make(chan struct{})
+	pool.Register(testJobType, func(ctx context.Context, payload json.RawMessage) error {
+		close(failed)
+		return errors.New("temporary failure")
+	})
+
+	if err := pool.Submit(context.Background(), testJobType, nil); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-failed
+
+	done := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close() waited for the retry backoff")
+	}
+
+	entries := deadLetters()
+	if len(entries) != 1 {
+		t.Fatalf("dead-letter entries = %d, want 1", len(entries))
+	}
+	if attempts := entries[0].ContextMap()["attempts"]; attempts != int64(1) {
+		t.Errorf("dead-letter attempts = %v, want 1", attempts)
+	}
+}
+
+func TestPoolJobsAuthenticateDownstream(t *testing.T) {
+	const secret = "test-secret"
+	log := &logger.Logger{Logger: zap.NewNop()}
+
+	var userID, tenantID string
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/quotas/reservations/adjust", middleware.InternalAuth(secret, time.Minute, log)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID = middleware.GetUserID(r.Context())
+			tenantID = middleware.GetTenantID(r.Context())
+			response.Success(w, nil)
+		}),
+	))
+	// Same order as the services' chains, innermost first
+	var chain http.Handler = mux
+	chain = middleware.ResolveTenant(config.TenancyConfig{ResolutionSource: middleware.TenantSourceSlug}, nil, nil)(chain)
+	chain = middleware.ExtractAuthHeaders(log)(chain)
+	chain = middleware.VerifyInternalRequest(secret, time.Minute, log)(chain)
+	server := httptest.NewServer(chain)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		client  *client.Client
+		wantErr bool
+	}{
+		{"signed call acts as the service", client.New("quota-service", server.URL, zap.NewNop()).WithSigningSecret(secret), false},
+		{"unsigned call is rejected", client.New("quota-service", server.URL, zap.NewNop()), true},
+		{"call signed with another secret is rejected", client.New("quota-service", server.URL, zap.NewNop()).WithSigningSecret("other-secret"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, tenantID = "", ""
+			pool := New("storage-service", testConfig, nil, zap.NewNop())
+
+			var callErr error
+			pool.Register(testJobType, func(ctx context.Context, payload json.RawMessage) error {
+				callErr = tt.client.Do(ctx, http.MethodPost, "/api/quotas/reservations/adjust", payload, nil)
+				return nil
+			})
+			ctx := middleware.WithTenantID(context.Background(), "tenant-1")
+			if err := pool.Submit(ctx, testJobType, map[string]int{"delta": -10}); err != nil {
+				t.Fatalf("Submit() error = %v", err)
+			}
+			pool.Close()
+
+			if tt.wantErr {
+				if callErr == nil || userID != "" {
+					t.Errorf("downstream call succeeded as %q, want it rejected", userID)
+				}
+				return
+			}
+			if callErr != nil {
+				t.Fatalf("downstream call error = %v", callErr)
+			}
+			if userID != middleware.ServiceUserPrefix+"storage-service" || tenantID != "tenant-1" {
+				t.Errorf("downstream saw user %q and tenant %q, want service:storage-service and tenant-1", userID, tenantID)
+			}
+		})
+	}
+}
@@ -10,16 +10,23 @@ import (
 	"time"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/handler"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/repository"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/service"
 	"go.uber.org/zap"
 )
 
+// defaultPort is used when SERVER_PORT is not set
+const defaultPort = 10002
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -27,11 +34,15 @@ func main() {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// Override port for document service
-	cfg.Server.Port = 10002
+	// Listen on defaultPort unless SERVER_PORT is set
+	if err := cfg.Server.UseDefaultPort(defaultPort); err != nil {
+		panic(fmt.Sprintf("invalid server config: %v", err))
+	}
 
 	// Initialize logger
-	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format)
+	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format,
+		logger.WithSampling(cfg.Logger.SamplingInitial, cfg.Logger.SamplingThereafter),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
@@ -42,40 +53,46 @@ func main() {
 		zap.String("environment", cfg.Environment),
 		zap.String("version", cfg.AppVersion),
 		zap.Int("port", cfg.Server.Port),
+		zap.Bool("log_sampling", log.SamplingEnabled()),
 	)
+	log.Info("effective configuration", zap.Any("config", cfg.Redacted()))
 
 	// Connect to database
-	db, err := database.NewPostgresDB(cfg.Database, log.Logger)
+	db, err := database.ConnectPostgresDB(cfg.Database, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
-
-	// Verify database health
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.HealthCheck(ctx); err != nil {
-		log.Fatal("database health check failed", zap.Error(err))
-	}
 	log.Info("database connection established")
 
 	// Connect to Redis cache
-	cacheClient, err := cache.NewRedisCache(cfg.Redis, log.Logger)
+	cacheClient, err := cache.ConnectRedisCache(cfg.Redis, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to cache", zap.Error(err))
 	}
 	defer cacheClient.Close()
-
-	// Verify cache health
-	if err := cacheClient.HealthCheck(ctx); err != nil {
-		log.Fatal("cache health check failed", zap.Error(err))
-	}
 	log.Info("cache connection established")
 
+	// Initialize internal service clients
+	tenantClient := client.NewTenantClient(client.New("tenant-service", cfg.Services.TenantServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	rbacClient := client.NewRBACClient(client.New("rbac-service", cfg.Services.RBACServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	storageClient := client.NewStorageClient(client.New("storage-service", cfg.Services.StorageServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	quotaClient := client.NewQuotaClient(client.New("quota-service", cfg.Services.QuotaServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+
 	// Initialize layers
 	repo := repository.NewRepository(db, log.Logger)
-	svc := service.NewService(repo, cacheClient, log.Logger)
-	h := handler.NewHandler(svc, log.Logger)
+	svc := service.NewService(repo, cacheClient, tenantClient, rbacClient, storageClient, cfg.Documents, log.Logger)
+	readiness := health.NewChecker("document-service", cfg.Health, log.Logger).WithVersion(cfg.AppVersion)
+	h := handler.NewHandler(svc, readiness, log.Logger)
+
+	// Sparse fieldsets (?fields=) on list endpoints
+	response.SetStrictFields(cfg.Server.StrictFieldSelection)
+
+	// Unknown request body fields
+	response.SetStrictJSON(cfg.Server.StrictJSON)
+
+	// Signed service-to-service requests only
+	internalAuth := middleware.InternalAuth(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -84,34 +101,89 @@ func main() {
 	mux.HandleFunc("GET /health", h.HealthCheck)
 	mux.HandleFunc("GET /health/ready", h.ReadyCheck)
 
-	// Document endpoints (auth required)
-	mux.HandleFunc("POST /api/documents", h.CreateDocument)
-	mux.HandleFunc("GET /api/documents", h.ListDocuments)
-	mux.HandleFunc("GET /api/documents/{id}", h.GetDocument)
-	mux.HandleFunc("PUT /api/documents/{id}", h.UpdateDocument)
-	mux.HandleFunc("DELETE /api/documents/{id}", h.DeleteDocument)
-
-	// Folder endpoints (auth required)
-	mux.HandleFunc("POST /api/folders", h.CreateFolder)
-	mux.HandleFunc("GET /api/folders", h.ListFolders)
-	mux.HandleFunc("GET /api/folders/{id}", h.GetFolder)
-	mux.HandleFunc("DELETE /api/folders/{id}", h.DeleteFolder)
+	// Effective configuration with secrets masked (internal use, operators)
+	mux.Handle("GET /api/config", internalAuth(http.HandlerFunc(config.NewHandler(cfg).Get)))
+
+	// Published metrics as JSON (internal use, scrapers)
+	mux.Handle("GET /api/metrics", internalAuth(metrics.Handler()))
+
+	// Tenant data purge (internal use, called by tenant deletion)
+	mux.Handle("DELETE /api/documents/tenant-data", internalAuth(http.HandlerFunc(h.PurgeTenant)))
+
+	// Search vector rebuild (internal use, resumable with the returned cursor)
+	mux.Handle("POST /api/documents/reindex-search", internalAuth(http.HandlerFunc(h.ReindexSearch)))
+
+	// Document existence check (internal use, called by share and file creation)
+	mux.Handle("GET /api/documents/{id}/exists", internalAuth(http.HandlerFunc(h.DocumentExists)))
+
+	// Document endpoints (auth required). Served under /api/... and /api/v1/...;
+	// a breaking change adds an APIVersion2 handler next to the v1 one and
+	// wraps the v1 handler in middleware.Deprecated once v2 is released.
+	mux.Handle("POST /api/documents", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.CreateDocument}))
+	mux.Handle("GET /api/documents", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.ListDocuments}))
+	mux.Handle("GET /api/documents/changes", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.ListDocumentChanges}))
+	mux.Handle("GET /api/documents/quicksearch", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.QuickSearch}))
+	mux.Handle("POST /api/documents/bulk/reassign", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.ReassignDocuments}))
+	mux.Handle("POST /api/documents/batch-get", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.BatchGetDocuments}))
+	mux.Handle("POST /api/documents/bulk/status", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.BulkUpdateStatus}))
+	mux.Handle("POST /api/documents/bulk/delete", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.BulkDeleteDocuments}))
+	mux.Handle("POST /api/documents/bulk/move", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.BulkMoveDocuments}))
+	mux.Handle("GET /api/documents/{id}", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetDocument}))
+	mux.Handle("GET /api/documents/{id}/details", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetDocumentDetails}))
+	mux.Handle("PUT /api/documents/{id}", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.UpdateDocument}))
+	mux.Handle("PATCH /api/documents/{id}", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.PatchDocument}))
+	mux.Handle("DELETE /api/documents/{id}", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.DeleteDocument}))
+	mux.Handle("POST /api/documents/{id}/versions/{version}/restore", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.RestoreVersion}))
+	mux.Handle("POST /api/documents/{id}/ocr/retry", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.RetryOCR}))
+	mux.Handle("POST /api/documents/{id}/pin", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.PinDocument}))
+	mux.Handle("DELETE /api/documents/{id}/pin", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.UnpinDocument}))
+	mux.Handle("POST /api/documents/{id}/presence", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.Heartbeat}))
+	mux.Handle("GET /api/documents/{id}/presence", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetPresence}))
+	mux.Handle("POST /api/documents/{id}/acl", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.SetDocumentACL}))
+	mux.Handle("GET /api/documents/{id}/acl", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetDocumentACL}))
+
+	// Folder endpoints (auth required), versioned like the document endpoints
+	mux.Handle("POST /api/folders", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.CreateFolder}))
+	mux.Handle("GET /api/folders", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.ListFolders}))
+	mux.Handle("GET /api/folders/tree", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetFolderTree}))
+	mux.Handle("GET /api/folders/{id}", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetFolder}))
+	mux.Handle("DELETE /api/folders/{id}", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.DeleteFolder}))
+	mux.Handle("POST /api/folders/{id}/move", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.MoveFolder}))
+	mux.Handle("POST /api/folders/{id}/move-contents", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.MoveFolderContents}))
+	mux.Handle("POST /api/folders/{id}/acl", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.SetFolderACL}))
+	mux.Handle("GET /api/folders/{id}/acl", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.GetFolderACL}))
 
 	// Tag endpoints (auth required)
-	mux.HandleFunc("POST /api/tags", h.CreateTag)
-	mux.HandleFunc("GET /api/tags", h.ListTags)
+	mux.Handle("POST /api/tags", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.CreateTag}))
+	mux.Handle("GET /api/tags", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.ListTags}))
+	mux.Handle("GET /api/tags/suggest", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.SuggestTags}))
 
 	// Category endpoints (auth required)
-	mux.HandleFunc("POST /api/categories", h.CreateCategory)
-	mux.HandleFunc("GET /api/categories", h.ListCategories)
+	mux.Handle("POST /api/categories", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.CreateCategory}))
+	mux.Handle("GET /api/categories", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.ListCategories}))
+	mux.Handle("POST /api/categories/{id}/assign", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.AssignCategory}))
+	mux.Handle("POST /api/categories/{id}/unassign", middleware.Versions(map[string]http.HandlerFunc{middleware.APIVersion1: h.UnassignCategory}))
 
 	// Apply middleware chain
+	middleware.SetSlowRequestThreshold(cfg.Logger.SlowRequestThreshold)
 	var httpHandler http.Handler = mux
+	httpHandler = middleware.Versioning(middleware.APIVersion1)(httpHandler)
 	httpHandler = middleware.RequestID()(httpHandler)
+	httpHandler = middleware.CountAPICalls(cacheClient.IncrementAPICalls, log)(httpHandler)
+	httpHandler = middleware.RateLimit(cfg.RateLimit, cacheClient.RateLimitResolver(quotaClient.RateLimit, cfg.RateLimit.CacheTTL), cacheClient.IncrementRateWindow, log)(httpHandler)
+	httpHandler = middleware.ResolveTenant(cfg.Tenancy, tenantClient.ResolveSlug, tenantClient.IsMember)(httpHandler)
 	httpHandler = middleware.ExtractAuthHeaders(log)(httpHandler)
+	httpHandler = middleware.VerifyInternalRequest(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)(httpHandler)
+	// CORS runs ahead of auth so browser preflights are answered without credentials
+	httpHandler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:      cfg.CORS.AllowedOrigins,
+		CredentialedOrigins: cfg.CORS.CredentialedOrigins,
+		MaxAge:              cfg.CORS.MaxAge,
+	})(httpHandler)
 	httpHandler = middleware.Logging(log)(httpHandler)
 	httpHandler = middleware.Recovery(log)(httpHandler)
 	httpHandler = middleware.Timeout(30 * time.Second)(httpHandler)
+	httpHandler = middleware.MaxBodySize(cfg.Server.MaxBodySize)(httpHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -1,11 +1,15 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/models"
@@ -15,15 +19,17 @@ import (
 
 // Handler handles HTTP requests for document operations
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service   *service.Service
+	readiness *health.Checker
+	logger    *zap.Logger
 }
 
 // NewHandler creates a new document handler
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
+func NewHandler(svc *service.Service, readiness *health.Checker, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: svc,
-		logger:  logger,
+		service:   svc,
+		readiness: readiness,
+		logger:    logger,
 	}
 }
 
@@ -32,8 +38,8 @@ func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
 // CreateDocument handles POST /api/documents
 func (h *Handler) CreateDocument(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateDocumentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -57,7 +63,62 @@ func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	doc, err := h.service.GetDocument(r.Context(), docID)
+	includeTrashed := includes(r, models.IncludeTrashed)
+	if includes(r, models.IncludeShares) {
+		doc, err := h.service.GetDocumentWithShares(r.Context(), docID, includeTrashed)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		response.Success(w, doc)
+		return
+	}
+
+	doc, err := h.service.GetDocument(r.Context(), docID, includeTrashed)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, doc)
+}
+
+// ListDocumentChanges handles GET /api/documents/changes
+func (h *Handler) ListDocumentChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := models.DocumentChangesParams{
+		Since:  query.Get("since"),
+		Cursor: query.Get("cursor"),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = l
+		}
+	}
+
+	if err := validator.Validate(params); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	page, err := h.service.ListDocumentChanges(r.Context(), params)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, page)
+}
+
+// GetDocumentDetails handles GET /api/documents/:id/details
+func (h *Handler) GetDocumentDetails(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	doc, err := h.service.GetDocumentWithDetails(r.Context(), docID, includes(r, models.IncludeShares))
 	if err != nil {
 		response.Error(w, err)
 		return
@@ -66,6 +127,31 @@ func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, doc)
 }
 
+// includes reports whether the comma-separated ?include= list names what
+func includes(r *http.Request, what string) bool {
+	for _, name := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(name) == what {
+			return true
+		}
+	}
+	return false
+}
+
+// QuickSearch handles GET /api/documents/quicksearch
+func (h *Handler) QuickSearch(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	results, err := h.service.QuickSearch(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Lets the browser reuse results when a user retypes a recent prefix
+	w.Header().Set("Cache-Control", "private, max-age=5")
+	response.Success(w, results)
+}
+
 // ListDocuments handles GET /api/documents
 func (h *Handler) ListDocuments(w http.ResponseWriter, r *http.Request) {
 	params := &models.ListDocumentsParams{
@@ -74,9 +160,59 @@ func (h *Handler) ListDocuments(w http.ResponseWriter, r *http.Request) {
 		Tags:       r.URL.Query().Get("tags"),
 		Status:     r.URL.Query().Get("status"),
 		Search:     r.URL.Query().Get("search"),
+		UploadedBy: r.URL.Query().Get("uploaded_by"),
+		FileType:   r.URL.Query().Get("file_type"),
+		WithShares: includes(r, models.IncludeShares),
 		SortBy:     r.URL.Query().Get("sort_by"),
 		SortOrder:  r.URL.Query().Get("sort_order"),
 	}
+	params.IncludeTrashed = includes(r, models.IncludeTrashed)
+
+	// Multi-folder filter (?folder_ids=a,b,c)
+	if folderIDs := r.URL.Query().Get("folder_ids"); folderIDs != "" {
+		for _, id := range strings.Split(folderIDs, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				params.FolderIDs = append(params.FolderIDs, id)
+			}
+		}
+	}
+
+	// Size range (bytes)
+	for _, bound := range []struct {
+		field string
+		dest  *int64
+	}{
+		{"min_size", &params.MinSize},
+		{"max_size", &params.MaxSize},
+	} {
+		field := bound.field
+		if value := r.URL.Query().Get(field); value != "" {
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				response.Error(w, errors.Validationf("invalid %s", field).WithField(field, field+" must be a whole number of bytes"))
+				return
+			}
+			*bound.dest = size
+		}
+	}
+
+	// Date ranges (RFC3339)
+	for _, bound := range []struct {
+		field string
+		dest  *time.Time
+	}{
+		{"created_after", &params.CreatedAfter},
+		{"created_before", &params.CreatedBefore},
+		{"updated_after", &params.UpdatedAfter},
+		{"updated_before", &params.UpdatedBefore},
+	} {
+		value, _, err := validator.ParseTimeParam(bound.field, r.URL.Query().Get(bound.field))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		*bound.dest = value
+	}
 
 	// Parse page and limit
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
@@ -102,7 +238,13 @@ func (h *Handler) ListDocuments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Paginated(w, documents, params.Page, params.Limit, total)
+	data, err := response.ProjectFields(r, documents)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Paginated(w, data, params.Page, params.Limit, total)
 }
 
 // UpdateDocument handles PUT /api/documents/:id
@@ -115,8 +257,8 @@ func (h *Handler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateDocumentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -134,6 +276,35 @@ func (h *Handler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "document updated successfully"})
 }
 
+// PatchDocument handles PATCH /api/documents/:id
+func (h *Handler) PatchDocument(w http.ResponseWriter, r *http.Request) {
+	docIDStr := r.PathValue("id")
+	docID, err := uuid.Parse(docIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	var req models.PatchDocumentRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	if err := h.service.PatchDocument(r.Context(), docID, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]string{"message": "document updated successfully"})
+}
+
 // DeleteDocument handles DELETE /api/documents/:id
 func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
 	docIDStr := r.PathValue("id")
@@ -151,13 +322,263 @@ func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "document deleted successfully"})
 }
 
+// RestoreVersion handles POST /api/documents/:id/versions/:version/restore
+func (h *Handler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || versionNumber < 1 {
+		response.BadRequest(w, "invalid version number")
+		return
+	}
+
+	doc, err := h.service.RestoreVersion(r.Context(), docID, versionNumber)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, doc)
+}
+
+// RetryOCR handles POST /api/documents/:id/ocr/retry?language=
+func (h *Handler) RetryOCR(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	doc, err := h.service.RetryOCR(r.Context(), docID, r.URL.Query().Get("language"))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, doc)
+}
+
+// PinDocument handles POST /api/documents/:id/pin
+func (h *Handler) PinDocument(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	doc, err := h.service.PinDocument(r.Context(), docID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, doc)
+}
+
+// UnpinDocument handles DELETE /api/documents/:id/pin
+func (h *Handler) UnpinDocument(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	doc, err := h.service.UnpinDocument(r.Context(), docID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, doc)
+}
+
+// Heartbeat handles POST /api/documents/:id/presence
+func (h *Handler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	presence, err := h.service.Heartbeat(r.Context(), docID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, presence)
+}
+
+// GetPresence handles GET /api/documents/:id/presence
+func (h *Handler) GetPresence(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	presence, err := h.service.GetPresence(r.Context(), docID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, presence)
+}
+
+// ReassignDocuments handles POST /api/documents/bulk/reassign
+func (h *Handler) ReassignDocuments(w http.ResponseWriter, r *http.Request) {
+	var req models.ReassignDocumentsRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if len(req.DocumentIDs) > 0 {
+		if err := bulk.CheckSize("document_ids", len(req.DocumentIDs), models.MaxReassignBatchSize); err != nil {
+			response.Error(w, err)
+			return
+		}
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.ReassignDocuments(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// BatchGetDocuments handles POST /api/documents/batch-get
+func (h *Handler) BatchGetDocuments(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchGetDocumentsRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("ids", len(req.IDs), models.MaxBatchGetSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.BatchGetDocuments(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// BulkUpdateStatus handles POST /api/documents/bulk/status
+func (h *Handler) BulkUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkStatusRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("document_ids", len(req.DocumentIDs), models.MaxStatusBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.BulkUpdateStatus(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// BulkDeleteDocuments handles POST /api/documents/bulk/delete
+func (h *Handler) BulkDeleteDocuments(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkDeleteRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("document_ids", len(req.DocumentIDs), models.MaxDeleteBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.BulkDeleteDocuments(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// BulkMoveDocuments handles POST /api/documents/bulk/move
+func (h *Handler) BulkMoveDocuments(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkMoveRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("document_ids", len(req.DocumentIDs), models.MaxMoveBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.BulkMoveDocuments(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
 // Folder handlers
 
 // CreateFolder handles POST /api/folders
 func (h *Handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateFolderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -176,6 +597,66 @@ func (h *Handler) CreateFolder(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, folder)
 }
 
+// MoveFolder handles POST /api/folders/:id/move
+func (h *Handler) MoveFolder(w http.ResponseWriter, r *http.Request) {
+	folderIDStr := r.PathValue("id")
+	folderID, err := uuid.Parse(folderIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid folder ID")
+		return
+	}
+
+	var req models.MoveFolderRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	folder, err := h.service.MoveFolder(r.Context(), folderID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, folder)
+}
+
+// MoveFolderContents handles POST /api/folders/:id/move-contents
+func (h *Handler) MoveFolderContents(w http.ResponseWriter, r *http.Request) {
+	folderIDStr := r.PathValue("id")
+	folderID, err := uuid.Parse(folderIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid folder ID")
+		return
+	}
+
+	var req models.MoveFolderContentsRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.MoveFolderContents(r.Context(), folderID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
 // GetFolder handles GET /api/folders/:id
 func (h *Handler) GetFolder(w http.ResponseWriter, r *http.Request) {
 	folderIDStr := r.PathValue("id")
@@ -194,6 +675,98 @@ func (h *Handler) GetFolder(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, folder)
 }
 
+// SetFolderACL handles POST /api/folders/{id}/acl
+func (h *Handler) SetFolderACL(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid folder ID")
+		return
+	}
+
+	var req models.SetFolderACLRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	acl, err := h.service.SetFolderACL(r.Context(), folderID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, acl)
+}
+
+// GetFolderACL handles GET /api/folders/{id}/acl
+func (h *Handler) GetFolderACL(w http.ResponseWriter, r *http.Request) {
+	folderID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid folder ID")
+		return
+	}
+
+	acl, err := h.service.GetFolderACL(r.Context(), folderID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, acl)
+}
+
+// SetDocumentACL handles POST /api/documents/{id}/acl
+func (h *Handler) SetDocumentACL(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	var req models.SetDocumentACLRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	acl, err := h.service.SetDocumentACL(r.Context(), docID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, acl)
+}
+
+// GetDocumentACL handles GET /api/documents/{id}/acl
+func (h *Handler) GetDocumentACL(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	acl, err := h.service.GetDocumentACL(r.Context(), docID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, acl)
+}
+
 // ListFolders handles GET /api/folders
 func (h *Handler) ListFolders(w http.ResponseWriter, r *http.Request) {
 	var parentID *string
@@ -210,6 +783,17 @@ func (h *Handler) ListFolders(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, folders)
 }
 
+// GetFolderTree handles GET /api/folders/tree
+func (h *Handler) GetFolderTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetFolderTree(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, tree)
+}
+
 // DeleteFolder handles DELETE /api/folders/:id
 func (h *Handler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
 	folderIDStr := r.PathValue("id")
@@ -232,8 +816,8 @@ func (h *Handler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
 // CreateTag handles POST /api/tags
 func (h *Handler) CreateTag(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTagRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -263,13 +847,32 @@ func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, tags)
 }
 
+// SuggestTags handles GET /api/tags/suggest
+func (h *Handler) SuggestTags(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		response.BadRequest(w, "q is required")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	suggestions, err := h.service.SuggestTags(r.Context(), query, limit)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, suggestions)
+}
+
 // Category handlers
 
 // CreateCategory handles POST /api/categories
 func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateCategoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -299,21 +902,142 @@ func (h *Handler) ListCategories(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, categories)
 }
 
+// AssignCategory handles POST /api/categories/:id/assign
+func (h *Handler) AssignCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid category ID")
+		return
+	}
+
+	var req models.CategoryAssignRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("document_ids", len(req.DocumentIDs), models.MaxCategoryBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.AssignCategory(r.Context(), categoryID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// UnassignCategory handles POST /api/categories/:id/unassign
+func (h *Handler) UnassignCategory(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid category ID")
+		return
+	}
+
+	var req models.CategoryAssignRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("document_ids", len(req.DocumentIDs), models.MaxCategoryBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.UnassignCategory(r.Context(), categoryID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
 // Health check handlers
 
+// ReindexSearch handles POST /api/documents/reindex-search (internal use)
+func (h *Handler) ReindexSearch(w http.ResponseWriter, r *http.Request) {
+	var req models.ReindexSearchRequest
+	if r.ContentLength != 0 {
+		if err := response.DecodeJSON(r, &req); err != nil {
+			response.InvalidBody(w, err)
+			return
+		}
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.ReindexSearch(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// DocumentExists handles GET /api/documents/:id/exists (internal use)
+func (h *Handler) DocumentExists(w http.ResponseWriter, r *http.Request) {
+	docID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	result, err := h.service.DocumentExists(r.Context(), docID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// PurgeTenant handles DELETE /api/documents/tenant-data (internal use)
+func (h *Handler) PurgeTenant(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.service.PurgeTenant(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]int64{"deleted": deleted})
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.Success(w, map[string]string{
-		"status":  "healthy",
-		"service": "document-service",
-	})
+	response.Success(w, h.readiness.Live())
 }
 
 // ReadyCheck handles GET /health/ready
 func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 	// TODO: Check database and cache connectivity
-	response.Success(w, map[string]string{
-		"status":  "ready",
-		"service": "document-service",
-	})
+	report := h.readiness.Check(r.Context())
+	if !report.Ready() {
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	response.Success(w, report)
 }
@@ -2,43 +2,105 @@ package models
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 )
 
+// Document statuses
+const (
+	DocumentStatusActive   = "active"
+	DocumentStatusArchived = "archived"
+)
+
+// documentStatusTransitions lists the statuses each status may move to
+var documentStatusTransitions = map[string][]string{
+	DocumentStatusActive:   {DocumentStatusArchived},
+	DocumentStatusArchived: {DocumentStatusActive},
+}
+
+// CanTransitionStatus reports whether a document may move from one status to another
+func CanTransitionStatus(from, to string) bool {
+	for _, allowed := range documentStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusesTransitioningTo returns the statuses from which a document may move to the given status
+func StatusesTransitioningTo(to string) []string {
+	var from []string
+	for status := range documentStatusTransitions {
+		if CanTransitionStatus(status, to) {
+			from = append(from, status)
+		}
+	}
+	return from
+}
+
 // Document represents a document in the system
 type Document struct {
-	ID             uuid.UUID      `json:"id" db:"id"`
-	TenantID       uuid.UUID      `json:"tenant_id" db:"tenant_id"`
-	FolderID       sql.NullString `json:"folder_id,omitempty" db:"folder_id"`
-	Name           string         `json:"name" db:"name"`
-	Description    sql.NullString `json:"description,omitempty" db:"description"`
-	FileType       string         `json:"file_type" db:"file_type"`
-	FileSize       int64          `json:"file_size" db:"file_size"`
-	MimeType       string         `json:"mime_type" db:"mime_type"`
-	StoragePath    string         `json:"-" db:"storage_path"` // Don't expose storage path
-	ThumbnailPath  sql.NullString `json:"-" db:"thumbnail_path"`
-	Status         string         `json:"status" db:"status"`
-	UploadedBy     string         `json:"uploaded_by" db:"uploaded_by"`
-	CategoryID     sql.NullString `json:"category_id,omitempty" db:"category_id"`
-	OCRStatus      string         `json:"ocr_status" db:"ocr_status"`
-	SearchVector   sql.NullString `json:"-" db:"search_vector"` // PostgreSQL tsvector
-	Version        int            `json:"version" db:"version"`
-	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	ID            uuid.UUID      `json:"id" db:"id"`
+	TenantID      uuid.UUID      `json:"tenant_id" db:"tenant_id"`
+	FolderID      sql.NullString `json:"folder_id,omitempty" db:"folder_id"`
+	Name          string         `json:"name" db:"name"`
+	Description   sql.NullString `json:"description,omitempty" db:"description"`
+	FileType      string         `json:"file_type" db:"file_type"`
+	FileSize      int64          `json:"file_size" db:"file_size"`
+	MimeType      string         `json:"mime_type" db:"mime_type"`
+	StoragePath   string         `json:"-" db:"storage_path"` // Don't expose storage path
+	ThumbnailPath sql.NullString `json:"-" db:"thumbnail_path"`
+	Status        string         `json:"status" db:"status"`
+	UploadedBy    string         `json:"uploaded_by" db:"uploaded_by"`
+	CategoryID    sql.NullString `json:"category_id,omitempty" db:"category_id"`
+	OCRStatus     string         `json:"ocr_status" db:"ocr_status"`
+	OCRLanguage   sql.NullString `json:"ocr_language,omitempty" db:"ocr_language"` // ISO 639-1 hint for the OCR service
+	SearchVector  sql.NullString `json:"-" db:"search_vector"`                     // PostgreSQL tsvector
+	Version       int            `json:"version" db:"version"`
+	IsPinned      bool           `json:"is_pinned" db:"is_pinned"` // listed first in its folder, for every user of the tenant
+	CreatedAt     timeutil.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     timeutil.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Document OCR states
+const (
+	OCRStatusPending    = "pending"
+	OCRStatusProcessing = "processing"
+	OCRStatusCompleted  = "completed"
+	OCRStatusFailed     = "failed"
+)
+
+// OCRLanguages lists the ISO 639-1 codes the OCR service has language data for
+var OCRLanguages = []string{"ar", "de", "en", "es", "fr", "it", "ja", "ko", "nl", "pt", "ru", "zh"}
+
+// OCRJob is an OCR request queued for the OCR service (ocr_jobs); the
+// language hint travels in the job metadata
+type OCRJob struct {
+	ID          uuid.UUID     `json:"id" db:"id"`
+	DocumentID  uuid.UUID     `json:"document_id" db:"document_id"`
+	TenantID    uuid.UUID     `json:"tenant_id" db:"tenant_id"`
+	RequestedBy string        `json:"requested_by" db:"requested_by"`
+	Language    string        `json:"language,omitempty" db:"-"`
+	CreatedAt   timeutil.Time `json:"created_at" db:"created_at"`
 }
 
 // DocumentVersion represents a version of a document
 type DocumentVersion struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	DocumentID    uuid.UUID `json:"document_id" db:"document_id"`
-	VersionNumber int       `json:"version_number" db:"version_number"`
-	FileSize      int64     `json:"file_size" db:"file_size"`
-	StoragePath   string    `json:"-" db:"storage_path"`
-	UploadedBy    string    `json:"uploaded_by" db:"uploaded_by"`
-	Comment       string    `json:"comment,omitempty" db:"comment"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	ID            uuid.UUID     `json:"id" db:"id"`
+	DocumentID    uuid.UUID     `json:"document_id" db:"document_id"`
+	VersionNumber int           `json:"version_number" db:"version_number"`
+	FileSize      int64         `json:"file_size" db:"file_size"`
+	StoragePath   string        `json:"-" db:"storage_path"`
+	UploadedBy    string        `json:"uploaded_by" db:"uploaded_by"`
+	Comment       string        `json:"comment,omitempty" db:"comment"`
+	CreatedAt     timeutil.Time `json:"created_at" db:"created_at"`
 }
 
 // Folder represents a folder/directory
@@ -46,63 +108,406 @@ type Folder struct {
 	ID          uuid.UUID      `json:"id" db:"id"`
 	TenantID    uuid.UUID      `json:"tenant_id" db:"tenant_id"`
 	ParentID    sql.NullString `json:"parent_id,omitempty" db:"parent_id"`
-	Name        string         `json:"name" db:"name"`
-	Path        string         `json:"path" db:"path"`
+	Name        string         `json:"name" db:"name"`   // display name, stored verbatim
+	Path        string         `json:"path" db:"path"`   // escaped segments, see JoinFolderPath
+	Depth       int            `json:"depth" db:"depth"` // root folders are 1
 	Description sql.NullString `json:"description,omitempty" db:"description"`
 	Color       sql.NullString `json:"color,omitempty" db:"color"`
 	Icon        sql.NullString `json:"icon,omitempty" db:"icon"`
 	CreatedBy   string         `json:"created_by" db:"created_by"`
-	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	CreatedAt   timeutil.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   timeutil.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// FolderPathSeparator separates the segments of a materialized folder path
+const FolderPathSeparator = "/"
+
+var (
+	folderSegmentEscaper   = strings.NewReplacer("%", "%25", "/", "%2F")
+	folderSegmentUnescaper = strings.NewReplacer("%2F", "/", "%25", "%")
+)
+
+// EscapeFolderSegment encodes a folder name for use as a path segment so a
+// separator inside the name cannot be mistaken for a level boundary. The
+// update_folder_path trigger applies the same encoding.
+func EscapeFolderSegment(name string) string {
+	return folderSegmentEscaper.Replace(name)
+}
+
+// UnescapeFolderSegment reverses EscapeFolderSegment
+func UnescapeFolderSegment(segment string) string {
+	return folderSegmentUnescaper.Replace(segment)
+}
+
+// JoinFolderPath appends a folder name to its parent's path; an empty parent
+// path yields a root-level path
+func JoinFolderPath(parentPath, name string) string {
+	return parentPath + FolderPathSeparator + EscapeFolderSegment(name)
+}
+
+// SplitFolderPath returns the display names along a materialized path, root
+// first
+func SplitFolderPath(path string) []string {
+	path = strings.TrimPrefix(path, FolderPathSeparator)
+	if path == "" {
+		return nil
+	}
+	segments := strings.Split(path, FolderPathSeparator)
+	for i, segment := range segments {
+		segments[i] = UnescapeFolderSegment(segment)
+	}
+	return segments
+}
+
+// Document access actions granted by document and folder ACLs
+const (
+	AccessRead   = "read"
+	AccessUpdate = "update"
+	AccessDelete = "delete"
+)
+
+// FolderACLEntry grants a user a set of actions on documents in a folder, or
+// on a single document when part of a DocumentACL
+type FolderACLEntry struct {
+	UserID  string   `json:"user_id" validate:"required,uuid"`
+	Actions []string `json:"actions" validate:"required,min=1,dive,oneof=read update delete"`
+}
+
+// FolderACL represents the access list of a folder. When Inherit is set the
+// list also applies to documents in subfolders that have no ACL of their own.
+type FolderACL struct {
+	FolderID  uuid.UUID        `json:"folder_id"`
+	Inherit   bool             `json:"inherit"`
+	Entries   []FolderACLEntry `json:"entries"`
+	UpdatedBy string           `json:"updated_by,omitempty"`
+	UpdatedAt timeutil.Time    `json:"updated_at"`
+}
+
+// DocumentACL represents the access list of a single document. A document
+// with an ACL is decided by it alone; folder ACLs and RBAC are not consulted.
+type DocumentACL struct {
+	DocumentID uuid.UUID        `json:"document_id"`
+	Entries    []FolderACLEntry `json:"entries"`
+	UpdatedBy  string           `json:"updated_by,omitempty"`
+	UpdatedAt  timeutil.Time    `json:"updated_at"`
+}
+
+// DocumentAccessFilter restricts listings to the documents a user may access
+// with Action, applying the same precedence as single-document checks
+type DocumentAccessFilter struct {
+	UserID          string
+	Action          string
+	RBACAllowed     bool     // tenant-wide permission, for documents no ACL governs
+	GovernedFolders []string // folders whose documents a folder ACL decides
+	AllowedFolders  []string // the governed folders whose ACL grants Action
+}
+
+// FolderACLGrant is a folder ACL that applies to a document, with the actions
+// it grants the requesting user
+type FolderACLGrant struct {
+	FolderID uuid.UUID
+	Depth    int // 0 is the document's own folder
+	Inherit  bool
+	Actions  []string // empty when the user has no entry
+}
+
+// Allows reports whether the grant permits an action
+func (g *FolderACLGrant) Allows(action string) bool {
+	for _, a := range g.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
 }
 
 // Tag represents a document tag
 type Tag struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	TenantID   uuid.UUID `json:"tenant_id" db:"tenant_id"`
-	Name       string    `json:"name" db:"name"`
-	Color      string    `json:"color" db:"color"`
-	UsageCount int       `json:"usage_count" db:"usage_count"`
-	CreatedBy  string    `json:"created_by" db:"created_by"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID         uuid.UUID     `json:"id" db:"id"`
+	TenantID   uuid.UUID     `json:"tenant_id" db:"tenant_id"`
+	Name       string        `json:"name" db:"name"`
+	Color      string        `json:"color" db:"color"`
+	UsageCount int           `json:"usage_count" db:"usage_count"`
+	CreatedBy  string        `json:"created_by" db:"created_by"`
+	CreatedAt  timeutil.Time `json:"created_at" db:"created_at"`
+}
+
+// TagSuggestion is an existing tag ranked by similarity to a typed name
+type TagSuggestion struct {
+	Tag
+	Score float64 `json:"score"` // 1 for an exact match
+}
+
+// TagSuggestionsResponse lists tags similar to a query and whether one of
+// them already matches it exactly (ignoring case)
+type TagSuggestionsResponse struct {
+	Query       string          `json:"query"`
+	ExactMatch  bool            `json:"exact_match"`
+	Suggestions []TagSuggestion `json:"suggestions"`
+}
+
+// QuickSearchResult is a minimal document match for search-as-you-type
+type QuickSearchResult struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	FileType string    `json:"file_type"`
 }
 
 // DocumentTag represents the association between documents and tags
 type DocumentTag struct {
-	DocumentID uuid.UUID `json:"document_id" db:"document_id"`
-	TagID      uuid.UUID `json:"tag_id" db:"tag_id"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	DocumentID uuid.UUID     `json:"document_id" db:"document_id"`
+	TagID      uuid.UUID     `json:"tag_id" db:"tag_id"`
+	CreatedAt  timeutil.Time `json:"created_at" db:"created_at"`
 }
 
 // Category represents a document category
 type Category struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	TenantID      uuid.UUID `json:"tenant_id" db:"tenant_id"`
-	Name          string    `json:"name" db:"name"`
-	Description   string    `json:"description,omitempty" db:"description"`
-	Color         string    `json:"color" db:"color"`
-	Icon          string    `json:"icon,omitempty" db:"icon"`
-	DocumentCount int       `json:"document_count" db:"document_count"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+	ID            uuid.UUID     `json:"id" db:"id"`
+	TenantID      uuid.UUID     `json:"tenant_id" db:"tenant_id"`
+	Name          string        `json:"name" db:"name"`
+	Description   string        `json:"description,omitempty" db:"description"`
+	Color         string        `json:"color" db:"color"`
+	Icon          string        `json:"icon,omitempty" db:"icon"`
+	DocumentCount int           `json:"document_count" db:"document_count"`
+	CreatedAt     timeutil.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     timeutil.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Name length limits, matching the max= validation tags; names are checked
+// again after sanitization
+const (
+	MaxDocumentNameLength = 255
+	MaxFolderNameLength   = 100
+	MaxTagNameLength      = 50
+	MaxCategoryNameLength = 100
+)
+
+// DefaultTagColor is given to tags created implicitly from a tag name
+const DefaultTagColor = "#6B7280"
+
 // CreateDocumentRequest represents document creation request
 type CreateDocumentRequest struct {
 	Name        string   `json:"name" validate:"required,min=1,max=255"`
 	Description string   `json:"description,omitempty" validate:"omitempty,max=1000"`
 	FolderID    string   `json:"folder_id,omitempty" validate:"omitempty,uuid"`
 	CategoryID  string   `json:"category_id,omitempty" validate:"omitempty,uuid"`
-	Tags        []string `json:"tags,omitempty"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=50,dive,required,max=50"` // Tag IDs or names
+	OCRLanguage string   `json:"ocr_language,omitempty"`                                          // one of OCRLanguages
 }
 
-// UpdateDocumentRequest represents document update request
+// UpdateDocumentRequest represents a full document replacement (PUT);
+// omitted optional fields are cleared
 type UpdateDocumentRequest struct {
-	Name        string   `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Name        string   `json:"name" validate:"required,min=1,max=255"`
 	Description string   `json:"description,omitempty" validate:"omitempty,max=1000"`
-	FolderID    *string  `json:"folder_id,omitempty" validate:"omitempty,uuid"`
-	CategoryID  *string  `json:"category_id,omitempty" validate:"omitempty,uuid"`
+	FolderID    string   `json:"folder_id,omitempty" validate:"omitempty,uuid"`
+	CategoryID  string   `json:"category_id,omitempty" validate:"omitempty,uuid"`
 	Tags        []string `json:"tags,omitempty"`
+	OCRLanguage string   `json:"ocr_language,omitempty"`
+}
+
+// PatchDocumentRequest represents a partial document update (PATCH); nil fields
+// are left unchanged and empty strings clear optional fields
+type PatchDocumentRequest struct {
+	Name        *string   `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=1000"`
+	FolderID    *string   `json:"folder_id,omitempty"`
+	CategoryID  *string   `json:"category_id,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+	OCRLanguage *string   `json:"ocr_language,omitempty"`
+}
+
+// Maximum number of items per bulk request
+const (
+	MaxReassignBatchSize = 1000
+	MaxStatusBatchSize   = 500
+	MaxCategoryBatchSize = 500
+	MaxBatchGetSize      = 100
+	MaxDeleteBatchSize   = 1000
+	MaxMoveBatchSize     = 1000
+)
+
+// ReassignDocumentsRequest represents a document ownership transfer request;
+// without document_ids every document of from_user_id is transferred
+type ReassignDocumentsRequest struct {
+	FromUserID  string   `json:"from_user_id" validate:"required"`
+	ToUserID    string   `json:"to_user_id" validate:"required,nefield=FromUserID"`
+	DocumentIDs []string `json:"document_ids,omitempty" validate:"omitempty,dive,uuid"`
+}
+
+// ReassignDocumentsResponse represents a document ownership transfer result
+type ReassignDocumentsResponse = bulk.Response[bulk.Result]
+
+// BulkStatusRequest represents a bulk document status transition request
+type BulkStatusRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required,dive,uuid"`
+	Status      string   `json:"status" validate:"required,oneof=active archived"`
+}
+
+// BulkStatusResult represents the outcome for a single document
+type BulkStatusResult struct {
+	bulk.Result
+	PreviousStatus string `json:"previous_status,omitempty"`
+}
+
+// BulkStatusResponse represents a bulk document status transition result
+type BulkStatusResponse = bulk.Response[BulkStatusResult]
+
+// BulkDeleteRequest represents a bulk document deletion request
+type BulkDeleteRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required,dive,uuid"`
+}
+
+// BulkMoveRequest represents a bulk document move; an empty target_folder_id
+// moves the documents to the root
+type BulkMoveRequest struct {
+	DocumentIDs    []string `json:"document_ids" validate:"required,dive,uuid"`
+	TargetFolderID string   `json:"target_folder_id,omitempty" validate:"omitempty,uuid"`
+}
+
+// BulkDocumentsResponse represents the result of a bulk delete or move
+type BulkDocumentsResponse = bulk.Response[bulk.Result]
+
+// BatchGetDocumentsRequest lists documents to fetch in one call
+type BatchGetDocumentsRequest struct {
+	IDs []string `json:"ids" validate:"required,dive,uuid"`
+}
+
+// BatchGetDocumentsResponse holds one entry per requested ID, in request
+// order; entries are null for documents that are missing, in another tenant
+// or not readable by the caller
+type BatchGetDocumentsResponse struct {
+	Documents []*Document `json:"documents"`
+}
+
+// Document change types reported by the changes feed
+const (
+	ChangeTypeCreated = "created"
+	ChangeTypeUpdated = "updated"
+	ChangeTypeDeleted = "deleted"
+)
+
+// DocumentChangesParams represents query parameters for the changes feed
+type DocumentChangesParams struct {
+	Since  string `json:"since,omitempty" form:"since" validate:"omitempty,rfc3339"`
+	Cursor string `json:"cursor,omitempty" form:"cursor"`
+	Limit  int    `json:"limit" form:"limit"`
+}
+
+// Normalize sets default values for changes feed parameters
+func (p *DocumentChangesParams) Normalize() {
+	if p.Limit < 1 {
+		p.Limit = 100
+	}
+	if p.Limit > 500 {
+		p.Limit = 500
+	}
+}
+
+// DocumentChange is one entry of the changes feed. Document is omitted for
+// deletions.
+type DocumentChange struct {
+	DocumentID uuid.UUID      `json:"document_id"`
+	ChangeType string         `json:"change_type"`
+	ChangedAt  timeutil.Time  `json:"changed_at"`
+	Document   *Document      `json:"document,omitempty"`
+	FolderID   sql.NullString `json:"-"` // authorizes deletions, whose document is gone
+}
+
+// DocumentChangeCursor marks the last change of a page; changes are ordered
+// oldest first, with the document ID breaking ties between identical times
+type DocumentChangeCursor struct {
+	ChangedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns the opaque token handed to clients as next_cursor
+func (c DocumentChangeCursor) Encode() string {
+	raw := c.ChangedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeDocumentChangeCursor parses a token produced by DocumentChangeCursor.Encode
+func DecodeDocumentChangeCursor(token string) (*DocumentChangeCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	changedAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	docID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	return &DocumentChangeCursor{ChangedAt: changedAt, ID: docID}, nil
+}
+
+// DocumentChangesPage is one page of the changes feed. Once NextCursor is
+// empty the client is caught up and passes ServerTime as its next since.
+type DocumentChangesPage struct {
+	Changes    []DocumentChange `json:"changes"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	ServerTime timeutil.Time    `json:"server_time"`
+}
+
+// CategoryAssignRequest lists documents to move into or out of a category
+type CategoryAssignRequest struct {
+	DocumentIDs []string `json:"document_ids" validate:"required,dive,uuid"`
+}
+
+// CategoryAssignResult represents the outcome for a single document
+type CategoryAssignResult struct {
+	bulk.Result
+	PreviousCategoryID string `json:"previous_category_id,omitempty"`
+}
+
+// CategoryAssignResponse represents a bulk category assignment result
+type CategoryAssignResponse = bulk.Response[CategoryAssignResult]
+
+// ReindexSearchRequest rebuilds search vectors for the tenant's documents,
+// resuming after Cursor (the last document ID of a previous run)
+type ReindexSearchRequest struct {
+	Cursor     string `json:"cursor,omitempty" validate:"omitempty,uuid"`
+	BatchSize  int    `json:"batch_size,omitempty" validate:"omitempty,gte=1,lte=1000"`
+	MaxBatches int    `json:"max_batches,omitempty" validate:"omitempty,gte=1,lte=1000"`
+}
+
+// ReindexSearchResponse reports a reindex run. When Done is false, pass
+// NextCursor back as cursor to continue.
+type ReindexSearchResponse struct {
+	Processed  int    `json:"processed"`
+	Batches    int    `json:"batches"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Done       bool   `json:"done"`
+}
+
+// DocumentExistence reports whether a document exists in the calling tenant.
+// Documents of other tenants are reported as missing.
+type DocumentExistence struct {
+	DocumentID uuid.UUID `json:"document_id"`
+	Exists     bool      `json:"exists"`
+}
+
+// DocumentPresence is a user who currently has a document open, as of their
+// last heartbeat
+type DocumentPresence struct {
+	UserID   string        `json:"user_id"`
+	Email    string        `json:"email,omitempty"`
+	LastSeen timeutil.Time `json:"last_seen"`
+}
+
+// PresenceResponse lists the users present on a document. A user drops out
+// once TTLSeconds pass without a heartbeat.
+type PresenceResponse struct {
+	DocumentID uuid.UUID          `json:"document_id"`
+	Users      []DocumentPresence `json:"users"`
+	TTLSeconds int                `json:"ttl_seconds"`
 }
 
 // CreateFolderRequest represents folder creation request
@@ -114,6 +519,24 @@ type CreateFolderRequest struct {
 	Icon        string `json:"icon,omitempty" validate:"omitempty,max=50"`
 }
 
+// MoveFolderRequest represents a folder move; an empty parent_id moves it to the root
+type MoveFolderRequest struct {
+	ParentID string `json:"parent_id,omitempty" validate:"omitempty,uuid"`
+}
+
+// MoveFolderContentsRequest moves a folder's documents, and optionally its
+// subfolders, into another folder; an empty target_folder_id means the root
+type MoveFolderContentsRequest struct {
+	TargetFolderID    string `json:"target_folder_id,omitempty" validate:"omitempty,uuid"`
+	IncludeSubfolders bool   `json:"include_subfolders"`
+}
+
+// MoveFolderContentsResult reports how much was moved out of a folder
+type MoveFolderContentsResult struct {
+	DocumentsMoved int64 `json:"documents_moved"`
+	FoldersMoved   int64 `json:"folders_moved"` // direct subfolders; their own contents move with them
+}
+
 // UpdateFolderRequest represents folder update request
 type UpdateFolderRequest struct {
 	Name        string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
@@ -123,6 +546,17 @@ type UpdateFolderRequest struct {
 	Icon        string `json:"icon,omitempty" validate:"omitempty,max=50"`
 }
 
+// SetFolderACLRequest replaces a folder's ACL; an empty entry list removes it
+type SetFolderACLRequest struct {
+	Inherit bool             `json:"inherit"`
+	Entries []FolderACLEntry `json:"entries" validate:"max=200,dive"`
+}
+
+// SetDocumentACLRequest replaces a document's ACL; an empty entry list removes it
+type SetDocumentACLRequest struct {
+	Entries []FolderACLEntry `json:"entries" validate:"max=200,dive"`
+}
+
 // CreateTagRequest represents tag creation request
 type CreateTagRequest struct {
 	Name  string `json:"name" validate:"required,min=1,max=50"`
@@ -140,10 +574,28 @@ type CreateCategoryRequest struct {
 // DocumentWithDetails includes document with related data
 type DocumentWithDetails struct {
 	Document
-	Tags         []Tag     `json:"tags,omitempty"`
-	Category     *Category `json:"category,omitempty"`
-	FolderName   string    `json:"folder_name,omitempty"`
-	UploadedByName string  `json:"uploaded_by_name,omitempty"`
+	Tags           []Tag     `json:"tags,omitempty"`
+	Category       *Category `json:"category,omitempty"`
+	FolderName     string    `json:"folder_name,omitempty"`
+	FolderPath     string    `json:"folder_path,omitempty"`
+	UploadedByName string    `json:"uploaded_by_name,omitempty"`
+
+	// Set only with ?include=shares
+	ActiveShareCount *int  `json:"active_share_count,omitempty"`
+	IsShared         *bool `json:"is_shared,omitempty"`
+}
+
+// IncludeShares is the ?include= value that adds share indicators to documents
+const IncludeShares = "shares"
+
+// IncludeTrashed is the ?include= value that returns soft-deleted documents too
+const IncludeTrashed = "trashed"
+
+// SetShareCount sets the share indicators from the document's active share count
+func (d *DocumentWithDetails) SetShareCount(count int) {
+	shared := count > 0
+	d.ActiveShareCount = &count
+	d.IsShared = &shared
 }
 
 // FolderWithContents includes folder with children and documents
@@ -154,17 +606,45 @@ type FolderWithContents struct {
 	DocumentCount int        `json:"document_count"`
 }
 
+// FolderTreeNode is a folder with its subfolders nested below it
+type FolderTreeNode struct {
+	Folder
+	Children []*FolderTreeNode `json:"children"`
+}
+
+// UploadedByMe selects the caller's own documents in ListDocumentsParams.UploadedBy
+const UploadedByMe = "me"
+
 // ListDocumentsParams represents query parameters for listing documents
 type ListDocumentsParams struct {
-	FolderID   string `json:"folder_id,omitempty" form:"folder_id"`
-	CategoryID string `json:"category_id,omitempty" form:"category_id"`
-	Tags       string `json:"tags,omitempty" form:"tags"` // Comma-separated tag IDs
-	Status     string `json:"status,omitempty" form:"status"`
-	Search     string `json:"search,omitempty" form:"search"`
-	Page       int    `json:"page" form:"page" validate:"omitempty,gte=1"`
-	Limit      int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
-	SortBy     string `json:"sort_by,omitempty" form:"sort_by"`
-	SortOrder  string `json:"sort_order,omitempty" form:"sort_order" validate:"omitempty,oneof=asc desc"`
+	FolderID   string   `json:"folder_id,omitempty" form:"folder_id"`
+	FolderIDs  []string `json:"folder_ids,omitempty" form:"folder_ids" validate:"omitempty,max=50,dive,uuid"` // Comma-separated folder IDs
+	CategoryID string   `json:"category_id,omitempty" form:"category_id"`
+	Tags       string   `json:"tags,omitempty" form:"tags"` // Comma-separated tag IDs
+	Status     string   `json:"status,omitempty" form:"status"`
+	Search     string   `json:"search,omitempty" form:"search"`
+	UploadedBy string   `json:"uploaded_by,omitempty" form:"uploaded_by" validate:"omitempty,max=255"` // "me" for the caller; other users need document:manage
+	FileType   string   `json:"file_type,omitempty" form:"file_type" validate:"omitempty,max=50"`
+	MinSize    int64    `json:"min_size,omitempty" form:"min_size" validate:"omitempty,gte=0"` // bytes, inclusive
+	MaxSize    int64    `json:"max_size,omitempty" form:"max_size" validate:"omitempty,gte=0"` // bytes, inclusive
+	WithShares bool     `json:"-"`                                                             // ?include=shares
+	Page       int      `json:"page" form:"page" validate:"omitempty,gte=1"`
+	Limit      int      `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
+	SortBy     string   `json:"sort_by,omitempty" form:"sort_by" validate:"omitempty,oneof=name file_type file_size status created_at updated_at"`
+	SortOrder  string   `json:"sort_order,omitempty" form:"sort_order" validate:"omitempty,oneof=asc desc"`
+
+	// Date ranges, parsed from RFC3339 query parameters; after is inclusive,
+	// before exclusive, and zero means unbounded
+	CreatedAfter  time.Time `json:"created_after,omitempty"`
+	CreatedBefore time.Time `json:"created_before,omitempty"`
+	UpdatedAfter  time.Time `json:"updated_after,omitempty"`
+	UpdatedBefore time.Time `json:"updated_before,omitempty"`
+
+	// Access limits results to what the caller may read; set by the service
+	Access *DocumentAccessFilter `json:"-"`
+
+	// IncludeTrashed returns soft-deleted documents too (?include=trashed)
+	IncludeTrashed bool `json:"-"`
 }
 
 // Normalize sets default values for list parameters
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinFolderPath(t *testing.T) {
+	tests := []struct {
+		name       string
+		parentPath string
+		folder     string
+		want       string
+	}{
+		{"root level", "", "Finance", "/Finance"},
+		{"nested", "/Finance/2024", "Q1", "/Finance/2024/Q1"},
+		{"separator in name is escaped", "/Finance", "Q1/Q2", "/Finance/Q1%2FQ2"},
+		{"percent in name is escaped", "", "100% done", "/100%25 done"},
+		{"escaped sequence in name is not decoded later", "", "a%2Fb", "/a%252Fb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JoinFolderPath(tt.parentPath, tt.folder); got != tt.want {
+				t.Errorf("JoinFolderPath(%q, %q) = %q, want %q", tt.parentPath, tt.folder, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitFolderPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"empty path", "", nil},
+		{"separator only", "/", nil},
+		{"root level", "/Finance", []string{"Finance"}},
+		{"nested", "/Finance/2024/Q1", []string{"Finance", "2024", "Q1"}},
+		{"escaped names are decoded", "/Finance/Q1%2FQ2/100%25 done", []string{"Finance", "Q1/Q2", "100% done"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitFolderPath(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitFolderPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFolderPathRoundTrip(t *testing.T) {
+	names := []string{"Finance", "Q1/Q2", "100%", "a%2Fb", "%%//"}
+
+	path := ""
+	for _, name := range names {
+		path = JoinFolderPath(path, name)
+	}
+
+	if got := SplitFolderPath(path); !reflect.DeepEqual(got, names) {
+		t.Errorf("SplitFolderPath(%q) = %q, want %q", path, got, names)
+	}
+}
@@ -1,13 +1,17 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/models"
@@ -30,47 +34,82 @@ func NewRepository(db *database.DB, logger *zap.Logger) *Repository {
 
 // Document operations
 
-// CreateDocument creates a new document
-func (r *Repository) CreateDocument(ctx context.Context, doc *models.Document) error {
+// CreateDocument creates a document with its tag associations and its OCR
+// job in one transaction, incrementing the usage count of each tag and the document
+// count of its category. created lists the tags not saved yet, pointing into
+// tags; they are inserted in the same transaction. Tag and category IDs must
+// already be validated as belonging to the document's tenant.
+func (r *Repository) CreateDocument(ctx context.Context, doc *models.Document, tags []models.Tag, created []*models.Tag) error {
 	query := `
 		INSERT INTO documents (
 			id, tenant_id, folder_id, name, description, file_type, file_size,
 			mime_type, storage_path, thumbnail_path, status, uploaded_by,
-			category_id, ocr_status, version, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			category_id, ocr_status, ocr_language, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		doc.ID, doc.TenantID, doc.FolderID, doc.Name, doc.Description,
-		doc.FileType, doc.FileSize, doc.MimeType, doc.StoragePath,
-		doc.ThumbnailPath, doc.Status, doc.UploadedBy, doc.CategoryID,
-		doc.OCRStatus, doc.Version, doc.CreatedAt, doc.UpdatedAt,
-	)
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			doc.ID, doc.TenantID, doc.FolderID, doc.Name, doc.Description,
+			doc.FileType, doc.FileSize, doc.MimeType, doc.StoragePath,
+			doc.ThumbnailPath, doc.Status, doc.UploadedBy, doc.CategoryID,
+			doc.OCRStatus, doc.OCRLanguage, doc.Version, doc.CreatedAt, doc.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to create document", zap.Error(err))
+			return database.WrapError("failed to create document", err)
+		}
 
-	if err != nil {
-		r.logger.Error("failed to create document", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to create document", err)
-	}
+		if err := r.insertOCRJob(ctx, tx, &models.OCRJob{
+			ID:          uuid.New(),
+			DocumentID:  doc.ID,
+			TenantID:    doc.TenantID,
+			RequestedBy: doc.UploadedBy,
+			Language:    doc.OCRLanguage.String,
+			CreatedAt:   doc.CreatedAt,
+		}); err != nil {
+			return err
+		}
 
-	return nil
+		if err := r.insertTags(ctx, tx, created); err != nil {
+			return err
+		}
+
+		if len(tags) > 0 {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO document_tags (document_id, tag_id, created_at)
+				SELECT $1, tag_id, $3 FROM unnest($2::uuid[]) AS tag_id
+				ON CONFLICT (document_id, tag_id) DO NOTHING`,
+				doc.ID, pq.Array(tagIDsOf(tags)), doc.CreatedAt,
+			)
+			if err != nil {
+				r.logger.Error("failed to add document tags", zap.Error(err))
+				return database.WrapError("failed to add tags", err)
+			}
+			// tags.usage_count is maintained by the document_tags triggers
+		}
+
+		return r.incrementCategoryDocumentCount(ctx, tx, doc.TenantID, doc.CategoryID, 1)
+	})
 }
 
-// GetDocument retrieves a document by ID
-func (r *Repository) GetDocument(ctx context.Context, tenantID, docID uuid.UUID) (*models.Document, error) {
+// GetDocument retrieves a document by ID; a soft-deleted document is only
+// found when includeTrashed is set
+func (r *Repository) GetDocument(ctx context.Context, tenantID, docID uuid.UUID, includeTrashed bool) (*models.Document, error) {
+	where := database.NotDeleted([]string{"id = $1", "tenant_id = $2"}, "", includeTrashed)
 	query := `
 		SELECT id, tenant_id, folder_id, name, description, file_type, file_size,
 		       mime_type, storage_path, thumbnail_path, status, uploaded_by,
-		       category_id, ocr_status, version, created_at, updated_at
+		       category_id, ocr_status, ocr_language, version, is_pinned, created_at, updated_at
 		FROM documents
-		WHERE id = $1 AND tenant_id = $2
-	`
+		WHERE ` + strings.Join(where, " AND ")
 
 	var doc models.Document
 	err := r.db.QueryRowContext(ctx, query, docID, tenantID).Scan(
 		&doc.ID, &doc.TenantID, &doc.FolderID, &doc.Name, &doc.Description,
 		&doc.FileType, &doc.FileSize, &doc.MimeType, &doc.StoragePath,
 		&doc.ThumbnailPath, &doc.Status, &doc.UploadedBy, &doc.CategoryID,
-		&doc.OCRStatus, &doc.Version, &doc.CreatedAt, &doc.UpdatedAt,
+		&doc.OCRStatus, &doc.OCRLanguage, &doc.Version, &doc.IsPinned, &doc.CreatedAt, &doc.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -78,60 +117,178 @@ func (r *Repository) GetDocument(ctx context.Context, tenantID, docID uuid.UUID)
 	}
 	if err != nil {
 		r.logger.Error("failed to get document", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get document", err)
+		return nil, database.WrapError("failed to get document", err)
 	}
 
 	return &doc, nil
 }
 
-// ListDocuments retrieves documents with filtering and pagination
-func (r *Repository) ListDocuments(ctx context.Context, tenantID uuid.UUID, params *models.ListDocumentsParams) ([]models.Document, int64, error) {
+// GetDocuments retrieves the documents of a tenant among ids in one query;
+// IDs that are missing, soft-deleted or belong to another tenant are left out
+func (r *Repository) GetDocuments(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID) ([]models.Document, error) {
+	where := database.NotDeleted([]string{"id = ANY($1)", "tenant_id = $2"}, "", false)
+	query := `
+		SELECT id, tenant_id, folder_id, name, description, file_type, file_size,
+		       mime_type, storage_path, thumbnail_path, status, uploaded_by,
+		       category_id, ocr_status, ocr_language, version, is_pinned, created_at, updated_at
+		FROM documents
+		WHERE ` + strings.Join(where, " AND ")
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), tenantID)
+	if err != nil {
+		r.logger.Error("failed to get documents", zap.Error(err))
+		return nil, database.WrapError("failed to get documents", err)
+	}
+	defer rows.Close()
+
+	var documents []models.Document
+	for rows.Next() {
+		var doc models.Document
+		err := rows.Scan(
+			&doc.ID, &doc.TenantID, &doc.FolderID, &doc.Name, &doc.Description,
+			&doc.FileType, &doc.FileSize, &doc.MimeType, &doc.StoragePath,
+			&doc.ThumbnailPath, &doc.Status, &doc.UploadedBy, &doc.CategoryID,
+			&doc.OCRStatus, &doc.OCRLanguage, &doc.Version, &doc.IsPinned, &doc.CreatedAt, &doc.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan document", zap.Error(err))
+			return nil, database.WrapError("failed to get documents", err)
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents, rows.Err()
+}
+
+// DocumentExists reports whether a document exists in a tenant and is not
+// soft-deleted
+func (r *Repository) DocumentExists(ctx context.Context, tenantID, docID uuid.UUID) (bool, error) {
+	where := database.NotDeleted([]string{"id = $1", "tenant_id = $2"}, "", false)
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM documents WHERE `+strings.Join(where, " AND ")+`)`,
+		docID, tenantID,
+	).Scan(&exists)
+	if err != nil {
+		r.logger.Error("failed to check document existence", zap.Error(err))
+		return false, database.WrapError("failed to check document", err)
+	}
+
+	return exists, nil
+}
+
+// ListDocuments retrieves documents with filtering and pagination. Each
+// document carries the name and path of its folder; root documents have none.
+func (r *Repository) ListDocuments(ctx context.Context, tenantID uuid.UUID, params *models.ListDocumentsParams) ([]models.DocumentWithDetails, int64, error) {
 	// Build WHERE clause
-	whereClauses := []string{"tenant_id = $1"}
+	whereClauses := database.NotDeleted([]string{"d.tenant_id = $1"}, "d", params.IncludeTrashed)
 	args := []interface{}{tenantID}
 	argPos := 2
 
 	if params.FolderID != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("folder_id = $%d", argPos))
+		whereClauses = append(whereClauses, fmt.Sprintf("d.folder_id = $%d", argPos))
 		args = append(args, params.FolderID)
 		argPos++
 	}
 
+	if len(params.FolderIDs) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("d.folder_id = ANY($%d)", argPos))
+		args = append(args, pq.Array(params.FolderIDs))
+		argPos++
+	}
+
 	if params.CategoryID != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("category_id = $%d", argPos))
+		whereClauses = append(whereClauses, fmt.Sprintf("d.category_id = $%d", argPos))
 		args = append(args, params.CategoryID)
 		argPos++
 	}
 
 	if params.Status != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("status = $%d", argPos))
+		whereClauses = append(whereClauses, fmt.Sprintf("d.status = $%d", argPos))
 		args = append(args, params.Status)
 		argPos++
 	}
 
+	// Full-text match on the trigger-maintained search_vector; documents
+	// indexed before a weighting change need POST /api/documents/reindex-search
 	if params.Search != "" {
-		whereClauses = append(whereClauses, fmt.Sprintf("(name ILIKE $%d OR description ILIKE $%d)", argPos, argPos))
-		args = append(args, "%"+params.Search+"%")
+		whereClauses = append(whereClauses, fmt.Sprintf("d.search_vector @@ plainto_tsquery('english', $%d)", argPos))
+		args = append(args, params.Search)
+		argPos++
+	}
+
+	// Equality on tenant and uploader with the default created_at order can be
+	// served by an index on (tenant_id, uploaded_by, created_at)
+	if params.UploadedBy != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("d.uploaded_by = $%d", argPos))
+		args = append(args, params.UploadedBy)
+		argPos++
+	}
+
+	if params.FileType != "" {
+		whereClauses = append(whereClauses, fmt.Sprintf("d.file_type = $%d", argPos))
+		args = append(args, params.FileType)
+		argPos++
+	}
+
+	if params.MinSize > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("d.file_size >= $%d", argPos))
+		args = append(args, params.MinSize)
+		argPos++
+	}
+
+	if params.MaxSize > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("d.file_size <= $%d", argPos))
+		args = append(args, params.MaxSize)
+		argPos++
+	}
+
+	if params.Access != nil {
+		clause, accessArgs, next := documentAccessClause(params.Access, argPos)
+		whereClauses = append(whereClauses, clause)
+		args = append(args, accessArgs...)
+		argPos = next
+	}
+
+	// Date ranges: after is inclusive, before exclusive
+	for _, bound := range []struct {
+		condition string
+		value     time.Time
+	}{
+		{"d.created_at >= $%d", params.CreatedAfter},
+		{"d.created_at < $%d", params.CreatedBefore},
+		{"d.updated_at >= $%d", params.UpdatedAfter},
+		{"d.updated_at < $%d", params.UpdatedBefore},
+	} {
+		if bound.value.IsZero() {
+			continue
+		}
+		whereClauses = append(whereClauses, fmt.Sprintf(bound.condition, argPos))
+		args = append(args, bound.value)
 		argPos++
 	}
 
 	whereClause := strings.Join(whereClauses, " AND ")
 
 	// Count total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM documents WHERE %s", whereClause)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM documents d WHERE %s", whereClause)
 	var total int64
 	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, errors.Wrap(errors.ErrCodeDatabase, "failed to count documents", err)
+		return nil, 0, database.WrapError("failed to count documents", err)
 	}
 
-	// Get documents
+	// Get documents, pinned ones first; the folder join is on the folders
+	// primary key and is scoped to the tenant so a stale folder_id can never
+	// leak another name
 	query := fmt.Sprintf(`
-		SELECT id, tenant_id, folder_id, name, description, file_type, file_size,
-		       mime_type, storage_path, thumbnail_path, status, uploaded_by,
-		       category_id, ocr_status, version, created_at, updated_at
-		FROM documents
+		SELECT d.id, d.tenant_id, d.folder_id, d.name, d.description, d.file_type, d.file_size,
+		       d.mime_type, d.storage_path, d.thumbnail_path, d.status, d.uploaded_by,
+		       d.category_id, d.ocr_status, d.ocr_language, d.version, d.is_pinned, d.created_at, d.updated_at,
+		       COALESCE(f.name, ''), COALESCE(f.path, '')
+		FROM documents d
+		LEFT JOIN folders f ON f.id = d.folder_id AND f.tenant_id = d.tenant_id
 		WHERE %s
-		ORDER BY %s %s
+		ORDER BY d.is_pinned DESC, d.%s %s
 		LIMIT $%d OFFSET $%d
 	`, whereClause, params.SortBy, params.SortOrder, argPos, argPos+1)
 
@@ -140,193 +297,1440 @@ func (r *Repository) ListDocuments(ctx context.Context, tenantID uuid.UUID, para
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to list documents", zap.Error(err))
-		return nil, 0, errors.Wrap(errors.ErrCodeDatabase, "failed to list documents", err)
+		return nil, 0, database.WrapError("failed to list documents", err)
 	}
 	defer rows.Close()
 
-	var documents []models.Document
+	var documents []models.DocumentWithDetails
 	for rows.Next() {
-		var doc models.Document
+		// A canceled request stops the scan; the deferred rows.Close still runs
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		var doc models.DocumentWithDetails
 		err := rows.Scan(
 			&doc.ID, &doc.TenantID, &doc.FolderID, &doc.Name, &doc.Description,
 			&doc.FileType, &doc.FileSize, &doc.MimeType, &doc.StoragePath,
 			&doc.ThumbnailPath, &doc.Status, &doc.UploadedBy, &doc.CategoryID,
-			&doc.OCRStatus, &doc.Version, &doc.CreatedAt, &doc.UpdatedAt,
+			&doc.OCRStatus, &doc.OCRLanguage, &doc.Version, &doc.IsPinned, &doc.CreatedAt, &doc.UpdatedAt,
+			&doc.FolderName, &doc.FolderPath,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan document", zap.Error(err))
+			continue
+		}
+		documents = append(documents, doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return documents, total, nil
+}
+
+// likeEscaper escapes LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// QuickSearchDocuments returns documents whose name contains query or that
+// carry a tag starting with it, without duplicates. Name prefix matches rank
+// first, then other name matches, then tag matches. The ILIKE patterns can
+// use pg_trgm GIN indexes on documents.name and tags.name. A non-nil access
+// filter drops documents the caller may not read.
+func (r *Repository) QuickSearchDocuments(ctx context.Context, tenantID uuid.UUID, query string, limit int, access *models.DocumentAccessFilter) ([]models.QuickSearchResult, error) {
+	escaped := likeEscaper.Replace(query)
+	prefix := escaped + "%"
+	contains := "%" + escaped + "%"
+
+	args := []interface{}{tenantID, prefix, contains, limit}
+	accessClause := "TRUE"
+	if access != nil {
+		var accessArgs []interface{}
+		accessClause, accessArgs, _ = documentAccessClause(access, len(args)+1)
+		args = append(args, accessArgs...)
+	}
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT id, name, file_type
+		FROM (
+			SELECT DISTINCT ON (m.id) m.id, m.name, m.file_type, m.rank
+			FROM (
+				SELECT d.id, d.name, d.file_type,
+				       CASE WHEN d.name ILIKE $2 THEN 0 ELSE 1 END AS rank
+				FROM documents d
+				WHERE d.tenant_id = $1 AND d.deleted_at IS NULL AND d.name ILIKE $3 AND %[1]s
+				UNION ALL
+				SELECT d.id, d.name, d.file_type, 2 AS rank
+				FROM tags t
+				INNER JOIN document_tags dt ON dt.tag_id = t.id
+				INNER JOIN documents d ON d.id = dt.document_id AND d.tenant_id = t.tenant_id
+				WHERE t.tenant_id = $1 AND d.deleted_at IS NULL AND t.name ILIKE $2 AND %[1]s
+			) m
+			ORDER BY m.id, m.rank
+		) matches
+		ORDER BY rank, lower(name), id
+		LIMIT $4
+	`, accessClause)
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		r.logger.Error("failed to quick search documents", zap.Error(err))
+		return nil, database.WrapError("failed to search documents", err)
+	}
+	defer rows.Close()
+
+	var results []models.QuickSearchResult
+	for rows.Next() {
+		var result models.QuickSearchResult
+		if err := rows.Scan(&result.ID, &result.Name, &result.FileType); err != nil {
+			r.logger.Error("failed to scan quick search result", zap.Error(err))
+			continue
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// UpdateDocument updates the given document columns. A category_id change
+// moves the document between the categories' document counts. A non-nil tags
+// becomes the document's exact tag set; created lists the tags not saved yet,
+// pointing into tags. The columns, new tags and tag set change in one
+// transaction.
+func (r *Repository) UpdateDocument(ctx context.Context, tenantID, docID uuid.UUID, updates map[string]interface{}, tags *[]models.Tag, created []*models.Tag) error {
+	if len(updates) == 0 && tags == nil {
+		return nil
+	}
+
+	// Build SET clause
+	setClauses := []string{}
+	args := []interface{}{}
+	argPos := 1
+
+	for key, value := range updates {
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", key, argPos))
+		args = append(args, value)
+		argPos++
+	}
+
+	// Add updated_at
+	setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argPos))
+	args = append(args, time.Now())
+	argPos++
+
+	// Add WHERE conditions
+	args = append(args, docID, tenantID)
+
+	query := fmt.Sprintf(`
+		UPDATE documents
+		SET %s
+		WHERE id = $%d AND tenant_id = $%d
+	`, strings.Join(setClauses, ", "), argPos, argPos+1)
+
+	newCategoryID, categoryChanging := updates["category_id"].(sql.NullString)
+
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		// Lock the row to read the category the update moves the document from
+		var oldCategoryID sql.NullString
+		if categoryChanging {
+			err := tx.QueryRowContext(ctx,
+				`SELECT category_id FROM documents WHERE id = $1 AND tenant_id = $2 FOR UPDATE`,
+				docID, tenantID,
+			).Scan(&oldCategoryID)
+			if err == sql.ErrNoRows {
+				return errors.NotFoundf("document not found")
+			}
+			if err != nil {
+				r.logger.Error("failed to lock document", zap.Error(err))
+				return database.WrapError("failed to update document", err)
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			r.logger.Error("failed to update document", zap.Error(err))
+			return database.WrapError("failed to update document", err)
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return errors.NotFoundf("document not found")
+		}
+
+		if tags != nil {
+			if err := r.insertTags(ctx, tx, created); err != nil {
+				return err
+			}
+			if err := r.replaceDocumentTags(ctx, tx, docID, tagIDsOf(*tags)); err != nil {
+				return err
+			}
+		}
+
+		if !categoryChanging || oldCategoryID == newCategoryID {
+			return nil
+		}
+		if err := r.decrementCategoryDocumentCount(ctx, tx, tenantID, oldCategoryID, 1); err != nil {
+			return err
+		}
+		return r.incrementCategoryDocumentCount(ctx, tx, tenantID, newCategoryID, 1)
+	})
+}
+
+// PinDocument pins a document in its folder (the root when folderID is NULL)
+// unless the folder already has maxPins pinned documents. Pins of the same
+// folder are serialized so concurrent pins cannot exceed the limit. Pinning
+// does not change updated_at.
+func (r *Repository) PinDocument(ctx context.Context, tenantID, docID uuid.UUID, folderID sql.NullString, maxPins int) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		scope := "document_pins|" + tenantID.String() + "|" + folderID.String
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, scope); err != nil {
+			return database.WrapError("failed to pin document", err)
+		}
+
+		var pinned int
+		err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM documents
+			WHERE tenant_id = $1 AND folder_id IS NOT DISTINCT FROM $2 AND is_pinned AND id <> $3`,
+			tenantID, folderID, docID,
+		).Scan(&pinned)
+		if err != nil {
+			r.logger.Error("failed to count pinned documents", zap.Error(err))
+			return database.WrapError("failed to pin document", err)
+		}
+		if pinned >= maxPins {
+			return errors.Validationf("a folder can have at most %d pinned documents", maxPins).
+				WithMeta("max_pins", maxPins)
+		}
+
+		result, err := tx.ExecContext(ctx,
+			`UPDATE documents SET is_pinned = true WHERE id = $1 AND tenant_id = $2`,
+			docID, tenantID,
+		)
+		if err != nil {
+			r.logger.Error("failed to pin document", zap.Error(err))
+			return database.WrapError("failed to pin document", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return errors.NotFoundf("document not found")
+		}
+
+		return nil
+	})
+}
+
+// UnpinDocument clears a document's pin; unpinning is idempotent
+func (r *Repository) UnpinDocument(ctx context.Context, tenantID, docID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE documents SET is_pinned = false WHERE id = $1 AND tenant_id = $2`,
+		docID, tenantID,
+	)
+	if err != nil {
+		r.logger.Error("failed to unpin document", zap.Error(err))
+		return database.WrapError("failed to unpin document", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return errors.NotFoundf("document not found")
+	}
+
+	return nil
+}
+
+// CountActiveShares returns the number of usable shares per document:
+// active, not expired, below their access limit and not idle past their
+// inactivity window. Documents without such shares are absent from the map.
+func (r *Repository) CountActiveShares(ctx context.Context, tenantID uuid.UUID, docIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int)
+	if len(docIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT document_id, COUNT(*)
+		FROM shares
+		WHERE tenant_id = $1 AND document_id = ANY($2)
+		  AND is_active
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND (max_access IS NULL OR access_count < max_access)
+		  AND (expire_after_inactivity IS NULL OR expire_after_inactivity <= 0
+		       OR COALESCE(last_accessed_at, created_at) + expire_after_inactivity * INTERVAL '1 second' > NOW())
+		GROUP BY document_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, pq.Array(docIDs))
+	if err != nil {
+		r.logger.Error("failed to count document shares", zap.Error(err))
+		return nil, database.WrapError("failed to count document shares", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			docID uuid.UUID
+			count int
+		)
+		if err := rows.Scan(&docID, &count); err != nil {
+			return nil, database.WrapError("failed to scan share count", err)
+		}
+		counts[docID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetDocumentVersion retrieves a stored version of a tenant's document
+func (r *Repository) GetDocumentVersion(ctx context.Context, tenantID, docID uuid.UUID, versionNumber int) (*models.DocumentVersion, error) {
+	query := `
+		SELECT v.id, v.document_id, v.version_number, v.file_size, v.storage_path,
+		       v.uploaded_by, COALESCE(v.comment, ''), v.created_at
+		FROM document_versions v
+		JOIN documents d ON d.id = v.document_id
+		WHERE v.document_id = $1 AND d.tenant_id = $2 AND v.version_number = $3`
+
+	var version models.DocumentVersion
+	err := r.db.QueryRowContext(ctx, query, docID, tenantID, versionNumber).Scan(
+		&version.ID, &version.DocumentID, &version.VersionNumber, &version.FileSize,
+		&version.StoragePath, &version.UploadedBy, &version.Comment, &version.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundf("version %d not found", versionNumber)
+	}
+	if err != nil {
+		r.logger.Error("failed to get document version", zap.Error(err))
+		return nil, database.WrapError("failed to get document version", err)
+	}
+
+	return &version, nil
+}
+
+// RestoreDocumentVersion makes restored the current content of doc. In one
+// transaction it records the content being replaced as version doc.Version
+// (if not recorded yet), points the document at the restored object under
+// the next version number, and adds a version entry for the restore. It
+// fails with a conflict if the document changed since doc was read.
+func (r *Repository) RestoreDocumentVersion(ctx context.Context, doc *models.Document, restored *models.DocumentVersion, mimeType string) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO document_versions (id, document_id, version_number, file_size, storage_path, uploaded_by, comment, created_at)
+			SELECT $1, $2, $3, $4, $5, $6, NULL, $7
+			WHERE NOT EXISTS (
+				SELECT 1 FROM document_versions WHERE document_id = $2 AND version_number = $3
+			)`,
+			uuid.New(), doc.ID, doc.Version, doc.FileSize, doc.StoragePath, doc.UploadedBy, doc.UpdatedAt.Time,
+		)
+		if err != nil {
+			r.logger.Error("failed to record replaced version", zap.Error(err))
+			return database.WrapError("failed to restore document version", err)
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE documents
+			SET storage_path = $1, file_size = $2, mime_type = $3, version = $4, updated_at = $5
+			WHERE id = $6 AND tenant_id = $7 AND version = $8`,
+			restored.StoragePath, restored.FileSize, mimeType, restored.VersionNumber, restored.CreatedAt.Time,
+			doc.ID, doc.TenantID, doc.Version,
+		)
+		if err != nil {
+			r.logger.Error("failed to update restored document", zap.Error(err))
+			return database.WrapError("failed to restore document version", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return errors.Conflictf("document was modified during restore, retry")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO document_versions (id, document_id, version_number, file_size, storage_path, uploaded_by, comment, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			restored.ID, restored.DocumentID, restored.VersionNumber, restored.FileSize,
+			restored.StoragePath, restored.UploadedBy, restored.Comment, restored.CreatedAt.Time,
+		)
+		if err != nil {
+			r.logger.Error("failed to record restored version", zap.Error(err))
+			return database.WrapError("failed to restore document version", err)
+		}
+
+		return nil
+	})
+}
+
+// RetryOCR resets a document's OCR status to pending with the given language
+// hint and queues a new OCR job in one transaction. Pending jobs for the
+// document are marked failed as superseded. It returns a conflict error while
+// OCR is processing.
+func (r *Repository) RetryOCR(ctx context.Context, tenantID, docID uuid.UUID, language sql.NullString, job *models.OCRJob) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE documents SET ocr_status = $3, ocr_language = $4, updated_at = $5
+			WHERE id = $1 AND tenant_id = $2 AND ocr_status <> $6`,
+			docID, tenantID, models.OCRStatusPending, language, job.CreatedAt, models.OCRStatusProcessing,
+		)
+		if err != nil {
+			r.logger.Error("failed to reset document OCR status", zap.Error(err))
+			return database.WrapError("failed to reset OCR status", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return errors.Conflictf("OCR is already processing for this document")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE ocr_jobs SET status = 'failed', error_message = 'superseded by retry', updated_at = $3
+			WHERE document_id = $1 AND tenant_id = $2 AND status = 'pending'`,
+			docID, tenantID, job.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to supersede pending OCR jobs", zap.Error(err))
+			return database.WrapError("failed to reset OCR status", err)
+		}
+
+		return r.insertOCRJob(ctx, tx, job)
+	})
+}
+
+// incrementCategoryDocumentCount adds n documents to a category's count; a
+// NULL category is a no-op
+func (r *Repository) incrementCategoryDocumentCount(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, categoryID sql.NullString, n int) error {
+	if !categoryID.Valid || n == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE categories SET document_count = document_count + $3, updated_at = $4 WHERE id = $1 AND tenant_id = $2`,
+		categoryID.String, tenantID, n, time.Now(),
+	)
+	if err != nil {
+		r.logger.Error("failed to update category document count", zap.Error(err))
+		return database.WrapError("failed to update category document count", err)
+	}
+	return nil
+}
+
+// decrementCategoryDocumentCount removes n documents from a category's count,
+// never going below zero; a NULL category is a no-op
+func (r *Repository) decrementCategoryDocumentCount(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, categoryID sql.NullString, n int) error {
+	if !categoryID.Valid || n == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE categories SET document_count = GREATEST(document_count - $3, 0), updated_at = $4 WHERE id = $1 AND tenant_id = $2`,
+		categoryID.String, tenantID, n, time.Now(),
+	)
+	if err != nil {
+		r.logger.Error("failed to update category document count", zap.Error(err))
+		return database.WrapError("failed to update category document count", err)
+	}
+	return nil
+}
+
+// insertOCRJob queues an OCR job; retry_count is the number of earlier jobs
+// for the document
+func (r *Repository) insertOCRJob(ctx context.Context, tx *sql.Tx, job *models.OCRJob) error {
+	metadata := "{}"
+	if job.Language != "" {
+		data, _ := json.Marshal(map[string]string{"language": job.Language})
+		metadata = string(data)
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ocr_jobs (id, document_id, tenant_id, requested_by, status, retry_count, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending',
+			(SELECT COUNT(*) FROM ocr_jobs WHERE document_id = $2), $5::jsonb, $6, $6)`,
+		job.ID, job.DocumentID, job.TenantID, job.RequestedBy, metadata, job.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to queue OCR job", zap.Error(err))
+		return database.WrapError("failed to queue OCR job", err)
+	}
+
+	return nil
+}
+
+// DeleteDocument deletes a document
+// and leaves a tombstone so the changes feed can report the deletion
+func (r *Repository) DeleteDocument(ctx context.Context, tenantID, docID uuid.UUID) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var folderID, categoryID sql.NullString
+		err := tx.QueryRowContext(ctx,
+			`DELETE FROM documents WHERE id = $1 AND tenant_id = $2 RETURNING folder_id, category_id`,
+			docID, tenantID,
+		).Scan(&folderID, &categoryID)
+		if err == sql.ErrNoRows {
+			return errors.NotFoundf("document not found")
+		}
+		if err != nil {
+			r.logger.Error("failed to delete document", zap.Error(err))
+			return database.WrapError("failed to delete document", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO document_tombstones (document_id, tenant_id, folder_id, deleted_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (document_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at`,
+			docID, tenantID, folderID, time.Now(),
+		)
+		if err != nil {
+			r.logger.Error("failed to record document tombstone", zap.Error(err))
+			return database.WrapError("failed to delete document", err)
+		}
+
+		return r.decrementCategoryDocumentCount(ctx, tx, tenantID, categoryID, 1)
+	})
+}
+
+// DeleteDocuments deletes the given documents in one transaction, leaving a
+// tombstone for each like DeleteDocument. It returns the IDs actually deleted;
+// documents that are missing or soft-deleted are skipped.
+func (r *Repository) DeleteDocuments(ctx context.Context, tenantID uuid.UUID, docIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var deleted []uuid.UUID
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		deleted = nil
+
+		rows, err := tx.QueryContext(ctx, `
+			WITH deleted AS (
+				DELETE FROM documents
+				WHERE tenant_id = $1 AND id = ANY($2) AND deleted_at IS NULL
+				RETURNING id, folder_id, category_id
+			), tombstones AS (
+				INSERT INTO document_tombstones (document_id, tenant_id, folder_id, deleted_at)
+				SELECT id, $1, folder_id, $3 FROM deleted
+				ON CONFLICT (document_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at
+			)
+			SELECT id, category_id FROM deleted
+		`, tenantID, pq.Array(docIDs), time.Now())
+		if err != nil {
+			r.logger.Error("failed to delete documents", zap.Error(err))
+			return database.WrapError("failed to delete documents", err)
+		}
+		defer rows.Close()
+
+		perCategory := make(map[sql.NullString]int)
+		for rows.Next() {
+			var id uuid.UUID
+			var categoryID sql.NullString
+			if err := rows.Scan(&id, &categoryID); err != nil {
+				return database.WrapError("failed to delete documents", err)
+			}
+			deleted = append(deleted, id)
+			perCategory[categoryID]++
+		}
+		if err := rows.Err(); err != nil {
+			return database.WrapError("failed to delete documents", err)
+		}
+		rows.Close()
+
+		for categoryID, n := range perCategory {
+			if err := r.decrementCategoryDocumentCount(ctx, tx, tenantID, categoryID, n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// MoveDocuments sets folder_id (NULL for the root) on the given documents and
+// returns the IDs actually moved; documents that are missing or soft-deleted
+// are skipped
+func (r *Repository) MoveDocuments(ctx context.Context, tenantID uuid.UUID, docIDs []uuid.UUID, folderID sql.NullString) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE documents
+		SET folder_id = $1, updated_at = $2
+		WHERE tenant_id = $3 AND id = ANY($4) AND deleted_at IS NULL
+		RETURNING id
+	`, folderID, time.Now(), tenantID, pq.Array(docIDs))
+	if err != nil {
+		r.logger.Error("failed to move documents", zap.Error(err))
+		return nil, database.WrapError("failed to move documents", err)
+	}
+	defer rows.Close()
+
+	var moved []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, database.WrapError("failed to move documents", err)
+		}
+		moved = append(moved, id)
+	}
+
+	return moved, rows.Err()
+}
+
+// ListDocumentChanges returns up to limit documents created, updated or
+// deleted after since, oldest change first and after the cursor position
+// when set. Deletions come from tombstones and from soft-deleted rows; the
+// documents of other changes are not loaded.
+func (r *Repository) ListDocumentChanges(ctx context.Context, tenantID uuid.UUID, since time.Time, cursor *models.DocumentChangeCursor, limit int) ([]models.DocumentChange, error) {
+	query := `
+		WITH changes AS (
+			SELECT id,
+			       GREATEST(updated_at, COALESCE(deleted_at, updated_at)) AS changed_at,
+			       CASE
+			           WHEN deleted_at IS NOT NULL THEN 'deleted'
+			           WHEN created_at > $2 THEN 'created'
+			           ELSE 'updated'
+			       END AS change_type,
+			       folder_id
+			FROM documents
+			WHERE tenant_id = $1 AND (updated_at > $2 OR deleted_at > $2)
+
+			UNION ALL
+
+			SELECT document_id, deleted_at, 'deleted', folder_id
+			FROM document_tombstones
+			WHERE tenant_id = $1 AND deleted_at > $2
+		)
+		SELECT id, changed_at, change_type, folder_id
+		FROM changes`
+
+	args := []interface{}{tenantID, since}
+	argPos := 3
+
+	if cursor != nil {
+		query += fmt.Sprintf(" WHERE (changed_at, id) > ($%d, $%d)", argPos, argPos+1)
+		args = append(args, cursor.ChangedAt, cursor.ID)
+		argPos += 2
+	}
+
+	query += fmt.Sprintf(" ORDER BY changed_at ASC, id ASC LIMIT $%d", argPos)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to list document changes", zap.Error(err))
+		return nil, database.WrapError("failed to list document changes", err)
+	}
+	defer rows.Close()
+
+	changes := []models.DocumentChange{}
+	for rows.Next() {
+		var change models.DocumentChange
+		if err := rows.Scan(&change.DocumentID, &change.ChangedAt, &change.ChangeType, &change.FolderID); err != nil {
+			r.logger.Error("failed to scan document change", zap.Error(err))
+			return nil, database.WrapError("failed to list document changes", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// ReassignDocuments transfers ownership (uploaded_by) of documents from one user to
+// another within a transaction. When docIDs is empty, all of the user's documents
+// are reassigned; otherwise every listed document must be owned by fromUserID.
+func (r *Repository) ReassignDocuments(ctx context.Context, tenantID uuid.UUID, fromUserID, toUserID string, docIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var reassigned []uuid.UUID
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		query := `
+			UPDATE documents
+			SET uploaded_by = $1, updated_at = $2
+			WHERE tenant_id = $3 AND uploaded_by = $4
+		`
+		args := []interface{}{toUserID, time.Now(), tenantID, fromUserID}
+
+		if len(docIDs) > 0 {
+			query += ` AND id = ANY($5)`
+			ids := make([]string, len(docIDs))
+			for i, id := range docIDs {
+				ids[i] = id.String()
+			}
+			args = append(args, pq.Array(ids))
+		}
+		query += ` RETURNING id`
+
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			r.logger.Error("failed to reassign documents", zap.Error(err))
+			return database.WrapError("failed to reassign documents", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return database.WrapError("failed to reassign documents", err)
+			}
+			reassigned = append(reassigned, id)
+		}
+		if err := rows.Err(); err != nil {
+			return database.WrapError("failed to reassign documents", err)
+		}
+
+		// Roll back if any requested document was missing or owned by someone else
+		if len(docIDs) > 0 && len(reassigned) != len(docIDs) {
+			return errors.NotFoundf("one or more documents not found or not owned by user")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reassigned, nil
+}
+
+// TransitionDocumentStatus moves the given documents to a new status within a
+// transaction. Only documents currently in one of the fromStatuses are changed.
+// It returns the status each found document had before the update.
+func (r *Repository) TransitionDocumentStatus(ctx context.Context, tenantID uuid.UUID, docIDs []uuid.UUID, status string, fromStatuses []string) (map[uuid.UUID]string, error) {
+	previous := make(map[uuid.UUID]string, len(docIDs))
+
+	ids := make([]string, len(docIDs))
+	for i, id := range docIDs {
+		ids[i] = id.String()
+	}
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, status FROM documents
+			WHERE tenant_id = $1 AND id = ANY($2)
+			FOR UPDATE
+		`, tenantID, pq.Array(ids))
+		if err != nil {
+			r.logger.Error("failed to lock documents", zap.Error(err))
+			return database.WrapError("failed to update document status", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			var current string
+			if err := rows.Scan(&id, &current); err != nil {
+				return database.WrapError("failed to update document status", err)
+			}
+			previous[id] = current
+		}
+		if err := rows.Err(); err != nil {
+			return database.WrapError("failed to update document status", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE documents
+			SET status = $1, updated_at = $2
+			WHERE tenant_id = $3 AND id = ANY($4) AND status = ANY($5)
+		`, status, time.Now(), tenantID, pq.Array(ids), pq.Array(fromStatuses))
+		if err != nil {
+			r.logger.Error("failed to update document status", zap.Error(err))
+			return database.WrapError("failed to update document status", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// SetDocumentsCategory sets category_id (NULL to clear it) on the given
+// documents within a transaction and moves their category document counts
+// along. With fromCategoryID set, only documents currently in that category
+// change. It returns the category each found document had before the update.
+func (r *Repository) SetDocumentsCategory(ctx context.Context, tenantID uuid.UUID, docIDs []uuid.UUID, categoryID, fromCategoryID sql.NullString) (map[uuid.UUID]sql.NullString, error) {
+	previous := make(map[uuid.UUID]sql.NullString, len(docIDs))
+
+	ids := make([]string, len(docIDs))
+	for i, id := range docIDs {
+		ids[i] = id.String()
+	}
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, category_id FROM documents
+			WHERE tenant_id = $1 AND id = ANY($2)
+			FOR UPDATE
+		`, tenantID, pq.Array(ids))
+		if err != nil {
+			r.logger.Error("failed to lock documents", zap.Error(err))
+			return database.WrapError("failed to update document category", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			var current sql.NullString
+			if err := rows.Scan(&id, &current); err != nil {
+				return database.WrapError("failed to update document category", err)
+			}
+			previous[id] = current
+		}
+		if err := rows.Err(); err != nil {
+			return database.WrapError("failed to update document category", err)
+		}
+
+		var changed []string
+		removed := make(map[string]int)
+		for id, current := range previous {
+			if current == categoryID || (fromCategoryID.Valid && current != fromCategoryID) {
+				continue
+			}
+			changed = append(changed, id.String())
+			if current.Valid {
+				removed[current.String]++
+			}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		_, err = tx.ExecContext(ctx, `
+			UPDATE documents SET category_id = $1, updated_at = $2
+			WHERE tenant_id = $3 AND id = ANY($4)
+		`, categoryID, now, tenantID, pq.Array(changed))
+		if err != nil {
+			r.logger.Error("failed to update document category", zap.Error(err))
+			return database.WrapError("failed to update document category", err)
+		}
+
+		for oldID, n := range removed {
+			if err := r.decrementCategoryDocumentCount(ctx, tx, tenantID, sql.NullString{String: oldID, Valid: true}, n); err != nil {
+				return err
+			}
+		}
+
+		return r.incrementCategoryDocumentCount(ctx, tx, tenantID, categoryID, len(changed))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+// Folder operations
+
+// CreateFolder creates a new folder
+func (r *Repository) CreateFolder(ctx context.Context, folder *models.Folder) error {
+	query := `
+		INSERT INTO folders (id, tenant_id, parent_id, name, path, depth, description, color, icon, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		folder.ID, folder.TenantID, folder.ParentID, folder.Name, folder.Path, folder.Depth,
+		folder.Description, folder.Color, folder.Icon, folder.CreatedBy,
+		folder.CreatedAt, folder.UpdatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("failed to create folder", zap.Error(err))
+		return database.WrapError("failed to create folder", err)
+	}
+
+	return nil
+}
+
+// GetFolder retrieves a folder by ID
+func (r *Repository) GetFolder(ctx context.Context, tenantID, folderID uuid.UUID) (*models.Folder, error) {
+	query := `
+		SELECT id, tenant_id, parent_id, name, path, depth, description, color, icon, created_by, created_at, updated_at
+		FROM folders
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var folder models.Folder
+	err := r.db.QueryRowContext(ctx, query, folderID, tenantID).Scan(
+		&folder.ID, &folder.TenantID, &folder.ParentID, &folder.Name, &folder.Path, &folder.Depth,
+		&folder.Description, &folder.Color, &folder.Icon, &folder.CreatedBy,
+		&folder.CreatedAt, &folder.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundf("folder not found")
+	}
+	if err != nil {
+		r.logger.Error("failed to get folder", zap.Error(err))
+		return nil, database.WrapError("failed to get folder", err)
+	}
+
+	return &folder, nil
+}
+
+// ListFolders retrieves all folders in a tenant
+func (r *Repository) ListFolders(ctx context.Context, tenantID uuid.UUID, parentID *string) ([]models.Folder, error) {
+	var query string
+	var args []interface{}
+
+	if parentID != nil && *parentID != "" {
+		query = `
+			SELECT id, tenant_id, parent_id, name, path, depth, description, color, icon, created_by, created_at, updated_at
+			FROM folders
+			WHERE tenant_id = $1 AND parent_id = $2
+			ORDER BY name ASC
+		`
+		args = []interface{}{tenantID, *parentID}
+	} else {
+		query = `
+			SELECT id, tenant_id, parent_id, name, path, depth, description, color, icon, created_by, created_at, updated_at
+			FROM folders
+			WHERE tenant_id = $1 AND parent_id IS NULL
+			ORDER BY name ASC
+		`
+		args = []interface{}{tenantID}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to list folders", zap.Error(err))
+		return nil, database.WrapError("failed to list folders", err)
+	}
+	defer rows.Close()
+
+	var folders []models.Folder
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var folder models.Folder
+		err := rows.Scan(
+			&folder.ID, &folder.TenantID, &folder.ParentID, &folder.Name, &folder.Path, &folder.Depth,
+			&folder.Description, &folder.Color, &folder.Icon, &folder.CreatedBy,
+			&folder.CreatedAt, &folder.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan folder", zap.Error(err))
+			continue
+		}
+		folders = append(folders, folder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// GetFolderSubtreeHeight returns how many levels lie below a folder: 0 for a
+// leaf, 1 when it only has direct children, and so on
+func (r *Repository) GetFolderSubtreeHeight(ctx context.Context, tenantID, folderID uuid.UUID) (int, error) {
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id, 0 AS level
+			FROM folders
+			WHERE id = $1 AND tenant_id = $2
+			UNION ALL
+			SELECT f.id, s.level + 1
+			FROM folders f
+			JOIN subtree s ON f.parent_id = s.id
+			WHERE f.tenant_id = $2 AND s.level < 1024
+		)
+		SELECT COALESCE(MAX(level), 0) FROM subtree
+	`
+
+	var height int
+	if err := r.db.QueryRowContext(ctx, query, folderID, tenantID).Scan(&height); err != nil {
+		r.logger.Error("failed to get folder subtree height", zap.Error(err))
+		return 0, database.WrapError("failed to get folder subtree", err)
+	}
+
+	return height, nil
+}
+
+// ListFolderTree returns a tenant's folders reachable from the root down to
+// maxDepth levels, parents before children and siblings by name. A folder is
+// never visited twice on one branch, so corrupt parent_id cycles end the walk
+// instead of looping.
+func (r *Repository) ListFolderTree(ctx context.Context, tenantID uuid.UUID, maxDepth int) ([]models.Folder, error) {
+	query := `
+		WITH RECURSIVE tree AS (
+			SELECT id, 1 AS level, ARRAY[id] AS visited, ARRAY[lower(name)] AS sort_key
+			FROM folders
+			WHERE tenant_id = $1 AND parent_id IS NULL
+			UNION ALL
+			SELECT f.id, t.level + 1, t.visited || f.id, t.sort_key || lower(f.name)
+			FROM folders f
+			JOIN tree t ON f.parent_id = t.id
+			WHERE f.tenant_id = $1 AND t.level < $2 AND NOT f.id = ANY(t.visited)
+		)
+		SELECT f.id, f.tenant_id, f.parent_id, f.name, f.path, f.depth, f.description, f.color, f.icon, f.created_by, f.created_at, f.updated_at
+		FROM tree t
+		JOIN folders f ON f.id = t.id
+		ORDER BY t.sort_key
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, maxDepth)
+	if err != nil {
+		r.logger.Error("failed to list folder tree", zap.Error(err))
+		return nil, database.WrapError("failed to list folder tree", err)
+	}
+	defer rows.Close()
+
+	var folders []models.Folder
+	for rows.Next() {
+		var folder models.Folder
+		err := rows.Scan(
+			&folder.ID, &folder.TenantID, &folder.ParentID, &folder.Name, &folder.Path, &folder.Depth,
+			&folder.Description, &folder.Color, &folder.Icon, &folder.CreatedBy,
+			&folder.CreatedAt, &folder.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan folder", zap.Error(err))
+			return nil, database.WrapError("failed to list folder tree", err)
+		}
+		folders = append(folders, folder)
+	}
+
+	return folders, rows.Err()
+}
+
+// IsFolderAncestor reports whether ancestorID is folderID itself or one of its ancestors
+func (r *Repository) IsFolderAncestor(ctx context.Context, tenantID, ancestorID, folderID uuid.UUID) (bool, error) {
+	query := `
+		WITH RECURSIVE ancestry AS (
+			SELECT id, parent_id, 0 AS level
+			FROM folders
+			WHERE id = $1 AND tenant_id = $2
+			UNION ALL
+			SELECT f.id, f.parent_id, a.level + 1
+			FROM folders f
+			JOIN ancestry a ON f.id = a.parent_id
+			WHERE f.tenant_id = $2 AND a.level < 1024
+		)
+		SELECT EXISTS(SELECT 1 FROM ancestry WHERE id = $3)
+	`
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, folderID, tenantID, ancestorID).Scan(&exists); err != nil {
+		r.logger.Error("failed to check folder ancestry", zap.Error(err))
+		return false, database.WrapError("failed to check folder ancestry", err)
+	}
+
+	return exists, nil
+}
+
+// MoveFolder re-parents a folder and rewrites the path and depth of the folder
+// and all of its descendants in a single transaction
+func (r *Repository) MoveFolder(ctx context.Context, folder *models.Folder, parentID sql.NullString, path string, depth int) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+
+		_, err := tx.ExecContext(ctx, `
+			UPDATE folders SET parent_id = $1, path = $2, depth = $3, updated_at = $4
+			WHERE id = $5 AND tenant_id = $6`,
+			parentID, path, depth, now, folder.ID, folder.TenantID,
 		)
 		if err != nil {
-			r.logger.Error("failed to scan document", zap.Error(err))
-			continue
+			r.logger.Error("failed to move folder", zap.Error(err))
+			return database.WrapError("failed to move folder", err)
 		}
-		documents = append(documents, doc)
-	}
 
-	return documents, total, nil
+		// Descendant paths all start with the old path, so swap that prefix
+		_, err = tx.ExecContext(ctx, `
+			WITH RECURSIVE subtree AS (
+				SELECT id, 1 AS level
+				FROM folders
+				WHERE parent_id = $1 AND tenant_id = $2
+				UNION ALL
+				SELECT f.id, s.level + 1
+				FROM folders f
+				JOIN subtree s ON f.parent_id = s.id
+				WHERE f.tenant_id = $2 AND s.level < 1024
+			)
+			UPDATE folders f
+			SET path = $3 || substr(f.path, $4), depth = $5 + s.level, updated_at = $6
+			FROM subtree s
+			WHERE f.id = s.id AND f.tenant_id = $2`,
+			folder.ID, folder.TenantID, path, utf8.RuneCountInString(folder.Path)+1, depth, now,
+		)
+		if err != nil {
+			r.logger.Error("failed to move folder descendants", zap.Error(err))
+			return database.WrapError("failed to move folder", err)
+		}
+
+		return nil
+	})
 }
 
-// UpdateDocument updates a document
-func (r *Repository) UpdateDocument(ctx context.Context, tenantID, docID uuid.UUID, req *models.UpdateDocumentRequest) error {
-	query := `
-		UPDATE documents
-		SET name = COALESCE(NULLIF($1, ''), name),
-		    description = COALESCE(NULLIF($2, ''), description),
-		    folder_id = COALESCE($3, folder_id),
-		    category_id = COALESCE($4, category_id),
-		    updated_at = $5
-		WHERE id = $6 AND tenant_id = $7
-	`
+// MoveFolderContents moves a folder's documents, and with includeSubfolders
+// its direct subfolders, under the target (NULL for the root) in a single
+// transaction. Subfolders keep their subtrees; every descendant path swaps
+// the source path prefix for targetPath and shifts its depth to match.
+// It returns the IDs of the moved documents and the number of moved subfolders.
+func (r *Repository) MoveFolderContents(ctx context.Context, source *models.Folder, targetID sql.NullString, targetPath string, targetDepth int, includeSubfolders bool) ([]uuid.UUID, int64, error) {
+	var docIDs []uuid.UUID
+	var foldersMoved int64
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		docIDs = nil
+		now := time.Now()
+
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE documents SET folder_id = $1, updated_at = $2
+			WHERE folder_id = $3 AND tenant_id = $4
+			RETURNING id`,
+			targetID, now, source.ID, source.TenantID,
+		)
+		if err != nil {
+			r.logger.Error("failed to move folder documents", zap.Error(err))
+			return database.WrapError("failed to move folder contents", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				return database.WrapError("failed to move folder contents", err)
+			}
+			docIDs = append(docIDs, id)
+		}
+		if err := rows.Err(); err != nil {
+			return database.WrapError("failed to move folder contents", err)
+		}
 
-	var folderID, categoryID interface{}
-	if req.FolderID != nil {
-		folderID = *req.FolderID
-	}
-	if req.CategoryID != nil {
-		categoryID = *req.CategoryID
-	}
+		if !includeSubfolders {
+			return nil
+		}
 
-	_, err := r.db.ExecContext(ctx, query,
-		req.Name, req.Description, folderID, categoryID,
-		time.Now(), docID, tenantID,
-	)
+		_, err = tx.ExecContext(ctx, `
+			WITH RECURSIVE subtree AS (
+				SELECT id, 1 AS level
+				FROM folders
+				WHERE parent_id = $1 AND tenant_id = $2
+				UNION ALL
+				SELECT f.id, s.level + 1
+				FROM folders f
+				JOIN subtree s ON f.parent_id = s.id
+				WHERE f.tenant_id = $2 AND s.level < 1024
+			)
+			UPDATE folders f
+			SET path = $3 || substr(f.path, $4), depth = $5 + s.level, updated_at = $6
+			FROM subtree s
+			WHERE f.id = s.id AND f.tenant_id = $2`,
+			source.ID, source.TenantID, targetPath, utf8.RuneCountInString(source.Path)+1, targetDepth, now,
+		)
+		if err != nil {
+			r.logger.Error("failed to move subfolder paths", zap.Error(err))
+			return database.WrapError("failed to move folder contents", err)
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			UPDATE folders SET parent_id = $1, updated_at = $2
+			WHERE parent_id = $3 AND tenant_id = $4`,
+			targetID, now, source.ID, source.TenantID,
+		)
+		if err != nil {
+			r.logger.Error("failed to move subfolders", zap.Error(err))
+			return database.WrapError("failed to move folder contents", err)
+		}
+		foldersMoved, _ = result.RowsAffected()
 
+		return nil
+	})
 	if err != nil {
-		r.logger.Error("failed to update document", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to update document", err)
+		return nil, 0, err
 	}
 
-	return nil
+	return docIDs, foldersMoved, nil
 }
 
-// DeleteDocument deletes a document
-func (r *Repository) DeleteDocument(ctx context.Context, tenantID, docID uuid.UUID) error {
-	query := `DELETE FROM documents WHERE id = $1 AND tenant_id = $2`
+// DeleteFolder deletes a folder
+func (r *Repository) DeleteFolder(ctx context.Context, tenantID, folderID uuid.UUID) error {
+	query := `DELETE FROM folders WHERE id = $1 AND tenant_id = $2`
 
-	result, err := r.db.ExecContext(ctx, query, docID, tenantID)
+	result, err := r.db.ExecContext(ctx, query, folderID, tenantID)
 	if err != nil {
-		r.logger.Error("failed to delete document", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to delete document", err)
+		r.logger.Error("failed to delete folder", zap.Error(err))
+		return database.WrapError("failed to delete folder", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.NotFoundf("document not found")
+		return errors.NotFoundf("folder not found")
 	}
 
 	return nil
 }
 
-// Folder operations
+// Folder ACL operations
 
-// CreateFolder creates a new folder
-func (r *Repository) CreateFolder(ctx context.Context, folder *models.Folder) error {
+// ReplaceFolderACL replaces all ACL entries of a folder in a single transaction
+func (r *Repository) ReplaceFolderACL(ctx context.Context, tenantID uuid.UUID, acl *models.FolderACL) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM folder_acls WHERE tenant_id = $1 AND folder_id = $2
+		`, tenantID, acl.FolderID)
+		if err != nil {
+			r.logger.Error("failed to clear folder acl", zap.Error(err))
+			return database.WrapError("failed to update folder acl", err)
+		}
+
+		for _, entry := range acl.Entries {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO folder_acls (id, tenant_id, folder_id, user_id, actions, inherit, created_by, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, uuid.New(), tenantID, acl.FolderID, entry.UserID, pq.Array(entry.Actions), acl.Inherit, acl.UpdatedBy, acl.UpdatedAt)
+			if err != nil {
+				r.logger.Error("failed to insert folder acl entry", zap.Error(err))
+				return database.WrapError("failed to update folder acl", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetFolderACL retrieves the ACL of a folder; a folder without entries has an empty ACL
+func (r *Repository) GetFolderACL(ctx context.Context, tenantID, folderID uuid.UUID) (*models.FolderACL, error) {
 	query := `
-		INSERT INTO folders (id, tenant_id, parent_id, name, path, description, color, icon, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		SELECT user_id, actions, inherit, created_by, created_at
+		FROM folder_acls
+		WHERE tenant_id = $1 AND folder_id = $2
+		ORDER BY user_id ASC
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
-		folder.ID, folder.TenantID, folder.ParentID, folder.Name, folder.Path,
-		folder.Description, folder.Color, folder.Icon, folder.CreatedBy,
-		folder.CreatedAt, folder.UpdatedAt,
-	)
-
+	rows, err := r.db.QueryContext(ctx, query, tenantID, folderID)
 	if err != nil {
-		r.logger.Error("failed to create folder", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to create folder", err)
+		r.logger.Error("failed to get folder acl", zap.Error(err))
+		return nil, database.WrapError("failed to get folder acl", err)
 	}
+	defer rows.Close()
 
-	return nil
+	acl := &models.FolderACL{
+		FolderID: folderID,
+		Entries:  []models.FolderACLEntry{},
+	}
+	for rows.Next() {
+		var entry models.FolderACLEntry
+		if err := rows.Scan(&entry.UserID, pq.Array(&entry.Actions), &acl.Inherit, &acl.UpdatedBy, &acl.UpdatedAt); err != nil {
+			r.logger.Error("failed to scan folder acl entry", zap.Error(err))
+			return nil, database.WrapError("failed to get folder acl", err)
+		}
+		acl.Entries = append(acl.Entries, entry)
+	}
+
+	return acl, rows.Err()
 }
 
-// GetFolder retrieves a folder by ID
-func (r *Repository) GetFolder(ctx context.Context, tenantID, folderID uuid.UUID) (*models.Folder, error) {
+// GetFolderACLGrants walks a folder and its ancestors and returns every folder
+// on that chain that has an ACL, nearest first, with the actions granted to the user
+func (r *Repository) GetFolderACLGrants(ctx context.Context, tenantID, folderID uuid.UUID, userID string) ([]models.FolderACLGrant, error) {
 	query := `
-		SELECT id, tenant_id, parent_id, name, path, description, color, icon, created_by, created_at, updated_at
-		FROM folders
-		WHERE id = $1 AND tenant_id = $2
+		WITH RECURSIVE ancestry AS (
+			SELECT id, parent_id, 0 AS depth
+			FROM folders
+			WHERE id = $1 AND tenant_id = $2
+			UNION ALL
+			SELECT f.id, f.parent_id, a.depth + 1
+			FROM folders f
+			JOIN ancestry a ON f.id = a.parent_id
+			WHERE f.tenant_id = $2 AND a.depth < 64
+		)
+		SELECT a.id, a.depth, bool_or(acl.inherit),
+			COALESCE(string_agg(array_to_string(acl.actions, ','), ',') FILTER (WHERE acl.user_id = $3), '')
+		FROM ancestry a
+		JOIN folder_acls acl ON acl.folder_id = a.id AND acl.tenant_id = $2
+		GROUP BY a.id, a.depth
+		ORDER BY a.depth ASC
 	`
 
-	var folder models.Folder
-	err := r.db.QueryRowContext(ctx, query, folderID, tenantID).Scan(
-		&folder.ID, &folder.TenantID, &folder.ParentID, &folder.Name, &folder.Path,
-		&folder.Description, &folder.Color, &folder.Icon, &folder.CreatedBy,
-		&folder.CreatedAt, &folder.UpdatedAt,
-	)
+	rows, err := r.db.QueryContext(ctx, query, folderID, tenantID, userID)
+	if err != nil {
+		r.logger.Error("failed to get folder acl grants", zap.Error(err))
+		return nil, database.WrapError("failed to check folder access", err)
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return nil, errors.NotFoundf("folder not found")
+	var grants []models.FolderACLGrant
+	for rows.Next() {
+		var grant models.FolderACLGrant
+		var actions string
+		if err := rows.Scan(&grant.FolderID, &grant.Depth, &grant.Inherit, &actions); err != nil {
+			r.logger.Error("failed to scan folder acl grant", zap.Error(err))
+			return nil, database.WrapError("failed to check folder access", err)
+		}
+		if actions != "" {
+			grant.Actions = strings.Split(actions, ",")
+		}
+		grants = append(grants, grant)
 	}
+
+	return grants, rows.Err()
+}
+
+// GetFolderAccess decides, for every folder of the tenant whose documents are
+// governed by a folder ACL, whether that ACL grants the user action. It walks
+// the tree from the roots, handing the nearest ACL with inherit set down to
+// subfolders without an ACL of their own, which matches GetFolderACLGrants
+// applied folder by folder. Folders no ACL governs are not returned.
+func (r *Repository) GetFolderAccess(ctx context.Context, tenantID uuid.UUID, userID, action string, maxDepth int) (governed, allowed []string, err error) {
+	query := `
+		WITH RECURSIVE acls AS (
+			SELECT folder_id, bool_or(inherit) AS inherit,
+			       bool_or(user_id::text = $2 AND $3 = ANY(actions)) AS allowed
+			FROM folder_acls
+			WHERE tenant_id = $1
+			GROUP BY folder_id
+		),
+		tree AS (
+			-- own decides the folder's documents, passed is handed to subfolders
+			SELECT f.id, a.allowed AS own,
+			       CASE WHEN a.inherit THEN a.allowed END AS passed,
+			       ARRAY[f.id] AS visited
+			FROM folders f
+			LEFT JOIN acls a ON a.folder_id = f.id
+			WHERE f.tenant_id = $1 AND f.parent_id IS NULL
+			UNION ALL
+			SELECT f.id, CASE WHEN a.folder_id IS NOT NULL THEN a.allowed ELSE t.passed END,
+			       CASE WHEN a.inherit THEN a.allowed ELSE t.passed END,
+			       t.visited || f.id
+			FROM folders f
+			JOIN tree t ON f.parent_id = t.id
+			LEFT JOIN acls a ON a.folder_id = f.id
+			WHERE f.tenant_id = $1 AND cardinality(t.visited) < $4 AND NOT f.id = ANY(t.visited)
+		)
+		SELECT id, own FROM tree WHERE own IS NOT NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, userID, action, maxDepth)
 	if err != nil {
-		r.logger.Error("failed to get folder", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get folder", err)
+		r.logger.Error("failed to get folder access", zap.Error(err))
+		return nil, nil, database.WrapError("failed to check folder access", err)
 	}
+	defer rows.Close()
 
-	return &folder, nil
-}
+	for rows.Next() {
+		var folderID string
+		var granted bool
+		if err := rows.Scan(&folderID, &granted); err != nil {
+			r.logger.Error("failed to scan folder access", zap.Error(err))
+			return nil, nil, database.WrapError("failed to check folder access", err)
+		}
+		governed = append(governed, folderID)
+		if granted {
+			allowed = append(allowed, folderID)
+		}
+	}
 
-// ListFolders retrieves all folders in a tenant
-func (r *Repository) ListFolders(ctx context.Context, tenantID uuid.UUID, parentID *string) ([]models.Folder, error) {
-	var query string
-	var args []interface{}
+	return governed, allowed, rows.Err()
+}
 
-	if parentID != nil && *parentID != "" {
-		query = `
-			SELECT id, tenant_id, parent_id, name, path, description, color, icon, created_by, created_at, updated_at
-			FROM folders
-			WHERE tenant_id = $1 AND parent_id = $2
-			ORDER BY name ASC
-		`
-		args = []interface{}{tenantID, *parentID}
-	} else {
-		query = `
-			SELECT id, tenant_id, parent_id, name, path, description, color, icon, created_by, created_at, updated_at
-			FROM folders
-			WHERE tenant_id = $1 AND parent_id IS NULL
-			ORDER BY name ASC
-		`
-		args = []interface{}{tenantID}
+// documentAccessClause returns the WHERE condition that keeps only documents
+// (aliased d) the filter's user may access: a document ACL decides when the
+// document has one, then the folder ACL governing its folder, then RBAC
+func documentAccessClause(access *models.DocumentAccessFilter, argPos int) (string, []interface{}, int) {
+	clause := fmt.Sprintf(`CASE
+			WHEN EXISTS (SELECT 1 FROM document_acls da WHERE da.document_id = d.id AND da.tenant_id = d.tenant_id)
+			THEN EXISTS (SELECT 1 FROM document_acls da WHERE da.document_id = d.id AND da.tenant_id = d.tenant_id
+			             AND da.user_id::text = $%d AND $%d = ANY(da.actions))
+			WHEN d.folder_id = ANY($%d) THEN d.folder_id = ANY($%d)
+			ELSE $%d
+		END`, argPos, argPos+1, argPos+2, argPos+3, argPos+4)
+
+	args := []interface{}{
+		access.UserID,
+		access.Action,
+		pq.Array(access.GovernedFolders),
+		pq.Array(access.AllowedFolders),
+		access.RBACAllowed,
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	return clause, args, argPos + len(args)
+}
+
+// Document ACL operations
+
+// ReplaceDocumentACL replaces all ACL entries of a document in a single transaction
+func (r *Repository) ReplaceDocumentACL(ctx context.Context, tenantID uuid.UUID, acl *models.DocumentACL) error {
+	return r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM document_acls WHERE tenant_id = $1 AND document_id = $2
+		`, tenantID, acl.DocumentID)
+		if err != nil {
+			r.logger.Error("failed to clear document acl", zap.Error(err))
+			return database.WrapError("failed to update document acl", err)
+		}
+
+		for _, entry := range acl.Entries {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO document_acls (id, tenant_id, document_id, user_id, actions, created_by, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, uuid.New(), tenantID, acl.DocumentID, entry.UserID, pq.Array(entry.Actions), acl.UpdatedBy, acl.UpdatedAt)
+			if err != nil {
+				r.logger.Error("failed to insert document acl entry", zap.Error(err))
+				return database.WrapError("failed to update document acl", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetDocumentACL retrieves the ACL of a document; a document without entries has an empty ACL
+func (r *Repository) GetDocumentACL(ctx context.Context, tenantID, docID uuid.UUID) (*models.DocumentACL, error) {
+	query := `
+		SELECT user_id, actions, created_by, created_at
+		FROM document_acls
+		WHERE tenant_id = $1 AND document_id = $2
+		ORDER BY user_id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, docID)
 	if err != nil {
-		r.logger.Error("failed to list folders", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to list folders", err)
+		r.logger.Error("failed to get document acl", zap.Error(err))
+		return nil, database.WrapError("failed to get document acl", err)
 	}
 	defer rows.Close()
 
-	var folders []models.Folder
+	acl := &models.DocumentACL{
+		DocumentID: docID,
+		Entries:    []models.FolderACLEntry{},
+	}
 	for rows.Next() {
-		var folder models.Folder
-		err := rows.Scan(
-			&folder.ID, &folder.TenantID, &folder.ParentID, &folder.Name, &folder.Path,
-			&folder.Description, &folder.Color, &folder.Icon, &folder.CreatedBy,
-			&folder.CreatedAt, &folder.UpdatedAt,
-		)
-		if err != nil {
-			r.logger.Error("failed to scan folder", zap.Error(err))
-			continue
+		var entry models.FolderACLEntry
+		if err := rows.Scan(&entry.UserID, pq.Array(&entry.Actions), &acl.UpdatedBy, &acl.UpdatedAt); err != nil {
+			r.logger.Error("failed to scan document acl entry", zap.Error(err))
+			return nil, database.WrapError("failed to get document acl", err)
 		}
-		folders = append(folders, folder)
+		acl.Entries = append(acl.Entries, entry)
 	}
 
-	return folders, nil
+	return acl, rows.Err()
 }
 
-// DeleteFolder deletes a folder
-func (r *Repository) DeleteFolder(ctx context.Context, tenantID, folderID uuid.UUID) error {
-	query := `DELETE FROM folders WHERE id = $1 AND tenant_id = $2`
+// GetDocumentACLGrants returns, for each of the documents that has an ACL,
+// the actions it grants the user; the slice is empty when the user has no
+// entry. Documents without an ACL are absent from the map.
+func (r *Repository) GetDocumentACLGrants(ctx context.Context, tenantID uuid.UUID, docIDs []uuid.UUID, userID string) (map[uuid.UUID][]string, error) {
+	grants := make(map[uuid.UUID][]string)
+	if len(docIDs) == 0 {
+		return grants, nil
+	}
 
-	result, err := r.db.ExecContext(ctx, query, folderID, tenantID)
+	query := `
+		SELECT document_id,
+			COALESCE(string_agg(array_to_string(actions, ','), ',') FILTER (WHERE user_id::text = $3), '')
+		FROM document_acls
+		WHERE tenant_id = $1 AND document_id = ANY($2)
+		GROUP BY document_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, pq.Array(docIDs), userID)
 	if err != nil {
-		r.logger.Error("failed to delete folder", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to delete folder", err)
+		r.logger.Error("failed to get document acl grants", zap.Error(err))
+		return nil, database.WrapError("failed to check document access", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.NotFoundf("folder not found")
+	for rows.Next() {
+		var docID uuid.UUID
+		var actions string
+		if err := rows.Scan(&docID, &actions); err != nil {
+			r.logger.Error("failed to scan document acl grant", zap.Error(err))
+			return nil, database.WrapError("failed to check document access", err)
+		}
+		grants[docID] = []string{}
+		if actions != "" {
+			grants[docID] = strings.Split(actions, ",")
+		}
 	}
 
-	return nil
+	return grants, rows.Err()
 }
 
 // Tag operations
@@ -344,12 +1748,41 @@ func (r *Repository) CreateTag(ctx context.Context, tag *models.Tag) error {
 
 	if err != nil {
 		r.logger.Error("failed to create tag", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to create tag", err)
+		return database.WrapError("failed to create tag", err)
 	}
 
 	return nil
 }
 
+// insertTags saves new tags in tx. A tag whose name the tenant got from a
+// concurrent request in the meantime takes that tag's ID instead, so the
+// caller attaches the existing tag.
+func (r *Repository) insertTags(ctx context.Context, tx *sql.Tx, tags []*models.Tag) error {
+	for _, tag := range tags {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO tags (id, tenant_id, name, color, created_by, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (tenant_id, name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id`,
+			tag.ID, tag.TenantID, tag.Name, tag.Color, tag.CreatedBy, tag.CreatedAt,
+		).Scan(&tag.ID)
+		if err != nil {
+			r.logger.Error("failed to create tag", zap.Error(err))
+			return database.WrapError("failed to create tag", err)
+		}
+	}
+	return nil
+}
+
+// tagIDsOf returns the IDs of tags, never nil so it binds as an empty array
+func tagIDsOf(tags []models.Tag) []uuid.UUID {
+	ids := make([]uuid.UUID, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	return ids
+}
+
 // ListTags retrieves all tags in a tenant
 func (r *Repository) ListTags(ctx context.Context, tenantID uuid.UUID) ([]models.Tag, error) {
 	query := `
@@ -362,7 +1795,7 @@ func (r *Repository) ListTags(ctx context.Context, tenantID uuid.UUID) ([]models
 	rows, err := r.db.QueryContext(ctx, query, tenantID)
 	if err != nil {
 		r.logger.Error("failed to list tags", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to list tags", err)
+		return nil, database.WrapError("failed to list tags", err)
 	}
 	defer rows.Close()
 
@@ -380,6 +1813,65 @@ func (r *Repository) ListTags(ctx context.Context, tenantID uuid.UUID) ([]models
 	return tags, nil
 }
 
+// FindTags returns the tenant's tags matching any of ids or, ignoring case,
+// any of names
+func (r *Repository) FindTags(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, names []string) ([]models.Tag, error) {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+
+	query := `
+		SELECT id, tenant_id, name, color, usage_count, created_by, created_at
+		FROM tags
+		WHERE tenant_id = $1 AND (id = ANY($2) OR lower(name) = ANY($3))
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, pq.Array(ids), pq.Array(lowered))
+	if err != nil {
+		r.logger.Error("failed to find tags", zap.Error(err))
+		return nil, database.WrapError("failed to find tags", err)
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.TenantID, &tag.Name, &tag.Color, &tag.UsageCount, &tag.CreatedBy, &tag.CreatedAt); err != nil {
+			r.logger.Error("failed to scan tag", zap.Error(err))
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// GetTagByName retrieves a tenant's tag by name, ignoring case
+func (r *Repository) GetTagByName(ctx context.Context, tenantID uuid.UUID, name string) (*models.Tag, error) {
+	query := `
+		SELECT id, tenant_id, name, color, usage_count, created_by, created_at
+		FROM tags
+		WHERE tenant_id = $1 AND lower(name) = lower($2)
+		ORDER BY created_at ASC
+		LIMIT 1
+	`
+
+	var tag models.Tag
+	err := r.db.QueryRowContext(ctx, query, tenantID, name).Scan(
+		&tag.ID, &tag.TenantID, &tag.Name, &tag.Color, &tag.UsageCount, &tag.CreatedBy, &tag.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundf("tag not found")
+	}
+	if err != nil {
+		r.logger.Error("failed to get tag by name", zap.Error(err))
+		return nil, database.WrapError("failed to get tag", err)
+	}
+
+	return &tag, nil
+}
+
 // AddTagToDocument adds a tag to a document
 func (r *Repository) AddTagToDocument(ctx context.Context, documentID, tagID uuid.UUID) error {
 	query := `
@@ -391,7 +1883,38 @@ func (r *Repository) AddTagToDocument(ctx context.Context, documentID, tagID uui
 	_, err := r.db.ExecContext(ctx, query, documentID, tagID, time.Now())
 	if err != nil {
 		r.logger.Error("failed to add tag to document", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to add tag", err)
+		return database.WrapError("failed to add tag", err)
+	}
+
+	return nil
+}
+
+// replaceDocumentTags makes tagIDs the document's exact tag set. Only rows
+// that change are deleted or inserted, so repeating the same set is a no-op
+// and the document_tags triggers adjust tags.usage_count for real changes only.
+func (r *Repository) replaceDocumentTags(ctx context.Context, tx *sql.Tx, documentID uuid.UUID, tagIDs []uuid.UUID) error {
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM document_tags WHERE document_id = $1 AND NOT (tag_id = ANY($2))`,
+		documentID, pq.Array(tagIDs),
+	)
+	if err != nil {
+		r.logger.Error("failed to remove document tags", zap.Error(err))
+		return database.WrapError("failed to replace tags", err)
+	}
+
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO document_tags (document_id, tag_id, created_at)
+		SELECT $1, tag_id, $3 FROM unnest($2::uuid[]) AS tag_id
+		ON CONFLICT (document_id, tag_id) DO NOTHING`,
+		documentID, pq.Array(tagIDs), time.Now(),
+	)
+	if err != nil {
+		r.logger.Error("failed to add document tags", zap.Error(err))
+		return database.WrapError("failed to replace tags", err)
 	}
 
 	return nil
@@ -404,7 +1927,7 @@ func (r *Repository) RemoveTagFromDocument(ctx context.Context, documentID, tagI
 	_, err := r.db.ExecContext(ctx, query, documentID, tagID)
 	if err != nil {
 		r.logger.Error("failed to remove tag from document", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to remove tag", err)
+		return database.WrapError("failed to remove tag", err)
 	}
 
 	return nil
@@ -423,7 +1946,7 @@ func (r *Repository) GetDocumentTags(ctx context.Context, documentID uuid.UUID)
 	rows, err := r.db.QueryContext(ctx, query, documentID)
 	if err != nil {
 		r.logger.Error("failed to get document tags", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get document tags", err)
+		return nil, database.WrapError("failed to get document tags", err)
 	}
 	defer rows.Close()
 
@@ -457,12 +1980,36 @@ func (r *Repository) CreateCategory(ctx context.Context, category *models.Catego
 
 	if err != nil {
 		r.logger.Error("failed to create category", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to create category", err)
+		return database.WrapError("failed to create category", err)
 	}
 
 	return nil
 }
 
+// GetCategory retrieves a category by ID within a tenant
+func (r *Repository) GetCategory(ctx context.Context, tenantID, categoryID uuid.UUID) (*models.Category, error) {
+	query := `
+		SELECT id, tenant_id, name, description, color, icon, document_count, created_at, updated_at
+		FROM categories
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var cat models.Category
+	err := r.db.QueryRowContext(ctx, query, categoryID, tenantID).Scan(
+		&cat.ID, &cat.TenantID, &cat.Name, &cat.Description, &cat.Color, &cat.Icon,
+		&cat.DocumentCount, &cat.CreatedAt, &cat.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.NotFoundf("category not found")
+	}
+	if err != nil {
+		r.logger.Error("failed to get category", zap.Error(err))
+		return nil, database.WrapError("failed to get category", err)
+	}
+
+	return &cat, nil
+}
+
 // ListCategories retrieves all categories in a tenant
 func (r *Repository) ListCategories(ctx context.Context, tenantID uuid.UUID) ([]models.Category, error) {
 	query := `
@@ -475,7 +2022,7 @@ func (r *Repository) ListCategories(ctx context.Context, tenantID uuid.UUID) ([]
 	rows, err := r.db.QueryContext(ctx, query, tenantID)
 	if err != nil {
 		r.logger.Error("failed to list categories", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to list categories", err)
+		return nil, database.WrapError("failed to list categories", err)
 	}
 	defer rows.Close()
 
@@ -492,3 +2039,90 @@ func (r *Repository) ListCategories(ctx context.Context, tenantID uuid.UUID) ([]
 
 	return categories, nil
 }
+
+// searchVectorExpr computes documents.search_vector with the weighting of the
+// update_document_search_vector trigger that maintains it on insert and
+// update: the name weighs most, then the description, then the file name
+const searchVectorExpr = `
+	setweight(to_tsvector('english', COALESCE(d.name, '')), 'A') ||
+	setweight(to_tsvector('english', COALESCE(d.description, '')), 'B') ||
+	setweight(to_tsvector('english', COALESCE(d.file_name, '')), 'C')`
+
+// ReindexSearchVectors recomputes search_vector for up to limit of the
+// tenant's documents with IDs after the given one, in ID order. Each batch is
+// its own statement so row locks are held briefly. Returns the number of
+// documents updated and the last ID, which is the cursor for the next batch.
+func (r *Repository) ReindexSearchVectors(ctx context.Context, tenantID, after uuid.UUID, limit int) (int, uuid.UUID, error) {
+	query := fmt.Sprintf(`
+		WITH batch AS (
+			SELECT id FROM documents
+			WHERE tenant_id = $1 AND id > $2
+			ORDER BY id
+			LIMIT $3
+		)
+		UPDATE documents d
+		SET search_vector = %s
+		FROM batch
+		WHERE d.id = batch.id
+		RETURNING d.id
+	`, searchVectorExpr)
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, after, limit)
+	if err != nil {
+		r.logger.Error("failed to reindex search vectors", zap.Error(err))
+		return 0, after, database.WrapError("failed to reindex documents", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	last := after
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return count, last, database.WrapError("failed to reindex documents", err)
+		}
+		count++
+		if bytes.Compare(id[:], last[:]) > 0 {
+			last = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return count, last, database.WrapError("failed to reindex documents", err)
+	}
+
+	return count, last, nil
+}
+
+// PurgeTenant deletes all documents, folders, ACLs, tags and categories of a tenant in a single transaction and
+// returns the number of rows removed
+func (r *Repository) PurgeTenant(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	statements := []string{
+		`DELETE FROM document_tags WHERE document_id IN (SELECT id FROM documents WHERE tenant_id = $1)`,
+		`DELETE FROM folder_acls WHERE tenant_id = $1`,
+		`DELETE FROM document_acls WHERE tenant_id = $1`,
+		`DELETE FROM documents WHERE tenant_id = $1`,
+		`DELETE FROM document_tombstones WHERE tenant_id = $1`,
+		`DELETE FROM folders WHERE tenant_id = $1`,
+		`DELETE FROM tags WHERE tenant_id = $1`,
+		`DELETE FROM categories WHERE tenant_id = $1`,
+	}
+
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			result, err := tx.ExecContext(ctx, statement, tenantID)
+			if err != nil {
+				r.logger.Error("failed to purge tenant documents", zap.Error(err))
+				return database.WrapError("failed to purge tenant documents", err)
+			}
+			rows, _ := result.RowsAffected()
+			deleted += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database/dbtest"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestUpdateDocumentCategoryCounts(t *testing.T) {
+	tenantID, docID := uuid.New(), uuid.New()
+	categoryA, categoryB := uuid.NewString(), uuid.NewString()
+	none := sql.NullString{}
+	category := func(id string) sql.NullString { return sql.NullString{String: id, Valid: true} }
+
+	tests := []struct {
+		name          string
+		from          driver.Value // category_id before the update
+		to            sql.NullString
+		wantDecrement string // category whose count drops; empty for none
+		wantIncrement string // category whose count grows; empty for none
+	}{
+		{"moving between categories updates both", categoryA, category(categoryB), categoryA, categoryB},
+		{"assigning a category increments it", nil, category(categoryA), "", categoryA},
+		{"clearing the category decrements it", categoryA, none, categoryA, ""},
+		{"keeping the category changes no count", categoryA, category(categoryA), "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			repo := NewRepository(db, zap.NewNop())
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT category_id FROM documents").
+				WithArgs(docID.String(), tenantID.String()).
+				WillReturnRows([]string{"category_id"}, []driver.Value{tt.from})
+			mock.ExpectExec("UPDATE documents").WillReturnResult(1)
+			if tt.wantDecrement != "" {
+				mock.ExpectExec("GREATEST(document_count - $3, 0)").
+					WithArgs(tt.wantDecrement, tenantID.String(), 1, dbtest.Any).
+					WillReturnResult(1)
+			}
+			if tt.wantIncrement != "" {
+				mock.ExpectExec("document_count = document_count + $3").
+					WithArgs(tt.wantIncrement, tenantID.String(), 1, dbtest.Any).
+					WillReturnResult(1)
+			}
+			mock.ExpectCommit()
+
+			updates := map[string]interface{}{"category_id": tt.to}
+			if err := repo.UpdateDocument(context.Background(), tenantID, docID, updates, nil, nil); err != nil {
+				t.Fatalf("UpdateDocument() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateDocumentCategoryMissingDocument(t *testing.T) {
+	db, mock := dbtest.New(t)
+	repo := NewRepository(db, zap.NewNop())
+
+	// No count changes when the document is gone
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT category_id FROM documents").WillReturnRows([]string{"category_id"})
+	mock.ExpectRollback()
+
+	updates := map[string]interface{}{"category_id": sql.NullString{String: uuid.NewString(), Valid: true}}
+	err := repo.UpdateDocument(context.Background(), uuid.New(), uuid.New(), updates, nil, nil)
+	if appErr := errors.FromError(err); appErr == nil || appErr.Code != errors.ErrCodeNotFound {
+		t.Errorf("UpdateDocument() error = %v, want not found", err)
+	}
+}
+
+func TestReassignDocuments(t *testing.T) {
+	tenantID := uuid.New()
+	docA, docB := uuid.New(), uuid.New()
+	idRow := func(id uuid.UUID) []driver.Value { return []driver.Value{id.String()} }
+
+	tests := []struct {
+		name     string
+		docIDs   []uuid.UUID
+		returned [][]driver.Value // rows the UPDATE returns
+		queryErr error
+		wantErr  errors.ErrorCode // empty when the transfer commits
+	}{
+		{"all documents of the user", nil, [][]driver.Value{idRow(docA), idRow(docB)}, nil, ""},
+		{"listed documents owned by the user", []uuid.UUID{docA, docB}, [][]driver.Value{idRow(docA), idRow(docB)}, nil, ""},
+		{"listed document owned by someone else rolls back", []uuid.UUID{docA, docB}, [][]driver.Value{idRow(docA)}, nil, errors.ErrCodeNotFound},
+		{"failed update rolls back", nil, nil, stderrors.New("connection reset"), errors.ErrCodeDatabase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			repo := NewRepository(db, zap.NewNop())
+
+			args := []interface{}{"user-2", dbtest.Any, tenantID.String(), "user-1"}
+			if tt.docIDs != nil {
+				args = append(args, "{\""+docA.String()+"\",\""+docB.String()+"\"}")
+			}
+
+			mock.ExpectBegin()
+			update := mock.ExpectQuery("SET uploaded_by = $1").WithArgs(args...)
+			if tt.queryErr != nil {
+				update.WillReturnError(tt.queryErr)
+			} else {
+				update.WillReturnRows([]string{"id"}, tt.returned...)
+			}
+			if tt.wantErr == "" {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			reassigned, err := repo.ReassignDocuments(context.Background(), tenantID, "user-1", "user-2", tt.docIDs)
+			if tt.wantErr != "" {
+				if appErr := errors.FromError(err); appErr == nil || appErr.Code != tt.wantErr {
+					t.Fatalf("ReassignDocuments() error = %v, want %s", err, tt.wantErr)
+				}
+				if reassigned != nil {
+					t.Errorf("ReassignDocuments() = %v after rolling back, want none", reassigned)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReassignDocuments() error = %v", err)
+			}
+			if len(reassigned) != len(tt.returned) {
+				t.Errorf("ReassignDocuments() reassigned %d documents, want %d", len(reassigned), len(tt.returned))
+			}
+		})
+	}
+}
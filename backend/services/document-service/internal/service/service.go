@@ -2,15 +2,25 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/models"
 	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/repository"
 	"go.uber.org/zap"
@@ -19,31 +29,73 @@ import (
 const (
 	documentCacheTTL = 30 * time.Minute
 	folderCacheTTL   = 1 * time.Hour
+
+	// Tag suggestions
+	defaultTagSuggestions = 10
+	maxTagSuggestions     = 50
+	minTagSimilarity      = 0.6  // below this a tag is not considered a near-duplicate
+	tagPrefixScore        = 0.75 // floor for tags that extend (or are contained in) the query
+
+	// Quick search (search-as-you-type)
+	defaultQuickSearchLimit = 8
+	maxQuickSearchLimit     = 20
+	maxQuickSearchQuery     = 100
+
+	// Search reindexing
+	defaultReindexBatchSize  = 500
+	maxReindexBatchSize      = 1000 // rows locked by one UPDATE statement
+	defaultReindexMaxBatches = 20
+	reindexTimeBudget        = 20 * time.Second // stays under the request timeout
+
+	// Folder tree levels returned when the folder depth is unlimited, matching
+	// the recursion bound of the other folder queries
+	maxFolderTreeDepth = 1024
+
+	// Presence: clients heartbeat well within the TTL while a document is open
+	presenceTTL = 30 * time.Second
+
+	// missingDocumentTTL caches negative existence checks only briefly
+	missingDocumentTTL = 30 * time.Second
 )
 
 // Service handles document business logic
 type Service struct {
-	repo   *repository.Repository
-	cache  *cache.Cache
-	logger *zap.Logger
+	repo           *repository.Repository
+	cache          *cache.Cache
+	tenants        *client.TenantClient
+	rbac           *client.RBACClient
+	storage        *client.StorageClient
+	maxFolderDepth int
+	maxPins        int
+	logger         *zap.Logger
 }
 
 // NewService creates a new document service
-func NewService(repo *repository.Repository, cache *cache.Cache, logger *zap.Logger) *Service {
+func NewService(repo *repository.Repository, cache *cache.Cache, tenants *client.TenantClient, rbac *client.RBACClient, storage *client.StorageClient, cfg config.DocumentsConfig, logger *zap.Logger) *Service {
 	return &Service{
-		repo:   repo,
-		cache:  cache,
-		logger: logger,
+		repo:           repo,
+		cache:          cache,
+		tenants:        tenants,
+		rbac:           rbac,
+		storage:        storage,
+		maxFolderDepth: cfg.MaxFolderDepth,
+		maxPins:        cfg.MaxPinsPerFolder,
+		logger:         logger,
 	}
 }
 
 // Document operations
 
 // CreateDocument creates a new document (metadata only, file upload handled separately)
-func (s *Service) CreateDocument(ctx context.Context, req *models.CreateDocumentRequest, fileInfo FileInfo) (*models.Document, error) {
+func (s *Service) CreateDocument(ctx context.Context, req *models.CreateDocumentRequest, fileInfo FileInfo) (*models.DocumentWithDetails, error) {
 	tenantID := getTenantID(ctx)
 	userID := middleware.GetUserID(ctx)
 
+	name, err := validator.CleanName("name", req.Name, models.MaxDocumentNameLength)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate folder ownership if provided
 	if req.FolderID != "" {
 		folderUUID, _ := uuid.Parse(req.FolderID)
@@ -54,24 +106,36 @@ func (s *Service) CreateDocument(ctx context.Context, req *models.CreateDocument
 
 	// Validate category ownership if provided
 	if req.CategoryID != "" {
-		// TODO: Validate category exists and belongs to tenant
+		categoryUUID, _ := uuid.Parse(req.CategoryID)
+		if _, err := s.repo.GetCategory(ctx, tenantID, categoryUUID); err != nil {
+			if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeNotFound {
+				return nil, errors.Validationf("category_id %s does not exist", req.CategoryID).WithField("category_id", "not found")
+			}
+			return nil, err
+		}
+	}
+
+	ocrLanguage, err := checkOCRLanguage(req.OCRLanguage)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create document
 	doc := &models.Document{
 		ID:            uuid.New(),
 		TenantID:      tenantID,
-		Name:          req.Name,
+		Name:          name,
 		FileType:      fileInfo.Extension,
 		FileSize:      fileInfo.Size,
 		MimeType:      fileInfo.MimeType,
 		StoragePath:   fileInfo.StoragePath,
-		Status:        "active",
+		Status:        models.DocumentStatusActive,
 		UploadedBy:    userID,
-		OCRStatus:     "pending",
+		OCRStatus:     models.OCRStatusPending,
+		OCRLanguage:   nullString(ocrLanguage),
 		Version:       1,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		CreatedAt:     timeutil.Now(),
+		UpdatedAt:     timeutil.Now(),
 	}
 
 	if req.Description != "" {
@@ -89,279 +153,1877 @@ func (s *Service) CreateDocument(ctx context.Context, req *models.CreateDocument
 		doc.CategoryID.Valid = true
 	}
 
-	if err := s.repo.CreateDocument(ctx, doc); err != nil {
+	// New tags are saved with the document, so a rejected request creates none
+	tags, created, err := s.resolveTags(ctx, tenantID, req.Tags)
+	if err != nil {
 		return nil, err
 	}
 
-	// Add tags if provided
-	if len(req.Tags) > 0 {
-		for _, tagName := range req.Tags {
-			// TODO: Find or create tag, then associate with document
-			_ = tagName
-		}
+	if err := s.repo.CreateDocument(ctx, doc, tags, created); err != nil {
+		return nil, err
 	}
 
 	logger.InfoContext(ctx, "document created",
 		zap.String("document_id", doc.ID.String()),
 		zap.String("name", doc.Name),
+		zap.Int("tags", len(tags)),
 	)
 
-	return doc, nil
+	return &models.DocumentWithDetails{Document: *doc, Tags: tags}, nil
+}
+
+// resolveTags maps tag references to tags. Names match existing tags ignoring
+// case and references naming the same tag twice are kept once. A name with no
+// tag yet gets a new, unsaved tag, also returned in created as a pointer into
+// tags so the repository can save it with the document. Unknown tag IDs are
+// rejected.
+func (s *Service) resolveTags(ctx context.Context, tenantID uuid.UUID, refs []string) ([]models.Tag, []*models.Tag, error) {
+	if len(refs) == 0 {
+		return nil, nil, nil
+	}
+
+	var ids []uuid.UUID
+	var names []string
+	for _, ref := range refs {
+		if id, err := uuid.Parse(ref); err == nil {
+			ids = append(ids, id)
+		} else {
+			names = append(names, ref)
+		}
+	}
+
+	byID := make(map[uuid.UUID]models.Tag, len(ids))
+	if len(ids) > 0 {
+		found, err := s.repo.FindTags(ctx, tenantID, ids, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, tag := range found {
+			byID[tag.ID] = tag
+		}
+		var unknown []string
+		for _, id := range ids {
+			if _, ok := byID[id]; !ok {
+				unknown = append(unknown, id.String())
+			}
+		}
+		if len(unknown) > 0 {
+			return nil, nil, errors.Validationf("unknown tags: %s", strings.Join(unknown, ", ")).WithField("tags", "not found")
+		}
+	}
+
+	byName := make(map[string]models.Tag, len(names))
+	isNew := make(map[uuid.UUID]bool)
+	for _, ref := range names {
+		name, err := validator.CleanName("tags", ref, models.MaxTagNameLength)
+		if err != nil {
+			return nil, nil, err
+		}
+		key := strings.ToLower(name)
+		if _, ok := byName[key]; ok {
+			continue
+		}
+		tag, err := s.repo.GetTagByName(ctx, tenantID, name)
+		if err != nil {
+			if !isNotFound(err) {
+				return nil, nil, err
+			}
+			tag = &models.Tag{
+				ID:        uuid.New(),
+				TenantID:  tenantID,
+				Name:      name,
+				Color:     models.DefaultTagColor,
+				CreatedBy: middleware.GetUserID(ctx),
+				CreatedAt: timeutil.Now(),
+			}
+			isNew[tag.ID] = true
+		}
+		byName[key] = *tag
+	}
+
+	seen := make(map[uuid.UUID]bool, len(refs))
+	tags := make([]models.Tag, 0, len(refs))
+	for _, ref := range refs {
+		var tag models.Tag
+		if id, err := uuid.Parse(ref); err == nil {
+			tag = byID[id]
+		} else {
+			tag = byName[strings.ToLower(validator.SanitizeName(ref))]
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	var created []*models.Tag
+	for i := range tags {
+		if isNew[tags[i].ID] {
+			created = append(created, &tags[i])
+		}
+	}
+
+	return tags, created, nil
 }
 
-// GetDocument retrieves a document by ID
-func (s *Service) GetDocument(ctx context.Context, docID uuid.UUID) (*models.Document, error) {
+// GetDocument retrieves a document by ID; a soft-deleted one is only found
+// when includeTrashed is set
+func (s *Service) GetDocument(ctx context.Context, docID uuid.UUID, includeTrashed bool) (*models.Document, error) {
 	tenantID := getTenantID(ctx)
 
-	// Try cache first
+	// Try cache first; only live documents are cached
 	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
 	var doc models.Document
 	if err := s.cache.Get(ctx, cacheKey, &doc); err == nil {
+		if err := s.authorizeDocument(ctx, &doc, models.AccessRead); err != nil {
+			return nil, err
+		}
 		return &doc, nil
 	}
 
 	// Fetch from database
-	docPtr, err := s.repo.GetDocument(ctx, tenantID, docID)
+	docPtr, err := s.repo.GetDocument(ctx, tenantID, docID, includeTrashed)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache for future requests
-	_ = s.cache.Set(ctx, cacheKey, docPtr, documentCacheTTL)
+	if !includeTrashed {
+		_ = s.cache.Set(ctx, cacheKey, docPtr, documentCacheTTL)
+	}
+
+	if err := s.authorizeDocument(ctx, docPtr, models.AccessRead); err != nil {
+		return nil, err
+	}
 
 	return docPtr, nil
 }
 
-// ListDocuments retrieves documents with filtering
-func (s *Service) ListDocuments(ctx context.Context, params *models.ListDocumentsParams) ([]models.Document, int64, error) {
+// BatchGetDocuments fetches several documents in one query, in request order.
+// Documents that are missing, in another tenant or not readable by the caller
+// come back as null entries. Document ACLs are read in one query and folder
+// access is decided once per folder.
+func (s *Service) BatchGetDocuments(ctx context.Context, req *models.BatchGetDocumentsRequest) (*models.BatchGetDocumentsResponse, error) {
 	tenantID := getTenantID(ctx)
 
-	params.Normalize()
+	ids := make([]uuid.UUID, len(req.IDs))
+	for i, idStr := range req.IDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid document ID: %s", idStr)
+		}
+		ids[i] = id
+	}
 
-	documents, total, err := s.repo.ListDocuments(ctx, tenantID, params)
+	documents, err := s.repo.GetDocuments(ctx, tenantID, ids)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	return documents, total, nil
+	readable, err := s.newAccessChecker(ctx, ids, models.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[uuid.UUID]*models.Document, len(documents))
+	for i := range documents {
+		doc := &documents[i]
+		allowed, err := readable.allowed(ctx, doc.ID, doc.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			found[doc.ID] = doc
+		}
+	}
+
+	result := &models.BatchGetDocumentsResponse{Documents: make([]*models.Document, len(ids))}
+	for i, id := range ids {
+		result.Documents[i] = found[id]
+	}
+
+	return result, nil
 }
 
-// UpdateDocument updates a document
-func (s *Service) UpdateDocument(ctx context.Context, docID uuid.UUID, req *models.UpdateDocumentRequest) error {
+// ListDocumentChanges returns a page of the documents created, updated or
+// deleted after params.Since, for incremental sync. Changes the caller cannot
+// read are left out, deciding read access once per folder; a page may hold
+// fewer changes than the limit while more follow.
+func (s *Service) ListDocumentChanges(ctx context.Context, params models.DocumentChangesParams) (*models.DocumentChangesPage, error) {
 	tenantID := getTenantID(ctx)
 
-	// Verify document exists and belongs to tenant
-	if _, err := s.repo.GetDocument(ctx, tenantID, docID); err != nil {
-		return err
+	params.Normalize()
+
+	since, _, err := validator.ParseTimeParam("since", params.Since)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate folder if provided
-	if req.FolderID != nil && *req.FolderID != "" {
-		folderUUID, _ := uuid.Parse(*req.FolderID)
-		if _, err := s.repo.GetFolder(ctx, tenantID, folderUUID); err != nil {
-			return errors.Validationf("invalid folder_id")
+	var cursor *models.DocumentChangeCursor
+	if params.Cursor != "" {
+		c, err := models.DecodeDocumentChangeCursor(params.Cursor)
+		if err != nil {
+			return nil, errors.Validationf("invalid cursor")
 		}
+		cursor = c
 	}
 
-	// Update document
-	if err := s.repo.UpdateDocument(ctx, tenantID, docID, req); err != nil {
-		return err
+	// Taken before reading so changes committed meanwhile are seen next time
+	page := &models.DocumentChangesPage{ServerTime: timeutil.Now()}
+
+	// Fetch one extra row to learn whether another page follows
+	changes, err := s.repo.ListDocumentChanges(ctx, tenantID, since, cursor, params.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > params.Limit {
+		changes = changes[:params.Limit]
+		last := changes[len(changes)-1]
+		page.NextCursor = models.DocumentChangeCursor{ChangedAt: last.ChangedAt.Time, ID: last.DocumentID}.Encode()
 	}
 
-	// Invalidate cache
-	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
-	_ = s.cache.Delete(ctx, cacheKey)
+	var ids []uuid.UUID
+	for _, change := range changes {
+		if change.ChangeType != models.ChangeTypeDeleted {
+			ids = append(ids, change.DocumentID)
+		}
+	}
+	documents, err := s.repo.GetDocuments(ctx, tenantID, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]*models.Document, len(documents))
+	for i := range documents {
+		byID[documents[i].ID] = &documents[i]
+	}
 
-	// Update tags if provided
-	if len(req.Tags) > 0 {
-		// TODO: Update document tags
+	// Deleted documents lost their ACL with them and are judged by folder
+	readable, err := s.newAccessChecker(ctx, ids, models.AccessRead)
+	if err != nil {
+		return nil, err
 	}
+	page.Changes = make([]models.DocumentChange, 0, len(changes))
+	for _, change := range changes {
+		doc := byID[change.DocumentID]
+		if change.ChangeType != models.ChangeTypeDeleted && doc == nil {
+			// Deleted after the feed was read; its tombstone follows later
+			continue
+		}
+		if doc != nil {
+			change.FolderID = doc.FolderID
+		}
 
-	logger.InfoContext(ctx, "document updated", zap.String("document_id", docID.String()))
+		allowed, err := readable.allowed(ctx, change.DocumentID, change.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
 
-	return nil
+		change.Document = doc
+		page.Changes = append(page.Changes, change)
+	}
+
+	return page, nil
 }
 
-// DeleteDocument deletes a document
-func (s *Service) DeleteDocument(ctx context.Context, docID uuid.UUID) error {
+// DocumentExists reports whether a document exists in the tenant in ctx
+// (internal use, e.g. before creating shares or files for it). Misses are
+// cached for missingDocumentTTL; a cached document counts as existing.
+func (s *Service) DocumentExists(ctx context.Context, docID uuid.UUID) (*models.DocumentExistence, error) {
 	tenantID := getTenantID(ctx)
+	result := &models.DocumentExistence{DocumentID: docID}
 
-	// Verify document exists
-	doc, err := s.repo.GetDocument(ctx, tenantID, docID)
-	if err != nil {
-		return err
+	if found, _ := s.cache.Exists(ctx, cache.TenantKey(tenantID.String(), "document", docID.String())); found {
+		result.Exists = true
+		return result, nil
 	}
 
-	// Delete from database
-	if err := s.repo.DeleteDocument(ctx, tenantID, docID); err != nil {
-		return err
+	missingKey := cache.TenantKey(tenantID.String(), "document_missing", docID.String())
+	if missing, _ := s.cache.Exists(ctx, missingKey); missing {
+		return result, nil
 	}
 
-	// Invalidate cache
-	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
-	_ = s.cache.Delete(ctx, cacheKey)
-
-	// TODO: Delete file from storage service
-	_ = doc.StoragePath
+	exists, err := s.repo.DocumentExists(ctx, tenantID, docID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		_ = s.cache.SetString(ctx, missingKey, "1", missingDocumentTTL)
+	}
 
-	logger.InfoContext(ctx, "document deleted", zap.String("document_id", docID.String()))
+	result.Exists = exists
+	return result, nil
+}
 
+// checkListRanges rejects size and date ranges whose lower bound is above
+// their upper bound
+func checkListRanges(params *models.ListDocumentsParams) error {
+	if params.MinSize > 0 && params.MaxSize > 0 && params.MinSize > params.MaxSize {
+		return errors.Validationf("min_size must not exceed max_size").WithField("min_size", "must not exceed max_size")
+	}
+	if !params.CreatedAfter.IsZero() && !params.CreatedBefore.IsZero() && !params.CreatedAfter.Before(params.CreatedBefore) {
+		return errors.Validationf("created_after must be before created_before").WithField("created_after", "must be before created_before")
+	}
+	if !params.UpdatedAfter.IsZero() && !params.UpdatedBefore.IsZero() && !params.UpdatedAfter.Before(params.UpdatedBefore) {
+		return errors.Validationf("updated_after must be before updated_before").WithField("updated_after", "must be before updated_before")
+	}
 	return nil
 }
 
-// Folder operations
-
-// CreateFolder creates a new folder
-func (s *Service) CreateFolder(ctx context.Context, req *models.CreateFolderRequest) (*models.Folder, error) {
+// ListDocuments retrieves documents with filtering
+func (s *Service) ListDocuments(ctx context.Context, params *models.ListDocumentsParams) ([]models.DocumentWithDetails, int64, error) {
 	tenantID := getTenantID(ctx)
 	userID := middleware.GetUserID(ctx)
 
-	// Build folder path
-	var path string
-	if req.ParentID != "" {
-		parentUUID, _ := uuid.Parse(req.ParentID)
-		parent, err := s.repo.GetFolder(ctx, tenantID, parentUUID)
+	params.Normalize()
+
+	if err := checkListRanges(params); err != nil {
+		return nil, 0, err
+	}
+
+	// Anyone may list their own uploads; another user's require document:manage
+	switch params.UploadedBy {
+	case "", userID:
+	case models.UploadedByMe:
+		params.UploadedBy = userID
+	default:
+		allowed, err := s.rbac.CheckPermission(ctx, userID, "document", "manage")
 		if err != nil {
-			return nil, errors.Validationf("invalid parent_id")
+			return nil, 0, err
+		}
+		if !allowed {
+			return nil, 0, errors.Forbiddenf("document:manage permission required to list another user's documents")
 		}
-		path = parent.Path + "/" + sanitizeFolderName(req.Name)
-	} else {
-		path = "/" + sanitizeFolderName(req.Name)
 	}
 
-	folder := &models.Folder{
-		ID:        uuid.New(),
-		TenantID:  tenantID,
-		Name:      req.Name,
-		Path:      path,
-		CreatedBy: userID,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	access, err := s.documentAccessFilter(ctx, models.AccessRead)
+	if err != nil {
+		return nil, 0, err
 	}
+	params.Access = access
 
-	if req.ParentID != "" {
-		folder.ParentID.String = req.ParentID
-		folder.ParentID.Valid = true
+	documents, total, err := s.repo.ListDocuments(ctx, tenantID, params)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if req.Description != "" {
-		folder.Description.String = req.Description
-		folder.Description.Valid = true
+	if params.WithShares {
+		if err := s.attachShareCounts(ctx, tenantID, documents); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	if req.Color != "" {
-		folder.Color.String = req.Color
-		folder.Color.Valid = true
-	}
+	return documents, total, nil
+}
 
-	if req.Icon != "" {
-		folder.Icon.String = req.Icon
-		folder.Icon.Valid = true
+// GetDocumentWithShares retrieves a document with its share indicators
+func (s *Service) GetDocumentWithShares(ctx context.Context, docID uuid.UUID, includeTrashed bool) (*models.DocumentWithDetails, error) {
+	doc, err := s.GetDocument(ctx, docID, includeTrashed)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.CreateFolder(ctx, folder); err != nil {
+	details := []models.DocumentWithDetails{{Document: *doc}}
+	if err := s.attachShareCounts(ctx, getTenantID(ctx), details); err != nil {
 		return nil, err
 	}
 
-	logger.InfoContext(ctx, "folder created",
-		zap.String("folder_id", folder.ID.String()),
-		zap.String("name", folder.Name),
-	)
-
-	return folder, nil
+	return &details[0], nil
 }
 
-// GetFolder retrieves a folder by ID
-func (s *Service) GetFolder(ctx context.Context, folderID uuid.UUID) (*models.Folder, error) {
+// GetDocumentWithDetails retrieves a document with its tags, category and
+// folder. A category or folder that no longer exists is left out.
+func (s *Service) GetDocumentWithDetails(ctx context.Context, docID uuid.UUID, withShares bool) (*models.DocumentWithDetails, error) {
 	tenantID := getTenantID(ctx)
 
-	folder, err := s.repo.GetFolder(ctx, tenantID, folderID)
+	doc, err := s.GetDocument(ctx, docID, false)
 	if err != nil {
 		return nil, err
 	}
 
-	return folder, nil
-}
-
-// ListFolders retrieves folders
-func (s *Service) ListFolders(ctx context.Context, parentID *string) ([]models.Folder, error) {
-	tenantID := getTenantID(ctx)
+	details := []models.DocumentWithDetails{{Document: *doc}}
+	result := &details[0]
 
-	folders, err := s.repo.ListFolders(ctx, tenantID, parentID)
-	if err != nil {
+	if result.Tags, err = s.repo.GetDocumentTags(ctx, doc.ID); err != nil {
 		return nil, err
 	}
 
-	return folders, nil
+	if doc.CategoryID.Valid {
+		categoryID, _ := uuid.Parse(doc.CategoryID.String)
+		category, err := s.repo.GetCategory(ctx, tenantID, categoryID)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		result.Category = category
+	}
+
+	if doc.FolderID.Valid {
+		folderID, _ := uuid.Parse(doc.FolderID.String)
+		folder, err := s.repo.GetFolder(ctx, tenantID, folderID)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		if folder != nil {
+			result.FolderName = folder.Name
+			result.FolderPath = folder.Path
+		}
+	}
+
+	if withShares {
+		if err := s.attachShareCounts(ctx, tenantID, details); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
 }
 
-// DeleteFolder deletes a folder
-func (s *Service) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
-	tenantID := getTenantID(ctx)
+// isNotFound reports whether err is a not-found application error
+func isNotFound(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && appErr.Code == errors.ErrCodeNotFound
+}
 
-	// TODO: Check if folder has documents or subfolders
+// attachShareCounts sets the active share count of each document in one query
+func (s *Service) attachShareCounts(ctx context.Context, tenantID uuid.UUID, documents []models.DocumentWithDetails) error {
+	docIDs := make([]uuid.UUID, len(documents))
+	for i := range documents {
+		docIDs[i] = documents[i].ID
+	}
 
-	if err := s.repo.DeleteFolder(ctx, tenantID, folderID); err != nil {
+	counts, err := s.repo.CountActiveShares(ctx, tenantID, docIDs)
+	if err != nil {
 		return err
 	}
 
-	logger.InfoContext(ctx, "folder deleted", zap.String("folder_id", folderID.String()))
-
+	for i := range documents {
+		documents[i].SetShareCount(counts[documents[i].ID])
+	}
 	return nil
 }
 
-// Tag operations
-
-// CreateTag creates a new tag
-func (s *Service) CreateTag(ctx context.Context, req *models.CreateTagRequest) (*models.Tag, error) {
+// QuickSearch matches documents by name and tag name for a search box. It is
+// kept cheap for per-keystroke calls: minimal fields, a small result cap and
+// no counting or ranking beyond match type.
+func (s *Service) QuickSearch(ctx context.Context, query string, limit int) ([]models.QuickSearchResult, error) {
 	tenantID := getTenantID(ctx)
-	userID := middleware.GetUserID(ctx)
 
-	tag := &models.Tag{
-		ID:        uuid.New(),
-		TenantID:  tenantID,
-		Name:      strings.TrimSpace(req.Name),
-		Color:     req.Color,
-		CreatedBy: userID,
-		CreatedAt: time.Now(),
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []models.QuickSearchResult{}, nil
+	}
+	if len([]rune(query)) > maxQuickSearchQuery {
+		return nil, errors.Validationf("q must be at most %d characters", maxQuickSearchQuery)
+	}
+	if limit < 1 {
+		limit = defaultQuickSearchLimit
+	}
+	if limit > maxQuickSearchLimit {
+		limit = maxQuickSearchLimit
 	}
 
-	if err := s.repo.CreateTag(ctx, tag); err != nil {
+	access, err := s.documentAccessFilter(ctx, models.AccessRead)
+	if err != nil {
 		return nil, err
 	}
 
-	logger.InfoContext(ctx, "tag created", zap.String("tag", tag.Name))
-
-	return tag, nil
+	return s.repo.QuickSearchDocuments(ctx, tenantID, query, limit, access)
 }
 
-// ListTags retrieves all tags
-func (s *Service) ListTags(ctx context.Context) ([]models.Tag, error) {
-	tenantID := getTenantID(ctx)
+// UpdateDocument replaces a document's editable fields; omitted optional fields are cleared
+func (s *Service) UpdateDocument(ctx context.Context, docID uuid.UUID, req *models.UpdateDocumentRequest) error {
+	name, err := validator.CleanName("name", req.Name, models.MaxDocumentNameLength)
+	if err != nil {
+		return err
+	}
 
-	tags, err := s.repo.ListTags(ctx, tenantID)
+	ocrLanguage, err := checkOCRLanguage(req.OCRLanguage)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return tags, nil
+	updates := map[string]interface{}{
+		"name":         name,
+		"description":  nullString(req.Description),
+		"folder_id":    nullString(req.FolderID),
+		"category_id":  nullString(req.CategoryID),
+		"ocr_language": nullString(ocrLanguage),
+	}
+
+	// Tags are replaced like every other field; omitting them removes them all
+	tags := req.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return s.applyDocumentUpdates(ctx, docID, updates, &tags)
 }
 
-// Category operations
+// PatchDocument applies a partial update; only provided fields change
+func (s *Service) PatchDocument(ctx context.Context, docID uuid.UUID, req *models.PatchDocumentRequest) error {
+	updates := make(map[string]interface{})
 
-// CreateCategory creates a new category
-func (s *Service) CreateCategory(ctx context.Context, req *models.CreateCategoryRequest) (*models.Category, error) {
-	tenantID := getTenantID(ctx)
+	if req.Name != nil {
+		name, err := validator.CleanName("name", *req.Name, models.MaxDocumentNameLength)
+		if err != nil {
+			return err
+		}
+		updates["name"] = name
+	}
+
+	if req.Description != nil {
+		updates["description"] = nullString(*req.Description)
+	}
+
+	if req.FolderID != nil {
+		if *req.FolderID != "" {
+			if _, err := uuid.Parse(*req.FolderID); err != nil {
+				return errors.Validationf("invalid folder_id")
+			}
+		}
+		updates["folder_id"] = nullString(*req.FolderID)
+	}
+
+	if req.CategoryID != nil {
+		if *req.CategoryID != "" {
+			if _, err := uuid.Parse(*req.CategoryID); err != nil {
+				return errors.Validationf("invalid category_id")
+			}
+		}
+		updates["category_id"] = nullString(*req.CategoryID)
+	}
+
+	if req.OCRLanguage != nil {
+		ocrLanguage, err := checkOCRLanguage(*req.OCRLanguage)
+		if err != nil {
+			return err
+		}
+		updates["ocr_language"] = nullString(ocrLanguage)
+	}
+
+	return s.applyDocumentUpdates(ctx, docID, updates, req.Tags)
+}
+
+// applyDocumentUpdates validates references and persists document changes.
+// A non-nil tags replaces the document's tags, resolved like CreateDocument
+// and saved in the same transaction as the other changes.
+func (s *Service) applyDocumentUpdates(ctx context.Context, docID uuid.UUID, updates map[string]interface{}, tags *[]string) error {
+	tenantID := getTenantID(ctx)
+
+	// Verify document exists and belongs to tenant
+	doc, err := s.repo.GetDocument(ctx, tenantID, docID, false)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeDocument(ctx, doc, models.AccessUpdate); err != nil {
+		return err
+	}
+
+	// Validate folder if provided
+	if folderID, ok := updates["folder_id"].(sql.NullString); ok && folderID.Valid {
+		folderUUID, _ := uuid.Parse(folderID.String)
+		if _, err := s.repo.GetFolder(ctx, tenantID, folderUUID); err != nil {
+			return errors.Validationf("invalid folder_id")
+		}
+	}
+
+	// Validate category if provided, so counts only move to real categories
+	if categoryID, ok := updates["category_id"].(sql.NullString); ok && categoryID.Valid {
+		categoryUUID, _ := uuid.Parse(categoryID.String)
+		if _, err := s.repo.GetCategory(ctx, tenantID, categoryUUID); err != nil {
+			if isNotFound(err) {
+				return errors.Validationf("category_id %s does not exist", categoryID.String).WithField("category_id", "not found")
+			}
+			return err
+		}
+	}
+
+	// Resolve tags before writing so a bad tag leaves the document untouched
+	var resolved *[]models.Tag
+	var created []*models.Tag
+	if tags != nil {
+		found, newTags, err := s.resolveTags(ctx, tenantID, *tags)
+		if err != nil {
+			return err
+		}
+		resolved, created = &found, newTags
+	}
+
+	// Update document and tags together
+	if err := s.repo.UpdateDocument(ctx, tenantID, docID, updates, resolved, created); err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	logger.InfoContext(ctx, "document updated", zap.String("document_id", docID.String()))
+
+	return nil
+}
+
+// Heartbeat marks the caller as present on a document for presenceTTL and
+// returns everyone currently present
+func (s *Service) Heartbeat(ctx context.Context, docID uuid.UUID) (*models.PresenceResponse, error) {
+	if _, err := s.GetDocument(ctx, docID, false); err != nil {
+		return nil, err
+	}
+
+	// Redis sets have no per-member TTL, so each user's last heartbeat is kept
+	// in a hash and stale entries are filtered out on read. The key itself
+	// expires once the last user stops heartbeating.
+	key := cache.TenantKey(getTenantID(ctx).String(), "presence", docID.String())
+	entry := models.DocumentPresence{
+		UserID:   middleware.GetUserID(ctx),
+		Email:    middleware.GetUserEmail(ctx),
+		LastSeen: timeutil.Now(),
+	}
+	if err := s.cache.HSet(ctx, key, entry.UserID, entry); err != nil {
+		return nil, err
+	}
+	if err := s.cache.Expire(ctx, key, presenceTTL); err != nil {
+		return nil, err
+	}
+
+	return s.presence(ctx, docID, key)
+}
+
+// GetPresence returns the users whose last heartbeat on a document is
+// within presenceTTL
+func (s *Service) GetPresence(ctx context.Context, docID uuid.UUID) (*models.PresenceResponse, error) {
+	if _, err := s.GetDocument(ctx, docID, false); err != nil {
+		return nil, err
+	}
+
+	key := cache.TenantKey(getTenantID(ctx).String(), "presence", docID.String())
+	return s.presence(ctx, docID, key)
+}
+
+// presence reads the presence hash at key, dropping entries older than
+// presenceTTL. Most recently seen users come first.
+func (s *Service) presence(ctx context.Context, docID uuid.UUID, key string) (*models.PresenceResponse, error) {
+	fields, err := s.cache.HGetAll(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-presenceTTL)
+	users := make([]models.DocumentPresence, 0, len(fields))
+	var stale []string
+	for userID, data := range fields {
+		var entry models.DocumentPresence
+		if err := json.Unmarshal([]byte(data), &entry); err != nil || entry.LastSeen.Before(cutoff) {
+			stale = append(stale, userID)
+			continue
+		}
+		users = append(users, entry)
+	}
+	if len(stale) > 0 {
+		_ = s.cache.HDel(ctx, key, stale...)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].LastSeen.Equal(users[j].LastSeen.Time) {
+			return users[i].LastSeen.After(users[j].LastSeen.Time)
+		}
+		return users[i].UserID < users[j].UserID
+	})
+
+	return &models.PresenceResponse{
+		DocumentID: docID,
+		Users:      users,
+		TTLSeconds: int(presenceTTL / time.Second),
+	}, nil
+}
+
+// RetryOCR resets a document's OCR status to pending and queues a new OCR
+// job. A non-empty language replaces the document's OCR language hint;
+// otherwise the current hint is kept.
+func (s *Service) RetryOCR(ctx context.Context, docID uuid.UUID, language string) (*models.Document, error) {
+	tenantID := getTenantID(ctx)
+
+	ocrLanguage, err := checkOCRLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := s.repo.GetDocument(ctx, tenantID, docID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeDocument(ctx, doc, models.AccessUpdate); err != nil {
+		return nil, err
+	}
+
+	if ocrLanguage != "" {
+		doc.OCRLanguage = nullString(ocrLanguage)
+	}
+
+	job := &models.OCRJob{
+		ID:          uuid.New(),
+		DocumentID:  doc.ID,
+		TenantID:    tenantID,
+		RequestedBy: middleware.GetUserID(ctx),
+		Language:    doc.OCRLanguage.String,
+		CreatedAt:   timeutil.Now(),
+	}
+	if err := s.repo.RetryOCR(ctx, tenantID, docID, doc.OCRLanguage, job); err != nil {
+		return nil, err
+	}
+
+	doc.OCRStatus = models.OCRStatusPending
+	doc.UpdatedAt = job.CreatedAt
+
+	// Invalidate cache
+	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	logger.InfoContext(ctx, "document OCR requeued",
+		zap.String("document_id", docID.String()),
+		zap.String("ocr_job_id", job.ID.String()),
+		zap.String("language", job.Language),
+	)
+
+	return doc, nil
+}
+
+// PinDocument pins a document to the top of its folder's listing. Pins are
+// tenant-wide: every user sees the same pinned documents, as folders are
+// shared views.
+func (s *Service) PinDocument(ctx context.Context, docID uuid.UUID) (*models.Document, error) {
+	return s.setPinned(ctx, docID, true)
+}
+
+// UnpinDocument removes a document's pin
+func (s *Service) UnpinDocument(ctx context.Context, docID uuid.UUID) (*models.Document, error) {
+	return s.setPinned(ctx, docID, false)
+}
+
+func (s *Service) setPinned(ctx context.Context, docID uuid.UUID, pinned bool) (*models.Document, error) {
+	tenantID := getTenantID(ctx)
+
+	doc, err := s.repo.GetDocument(ctx, tenantID, docID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeDocument(ctx, doc, models.AccessUpdate); err != nil {
+		return nil, err
+	}
+	if doc.IsPinned == pinned {
+		return doc, nil
+	}
+
+	if pinned {
+		err = s.repo.PinDocument(ctx, tenantID, docID, doc.FolderID, s.maxPins)
+	} else {
+		err = s.repo.UnpinDocument(ctx, tenantID, docID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.IsPinned = pinned
+
+	// Invalidate cache
+	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	logger.InfoContext(ctx, "document pin changed",
+		zap.String("document_id", docID.String()),
+		zap.Bool("pinned", pinned),
+	)
+
+	return doc, nil
+}
+
+// DeleteDocument deletes a document
+func (s *Service) DeleteDocument(ctx context.Context, docID uuid.UUID) error {
+	tenantID := getTenantID(ctx)
+
+	// Verify document exists
+	doc, err := s.repo.GetDocument(ctx, tenantID, docID, false)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeDocument(ctx, doc, models.AccessDelete); err != nil {
+		return err
+	}
+
+	// Delete from database
+	if err := s.repo.DeleteDocument(ctx, tenantID, docID); err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	// TODO: Delete file from storage service
+	_ = doc.StoragePath
+
+	logger.InfoContext(ctx, "document deleted", zap.String("document_id", docID.String()))
+
+	return nil
+}
+
+// RestoreVersion makes an earlier version's content current again. The old
+// object is copied server-side in storage and the restore is recorded as a
+// new version, so history is never rewritten.
+func (s *Service) RestoreVersion(ctx context.Context, docID uuid.UUID, versionNumber int) (*models.Document, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	doc, err := s.repo.GetDocument(ctx, tenantID, docID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeDocument(ctx, doc, models.AccessUpdate); err != nil {
+		return nil, err
+	}
+
+	if versionNumber == doc.Version {
+		return nil, errors.Validationf("version %d is already the current version", versionNumber)
+	}
+
+	version, err := s.repo.GetDocumentVersion(ctx, tenantID, docID, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	copied, err := s.storage.CopyObject(ctx, version.StoragePath, docID.String())
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeNotFound {
+			return nil, errors.NotFoundf("content of version %d has been purged and cannot be restored", versionNumber).
+				WithMeta("version", versionNumber)
+		}
+		return nil, err
+	}
+
+	mimeType := copied.MimeType
+	if mimeType == "" {
+		mimeType = doc.MimeType
+	}
+
+	restored := &models.DocumentVersion{
+		ID:            uuid.New(),
+		DocumentID:    docID,
+		VersionNumber: doc.Version + 1,
+		FileSize:      copied.Size,
+		StoragePath:   copied.ObjectKey,
+		UploadedBy:    userID,
+		Comment:       fmt.Sprintf("Restored from version %d", versionNumber),
+		CreatedAt:     timeutil.Now(),
+	}
+
+	if err := s.repo.RestoreDocumentVersion(ctx, doc, restored, mimeType); err != nil {
+		logger.WarnContext(ctx, "version restore failed after copying content",
+			zap.String("document_id", docID.String()),
+			zap.String("object_key", copied.ObjectKey),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "document", docID.String()))
+
+	logger.InfoContext(ctx, "document version restored",
+		zap.String("document_id", docID.String()),
+		zap.Int("restored_version", versionNumber),
+		zap.Int("version", restored.VersionNumber),
+	)
+
+	doc.StoragePath = restored.StoragePath
+	doc.FileSize = restored.FileSize
+	doc.MimeType = mimeType
+	doc.Version = restored.VersionNumber
+	doc.UpdatedAt = restored.CreatedAt
+	return doc, nil
+}
+
+// ReassignDocuments transfers ownership of documents from one user to another
+func (s *Service) ReassignDocuments(ctx context.Context, req *models.ReassignDocumentsRequest) (*models.ReassignDocumentsResponse, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	// Require document:manage
+	allowed, err := s.rbac.CheckPermission(ctx, userID, "document", "manage")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.Forbiddenf("document:manage permission required")
+	}
+
+	// Both users must belong to the tenant
+	for _, memberID := range []string{req.FromUserID, req.ToUserID} {
+		isMember, err := s.tenants.IsMember(ctx, tenantID.String(), memberID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, errors.Validationf("user %s is not a member of this tenant", memberID)
+		}
+	}
+
+	docIDs := make([]uuid.UUID, 0, len(req.DocumentIDs))
+	for _, idStr := range req.DocumentIDs {
+		docID, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid document_id: %s", idStr)
+		}
+		docIDs = append(docIDs, docID)
+	}
+
+	reassigned, err := s.repo.ReassignDocuments(ctx, tenantID, req.FromUserID, req.ToUserID, docIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	for _, docID := range reassigned {
+		cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+		_ = s.cache.Delete(ctx, cacheKey)
+	}
+
+	logger.InfoContext(ctx, "documents reassigned",
+		zap.String("from_user_id", req.FromUserID),
+		zap.String("to_user_id", req.ToUserID),
+		zap.Int("count", len(reassigned)),
+	)
+
+	response := bulk.NewResponse[bulk.Result](len(reassigned))
+	for _, docID := range reassigned {
+		response.Add(bulk.Result{ID: docID.String(), Success: true}, true)
+	}
+
+	return response, nil
+}
+
+// BulkUpdateStatus transitions many documents to a status. Documents that are
+// missing or cannot make the transition are reported individually.
+func (s *Service) BulkUpdateStatus(ctx context.Context, req *models.BulkStatusRequest) (*models.BulkStatusResponse, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	// Require document:update
+	allowed, err := s.rbac.CheckPermission(ctx, userID, "document", "update")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.Forbiddenf("document:update permission required")
+	}
+
+	docIDs := make([]uuid.UUID, 0, len(req.DocumentIDs))
+	for _, idStr := range req.DocumentIDs {
+		docID, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid document_id: %s", idStr)
+		}
+		docIDs = append(docIDs, docID)
+	}
+
+	response := bulk.NewResponse[models.BulkStatusResult](len(docIDs))
+	fromStatuses := models.StatusesTransitioningTo(req.Status)
+
+	// Each chunk is applied in its own transaction so large batches do not hold
+	// row locks for the whole request
+	chunks := bulk.Chunks(docIDs, bulk.DefaultChunkSize)
+	for i, chunk := range chunks {
+		previous, err := s.repo.TransitionDocumentStatus(ctx, tenantID, chunk, req.Status, fromStatuses)
+		if err != nil {
+			// Report the unprocessed remainder instead of discarding completed chunks
+			logger.ErrorContext(ctx, "bulk status update stopped",
+				zap.Int("chunk", i+1),
+				zap.Int("processed", response.Processed),
+				zap.Error(err),
+			)
+			for _, remaining := range chunks[i:] {
+				for _, docID := range remaining {
+					response.Add(models.BulkStatusResult{
+						Result: bulk.Result{ID: docID.String(), Error: "not processed: " + errors.FromError(err).Message},
+					}, false)
+				}
+			}
+			break
+		}
+
+		for _, docID := range chunk {
+			result := models.BulkStatusResult{Result: bulk.Result{ID: docID.String()}}
+
+			current, found := previous[docID]
+			switch {
+			case !found:
+				result.Error = "document not found"
+			case !models.CanTransitionStatus(current, req.Status):
+				result.PreviousStatus = current
+				result.Error = fmt.Sprintf("cannot change status from %s to %s", current, req.Status)
+			default:
+				result.PreviousStatus = current
+				result.Success = true
+
+				// Invalidate cache
+				cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+				_ = s.cache.Delete(ctx, cacheKey)
+			}
+
+			response.Add(result, result.Success)
+		}
+		response.Chunks++
+	}
+
+	logger.InfoContext(ctx, "document statuses updated",
+		zap.String("status", req.Status),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+// BulkDeleteDocuments deletes documents in chunks, each in its own
+// transaction. Every document needs delete access; the rest are reported as
+// failed.
+func (s *Service) BulkDeleteDocuments(ctx context.Context, req *models.BulkDeleteRequest) (*models.BulkDocumentsResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	if err := s.requireDocumentPermission(ctx, models.AccessDelete); err != nil {
+		return nil, err
+	}
+
+	docIDs, err := parseDocumentIDs(req.DocumentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.applyInChunks(ctx, "bulk delete", docIDs, models.AccessDelete, func(ids []uuid.UUID) ([]uuid.UUID, error) {
+		return s.repo.DeleteDocuments(ctx, tenantID, ids)
+	})
+
+	logger.InfoContext(ctx, "documents deleted",
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+// BulkMoveDocuments moves documents to a folder (the root when none is given)
+// in chunks, each in its own transaction. Like MoveFolderContents, the target
+// folder's ACL and every document's own access must allow updates.
+func (s *Service) BulkMoveDocuments(ctx context.Context, req *models.BulkMoveRequest) (*models.BulkDocumentsResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	if err := s.requireDocumentPermission(ctx, models.AccessUpdate); err != nil {
+		return nil, err
+	}
+
+	var targetID sql.NullString
+	if req.TargetFolderID != "" {
+		targetUUID, _ := uuid.Parse(req.TargetFolderID)
+		target, err := s.repo.GetFolder(ctx, tenantID, targetUUID)
+		if err != nil {
+			return nil, errors.Validationf("invalid target_folder_id")
+		}
+		targetID = nullString(target.ID.String())
+		if _, err := s.folderACLDecision(ctx, targetID, models.AccessUpdate); err != nil {
+			return nil, err
+		}
+	}
+
+	docIDs, err := parseDocumentIDs(req.DocumentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.applyInChunks(ctx, "bulk move", docIDs, models.AccessUpdate, func(ids []uuid.UUID) ([]uuid.UUID, error) {
+		return s.repo.MoveDocuments(ctx, tenantID, ids, targetID)
+	})
+
+	logger.InfoContext(ctx, "documents moved",
+		zap.String("target_folder_id", req.TargetFolderID),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+// applyInChunks runs apply over docIDs one chunk at a time. Documents that
+// are missing or that the caller may not perform action on are reported as
+// failed without reaching apply. apply returns the IDs it changed. An error
+// stops the run and the unprocessed remainder is reported, keeping the
+// results of completed chunks.
+func (s *Service) applyInChunks(ctx context.Context, operation string, docIDs []uuid.UUID, action string, apply func([]uuid.UUID) ([]uuid.UUID, error)) *models.BulkDocumentsResponse {
+	tenantID := getTenantID(ctx)
+	response := bulk.NewResponse[bulk.Result](len(docIDs))
+
+	chunks := bulk.Chunks(docIDs, bulk.DefaultChunkSize)
+	for i, chunk := range chunks {
+		results, err := s.applyChunk(ctx, chunk, action, apply)
+		if err != nil {
+			logger.ErrorContext(ctx, operation+" stopped",
+				zap.Int("chunk", i+1),
+				zap.Int("processed", response.Processed),
+				zap.Error(err),
+			)
+			for _, remaining := range chunks[i:] {
+				for _, docID := range remaining {
+					response.Add(bulk.Result{ID: docID.String(), Error: "not processed: " + errors.FromError(err).Message}, false)
+				}
+			}
+			break
+		}
+
+		for _, result := range results {
+			if result.Success {
+				_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "document", result.ID))
+			}
+			response.Add(result, result.Success)
+		}
+		response.Chunks++
+	}
+
+	return response
+}
+
+// applyChunk authorizes one chunk and applies it, returning a result per
+// document in chunk order
+func (s *Service) applyChunk(ctx context.Context, chunk []uuid.UUID, action string, apply func([]uuid.UUID) ([]uuid.UUID, error)) ([]bulk.Result, error) {
+	docs, err := s.repo.GetDocuments(ctx, getTenantID(ctx), chunk)
+	if err != nil {
+		return nil, err
+	}
+	folders := make(map[uuid.UUID]sql.NullString, len(docs))
+	for _, doc := range docs {
+		folders[doc.ID] = doc.FolderID
+	}
+
+	checker, err := s.newAccessChecker(ctx, chunk, action)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]bulk.Result, len(chunk))
+	permitted := make([]uuid.UUID, 0, len(chunk))
+	for i, docID := range chunk {
+		results[i] = bulk.Result{ID: docID.String()}
+
+		folderID, found := folders[docID]
+		if !found {
+			results[i].Error = "document not found"
+			continue
+		}
+		allowed, err := checker.allowed(ctx, docID, folderID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			results[i].Error = "document access denied for document:" + action
+			continue
+		}
+		permitted = append(permitted, docID)
+	}
+
+	if len(permitted) == 0 {
+		return results, nil
+	}
+	applied, err := apply(permitted)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[uuid.UUID]bool, len(applied))
+	for _, docID := range applied {
+		done[docID] = true
+	}
+	for i, docID := range chunk {
+		if results[i].Error != "" {
+			continue
+		}
+		if done[docID] {
+			results[i].Success = true
+		} else {
+			results[i].Error = "document not found"
+		}
+	}
+
+	return results, nil
+}
+
+// parseDocumentIDs parses document IDs, rejecting the request on the first invalid one
+func parseDocumentIDs(ids []string) ([]uuid.UUID, error) {
+	docIDs := make([]uuid.UUID, 0, len(ids))
+	for _, idStr := range ids {
+		docID, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid document_id: %s", idStr)
+		}
+		docIDs = append(docIDs, docID)
+	}
+	return docIDs, nil
+}
+
+// Folder operations
+
+// CreateFolder creates a new folder
+func (s *Service) CreateFolder(ctx context.Context, req *models.CreateFolderRequest) (*models.Folder, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	name, err := validator.CleanName("name", req.Name, models.MaxFolderNameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build folder path
+	var path string
+	depth := 1
+	if req.ParentID != "" {
+		parentUUID, _ := uuid.Parse(req.ParentID)
+		parent, err := s.repo.GetFolder(ctx, tenantID, parentUUID)
+		if err != nil {
+			return nil, errors.Validationf("invalid parent_id")
+		}
+		path = models.JoinFolderPath(parent.Path, name)
+		depth = folderDepth(parent) + 1
+	} else {
+		path = models.JoinFolderPath("", name)
+	}
+
+	if err := s.checkFolderDepth(depth); err != nil {
+		return nil, err
+	}
+
+	folder := &models.Folder{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Name:      name,
+		Path:      path,
+		Depth:     depth,
+		CreatedBy: userID,
+		CreatedAt: timeutil.Now(),
+		UpdatedAt: timeutil.Now(),
+	}
+
+	if req.ParentID != "" {
+		folder.ParentID.String = req.ParentID
+		folder.ParentID.Valid = true
+	}
+
+	if req.Description != "" {
+		folder.Description.String = req.Description
+		folder.Description.Valid = true
+	}
+
+	if req.Color != "" {
+		folder.Color.String = req.Color
+		folder.Color.Valid = true
+	}
+
+	if req.Icon != "" {
+		folder.Icon.String = req.Icon
+		folder.Icon.Valid = true
+	}
+
+	if err := s.repo.CreateFolder(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "folder created",
+		zap.String("folder_id", folder.ID.String()),
+		zap.String("name", folder.Name),
+	)
+
+	return folder, nil
+}
+
+// GetFolder retrieves a folder by ID
+func (s *Service) GetFolder(ctx context.Context, folderID uuid.UUID) (*models.Folder, error) {
+	tenantID := getTenantID(ctx)
+
+	folder, err := s.repo.GetFolder(ctx, tenantID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// ListFolders retrieves folders
+func (s *Service) ListFolders(ctx context.Context, parentID *string) ([]models.Folder, error) {
+	tenantID := getTenantID(ctx)
+
+	folders, err := s.repo.ListFolders(ctx, tenantID, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// GetFolderTree returns the tenant's folder hierarchy as nested nodes, roots
+// first. Levels below the maximum folder depth are left out.
+func (s *Service) GetFolderTree(ctx context.Context) ([]*models.FolderTreeNode, error) {
+	tenantID := getTenantID(ctx)
+
+	maxDepth := s.maxFolderDepth
+	if maxDepth <= 0 {
+		maxDepth = maxFolderTreeDepth
+	}
+
+	folders, err := s.repo.ListFolderTree(ctx, tenantID, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parents come before their children, so each parent node already exists
+	nodes := make(map[string]*models.FolderTreeNode, len(folders))
+	roots := []*models.FolderTreeNode{}
+	for _, folder := range folders {
+		node := &models.FolderTreeNode{Folder: folder, Children: []*models.FolderTreeNode{}}
+		nodes[folder.ID.String()] = node
+		if parent, ok := nodes[folder.ParentID.String]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	return roots, nil
+}
+
+// MoveFolder moves a folder, with everything below it, under a new parent or
+// to the root. The move is rejected when the deepest descendant would exceed
+// the maximum folder depth.
+func (s *Service) MoveFolder(ctx context.Context, folderID uuid.UUID, req *models.MoveFolderRequest) (*models.Folder, error) {
+	tenantID := getTenantID(ctx)
+
+	folder, err := s.repo.GetFolder(ctx, tenantID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentID sql.NullString
+	path := models.JoinFolderPath("", folder.Name)
+	depth := 1
+	if req.ParentID != "" {
+		parentUUID, _ := uuid.Parse(req.ParentID)
+		parent, err := s.repo.GetFolder(ctx, tenantID, parentUUID)
+		if err != nil {
+			return nil, errors.Validationf("invalid parent_id")
+		}
+
+		// A folder cannot become its own descendant
+		cyclic, err := s.repo.IsFolderAncestor(ctx, tenantID, folder.ID, parent.ID)
+		if err != nil {
+			return nil, err
+		}
+		if cyclic {
+			return nil, errors.Validationf("cannot move a folder into itself or one of its subfolders")
+		}
+
+		parentID = nullString(parent.ID.String())
+		path = parent.Path + path
+		depth = folderDepth(parent) + 1
+	}
+
+	height, err := s.repo.GetFolderSubtreeHeight(ctx, tenantID, folder.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkFolderDepth(depth + height); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.MoveFolder(ctx, folder, parentID, path, depth); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "folder moved",
+		zap.String("folder_id", folder.ID.String()),
+		zap.String("parent_id", req.ParentID),
+		zap.String("path", path),
+	)
+
+	return s.repo.GetFolder(ctx, tenantID, folder.ID)
+}
+
+// MoveFolderContents empties a folder into another folder or the root, for
+// example before deleting it. Subfolders only move with include_subfolders;
+// the target must not be the folder itself or one of its descendants. The
+// caller needs document:update and update access under the ACLs of both the
+// source and the target folder.
+func (s *Service) MoveFolderContents(ctx context.Context, folderID uuid.UUID, req *models.MoveFolderContentsRequest) (*models.MoveFolderContentsResult, error) {
+	tenantID := getTenantID(ctx)
+
+	// Moving documents takes them out from under the source folder's ACL and
+	// puts them under the target's, so both must allow updates
+	if err := s.requireDocumentPermission(ctx, models.AccessUpdate); err != nil {
+		return nil, err
+	}
+
+	source, err := s.repo.GetFolder(ctx, tenantID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.folderACLDecision(ctx, nullString(source.ID.String()), models.AccessUpdate); err != nil {
+		return nil, err
+	}
+
+	var targetID sql.NullString
+	targetPath := ""
+	targetDepth := 0
+	if req.TargetFolderID != "" {
+		targetUUID, _ := uuid.Parse(req.TargetFolderID)
+		target, err := s.repo.GetFolder(ctx, tenantID, targetUUID)
+		if err != nil {
+			return nil, errors.Validationf("invalid target_folder_id")
+		}
+
+		cyclic, err := s.repo.IsFolderAncestor(ctx, tenantID, source.ID, target.ID)
+		if err != nil {
+			return nil, err
+		}
+		if cyclic {
+			return nil, errors.Validationf("cannot move a folder's contents into itself or one of its subfolders")
+		}
+		if _, err := s.folderACLDecision(ctx, nullString(target.ID.String()), models.AccessUpdate); err != nil {
+			return nil, err
+		}
+
+		targetID = nullString(target.ID.String())
+		targetPath = target.Path
+		targetDepth = folderDepth(target)
+	}
+
+	if req.IncludeSubfolders {
+		height, err := s.repo.GetFolderSubtreeHeight(ctx, tenantID, source.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkFolderDepth(targetDepth + height); err != nil {
+			return nil, err
+		}
+	}
+
+	docIDs, foldersMoved, err := s.repo.MoveFolderContents(ctx, source, targetID, targetPath, targetDepth, req.IncludeSubfolders)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, docID := range docIDs {
+		_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "document", docID.String()))
+	}
+
+	logger.InfoContext(ctx, "folder contents moved",
+		zap.String("folder_id", source.ID.String()),
+		zap.String("target_folder_id", req.TargetFolderID),
+		zap.Int("documents", len(docIDs)),
+		zap.Int64("folders", foldersMoved),
+	)
+
+	return &models.MoveFolderContentsResult{
+		DocumentsMoved: int64(len(docIDs)),
+		FoldersMoved:   foldersMoved,
+	}, nil
+}
+
+// DeleteFolder deletes a folder
+func (s *Service) DeleteFolder(ctx context.Context, folderID uuid.UUID) error {
+	tenantID := getTenantID(ctx)
+
+	// TODO: Check if folder has documents or subfolders
+
+	if err := s.repo.DeleteFolder(ctx, tenantID, folderID); err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "folder deleted", zap.String("folder_id", folderID.String()))
+
+	return nil
+}
+
+// Folder ACL operations
+
+// SetFolderACL replaces the ACL of a folder
+func (s *Service) SetFolderACL(ctx context.Context, folderID uuid.UUID, req *models.SetFolderACLRequest) (*models.FolderACL, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	// Require document:manage
+	allowed, err := s.rbac.CheckPermission(ctx, userID, "document", "manage")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.Forbiddenf("document:manage permission required")
+	}
+
+	if _, err := s.repo.GetFolder(ctx, tenantID, folderID); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(req.Entries))
+	for _, entry := range req.Entries {
+		if seen[entry.UserID] {
+			return nil, errors.Validationf("duplicate acl entry for user %s", entry.UserID)
+		}
+		seen[entry.UserID] = true
+	}
+
+	acl := &models.FolderACL{
+		FolderID:  folderID,
+		Inherit:   req.Inherit,
+		Entries:   req.Entries,
+		UpdatedBy: userID,
+		UpdatedAt: timeutil.Now(),
+	}
+	if acl.Entries == nil {
+		acl.Entries = []models.FolderACLEntry{}
+	}
+
+	if err := s.repo.ReplaceFolderACL(ctx, tenantID, acl); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "folder acl updated",
+		zap.String("folder_id", folderID.String()),
+		zap.Int("entries", len(acl.Entries)),
+		zap.Bool("inherit", acl.Inherit),
+	)
+
+	return acl, nil
+}
+
+// GetFolderACL retrieves the ACL of a folder. Only the folder's owner or a
+// user with document:manage may read it.
+func (s *Service) GetFolderACL(ctx context.Context, folderID uuid.UUID) (*models.FolderACL, error) {
+	tenantID := getTenantID(ctx)
+
+	folder, err := s.repo.GetFolder(ctx, tenantID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if folder.CreatedBy != middleware.GetUserID(ctx) {
+		if err := s.requireDocumentPermission(ctx, "manage"); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetFolderACL(ctx, tenantID, folderID)
+}
+
+// Document ACL operations
+
+// SetDocumentACL replaces the ACL of a document. A document ACL takes
+// precedence over folder ACLs and RBAC for that document.
+func (s *Service) SetDocumentACL(ctx context.Context, docID uuid.UUID, req *models.SetDocumentACLRequest) (*models.DocumentACL, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	// Require document:manage
+	if err := s.requireDocumentPermission(ctx, "manage"); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.GetDocument(ctx, tenantID, docID, false); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(req.Entries))
+	for _, entry := range req.Entries {
+		if seen[entry.UserID] {
+			return nil, errors.Validationf("duplicate acl entry for user %s", entry.UserID)
+		}
+		seen[entry.UserID] = true
+	}
+
+	acl := &models.DocumentACL{
+		DocumentID: docID,
+		Entries:    req.Entries,
+		UpdatedBy:  userID,
+		UpdatedAt:  timeutil.Now(),
+	}
+	if acl.Entries == nil {
+		acl.Entries = []models.FolderACLEntry{}
+	}
+
+	if err := s.repo.ReplaceDocumentACL(ctx, tenantID, acl); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "document acl updated",
+		zap.String("document_id", docID.String()),
+		zap.Int("entries", len(acl.Entries)),
+	)
+
+	return acl, nil
+}
+
+// GetDocumentACL retrieves the ACL of a document
+func (s *Service) GetDocumentACL(ctx context.Context, docID uuid.UUID) (*models.DocumentACL, error) {
+	tenantID := getTenantID(ctx)
+
+	if _, err := s.repo.GetDocument(ctx, tenantID, docID, false); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetDocumentACL(ctx, tenantID, docID)
+}
+
+// authorizeDocument checks whether the current user may perform an action on a
+// document. Precedence is: the document's own ACL, then the ACL of its
+// folder, then the nearest ancestor ACL with inherit set, then the
+// tenant-wide RBAC permission. The first ACL that applies decides, even when
+// it has no entry for the user.
+func (s *Service) authorizeDocument(ctx context.Context, doc *models.Document, action string) error {
+	grants, err := s.repo.GetDocumentACLGrants(ctx, getTenantID(ctx), []uuid.UUID{doc.ID}, middleware.GetUserID(ctx))
+	if err != nil {
+		return err
+	}
+	if actions, ok := grants[doc.ID]; ok {
+		if (&models.FolderACLGrant{Actions: actions}).Allows(action) {
+			return nil
+		}
+		return errors.Forbiddenf("document access denied for document:%s", action)
+	}
+
+	return s.authorizeInFolder(ctx, doc.FolderID, action)
+}
+
+// authorizeInFolder checks an action on a document in folderID that has no
+// ACL of its own: the folder ACLs decide when one applies, else RBAC
+func (s *Service) authorizeInFolder(ctx context.Context, folderID sql.NullString, action string) error {
+	decided, err := s.folderACLDecision(ctx, folderID, action)
+	if err != nil || decided {
+		return err
+	}
+
+	return s.requireDocumentPermission(ctx, action)
+}
+
+// accessChecker decides one action for a batch of documents with one document
+// ACL query and at most one folder check per folder
+type accessChecker struct {
+	s              *Service
+	action         string
+	documentGrants map[uuid.UUID][]string
+	folders        map[string]bool
+}
+
+// newAccessChecker loads the document ACLs of ids for the current user
+func (s *Service) newAccessChecker(ctx context.Context, ids []uuid.UUID, action string) (*accessChecker, error) {
+	grants, err := s.repo.GetDocumentACLGrants(ctx, getTenantID(ctx), ids, middleware.GetUserID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &accessChecker{s: s, action: action, documentGrants: grants, folders: make(map[string]bool)}, nil
+}
+
+// allowed reports whether the current user may perform the action on the document
+func (c *accessChecker) allowed(ctx context.Context, docID uuid.UUID, folderID sql.NullString) (bool, error) {
+	if actions, ok := c.documentGrants[docID]; ok {
+		return (&models.FolderACLGrant{Actions: actions}).Allows(c.action), nil
+	}
+
+	allowed, checked := c.folders[folderID.String]
+	if !checked {
+		err := c.s.authorizeInFolder(ctx, folderID, c.action)
+		if appErr := errors.FromError(err); appErr != nil && appErr.Code != errors.ErrCodeForbidden {
+			return false, err
+		}
+		allowed = err == nil
+		c.folders[folderID.String] = allowed
+	}
+
+	return allowed, nil
+}
+
+// documentAccessFilter builds the listing filter matching authorizeDocument
+// for the current user
+func (s *Service) documentAccessFilter(ctx context.Context, action string) (*models.DocumentAccessFilter, error) {
+	userID := middleware.GetUserID(ctx)
+
+	rbacAllowed, err := s.rbac.CheckPermission(ctx, userID, "document", action)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDepth := s.maxFolderDepth
+	if maxDepth <= 0 {
+		maxDepth = maxFolderTreeDepth
+	}
+	governed, allowed, err := s.repo.GetFolderAccess(ctx, getTenantID(ctx), userID, action, maxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DocumentAccessFilter{
+		UserID:          userID,
+		Action:          action,
+		RBACAllowed:     rbacAllowed,
+		GovernedFolders: governed,
+		AllowedFolders:  allowed,
+	}, nil
+}
+
+// folderACLDecision applies the folder ACLs that govern documents in folderID
+// (invalid for the root, which has none). It reports whether an ACL decided,
+// returning a forbidden error when that ACL denies the action.
+func (s *Service) folderACLDecision(ctx context.Context, folderID sql.NullString, action string) (bool, error) {
+	if !folderID.Valid {
+		return false, nil
+	}
+	id, err := uuid.Parse(folderID.String)
+	if err != nil {
+		return false, nil
+	}
+
+	grants, err := s.repo.GetFolderACLGrants(ctx, getTenantID(ctx), id, middleware.GetUserID(ctx))
+	if err != nil {
+		return false, err
+	}
+	for _, grant := range grants {
+		if grant.Depth > 0 && !grant.Inherit {
+			continue
+		}
+		if grant.Allows(action) {
+			return true, nil
+		}
+		return true, errors.Forbiddenf("folder access denied for document:%s", action)
+	}
+
+	return false, nil
+}
+
+// requireDocumentPermission checks the tenant-wide RBAC permission document:action
+func (s *Service) requireDocumentPermission(ctx context.Context, action string) error {
+	allowed, err := s.rbac.CheckPermission(ctx, middleware.GetUserID(ctx), "document", action)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.Forbiddenf("document:%s permission required", action)
+	}
+
+	return nil
+}
+
+// Tag operations
+
+// CreateTag creates a new tag
+func (s *Service) CreateTag(ctx context.Context, req *models.CreateTagRequest) (*models.Tag, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	name, err := validator.CleanName("name", req.Name, models.MaxTagNameLength)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &models.Tag{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Name:      name,
+		Color:     req.Color,
+		CreatedBy: userID,
+		CreatedAt: timeutil.Now(),
+	}
+
+	if err := s.repo.CreateTag(ctx, tag); err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "tag created", zap.String("tag", tag.Name))
+
+	return tag, nil
+}
+
+// ListTags retrieves all tags
+func (s *Service) ListTags(ctx context.Context) ([]models.Tag, error) {
+	tenantID := getTenantID(ctx)
+
+	tags, err := s.repo.ListTags(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// SuggestTags returns existing tags similar to query, best match first, so a
+// near-duplicate ("invoices" for "invoice", or a typo) can be reused instead
+// of created. A tenant's tag vocabulary is small, so matching runs in Go over
+// the full list and needs no database extension.
+func (s *Service) SuggestTags(ctx context.Context, query string, limit int) (*models.TagSuggestionsResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	query = validator.SanitizeName(query)
+	if query == "" {
+		return nil, errors.Validationf("q is required")
+	}
+	if limit < 1 || limit > maxTagSuggestions {
+		limit = defaultTagSuggestions
+	}
+
+	tags, err := s.repo.ListTags(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.TagSuggestionsResponse{
+		Query:       query,
+		Suggestions: []models.TagSuggestion{},
+	}
+	for _, tag := range tags {
+		score := tagSimilarity(query, tag.Name)
+		if score < minTagSimilarity {
+			continue
+		}
+		if score == 1 {
+			result.ExactMatch = true
+		}
+		result.Suggestions = append(result.Suggestions, models.TagSuggestion{Tag: tag, Score: score})
+	}
+
+	sort.SliceStable(result.Suggestions, func(i, j int) bool {
+		a, b := result.Suggestions[i], result.Suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.UsageCount > b.UsageCount
+	})
+	if len(result.Suggestions) > limit {
+		result.Suggestions = result.Suggestions[:limit]
+	}
+
+	return result, nil
+}
+
+// Category operations
+
+// CreateCategory creates a new category
+func (s *Service) CreateCategory(ctx context.Context, req *models.CreateCategoryRequest) (*models.Category, error) {
+	tenantID := getTenantID(ctx)
+
+	name, err := validator.CleanName("name", req.Name, models.MaxCategoryNameLength)
+	if err != nil {
+		return nil, err
+	}
 
 	category := &models.Category{
 		ID:          uuid.New(),
 		TenantID:    tenantID,
-		Name:        req.Name,
+		Name:        name,
 		Description: req.Description,
 		Color:       req.Color,
 		Icon:        req.Icon,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   timeutil.Now(),
+		UpdatedAt:   timeutil.Now(),
 	}
 
 	if err := s.repo.CreateCategory(ctx, category); err != nil {
@@ -385,6 +2047,171 @@ func (s *Service) ListCategories(ctx context.Context) ([]models.Category, error)
 	return categories, nil
 }
 
+// AssignCategory puts documents into a category, moving them out of whatever
+// category they were in. Documents already in the category succeed
+// unchanged; missing ones are reported individually.
+func (s *Service) AssignCategory(ctx context.Context, categoryID uuid.UUID, req *models.CategoryAssignRequest) (*models.CategoryAssignResponse, error) {
+	return s.setDocumentsCategory(ctx, categoryID, req, true)
+}
+
+// UnassignCategory clears the category of documents that are in it;
+// documents in another category, or none, are reported individually
+func (s *Service) UnassignCategory(ctx context.Context, categoryID uuid.UUID, req *models.CategoryAssignRequest) (*models.CategoryAssignResponse, error) {
+	return s.setDocumentsCategory(ctx, categoryID, req, false)
+}
+
+func (s *Service) setDocumentsCategory(ctx context.Context, categoryID uuid.UUID, req *models.CategoryAssignRequest, assign bool) (*models.CategoryAssignResponse, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	// Require document:update
+	allowed, err := s.rbac.CheckPermission(ctx, userID, "document", "update")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.Forbiddenf("document:update permission required")
+	}
+
+	if _, err := s.repo.GetCategory(ctx, tenantID, categoryID); err != nil {
+		return nil, err
+	}
+
+	docIDs := make([]uuid.UUID, 0, len(req.DocumentIDs))
+	for _, idStr := range req.DocumentIDs {
+		docID, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid document_id: %s", idStr)
+		}
+		docIDs = append(docIDs, docID)
+	}
+
+	category := nullString(categoryID.String())
+	target, from := category, sql.NullString{}
+	if !assign {
+		target, from = sql.NullString{}, category
+	}
+
+	previous, err := s.repo.SetDocumentsCategory(ctx, tenantID, docIDs, target, from)
+	if err != nil {
+		return nil, err
+	}
+
+	response := bulk.NewResponse[models.CategoryAssignResult](len(docIDs))
+	for _, docID := range docIDs {
+		result := models.CategoryAssignResult{Result: bulk.Result{ID: docID.String()}}
+
+		current, found := previous[docID]
+		switch {
+		case !found:
+			result.Error = "document not found"
+		case !assign && current != category:
+			result.PreviousCategoryID = current.String
+			result.Error = "document is not in this category"
+		default:
+			result.PreviousCategoryID = current.String
+			result.Success = true
+
+			// Invalidate cache
+			cacheKey := cache.TenantKey(tenantID.String(), "document", docID.String())
+			_ = s.cache.Delete(ctx, cacheKey)
+		}
+
+		response.Add(result, result.Success)
+	}
+
+	logger.InfoContext(ctx, "document categories updated",
+		zap.String("category_id", categoryID.String()),
+		zap.Bool("assign", assign),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
+// ReindexSearch rebuilds the search vectors of the tenant's documents in ID
+// order, one batch per statement. A run stops after MaxBatches batches or the
+// time budget and returns a cursor to continue from; rerunning from any
+// cursor, or from the start, is safe.
+func (s *Service) ReindexSearch(ctx context.Context, req *models.ReindexSearchRequest) (*models.ReindexSearchResponse, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return nil, errors.Validationf("tenant is required")
+	}
+
+	cursor := uuid.Nil
+	if req.Cursor != "" {
+		parsed, err := uuid.Parse(req.Cursor)
+		if err != nil {
+			return nil, errors.Validationf("invalid cursor")
+		}
+		cursor = parsed
+	}
+
+	batchSize := req.BatchSize
+	if batchSize < 1 {
+		batchSize = defaultReindexBatchSize
+	}
+	if batchSize > maxReindexBatchSize {
+		batchSize = maxReindexBatchSize
+	}
+	maxBatches := req.MaxBatches
+	if maxBatches < 1 {
+		maxBatches = defaultReindexMaxBatches
+	}
+
+	result := &models.ReindexSearchResponse{}
+	deadline := time.Now().Add(reindexTimeBudget)
+	for result.Batches < maxBatches && time.Now().Before(deadline) {
+		count, last, err := s.repo.ReindexSearchVectors(ctx, tenantID, cursor, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		result.Processed += count
+		result.Batches++
+		cursor = last
+
+		if count < batchSize {
+			result.Done = true
+			break
+		}
+	}
+	if !result.Done {
+		result.NextCursor = cursor.String()
+	}
+
+	logger.InfoContext(ctx, "search reindex run finished",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int("processed", result.Processed),
+		zap.Int("batches", result.Batches),
+		zap.Bool("done", result.Done),
+	)
+
+	return result, nil
+}
+
+// PurgeTenant deletes all documents, folders, tags and categories of the tenant in ctx (internal use,
+// called by tenant deletion); it is safe to call again after a partial run
+func (s *Service) PurgeTenant(ctx context.Context) (int64, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return 0, errors.Validationf("tenant is required")
+	}
+
+	deleted, err := s.repo.PurgeTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.InfoContext(ctx, "tenant documents purged",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int64("deleted", deleted),
+	)
+
+	return deleted, nil
+}
+
 // Helper functions
 
 func getTenantID(ctx context.Context) uuid.UUID {
@@ -393,11 +2220,88 @@ func getTenantID(ctx context.Context) uuid.UUID {
 	return tenantID
 }
 
-func sanitizeFolderName(name string) string {
-	// Remove any path separators and special characters
-	name = strings.ReplaceAll(name, "/", "-")
-	name = strings.ReplaceAll(name, "\\", "-")
-	return strings.TrimSpace(name)
+// nullString converts an empty string to SQL NULL
+// checkOCRLanguage normalizes an OCR language hint; empty means none
+func checkOCRLanguage(language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return "", nil
+	}
+	for _, supported := range models.OCRLanguages {
+		if language == supported {
+			return language, nil
+		}
+	}
+	return "", errors.Validationf("unsupported OCR language '%s'", language).
+		WithField("ocr_language", "must be one of "+strings.Join(models.OCRLanguages, ", "))
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// folderDepth returns a folder's level, deriving it from the path for rows
+// created before depth was stored
+func folderDepth(folder *models.Folder) int {
+	if folder.Depth > 0 {
+		return folder.Depth
+	}
+	return strings.Count(folder.Path, "/")
+}
+
+// checkFolderDepth rejects folder levels beyond the configured maximum
+func (s *Service) checkFolderDepth(depth int) error {
+	if s.maxFolderDepth > 0 && depth > s.maxFolderDepth {
+		return errors.Validationf("folders cannot be nested more than %d levels deep", s.maxFolderDepth)
+	}
+	return nil
+}
+
+// tagSimilarity scores how close a tag name is to a query, from 0 to 1. Exact
+// matches ignoring case score 1; otherwise the score is the normalized edit
+// distance, raised for prefix matches so "inv" still ranks "invoice".
+func tagSimilarity(query, name string) float64 {
+	a := []rune(strings.ToLower(query))
+	b := []rune(strings.ToLower(name))
+	if string(a) == string(b) {
+		return 1
+	}
+
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	score := 1 - float64(levenshtein(a, b))/float64(longest)
+
+	if strings.HasPrefix(string(b), string(a)) || strings.HasPrefix(string(a), string(b)) {
+		score = math.Max(score, tagPrefixScore)
+	}
+
+	// Keep non-exact matches strictly below an exact one
+	return math.Round(math.Min(score, 0.99)*100) / 100
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
 }
 
 // FileInfo represents uploaded file information
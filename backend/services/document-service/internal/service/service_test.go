@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database/dbtest"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/models"
+	"github.com/SidahmedSeg/document-manager/backend/services/document-service/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeRBAC is an rbac service that grants a fixed set of document actions
+// and records every permission it was asked about
+type fakeRBAC struct {
+	mu      sync.Mutex
+	allowed map[string]bool
+	checked []string
+}
+
+func (f *fakeRBAC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Resource string `json:"resource"`
+		Action   string `json:"action"`
+	}
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+	permission := req.Resource + ":" + req.Action
+
+	f.mu.Lock()
+	f.checked = append(f.checked, permission)
+	allowed := f.allowed[permission]
+	f.mu.Unlock()
+
+	response.Success(w, map[string]bool{"allowed": allowed})
+}
+
+func (f *fakeRBAC) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.checked...)
+}
+
+// newACLTestService returns a service on a scripted database whose rbac
+// client calls a fake granting the given permissions
+func newACLTestService(t *testing.T, permissions ...string) (*Service, *dbtest.Mock, *fakeRBAC) {
+	t.Helper()
+
+	rbac := &fakeRBAC{allowed: make(map[string]bool)}
+	for _, p := range permissions {
+		rbac.allowed[p] = true
+	}
+	server := httptest.NewServer(rbac)
+	t.Cleanup(server.Close)
+
+	db, mock := dbtest.New(t)
+	s := NewService(
+		repository.NewRepository(db, zap.NewNop()),
+		nil, nil,
+		client.NewRBACClient(client.New("rbac-service", server.URL, zap.NewNop())),
+		nil,
+		config.DocumentsConfig{},
+		zap.NewNop(),
+	)
+
+	return s, mock, rbac
+}
+
+// userContext returns the context a request from userID in tenantID carries
+// once ExtractAuthHeaders has run
+func userContext(t *testing.T, userID string, tenantID uuid.UUID) context.Context {
+	t.Helper()
+
+	var ctx context.Context
+	handler := middleware.ExtractAuthHeaders(&logger.Logger{Logger: zap.NewNop()})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ctx = r.Context() }),
+	)
+	req := httptest.NewRequest(http.MethodGet, "/api/documents", nil)
+	req.Header.Set(middleware.HeaderUserID, userID)
+	req.Header.Set(middleware.HeaderTenantID, tenantID.String())
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if ctx == nil {
+		t.Fatal("ExtractAuthHeaders rejected the test request")
+	}
+
+	return ctx
+}
+
+var (
+	documentGrantColumns = []string{"document_id", "actions"}
+	folderGrantColumns   = []string{"folder_id", "depth", "inherit", "actions"}
+)
+
+func TestAuthorizeDocument(t *testing.T) {
+	tenantID, docID := uuid.New(), uuid.New()
+	folderID, parentID, rootID := uuid.New(), uuid.New(), uuid.New()
+	inFolder := sql.NullString{String: folderID.String(), Valid: true}
+
+	folderGrant := func(id uuid.UUID, depth int64, inherit bool, actions string) []driver.Value {
+		return []driver.Value{id.String(), depth, inherit, actions}
+	}
+
+	tests := []struct {
+		name         string
+		folderID     sql.NullString
+		documentACL  [][]driver.Value // nil when the document has no ACL
+		folderACLs   [][]driver.Value // nearest first; nil skips the folder query
+		rbac         []string
+		wantAllowed  bool
+		wantRBACCall bool
+	}{
+		{
+			name:        "document acl grants the action",
+			folderID:    inFolder,
+			documentACL: [][]driver.Value{{docID.String(), "view,edit"}},
+			wantAllowed: true,
+		},
+		{
+			name:        "document acl without the user overrides rbac",
+			folderID:    inFolder,
+			documentACL: [][]driver.Value{{docID.String(), ""}},
+			rbac:        []string{"document:view"},
+		},
+		{
+			name:        "document acl overrides a granting folder acl",
+			folderID:    inFolder,
+			documentACL: [][]driver.Value{{docID.String(), "edit"}},
+		},
+		{
+			name:        "own folder acl grants the action",
+			folderID:    inFolder,
+			folderACLs:  [][]driver.Value{folderGrant(folderID, 0, false, "view")},
+			wantAllowed: true,
+		},
+		{
+			name:       "own folder acl without the user overrides rbac",
+			folderID:   inFolder,
+			folderACLs: [][]driver.Value{folderGrant(folderID, 0, false, "")},
+			rbac:       []string{"document:view"},
+		},
+		{
+			name:        "nearest inherited ancestor acl grants the action",
+			folderID:    inFolder,
+			folderACLs:  [][]driver.Value{folderGrant(parentID, 1, true, "view"), folderGrant(rootID, 2, true, "")},
+			wantAllowed: true,
+		},
+		{
+			name:       "nearest inherited ancestor acl denies before a farther grant",
+			folderID:   inFolder,
+			folderACLs: [][]driver.Value{folderGrant(parentID, 1, true, "edit"), folderGrant(rootID, 2, true, "view")},
+		},
+		{
+			name:         "ancestor acl without inherit falls back to rbac",
+			folderID:     inFolder,
+			folderACLs:   [][]driver.Value{folderGrant(parentID, 1, false, "")},
+			rbac:         []string{"document:view"},
+			wantAllowed:  true,
+			wantRBACCall: true,
+		},
+		{
+			name:         "no acl falls back to rbac grant",
+			folderID:     inFolder,
+			folderACLs:   [][]driver.Value{},
+			rbac:         []string{"document:view"},
+			wantAllowed:  true,
+			wantRBACCall: true,
+		},
+		{
+			name:         "no acl falls back to rbac denial",
+			folderID:     inFolder,
+			folderACLs:   [][]driver.Value{},
+			wantRBACCall: true,
+		},
+		{
+			name:         "root document uses rbac",
+			rbac:         []string{"document:view"},
+			wantAllowed:  true,
+			wantRBACCall: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, rbac := newACLTestService(t, tt.rbac...)
+			ctx := userContext(t, "user-1", tenantID)
+
+			mock.ExpectQuery("FROM document_acls").
+				WithArgs(tenantID.String(), dbtest.Any, "user-1").
+				WillReturnRows(documentGrantColumns, tt.documentACL...)
+			if tt.folderACLs != nil {
+				mock.ExpectQuery("WITH RECURSIVE ancestry").
+					WithArgs(folderID.String(), tenantID.String(), "user-1").
+					WillReturnRows(folderGrantColumns, tt.folderACLs...)
+			}
+
+			doc := &models.Document{ID: docID, TenantID: tenantID, FolderID: tt.folderID}
+			err := s.authorizeDocument(ctx, doc, "view")
+			if tt.wantAllowed {
+				if err != nil {
+					t.Errorf("authorizeDocument() error = %v, want allowed", err)
+				}
+			} else if appErr := errors.FromError(err); appErr == nil || appErr.Code != errors.ErrCodeForbidden {
+				t.Errorf("authorizeDocument() error = %v, want forbidden", err)
+			}
+
+			if called := len(rbac.calls()) > 0; called != tt.wantRBACCall {
+				t.Errorf("rbac checked = %v, want %v", called, tt.wantRBACCall)
+			}
+		})
+	}
+}
+
+func TestGetFolderACL(t *testing.T) {
+	tenantID, folderID := uuid.New(), uuid.New()
+	now := time.Now().UTC()
+	folderColumns := []string{
+		"id", "tenant_id", "parent_id", "name", "path", "depth",
+		"description", "color", "icon", "created_by", "created_at", "updated_at",
+	}
+	folderRow := []driver.Value{
+		folderID.String(), tenantID.String(), nil, "Contracts", "Contracts", int64(1),
+		nil, nil, nil, "owner-1", now, now,
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		rbac        []string
+		wantAllowed bool
+		wantChecked []string
+	}{
+		{"owner reads without document:manage", "owner-1", nil, true, nil},
+		{"manager reads another user's folder", "user-2", []string{"document:manage"}, true, []string{"document:manage"}},
+		{"other user is forbidden", "user-2", []string{"document:view"}, false, []string{"document:manage"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, rbac := newACLTestService(t, tt.rbac...)
+			ctx := userContext(t, tt.userID, tenantID)
+
+			mock.ExpectQuery("FROM folders").
+				WithArgs(folderID.String(), tenantID.String()).
+				WillReturnRows(folderColumns, folderRow)
+			if tt.wantAllowed {
+				mock.ExpectQuery("FROM folder_acls").
+					WithArgs(tenantID.String(), folderID.String()).
+					WillReturnRows([]string{"user_id", "actions", "inherit", "created_by", "created_at"},
+						[]driver.Value{"user-3", "{view}", true, "owner-1", now})
+			}
+
+			acl, err := s.GetFolderACL(ctx, folderID)
+			if tt.wantAllowed {
+				if err != nil {
+					t.Fatalf("GetFolderACL() error = %v", err)
+				}
+				if len(acl.Entries) != 1 || acl.Entries[0].UserID != "user-3" {
+					t.Errorf("GetFolderACL() entries = %+v, want user-3", acl.Entries)
+				}
+			} else if appErr := errors.FromError(err); appErr == nil || appErr.Code != errors.ErrCodeForbidden {
+				t.Errorf("GetFolderACL() error = %v, want forbidden", err)
+			}
+
+			checked := rbac.calls()
+			if len(checked) != len(tt.wantChecked) || (len(checked) > 0 && checked[0] != tt.wantChecked[0]) {
+				t.Errorf("rbac checked %v, want %v", checked, tt.wantChecked)
+			}
+		})
+	}
+}
+
+func TestReassignDocumentsStopsBeforeTransfer(t *testing.T) {
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name      string
+		rbac      []string
+		members   map[string]bool
+		wantErr   errors.ErrorCode
+		wantCalls int // membership checks made
+	}{
+		{"rbac denial", []string{"document:view"}, map[string]bool{"user-1": true, "user-2": true}, errors.ErrCodeForbidden, 0},
+		{"successor outside the tenant", []string{"document:manage"}, map[string]bool{"user-1": true}, errors.ErrCodeValidation, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The script expects no statements, so any transaction fails the test
+			s, _, _ := newACLTestService(t, tt.rbac...)
+
+			var mu sync.Mutex
+			calls := 0
+			tenants := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				userID := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/users/")+len("/users/"):], "/membership")
+				response.Success(w, client.MembershipResponse{IsMember: tt.members[userID]})
+			}))
+			t.Cleanup(tenants.Close)
+			s.tenants = client.NewTenantClient(client.New("tenant-service", tenants.URL, zap.NewNop()))
+
+			req := &models.ReassignDocumentsRequest{FromUserID: "user-1", ToUserID: "user-2"}
+			_, err := s.ReassignDocuments(userContext(t, "admin-1", tenantID), req)
+			if appErr := errors.FromError(err); appErr == nil || appErr.Code != tt.wantErr {
+				t.Errorf("ReassignDocuments() error = %v, want %s", err, tt.wantErr)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if calls != tt.wantCalls {
+				t.Errorf("membership checked %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTagSimilarity(t *testing.T) {
+	tests := []struct {
+		query string
+		name  string
+		want  float64
+	}{
+		{"invoice", "invoice", 1},
+		{"Invoice", "INVOICE", 1},
+		{"invoice", "invoices", 0.88},
+		{"invoice", "invoce", 0.86},
+		{"contract", "contact", 0.88},
+		{"inv", "invoice", 0.75},            // prefix floor
+		{"invoices-2024", "invoices", 0.75}, // contained in the query
+		{"caf\u00e9", "cafe", 0.75},         // runes, not bytes
+		{"invoice", "receipt", 0.14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.name, func(t *testing.T) {
+			if got := tagSimilarity(tt.query, tt.name); got != tt.want {
+				t.Errorf("tagSimilarity(%q, %q) = %v, want %v", tt.query, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"caf\u00e9", "cafe", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestTags(t *testing.T) {
+	tenantID := uuid.New()
+	now := time.Now().UTC()
+	tagColumns := []string{"id", "tenant_id", "name", "color", "usage_count", "created_by", "created_at"}
+	tag := func(name string, usage int64) []driver.Value {
+		return []driver.Value{uuid.NewString(), tenantID.String(), name, "#000000", usage, "user-1", now}
+	}
+	tags := [][]driver.Value{
+		tag("contracts", 4),
+		tag("invoce", 2),
+		tag("invoice", 5),
+		tag("invoices", 9),
+		tag("invoicez", 20),
+		tag("receipts", 7),
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		want      []string
+		wantExact bool
+	}{
+		{"best match first, ties by usage", "invoice", 10, []string{"invoice", "invoicez", "invoices", "invoce"}, true},
+		{"query is sanitized before matching", "  Invoice\u200b ", 10, []string{"invoice", "invoicez", "invoices", "invoce"}, true},
+		{"limit keeps the best matches", "invoice", 2, []string{"invoice", "invoicez"}, true},
+		{"near duplicates without an exact match", "invoic", 10, []string{"invoice", "invoicez", "invoices", "invoce"}, false},
+		{"no similar tags", "passport", 10, []string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, _ := newACLTestService(t)
+			mock.ExpectQuery("FROM tags").WithArgs(tenantID.String()).WillReturnRows(tagColumns, tags...)
+
+			got, err := s.SuggestTags(userContext(t, "user-1", tenantID), tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("SuggestTags() error = %v", err)
+			}
+			names := make([]string, 0, len(got.Suggestions))
+			for _, suggestion := range got.Suggestions {
+				names = append(names, suggestion.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("SuggestTags(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+			if got.ExactMatch != tt.wantExact {
+				t.Errorf("ExactMatch = %v, want %v", got.ExactMatch, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestSuggestTagsRequiresQuery(t *testing.T) {
+	// No tags are loaded for a blank query
+	s, _, _ := newACLTestService(t)
+
+	_, err := s.SuggestTags(userContext(t, "user-1", uuid.New()), " \u200b ", 10)
+	if appErr := errors.FromError(err); appErr == nil || appErr.Code != errors.ErrCodeValidation {
+		t.Errorf("SuggestTags() error = %v, want a validation error", err)
+	}
+}
@@ -10,16 +10,24 @@ import (
 	"time"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/retention"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/handler"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/repository"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/service"
 	"go.uber.org/zap"
 )
 
+// defaultPort is used when SERVER_PORT is not set
+const defaultPort = 10006
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -27,11 +35,15 @@ func main() {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// Override port for quota service
-	cfg.Server.Port = 10006
+	// Listen on defaultPort unless SERVER_PORT is set
+	if err := cfg.Server.UseDefaultPort(defaultPort); err != nil {
+		panic(fmt.Sprintf("invalid server config: %v", err))
+	}
 
 	// Initialize logger
-	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format)
+	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format,
+		logger.WithSampling(cfg.Logger.SamplingInitial, cfg.Logger.SamplingThereafter),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
@@ -42,40 +54,51 @@ func main() {
 		zap.String("environment", cfg.Environment),
 		zap.String("version", cfg.AppVersion),
 		zap.Int("port", cfg.Server.Port),
+		zap.Bool("log_sampling", log.SamplingEnabled()),
 	)
+	log.Info("effective configuration", zap.Any("config", cfg.Redacted()))
 
 	// Connect to database
-	db, err := database.NewPostgresDB(cfg.Database, log.Logger)
+	db, err := database.ConnectPostgresDB(cfg.Database, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
-
-	// Verify database health
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.HealthCheck(ctx); err != nil {
-		log.Fatal("database health check failed", zap.Error(err))
-	}
 	log.Info("database connection established")
 
 	// Connect to Redis cache
-	cacheClient, err := cache.NewRedisCache(cfg.Redis, log.Logger)
+	cacheClient, err := cache.ConnectRedisCache(cfg.Redis, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to cache", zap.Error(err))
 	}
 	defer cacheClient.Close()
-
-	// Verify cache health
-	if err := cacheClient.HealthCheck(ctx); err != nil {
-		log.Fatal("cache health check failed", zap.Error(err))
-	}
 	log.Info("cache connection established")
 
+	// Initialize internal service clients
+	tenantResolver := client.NewTenantClient(client.New("tenant-service", cfg.Services.TenantServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+
 	// Initialize layers
 	repo := repository.NewRepository(db, log.Logger)
 	svc := service.NewService(repo, cacheClient, log.Logger)
-	h := handler.NewHandler(svc, log.Logger)
+	defer svc.Close()
+
+	// Audit retention: purged on a schedule and on demand
+	purger := retention.New(cfg.Retention, log.Logger, retention.Table{
+		Name: "usage_logs",
+		Purge: func(ctx context.Context, limit int) (int64, error) {
+			return repo.PurgeExpiredUsageLogs(ctx, cfg.Retention.UsageLogs, limit)
+		},
+	})
+	defer purger.Close()
+
+	readiness := health.NewChecker("quota-service", cfg.Health, log.Logger).WithVersion(cfg.AppVersion)
+	h := handler.NewHandler(svc, readiness, log.Logger)
+
+	// Unknown request body fields
+	response.SetStrictJSON(cfg.Server.StrictJSON)
+
+	// Signed service-to-service requests only
+	internalAuth := middleware.InternalAuth(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -84,20 +107,44 @@ func main() {
 	mux.HandleFunc("GET /health", h.HealthCheck)
 	mux.HandleFunc("GET /health/ready", h.ReadyCheck)
 
+	// Effective configuration with secrets masked (internal use, operators)
+	mux.Handle("GET /api/config", internalAuth(http.HandlerFunc(config.NewHandler(cfg).Get)))
+
+	// Published metrics as JSON (internal use, scrapers)
+	mux.Handle("GET /api/metrics", internalAuth(metrics.Handler()))
+
+	// Audit retention purge (internal use, admin trigger)
+	mux.Handle("POST /api/retention/purge", internalAuth(http.HandlerFunc(retention.NewHandler(purger).Purge)))
+
+	// Tenant data purge (internal use, called by tenant deletion)
+	mux.Handle("DELETE /api/quotas/tenant-data", internalAuth(http.HandlerFunc(h.PurgeTenant)))
+
+	// Default quota of a new tenant (internal use, called by tenant creation)
+	mux.Handle("POST /api/quotas/provision", internalAuth(http.HandlerFunc(h.ProvisionQuota)))
+
+	// Usage reconciliation (admin use, signed requests only)
+	mux.Handle("POST /api/quotas/{tenantId}/reconcile", internalAuth(http.HandlerFunc(h.ReconcileUsage)))
+
 	// Public endpoints
 	mux.HandleFunc("GET /api/quotas/plans", h.GetPredefinedPlans)
 
 	// Quota check endpoint (internal use)
-	mux.HandleFunc("POST /api/quotas/check", h.CheckQuota)
+	mux.Handle("POST /api/quotas/check", internalAuth(http.HandlerFunc(h.CheckQuota)))
+	mux.Handle("POST /api/quotas/check-multi", internalAuth(http.HandlerFunc(h.CheckQuotaMulti)))
+	mux.Handle("POST /api/quotas/feature-check", internalAuth(http.HandlerFunc(h.CheckFeature)))
+	mux.Handle("GET /api/quotas/rate-limit", internalAuth(http.HandlerFunc(h.GetRateLimit)))
+	mux.Handle("POST /api/quotas/usage/adjust", internalAuth(http.HandlerFunc(h.AdjustReservation)))
 
 	// Quota endpoints (auth required)
 	mux.HandleFunc("POST /api/quotas", h.CreateQuota)
 	mux.HandleFunc("GET /api/quotas/me", h.GetQuota)
 	mux.HandleFunc("PUT /api/quotas/me", h.UpdateQuota)
+	mux.HandleFunc("PATCH /api/quotas/me", h.PatchQuota)
 
 	// Usage endpoints (auth required)
 	mux.HandleFunc("GET /api/quotas/usage", h.GetUsage)
 	mux.HandleFunc("GET /api/quotas/overview", h.GetOverview)
+	mux.HandleFunc("POST /api/quotas/plan/preview", h.PreviewPlan)
 	mux.HandleFunc("POST /api/quotas/usage/increment", h.IncrementUsage)
 	mux.HandleFunc("POST /api/quotas/usage/decrement", h.DecrementUsage)
 
@@ -106,12 +153,32 @@ func main() {
 	mux.HandleFunc("GET /api/quotas/logs", h.GetUsageLogs)
 
 	// Apply middleware chain
+	middleware.SetSlowRequestThreshold(cfg.Logger.SlowRequestThreshold)
 	var httpHandler http.Handler = mux
+	httpHandler = middleware.Versioning(middleware.APIVersion1)(httpHandler)
 	httpHandler = middleware.RequestID()(httpHandler)
+	httpHandler = middleware.CountAPICalls(cacheClient.IncrementAPICalls, log)(httpHandler)
+	httpHandler = middleware.RateLimit(cfg.RateLimit, cacheClient.RateLimitResolver(func(ctx context.Context) (int, error) {
+		// Resolved locally; other services ask this one
+		limit, err := svc.GetRateLimit(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return limit.RequestsPerMinute, nil
+	}, cfg.RateLimit.CacheTTL), cacheClient.IncrementRateWindow, log)(httpHandler)
+	httpHandler = middleware.ResolveTenant(cfg.Tenancy, tenantResolver.ResolveSlug, tenantResolver.IsMember)(httpHandler)
 	httpHandler = middleware.ExtractAuthHeaders(log)(httpHandler)
+	httpHandler = middleware.VerifyInternalRequest(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)(httpHandler)
+	// CORS runs ahead of auth so browser preflights are answered without credentials
+	httpHandler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:      cfg.CORS.AllowedOrigins,
+		CredentialedOrigins: cfg.CORS.CredentialedOrigins,
+		MaxAge:              cfg.CORS.MaxAge,
+	})(httpHandler)
 	httpHandler = middleware.Logging(log)(httpHandler)
 	httpHandler = middleware.Recovery(log)(httpHandler)
 	httpHandler = middleware.Timeout(30 * time.Second)(httpHandler)
+	httpHandler = middleware.MaxBodySize(cfg.Server.MaxBodySize)(httpHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -1,9 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/humanize"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/models"
@@ -13,23 +15,25 @@ import (
 
 // Handler handles HTTP requests for quota operations
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service   *service.Service
+	readiness *health.Checker
+	logger    *zap.Logger
 }
 
 // NewHandler creates a new quota handler
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
+func NewHandler(svc *service.Service, readiness *health.Checker, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: svc,
-		logger:  logger,
+		service:   svc,
+		readiness: readiness,
+		logger:    logger,
 	}
 }
 
 // CreateQuota handles POST /api/quotas
 func (h *Handler) CreateQuota(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateQuotaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -48,6 +52,29 @@ func (h *Handler) CreateQuota(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, quota)
 }
 
+// ProvisionQuota handles POST /api/quotas/provision
+func (h *Handler) ProvisionQuota(w http.ResponseWriter, r *http.Request) {
+	var req models.ProvisionQuotaRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	quota, err := h.service.ProvisionQuota(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, quota)
+}
+
 // GetQuota handles GET /api/quotas/me
 func (h *Handler) GetQuota(w http.ResponseWriter, r *http.Request) {
 	quota, err := h.service.GetQuota(r.Context())
@@ -62,8 +89,8 @@ func (h *Handler) GetQuota(w http.ResponseWriter, r *http.Request) {
 // UpdateQuota handles PUT /api/quotas/me
 func (h *Handler) UpdateQuota(w http.ResponseWriter, r *http.Request) {
 	var req models.UpdateQuotaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -81,6 +108,28 @@ func (h *Handler) UpdateQuota(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "quota updated successfully"})
 }
 
+// PatchQuota handles PATCH /api/quotas/me
+func (h *Handler) PatchQuota(w http.ResponseWriter, r *http.Request) {
+	var req models.PatchQuotaRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	if err := h.service.PatchQuota(r.Context(), &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]string{"message": "quota updated successfully"})
+}
+
 // GetUsage handles GET /api/quotas/usage
 func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
 	usage, err := h.service.GetUsage(r.Context())
@@ -100,14 +149,40 @@ func (h *Handler) GetOverview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if humanize.Requested(r) {
+		overview.Humanize()
+	}
+
 	response.Success(w, overview)
 }
 
+// PreviewPlan handles POST /api/quotas/plan/preview
+func (h *Handler) PreviewPlan(w http.ResponseWriter, r *http.Request) {
+	var req models.PlanPreviewRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	preview, err := h.service.PreviewPlanChange(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, preview)
+}
+
 // CheckQuota handles POST /api/quotas/check
 func (h *Handler) CheckQuota(w http.ResponseWriter, r *http.Request) {
 	var req models.CheckQuotaRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -126,11 +201,67 @@ func (h *Handler) CheckQuota(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, checkResp)
 }
 
+// CheckQuotaMulti handles POST /api/quotas/check-multi
+func (h *Handler) CheckQuotaMulti(w http.ResponseWriter, r *http.Request) {
+	var req models.MultiCheckQuotaRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	checkResp, err := h.service.CheckQuotaMulti(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, checkResp)
+}
+
+// CheckFeature handles POST /api/quotas/feature-check
+func (h *Handler) CheckFeature(w http.ResponseWriter, r *http.Request) {
+	var req models.FeatureCheckRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	checkResp, err := h.service.CheckFeature(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, checkResp)
+}
+
+// GetRateLimit handles GET /api/quotas/rate-limit
+func (h *Handler) GetRateLimit(w http.ResponseWriter, r *http.Request) {
+	limit, err := h.service.GetRateLimit(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, limit)
+}
+
 // IncrementUsage handles POST /api/quotas/usage/increment
 func (h *Handler) IncrementUsage(w http.ResponseWriter, r *http.Request) {
 	var req models.IncrementUsageRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -148,11 +279,33 @@ func (h *Handler) IncrementUsage(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "usage incremented successfully"})
 }
 
+// AdjustReservation handles POST /api/quotas/usage/adjust
+func (h *Handler) AdjustReservation(w http.ResponseWriter, r *http.Request) {
+	var req models.AdjustReservationRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.AdjustReservation(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
 // DecrementUsage handles POST /api/quotas/usage/decrement
 func (h *Handler) DecrementUsage(w http.ResponseWriter, r *http.Request) {
 	var req models.DecrementUsageRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -224,19 +377,47 @@ func (h *Handler) GetPredefinedPlans(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, plans)
 }
 
+// ReconcileUsage handles POST /api/quotas/{tenantId}/reconcile (admin use)
+func (h *Handler) ReconcileUsage(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(r.PathValue("tenantId"))
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	result, err := h.service.ReconcileUsage(r.Context(), tenantID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// PurgeTenant handles DELETE /api/quotas/tenant-data (internal use)
+func (h *Handler) PurgeTenant(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.service.PurgeTenant(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]int64{"deleted": deleted})
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.Success(w, map[string]string{
-		"status":  "healthy",
-		"service": "quota-service",
-	})
+	response.Success(w, h.readiness.Live())
 }
 
 // ReadyCheck handles GET /health/ready
 func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 	// TODO: Check database and cache connectivity
-	response.Success(w, map[string]string{
-		"status":  "ready",
-		"service": "quota-service",
-	})
+	report := h.readiness.Check(r.Context())
+	if !report.Ready() {
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	response.Success(w, report)
 }
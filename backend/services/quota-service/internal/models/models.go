@@ -2,54 +2,66 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/humanize"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 )
 
 // Quota represents tenant quota limits
 type Quota struct {
-	ID                uuid.UUID      `json:"id" db:"id"`
-	TenantID          uuid.UUID      `json:"tenant_id" db:"tenant_id"`
-	PlanName          string         `json:"plan_name" db:"plan_name"` // free, basic, pro, enterprise
-	MaxStorage        int64          `json:"max_storage" db:"max_storage"` // bytes
-	MaxDocuments      int            `json:"max_documents" db:"max_documents"`
-	MaxUsers          int            `json:"max_users" db:"max_users"`
-	MaxAPICallsPerDay int            `json:"max_api_calls_per_day" db:"max_api_calls_per_day"`
-	MaxFileSize       int64          `json:"max_file_size" db:"max_file_size"` // bytes
-	MaxBandwidth      int64          `json:"max_bandwidth" db:"max_bandwidth"` // bytes per month
-	Features          sql.NullString `json:"features,omitempty" db:"features"` // JSON array of enabled features
-	IsActive          bool           `json:"is_active" db:"is_active"`
-	ValidFrom         time.Time      `json:"valid_from" db:"valid_from"`
-	ValidUntil        sql.NullTime   `json:"valid_until,omitempty" db:"valid_until"`
-	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID         `json:"id" db:"id"`
+	TenantID          uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	PlanName          string            `json:"plan_name" db:"plan_name"`     // free, basic, pro, enterprise
+	MaxStorage        int64             `json:"max_storage" db:"max_storage"` // bytes
+	MaxDocuments      int               `json:"max_documents" db:"max_documents"`
+	MaxUsers          int               `json:"max_users" db:"max_users"`
+	MaxAPICallsPerDay int               `json:"max_api_calls_per_day" db:"max_api_calls_per_day"`
+	MaxFileSize       int64             `json:"max_file_size" db:"max_file_size"` // bytes
+	MaxBandwidth      int64             `json:"max_bandwidth" db:"max_bandwidth"` // bytes per month
+	Features          sql.NullString    `json:"features,omitempty" db:"features"` // JSON array of enabled features
+	IsActive          bool              `json:"is_active" db:"is_active"`
+	ValidFrom         timeutil.Time     `json:"valid_from" db:"valid_from"`
+	ValidUntil        timeutil.NullTime `json:"valid_until,omitempty" db:"valid_until"`
+	CreatedAt         timeutil.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt         timeutil.Time     `json:"updated_at" db:"updated_at"`
+
+	// Formatted sizes, only set when the caller asks for ?humanize=true
+	MaxStorageHuman   string `json:"max_storage_human,omitempty" db:"-"`
+	MaxFileSizeHuman  string `json:"max_file_size_human,omitempty" db:"-"`
+	MaxBandwidthHuman string `json:"max_bandwidth_human,omitempty" db:"-"`
 }
 
 // Usage represents current usage for a tenant
 type Usage struct {
-	ID               uuid.UUID `json:"id" db:"id"`
-	TenantID         uuid.UUID `json:"tenant_id" db:"tenant_id"`
-	StorageUsed      int64     `json:"storage_used" db:"storage_used"` // bytes
-	DocumentCount    int       `json:"document_count" db:"document_count"`
-	UserCount        int       `json:"user_count" db:"user_count"`
-	APICallsToday    int       `json:"api_calls_today" db:"api_calls_today"`
-	BandwidthMonth   int64     `json:"bandwidth_month" db:"bandwidth_month"` // bytes
-	LastAPICall      time.Time `json:"last_api_call" db:"last_api_call"`
-	LastResetDate    time.Time `json:"last_reset_date" db:"last_reset_date"`
-	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ID             uuid.UUID     `json:"id" db:"id"`
+	TenantID       uuid.UUID     `json:"tenant_id" db:"tenant_id"`
+	StorageUsed    int64         `json:"storage_used" db:"storage_used"` // bytes
+	DocumentCount  int           `json:"document_count" db:"document_count"`
+	UserCount      int           `json:"user_count" db:"user_count"`
+	APICallsToday  int           `json:"api_calls_today" db:"api_calls_today"`
+	BandwidthMonth int64         `json:"bandwidth_month" db:"bandwidth_month"` // bytes
+	LastAPICall    timeutil.Time `json:"last_api_call" db:"last_api_call"`
+	LastResetDate  timeutil.Time `json:"last_reset_date" db:"last_reset_date"`
+	UpdatedAt      timeutil.Time `json:"updated_at" db:"updated_at"`
+
+	// Formatted sizes, only set when the caller asks for ?humanize=true
+	StorageUsedHuman    string `json:"storage_used_human,omitempty" db:"-"`
+	BandwidthMonthHuman string `json:"bandwidth_month_human,omitempty" db:"-"`
 }
 
 // UsageLog represents detailed usage logging
 type UsageLog struct {
-	ID         uuid.UUID      `json:"id" db:"id"`
-	TenantID   uuid.UUID      `json:"tenant_id" db:"tenant_id"`
-	UserID     sql.NullString `json:"user_id,omitempty" db:"user_id"`
-	Action     string         `json:"action" db:"action"` // upload, download, api_call, etc.
-	Resource   string         `json:"resource" db:"resource"` // document, storage, api
-	Amount     int64          `json:"amount" db:"amount"` // bytes, count, etc.
-	Metadata   sql.NullString `json:"metadata,omitempty" db:"metadata"` // JSON
-	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	ID        uuid.UUID      `json:"id" db:"id"`
+	TenantID  uuid.UUID      `json:"tenant_id" db:"tenant_id"`
+	UserID    sql.NullString `json:"user_id,omitempty" db:"user_id"`
+	Action    string         `json:"action" db:"action"`               // upload, download, api_call, etc.
+	Resource  string         `json:"resource" db:"resource"`           // document, storage, api
+	Amount    int64          `json:"amount" db:"amount"`               // bytes, count, etc.
+	Metadata  sql.NullString `json:"metadata,omitempty" db:"metadata"` // JSON
+	CreatedAt timeutil.Time  `json:"created_at" db:"created_at"`
 }
 
 // QuotaUsageOverview combines quota and usage information
@@ -63,6 +75,49 @@ type QuotaUsageOverview struct {
 	BandwidthPercent  float64 `json:"bandwidth_percent"`
 	IsStorageExceeded bool    `json:"is_storage_exceeded"`
 	IsLimitReached    bool    `json:"is_limit_reached"`
+
+	// SuggestedPlan is advisory only; null when the current plan suffices
+	SuggestedPlan *PlanSuggestion `json:"suggested_plan"`
+}
+
+// Humanize fills formatted sizes alongside the quota and usage byte counts
+func (o *QuotaUsageOverview) Humanize() {
+	o.Quota.MaxStorageHuman = humanize.Bytes(o.Quota.MaxStorage)
+	o.Quota.MaxFileSizeHuman = humanize.Bytes(o.Quota.MaxFileSize)
+	o.Quota.MaxBandwidthHuman = humanize.Bytes(o.Quota.MaxBandwidth)
+	o.Usage.StorageUsedHuman = humanize.Bytes(o.Usage.StorageUsed)
+	o.Usage.BandwidthMonthHuman = humanize.Bytes(o.Usage.BandwidthMonth)
+}
+
+// PlanSuggestion recommends a plan that accommodates current usage
+type PlanSuggestion struct {
+	Plan        QuotaPlan `json:"plan"`
+	TriggeredBy []string  `json:"triggered_by"` // resources near or over their limit
+}
+
+// PlanPreviewRequest names the plan to compare current usage against
+type PlanPreviewRequest struct {
+	PlanName string `json:"plan_name" validate:"required,oneof=free basic pro enterprise"`
+}
+
+// ResourceFit reports whether a resource's current usage fits a plan limit
+type ResourceFit struct {
+	Resource string `json:"resource"`
+	Used     int64  `json:"used"`
+	Limit    int64  `json:"limit"`
+	Fits     bool   `json:"fits"`
+	Overage  int64  `json:"overage"` // usage above the limit; 0 when it fits
+}
+
+// PlanPreview is the read-only outcome of moving the tenant to another plan:
+// which resources would be over their new limits and by how much, and which
+// enabled features the plan does not include
+type PlanPreview struct {
+	CurrentPlan  string        `json:"current_plan"`
+	TargetPlan   QuotaPlan     `json:"target_plan"`
+	Fits         bool          `json:"fits"` // every resource fits
+	Resources    []ResourceFit `json:"resources"`
+	LostFeatures []string      `json:"lost_features"`
 }
 
 // CreateQuotaRequest represents quota creation request
@@ -75,20 +130,40 @@ type CreateQuotaRequest struct {
 	MaxFileSize       int64    `json:"max_file_size" validate:"required,gt=0"`
 	MaxBandwidth      int64    `json:"max_bandwidth" validate:"required,gt=0"`
 	Features          []string `json:"features,omitempty"`
-	ValidUntil        string   `json:"valid_until,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ValidUntil        string   `json:"valid_until,omitempty" validate:"omitempty,rfc3339"`
 }
 
-// UpdateQuotaRequest represents quota update request
+// ProvisionQuotaRequest asks for the default quota of a newly created tenant
+type ProvisionQuotaRequest struct {
+	PlanName string `json:"plan_name" validate:"required,oneof=free basic pro enterprise"`
+}
+
+// UpdateQuotaRequest represents a full quota replacement (PUT); omitted
+// features and valid_until are cleared
 type UpdateQuotaRequest struct {
-	MaxStorage        *int64   `json:"max_storage,omitempty" validate:"omitempty,gt=0"`
-	MaxDocuments      *int     `json:"max_documents,omitempty" validate:"omitempty,gt=0"`
-	MaxUsers          *int     `json:"max_users,omitempty" validate:"omitempty,gt=0"`
-	MaxAPICallsPerDay *int     `json:"max_api_calls_per_day,omitempty" validate:"omitempty,gt=0"`
-	MaxFileSize       *int64   `json:"max_file_size,omitempty" validate:"omitempty,gt=0"`
-	MaxBandwidth      *int64   `json:"max_bandwidth,omitempty" validate:"omitempty,gt=0"`
+	MaxStorage        int64    `json:"max_storage" validate:"required,gt=0"`
+	MaxDocuments      int      `json:"max_documents" validate:"required,gt=0"`
+	MaxUsers          int      `json:"max_users" validate:"required,gt=0"`
+	MaxAPICallsPerDay int      `json:"max_api_calls_per_day" validate:"required,gt=0"`
+	MaxFileSize       int64    `json:"max_file_size" validate:"required,gt=0"`
+	MaxBandwidth      int64    `json:"max_bandwidth" validate:"required,gt=0"`
 	Features          []string `json:"features,omitempty"`
-	ValidUntil        string   `json:"valid_until,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
-	IsActive          *bool    `json:"is_active,omitempty"`
+	ValidUntil        string   `json:"valid_until,omitempty" validate:"omitempty,rfc3339"`
+	IsActive          *bool    `json:"is_active" validate:"required"`
+}
+
+// PatchQuotaRequest represents a partial quota update (PATCH); nil fields are
+// left unchanged and an empty valid_until clears the expiry
+type PatchQuotaRequest struct {
+	MaxStorage        *int64    `json:"max_storage,omitempty" validate:"omitempty,gt=0"`
+	MaxDocuments      *int      `json:"max_documents,omitempty" validate:"omitempty,gt=0"`
+	MaxUsers          *int      `json:"max_users,omitempty" validate:"omitempty,gt=0"`
+	MaxAPICallsPerDay *int      `json:"max_api_calls_per_day,omitempty" validate:"omitempty,gt=0"`
+	MaxFileSize       *int64    `json:"max_file_size,omitempty" validate:"omitempty,gt=0"`
+	MaxBandwidth      *int64    `json:"max_bandwidth,omitempty" validate:"omitempty,gt=0"`
+	Features          *[]string `json:"features,omitempty"`
+	ValidUntil        *string   `json:"valid_until,omitempty"`
+	IsActive          *bool     `json:"is_active,omitempty"`
 }
 
 // CheckQuotaRequest represents quota check request
@@ -108,6 +183,76 @@ type CheckQuotaResponse struct {
 	Message       string `json:"message,omitempty"`
 }
 
+// MultiCheckQuotaRequest checks several resources as one operation, e.g. an
+// upload consuming both storage and a document slot. With Reserve set the
+// amounts are also added to usage, all or nothing.
+type MultiCheckQuotaRequest struct {
+	Resources []CheckQuotaRequest `json:"resources" validate:"required,min=1,max=6,unique=Resource,dive"`
+	Reserve   bool                `json:"reserve"`
+}
+
+// MultiCheckQuotaResponse reports the overall decision and each resource's detail
+type MultiCheckQuotaResponse struct {
+	Allowed   bool                 `json:"allowed"`
+	Reserved  bool                 `json:"reserved"`
+	Resources []CheckQuotaResponse `json:"resources"`
+}
+
+// FeatureCheckRequest represents feature availability check request
+type FeatureCheckRequest struct {
+	Feature string `json:"feature" validate:"required,max=50"`
+}
+
+// FeatureCheckResponse represents feature availability check response
+type FeatureCheckResponse struct {
+	Allowed  bool   `json:"allowed"`
+	Feature  string `json:"feature"`
+	PlanName string `json:"plan_name"`
+	Message  string `json:"message,omitempty"`
+}
+
+// FeatureSet represents the features enabled for a tenant
+type FeatureSet struct {
+	PlanName string   `json:"plan_name"`
+	Features []string `json:"features"`
+}
+
+// Has reports whether the feature is enabled
+func (f *FeatureSet) Has(feature string) bool {
+	for _, enabled := range f.Features {
+		if enabled == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureSet returns the features enabled by the quota: its own feature list
+// when set, else the features of its predefined plan. An inactive quota
+// enables none.
+func (q *Quota) FeatureSet() (*FeatureSet, error) {
+	features := &FeatureSet{PlanName: q.PlanName, Features: []string{}}
+	if !q.IsActive {
+		return features, nil
+	}
+
+	if q.Features.Valid && q.Features.String != "" {
+		if err := json.Unmarshal([]byte(q.Features.String), &features.Features); err != nil {
+			return nil, err
+		}
+	} else if plan, ok := GetPredefinedPlan(q.PlanName); ok {
+		features.Features = plan.Features
+	}
+
+	return features, nil
+}
+
+// RateLimit is a tenant's API rate limit, derived from its plan
+type RateLimit struct {
+	PlanName          string `json:"plan_name"`
+	RequestsPerMinute int    `json:"requests_per_minute"`
+}
+
 // IncrementUsageRequest represents usage increment request
 type IncrementUsageRequest struct {
 	Resource string `json:"resource" validate:"required,oneof=storage documents users api_calls bandwidth"`
@@ -123,10 +268,39 @@ type DecrementUsageRequest struct {
 	UserID   string `json:"user_id,omitempty"`
 }
 
+// AdjustReservationRequest settles an earlier reservation of Reserved at the
+// final Actual amount, e.g. a presigned upload whose object turned out smaller
+// than declared, or share download bandwidth released when the download failed
+type AdjustReservationRequest struct {
+	Resource string `json:"resource" validate:"required,oneof=storage bandwidth"`
+	Reserved int64  `json:"reserved" validate:"gte=0"`
+	Actual   int64  `json:"actual" validate:"gte=0"`
+}
+
+// AdjustReservationResponse reports the correction applied to usage
+type AdjustReservationResponse struct {
+	Resource   string `json:"resource"`
+	Reserved   int64  `json:"reserved"`
+	Actual     int64  `json:"actual"`
+	Adjustment int64  `json:"adjustment"`
+}
+
+// UsageReconciliation reports usage counters before and after they were
+// recomputed from file metadata and documents
+type UsageReconciliation struct {
+	TenantID        uuid.UUID     `json:"tenant_id"`
+	StorageBefore   int64         `json:"storage_before"`
+	StorageAfter    int64         `json:"storage_after"`
+	DocumentsBefore int           `json:"documents_before"`
+	DocumentsAfter  int           `json:"documents_after"`
+	Corrected       bool          `json:"corrected"`
+	ReconciledAt    timeutil.Time `json:"reconciled_at"`
+}
+
 // UsageStatsParams represents query parameters for usage statistics
 type UsageStatsParams struct {
-	StartDate string `json:"start_date,omitempty" form:"start_date"`
-	EndDate   string `json:"end_date,omitempty" form:"end_date"`
+	StartDate string `json:"start_date,omitempty" form:"start_date" validate:"omitempty,rfc3339"`
+	EndDate   string `json:"end_date,omitempty" form:"end_date" validate:"omitempty,rfc3339"`
 	Resource  string `json:"resource,omitempty" form:"resource"`
 	Action    string `json:"action,omitempty" form:"action"`
 	Limit     int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=1000"`
@@ -175,66 +349,197 @@ type UserUsageStats struct {
 
 // QuotaPlan represents a predefined quota plan
 type QuotaPlan struct {
-	Name              string `json:"name"`
-	DisplayName       string `json:"display_name"`
-	MaxStorage        int64  `json:"max_storage"`
-	MaxDocuments      int    `json:"max_documents"`
-	MaxUsers          int    `json:"max_users"`
-	MaxAPICallsPerDay int    `json:"max_api_calls_per_day"`
-	MaxFileSize       int64  `json:"max_file_size"`
-	MaxBandwidth      int64  `json:"max_bandwidth"`
+	Name              string   `json:"name"`
+	DisplayName       string   `json:"display_name"`
+	MaxStorage        int64    `json:"max_storage"`
+	MaxDocuments      int      `json:"max_documents"`
+	MaxUsers          int      `json:"max_users"`
+	MaxAPICallsPerDay int      `json:"max_api_calls_per_day"`
+	MaxFileSize       int64    `json:"max_file_size"`
+	MaxBandwidth      int64    `json:"max_bandwidth"`
+	RequestsPerMinute int      `json:"requests_per_minute"` // API rate limit per tenant
 	Features          []string `json:"features"`
 	PriceMonthly      float64  `json:"price_monthly"`
 }
 
+// QuotaRequest returns the quota creation request for this plan's limits
+func (p QuotaPlan) QuotaRequest() *CreateQuotaRequest {
+	return &CreateQuotaRequest{
+		PlanName:          p.Name,
+		MaxStorage:        p.MaxStorage,
+		MaxDocuments:      p.MaxDocuments,
+		MaxUsers:          p.MaxUsers,
+		MaxAPICallsPerDay: p.MaxAPICallsPerDay,
+		MaxFileSize:       p.MaxFileSize,
+		MaxBandwidth:      p.MaxBandwidth,
+		Features:          p.Features,
+	}
+}
+
+// GetPredefinedPlan returns the predefined plan with the given name
+func GetPredefinedPlan(name string) (*QuotaPlan, bool) {
+	for _, plan := range GetPredefinedPlans() {
+		if plan.Name == name {
+			return &plan, true
+		}
+	}
+	return nil, false
+}
+
+// SuggestPlan returns the cheapest predefined plan that keeps every resource
+// below threshold percent of its limit, when at least one resource is at or
+// above threshold percent of the current quota. It returns nil when no
+// resource is near its limit or no plan accommodates the usage.
+func SuggestPlan(quota *Quota, usage *Usage, threshold float64) *PlanSuggestion {
+	current := usageAgainst(usage, quota.MaxStorage, quota.MaxDocuments, quota.MaxUsers, quota.MaxAPICallsPerDay, quota.MaxBandwidth)
+
+	var triggeredBy []string
+	for _, r := range current {
+		if r.percent() >= threshold {
+			triggeredBy = append(triggeredBy, r.name)
+		}
+	}
+	if len(triggeredBy) == 0 {
+		return nil
+	}
+
+	var best *QuotaPlan
+	for _, plan := range GetPredefinedPlans() {
+		if plan.Name == quota.PlanName {
+			continue
+		}
+
+		fits := true
+		candidate := usageAgainst(usage, plan.MaxStorage, plan.MaxDocuments, plan.MaxUsers, plan.MaxAPICallsPerDay, plan.MaxBandwidth)
+		for i, r := range candidate {
+			// Must fit all usage and raise every limit that triggered the suggestion
+			if r.percent() >= threshold || (current[i].percent() >= threshold && r.limit <= current[i].limit) {
+				fits = false
+				break
+			}
+		}
+		if fits && (best == nil || plan.PriceMonthly < best.PriceMonthly) {
+			p := plan
+			best = &p
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	return &PlanSuggestion{
+		Plan:        *best,
+		TriggeredBy: triggeredBy,
+	}
+}
+
+// PreviewPlan compares usage and the currently enabled features against plan
+func PreviewPlan(features *FeatureSet, usage *Usage, plan QuotaPlan) *PlanPreview {
+	preview := &PlanPreview{
+		CurrentPlan:  features.PlanName,
+		TargetPlan:   plan,
+		Fits:         true,
+		LostFeatures: []string{},
+	}
+
+	for _, r := range usageAgainst(usage, plan.MaxStorage, plan.MaxDocuments, plan.MaxUsers, plan.MaxAPICallsPerDay, plan.MaxBandwidth) {
+		fit := ResourceFit{Resource: r.name, Used: r.used, Limit: r.limit, Fits: r.used <= r.limit}
+		if !fit.Fits {
+			fit.Overage = r.used - r.limit
+			preview.Fits = false
+		}
+		preview.Resources = append(preview.Resources, fit)
+	}
+
+	kept := make(map[string]bool, len(plan.Features))
+	for _, feature := range plan.Features {
+		kept[feature] = true
+	}
+	for _, feature := range features.Features {
+		if !kept[feature] {
+			preview.LostFeatures = append(preview.LostFeatures, feature)
+		}
+	}
+
+	return preview
+}
+
+// resourceUsage pairs a resource's usage with a limit
+type resourceUsage struct {
+	name  string
+	used  int64
+	limit int64
+}
+
+func (r resourceUsage) percent() float64 {
+	if r.limit <= 0 {
+		return 0
+	}
+	return float64(r.used) / float64(r.limit) * 100
+}
+
+func usageAgainst(usage *Usage, storage int64, documents, users, apiCalls int, bandwidth int64) []resourceUsage {
+	return []resourceUsage{
+		{name: "storage", used: usage.StorageUsed, limit: storage},
+		{name: "documents", used: int64(usage.DocumentCount), limit: int64(documents)},
+		{name: "users", used: int64(usage.UserCount), limit: int64(users)},
+		{name: "api_calls", used: int64(usage.APICallsToday), limit: int64(apiCalls)},
+		{name: "bandwidth", used: usage.BandwidthMonth, limit: bandwidth},
+	}
+}
+
 // GetPredefinedPlans returns predefined quota plans
 func GetPredefinedPlans() []QuotaPlan {
 	return []QuotaPlan{
 		{
 			Name:              "free",
 			DisplayName:       "Free",
-			MaxStorage:        1 * 1024 * 1024 * 1024, // 1 GB
+			MaxStorage:        1 * humanize.GB,
 			MaxDocuments:      100,
 			MaxUsers:          3,
 			MaxAPICallsPerDay: 1000,
-			MaxFileSize:       10 * 1024 * 1024, // 10 MB
-			MaxBandwidth:      5 * 1024 * 1024 * 1024, // 5 GB
+			MaxFileSize:       10 * humanize.MB,
+			MaxBandwidth:      5 * humanize.GB,
+			RequestsPerMinute: 60,
 			Features:          []string{"basic_storage", "basic_sharing"},
 			PriceMonthly:      0,
 		},
 		{
 			Name:              "basic",
 			DisplayName:       "Basic",
-			MaxStorage:        10 * 1024 * 1024 * 1024, // 10 GB
+			MaxStorage:        10 * humanize.GB,
 			MaxDocuments:      1000,
 			MaxUsers:          10,
 			MaxAPICallsPerDay: 10000,
-			MaxFileSize:       50 * 1024 * 1024, // 50 MB
-			MaxBandwidth:      50 * 1024 * 1024 * 1024, // 50 GB
+			MaxFileSize:       50 * humanize.MB,
+			MaxBandwidth:      50 * humanize.GB,
+			RequestsPerMinute: 300,
 			Features:          []string{"basic_storage", "basic_sharing", "ocr", "search"},
 			PriceMonthly:      9.99,
 		},
 		{
 			Name:              "pro",
 			DisplayName:       "Professional",
-			MaxStorage:        100 * 1024 * 1024 * 1024, // 100 GB
+			MaxStorage:        100 * humanize.GB,
 			MaxDocuments:      10000,
 			MaxUsers:          50,
 			MaxAPICallsPerDay: 100000,
-			MaxFileSize:       500 * 1024 * 1024, // 500 MB
-			MaxBandwidth:      500 * 1024 * 1024 * 1024, // 500 GB
+			MaxFileSize:       500 * humanize.MB,
+			MaxBandwidth:      500 * humanize.GB,
+			RequestsPerMinute: 1200,
 			Features:          []string{"basic_storage", "basic_sharing", "ocr", "search", "advanced_sharing", "categorization", "audit"},
 			PriceMonthly:      49.99,
 		},
 		{
 			Name:              "enterprise",
 			DisplayName:       "Enterprise",
-			MaxStorage:        1024 * 1024 * 1024 * 1024, // 1 TB
+			MaxStorage:        1 * humanize.TB,
 			MaxDocuments:      100000,
 			MaxUsers:          500,
 			MaxAPICallsPerDay: 1000000,
-			MaxFileSize:       2 * 1024 * 1024 * 1024, // 2 GB
-			MaxBandwidth:      5 * 1024 * 1024 * 1024 * 1024, // 5 TB
+			MaxFileSize:       2 * humanize.GB,
+			MaxBandwidth:      5 * humanize.TB,
+			RequestsPerMinute: 6000,
 			Features:          []string{"basic_storage", "basic_sharing", "ocr", "search", "advanced_sharing", "categorization", "audit", "sso", "priority_support"},
 			PriceMonthly:      199.99,
 		},
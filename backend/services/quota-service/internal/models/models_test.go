@@ -0,0 +1,77 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestQuotaHasFeature(t *testing.T) {
+	override := func(features string) sql.NullString { return sql.NullString{String: features, Valid: true} }
+
+	tests := []struct {
+		name     string
+		quota    Quota
+		feature  string
+		want     bool
+		wantPlan string
+	}{
+		{"plan feature is enabled", Quota{PlanName: "pro", IsActive: true}, "ocr", true, "pro"},
+		{"feature of a higher plan is disabled", Quota{PlanName: "basic", IsActive: true}, "sso", false, "basic"},
+		{"free plan has basic storage", Quota{PlanName: "free", IsActive: true}, "basic_storage", true, "free"},
+		{"override enables a feature outside the plan", Quota{PlanName: "free", IsActive: true, Features: override(`["basic_storage","sso"]`)}, "sso", true, "free"},
+		{"override replaces the plan features", Quota{PlanName: "enterprise", IsActive: true, Features: override(`["basic_storage"]`)}, "ocr", false, "enterprise"},
+		{"empty override falls back to the plan", Quota{PlanName: "pro", IsActive: true, Features: override("")}, "search", true, "pro"},
+		{"unknown feature name is disabled", Quota{PlanName: "enterprise", IsActive: true}, "teleport", false, "enterprise"},
+		{"feature names are case sensitive", Quota{PlanName: "enterprise", IsActive: true}, "OCR", false, "enterprise"},
+		{"unknown plan enables nothing", Quota{PlanName: "custom", IsActive: true}, "basic_storage", false, "custom"},
+		{"inactive quota enables nothing", Quota{PlanName: "enterprise", Features: override(`["sso"]`)}, "sso", false, "enterprise"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features, err := tt.quota.FeatureSet()
+			if err != nil {
+				t.Fatalf("FeatureSet() error = %v", err)
+			}
+			if got := features.Has(tt.feature); got != tt.want {
+				t.Errorf("Has(%q) = %v, want %v (features %v)", tt.feature, got, tt.want, features.Features)
+			}
+			if features.PlanName != tt.wantPlan {
+				t.Errorf("PlanName = %q, want %q", features.PlanName, tt.wantPlan)
+			}
+		})
+	}
+}
+
+func TestQuotaFeatureSetInvalidOverride(t *testing.T) {
+	quota := Quota{PlanName: "pro", IsActive: true, Features: sql.NullString{String: `{"ocr":true}`, Valid: true}}
+	if _, err := quota.FeatureSet(); err == nil {
+		t.Error("FeatureSet() succeeded on a malformed feature list, want an error")
+	}
+}
+
+func TestPredefinedPlanRateLimits(t *testing.T) {
+	tests := []struct {
+		plan string
+		want int
+	}{
+		{"free", 60},
+		{"basic", 300},
+		{"pro", 1200},
+		{"enterprise", 6000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.plan, func(t *testing.T) {
+			plan, ok := GetPredefinedPlan(tt.plan)
+			if !ok {
+				t.Fatalf("GetPredefinedPlan(%q) found no plan", tt.plan)
+			}
+			if plan.RequestsPerMinute != tt.want {
+				t.Errorf("RequestsPerMinute = %d, want %d", plan.RequestsPerMinute, tt.want)
+			}
+		})
+	}
+
+	if _, ok := GetPredefinedPlan("custom"); ok {
+		t.Error("GetPredefinedPlan(custom) found a plan, want none so the default limit applies")
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/retention"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/models"
 	"go.uber.org/zap"
 )
@@ -59,7 +61,7 @@ func (r *Repository) CreateQuota(ctx context.Context, quota *models.Quota) error
 
 	if err != nil {
 		r.logger.Error("failed to create quota", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create quota")
+		return database.WrapError("failed to create quota", err)
 	}
 
 	return nil
@@ -142,7 +144,7 @@ func (r *Repository) UpdateQuota(ctx context.Context, tenantID uuid.UUID, update
 	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to update quota", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update quota")
+		return database.WrapError("failed to update quota", err)
 	}
 
 	rows, _ := result.RowsAffected()
@@ -178,7 +180,7 @@ func (r *Repository) CreateUsage(ctx context.Context, usage *models.Usage) error
 
 	if err != nil {
 		r.logger.Error("failed to create usage", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create usage")
+		return database.WrapError("failed to create usage", err)
 	}
 
 	return nil
@@ -217,6 +219,88 @@ func (r *Repository) GetUsage(ctx context.Context, tenantID uuid.UUID) (*models.
 	return &usage, nil
 }
 
+// usageColumns maps consumable resources to their usage counters
+var usageColumns = map[string]string{
+	"storage":   "storage_used",
+	"documents": "document_count",
+	"users":     "user_count",
+	"bandwidth": "bandwidth_month",
+}
+
+// ConsumeUsage locks the tenant's usage row and passes it to allow. When allow
+// approves, every amount is added in the same transaction, so the decision and
+// the increments see the same usage and either all amounts apply or none do.
+// Resources without a usage counter here (file_size, and api_calls, which are
+// counted in Redis) are only checked.
+func (r *Repository) ConsumeUsage(ctx context.Context, tenantID uuid.UUID, amounts map[string]int64, allow func(*models.Usage) bool) (*models.Usage, bool, error) {
+	var (
+		usage   models.Usage
+		allowed bool
+	)
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			SELECT id, tenant_id, storage_used, document_count, user_count,
+				api_calls_today, bandwidth_month, last_api_call, last_reset_date, updated_at
+			FROM usage
+			WHERE tenant_id = $1
+			FOR UPDATE`,
+			tenantID,
+		).Scan(
+			&usage.ID,
+			&usage.TenantID,
+			&usage.StorageUsed,
+			&usage.DocumentCount,
+			&usage.UserCount,
+			&usage.APICallsToday,
+			&usage.BandwidthMonth,
+			&usage.LastAPICall,
+			&usage.LastResetDate,
+			&usage.UpdatedAt,
+		)
+		if err == sql.ErrNoRows {
+			return errors.NotFoundf("usage not found")
+		}
+		if err != nil {
+			r.logger.Error("failed to lock usage", zap.Error(err))
+			return errors.New(errors.ErrCodeInternal, "failed to get usage")
+		}
+
+		allowed = allow(&usage)
+		if !allowed {
+			return nil
+		}
+
+		setClauses := []string{}
+		args := []interface{}{}
+		for resource, amount := range amounts {
+			column, ok := usageColumns[resource]
+			if !ok || amount == 0 {
+				continue
+			}
+			args = append(args, amount)
+			setClauses = append(setClauses, fmt.Sprintf("%s = %s + $%d", column, column, len(args)))
+		}
+		if len(setClauses) == 0 {
+			return nil
+		}
+
+		args = append(args, time.Now(), tenantID)
+		query := fmt.Sprintf("UPDATE usage SET %s, updated_at = $%d WHERE tenant_id = $%d",
+			strings.Join(setClauses, ", "), len(args)-1, len(args))
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			r.logger.Error("failed to consume usage", zap.Error(err))
+			return database.WrapError("failed to update usage", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &usage, allowed, nil
+}
+
 // IncrementStorage increments storage usage
 func (r *Repository) IncrementStorage(ctx context.Context, tenantID uuid.UUID, amount int64) error {
 	query := `
@@ -227,26 +311,33 @@ func (r *Repository) IncrementStorage(ctx context.Context, tenantID uuid.UUID, a
 	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), tenantID)
 	if err != nil {
 		r.logger.Error("failed to increment storage", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update usage")
+		return database.WrapError("failed to update usage", err)
 	}
 
 	return nil
 }
 
-// DecrementStorage decrements storage usage
-func (r *Repository) DecrementStorage(ctx context.Context, tenantID uuid.UUID, amount int64) error {
+// DecrementStorage decrements storage usage, flooring at zero. It returns the
+// shortfall: how much of amount could not be subtracted because usage ran out.
+func (r *Repository) DecrementStorage(ctx context.Context, tenantID uuid.UUID, amount int64) (int64, error) {
 	query := `
-		UPDATE usage
-		SET storage_used = GREATEST(0, storage_used - $1), updated_at = $2
-		WHERE tenant_id = $3`
-
-	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), tenantID)
+		UPDATE usage u
+		SET storage_used = GREATEST(0, prev.storage_used - $1), updated_at = $2
+		FROM (SELECT storage_used FROM usage WHERE tenant_id = $3 FOR UPDATE) prev
+		WHERE u.tenant_id = $3
+		RETURNING GREATEST(0, $1 - prev.storage_used)`
+
+	var shortfall int64
+	err := r.db.QueryRowContext(ctx, query, amount, time.Now(), tenantID).Scan(&shortfall)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
 	if err != nil {
 		r.logger.Error("failed to decrement storage", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update usage")
+		return 0, database.WrapError("failed to update usage", err)
 	}
 
-	return nil
+	return shortfall, nil
 }
 
 // IncrementDocumentCount increments document count
@@ -259,40 +350,48 @@ func (r *Repository) IncrementDocumentCount(ctx context.Context, tenantID uuid.U
 	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), tenantID)
 	if err != nil {
 		r.logger.Error("failed to increment document count", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update usage")
+		return database.WrapError("failed to update usage", err)
 	}
 
 	return nil
 }
 
-// DecrementDocumentCount decrements document count
-func (r *Repository) DecrementDocumentCount(ctx context.Context, tenantID uuid.UUID, amount int) error {
+// DecrementDocumentCount decrements document count, flooring at zero. It
+// returns the shortfall like DecrementStorage.
+func (r *Repository) DecrementDocumentCount(ctx context.Context, tenantID uuid.UUID, amount int) (int64, error) {
 	query := `
-		UPDATE usage
-		SET document_count = GREATEST(0, document_count - $1), updated_at = $2
-		WHERE tenant_id = $3`
-
-	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), tenantID)
+		UPDATE usage u
+		SET document_count = GREATEST(0, prev.document_count - $1), updated_at = $2
+		FROM (SELECT document_count FROM usage WHERE tenant_id = $3 FOR UPDATE) prev
+		WHERE u.tenant_id = $3
+		RETURNING GREATEST(0, $1 - prev.document_count)`
+
+	var shortfall int64
+	err := r.db.QueryRowContext(ctx, query, amount, time.Now(), tenantID).Scan(&shortfall)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
 	if err != nil {
 		r.logger.Error("failed to decrement document count", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update usage")
+		return 0, database.WrapError("failed to update usage", err)
 	}
 
-	return nil
+	return shortfall, nil
 }
 
-// IncrementAPICallCount increments API call count
-func (r *Repository) IncrementAPICallCount(ctx context.Context, tenantID uuid.UUID) error {
+// SetAPICallCount stores the tenant's API call count for today, flushed from
+// the real-time counter in Redis; rows already holding the count are not
+// rewritten
+func (r *Repository) SetAPICallCount(ctx context.Context, tenantID uuid.UUID, count int64) error {
 	query := `
 		UPDATE usage
-		SET api_calls_today = api_calls_today + 1, last_api_call = $1, updated_at = $2
-		WHERE tenant_id = $3`
+		SET api_calls_today = $1, last_api_call = $2, updated_at = $2
+		WHERE tenant_id = $3 AND api_calls_today <> $1`
 
-	now := time.Now()
-	_, err := r.db.ExecContext(ctx, query, now, now, tenantID)
+	_, err := r.db.ExecContext(ctx, query, count, time.Now(), tenantID)
 	if err != nil {
-		r.logger.Error("failed to increment API call count", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update usage")
+		r.logger.Error("failed to flush API call count", zap.Error(err))
+		return database.WrapError("failed to update usage", err)
 	}
 
 	return nil
@@ -308,12 +407,35 @@ func (r *Repository) IncrementBandwidth(ctx context.Context, tenantID uuid.UUID,
 	_, err := r.db.ExecContext(ctx, query, amount, time.Now(), tenantID)
 	if err != nil {
 		r.logger.Error("failed to increment bandwidth", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update usage")
+		return database.WrapError("failed to update usage", err)
 	}
 
 	return nil
 }
 
+// DecrementBandwidth decrements this month's bandwidth usage, flooring at
+// zero. It returns the shortfall like DecrementStorage.
+func (r *Repository) DecrementBandwidth(ctx context.Context, tenantID uuid.UUID, amount int64) (int64, error) {
+	query := `
+		UPDATE usage u
+		SET bandwidth_month = GREATEST(0, prev.bandwidth_month - $1), updated_at = $2
+		FROM (SELECT bandwidth_month FROM usage WHERE tenant_id = $3 FOR UPDATE) prev
+		WHERE u.tenant_id = $3
+		RETURNING GREATEST(0, $1 - prev.bandwidth_month)`
+
+	var shortfall int64
+	err := r.db.QueryRowContext(ctx, query, amount, time.Now(), tenantID).Scan(&shortfall)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		r.logger.Error("failed to decrement bandwidth", zap.Error(err))
+		return 0, database.WrapError("failed to update usage", err)
+	}
+
+	return shortfall, nil
+}
+
 // ResetDailyAPICallCount resets daily API call count
 func (r *Repository) ResetDailyAPICallCount(ctx context.Context, tenantID uuid.UUID) error {
 	query := `
@@ -368,7 +490,7 @@ func (r *Repository) CreateUsageLog(ctx context.Context, log *models.UsageLog) e
 
 	if err != nil {
 		r.logger.Error("failed to create usage log", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create usage log")
+		return database.WrapError("failed to create usage log", err)
 	}
 
 	return nil
@@ -383,7 +505,7 @@ func (r *Repository) GetUsageLogs(ctx context.Context, tenantID uuid.UUID, param
 
 	// Parse dates
 	if params.StartDate != "" {
-		startTime, err := time.Parse(time.RFC3339, params.StartDate)
+		startTime, err := timeutil.Parse(params.StartDate)
 		if err == nil {
 			where = append(where, fmt.Sprintf("created_at >= $%d", argPos))
 			args = append(args, startTime)
@@ -392,7 +514,7 @@ func (r *Repository) GetUsageLogs(ctx context.Context, tenantID uuid.UUID, param
 	}
 
 	if params.EndDate != "" {
-		endTime, err := time.Parse(time.RFC3339, params.EndDate)
+		endTime, err := timeutil.Parse(params.EndDate)
 		if err == nil {
 			where = append(where, fmt.Sprintf("created_at <= $%d", argPos))
 			args = append(args, endTime)
@@ -435,6 +557,9 @@ func (r *Repository) GetUsageLogs(ctx context.Context, tenantID uuid.UUID, param
 
 	var logs []models.UsageLog
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		var log models.UsageLog
 		err := rows.Scan(
 			&log.ID,
@@ -452,6 +577,9 @@ func (r *Repository) GetUsageLogs(ctx context.Context, tenantID uuid.UUID, param
 		}
 		logs = append(logs, log)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return logs, nil
 }
@@ -466,8 +594,8 @@ func (r *Repository) GetUsageStats(ctx context.Context, tenantID uuid.UUID, para
 	}
 
 	// Parse dates
-	startTime, _ := time.Parse(time.RFC3339, params.StartDate)
-	endTime, _ := time.Parse(time.RFC3339, params.EndDate)
+	startTime, _ := timeutil.Parse(params.StartDate)
+	endTime, _ := timeutil.Parse(params.EndDate)
 
 	stats.Period = fmt.Sprintf("%s to %s", startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
 
@@ -483,3 +611,102 @@ func (r *Repository) GetUsageStats(ctx context.Context, tenantID uuid.UUID, para
 
 	return stats, nil
 }
+
+// ReconcileUsage recomputes storage and document usage from file_metadata and
+// documents, overwrites the usage row and returns the values before and after
+func (r *Repository) ReconcileUsage(ctx context.Context, tenantID uuid.UUID) (*models.UsageReconciliation, error) {
+	result := &models.UsageReconciliation{TenantID: tenantID}
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx,
+			`SELECT storage_used, document_count FROM usage WHERE tenant_id = $1 FOR UPDATE`,
+			tenantID,
+		).Scan(&result.StorageBefore, &result.DocumentsBefore)
+		if err == sql.ErrNoRows {
+			return errors.NotFoundf("usage not found")
+		}
+		if err != nil {
+			r.logger.Error("failed to lock usage", zap.Error(err))
+			return errors.New(errors.ErrCodeInternal, "failed to reconcile usage")
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			SELECT
+				(SELECT COALESCE(SUM(file_size), 0) FROM file_metadata WHERE tenant_id = $1),
+				(SELECT COUNT(*) FROM documents WHERE tenant_id = $1)`,
+			tenantID,
+		).Scan(&result.StorageAfter, &result.DocumentsAfter)
+		if err != nil {
+			r.logger.Error("failed to compute actual usage", zap.Error(err))
+			return errors.New(errors.ErrCodeInternal, "failed to reconcile usage")
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE usage
+			SET storage_used = $1, document_count = $2, updated_at = $3
+			WHERE tenant_id = $4`,
+			result.StorageAfter, result.DocumentsAfter, time.Now(), tenantID,
+		)
+		if err != nil {
+			r.logger.Error("failed to update reconciled usage", zap.Error(err))
+			return errors.New(errors.ErrCodeInternal, "failed to reconcile usage")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// PurgeExpiredUsageLogs deletes up to limit usage logs older than their retention
+// window: window, or the tenant's longer usage_logs_retention_days setting
+func (r *Repository) PurgeExpiredUsageLogs(ctx context.Context, window time.Duration, limit int) (int64, error) {
+	query := `
+		DELETE FROM usage_logs
+		WHERE id IN (
+			SELECT l.id
+			FROM usage_logs l
+			WHERE l.created_at < ` + retention.CutoffSQL("l.tenant_id", "usage_logs_retention_days", 1) + `
+			LIMIT $2
+		)`
+
+	result, err := r.db.ExecContext(ctx, query, retention.Window(window), limit)
+	if err != nil {
+		r.logger.Error("failed to purge expired usage logs", zap.Error(err))
+		return 0, database.WrapError("failed to purge usage logs", err)
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
+
+// PurgeTenant deletes all quotas, usage counters and usage logs of a tenant in a single transaction and
+// returns the number of rows removed
+func (r *Repository) PurgeTenant(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	statements := []string{
+		`DELETE FROM usage_logs WHERE tenant_id = $1`,
+		`DELETE FROM usage WHERE tenant_id = $1`,
+		`DELETE FROM quotas WHERE tenant_id = $1`,
+	}
+
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			result, err := tx.ExecContext(ctx, statement, tenantID)
+			if err != nil {
+				r.logger.Error("failed to purge tenant quotas", zap.Error(err))
+				return errors.New(errors.ErrCodeInternal, "failed to purge tenant quotas")
+			}
+			rows, _ := result.RowsAffected()
+			deleted += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -9,31 +10,58 @@ import (
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/models"
 	"github.com/SidahmedSeg/document-manager/backend/services/quota-service/internal/repository"
 	"go.uber.org/zap"
 )
 
 const (
-	quotaCacheTTL = 1 * time.Hour
-	usageCacheTTL = 5 * time.Minute
+	quotaCacheTTL   = 1 * time.Hour
+	usageCacheTTL   = 5 * time.Minute
+	featureCacheTTL = 15 * time.Minute
+
+	// API calls are counted in Redis per request and flushed to the usage
+	// row at this interval for reporting
+	apiCallFlushInterval = 1 * time.Minute
+	apiCallFlushTimeout  = 30 * time.Second
+
+	// planSuggestionThreshold is the usage percentage at which the overview
+	// suggests a larger plan
+	planSuggestionThreshold = 90.0
 )
 
 // Service handles quota business logic
 type Service struct {
-	repo   *repository.Repository
-	cache  *cache.Cache
-	logger *zap.Logger
+	repo      *repository.Repository
+	cache     *cache.Cache
+	stopFlush chan struct{}
+	flushDone chan struct{}
+	logger    *zap.Logger
 }
 
-// NewService creates a new quota service
+// NewService creates a new quota service and starts flushing API call
+// counters to the database
 func NewService(repo *repository.Repository, cache *cache.Cache, logger *zap.Logger) *Service {
-	return &Service{
-		repo:   repo,
-		cache:  cache,
-		logger: logger,
-	}
+	s := &Service{
+		repo:      repo,
+		cache:     cache,
+		stopFlush: make(chan struct{}),
+		flushDone: make(chan struct{}),
+		logger:    logger,
+	}
+	go s.runAPICallFlush()
+	return s
+}
+
+// Close stops the API call flush after a final run; call after the server
+// stops accepting requests
+func (s *Service) Close() {
+	close(s.stopFlush)
+	<-s.flushDone
 }
 
 // CreateQuota creates a new quota for a tenant
@@ -43,7 +71,7 @@ func (s *Service) CreateQuota(ctx context.Context, req *models.CreateQuotaReques
 	// Parse valid_until if provided
 	var validUntil *time.Time
 	if req.ValidUntil != "" {
-		parsed, err := time.Parse(time.RFC3339, req.ValidUntil)
+		parsed, err := timeutil.Parse(req.ValidUntil)
 		if err != nil {
 			return nil, errors.Validationf("invalid valid_until format")
 		}
@@ -62,9 +90,9 @@ func (s *Service) CreateQuota(ctx context.Context, req *models.CreateQuotaReques
 		MaxFileSize:       req.MaxFileSize,
 		MaxBandwidth:      req.MaxBandwidth,
 		IsActive:          true,
-		ValidFrom:         time.Now(),
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
+		ValidFrom:         timeutil.Now(),
+		CreatedAt:         timeutil.Now(),
+		UpdatedAt:         timeutil.Now(),
 	}
 
 	if validUntil != nil {
@@ -91,9 +119,9 @@ func (s *Service) CreateQuota(ctx context.Context, req *models.CreateQuotaReques
 		UserCount:      1, // The tenant creator
 		APICallsToday:  0,
 		BandwidthMonth: 0,
-		LastAPICall:    time.Now(),
-		LastResetDate:  time.Now(),
-		UpdatedAt:      time.Now(),
+		LastAPICall:    timeutil.Now(),
+		LastResetDate:  timeutil.Now(),
+		UpdatedAt:      timeutil.Now(),
 	}
 
 	_ = s.repo.CreateUsage(ctx, usage)
@@ -101,6 +129,8 @@ func (s *Service) CreateQuota(ctx context.Context, req *models.CreateQuotaReques
 	// Invalidate cache
 	cacheKey := cache.TenantKey(tenantID.String(), "quota")
 	_ = s.cache.Delete(ctx, cacheKey)
+	_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "features"))
+	_ = s.cache.Delete(ctx, cache.RateLimitKey(tenantID.String()))
 
 	logger.InfoContext(ctx, "quota created",
 		zap.String("tenant_id", tenantID.String()),
@@ -110,6 +140,28 @@ func (s *Service) CreateQuota(ctx context.Context, req *models.CreateQuotaReques
 	return quota, nil
 }
 
+// ProvisionQuota creates the current tenant's quota from the predefined plan
+// (internal use, called on tenant creation). It is idempotent: an existing
+// active quota is returned unchanged.
+func (s *Service) ProvisionQuota(ctx context.Context, req *models.ProvisionQuotaRequest) (*models.Quota, error) {
+	tenantID := getTenantID(ctx)
+
+	plan, ok := models.GetPredefinedPlan(req.PlanName)
+	if !ok {
+		return nil, errors.Validationf("unknown plan: %s", req.PlanName)
+	}
+
+	existing, err := s.repo.GetQuota(ctx, tenantID)
+	if err == nil {
+		return existing, nil
+	}
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+		return nil, err
+	}
+
+	return s.CreateQuota(ctx, plan.QuotaRequest())
+}
+
 // GetQuota retrieves quota for current tenant
 func (s *Service) GetQuota(ctx context.Context) (*models.Quota, error) {
 	tenantID := getTenantID(ctx)
@@ -133,11 +185,30 @@ func (s *Service) GetQuota(ctx context.Context) (*models.Quota, error) {
 	return quotaPtr, nil
 }
 
-// UpdateQuota updates quota for current tenant
+// UpdateQuota replaces the current tenant's quota limits
 func (s *Service) UpdateQuota(ctx context.Context, req *models.UpdateQuotaRequest) error {
-	tenantID := getTenantID(ctx)
+	validUntil, err := parseValidUntil(req.ValidUntil)
+	if err != nil {
+		return err
+	}
 
-	// Build updates map
+	updates := map[string]interface{}{
+		"max_storage":           req.MaxStorage,
+		"max_documents":         req.MaxDocuments,
+		"max_users":             req.MaxUsers,
+		"max_api_calls_per_day": req.MaxAPICallsPerDay,
+		"max_file_size":         req.MaxFileSize,
+		"max_bandwidth":         req.MaxBandwidth,
+		"features":              featuresValue(req.Features),
+		"valid_until":           validUntil,
+		"is_active":             *req.IsActive,
+	}
+
+	return s.applyQuotaUpdates(ctx, updates)
+}
+
+// PatchQuota applies a partial update to the current tenant's quota
+func (s *Service) PatchQuota(ctx context.Context, req *models.PatchQuotaRequest) error {
 	updates := make(map[string]interface{})
 
 	if req.MaxStorage != nil {
@@ -164,17 +235,16 @@ func (s *Service) UpdateQuota(ctx context.Context, req *models.UpdateQuotaReques
 		updates["max_bandwidth"] = *req.MaxBandwidth
 	}
 
-	if len(req.Features) > 0 {
-		featuresJSON, _ := json.Marshal(req.Features)
-		updates["features"] = string(featuresJSON)
+	if req.Features != nil {
+		updates["features"] = featuresValue(*req.Features)
 	}
 
-	if req.ValidUntil != "" {
-		parsed, err := time.Parse(time.RFC3339, req.ValidUntil)
+	if req.ValidUntil != nil {
+		validUntil, err := parseValidUntil(*req.ValidUntil)
 		if err != nil {
-			return errors.Validationf("invalid valid_until format")
+			return err
 		}
-		updates["valid_until"] = parsed
+		updates["valid_until"] = validUntil
 	}
 
 	if req.IsActive != nil {
@@ -185,6 +255,13 @@ func (s *Service) UpdateQuota(ctx context.Context, req *models.UpdateQuotaReques
 		return nil
 	}
 
+	return s.applyQuotaUpdates(ctx, updates)
+}
+
+// applyQuotaUpdates persists quota changes and invalidates cached limits
+func (s *Service) applyQuotaUpdates(ctx context.Context, updates map[string]interface{}) error {
+	tenantID := getTenantID(ctx)
+
 	if err := s.repo.UpdateQuota(ctx, tenantID, updates); err != nil {
 		return err
 	}
@@ -192,6 +269,8 @@ func (s *Service) UpdateQuota(ctx context.Context, req *models.UpdateQuotaReques
 	// Invalidate cache
 	cacheKey := cache.TenantKey(tenantID.String(), "quota")
 	_ = s.cache.Delete(ctx, cacheKey)
+	_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "features"))
+	_ = s.cache.Delete(ctx, cache.RateLimitKey(tenantID.String()))
 
 	logger.InfoContext(ctx, "quota updated", zap.String("tenant_id", tenantID.String()))
 
@@ -206,6 +285,7 @@ func (s *Service) GetUsage(ctx context.Context) (*models.Usage, error) {
 	cacheKey := cache.TenantKey(tenantID.String(), "usage")
 	var usage models.Usage
 	if err := s.cache.Get(ctx, cacheKey, &usage); err == nil {
+		s.applyLiveAPICalls(ctx, &usage)
 		return &usage, nil
 	}
 
@@ -221,9 +301,21 @@ func (s *Service) GetUsage(ctx context.Context) (*models.Usage, error) {
 	// Cache for future requests
 	_ = s.cache.Set(ctx, cacheKey, usagePtr, usageCacheTTL)
 
+	s.applyLiveAPICalls(ctx, usagePtr)
 	return usagePtr, nil
 }
 
+// applyLiveAPICalls replaces the flushed API call count with the real-time
+// counter; the flushed value is kept if Redis cannot be read
+func (s *Service) applyLiveAPICalls(ctx context.Context, usage *models.Usage) {
+	count, err := s.cache.APICalls(ctx, usage.TenantID.String(), cache.APICallDay(time.Now()))
+	if err != nil {
+		logger.WarnContext(ctx, "failed to read api call counter", zap.Error(err))
+		return
+	}
+	usage.APICallsToday = int(count)
+}
+
 // GetQuotaUsageOverview retrieves quota and usage overview
 func (s *Service) GetQuotaUsageOverview(ctx context.Context) (*models.QuotaUsageOverview, error) {
 	quota, err := s.GetQuota(ctx)
@@ -269,9 +361,33 @@ func (s *Service) GetQuotaUsageOverview(ctx context.Context) (*models.QuotaUsage
 		usage.APICallsToday >= quota.MaxAPICallsPerDay ||
 		usage.BandwidthMonth >= quota.MaxBandwidth
 
+	// Advise the next plan when usage is near or over limits
+	overview.SuggestedPlan = models.SuggestPlan(quota, usage, planSuggestionThreshold)
+
 	return overview, nil
 }
 
+// PreviewPlanChange reports what moving the tenant to another predefined plan
+// would exceed or disable. Nothing is changed.
+func (s *Service) PreviewPlanChange(ctx context.Context, req *models.PlanPreviewRequest) (*models.PlanPreview, error) {
+	plan, ok := models.GetPredefinedPlan(req.PlanName)
+	if !ok {
+		return nil, errors.Validationf("unknown plan '%s'", req.PlanName)
+	}
+
+	usage, err := s.GetUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	features, err := s.getFeatureSet(ctx, getTenantID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return models.PreviewPlan(features, usage, *plan), nil
+}
+
 // CheckQuota checks if a resource usage is within quota
 func (s *Service) CheckQuota(ctx context.Context, req *models.CheckQuotaRequest) (*models.CheckQuotaResponse, error) {
 	quota, err := s.GetQuota(ctx)
@@ -284,47 +400,130 @@ func (s *Service) CheckQuota(ctx context.Context, req *models.CheckQuotaRequest)
 		return nil, err
 	}
 
+	return evaluateQuota(quota, usage, req.Resource, req.Amount)
+}
+
+// CheckQuotaMulti evaluates several resources against one locked snapshot of
+// usage. The operation is allowed only if every resource fits; with Reserve
+// set the amounts are added to usage in the same transaction.
+func (s *Service) CheckQuotaMulti(ctx context.Context, req *models.MultiCheckQuotaRequest) (*models.MultiCheckQuotaResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	quota, err := s.GetQuota(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make(map[string]int64, len(req.Resources))
+	if req.Reserve {
+		for _, resource := range req.Resources {
+			amounts[resource.Resource] = resource.Amount
+		}
+	}
+
+	result := &models.MultiCheckQuotaResponse{Resources: make([]models.CheckQuotaResponse, 0, len(req.Resources))}
+	var evalErr error
+	_, allowed, err := s.repo.ConsumeUsage(ctx, tenantID, amounts, func(usage *models.Usage) bool {
+		s.applyLiveAPICalls(ctx, usage)
+		result.Resources = result.Resources[:0]
+		allowed := true
+		for _, resource := range req.Resources {
+			detail, err := evaluateQuota(quota, usage, resource.Resource, resource.Amount)
+			if err != nil {
+				evalErr = err
+				return false
+			}
+			result.Resources = append(result.Resources, *detail)
+			allowed = allowed && detail.Allowed
+		}
+		return allowed
+	})
+	if err != nil {
+		return nil, err
+	}
+	if evalErr != nil {
+		return nil, evalErr
+	}
+
+	result.Allowed = allowed
+	result.Reserved = allowed && req.Reserve
+
+	if result.Reserved {
+		_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "usage"))
+
+		// API calls are counted in Redis rather than in the usage row
+		if amount, ok := amounts["api_calls"]; ok {
+			if _, err := s.cache.IncrementAPICalls(ctx, tenantID.String(), amount); err != nil {
+				logger.WarnContext(ctx, "failed to reserve api calls", zap.Error(err))
+			}
+		}
+
+		for _, resource := range req.Resources {
+			if resource.Resource == "file_size" {
+				continue // checked only, not a usage counter
+			}
+			_ = s.repo.CreateUsageLog(ctx, &models.UsageLog{
+				ID:        uuid.New(),
+				TenantID:  tenantID,
+				Action:    "reserve",
+				Resource:  resource.Resource,
+				Amount:    resource.Amount,
+				CreatedAt: timeutil.Now(),
+			})
+		}
+	} else if !allowed {
+		logger.InfoContext(ctx, "multi-resource quota check denied",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Int("resources", len(req.Resources)),
+		)
+	}
+
+	return result, nil
+}
+
+// evaluateQuota checks a single resource amount against quota and usage
+func evaluateQuota(quota *models.Quota, usage *models.Usage, resource string, amount int64) (*models.CheckQuotaResponse, error) {
 	response := &models.CheckQuotaResponse{
-		Resource:        req.Resource,
-		RequestedAmount: req.Amount,
+		Resource:        resource,
+		RequestedAmount: amount,
 	}
 
-	switch req.Resource {
+	switch resource {
 	case "storage":
 		response.CurrentUsage = usage.StorageUsed
 		response.MaxAllowed = quota.MaxStorage
 		response.Remaining = quota.MaxStorage - usage.StorageUsed
-		response.Allowed = (usage.StorageUsed + req.Amount) <= quota.MaxStorage
+		response.Allowed = (usage.StorageUsed + amount) <= quota.MaxStorage
 
 	case "documents":
 		response.CurrentUsage = int64(usage.DocumentCount)
 		response.MaxAllowed = int64(quota.MaxDocuments)
 		response.Remaining = int64(quota.MaxDocuments - usage.DocumentCount)
-		response.Allowed = (usage.DocumentCount + int(req.Amount)) <= quota.MaxDocuments
+		response.Allowed = (usage.DocumentCount + int(amount)) <= quota.MaxDocuments
 
 	case "users":
 		response.CurrentUsage = int64(usage.UserCount)
 		response.MaxAllowed = int64(quota.MaxUsers)
 		response.Remaining = int64(quota.MaxUsers - usage.UserCount)
-		response.Allowed = (usage.UserCount + int(req.Amount)) <= quota.MaxUsers
+		response.Allowed = (usage.UserCount + int(amount)) <= quota.MaxUsers
 
 	case "api_calls":
 		response.CurrentUsage = int64(usage.APICallsToday)
 		response.MaxAllowed = int64(quota.MaxAPICallsPerDay)
 		response.Remaining = int64(quota.MaxAPICallsPerDay - usage.APICallsToday)
-		response.Allowed = (usage.APICallsToday + int(req.Amount)) <= quota.MaxAPICallsPerDay
+		response.Allowed = (usage.APICallsToday + int(amount)) <= quota.MaxAPICallsPerDay
 
 	case "bandwidth":
 		response.CurrentUsage = usage.BandwidthMonth
 		response.MaxAllowed = quota.MaxBandwidth
 		response.Remaining = quota.MaxBandwidth - usage.BandwidthMonth
-		response.Allowed = (usage.BandwidthMonth + req.Amount) <= quota.MaxBandwidth
+		response.Allowed = (usage.BandwidthMonth + amount) <= quota.MaxBandwidth
 
 	case "file_size":
 		response.CurrentUsage = 0 // Single file check
 		response.MaxAllowed = quota.MaxFileSize
 		response.Remaining = quota.MaxFileSize
-		response.Allowed = req.Amount <= quota.MaxFileSize
+		response.Allowed = amount <= quota.MaxFileSize
 
 	default:
 		return nil, errors.Validationf("invalid resource type")
@@ -337,6 +536,59 @@ func (s *Service) CheckQuota(ctx context.Context, req *models.CheckQuotaRequest)
 	return response, nil
 }
 
+// HasFeature reports whether a feature is enabled on the tenant's plan
+func (s *Service) HasFeature(ctx context.Context, tenantID uuid.UUID, feature string) (bool, error) {
+	features, err := s.getFeatureSet(ctx, tenantID)
+	if err != nil {
+		return false, err
+	}
+	return features.Has(feature), nil
+}
+
+// CheckFeature checks whether a feature is available to the current tenant
+func (s *Service) CheckFeature(ctx context.Context, req *models.FeatureCheckRequest) (*models.FeatureCheckResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	features, err := s.getFeatureSet(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.FeatureCheckResponse{
+		Allowed:  features.Has(req.Feature),
+		Feature:  req.Feature,
+		PlanName: features.PlanName,
+	}
+
+	if !response.Allowed {
+		response.Message = "feature not available on your plan"
+	}
+
+	return response, nil
+}
+
+// GetRateLimit returns the current tenant's API rate limit from its plan. It
+// returns a not-found error when the tenant has no quota or its plan has no
+// predefined limit, so callers fall back to their configured default.
+func (s *Service) GetRateLimit(ctx context.Context) (*models.RateLimit, error) {
+	tenantID := getTenantID(ctx)
+
+	features, err := s.getFeatureSet(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, ok := models.GetPredefinedPlan(features.PlanName)
+	if !ok {
+		return nil, errors.NotFoundf("plan '%s' has no rate limit", features.PlanName)
+	}
+
+	return &models.RateLimit{
+		PlanName:          plan.Name,
+		RequestsPerMinute: plan.RequestsPerMinute,
+	}, nil
+}
+
 // IncrementUsage increments usage for a resource
 func (s *Service) IncrementUsage(ctx context.Context, req *models.IncrementUsageRequest) error {
 	tenantID := getTenantID(ctx)
@@ -348,7 +600,7 @@ func (s *Service) IncrementUsage(ctx context.Context, req *models.IncrementUsage
 	case "documents":
 		err = s.repo.IncrementDocumentCount(ctx, tenantID, int(req.Amount))
 	case "api_calls":
-		err = s.repo.IncrementAPICallCount(ctx, tenantID)
+		_, err = s.cache.IncrementAPICalls(ctx, tenantID.String(), req.Amount)
 	case "bandwidth":
 		err = s.repo.IncrementBandwidth(ctx, tenantID, req.Amount)
 	default:
@@ -366,7 +618,7 @@ func (s *Service) IncrementUsage(ctx context.Context, req *models.IncrementUsage
 		Action:    "increment",
 		Resource:  req.Resource,
 		Amount:    req.Amount,
-		CreatedAt: time.Now(),
+		CreatedAt: timeutil.Now(),
 	}
 
 	if req.UserID != "" {
@@ -392,12 +644,15 @@ func (s *Service) IncrementUsage(ctx context.Context, req *models.IncrementUsage
 func (s *Service) DecrementUsage(ctx context.Context, req *models.DecrementUsageRequest) error {
 	tenantID := getTenantID(ctx)
 
-	var err error
+	var (
+		shortfall int64
+		err       error
+	)
 	switch req.Resource {
 	case "storage":
-		err = s.repo.DecrementStorage(ctx, tenantID, req.Amount)
+		shortfall, err = s.repo.DecrementStorage(ctx, tenantID, req.Amount)
 	case "documents":
-		err = s.repo.DecrementDocumentCount(ctx, tenantID, int(req.Amount))
+		shortfall, err = s.repo.DecrementDocumentCount(ctx, tenantID, int(req.Amount))
 	default:
 		return errors.Validationf("invalid resource type")
 	}
@@ -406,6 +661,18 @@ func (s *Service) DecrementUsage(ctx context.Context, req *models.DecrementUsage
 		return err
 	}
 
+	// The floor hides a decrement larger than anything recorded; surface it so
+	// the counter can be reconciled instead of staying undercounted
+	if shortfall > 0 {
+		metrics.QuotaUnderflows.Add(req.Resource, 1)
+		logger.WarnContext(ctx, "usage decrement underflowed, clamped at zero",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("resource", req.Resource),
+			zap.Int64("amount", req.Amount),
+			zap.Int64("shortfall", shortfall),
+		)
+	}
+
 	// Log usage
 	usageLog := &models.UsageLog{
 		ID:        uuid.New(),
@@ -413,7 +680,7 @@ func (s *Service) DecrementUsage(ctx context.Context, req *models.DecrementUsage
 		Action:    "decrement",
 		Resource:  req.Resource,
 		Amount:    -req.Amount, // Negative for decrement
-		CreatedAt: time.Now(),
+		CreatedAt: timeutil.Now(),
 	}
 
 	if req.UserID != "" {
@@ -430,10 +697,115 @@ func (s *Service) DecrementUsage(ctx context.Context, req *models.DecrementUsage
 	return nil
 }
 
+// AdjustReservation corrects usage by the difference between a reservation
+// and the amount that was finally used. Releasing part of a reservation always
+// succeeds; growing it is checked against the quota like a new reservation
+// and is rejected as a whole when it would exceed the limit.
+func (s *Service) AdjustReservation(ctx context.Context, req *models.AdjustReservationRequest) (*models.AdjustReservationResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	result := &models.AdjustReservationResponse{
+		Resource:   req.Resource,
+		Reserved:   req.Reserved,
+		Actual:     req.Actual,
+		Adjustment: req.Actual - req.Reserved,
+	}
+
+	switch {
+	case result.Adjustment == 0:
+		return result, nil
+	case result.Adjustment < 0:
+		release := s.repo.DecrementStorage
+		if req.Resource == "bandwidth" {
+			release = s.repo.DecrementBandwidth
+		}
+		shortfall, err := release(ctx, tenantID, -result.Adjustment)
+		if err != nil {
+			return nil, err
+		}
+		if shortfall > 0 {
+			metrics.QuotaUnderflows.Add(req.Resource, 1)
+			logger.WarnContext(ctx, "reservation release underflowed, clamped at zero",
+				zap.String("tenant_id", tenantID.String()),
+				zap.Int64("amount", -result.Adjustment),
+				zap.Int64("shortfall", shortfall),
+			)
+		}
+	default:
+		quota, err := s.GetQuota(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var evalErr error
+		_, allowed, err := s.repo.ConsumeUsage(ctx, tenantID, map[string]int64{req.Resource: result.Adjustment}, func(usage *models.Usage) bool {
+			detail, err := evaluateQuota(quota, usage, req.Resource, result.Adjustment)
+			if err != nil {
+				evalErr = err
+				return false
+			}
+			return detail.Allowed
+		})
+		if err != nil {
+			return nil, err
+		}
+		if evalErr != nil {
+			return nil, evalErr
+		}
+		if !allowed {
+			return nil, errors.Forbiddenf("quota exceeded for %s", req.Resource).
+				WithMeta("resources", []string{req.Resource})
+		}
+	}
+
+	_ = s.repo.CreateUsageLog(ctx, &models.UsageLog{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Action:    "adjust",
+		Resource:  req.Resource,
+		Amount:    result.Adjustment,
+		CreatedAt: timeutil.Now(),
+	})
+
+	_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "usage"))
+
+	return result, nil
+}
+
+// ReconcileUsage recomputes the tenant's storage and document usage from the
+// authoritative records and corrects the usage counters (admin use)
+func (s *Service) ReconcileUsage(ctx context.Context, tenantID uuid.UUID) (*models.UsageReconciliation, error) {
+	result, err := s.repo.ReconcileUsage(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	result.Corrected = result.StorageBefore != result.StorageAfter || result.DocumentsBefore != result.DocumentsAfter
+	result.ReconciledAt = timeutil.Now()
+
+	cacheKey := cache.TenantKey(tenantID.String(), "usage")
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	if result.Corrected {
+		logger.WarnContext(ctx, "usage reconciled",
+			zap.String("tenant_id", tenantID.String()),
+			zap.Int64("storage_before", result.StorageBefore),
+			zap.Int64("storage_after", result.StorageAfter),
+			zap.Int("documents_before", result.DocumentsBefore),
+			zap.Int("documents_after", result.DocumentsAfter),
+		)
+	}
+
+	return result, nil
+}
+
 // GetUsageStats retrieves usage statistics
 func (s *Service) GetUsageStats(ctx context.Context, params *models.UsageStatsParams) (*models.UsageStats, error) {
 	tenantID := getTenantID(ctx)
 
+	if err := checkUsageRange(params); err != nil {
+		return nil, err
+	}
+
 	params.Normalize()
 
 	stats, err := s.repo.GetUsageStats(ctx, tenantID, params)
@@ -448,6 +820,10 @@ func (s *Service) GetUsageStats(ctx context.Context, params *models.UsageStatsPa
 func (s *Service) GetUsageLogs(ctx context.Context, params *models.UsageStatsParams) ([]models.UsageLog, error) {
 	tenantID := getTenantID(ctx)
 
+	if err := checkUsageRange(params); err != nil {
+		return nil, err
+	}
+
 	params.Normalize()
 
 	logs, err := s.repo.GetUsageLogs(ctx, tenantID, params)
@@ -463,6 +839,27 @@ func (s *Service) GetPredefinedPlans() []models.QuotaPlan {
 	return models.GetPredefinedPlans()
 }
 
+// PurgeTenant deletes all quota and usage records of the tenant in ctx (internal use,
+// called by tenant deletion); it is safe to call again after a partial run
+func (s *Service) PurgeTenant(ctx context.Context) (int64, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return 0, errors.Validationf("tenant is required")
+	}
+
+	deleted, err := s.repo.PurgeTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.InfoContext(ctx, "tenant quotas purged",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int64("deleted", deleted),
+	)
+
+	return deleted, nil
+}
+
 // Helper functions
 
 func getTenantID(ctx context.Context) uuid.UUID {
@@ -471,6 +868,78 @@ func getTenantID(ctx context.Context) uuid.UUID {
 	return tenantID
 }
 
+// getFeatureSet loads the tenant's enabled features, falling back to the
+// predefined plan features when the quota has no explicit list
+func (s *Service) getFeatureSet(ctx context.Context, tenantID uuid.UUID) (*models.FeatureSet, error) {
+	cacheKey := cache.TenantKey(tenantID.String(), "features")
+	var features models.FeatureSet
+	if err := s.cache.Get(ctx, cacheKey, &features); err == nil {
+		return &features, nil
+	}
+
+	quota, err := s.repo.GetQuota(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := quota.FeatureSet()
+	if err != nil {
+		s.logger.Error("failed to parse quota features", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to load plan features")
+	}
+
+	_ = s.cache.Set(ctx, cacheKey, set, featureCacheTTL)
+
+	return set, nil
+}
+
+// runAPICallFlush periodically copies today's API call counters from Redis
+// to the usage rows until Close is called
+func (s *Service) runAPICallFlush() {
+	defer close(s.flushDone)
+
+	ticker := time.NewTicker(apiCallFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushAPICalls()
+		case <-s.stopFlush:
+			s.flushAPICalls()
+			return
+		}
+	}
+}
+
+// flushAPICalls writes today's API call count of every tenant that made calls
+func (s *Service) flushAPICalls() {
+	ctx, cancel := context.WithTimeout(context.Background(), apiCallFlushTimeout)
+	defer cancel()
+
+	day := cache.APICallDay(time.Now())
+	tenantIDs, err := s.cache.APICallTenants(ctx, day)
+	if err != nil {
+		s.logger.Warn("failed to list tenants with api calls", zap.Error(err))
+		return
+	}
+
+	for _, id := range tenantIDs {
+		tenantID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+		count, err := s.cache.APICalls(ctx, id, day)
+		if err != nil {
+			s.logger.Warn("failed to read api call counter", zap.String("tenant_id", id), zap.Error(err))
+			continue
+		}
+		if err := s.repo.SetAPICallCount(ctx, tenantID, count); err != nil {
+			s.logger.Warn("failed to flush api call count", zap.String("tenant_id", id), zap.Error(err))
+		}
+	}
+}
+
 func (s *Service) checkAndResetCounters(ctx context.Context, usage *models.Usage) {
 	tenantID := usage.TenantID
 	now := time.Now()
@@ -487,3 +956,39 @@ func (s *Service) checkAndResetCounters(ctx context.Context, usage *models.Usage
 		_ = s.repo.ResetMonthlyBandwidth(ctx, tenantID)
 	}
 }
+
+// featuresValue encodes a feature list for storage; an empty list is stored as NULL
+func featuresValue(features []string) sql.NullString {
+	if len(features) == 0 {
+		return sql.NullString{}
+	}
+	featuresJSON, _ := json.Marshal(features)
+	return sql.NullString{String: string(featuresJSON), Valid: true}
+}
+
+// parseValidUntil parses an RFC3339 expiry; an empty value is stored as NULL
+func parseValidUntil(value string) (sql.NullTime, error) {
+	parsed, ok, err := validator.ParseTimeParam("valid_until", value)
+	if err != nil || !ok {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: parsed, Valid: true}, nil
+}
+
+// checkUsageRange rejects malformed usage date bounds, and an end before the
+// start, instead of silently widening the range
+func checkUsageRange(params *models.UsageStatsParams) error {
+	start, hasStart, err := validator.ParseTimeParam("start_date", params.StartDate)
+	if err != nil {
+		return err
+	}
+	end, hasEnd, err := validator.ParseTimeParam("end_date", params.EndDate)
+	if err != nil {
+		return err
+	}
+	if hasStart && hasEnd && end.Before(start) {
+		return errors.Validationf("end_date must not be before start_date").
+			WithField("end_date", "end_date must not be before start_date")
+	}
+	return nil
+}
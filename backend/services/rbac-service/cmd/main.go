@@ -10,16 +10,24 @@ import (
 	"time"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/retention"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/handler"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/repository"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/service"
 	"go.uber.org/zap"
 )
 
+// defaultPort is used when SERVER_PORT is not set
+const defaultPort = 10005
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -27,11 +35,15 @@ func main() {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// Override port for RBAC service
-	cfg.Server.Port = 10005
+	// Listen on defaultPort unless SERVER_PORT is set
+	if err := cfg.Server.UseDefaultPort(defaultPort); err != nil {
+		panic(fmt.Sprintf("invalid server config: %v", err))
+	}
 
 	// Initialize logger
-	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format)
+	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format,
+		logger.WithSampling(cfg.Logger.SamplingInitial, cfg.Logger.SamplingThereafter),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
@@ -42,40 +54,56 @@ func main() {
 		zap.String("environment", cfg.Environment),
 		zap.String("version", cfg.AppVersion),
 		zap.Int("port", cfg.Server.Port),
+		zap.Bool("log_sampling", log.SamplingEnabled()),
+		zap.Bool("decision_log", cfg.DecisionLog.Enabled),
 	)
+	log.Info("effective configuration", zap.Any("config", cfg.Redacted()))
 
 	// Connect to database
-	db, err := database.NewPostgresDB(cfg.Database, log.Logger)
+	db, err := database.ConnectPostgresDB(cfg.Database, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
-
-	// Verify database health
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.HealthCheck(ctx); err != nil {
-		log.Fatal("database health check failed", zap.Error(err))
-	}
 	log.Info("database connection established")
 
 	// Connect to Redis cache
-	cacheClient, err := cache.NewRedisCache(cfg.Redis, log.Logger)
+	cacheClient, err := cache.ConnectRedisCache(cfg.Redis, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to cache", zap.Error(err))
 	}
 	defer cacheClient.Close()
-
-	// Verify cache health
-	if err := cacheClient.HealthCheck(ctx); err != nil {
-		log.Fatal("cache health check failed", zap.Error(err))
-	}
 	log.Info("cache connection established")
 
+	// Initialize internal service clients
+	tenantClient := client.NewTenantClient(client.New("tenant-service", cfg.Services.TenantServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	quotaClient := client.NewQuotaClient(client.New("quota-service", cfg.Services.QuotaServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+
 	// Initialize layers
 	repo := repository.NewRepository(db, log.Logger)
-	svc := service.NewService(repo, cacheClient, log.Logger)
-	h := handler.NewHandler(svc, log.Logger)
+	svc := service.NewService(repo, cacheClient, tenantClient, cfg.DecisionLog, cfg.Roles, log.Logger)
+	defer svc.Close()
+
+	// Audit retention: purged on a schedule and on demand
+	purger := retention.New(cfg.Retention, log.Logger, retention.Table{
+		Name: "permission_decisions",
+		Purge: func(ctx context.Context, limit int) (int64, error) {
+			return repo.PurgeExpiredPermissionDecisions(ctx, cfg.Retention.PermissionDecisions, limit)
+		},
+	})
+	defer purger.Close()
+
+	readiness := health.NewChecker("rbac-service", cfg.Health, log.Logger).WithVersion(cfg.AppVersion)
+	h := handler.NewHandler(svc, readiness, log.Logger)
+
+	// Sparse fieldsets (?fields=) on list endpoints
+	response.SetStrictFields(cfg.Server.StrictFieldSelection)
+
+	// Unknown request body fields
+	response.SetStrictJSON(cfg.Server.StrictJSON)
+
+	// Signed service-to-service requests only
+	internalAuth := middleware.InternalAuth(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -84,8 +112,23 @@ func main() {
 	mux.HandleFunc("GET /health", h.HealthCheck)
 	mux.HandleFunc("GET /health/ready", h.ReadyCheck)
 
+	// Effective configuration with secrets masked (internal use, operators)
+	mux.Handle("GET /api/config", internalAuth(http.HandlerFunc(config.NewHandler(cfg).Get)))
+
+	// Published metrics as JSON (internal use, scrapers)
+	mux.Handle("GET /api/metrics", internalAuth(metrics.Handler()))
+
+	// Audit retention purge (internal use, admin trigger)
+	mux.Handle("POST /api/retention/purge", internalAuth(http.HandlerFunc(retention.NewHandler(purger).Purge)))
+
+	// Tenant data purge (internal use, called by tenant deletion)
+	mux.Handle("DELETE /api/roles/tenant-data", internalAuth(http.HandlerFunc(h.PurgeTenant)))
+
 	// Permission check endpoint (internal use)
-	mux.HandleFunc("POST /api/permissions/check", h.CheckPermission)
+	mux.Handle("POST /api/permissions/check", internalAuth(http.HandlerFunc(h.CheckPermission)))
+
+	// System role maintenance (internal use by seeding; public role updates reject system roles)
+	mux.Handle("PUT /api/roles/{id}/system-permissions", internalAuth(http.HandlerFunc(h.UpdateSystemRolePermissions)))
 
 	// Role endpoints (auth required)
 	mux.HandleFunc("POST /api/roles", h.CreateRole)
@@ -93,10 +136,12 @@ func main() {
 	mux.HandleFunc("GET /api/roles/{id}", h.GetRole)
 	mux.HandleFunc("GET /api/roles/{id}/permissions", h.GetRoleWithPermissions)
 	mux.HandleFunc("PUT /api/roles/{id}", h.UpdateRole)
+	mux.HandleFunc("PATCH /api/roles/{id}", h.PatchRole)
 	mux.HandleFunc("DELETE /api/roles/{id}", h.DeleteRole)
 
 	// Permission endpoints (auth required)
 	mux.HandleFunc("POST /api/permissions", h.CreatePermission)
+	mux.HandleFunc("POST /api/permissions/bulk", h.BulkCreatePermissions)
 	mux.HandleFunc("GET /api/permissions", h.ListPermissions)
 	mux.HandleFunc("GET /api/permissions/{id}", h.GetPermission)
 
@@ -105,18 +150,33 @@ func main() {
 	mux.HandleFunc("POST /api/user-roles/bulk", h.BulkAssignRole)
 	mux.HandleFunc("GET /api/user-roles/{userId}", h.GetUserRoles)
 	mux.HandleFunc("GET /api/user-roles/{userId}/permissions", h.GetUserPermissions)
+	mux.HandleFunc("GET /api/user-roles/{userId}/scheduled", h.GetScheduledRoles)
 	mux.HandleFunc("DELETE /api/user-roles/{userId}/roles/{roleId}", h.RemoveRole)
 
 	// Stats endpoint
 	mux.HandleFunc("GET /api/rbac/stats", h.GetStats)
+	mux.HandleFunc("GET /api/rbac/decisions", h.ListDecisions)
 
 	// Apply middleware chain
+	middleware.SetSlowRequestThreshold(cfg.Logger.SlowRequestThreshold)
 	var httpHandler http.Handler = mux
+	httpHandler = middleware.Versioning(middleware.APIVersion1)(httpHandler)
 	httpHandler = middleware.RequestID()(httpHandler)
+	httpHandler = middleware.CountAPICalls(cacheClient.IncrementAPICalls, log)(httpHandler)
+	httpHandler = middleware.RateLimit(cfg.RateLimit, cacheClient.RateLimitResolver(quotaClient.RateLimit, cfg.RateLimit.CacheTTL), cacheClient.IncrementRateWindow, log)(httpHandler)
+	httpHandler = middleware.ResolveTenant(cfg.Tenancy, tenantClient.ResolveSlug, tenantClient.IsMember)(httpHandler)
 	httpHandler = middleware.ExtractAuthHeaders(log)(httpHandler)
+	httpHandler = middleware.VerifyInternalRequest(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)(httpHandler)
+	// CORS runs ahead of auth so browser preflights are answered without credentials
+	httpHandler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:      cfg.CORS.AllowedOrigins,
+		CredentialedOrigins: cfg.CORS.CredentialedOrigins,
+		MaxAge:              cfg.CORS.MaxAge,
+	})(httpHandler)
 	httpHandler = middleware.Logging(log)(httpHandler)
 	httpHandler = middleware.Recovery(log)(httpHandler)
 	httpHandler = middleware.Timeout(30 * time.Second)(httpHandler)
+	httpHandler = middleware.MaxBodySize(cfg.Server.MaxBodySize)(httpHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -1,11 +1,13 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/models"
@@ -15,15 +17,17 @@ import (
 
 // Handler handles HTTP requests for RBAC operations
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service   *service.Service
+	readiness *health.Checker
+	logger    *zap.Logger
 }
 
 // NewHandler creates a new RBAC handler
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
+func NewHandler(svc *service.Service, readiness *health.Checker, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: svc,
-		logger:  logger,
+		service:   svc,
+		readiness: readiness,
+		logger:    logger,
 	}
 }
 
@@ -32,8 +36,8 @@ func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
 // CreateRole handles POST /api/roles
 func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -121,7 +125,13 @@ func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Paginated(w, roles, params.Page, params.Limit, total)
+	data, err := response.ProjectFields(r, roles)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Paginated(w, data, params.Page, params.Limit, total)
 }
 
 // UpdateRole handles PUT /api/roles/:id
@@ -134,8 +144,8 @@ func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -153,6 +163,63 @@ func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "role updated successfully"})
 }
 
+// PatchRole handles PATCH /api/roles/:id
+func (h *Handler) PatchRole(w http.ResponseWriter, r *http.Request) {
+	roleIDStr := r.PathValue("id")
+	roleID, err := uuid.Parse(roleIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid role ID")
+		return
+	}
+
+	var req models.PatchRoleRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	if err := h.service.PatchRole(r.Context(), roleID, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]string{"message": "role updated successfully"})
+}
+
+// UpdateSystemRolePermissions handles PUT /api/roles/:id/system-permissions (internal use)
+func (h *Handler) UpdateSystemRolePermissions(w http.ResponseWriter, r *http.Request) {
+	roleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid role ID")
+		return
+	}
+
+	var req models.SystemRolePermissionsRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	role, err := h.service.UpdateSystemRolePermissions(r.Context(), roleID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, role)
+}
+
 // DeleteRole handles DELETE /api/roles/:id
 func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 	roleIDStr := r.PathValue("id")
@@ -175,8 +242,8 @@ func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
 // CreatePermission handles POST /api/permissions
 func (h *Handler) CreatePermission(w http.ResponseWriter, r *http.Request) {
 	var req models.CreatePermissionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -195,6 +262,34 @@ func (h *Handler) CreatePermission(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, permission)
 }
 
+// BulkCreatePermissions handles POST /api/permissions/bulk
+func (h *Handler) BulkCreatePermissions(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkCreatePermissionsRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("permissions", len(req.Permissions), models.MaxBulkPermissionBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.BulkCreatePermissions(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, result)
+}
+
 // GetPermission handles GET /api/permissions/:id
 func (h *Handler) GetPermission(w http.ResponseWriter, r *http.Request) {
 	permIDStr := r.PathValue("id")
@@ -218,10 +313,20 @@ func (h *Handler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 	params := &models.ListPermissionsParams{
 		Resource:  r.URL.Query().Get("resource"),
 		Action:    r.URL.Query().Get("action"),
+		Query:     strings.TrimSpace(r.URL.Query().Get("q")),
 		SortBy:    r.URL.Query().Get("sort_by"),
 		SortOrder: r.URL.Query().Get("sort_order"),
 	}
 
+	// Parse comma-separated resources
+	if resourcesStr := r.URL.Query().Get("resources"); resourcesStr != "" {
+		for _, resource := range strings.Split(resourcesStr, ",") {
+			if resource = strings.TrimSpace(resource); resource != "" {
+				params.Resources = append(params.Resources, resource)
+			}
+		}
+	}
+
 	// Parse page and limit
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil {
@@ -254,8 +359,8 @@ func (h *Handler) ListPermissions(w http.ResponseWriter, r *http.Request) {
 // AssignRole handles POST /api/user-roles
 func (h *Handler) AssignRole(w http.ResponseWriter, r *http.Request) {
 	var req models.AssignRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -276,8 +381,13 @@ func (h *Handler) AssignRole(w http.ResponseWriter, r *http.Request) {
 // BulkAssignRole handles POST /api/user-roles/bulk
 func (h *Handler) BulkAssignRole(w http.ResponseWriter, r *http.Request) {
 	var req models.BulkAssignRoleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("user_ids", len(req.UserIDs), models.MaxBulkAssignBatchSize); err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -336,6 +446,23 @@ func (h *Handler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, roles)
 }
 
+// GetScheduledRoles handles GET /api/user-roles/:userId/scheduled
+func (h *Handler) GetScheduledRoles(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("userId")
+	if userID == "" {
+		response.BadRequest(w, "user ID is required")
+		return
+	}
+
+	userRoles, err := h.service.GetScheduledRoles(r.Context(), userID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, userRoles)
+}
+
 // GetUserPermissions handles GET /api/user-roles/:userId/permissions
 func (h *Handler) GetUserPermissions(w http.ResponseWriter, r *http.Request) {
 	userID := r.PathValue("userId")
@@ -356,8 +483,8 @@ func (h *Handler) GetUserPermissions(w http.ResponseWriter, r *http.Request) {
 // CheckPermission handles POST /api/permissions/check
 func (h *Handler) CheckPermission(w http.ResponseWriter, r *http.Request) {
 	var req models.CheckPermissionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -387,19 +514,68 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, stats)
 }
 
+// ListDecisions handles GET /api/rbac/decisions
+func (h *Handler) ListDecisions(w http.ResponseWriter, r *http.Request) {
+	params := &models.ListDecisionsParams{
+		UserID:   r.URL.Query().Get("user_id"),
+		Resource: r.URL.Query().Get("resource"),
+		Action:   r.URL.Query().Get("action"),
+		Allowed:  r.URL.Query().Get("allowed"),
+		From:     r.URL.Query().Get("from"),
+		To:       r.URL.Query().Get("to"),
+	}
+
+	// Parse page and limit
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil {
+			params.Page = page
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = limit
+		}
+	}
+
+	// Validate params
+	if err := validator.Validate(params); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	decisions, total, err := h.service.ListDecisions(r.Context(), params)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Paginated(w, decisions, params.Page, params.Limit, total)
+}
+
+// PurgeTenant handles DELETE /api/roles/tenant-data (internal use)
+func (h *Handler) PurgeTenant(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.service.PurgeTenant(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]int64{"deleted": deleted})
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.Success(w, map[string]string{
-		"status":  "healthy",
-		"service": "rbac-service",
-	})
+	response.Success(w, h.readiness.Live())
 }
 
 // ReadyCheck handles GET /health/ready
 func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 	// TODO: Check database and cache connectivity
-	response.Success(w, map[string]string{
-		"status":  "ready",
-		"service": "rbac-service",
-	})
+	report := h.readiness.Check(r.Context())
+	if !report.Ready() {
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	response.Success(w, report)
 }
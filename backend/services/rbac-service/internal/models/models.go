@@ -2,9 +2,10 @@ package models
 
 import (
 	"database/sql"
-	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 )
 
 // Role represents a role in the system
@@ -13,11 +14,11 @@ type Role struct {
 	TenantID    uuid.UUID      `json:"tenant_id" db:"tenant_id"`
 	Name        string         `json:"name" db:"name"`
 	Description sql.NullString `json:"description,omitempty" db:"description"`
-	IsSystem    bool           `json:"is_system" db:"is_system"` // System roles can't be deleted
+	IsSystem    bool           `json:"is_system" db:"is_system"`   // System roles can't be deleted
 	IsDefault   bool           `json:"is_default" db:"is_default"` // Default role for new users
 	CreatedBy   string         `json:"created_by" db:"created_by"`
-	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	CreatedAt   timeutil.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   timeutil.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // Permission represents a permission in the system
@@ -27,24 +28,26 @@ type Permission struct {
 	Resource    string         `json:"resource" db:"resource"` // e.g., document, folder, share
 	Action      string         `json:"action" db:"action"`     // e.g., create, read, update, delete
 	Description sql.NullString `json:"description,omitempty" db:"description"`
-	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	CreatedAt   timeutil.Time  `json:"created_at" db:"created_at"`
 }
 
 // RolePermission represents the association between roles and permissions
 type RolePermission struct {
-	RoleID       uuid.UUID `json:"role_id" db:"role_id"`
-	PermissionID uuid.UUID `json:"permission_id" db:"permission_id"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	RoleID       uuid.UUID     `json:"role_id" db:"role_id"`
+	PermissionID uuid.UUID     `json:"permission_id" db:"permission_id"`
+	CreatedAt    timeutil.Time `json:"created_at" db:"created_at"`
 }
 
 // UserRole represents a user's role assignment
 type UserRole struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
-	UserID    string    `json:"user_id" db:"user_id"`
-	RoleID    uuid.UUID `json:"role_id" db:"role_id"`
-	AssignedBy string   `json:"assigned_by" db:"assigned_by"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         uuid.UUID         `json:"id" db:"id"`
+	TenantID   uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	UserID     string            `json:"user_id" db:"user_id"`
+	RoleID     uuid.UUID         `json:"role_id" db:"role_id"`
+	AssignedBy string            `json:"assigned_by" db:"assigned_by"`
+	ActiveFrom timeutil.NullTime `json:"active_from,omitempty" db:"active_from"` // assignment is ignored before this time
+	ExpiresAt  timeutil.NullTime `json:"expires_at,omitempty" db:"expires_at"`   // assignment is ignored from this time
+	CreatedAt  timeutil.Time     `json:"created_at" db:"created_at"`
 }
 
 // RoleWithPermissions includes role with its permissions
@@ -62,24 +65,43 @@ type UserRoleWithDetails struct {
 
 // CreateRoleRequest represents role creation request
 type CreateRoleRequest struct {
-	Name        string   `json:"name" validate:"required,min=2,max=50"`
-	Description string   `json:"description,omitempty" validate:"omitempty,max=255"`
-	IsDefault   bool     `json:"is_default,omitempty"`
-	Permissions []string `json:"permissions,omitempty"` // Permission IDs
+	Name          string   `json:"name" validate:"required,min=2,max=50"`
+	Description   string   `json:"description,omitempty" validate:"omitempty,max=255"`
+	IsDefault     bool     `json:"is_default,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`    // Permission IDs
+	ApplyDefaults bool     `json:"apply_defaults,omitempty"` // with no permissions, grant the configured baseline
 }
 
-// UpdateRoleRequest represents role update request
+// UpdateRoleRequest represents a full role replacement (PUT); omitted fields
+// are reset and the permission set is replaced
 type UpdateRoleRequest struct {
-	Name        string   `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
 	Description string   `json:"description,omitempty" validate:"omitempty,max=255"`
-	IsDefault   *bool    `json:"is_default,omitempty"`
-	Permissions []string `json:"permissions,omitempty"` // Permission IDs to replace existing
+	IsDefault   bool     `json:"is_default"`
+	Permissions []string `json:"permissions" validate:"dive,uuid"` // Permission IDs to replace existing
+}
+
+// SystemRolePermissionsRequest replaces a system role's permission set
+// (internal use by seeding and migrations); the reason is recorded in the audit log
+type SystemRolePermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"dive,uuid"`
+	Reason      string   `json:"reason" validate:"required,min=3,max=500"`
+}
+
+// PatchRoleRequest represents a partial role update (PATCH); nil fields are left unchanged
+type PatchRoleRequest struct {
+	Name        *string   `json:"name,omitempty" validate:"omitempty,min=2,max=50"`
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=255"`
+	IsDefault   *bool     `json:"is_default,omitempty"`
+	Permissions *[]string `json:"permissions,omitempty"` // Permission IDs to replace existing
 }
 
 // AssignRoleRequest represents role assignment request
 type AssignRoleRequest struct {
-	UserID string `json:"user_id" validate:"required"`
-	RoleID string `json:"role_id" validate:"required,uuid"`
+	UserID     string `json:"user_id" validate:"required"`
+	RoleID     string `json:"role_id" validate:"required,uuid"`
+	ActiveFrom string `json:"active_from,omitempty" validate:"omitempty,rfc3339"`
+	ExpiresAt  string `json:"expires_at,omitempty" validate:"omitempty,rfc3339"`
 }
 
 // CheckPermissionRequest represents permission check request
@@ -97,6 +119,7 @@ type CheckPermissionResponse struct {
 	Action      string   `json:"action"`
 	Roles       []string `json:"roles,omitempty"`
 	Permissions []string `json:"permissions,omitempty"`
+	MatchedRole string   `json:"matched_role,omitempty"` // role that granted the permission
 }
 
 // CreatePermissionRequest represents permission creation request
@@ -107,6 +130,24 @@ type CreatePermissionRequest struct {
 	Description string `json:"description,omitempty" validate:"omitempty,max=255"`
 }
 
+// MaxBulkPermissionBatchSize is the maximum number of permissions per bulk create
+const MaxBulkPermissionBatchSize = 500
+
+// BulkCreatePermissionsRequest creates several permissions at once (seeding,
+// admin imports); permissions whose resource and action already exist are skipped
+type BulkCreatePermissionsRequest struct {
+	Permissions []CreatePermissionRequest `json:"permissions" validate:"required,dive"`
+}
+
+// BulkCreatePermissionsResponse reports which permissions were created and
+// which already existed (as "resource:action")
+type BulkCreatePermissionsResponse struct {
+	Created     int          `json:"created"`
+	Skipped     int          `json:"skipped"`
+	Permissions []Permission `json:"permissions"`
+	SkippedKeys []string     `json:"skipped_keys"`
+}
+
 // ListRolesParams represents query parameters for listing roles
 type ListRolesParams struct {
 	IsSystem  string `json:"is_system,omitempty" form:"is_system"`
@@ -143,12 +184,14 @@ func (p *ListRolesParams) GetOffset() int {
 
 // ListPermissionsParams represents query parameters for listing permissions
 type ListPermissionsParams struct {
-	Resource  string `json:"resource,omitempty" form:"resource"`
-	Action    string `json:"action,omitempty" form:"action"`
-	Page      int    `json:"page" form:"page" validate:"omitempty,gte=1"`
-	Limit     int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
-	SortBy    string `json:"sort_by,omitempty" form:"sort_by"`
-	SortOrder string `json:"sort_order,omitempty" form:"sort_order" validate:"omitempty,oneof=asc desc"`
+	Resource  string   `json:"resource,omitempty" form:"resource"`
+	Resources []string `json:"resources,omitempty" form:"resources" validate:"omitempty,max=50"`
+	Action    string   `json:"action,omitempty" form:"action"`
+	Query     string   `json:"q,omitempty" form:"q" validate:"omitempty,max=100"` // matches name or description
+	Page      int      `json:"page" form:"page" validate:"omitempty,gte=1"`
+	Limit     int      `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
+	SortBy    string   `json:"sort_by,omitempty" form:"sort_by" validate:"omitempty,oneof=name resource action created_at"`
+	SortOrder string   `json:"sort_order,omitempty" form:"sort_order" validate:"omitempty,oneof=asc desc"`
 }
 
 // Normalize sets default values for list parameters
@@ -175,6 +218,51 @@ func (p *ListPermissionsParams) GetOffset() int {
 	return (p.Page - 1) * p.Limit
 }
 
+// PermissionDecision records the outcome of a permission check
+type PermissionDecision struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	TenantID    uuid.UUID      `json:"tenant_id" db:"tenant_id"`
+	UserID      string         `json:"user_id" db:"user_id"`
+	Resource    string         `json:"resource" db:"resource"`
+	Action      string         `json:"action" db:"action"`
+	Allowed     bool           `json:"allowed" db:"allowed"`
+	MatchedRole sql.NullString `json:"matched_role,omitempty" db:"matched_role"`
+	Cached      bool           `json:"cached" db:"cached"` // served from the permission cache
+	CheckedBy   string         `json:"checked_by,omitempty" db:"checked_by"`
+	RequestID   string         `json:"request_id,omitempty" db:"request_id"`
+	CreatedAt   timeutil.Time  `json:"created_at" db:"created_at"`
+}
+
+// ListDecisionsParams represents query parameters for listing permission decisions
+type ListDecisionsParams struct {
+	UserID   string `json:"user_id,omitempty" form:"user_id"`
+	Resource string `json:"resource,omitempty" form:"resource"`
+	Action   string `json:"action,omitempty" form:"action"`
+	Allowed  string `json:"allowed,omitempty" form:"allowed" validate:"omitempty,oneof=true false"`
+	From     string `json:"from,omitempty" form:"from" validate:"omitempty,rfc3339"`
+	To       string `json:"to,omitempty" form:"to" validate:"omitempty,rfc3339"`
+	Page     int    `json:"page" form:"page" validate:"omitempty,gte=1"`
+	Limit    int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Normalize sets default values for list parameters
+func (p *ListDecisionsParams) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = 50
+	}
+	if p.Limit > 100 {
+		p.Limit = 100
+	}
+}
+
+// GetOffset calculates the database offset
+func (p *ListDecisionsParams) GetOffset() int {
+	return (p.Page - 1) * p.Limit
+}
+
 // RBACStats represents RBAC statistics
 type RBACStats struct {
 	TotalRoles       int64            `json:"total_roles"`
@@ -185,15 +273,37 @@ type RBACStats struct {
 	RoleDistribution map[string]int64 `json:"role_distribution"` // role_name -> count
 }
 
-// BulkAssignRoleRequest represents bulk role assignment
+// MaxBulkAssignBatchSize is the maximum number of users per bulk assignment
+const MaxBulkAssignBatchSize = 100
+
+// BulkAssignRoleRequest represents bulk role assignment. With
+// VerifyMembership set, users who are not members of the tenant fail
+// instead of being assigned.
 type BulkAssignRoleRequest struct {
-	UserIDs []string `json:"user_ids" validate:"required,min=1,max=100"`
-	RoleID  string   `json:"role_id" validate:"required,uuid"`
+	UserIDs          []string `json:"user_ids" validate:"required"`
+	RoleID           string   `json:"role_id" validate:"required,uuid"`
+	ActiveFrom       string   `json:"active_from,omitempty" validate:"omitempty,rfc3339"`
+	ExpiresAt        string   `json:"expires_at,omitempty" validate:"omitempty,rfc3339"`
+	VerifyMembership bool     `json:"verify_membership"`
+}
+
+// Bulk assignment outcomes
+const (
+	AssignStatusAssigned = "assigned"
+	AssignStatusSkipped  = "skipped" // user already holds the role
+	AssignStatusFailed   = "failed"
+)
+
+// BulkAssignResult represents the outcome for a single user
+type BulkAssignResult struct {
+	bulk.Result
+	Status string `json:"status"`
 }
 
-// BulkAssignRoleResponse represents bulk assignment response
+// BulkAssignRoleResponse represents bulk assignment response. Skipped users
+// count as succeeded; Assigned and Skipped split that count.
 type BulkAssignRoleResponse struct {
-	Assigned int      `json:"assigned"`
-	Failed   int      `json:"failed"`
-	Errors   []string `json:"errors,omitempty"`
+	*bulk.Response[BulkAssignResult]
+	Assigned int `json:"assigned"`
+	Skipped  int `json:"skipped"`
 }
@@ -8,8 +8,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/retention"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/models"
 	"go.uber.org/zap"
 )
@@ -52,7 +55,7 @@ func (r *Repository) CreateRole(ctx context.Context, role *models.Role) error {
 
 	if err != nil {
 		r.logger.Error("failed to create role", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create role")
+		return database.WrapError("failed to create role", err)
 	}
 
 	return nil
@@ -239,7 +242,7 @@ func (r *Repository) UpdateRole(ctx context.Context, tenantID, roleID uuid.UUID,
 	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to update role", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update role")
+		return database.WrapError("failed to update role", err)
 	}
 
 	rows, _ := result.RowsAffected()
@@ -287,12 +290,83 @@ func (r *Repository) CreatePermission(ctx context.Context, permission *models.Pe
 
 	if err != nil {
 		r.logger.Error("failed to create permission", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create permission")
+		return database.WrapError("failed to create permission", err)
 	}
 
 	return nil
 }
 
+// CreatePermissions inserts permissions in one transaction, skipping those
+// whose (resource, action) already exists, including earlier entries of the
+// same batch. Returns the permissions that were inserted.
+func (r *Repository) CreatePermissions(ctx context.Context, permissions []models.Permission) ([]models.Permission, error) {
+	query := `
+		INSERT INTO permissions (id, name, resource, action, description, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (resource, action) DO NOTHING
+		RETURNING id`
+
+	var created []models.Permission
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, permission := range permissions {
+			var id uuid.UUID
+			err := tx.QueryRowContext(ctx, query,
+				permission.ID,
+				permission.Name,
+				permission.Resource,
+				permission.Action,
+				permission.Description,
+				permission.CreatedAt,
+			).Scan(&id)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				r.logger.Error("failed to create permission", zap.Error(err))
+				return database.WrapError("failed to create permissions", err)
+			}
+			created = append(created, permission)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetPermissionIDsByKey resolves resource:action keys to permission IDs.
+// Keys without a matching permission are left out of the result.
+func (r *Repository) GetPermissionIDsByKey(ctx context.Context, keys []string) (map[string]uuid.UUID, error) {
+	query := `
+		SELECT id, resource || ':' || action
+		FROM permissions
+		WHERE resource || ':' || action = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		r.logger.Error("failed to resolve permissions", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to resolve permissions")
+	}
+	defer rows.Close()
+
+	ids := make(map[string]uuid.UUID, len(keys))
+	for rows.Next() {
+		var (
+			id  uuid.UUID
+			key string
+		)
+		if err := rows.Scan(&id, &key); err != nil {
+			r.logger.Error("failed to scan permission", zap.Error(err))
+			return nil, errors.New(errors.ErrCodeInternal, "failed to resolve permissions")
+		}
+		ids[key] = id
+	}
+
+	return ids, rows.Err()
+}
+
 // GetPermission retrieves a permission by ID
 func (r *Repository) GetPermission(ctx context.Context, permissionID uuid.UUID) (*models.Permission, error) {
 	query := `
@@ -334,12 +408,24 @@ func (r *Repository) ListPermissions(ctx context.Context, params *models.ListPer
 		argPos++
 	}
 
+	if len(params.Resources) > 0 {
+		where = append(where, fmt.Sprintf("resource = ANY($%d)", argPos))
+		args = append(args, pq.Array(params.Resources))
+		argPos++
+	}
+
 	if params.Action != "" {
 		where = append(where, fmt.Sprintf("action = $%d", argPos))
 		args = append(args, params.Action)
 		argPos++
 	}
 
+	if params.Query != "" {
+		where = append(where, fmt.Sprintf("(name ILIKE $%d OR description ILIKE $%d)", argPos, argPos))
+		args = append(args, "%"+params.Query+"%")
+		argPos++
+	}
+
 	whereClause := "TRUE"
 	if len(where) > 0 {
 		whereClause = strings.Join(where, " AND ")
@@ -407,7 +493,7 @@ func (r *Repository) AssignPermissionsToRole(ctx context.Context, roleID uuid.UU
 	_, err := r.db.ExecContext(ctx, deleteQuery, roleID)
 	if err != nil {
 		r.logger.Error("failed to delete existing permissions", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update permissions")
+		return database.WrapError("failed to update permissions", err)
 	}
 
 	// Then, add new permissions
@@ -464,11 +550,16 @@ func (r *Repository) GetRolePermissions(ctx context.Context, roleID uuid.UUID) (
 
 // User Role operations
 
+// activeAssignment restricts user_roles (aliased ur) to assignments in effect now
+const activeAssignment = `(ur.active_from IS NULL OR ur.active_from <= NOW())
+			AND (ur.expires_at IS NULL OR ur.expires_at > NOW())`
+
 // AssignRoleToUser assigns a role to a user
 func (r *Repository) AssignRoleToUser(ctx context.Context, userRole *models.UserRole) error {
 	query := `
-		INSERT INTO user_roles (id, tenant_id, user_id, role_id, assigned_by, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)`
+		INSERT INTO user_roles (id, tenant_id, user_id, role_id, assigned_by,
+			active_from, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		userRole.ID,
@@ -476,17 +567,47 @@ func (r *Repository) AssignRoleToUser(ctx context.Context, userRole *models.User
 		userRole.UserID,
 		userRole.RoleID,
 		userRole.AssignedBy,
+		userRole.ActiveFrom,
+		userRole.ExpiresAt,
 		userRole.CreatedAt,
 	)
 
 	if err != nil {
 		r.logger.Error("failed to assign role to user", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to assign role")
+		return database.WrapError("failed to assign role", err)
 	}
 
 	return nil
 }
 
+// AssignRoleToUserIfAbsent assigns a role to a user unless the user already
+// holds it; it reports whether a new assignment was created
+func (r *Repository) AssignRoleToUserIfAbsent(ctx context.Context, userRole *models.UserRole) (bool, error) {
+	query := `
+		INSERT INTO user_roles (id, tenant_id, user_id, role_id, assigned_by,
+			active_from, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (tenant_id, user_id, role_id) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query,
+		userRole.ID,
+		userRole.TenantID,
+		userRole.UserID,
+		userRole.RoleID,
+		userRole.AssignedBy,
+		userRole.ActiveFrom,
+		userRole.ExpiresAt,
+		userRole.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to assign role to user", zap.Error(err))
+		return false, database.WrapError("failed to assign role", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
 // RemoveRoleFromUser removes a role from a user
 func (r *Repository) RemoveRoleFromUser(ctx context.Context, tenantID uuid.UUID, userID string, roleID uuid.UUID) error {
 	query := `DELETE FROM user_roles WHERE tenant_id = $1 AND user_id = $2 AND role_id = $3`
@@ -512,7 +633,7 @@ func (r *Repository) GetUserRoles(ctx context.Context, tenantID uuid.UUID, userI
 			r.is_default, r.created_by, r.created_at, r.updated_at
 		FROM roles r
 		INNER JOIN user_roles ur ON r.id = ur.role_id
-		WHERE ur.tenant_id = $1 AND ur.user_id = $2
+		WHERE ur.tenant_id = $1 AND ur.user_id = $2 AND `+activeAssignment+`
 		ORDER BY r.name`
 
 	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
@@ -553,7 +674,7 @@ func (r *Repository) GetUserPermissions(ctx context.Context, tenantID uuid.UUID,
 		FROM permissions p
 		INNER JOIN role_permissions rp ON p.id = rp.permission_id
 		INNER JOIN user_roles ur ON rp.role_id = ur.role_id
-		WHERE ur.tenant_id = $1 AND ur.user_id = $2
+		WHERE ur.tenant_id = $1 AND ur.user_id = $2 AND `+activeAssignment+`
 		ORDER BY p.resource, p.action`
 
 	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
@@ -596,6 +717,7 @@ func (r *Repository) CheckUserPermission(ctx context.Context, tenantID uuid.UUID
 				AND ur.user_id = $2
 				AND p.resource = $3
 				AND p.action = $4
+				AND `+activeAssignment+`
 		)`
 
 	var exists bool
@@ -608,6 +730,234 @@ func (r *Repository) CheckUserPermission(ctx context.Context, tenantID uuid.UUID
 	return exists, nil
 }
 
+// GetScheduledUserRoles retrieves a user's assignments that are not active yet
+func (r *Repository) GetScheduledUserRoles(ctx context.Context, tenantID uuid.UUID, userID string) ([]models.UserRoleWithDetails, error) {
+	query := `
+		SELECT ur.id, ur.tenant_id, ur.user_id, ur.role_id, ur.assigned_by,
+			ur.active_from, ur.expires_at, ur.created_at, r.name, r.description
+		FROM user_roles ur
+		INNER JOIN roles r ON r.id = ur.role_id
+		WHERE ur.tenant_id = $1 AND ur.user_id = $2 AND ur.active_from > NOW()
+		ORDER BY ur.active_from`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, userID)
+	if err != nil {
+		r.logger.Error("failed to get scheduled user roles", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to get scheduled roles")
+	}
+	defer rows.Close()
+
+	var userRoles []models.UserRoleWithDetails
+	for rows.Next() {
+		var userRole models.UserRoleWithDetails
+		var description sql.NullString
+		err := rows.Scan(
+			&userRole.ID,
+			&userRole.TenantID,
+			&userRole.UserID,
+			&userRole.RoleID,
+			&userRole.AssignedBy,
+			&userRole.ActiveFrom,
+			&userRole.ExpiresAt,
+			&userRole.CreatedAt,
+			&userRole.RoleName,
+			&description,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan user role", zap.Error(err))
+			continue
+		}
+		userRole.RoleDescription = description.String
+		userRoles = append(userRoles, userRole)
+	}
+
+	return userRoles, nil
+}
+
+// GetNextAssignmentChange returns the nearest future time at which one of the
+// user's assignments becomes active or expires
+func (r *Repository) GetNextAssignmentChange(ctx context.Context, tenantID uuid.UUID, userID string) (sql.NullTime, error) {
+	query := `
+		SELECT MIN(boundary) FROM (
+			SELECT active_from AS boundary FROM user_roles
+			WHERE tenant_id = $1 AND user_id = $2 AND active_from > NOW()
+			UNION ALL
+			SELECT expires_at FROM user_roles
+			WHERE tenant_id = $1 AND user_id = $2 AND expires_at > NOW()
+		) boundaries`
+
+	var next sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, tenantID, userID).Scan(&next); err != nil {
+		r.logger.Error("failed to get next assignment change", zap.Error(err))
+		return sql.NullTime{}, errors.New(errors.ErrCodeInternal, "failed to get role assignments")
+	}
+
+	return next, nil
+}
+
+// GetMatchingRole returns the name of a role that grants the user the permission
+func (r *Repository) GetMatchingRole(ctx context.Context, tenantID uuid.UUID, userID, resource, action string) (string, error) {
+	query := `
+		SELECT r.name
+		FROM roles r
+		INNER JOIN user_roles ur ON r.id = ur.role_id
+		INNER JOIN role_permissions rp ON rp.role_id = r.id
+		INNER JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.tenant_id = $1
+			AND ur.user_id = $2
+			AND p.resource = $3
+			AND p.action = $4
+			AND `+activeAssignment+`
+		ORDER BY r.is_system DESC, r.name
+		LIMIT 1`
+
+	var name string
+	err := r.db.QueryRowContext(ctx, query, tenantID, userID, resource, action).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		r.logger.Error("failed to get matching role", zap.Error(err))
+		return "", errors.New(errors.ErrCodeInternal, "failed to get matching role")
+	}
+
+	return name, nil
+}
+
+// Permission decision operations
+
+// CreatePermissionDecision records a permission check outcome
+func (r *Repository) CreatePermissionDecision(ctx context.Context, decision *models.PermissionDecision) error {
+	query := `
+		INSERT INTO permission_decisions (id, tenant_id, user_id, resource, action,
+			allowed, matched_role, cached, checked_by, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		decision.ID,
+		decision.TenantID,
+		decision.UserID,
+		decision.Resource,
+		decision.Action,
+		decision.Allowed,
+		decision.MatchedRole,
+		decision.Cached,
+		decision.CheckedBy,
+		decision.RequestID,
+		decision.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("failed to record permission decision", zap.Error(err))
+		return errors.New(errors.ErrCodeInternal, "failed to record permission decision")
+	}
+
+	return nil
+}
+
+// ListPermissionDecisions retrieves recorded permission decisions with filtering and pagination
+func (r *Repository) ListPermissionDecisions(ctx context.Context, tenantID uuid.UUID, params *models.ListDecisionsParams) ([]models.PermissionDecision, int64, error) {
+	// Build WHERE clause
+	where := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	argPos := 2
+
+	if params.UserID != "" {
+		where = append(where, fmt.Sprintf("user_id = $%d", argPos))
+		args = append(args, params.UserID)
+		argPos++
+	}
+
+	if params.Resource != "" {
+		where = append(where, fmt.Sprintf("resource = $%d", argPos))
+		args = append(args, params.Resource)
+		argPos++
+	}
+
+	if params.Action != "" {
+		where = append(where, fmt.Sprintf("action = $%d", argPos))
+		args = append(args, params.Action)
+		argPos++
+	}
+
+	if params.Allowed != "" {
+		where = append(where, fmt.Sprintf("allowed = $%d", argPos))
+		args = append(args, params.Allowed == "true")
+		argPos++
+	}
+
+	if params.From != "" {
+		if from, err := timeutil.Parse(params.From); err == nil {
+			where = append(where, fmt.Sprintf("created_at >= $%d", argPos))
+			args = append(args, from)
+			argPos++
+		}
+	}
+
+	if params.To != "" {
+		if to, err := timeutil.Parse(params.To); err == nil {
+			where = append(where, fmt.Sprintf("created_at < $%d", argPos))
+			args = append(args, to)
+			argPos++
+		}
+	}
+
+	whereClause := strings.Join(where, " AND ")
+
+	// Get total count
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM permission_decisions WHERE %s", whereClause)
+	var total int64
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		r.logger.Error("failed to count permission decisions", zap.Error(err))
+		return nil, 0, errors.New(errors.ErrCodeInternal, "failed to count permission decisions")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, tenant_id, user_id, resource, action, allowed,
+			matched_role, cached, checked_by, request_id, created_at
+		FROM permission_decisions
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`,
+		whereClause,
+		argPos,
+		argPos+1,
+	)
+
+	args = append(args, params.Limit, params.GetOffset())
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to list permission decisions", zap.Error(err))
+		return nil, 0, errors.New(errors.ErrCodeInternal, "failed to list permission decisions")
+	}
+	defer rows.Close()
+
+	var decisions []models.PermissionDecision
+	for rows.Next() {
+		var decision models.PermissionDecision
+		err := rows.Scan(
+			&decision.ID,
+			&decision.TenantID,
+			&decision.UserID,
+			&decision.Resource,
+			&decision.Action,
+			&decision.Allowed,
+			&decision.MatchedRole,
+			&decision.Cached,
+			&decision.CheckedBy,
+			&decision.RequestID,
+			&decision.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan permission decision", zap.Error(err))
+			continue
+		}
+		decisions = append(decisions, decision)
+	}
+
+	return decisions, total, nil
+}
+
 // GetRBACStats retrieves RBAC statistics for a tenant
 func (r *Repository) GetRBACStats(ctx context.Context, tenantID uuid.UUID) (*models.RBACStats, error) {
 	stats := &models.RBACStats{
@@ -673,3 +1023,55 @@ func (r *Repository) GetRBACStats(ctx context.Context, tenantID uuid.UUID) (*mod
 
 	return stats, nil
 }
+
+// PurgeExpiredPermissionDecisions deletes up to limit permission decisions older than their retention
+// window: window, or the tenant's longer permission_decisions_retention_days setting
+func (r *Repository) PurgeExpiredPermissionDecisions(ctx context.Context, window time.Duration, limit int) (int64, error) {
+	query := `
+		DELETE FROM permission_decisions
+		WHERE id IN (
+			SELECT d.id
+			FROM permission_decisions d
+			WHERE d.created_at < ` + retention.CutoffSQL("d.tenant_id", "permission_decisions_retention_days", 1) + `
+			LIMIT $2
+		)`
+
+	result, err := r.db.ExecContext(ctx, query, retention.Window(window), limit)
+	if err != nil {
+		r.logger.Error("failed to purge expired permission decisions", zap.Error(err))
+		return 0, database.WrapError("failed to purge permission decisions", err)
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
+
+// PurgeTenant deletes all roles, including system roles, with their assignments and decision logs of a tenant in a single transaction and
+// returns the number of rows removed
+func (r *Repository) PurgeTenant(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	statements := []string{
+		`DELETE FROM user_roles WHERE tenant_id = $1`,
+		`DELETE FROM role_permissions WHERE role_id IN (SELECT id FROM roles WHERE tenant_id = $1)`,
+		`DELETE FROM roles WHERE tenant_id = $1`,
+		`DELETE FROM permission_decisions WHERE tenant_id = $1`,
+	}
+
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			result, err := tx.ExecContext(ctx, statement, tenantID)
+			if err != nil {
+				r.logger.Error("failed to purge tenant roles", zap.Error(err))
+				return errors.New(errors.ErrCodeInternal, "failed to purge tenant roles")
+			}
+			rows, _ := result.RowsAffected()
+			deleted += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
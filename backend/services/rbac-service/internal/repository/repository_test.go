@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestActiveAssignmentBoundaries(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"no activation time is active", "ur.active_from IS NULL"},
+		{"active from the activation instant on", "ur.active_from <= NOW()"},
+		{"no expiry never expires", "ur.expires_at IS NULL"},
+		{"expired from the expiry instant on", "ur.expires_at > NOW()"},
+	}
+	clause := strings.Join(strings.Fields(activeAssignment), " ")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(clause, tt.want) {
+				t.Errorf("activeAssignment = %q, missing %q", clause, tt.want)
+			}
+		})
+	}
+}
@@ -2,13 +2,20 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"math/rand/v2"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/models"
 	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/repository"
 	"go.uber.org/zap"
@@ -18,21 +25,41 @@ const (
 	roleCacheTTL       = 1 * time.Hour
 	permissionCacheTTL = 2 * time.Hour
 	userRoleCacheTTL   = 30 * time.Minute
+
+	decisionWriteTimeout = 5 * time.Second
 )
 
 // Service handles RBAC business logic
 type Service struct {
-	repo   *repository.Repository
-	cache  *cache.Cache
-	logger *zap.Logger
+	repo      *repository.Repository
+	cache     *cache.Cache
+	tenants   *client.TenantClient
+	decisions *decisionRecorder // nil when decision logging is disabled
+	roles     config.RolesConfig
+	logger    *zap.Logger
 }
 
 // NewService creates a new RBAC service
-func NewService(repo *repository.Repository, cache *cache.Cache, logger *zap.Logger) *Service {
-	return &Service{
-		repo:   repo,
-		cache:  cache,
-		logger: logger,
+func NewService(repo *repository.Repository, cache *cache.Cache, tenants *client.TenantClient, decisionLog config.DecisionLogConfig, roles config.RolesConfig, logger *zap.Logger) *Service {
+	s := &Service{
+		repo:    repo,
+		cache:   cache,
+		tenants: tenants,
+		roles:   roles,
+		logger:  logger,
+	}
+
+	if decisionLog.Enabled {
+		s.decisions = newDecisionRecorder(repo, decisionLog, logger)
+	}
+
+	return s
+}
+
+// Close flushes pending permission decisions; call after the server stops accepting requests
+func (s *Service) Close() {
+	if s.decisions != nil {
+		s.decisions.close()
 	}
 }
 
@@ -57,8 +84,8 @@ func (s *Service) CreateRole(ctx context.Context, req *models.CreateRoleRequest)
 		IsSystem:  false,
 		IsDefault: req.IsDefault,
 		CreatedBy: userID,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: timeutil.Now(),
+		UpdatedAt: timeutil.Now(),
 	}
 
 	if req.Description != "" {
@@ -83,6 +110,14 @@ func (s *Service) CreateRole(ctx context.Context, req *models.CreateRoleRequest)
 		if len(permIDs) > 0 {
 			_ = s.repo.AssignPermissionsToRole(ctx, role.ID, permIDs)
 		}
+	} else if req.ApplyDefaults {
+		permIDs, err := s.defaultRolePermissions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(permIDs) > 0 {
+			_ = s.repo.AssignPermissionsToRole(ctx, role.ID, permIDs)
+		}
 	}
 
 	logger.InfoContext(ctx, "role created",
@@ -93,6 +128,31 @@ func (s *Service) CreateRole(ctx context.Context, req *models.CreateRoleRequest)
 	return role, nil
 }
 
+// defaultRolePermissions resolves the configured baseline permissions to IDs.
+// Entries naming a permission that does not exist are skipped with a warning.
+func (s *Service) defaultRolePermissions(ctx context.Context) ([]uuid.UUID, error) {
+	if len(s.roles.DefaultPermissions) == 0 {
+		return nil, nil
+	}
+
+	ids, err := s.repo.GetPermissionIDsByKey(ctx, s.roles.DefaultPermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	permIDs := make([]uuid.UUID, 0, len(ids))
+	for _, key := range s.roles.DefaultPermissions {
+		id, ok := ids[key]
+		if !ok {
+			logger.WarnContext(ctx, "default role permission does not exist", zap.String("permission", key))
+			continue
+		}
+		permIDs = append(permIDs, id)
+	}
+
+	return permIDs, nil
+}
+
 // GetRole retrieves a role by ID
 func (s *Service) GetRole(ctx context.Context, roleID uuid.UUID) (*models.Role, error) {
 	tenantID := getTenantID(ctx)
@@ -152,8 +212,52 @@ func (s *Service) ListRoles(ctx context.Context, params *models.ListRolesParams)
 	return roles, total, nil
 }
 
-// UpdateRole updates a role
+// UpdateRole replaces a role's fields and permission set
 func (s *Service) UpdateRole(ctx context.Context, roleID uuid.UUID, req *models.UpdateRoleRequest) error {
+	updates := map[string]interface{}{
+		"name":        req.Name,
+		"description": sql.NullString{String: req.Description, Valid: req.Description != ""},
+		"is_default":  req.IsDefault,
+	}
+
+	permissions := req.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return s.applyRoleUpdates(ctx, roleID, updates, permissions)
+}
+
+// PatchRole applies a partial update; only provided fields change
+func (s *Service) PatchRole(ctx context.Context, roleID uuid.UUID, req *models.PatchRoleRequest) error {
+	updates := make(map[string]interface{})
+
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+
+	if req.Description != nil {
+		updates["description"] = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+	}
+
+	if req.IsDefault != nil {
+		updates["is_default"] = *req.IsDefault
+	}
+
+	var permissions []string
+	if req.Permissions != nil {
+		permissions = *req.Permissions
+		if permissions == nil {
+			permissions = []string{}
+		}
+	}
+
+	return s.applyRoleUpdates(ctx, roleID, updates, permissions)
+}
+
+// applyRoleUpdates persists role changes; a nil permissions slice leaves
+// the role's permissions untouched
+func (s *Service) applyRoleUpdates(ctx context.Context, roleID uuid.UUID, updates map[string]interface{}, permissions []string) error {
 	tenantID := getTenantID(ctx)
 
 	// Verify role exists
@@ -167,24 +271,12 @@ func (s *Service) UpdateRole(ctx context.Context, roleID uuid.UUID, req *models.
 		return errors.Forbiddenf("cannot modify system role")
 	}
 
-	// Build updates map
-	updates := make(map[string]interface{})
-
-	if req.Name != "" {
+	if name, ok := updates["name"].(string); ok {
 		// Check if new name already exists
-		existing, _ := s.repo.GetRoleByName(ctx, tenantID, req.Name)
+		existing, _ := s.repo.GetRoleByName(ctx, tenantID, name)
 		if existing != nil && existing.ID != roleID {
-			return errors.Conflictf("role with name '%s' already exists", req.Name)
+			return errors.Conflictf("role with name '%s' already exists", name)
 		}
-		updates["name"] = req.Name
-	}
-
-	if req.Description != "" {
-		updates["description"] = req.Description
-	}
-
-	if req.IsDefault != nil {
-		updates["is_default"] = *req.IsDefault
 	}
 
 	// Update role
@@ -194,13 +286,13 @@ func (s *Service) UpdateRole(ctx context.Context, roleID uuid.UUID, req *models.
 		}
 	}
 
-	// Update permissions if provided
-	if len(req.Permissions) > 0 {
-		permIDs := make([]uuid.UUID, 0, len(req.Permissions))
-		for _, permIDStr := range req.Permissions {
+	// Replace permissions if provided
+	if permissions != nil {
+		permIDs := make([]uuid.UUID, 0, len(permissions))
+		for _, permIDStr := range permissions {
 			permID, err := uuid.Parse(permIDStr)
 			if err != nil {
-				continue
+				return errors.Validationf("invalid permission ID: %s", permIDStr)
 			}
 			permIDs = append(permIDs, permID)
 		}
@@ -218,6 +310,63 @@ func (s *Service) UpdateRole(ctx context.Context, roleID uuid.UUID, req *models.
 	return nil
 }
 
+// UpdateSystemRolePermissions replaces the permission set of a system role
+// (internal use). It is the only path that may change a system role; the
+// public UpdateRole and PatchRole keep rejecting them. Every change is written
+// to the audit log with the caller, the reason and the before/after sets.
+func (s *Service) UpdateSystemRolePermissions(ctx context.Context, roleID uuid.UUID, req *models.SystemRolePermissionsRequest) (*models.RoleWithPermissions, error) {
+	tenantID := getTenantID(ctx)
+
+	role, err := s.repo.GetRole(ctx, tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.IsSystem {
+		return nil, errors.Validationf("role is not a system role")
+	}
+
+	permIDs := make([]uuid.UUID, 0, len(req.Permissions))
+	for _, permIDStr := range req.Permissions {
+		permID, err := uuid.Parse(permIDStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid permission ID: %s", permIDStr)
+		}
+		permIDs = append(permIDs, permID)
+	}
+
+	previous, err := s.repo.GetRolePermissions(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AssignPermissionsToRole(ctx, roleID, permIDs); err != nil {
+		return nil, err
+	}
+
+	cacheKey := cache.TenantKey(tenantID.String(), "role", roleID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	permissions, err := s.repo.GetRolePermissions(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WarnContext(ctx, "audit: system role permissions updated",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("role_id", roleID.String()),
+		zap.String("role", role.Name),
+		zap.String("requested_by", middleware.GetUserID(ctx)),
+		zap.String("reason", req.Reason),
+		zap.Strings("previous_permissions", permissionNames(previous)),
+		zap.Strings("permissions", permissionNames(permissions)),
+	)
+
+	return &models.RoleWithPermissions{
+		Role:        *role,
+		Permissions: permissions,
+	}, nil
+}
+
 // DeleteRole deletes a role
 func (s *Service) DeleteRole(ctx context.Context, roleID uuid.UUID) error {
 	tenantID := getTenantID(ctx)
@@ -254,7 +403,7 @@ func (s *Service) CreatePermission(ctx context.Context, req *models.CreatePermis
 		Name:      req.Name,
 		Resource:  req.Resource,
 		Action:    req.Action,
-		CreatedAt: time.Now(),
+		CreatedAt: timeutil.Now(),
 	}
 
 	if req.Description != "" {
@@ -274,6 +423,56 @@ func (s *Service) CreatePermission(ctx context.Context, req *models.CreatePermis
 	return permission, nil
 }
 
+// BulkCreatePermissions creates a batch of permissions. Entries are validated
+// before the call, so the whole batch is rejected on a malformed entry, while
+// permissions that already exist are skipped rather than failing the batch.
+func (s *Service) BulkCreatePermissions(ctx context.Context, req *models.BulkCreatePermissionsRequest) (*models.BulkCreatePermissionsResponse, error) {
+	permissions := make([]models.Permission, 0, len(req.Permissions))
+	for _, entry := range req.Permissions {
+		permission := models.Permission{
+			ID:        uuid.New(),
+			Name:      entry.Name,
+			Resource:  entry.Resource,
+			Action:    entry.Action,
+			CreatedAt: timeutil.Now(),
+		}
+		if entry.Description != "" {
+			permission.Description.String = entry.Description
+			permission.Description.Valid = true
+		}
+		permissions = append(permissions, permission)
+	}
+
+	created, err := s.repo.CreatePermissions(ctx, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	createdIDs := make(map[uuid.UUID]bool, len(created))
+	for _, permission := range created {
+		createdIDs[permission.ID] = true
+	}
+
+	result := &models.BulkCreatePermissionsResponse{
+		Created:     len(created),
+		Permissions: created,
+		SkippedKeys: []string{},
+	}
+	for _, permission := range permissions {
+		if !createdIDs[permission.ID] {
+			result.SkippedKeys = append(result.SkippedKeys, permission.Resource+":"+permission.Action)
+		}
+	}
+	result.Skipped = len(result.SkippedKeys)
+
+	logger.InfoContext(ctx, "permissions bulk created",
+		zap.Int("created", result.Created),
+		zap.Int("skipped", result.Skipped),
+	)
+
+	return result, nil
+}
+
 // GetPermission retrieves a permission by ID
 func (s *Service) GetPermission(ctx context.Context, permissionID uuid.UUID) (*models.Permission, error) {
 	return s.repo.GetPermission(ctx, permissionID)
@@ -304,6 +503,11 @@ func (s *Service) AssignRole(ctx context.Context, req *models.AssignRoleRequest)
 		return errors.Validationf("invalid role_id")
 	}
 
+	activeFrom, expiresAt, err := parseAssignmentWindow(req.ActiveFrom, req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
 	// Verify role exists
 	if _, err := s.repo.GetRole(ctx, tenantID, roleID); err != nil {
 		return err
@@ -316,7 +520,9 @@ func (s *Service) AssignRole(ctx context.Context, req *models.AssignRoleRequest)
 		UserID:     req.UserID,
 		RoleID:     roleID,
 		AssignedBy: assignedBy,
-		CreatedAt:  time.Now(),
+		ActiveFrom: activeFrom,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  timeutil.Now(),
 	}
 
 	if err := s.repo.AssignRoleToUser(ctx, userRole); err != nil {
@@ -346,36 +552,90 @@ func (s *Service) BulkAssignRole(ctx context.Context, req *models.BulkAssignRole
 		return nil, errors.Validationf("invalid role_id")
 	}
 
+	activeFrom, expiresAt, err := parseAssignmentWindow(req.ActiveFrom, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify role exists
 	if _, err := s.repo.GetRole(ctx, tenantID, roleID); err != nil {
 		return nil, err
 	}
 
 	response := &models.BulkAssignRoleResponse{
-		Errors: []string{},
+		Response: bulk.NewResponse[models.BulkAssignResult](len(req.UserIDs)),
+	}
+	fail := func(userID, reason string) {
+		response.Add(models.BulkAssignResult{
+			Result: bulk.Result{ID: userID, Error: reason},
+			Status: models.AssignStatusFailed,
+		}, false)
 	}
 
+	seen := make(map[string]bool, len(req.UserIDs))
 	for _, userID := range req.UserIDs {
+		if seen[userID] {
+			fail(userID, "duplicate user in request")
+			continue
+		}
+		seen[userID] = true
+
+		if req.VerifyMembership {
+			isMember, err := s.tenants.IsMember(ctx, tenantID.String(), userID)
+			if err != nil {
+				fail(userID, errors.FromError(err).Message)
+				continue
+			}
+			if !isMember {
+				fail(userID, "user is not a member of the tenant")
+				continue
+			}
+		}
+
 		userRole := &models.UserRole{
 			ID:         uuid.New(),
 			TenantID:   tenantID,
 			UserID:     userID,
 			RoleID:     roleID,
 			AssignedBy: assignedBy,
-			CreatedAt:  time.Now(),
+			ActiveFrom: activeFrom,
+			ExpiresAt:  expiresAt,
+			CreatedAt:  timeutil.Now(),
 		}
 
-		if err := s.repo.AssignRoleToUser(ctx, userRole); err != nil {
-			response.Failed++
-			response.Errors = append(response.Errors, userID+": "+err.Error())
-		} else {
-			response.Assigned++
-			// Invalidate cache
-			userPermCacheKey := cache.TenantKey(tenantID.String(), "user_permissions", userID)
-			_ = s.cache.Delete(ctx, userPermCacheKey)
+		created, err := s.repo.AssignRoleToUserIfAbsent(ctx, userRole)
+		if err != nil {
+			fail(userID, errors.FromError(err).Message)
+			continue
 		}
+
+		if !created {
+			response.Skipped++
+			response.Add(models.BulkAssignResult{
+				Result: bulk.Result{ID: userID, Success: true},
+				Status: models.AssignStatusSkipped,
+			}, true)
+			continue
+		}
+
+		response.Assigned++
+		response.Add(models.BulkAssignResult{
+			Result: bulk.Result{ID: userID, Success: true},
+			Status: models.AssignStatusAssigned,
+		}, true)
+
+		// Invalidate cache
+		userPermCacheKey := cache.TenantKey(tenantID.String(), "user_permissions", userID)
+		_ = s.cache.Delete(ctx, userPermCacheKey)
 	}
 
+	logger.InfoContext(ctx, "role bulk assigned",
+		zap.String("role_id", req.RoleID),
+		zap.Int("assigned", response.Assigned),
+		zap.Int("skipped", response.Skipped),
+		zap.Int("failed", response.Failed),
+	)
+
 	return response, nil
 }
 
@@ -411,6 +671,18 @@ func (s *Service) GetUserRoles(ctx context.Context, userID string) ([]models.Rol
 	return roles, nil
 }
 
+// GetScheduledRoles retrieves a user's role assignments that start in the future
+func (s *Service) GetScheduledRoles(ctx context.Context, userID string) ([]models.UserRoleWithDetails, error) {
+	tenantID := getTenantID(ctx)
+
+	userRoles, err := s.repo.GetScheduledUserRoles(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return userRoles, nil
+}
+
 // CheckPermission checks if a user has a specific permission
 func (s *Service) CheckPermission(ctx context.Context, req *models.CheckPermissionRequest) (*models.CheckPermissionResponse, error) {
 	tenantID := getTenantID(ctx)
@@ -419,6 +691,7 @@ func (s *Service) CheckPermission(ctx context.Context, req *models.CheckPermissi
 	cacheKey := cache.TenantKey(tenantID.String(), "permission_check", req.UserID, req.Resource, req.Action)
 	var response models.CheckPermissionResponse
 	if err := s.cache.Get(ctx, cacheKey, &response); err == nil {
+		s.recordDecision(ctx, &response, true)
 		return &response, nil
 	}
 
@@ -450,10 +723,16 @@ func (s *Service) CheckPermission(ctx context.Context, req *models.CheckPermissi
 			permNames[i] = perm.Name
 		}
 		response.Permissions = permNames
+
+		response.MatchedRole, _ = s.repo.GetMatchingRole(ctx, tenantID, req.UserID, req.Resource, req.Action)
 	}
 
-	// Cache result
-	_ = s.cache.Set(ctx, cacheKey, &response, userRoleCacheTTL)
+	s.recordDecision(ctx, &response, false)
+
+	// Cache result until the user's next assignment change at the latest
+	if ttl := s.userCacheTTL(ctx, tenantID, req.UserID); ttl > 0 {
+		_ = s.cache.Set(ctx, cacheKey, &response, ttl)
+	}
 
 	return &response, nil
 }
@@ -475,12 +754,56 @@ func (s *Service) GetUserPermissions(ctx context.Context, userID string) ([]mode
 		return nil, err
 	}
 
-	// Cache for future requests
-	_ = s.cache.Set(ctx, cacheKey, permissions, userRoleCacheTTL)
+	// Cache until the user's next assignment change at the latest
+	if ttl := s.userCacheTTL(ctx, tenantID, userID); ttl > 0 {
+		_ = s.cache.Set(ctx, cacheKey, permissions, ttl)
+	}
 
 	return permissions, nil
 }
 
+// ListDecisions retrieves recorded permission decisions
+func (s *Service) ListDecisions(ctx context.Context, params *models.ListDecisionsParams) ([]models.PermissionDecision, int64, error) {
+	tenantID := getTenantID(ctx)
+
+	if _, _, err := validator.ParseTimeParam("from", params.From); err != nil {
+		return nil, 0, err
+	}
+	if _, _, err := validator.ParseTimeParam("to", params.To); err != nil {
+		return nil, 0, err
+	}
+
+	params.Normalize()
+
+	decisions, total, err := s.repo.ListPermissionDecisions(ctx, tenantID, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return decisions, total, nil
+}
+
+// recordDecision hands a permission check outcome to the decision log, if enabled
+func (s *Service) recordDecision(ctx context.Context, resp *models.CheckPermissionResponse, cached bool) {
+	if s.decisions == nil {
+		return
+	}
+
+	s.decisions.record(models.PermissionDecision{
+		ID:          uuid.New(),
+		TenantID:    getTenantID(ctx),
+		UserID:      resp.UserID,
+		Resource:    resp.Resource,
+		Action:      resp.Action,
+		Allowed:     resp.Allowed,
+		MatchedRole: sql.NullString{String: resp.MatchedRole, Valid: resp.MatchedRole != ""},
+		Cached:      cached,
+		CheckedBy:   middleware.GetUserID(ctx),
+		RequestID:   logger.GetRequestID(ctx),
+		CreatedAt:   timeutil.Now(),
+	})
+}
+
 // GetRBACStats retrieves RBAC statistics
 func (s *Service) GetRBACStats(ctx context.Context) (*models.RBACStats, error) {
 	tenantID := getTenantID(ctx)
@@ -493,10 +816,136 @@ func (s *Service) GetRBACStats(ctx context.Context) (*models.RBACStats, error) {
 	return stats, nil
 }
 
+// userCacheTTL caps the permission cache TTL at the time remaining until one of
+// the user's assignments activates or expires; zero means do not cache
+func (s *Service) userCacheTTL(ctx context.Context, tenantID uuid.UUID, userID string) time.Duration {
+	next, err := s.repo.GetNextAssignmentChange(ctx, tenantID, userID)
+	if err != nil {
+		return 0
+	}
+	if !next.Valid {
+		return userRoleCacheTTL
+	}
+	return min(userRoleCacheTTL, time.Until(next.Time))
+}
+
+// decisionRecorder persists permission decisions in the background so checks
+// never wait on the decision log. Allow decisions are sampled; denies are
+// always kept. When the queue is full new decisions are dropped.
+type decisionRecorder struct {
+	repo       *repository.Repository
+	sampleRate float64
+	queue      chan models.PermissionDecision
+	done       chan struct{}
+	logger     *zap.Logger
+}
+
+func newDecisionRecorder(repo *repository.Repository, cfg config.DecisionLogConfig, logger *zap.Logger) *decisionRecorder {
+	r := &decisionRecorder{
+		repo:       repo,
+		sampleRate: cfg.SampleRate,
+		queue:      make(chan models.PermissionDecision, max(cfg.BufferSize, 1)),
+		done:       make(chan struct{}),
+		logger:     logger,
+	}
+	go r.run()
+	return r
+}
+
+func (r *decisionRecorder) record(decision models.PermissionDecision) {
+	if decision.Allowed && rand.Float64() >= r.sampleRate {
+		return
+	}
+
+	select {
+	case r.queue <- decision:
+	default:
+		r.logger.Warn("permission decision log full, dropping decision",
+			zap.String("user_id", decision.UserID),
+			zap.Bool("allowed", decision.Allowed),
+		)
+	}
+}
+
+func (r *decisionRecorder) run() {
+	defer close(r.done)
+	for decision := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), decisionWriteTimeout)
+		_ = r.repo.CreatePermissionDecision(ctx, &decision)
+		cancel()
+	}
+}
+
+func (r *decisionRecorder) close() {
+	close(r.queue)
+	<-r.done
+}
+
+// PurgeTenant deletes all roles, role assignments and permission decisions of the tenant in ctx (internal use,
+// called by tenant deletion); it is safe to call again after a partial run
+func (s *Service) PurgeTenant(ctx context.Context) (int64, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return 0, errors.Validationf("tenant is required")
+	}
+
+	deleted, err := s.repo.PurgeTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.InfoContext(ctx, "tenant roles purged",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int64("deleted", deleted),
+	)
+
+	return deleted, nil
+}
+
 // Helper functions
 
+// permissionNames returns the names of permissions, for audit logs
+func permissionNames(permissions []models.Permission) []string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = permission.Name
+	}
+	return names
+}
+
 func getTenantID(ctx context.Context) uuid.UUID {
 	tenantIDStr := middleware.GetTenantID(ctx)
 	tenantID, _ := uuid.Parse(tenantIDStr)
 	return tenantID
 }
+
+// parseAssignmentWindow parses the optional activation and expiry times of a
+// role assignment; active_from must precede expires_at when both are set
+func parseAssignmentWindow(activeFromStr, expiresAtStr string) (timeutil.NullTime, timeutil.NullTime, error) {
+	var activeFrom, expiresAt timeutil.NullTime
+
+	parsed, ok, err := validator.ParseTimeParam("active_from", activeFromStr)
+	if err != nil {
+		return activeFrom, expiresAt, err
+	}
+	if ok {
+		activeFrom = timeutil.NullFrom(parsed)
+	}
+
+	parsed, ok, err = validator.ParseTimeParam("expires_at", expiresAtStr)
+	if err != nil {
+		return activeFrom, expiresAt, err
+	}
+	if ok {
+		if !parsed.After(time.Now()) {
+			return activeFrom, expiresAt, errors.Validationf("expires_at must be in the future")
+		}
+		expiresAt = timeutil.NullFrom(parsed)
+	}
+
+	if activeFrom.Valid && expiresAt.Valid && !activeFrom.Time.Before(expiresAt.Time) {
+		return activeFrom, expiresAt, errors.Validationf("active_from must be before expires_at")
+	}
+
+	return activeFrom, expiresAt, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database/dbtest"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/services/rbac-service/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestParseAssignmentWindow(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	format := func(d time.Duration) string { return now.Add(d).Format(time.RFC3339) }
+
+	tests := []struct {
+		name       string
+		activeFrom string
+		expiresAt  string
+		wantErr    bool
+	}{
+		{"neither set", "", "", false},
+		{"activation only, in the future", format(time.Hour), "", false},
+		{"activation only, in the past", format(-time.Hour), "", false},
+		{"expiry only", "", format(time.Hour), false},
+		{"activation before expiry", format(time.Hour), format(2 * time.Hour), false},
+		{"activation one second before expiry", format(time.Hour), format(time.Hour + time.Second), false},
+		{"activation at expiry", format(time.Hour), format(time.Hour), true},
+		{"activation after expiry", format(2 * time.Hour), format(time.Hour), true},
+		{"expiry in the past", "", format(-time.Second), true},
+		{"malformed activation", "next week", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activeFrom, expiresAt, err := parseAssignmentWindow(tt.activeFrom, tt.expiresAt)
+			if tt.wantErr {
+				if appErr := errors.FromError(err); appErr == nil || appErr.Code != errors.ErrCodeValidation {
+					t.Fatalf("parseAssignmentWindow(%q, %q) error = %v, want a validation error", tt.activeFrom, tt.expiresAt, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAssignmentWindow(%q, %q) error = %v", tt.activeFrom, tt.expiresAt, err)
+			}
+			if activeFrom.Valid != (tt.activeFrom != "") || expiresAt.Valid != (tt.expiresAt != "") {
+				t.Errorf("parseAssignmentWindow(%q, %q) = (%v, %v), want only the given times set", tt.activeFrom, tt.expiresAt, activeFrom, expiresAt)
+			}
+		})
+	}
+}
+
+func TestUserCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		next    driver.Value // nearest activation or expiry; nil when none
+		err     error
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{"no scheduled change", nil, nil, userRoleCacheTTL, userRoleCacheTTL},
+		{"change after the default TTL", time.Now().Add(2 * userRoleCacheTTL), nil, userRoleCacheTTL, userRoleCacheTTL},
+		{"activation before the default TTL", time.Now().Add(10 * time.Minute), nil, 9 * time.Minute, 10 * time.Minute},
+		{"expiry in a second", time.Now().Add(time.Second), nil, 0, time.Second},
+		{"lookup fails", nil, stderrors.New("connection reset"), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			s := &Service{repo: repository.NewRepository(db, zap.NewNop()), logger: zap.NewNop()}
+
+			tenantID := uuid.New()
+			query := mock.ExpectQuery("SELECT MIN(boundary)").WithArgs(tenantID.String(), "user-1")
+			if tt.err != nil {
+				query.WillReturnError(tt.err)
+			} else {
+				query.WillReturnRows([]string{"min"}, []driver.Value{tt.next})
+			}
+
+			got := s.userCacheTTL(context.Background(), tenantID, "user-1")
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("userCacheTTL() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
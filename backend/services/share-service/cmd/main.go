@@ -10,16 +10,25 @@ import (
 	"time"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/retention"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/handler"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/repository"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/service"
 	"go.uber.org/zap"
 )
 
+// defaultPort is used when SERVER_PORT is not set
+const defaultPort = 10004
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -27,11 +36,15 @@ func main() {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// Override port for share service
-	cfg.Server.Port = 10004
+	// Listen on defaultPort unless SERVER_PORT is set
+	if err := cfg.Server.UseDefaultPort(defaultPort); err != nil {
+		panic(fmt.Sprintf("invalid server config: %v", err))
+	}
 
 	// Initialize logger
-	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format)
+	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format,
+		logger.WithSampling(cfg.Logger.SamplingInitial, cfg.Logger.SamplingThereafter),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
@@ -42,40 +55,68 @@ func main() {
 		zap.String("environment", cfg.Environment),
 		zap.String("version", cfg.AppVersion),
 		zap.Int("port", cfg.Server.Port),
+		zap.Bool("log_sampling", log.SamplingEnabled()),
 	)
+	log.Info("effective configuration", zap.Any("config", cfg.Redacted()))
 
 	// Connect to database
-	db, err := database.NewPostgresDB(cfg.Database, log.Logger)
+	db, err := database.ConnectPostgresDB(cfg.Database, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
-
-	// Verify database health
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.HealthCheck(ctx); err != nil {
-		log.Fatal("database health check failed", zap.Error(err))
-	}
 	log.Info("database connection established")
 
 	// Connect to Redis cache
-	cacheClient, err := cache.NewRedisCache(cfg.Redis, log.Logger)
+	cacheClient, err := cache.ConnectRedisCache(cfg.Redis, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to cache", zap.Error(err))
 	}
 	defer cacheClient.Close()
+	log.Info("cache connection established")
 
-	// Verify cache health
-	if err := cacheClient.HealthCheck(ctx); err != nil {
-		log.Fatal("cache health check failed", zap.Error(err))
+	// Initialize internal service clients
+	tenantResolver := client.NewTenantClient(client.New("tenant-service", cfg.Services.TenantServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	quotaClient := client.NewQuotaClient(client.New("quota-service", cfg.Services.QuotaServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	documentClient := client.NewDocumentClient(client.New("document-service", cfg.Services.DocumentServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	storageClient := client.NewStorageClient(client.New("storage-service", cfg.Services.StorageServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	rbacClient := client.NewRBACClient(client.New("rbac-service", cfg.Services.RBACServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+
+	// Share access notifications are skipped without a notification service.
+	// That service is not part of this repository yet; notify_on_access is
+	// stored but nothing is sent until NOTIFICATION_SERVICE_URL points at one.
+	var notificationClient *client.NotificationClient
+	if cfg.Services.NotificationServiceURL != "" {
+		notificationClient = client.NewNotificationClient(client.New("notification-service", cfg.Services.NotificationServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	} else {
+		log.Info("share access notifications disabled: NOTIFICATION_SERVICE_URL is not set")
 	}
-	log.Info("cache connection established")
+
+	// Background jobs; closed before the database so queued jobs can drain
+	jobs := worker.New("share-service", cfg.Worker, worker.NewStore(db, "share-service", log.Logger), log.Logger)
+	defer jobs.Close()
 
 	// Initialize layers
 	repo := repository.NewRepository(db, log.Logger)
-	svc := service.NewService(repo, cacheClient, log.Logger)
-	h := handler.NewHandler(svc, log.Logger)
+	svc := service.NewService(repo, cacheClient, quotaClient, storageClient, documentClient, rbacClient, notificationClient, cfg.Shares, jobs, log.Logger)
+
+	// Audit retention: purged on a schedule and on demand
+	purger := retention.New(cfg.Retention, log.Logger, retention.Table{
+		Name: "share_access",
+		Purge: func(ctx context.Context, limit int) (int64, error) {
+			return repo.PurgeExpiredShareAccess(ctx, cfg.Retention.ShareAccess, limit)
+		},
+	})
+	defer purger.Close()
+
+	readiness := health.NewChecker("share-service", cfg.Health, log.Logger).WithVersion(cfg.AppVersion)
+	h := handler.NewHandler(svc, readiness, log.Logger)
+
+	// Unknown request body fields
+	response.SetStrictJSON(cfg.Server.StrictJSON)
+
+	// Signed service-to-service requests only
+	internalAuth := middleware.InternalAuth(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -84,27 +125,65 @@ func main() {
 	mux.HandleFunc("GET /health", h.HealthCheck)
 	mux.HandleFunc("GET /health/ready", h.ReadyCheck)
 
+	// Effective configuration with secrets masked (internal use, operators)
+	mux.Handle("GET /api/config", internalAuth(http.HandlerFunc(config.NewHandler(cfg).Get)))
+
+	// Published metrics as JSON (internal use, scrapers)
+	mux.Handle("GET /api/metrics", internalAuth(metrics.Handler()))
+
+	// Audit retention purge (internal use, admin trigger)
+	mux.Handle("POST /api/retention/purge", internalAuth(http.HandlerFunc(retention.NewHandler(purger).Purge)))
+
+	// Dead-letter jobs (internal use, admin view and requeue)
+	jobsHandler := worker.NewHandler(jobs)
+	mux.Handle("GET /api/jobs/dead-letter", internalAuth(http.HandlerFunc(jobsHandler.ListDeadLetters)))
+	mux.Handle("POST /api/jobs/dead-letter/{id}/requeue", internalAuth(http.HandlerFunc(jobsHandler.RequeueDeadLetter)))
+
+	// Tenant data purge (internal use, called by tenant deletion)
+	mux.Handle("DELETE /api/shares/tenant-data", internalAuth(http.HandlerFunc(h.PurgeTenant)))
+
+	// Share ownership transfer (internal use, called when a member is removed)
+	mux.Handle("POST /api/shares/reassign-owner", internalAuth(http.HandlerFunc(h.ReassignOwner)))
+
 	// Public share access (no auth required)
 	mux.HandleFunc("POST /api/shares/access", h.AccessShare)
 	mux.HandleFunc("POST /api/shares/verify", h.VerifyToken)
+	mux.HandleFunc("GET /share/{token}/download", h.DownloadShare)
 
 	// Share endpoints (auth required)
 	mux.HandleFunc("POST /api/shares", h.CreateShare)
 	mux.HandleFunc("GET /api/shares", h.ListShares)
 	mux.HandleFunc("GET /api/shares/stats", h.GetStats)
+	mux.HandleFunc("GET /api/shares/by-me", h.ListSharesByMe)
+	mux.HandleFunc("GET /api/shares/with-me", h.ListSharesWithMe)
 	mux.HandleFunc("GET /api/shares/{id}", h.GetShare)
 	mux.HandleFunc("PUT /api/shares/{id}", h.UpdateShare)
+	mux.HandleFunc("PATCH /api/shares/{id}", h.PatchShare)
 	mux.HandleFunc("POST /api/shares/{id}/revoke", h.RevokeShare)
+	mux.HandleFunc("POST /api/shares/{id}/rotate-token", h.RotateShareToken)
 	mux.HandleFunc("DELETE /api/shares/{id}", h.DeleteShare)
 	mux.HandleFunc("GET /api/shares/{id}/access-logs", h.GetShareAccessLogs)
 
 	// Apply middleware chain
+	middleware.SetSlowRequestThreshold(cfg.Logger.SlowRequestThreshold)
 	var httpHandler http.Handler = mux
+	httpHandler = middleware.Versioning(middleware.APIVersion1)(httpHandler)
 	httpHandler = middleware.RequestID()(httpHandler)
+	httpHandler = middleware.CountAPICalls(cacheClient.IncrementAPICalls, log)(httpHandler)
+	httpHandler = middleware.RateLimit(cfg.RateLimit, cacheClient.RateLimitResolver(quotaClient.RateLimit, cfg.RateLimit.CacheTTL), cacheClient.IncrementRateWindow, log)(httpHandler)
+	httpHandler = middleware.ResolveTenant(cfg.Tenancy, tenantResolver.ResolveSlug, tenantResolver.IsMember)(httpHandler)
 	httpHandler = middleware.ExtractAuthHeaders(log)(httpHandler)
+	httpHandler = middleware.VerifyInternalRequest(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)(httpHandler)
+	// CORS runs ahead of auth so browser preflights are answered without credentials
+	httpHandler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:      cfg.CORS.AllowedOrigins,
+		CredentialedOrigins: cfg.CORS.CredentialedOrigins,
+		MaxAge:              cfg.CORS.MaxAge,
+	})(httpHandler)
 	httpHandler = middleware.Logging(log)(httpHandler)
 	httpHandler = middleware.Recovery(log)(httpHandler)
 	httpHandler = middleware.Timeout(30 * time.Second)(httpHandler)
+	httpHandler = middleware.MaxBodySize(cfg.Server.MaxBodySize)(httpHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
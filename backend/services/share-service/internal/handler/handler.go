@@ -1,11 +1,12 @@
 package handler
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"strconv"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/models"
@@ -15,23 +16,25 @@ import (
 
 // Handler handles HTTP requests for share operations
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service   *service.Service
+	readiness *health.Checker
+	logger    *zap.Logger
 }
 
 // NewHandler creates a new share handler
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
+func NewHandler(svc *service.Service, readiness *health.Checker, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: svc,
-		logger:  logger,
+		service:   svc,
+		readiness: readiness,
+		logger:    logger,
 	}
 }
 
 // CreateShare handles POST /api/shares
 func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateShareRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -47,6 +50,12 @@ func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A deduplicated request created nothing
+	if share.Deduplicated {
+		response.Success(w, share)
+		return
+	}
+
 	response.Created(w, share)
 }
 
@@ -71,8 +80,8 @@ func (h *Handler) GetShare(w http.ResponseWriter, r *http.Request) {
 // AccessShare handles POST /api/shares/access
 func (h *Handler) AccessShare(w http.ResponseWriter, r *http.Request) {
 	var req models.AccessShareRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -82,12 +91,7 @@ func (h *Handler) AccessShare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get IP address and user agent
-	ipAddress := r.RemoteAddr
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		ipAddress = forwarded
-	}
-	userAgent := r.Header.Get("User-Agent")
+	ipAddress, userAgent := clientInfo(r)
 
 	accessResp, err := h.service.AccessShare(r.Context(), &req, ipAddress, userAgent)
 	if err != nil {
@@ -98,8 +102,46 @@ func (h *Handler) AccessShare(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, accessResp)
 }
 
+// DownloadShare handles GET /share/:token/download. A password-protected
+// share takes its password in the X-Share-Password header.
+func (h *Handler) DownloadShare(w http.ResponseWriter, r *http.Request) {
+	ipAddress, userAgent := clientInfo(r)
+
+	download, err := h.service.DownloadShare(r.Context(), r.PathValue("token"), r.Header.Get("X-Share-Password"), ipAddress, userAgent)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	http.Redirect(w, r, download.DownloadURL, http.StatusFound)
+}
+
+// clientInfo returns the IP address and user agent of a share access
+func clientInfo(r *http.Request) (string, string) {
+	ipAddress := r.RemoteAddr
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		ipAddress = forwarded
+	}
+	return ipAddress, r.Header.Get("User-Agent")
+}
+
 // ListShares handles GET /api/shares
 func (h *Handler) ListShares(w http.ResponseWriter, r *http.Request) {
+	h.listShares(w, r, h.service.ListShares)
+}
+
+// ListSharesByMe handles GET /api/shares/by-me
+func (h *Handler) ListSharesByMe(w http.ResponseWriter, r *http.Request) {
+	h.listShares(w, r, h.service.ListSharesByMe)
+}
+
+// ListSharesWithMe handles GET /api/shares/with-me
+func (h *Handler) ListSharesWithMe(w http.ResponseWriter, r *http.Request) {
+	h.listShares(w, r, h.service.ListSharesWithMe)
+}
+
+// listShares parses the shared list parameters and writes one page of shares
+func (h *Handler) listShares(w http.ResponseWriter, r *http.Request, list func(context.Context, *models.ListSharesParams) ([]models.Share, int64, error)) {
 	params := &models.ListSharesParams{
 		DocumentID: r.URL.Query().Get("document_id"),
 		ShareType:  r.URL.Query().Get("share_type"),
@@ -127,7 +169,7 @@ func (h *Handler) ListShares(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shares, total, err := h.service.ListShares(r.Context(), params)
+	shares, total, err := list(r.Context(), params)
 	if err != nil {
 		response.Error(w, err)
 		return
@@ -146,8 +188,8 @@ func (h *Handler) UpdateShare(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateShareRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -165,6 +207,35 @@ func (h *Handler) UpdateShare(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "share updated successfully"})
 }
 
+// PatchShare handles PATCH /api/shares/:id
+func (h *Handler) PatchShare(w http.ResponseWriter, r *http.Request) {
+	shareIDStr := r.PathValue("id")
+	shareID, err := uuid.Parse(shareIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid share ID")
+		return
+	}
+
+	var req models.PatchShareRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	if err := h.service.PatchShare(r.Context(), shareID, &req); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]string{"message": "share updated successfully"})
+}
+
 // RevokeShare handles POST /api/shares/:id/revoke
 func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
 	shareIDStr := r.PathValue("id")
@@ -182,6 +253,23 @@ func (h *Handler) RevokeShare(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "share revoked successfully"})
 }
 
+// RotateShareToken handles POST /api/shares/:id/rotate-token
+func (h *Handler) RotateShareToken(w http.ResponseWriter, r *http.Request) {
+	shareID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid share ID")
+		return
+	}
+
+	rotated, err := h.service.RotateShareToken(r.Context(), shareID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, rotated)
+}
+
 // DeleteShare handles DELETE /api/shares/:id
 func (h *Handler) DeleteShare(w http.ResponseWriter, r *http.Request) {
 	shareIDStr := r.PathValue("id")
@@ -208,21 +296,30 @@ func (h *Handler) GetShareAccessLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse limit
-	limit := 50
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+	query := r.URL.Query()
+	params := models.ListAccessLogsParams{
+		Cursor: query.Get("cursor"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
+			params.Limit = l
 		}
 	}
 
-	logs, err := h.service.GetShareAccessLogs(r.Context(), shareID, limit)
+	if err := validator.Validate(params); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	page, err := h.service.GetShareAccessLogs(r.Context(), shareID, params)
 	if err != nil {
 		response.Error(w, err)
 		return
 	}
 
-	response.Success(w, logs)
+	response.Success(w, page)
 }
 
 // GetStats handles GET /api/shares/stats
@@ -239,8 +336,8 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 // VerifyToken handles POST /api/shares/verify
 func (h *Handler) VerifyToken(w http.ResponseWriter, r *http.Request) {
 	var req models.VerifyShareTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -259,19 +356,52 @@ func (h *Handler) VerifyToken(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, verifyResp)
 }
 
+// ReassignOwner handles POST /api/shares/reassign-owner (internal use)
+func (h *Handler) ReassignOwner(w http.ResponseWriter, r *http.Request) {
+	var req models.ReassignOwnerRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.ReassignOwner(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
+}
+
+// PurgeTenant handles DELETE /api/shares/tenant-data (internal use)
+func (h *Handler) PurgeTenant(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.service.PurgeTenant(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]int64{"deleted": deleted})
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.Success(w, map[string]string{
-		"status":  "healthy",
-		"service": "share-service",
-	})
+	response.Success(w, h.readiness.Live())
 }
 
 // ReadyCheck handles GET /health/ready
 func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 	// TODO: Check database and cache connectivity
-	response.Success(w, map[string]string{
-		"status":  "ready",
-		"service": "share-service",
-	})
+	report := h.readiness.Check(r.Context())
+	if !report.Ready() {
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	response.Success(w, report)
 }
@@ -2,28 +2,51 @@ package models
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 )
 
 // Share represents a document share
 type Share struct {
-	ID          uuid.UUID      `json:"id" db:"id"`
-	TenantID    uuid.UUID      `json:"tenant_id" db:"tenant_id"`
-	DocumentID  uuid.UUID      `json:"document_id" db:"document_id"`
-	ShareType   string         `json:"share_type" db:"share_type"` // user, public, email
-	SharedBy    string         `json:"shared_by" db:"shared_by"`
-	SharedWith  sql.NullString `json:"shared_with,omitempty" db:"shared_with"` // user_id or email
-	Permission  string         `json:"permission" db:"permission"`             // view, edit, download
-	ShareToken  sql.NullString `json:"share_token,omitempty" db:"share_token"` // for public links
-	ExpiresAt   sql.NullTime   `json:"expires_at,omitempty" db:"expires_at"`
-	Password    sql.NullString `json:"-" db:"password"`                    // hashed password for protected links
-	MaxAccess   sql.NullInt64  `json:"max_access,omitempty" db:"max_access"` // max access count
-	AccessCount int            `json:"access_count" db:"access_count"`
-	IsActive    bool           `json:"is_active" db:"is_active"`
-	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID         `json:"id" db:"id"`
+	TenantID    uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	DocumentID  uuid.UUID         `json:"document_id" db:"document_id"`
+	ShareType   string            `json:"share_type" db:"share_type"` // user, public, email
+	SharedBy    string            `json:"shared_by" db:"shared_by"`
+	SharedWith  sql.NullString    `json:"shared_with,omitempty" db:"shared_with"` // user_id or email
+	Permission  string            `json:"permission" db:"permission"`             // view, edit, download
+	ShareToken  sql.NullString    `json:"share_token,omitempty" db:"share_token"` // for public links
+	ExpiresAt   timeutil.NullTime `json:"expires_at,omitempty" db:"expires_at"`
+	Password    sql.NullString    `json:"-" db:"password"`                      // hashed password for protected links
+	MaxAccess   sql.NullInt64     `json:"max_access,omitempty" db:"max_access"` // max access count
+	AccessCount int               `json:"access_count" db:"access_count"`
+	IsActive    bool              `json:"is_active" db:"is_active"`
+
+	ExpireAfterInactivity sql.NullInt64     `json:"expire_after_inactivity,omitempty" db:"expire_after_inactivity"` // idle window in seconds
+	LastAccessedAt        timeutil.NullTime `json:"last_accessed_at,omitempty" db:"last_accessed_at"`
+	NotifyOnAccess        bool              `json:"notify_on_access" db:"notify_on_access"` // tell the creator when the link is opened
+
+	CreatedAt timeutil.Time `json:"created_at" db:"created_at"`
+	UpdatedAt timeutil.Time `json:"updated_at" db:"updated_at"`
+}
+
+// InactivityExpired reports whether the share has been idle longer than its
+// inactivity window. Shares that were never accessed are measured from creation.
+func (s *Share) InactivityExpired(now time.Time) bool {
+	if !s.ExpireAfterInactivity.Valid || s.ExpireAfterInactivity.Int64 <= 0 {
+		return false
+	}
+	lastActivity := s.CreatedAt.Time
+	if s.LastAccessedAt.Valid {
+		lastActivity = s.LastAccessedAt.Time
+	}
+	window := time.Duration(s.ExpireAfterInactivity.Int64) * time.Second
+	return now.Sub(lastActivity) > window
 }
 
 // ShareAccess represents share access log
@@ -34,7 +57,67 @@ type ShareAccess struct {
 	IPAddress  string         `json:"ip_address" db:"ip_address"`
 	UserAgent  string         `json:"user_agent" db:"user_agent"`
 	Action     string         `json:"action" db:"action"` // view, download
-	AccessedAt time.Time      `json:"accessed_at" db:"accessed_at"`
+	AccessedAt timeutil.Time  `json:"accessed_at" db:"accessed_at"`
+}
+
+// ListAccessLogsParams represents query parameters for listing share access logs
+type ListAccessLogsParams struct {
+	Cursor string `json:"cursor,omitempty" form:"cursor"`
+	Limit  int    `json:"limit" form:"limit"`
+	From   string `json:"from,omitempty" form:"from" validate:"omitempty,rfc3339"`
+	To     string `json:"to,omitempty" form:"to" validate:"omitempty,rfc3339"`
+}
+
+// Normalize sets default values for access log parameters
+func (p *ListAccessLogsParams) Normalize() {
+	if p.Limit < 1 {
+		p.Limit = 50
+	}
+	if p.Limit > 100 {
+		p.Limit = 100
+	}
+}
+
+// AccessLogCursor marks the last log of a page; logs are ordered newest
+// first, with the ID breaking ties between identical timestamps
+type AccessLogCursor struct {
+	AccessedAt time.Time
+	ID         uuid.UUID
+}
+
+// Encode returns the opaque token handed to clients as next_cursor
+func (c AccessLogCursor) Encode() string {
+	raw := c.AccessedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeAccessLogCursor parses a token produced by AccessLogCursor.Encode
+func DecodeAccessLogCursor(token string) (*AccessLogCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	accessedAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	logID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	return &AccessLogCursor{AccessedAt: accessedAt, ID: logID}, nil
+}
+
+// AccessLogPage is one page of a share's access logs; Total counts every
+// log for the share regardless of the date filter
+type AccessLogPage struct {
+	Logs       []ShareAccess `json:"logs"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Total      int64         `json:"total"`
 }
 
 // ShareWithDetails includes share with document and user details
@@ -50,29 +133,75 @@ type CreateShareRequest struct {
 	ShareType  string `json:"share_type" validate:"required,oneof=user public email"`
 	SharedWith string `json:"shared_with,omitempty" validate:"required_if=ShareType user,omitempty,email"`
 	Permission string `json:"permission" validate:"required,oneof=view edit download"`
-	ExpiresAt  string `json:"expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ExpiresAt  string `json:"expires_at,omitempty" validate:"omitempty,rfc3339"`
 	Password   string `json:"password,omitempty" validate:"omitempty,min=8,max=100"`
 	MaxAccess  int    `json:"max_access,omitempty" validate:"omitempty,gte=1,lte=1000"`
+
+	// ExpireAfterInactivity is a Go duration string (e.g. "72h")
+	ExpireAfterInactivity string `json:"expire_after_inactivity,omitempty"`
+
+	// NotifyOnAccess notifies the creator when the share is opened, at most
+	// once per hour
+	NotifyOnAccess bool `json:"notify_on_access,omitempty"`
+
+	// DedupeKey makes creation idempotent: a retry with the same key for the
+	// same document and recipient returns the active share it created
+	DedupeKey string `json:"dedupe_key,omitempty" validate:"omitempty,max=128"`
 }
 
 // CreateShareResponse represents share creation response
 type CreateShareResponse struct {
-	ID         uuid.UUID  `json:"id"`
-	DocumentID uuid.UUID  `json:"document_id"`
-	ShareType  string     `json:"share_type"`
-	Permission string     `json:"permission"`
-	ShareToken *string    `json:"share_token,omitempty"`
-	ShareURL   *string    `json:"share_url,omitempty"`
-	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
-	CreatedAt  time.Time  `json:"created_at"`
+	ID         uuid.UUID      `json:"id"`
+	DocumentID uuid.UUID      `json:"document_id"`
+	ShareType  string         `json:"share_type"`
+	Permission string         `json:"permission"`
+	ShareToken *string        `json:"share_token,omitempty"`
+	ShareURL   *string        `json:"share_url,omitempty"`
+	ExpiresAt  *timeutil.Time `json:"expires_at,omitempty"`
+	CreatedAt  timeutil.Time  `json:"created_at"`
+
+	// ExpiryDefaulted is set when expires_at came from the expiry policy
+	// rather than the request
+	ExpiryDefaulted bool `json:"expiry_defaulted,omitempty"`
+
+	// Deduplicated is set when an existing share matched dedupe_key and no
+	// new share was created
+	Deduplicated bool `json:"deduplicated,omitempty"`
 }
 
-// UpdateShareRequest represents share update request
+// ExpiryPolicy bounds share lifetimes: DefaultExpiry is applied when no
+// expiry is given and MaxExpiry caps explicit ones. Zero disables either.
+type ExpiryPolicy struct {
+	DefaultExpiry time.Duration `json:"default_expiry"`
+	MaxExpiry     time.Duration `json:"max_expiry"`
+}
+
+// UpdateShareRequest represents a full share replacement (PUT); omitted
+// expiry and access limits are cleared
 type UpdateShareRequest struct {
-	Permission string `json:"permission,omitempty" validate:"omitempty,oneof=view edit download"`
-	ExpiresAt  string `json:"expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Permission string `json:"permission" validate:"required,oneof=view edit download"`
+	ExpiresAt  string `json:"expires_at,omitempty" validate:"omitempty,rfc3339"`
 	MaxAccess  *int   `json:"max_access,omitempty" validate:"omitempty,gte=1,lte=1000"`
-	IsActive   *bool  `json:"is_active,omitempty"`
+	IsActive   *bool  `json:"is_active" validate:"required"`
+
+	// ExpireAfterInactivity is a Go duration string (e.g. "72h")
+	ExpireAfterInactivity string `json:"expire_after_inactivity,omitempty"`
+
+	NotifyOnAccess bool `json:"notify_on_access"`
+}
+
+// PatchShareRequest represents a partial share update (PATCH); nil fields are
+// left unchanged and empty strings clear optional limits
+type PatchShareRequest struct {
+	Permission *string `json:"permission,omitempty" validate:"omitempty,oneof=view edit download"`
+	ExpiresAt  *string `json:"expires_at,omitempty"`
+	MaxAccess  *int    `json:"max_access,omitempty" validate:"omitempty,gte=1,lte=1000"`
+	IsActive   *bool   `json:"is_active,omitempty"`
+
+	// ExpireAfterInactivity is a Go duration string (e.g. "72h")
+	ExpireAfterInactivity *string `json:"expire_after_inactivity,omitempty"`
+
+	NotifyOnAccess *bool `json:"notify_on_access,omitempty"`
 }
 
 // AccessShareRequest represents share access request
@@ -83,11 +212,11 @@ type AccessShareRequest struct {
 
 // AccessShareResponse represents share access response
 type AccessShareResponse struct {
-	DocumentID   uuid.UUID `json:"document_id"`
-	DocumentName string    `json:"document_name"`
-	Permission   string    `json:"permission"`
-	DownloadURL  string    `json:"download_url,omitempty"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	DocumentID   uuid.UUID     `json:"document_id"`
+	DocumentName string        `json:"document_name"`
+	Permission   string        `json:"permission"`
+	DownloadURL  string        `json:"download_url,omitempty"`
+	ExpiresAt    timeutil.Time `json:"expires_at"`
 }
 
 // ListSharesParams represents query parameters for listing shares
@@ -100,6 +229,11 @@ type ListSharesParams struct {
 	Limit      int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
 	SortBy     string `json:"sort_by,omitempty" form:"sort_by"`
 	SortOrder  string `json:"sort_order,omitempty" form:"sort_order" validate:"omitempty,oneof=asc desc"`
+
+	// Set from the auth context by the by-me and with-me listings, never
+	// from the query string
+	SharedBy        string   `json:"-"`
+	SharedWithAnyOf []string `json:"-"` // matched ignoring case
 }
 
 // Normalize sets default values for list parameters
@@ -147,11 +281,38 @@ type VerifyShareTokenRequest struct {
 	Password string `json:"password,omitempty"`
 }
 
+// RotateShareTokenResponse carries a public share's new link
+type RotateShareTokenResponse struct {
+	ID         uuid.UUID `json:"id"`
+	ShareToken string    `json:"share_token"`
+	ShareURL   string    `json:"share_url"`
+}
+
+// What happens to a removed member's shares
+const (
+	OwnerActionReassign = "reassign" // hand the shares to another member
+	OwnerActionRevoke   = "revoke"   // deactivate the shares' links
+)
+
+// ReassignOwnerRequest moves or revokes every share created by a user;
+// ToUserID is required to reassign
+type ReassignOwnerRequest struct {
+	FromUserID string `json:"from_user_id" validate:"required"`
+	ToUserID   string `json:"to_user_id,omitempty"`
+	Action     string `json:"action" validate:"required,oneof=reassign revoke"`
+}
+
+// ReassignOwnerResponse reports how many shares were reassigned or revoked
+type ReassignOwnerResponse struct {
+	Action    string `json:"action"`
+	Succeeded int64  `json:"succeeded"`
+}
+
 // VerifyShareTokenResponse represents token verification response
 type VerifyShareTokenResponse struct {
-	Valid      bool       `json:"valid"`
-	ShareID    uuid.UUID  `json:"share_id,omitempty"`
-	DocumentID uuid.UUID  `json:"document_id,omitempty"`
-	Permission string     `json:"permission,omitempty"`
-	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Valid      bool           `json:"valid"`
+	ShareID    uuid.UUID      `json:"share_id,omitempty"`
+	DocumentID uuid.UUID      `json:"document_id,omitempty"`
+	Permission string         `json:"permission,omitempty"`
+	ExpiresAt  *timeutil.Time `json:"expires_at,omitempty"`
 }
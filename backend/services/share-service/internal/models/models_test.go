@@ -0,0 +1,50 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+)
+
+func TestShareInactivityExpired(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	accessed := created.Add(48 * time.Hour)
+	const window = 24 * time.Hour
+
+	tests := []struct {
+		name         string
+		window       sql.NullInt64
+		lastAccessed timeutil.NullTime
+		now          time.Time
+		want         bool
+	}{
+		{"no window never expires", sql.NullInt64{}, timeutil.NullTime{}, created.Add(365 * 24 * time.Hour), false},
+		{"zero window never expires", sql.NullInt64{Int64: 0, Valid: true}, timeutil.NullTime{}, created.Add(365 * 24 * time.Hour), false},
+		{"never accessed, just before the window", seconds(window), timeutil.NullTime{}, created.Add(window - time.Second), false},
+		{"never accessed, at the window", seconds(window), timeutil.NullTime{}, created.Add(window), false},
+		{"never accessed, just after the window", seconds(window), timeutil.NullTime{}, created.Add(window + time.Second), true},
+		{"accessed, just before the window", seconds(window), timeutil.NullFrom(accessed), accessed.Add(window - time.Second), false},
+		{"accessed, at the window", seconds(window), timeutil.NullFrom(accessed), accessed.Add(window), false},
+		{"accessed, just after the window", seconds(window), timeutil.NullFrom(accessed), accessed.Add(window + time.Second), true},
+		{"access restarts the window", seconds(window), timeutil.NullFrom(accessed), created.Add(window + time.Second), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			share := &Share{
+				ExpireAfterInactivity: tt.window,
+				LastAccessedAt:        tt.lastAccessed,
+				CreatedAt:             timeutil.Time{Time: created},
+			}
+			if got := share.InactivityExpired(tt.now); got != tt.want {
+				t.Errorf("InactivityExpired(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+// seconds is an inactivity window as stored in expire_after_inactivity
+func seconds(d time.Duration) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+}
@@ -8,8 +8,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/retention"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/models"
 	"go.uber.org/zap"
 )
@@ -30,17 +32,106 @@ func NewRepository(db *database.DB, logger *zap.Logger) *Repository {
 
 // CreateShare creates a new share
 func (r *Repository) CreateShare(ctx context.Context, share *models.Share) error {
+	return r.createShare(ctx, r.db, share, "")
+}
+
+// CreateShareOnce creates share unless an active, unexpired share of the same
+// tenant, document and recipient was created with dedupeKey; that share is
+// then returned instead, with created=false. Concurrent creates with the same
+// key are serialized by an advisory lock on the dedupe scope, and a unique
+// index on the scope and key (migration 000025) backs the lock.
+func (r *Repository) CreateShareOnce(ctx context.Context, share *models.Share, dedupeKey string) (*models.Share, bool, error) {
+	result := share
+	created := true
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		scope := strings.Join([]string{share.TenantID.String(), share.DocumentID.String(), share.SharedWith.String, dedupeKey}, "|")
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, scope); err != nil {
+			r.logger.Error("failed to lock share dedupe key", zap.Error(err))
+			return database.WrapError("failed to create share", err)
+		}
+
+		query := `
+			SELECT id, tenant_id, document_id, share_type, shared_by,
+				shared_with, permission, share_token, expires_at,
+				password, max_access, access_count, is_active,
+				expire_after_inactivity, last_accessed_at, notify_on_access,
+				created_at, updated_at
+			FROM shares
+			WHERE tenant_id = $1 AND document_id = $2 AND COALESCE(shared_with, '') = $3
+				AND dedupe_key = $4 AND is_active = true
+				AND (expires_at IS NULL OR expires_at > NOW())
+			ORDER BY created_at DESC
+			LIMIT 1`
+
+		var existing models.Share
+		err := tx.QueryRowContext(ctx, query, share.TenantID, share.DocumentID, share.SharedWith.String, dedupeKey).Scan(
+			&existing.ID,
+			&existing.TenantID,
+			&existing.DocumentID,
+			&existing.ShareType,
+			&existing.SharedBy,
+			&existing.SharedWith,
+			&existing.Permission,
+			&existing.ShareToken,
+			&existing.ExpiresAt,
+			&existing.Password,
+			&existing.MaxAccess,
+			&existing.AccessCount,
+			&existing.IsActive,
+			&existing.ExpireAfterInactivity,
+			&existing.LastAccessedAt,
+			&existing.NotifyOnAccess,
+			&existing.CreatedAt,
+			&existing.UpdatedAt,
+		)
+		if err == nil {
+			result, created = &existing, false
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			r.logger.Error("failed to find share by dedupe key", zap.Error(err))
+			return database.WrapError("failed to create share", err)
+		}
+
+		// A revoked or expired share keeps its key until a new share takes it
+		release := `
+			UPDATE shares SET dedupe_key = NULL
+			WHERE tenant_id = $1 AND document_id = $2 AND COALESCE(shared_with, '') = $3
+				AND dedupe_key = $4`
+		if _, err := tx.ExecContext(ctx, release, share.TenantID, share.DocumentID, share.SharedWith.String, dedupeKey); err != nil {
+			r.logger.Error("failed to release share dedupe key", zap.Error(err))
+			return database.WrapError("failed to create share", err)
+		}
+
+		return r.createShare(ctx, tx, share, dedupeKey)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return result, created, nil
+}
+
+// execer is satisfied by both *database.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (r *Repository) createShare(ctx context.Context, exec execer, share *models.Share, dedupeKey string) error {
 	query := `
 		INSERT INTO shares (
 			id, tenant_id, document_id, share_type, shared_by,
 			shared_with, permission, share_token, expires_at,
 			password, max_access, access_count, is_active,
-			created_at, updated_at
+			expire_after_inactivity, last_accessed_at, notify_on_access,
+			dedupe_key, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			$16, NULLIF($17, ''), $18, $19
 		)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := exec.ExecContext(ctx, query,
 		share.ID,
 		share.TenantID,
 		share.DocumentID,
@@ -54,13 +145,17 @@ func (r *Repository) CreateShare(ctx context.Context, share *models.Share) error
 		share.MaxAccess,
 		share.AccessCount,
 		share.IsActive,
+		share.ExpireAfterInactivity,
+		share.LastAccessedAt,
+		share.NotifyOnAccess,
+		dedupeKey,
 		share.CreatedAt,
 		share.UpdatedAt,
 	)
 
 	if err != nil {
 		r.logger.Error("failed to create share", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create share")
+		return database.WrapError("failed to create share", err)
 	}
 
 	return nil
@@ -72,6 +167,7 @@ func (r *Repository) GetShare(ctx context.Context, tenantID, shareID uuid.UUID)
 		SELECT id, tenant_id, document_id, share_type, shared_by,
 			shared_with, permission, share_token, expires_at,
 			password, max_access, access_count, is_active,
+			expire_after_inactivity, last_accessed_at, notify_on_access,
 			created_at, updated_at
 		FROM shares
 		WHERE id = $1 AND tenant_id = $2`
@@ -91,6 +187,9 @@ func (r *Repository) GetShare(ctx context.Context, tenantID, shareID uuid.UUID)
 		&share.MaxAccess,
 		&share.AccessCount,
 		&share.IsActive,
+		&share.ExpireAfterInactivity,
+		&share.LastAccessedAt,
+		&share.NotifyOnAccess,
 		&share.CreatedAt,
 		&share.UpdatedAt,
 	)
@@ -112,6 +211,7 @@ func (r *Repository) GetShareByToken(ctx context.Context, token string) (*models
 		SELECT id, tenant_id, document_id, share_type, shared_by,
 			shared_with, permission, share_token, expires_at,
 			password, max_access, access_count, is_active,
+			expire_after_inactivity, last_accessed_at, notify_on_access,
 			created_at, updated_at
 		FROM shares
 		WHERE share_token = $1`
@@ -131,6 +231,9 @@ func (r *Repository) GetShareByToken(ctx context.Context, token string) (*models
 		&share.MaxAccess,
 		&share.AccessCount,
 		&share.IsActive,
+		&share.ExpireAfterInactivity,
+		&share.LastAccessedAt,
+		&share.NotifyOnAccess,
 		&share.CreatedAt,
 		&share.UpdatedAt,
 	)
@@ -174,6 +277,18 @@ func (r *Repository) ListShares(ctx context.Context, tenantID uuid.UUID, params
 		argPos++
 	}
 
+	if params.SharedBy != "" {
+		where = append(where, fmt.Sprintf("shared_by = $%d", argPos))
+		args = append(args, params.SharedBy)
+		argPos++
+	}
+
+	if len(params.SharedWithAnyOf) > 0 {
+		where = append(where, fmt.Sprintf("LOWER(shared_with) = ANY($%d)", argPos))
+		args = append(args, pq.Array(params.SharedWithAnyOf))
+		argPos++
+	}
+
 	if params.IsActive != "" {
 		isActive := params.IsActive == "true"
 		where = append(where, fmt.Sprintf("is_active = $%d", argPos))
@@ -197,6 +312,7 @@ func (r *Repository) ListShares(ctx context.Context, tenantID uuid.UUID, params
 		SELECT id, tenant_id, document_id, share_type, shared_by,
 			shared_with, permission, share_token, expires_at,
 			password, max_access, access_count, is_active,
+			expire_after_inactivity, last_accessed_at, notify_on_access,
 			created_at, updated_at
 		FROM shares
 		WHERE %s
@@ -235,6 +351,9 @@ func (r *Repository) ListShares(ctx context.Context, tenantID uuid.UUID, params
 			&share.MaxAccess,
 			&share.AccessCount,
 			&share.IsActive,
+			&share.ExpireAfterInactivity,
+			&share.LastAccessedAt,
+			&share.NotifyOnAccess,
 			&share.CreatedAt,
 			&share.UpdatedAt,
 		)
@@ -285,7 +404,7 @@ func (r *Repository) UpdateShare(ctx context.Context, tenantID, shareID uuid.UUI
 	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to update share", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update share")
+		return database.WrapError("failed to update share", err)
 	}
 
 	rows, _ := result.RowsAffected()
@@ -314,20 +433,28 @@ func (r *Repository) DeleteShare(ctx context.Context, tenantID, shareID uuid.UUI
 	return nil
 }
 
-// IncrementAccessCount increments the access count for a share
-func (r *Repository) IncrementAccessCount(ctx context.Context, shareID uuid.UUID) error {
+// IncrementAccessCount increments the access count for a share and records
+// the access time. The increment only happens while the share is below its
+// max_access limit, so concurrent accesses cannot exceed it; false means the
+// limit was already reached and the access must be rejected.
+func (r *Repository) IncrementAccessCount(ctx context.Context, shareID uuid.UUID) (bool, error) {
 	query := `
 		UPDATE shares
-		SET access_count = access_count + 1, updated_at = $1
-		WHERE id = $2`
+		SET access_count = access_count + 1, last_accessed_at = $1, updated_at = $1
+		WHERE id = $2 AND (max_access IS NULL OR access_count < max_access)
+		RETURNING access_count`
 
-	_, err := r.db.ExecContext(ctx, query, time.Now(), shareID)
+	var accessCount int
+	err := r.db.QueryRowContext(ctx, query, time.Now(), shareID).Scan(&accessCount)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
 	if err != nil {
 		r.logger.Error("failed to increment access count", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to update access count")
+		return false, database.WrapError("failed to update access count", err)
 	}
 
-	return nil
+	return true, nil
 }
 
 // CreateShareAccess logs share access
@@ -356,24 +483,46 @@ func (r *Repository) CreateShareAccess(ctx context.Context, access *models.Share
 	return nil
 }
 
-// GetShareAccessLogs retrieves access logs for a share
-func (r *Repository) GetShareAccessLogs(ctx context.Context, shareID uuid.UUID, limit int) ([]models.ShareAccess, error) {
+// GetShareAccessLogs retrieves a page of access logs for a share, newest
+// first. Logs older than the cursor position are returned; from/to bound
+// accessed_at when set.
+func (r *Repository) GetShareAccessLogs(ctx context.Context, shareID uuid.UUID, cursor *models.AccessLogCursor, from, to *time.Time, limit int) ([]models.ShareAccess, error) {
 	query := `
 		SELECT id, share_id, accessed_by, ip_address,
 			user_agent, action, accessed_at
 		FROM share_access
-		WHERE share_id = $1
-		ORDER BY accessed_at DESC
-		LIMIT $2`
+		WHERE share_id = $1`
+
+	args := []interface{}{shareID}
+	argPos := 2
 
-	rows, err := r.db.QueryContext(ctx, query, shareID, limit)
+	if cursor != nil {
+		query += fmt.Sprintf(" AND (accessed_at, id) < ($%d, $%d)", argPos, argPos+1)
+		args = append(args, cursor.AccessedAt, cursor.ID)
+		argPos += 2
+	}
+	if from != nil {
+		query += fmt.Sprintf(" AND accessed_at >= $%d", argPos)
+		args = append(args, *from)
+		argPos++
+	}
+	if to != nil {
+		query += fmt.Sprintf(" AND accessed_at <= $%d", argPos)
+		args = append(args, *to)
+		argPos++
+	}
+
+	query += fmt.Sprintf(" ORDER BY accessed_at DESC, id DESC LIMIT $%d", argPos)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to get share access logs", zap.Error(err))
 		return nil, errors.New(errors.ErrCodeInternal, "failed to get access logs")
 	}
 	defer rows.Close()
 
-	var logs []models.ShareAccess
+	logs := []models.ShareAccess{}
 	for rows.Next() {
 		var log models.ShareAccess
 		err := rows.Scan(
@@ -395,6 +544,18 @@ func (r *Repository) GetShareAccessLogs(ctx context.Context, shareID uuid.UUID,
 	return logs, nil
 }
 
+// CountShareAccessLogs returns the total number of access logs for a share
+func (r *Repository) CountShareAccessLogs(ctx context.Context, shareID uuid.UUID) (int64, error) {
+	var total int64
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM share_access WHERE share_id = $1`, shareID).Scan(&total)
+	if err != nil {
+		r.logger.Error("failed to count share access logs", zap.Error(err))
+		return 0, errors.New(errors.ErrCodeInternal, "failed to count access logs")
+	}
+
+	return total, nil
+}
+
 // GetShareStats retrieves share statistics for a tenant
 func (r *Repository) GetShareStats(ctx context.Context, tenantID uuid.UUID) (*models.ShareStats, error) {
 	stats := &models.ShareStats{
@@ -471,3 +632,119 @@ func (r *Repository) GetShareStats(ctx context.Context, tenantID uuid.UUID) (*mo
 
 	return stats, nil
 }
+
+// GetTenantExpiryOverrides reads the tenant's share expiry settings, in
+// hours, from its share_default_expiry_hours and share_max_expiry_hours rows
+// in tenant_settings. Unset or non-numeric values are returned as invalid.
+func (r *Repository) GetTenantExpiryOverrides(ctx context.Context, tenantID uuid.UUID) (defaultHours, maxHours sql.NullInt64, err error) {
+	query := `
+		SELECT
+			MAX(CASE WHEN key = 'share_default_expiry_hours' AND value #>> '{}' ~ '^[0-9]{1,9}$'
+			         THEN (value #>> '{}')::bigint END),
+			MAX(CASE WHEN key = 'share_max_expiry_hours' AND value #>> '{}' ~ '^[0-9]{1,9}$'
+			         THEN (value #>> '{}')::bigint END)
+		FROM tenant_settings
+		WHERE tenant_id = $1 AND key IN ('share_default_expiry_hours', 'share_max_expiry_hours')`
+
+	err = r.db.QueryRowContext(ctx, query, tenantID).Scan(&defaultHours, &maxHours)
+	if err != nil {
+		r.logger.Error("failed to get tenant share settings", zap.Error(err))
+		return sql.NullInt64{}, sql.NullInt64{}, errors.New(errors.ErrCodeInternal, "failed to get share settings")
+	}
+
+	return defaultHours, maxHours, nil
+}
+
+// ReassignShareOwner makes toUserID the creator of every share fromUserID
+// created in a tenant and returns the IDs of the shares that changed
+func (r *Repository) ReassignShareOwner(ctx context.Context, tenantID uuid.UUID, fromUserID, toUserID string) ([]uuid.UUID, error) {
+	return r.updateUserShares(ctx, "failed to reassign shares", `
+		UPDATE shares SET shared_by = $3, updated_at = $4
+		WHERE tenant_id = $1 AND shared_by = $2
+		RETURNING id`,
+		tenantID, fromUserID, toUserID, time.Now(),
+	)
+}
+
+// RevokeUserShares deactivates every active share a user created in a tenant
+// and returns the IDs of the shares that changed
+func (r *Repository) RevokeUserShares(ctx context.Context, tenantID uuid.UUID, userID string) ([]uuid.UUID, error) {
+	return r.updateUserShares(ctx, "failed to revoke shares", `
+		UPDATE shares SET is_active = false, updated_at = $3
+		WHERE tenant_id = $1 AND shared_by = $2 AND is_active = true
+		RETURNING id`,
+		tenantID, userID, time.Now(),
+	)
+}
+
+// updateUserShares runs an UPDATE ... RETURNING id over a user's shares
+func (r *Repository) updateUserShares(ctx context.Context, message, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error(message, zap.Error(err))
+		return nil, database.WrapError(message, err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, database.WrapError(message, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// PurgeExpiredShareAccess deletes up to limit share access logs older than their retention
+// window: window, or the tenant's longer share_access_retention_days setting
+func (r *Repository) PurgeExpiredShareAccess(ctx context.Context, window time.Duration, limit int) (int64, error) {
+	query := `
+		DELETE FROM share_access
+		WHERE id IN (
+			SELECT a.id
+			FROM share_access a
+			INNER JOIN shares s ON s.id = a.share_id
+			WHERE a.accessed_at < ` + retention.CutoffSQL("s.tenant_id", "share_access_retention_days", 1) + `
+			LIMIT $2
+		)`
+
+	result, err := r.db.ExecContext(ctx, query, retention.Window(window), limit)
+	if err != nil {
+		r.logger.Error("failed to purge expired share access logs", zap.Error(err))
+		return 0, database.WrapError("failed to purge share access logs", err)
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
+
+// PurgeTenant deletes all shares and their access logs of a tenant in a single transaction and
+// returns the number of rows removed
+func (r *Repository) PurgeTenant(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	statements := []string{
+		`DELETE FROM share_access WHERE share_id IN (SELECT id FROM shares WHERE tenant_id = $1)`,
+		`DELETE FROM shares WHERE tenant_id = $1`,
+	}
+
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			result, err := tx.ExecContext(ctx, statement, tenantID)
+			if err != nil {
+				r.logger.Error("failed to purge tenant shares", zap.Error(err))
+				return errors.New(errors.ErrCodeInternal, "failed to purge tenant shares")
+			}
+			rows, _ := result.RowsAffected()
+			deleted += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
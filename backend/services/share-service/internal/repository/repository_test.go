@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	stderrors "errors"
+	"testing"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database/dbtest"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var shareColumns = []string{
+	"id", "tenant_id", "document_id", "share_type", "shared_by",
+	"shared_with", "permission", "share_token", "expires_at",
+	"password", "max_access", "access_count", "is_active",
+	"expire_after_inactivity", "last_accessed_at", "notify_on_access",
+	"created_at", "updated_at",
+}
+
+// shareRow is share as the shares table returns it
+func shareRow(share *models.Share) []driver.Value {
+	return []driver.Value{
+		share.ID.String(), share.TenantID.String(), share.DocumentID.String(), share.ShareType, share.SharedBy,
+		share.SharedWith.String, share.Permission, share.ShareToken.String, nil,
+		nil, nil, int64(share.AccessCount), share.IsActive,
+		nil, nil, share.NotifyOnAccess,
+		share.CreatedAt.Time, share.UpdatedAt.Time,
+	}
+}
+
+func newShare(tenantID, documentID uuid.UUID, token string) *models.Share {
+	now := timeutil.Now()
+	return &models.Share{
+		ID:         uuid.New(),
+		TenantID:   tenantID,
+		DocumentID: documentID,
+		ShareType:  "public",
+		SharedBy:   "owner-1",
+		SharedWith: sql.NullString{String: "alice@example.com", Valid: true},
+		Permission: "view",
+		ShareToken: sql.NullString{String: token, Valid: true},
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func TestCreateShareOnceSameKeyReturnsOneShare(t *testing.T) {
+	db, mock := dbtest.New(t)
+	repo := NewRepository(db, zap.NewNop())
+
+	tenantID, documentID := uuid.New(), uuid.New()
+	scope := tenantID.String() + "|" + documentID.String() + "|alice@example.com|retry-1"
+
+	// The first create finds no share with the key and inserts one
+	first := newShare(tenantID, documentID, "token-1")
+	mock.ExpectBegin()
+	mock.ExpectExec("pg_advisory_xact_lock").WithArgs(scope)
+	mock.ExpectQuery("AND dedupe_key = $4").
+		WithArgs(tenantID.String(), documentID.String(), "alice@example.com", "retry-1").
+		WillReturnRows(shareColumns)
+	mock.ExpectExec("SET dedupe_key = NULL").WillReturnResult(0)
+	mock.ExpectExec("INSERT INTO shares").WillReturnResult(1)
+	mock.ExpectCommit()
+
+	got, created, err := repo.CreateShareOnce(context.Background(), first, "retry-1")
+	if err != nil {
+		t.Fatalf("first CreateShareOnce() error = %v", err)
+	}
+	if !created || got != first {
+		t.Fatalf("first CreateShareOnce() = (%v, created %v), want the new share", got.ID, created)
+	}
+
+	// The retry finds it under the same lock scope and creates nothing
+	retry := newShare(tenantID, documentID, "token-2")
+	mock.ExpectBegin()
+	mock.ExpectExec("pg_advisory_xact_lock").WithArgs(scope)
+	mock.ExpectQuery("AND dedupe_key = $4").WillReturnRows(shareColumns, shareRow(first))
+	mock.ExpectCommit()
+
+	got, created, err = repo.CreateShareOnce(context.Background(), retry, "retry-1")
+	if err != nil {
+		t.Fatalf("retried CreateShareOnce() error = %v", err)
+	}
+	if created {
+		t.Error("retried CreateShareOnce() created a second share")
+	}
+	if got.ID != first.ID || got.ShareToken.String != "token-1" {
+		t.Errorf("retried CreateShareOnce() = share %v token %q, want share %v token token-1", got.ID, got.ShareToken.String, first.ID)
+	}
+}
+
+func TestCreateShareOnceRollsBack(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(mock *dbtest.Mock)
+	}{
+		{"lookup fails", func(mock *dbtest.Mock) {
+			mock.ExpectQuery("AND dedupe_key = $4").WillReturnError(stderrors.New("connection reset"))
+		}},
+		{"insert conflicts", func(mock *dbtest.Mock) {
+			mock.ExpectQuery("AND dedupe_key = $4").WillReturnRows(shareColumns)
+			mock.ExpectExec("SET dedupe_key = NULL").WillReturnResult(0)
+			mock.ExpectExec("INSERT INTO shares").WillReturnError(stderrors.New("duplicate key value violates unique constraint"))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			repo := NewRepository(db, zap.NewNop())
+
+			mock.ExpectBegin()
+			mock.ExpectExec("pg_advisory_xact_lock")
+			tt.script(mock)
+			mock.ExpectRollback()
+
+			share := newShare(uuid.New(), uuid.New(), "token-1")
+			if _, _, err := repo.CreateShareOnce(context.Background(), share, "retry-1"); err == nil {
+				t.Fatal("CreateShareOnce() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestIncrementAccessCount(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        [][]driver.Value // rows the conditional UPDATE returns
+		queryErr    error
+		wantCounted bool
+		wantErr     bool
+	}{
+		{"share below its limit is counted", [][]driver.Value{{int64(1)}}, nil, true, false},
+		{"share at its limit is not counted", nil, nil, false, false},
+		{"failed update is an error", nil, stderrors.New("connection reset"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			repo := NewRepository(db, zap.NewNop())
+			shareID := uuid.New()
+
+			// The limit is checked in the same statement as the increment
+			claim := mock.ExpectQuery("WHERE id = $2 AND (max_access IS NULL OR access_count < max_access)").
+				WithArgs(dbtest.Any, shareID.String())
+			if tt.queryErr != nil {
+				claim.WillReturnError(tt.queryErr)
+			} else {
+				claim.WillReturnRows([]string{"access_count"}, tt.rows...)
+			}
+
+			counted, err := repo.IncrementAccessCount(context.Background(), shareID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IncrementAccessCount() error = %v, want error %v", err, tt.wantErr)
+			}
+			if counted != tt.wantCounted {
+				t.Errorf("IncrementAccessCount() = %v, want %v", counted, tt.wantCounted)
+			}
+		})
+	}
+}
@@ -2,17 +2,25 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/models"
 	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/repository"
 	"go.uber.org/zap"
@@ -21,23 +29,65 @@ import (
 const (
 	shareCacheTTL = 30 * time.Minute
 	tokenLength   = 32
+
+	// expiryPolicyCacheTTL bounds how long a tenant's expiry settings are cached
+	expiryPolicyCacheTTL = 5 * time.Minute
 	baseURL       = "https://app.docmanager.com/share" // TODO: Make configurable
+
+	// featureAdvancedSharing gates password, expiry and access-limit options
+	featureAdvancedSharing = "advanced_sharing"
+
+	// notify_on_access: one notification per share per interval
+	shareAccessNotifyInterval = 1 * time.Hour
+	notificationTimeout       = 10 * time.Second
+
+	// accessNotificationJobType is the worker job that sends a share access notification
+	accessNotificationJobType = "share.access_notification"
+
+	// shareDownloadURLExpiry bounds the presigned URL a share download
+	// redirects to, so the link itself stays the only way in
+	shareDownloadURLExpiry = 5 * time.Minute
 )
 
 // Service handles share business logic
 type Service struct {
-	repo   *repository.Repository
-	cache  *cache.Cache
-	logger *zap.Logger
+	repo          *repository.Repository
+	cache         *cache.Cache
+	quota         *client.QuotaClient
+	storage       *client.StorageClient
+	documents     *client.DocumentClient
+	rbac          *client.RBACClient
+	notifications *client.NotificationClient // nil when no notification service is configured
+	expiry        models.ExpiryPolicy        // global defaults; tenant settings override
+	notifyIP      bool
+	enforceBW     bool // block downloads once the owner's monthly bandwidth is used up
+	jobs          *worker.Pool
+	logger        *zap.Logger
+
+	// claimNotify takes a share's access notification throttle slot (cache SetNX)
+	claimNotify func(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
 }
 
-// NewService creates a new share service
-func NewService(repo *repository.Repository, cache *cache.Cache, logger *zap.Logger) *Service {
-	return &Service{
-		repo:   repo,
-		cache:  cache,
-		logger: logger,
+// NewService creates a new share service and registers its background jobs on the pool
+func NewService(repo *repository.Repository, cache *cache.Cache, quota *client.QuotaClient, storage *client.StorageClient, documents *client.DocumentClient, rbac *client.RBACClient, notifications *client.NotificationClient, cfg config.SharesConfig, jobs *worker.Pool, logger *zap.Logger) *Service {
+	s := &Service{
+		repo:          repo,
+		cache:         cache,
+		quota:         quota,
+		storage:       storage,
+		documents:     documents,
+		rbac:          rbac,
+		notifications: notifications,
+		claimNotify:   cache.SetNX,
+		expiry:        models.ExpiryPolicy{DefaultExpiry: cfg.DefaultExpiry, MaxExpiry: cfg.MaxExpiry},
+		notifyIP:      cfg.AccessNotifyIncludeIP,
+		enforceBW:     cfg.EnforceBandwidthQuota,
+		jobs:          jobs,
+		logger:        logger,
 	}
+	jobs.Register(accessNotificationJobType, s.handleAccessNotificationJob)
+
+	return s
 }
 
 // CreateShare creates a new share
@@ -51,17 +101,33 @@ func (s *Service) CreateShare(ctx context.Context, req *models.CreateShareReques
 		return nil, errors.Validationf("invalid document_id")
 	}
 
-	// Parse expiration time if provided
-	var expiresAt *time.Time
-	if req.ExpiresAt != "" {
-		parsed, err := time.Parse(time.RFC3339, req.ExpiresAt)
-		if err != nil {
-			return nil, errors.Validationf("invalid expires_at format")
-		}
-		if parsed.Before(time.Now()) {
-			return nil, errors.Validationf("expires_at must be in the future")
+	// Shares may only reference documents of the caller's tenant
+	if err := s.documents.RequireDocument(ctx, documentID.String()); err != nil {
+		return nil, err
+	}
+
+	// Advanced options require the advanced_sharing feature
+	if req.ExpiresAt != "" || req.Password != "" || req.MaxAccess > 0 || req.ExpireAfterInactivity != "" {
+		if err := s.quota.CheckFeature(ctx, featureAdvancedSharing); err != nil {
+			return nil, err
 		}
-		expiresAt = &parsed
+	}
+
+	// Parse expiration time if provided; the tenant's expiry policy supplies
+	// a default and caps explicit values
+	expiresAt, err := parseShareExpiry(req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	expiryDefaulted, err := s.applyExpiryPolicy(ctx, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse inactivity window if provided
+	inactivityWindow, err := parseInactivityWindow(req.ExpireAfterInactivity)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create share
@@ -74,8 +140,8 @@ func (s *Service) CreateShare(ctx context.Context, req *models.CreateShareReques
 		Permission:  req.Permission,
 		AccessCount: 0,
 		IsActive:    true,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   timeutil.Now(),
+		UpdatedAt:   timeutil.Now(),
 	}
 
 	// Set shared_with for user shares
@@ -96,9 +162,13 @@ func (s *Service) CreateShare(ctx context.Context, req *models.CreateShareReques
 	}
 
 	// Set expiration
-	if expiresAt != nil {
-		share.ExpiresAt.Time = *expiresAt
-		share.ExpiresAt.Valid = true
+	share.ExpiresAt.NullTime = expiresAt
+	share.NotifyOnAccess = req.NotifyOnAccess
+
+	// Set inactivity expiry
+	if inactivityWindow > 0 {
+		share.ExpireAfterInactivity.Int64 = int64(inactivityWindow / time.Second)
+		share.ExpireAfterInactivity.Valid = true
 	}
 
 	// Hash password if provided
@@ -118,24 +188,39 @@ func (s *Service) CreateShare(ctx context.Context, req *models.CreateShareReques
 		share.MaxAccess.Valid = true
 	}
 
-	// Create share in database
-	if err := s.repo.CreateShare(ctx, share); err != nil {
+	// Create share in database, reusing a share created with the same dedupe key
+	created := true
+	if req.DedupeKey != "" {
+		share, created, err = s.repo.CreateShareOnce(ctx, share, req.DedupeKey)
+	} else {
+		err = s.repo.CreateShare(ctx, share)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	logger.InfoContext(ctx, "share created",
-		zap.String("share_id", share.ID.String()),
-		zap.String("document_id", documentID.String()),
-		zap.String("share_type", req.ShareType),
-	)
+	if !created {
+		logger.InfoContext(ctx, "share creation deduplicated",
+			zap.String("share_id", share.ID.String()),
+			zap.String("document_id", documentID.String()),
+		)
+		expiryDefaulted = false
+	} else {
+		logger.InfoContext(ctx, "share created",
+			zap.String("share_id", share.ID.String()),
+			zap.String("document_id", documentID.String()),
+			zap.String("share_type", req.ShareType),
+		)
+	}
 
 	// Build response
 	response := &models.CreateShareResponse{
-		ID:         share.ID,
-		DocumentID: documentID,
-		ShareType:  req.ShareType,
-		Permission: req.Permission,
-		CreatedAt:  share.CreatedAt,
+		ID:           share.ID,
+		DocumentID:   documentID,
+		ShareType:    share.ShareType,
+		Permission:   share.Permission,
+		CreatedAt:    share.CreatedAt,
+		Deduplicated: !created,
 	}
 
 	if share.ShareToken.Valid {
@@ -145,7 +230,9 @@ func (s *Service) CreateShare(ctx context.Context, req *models.CreateShareReques
 	}
 
 	if share.ExpiresAt.Valid {
-		response.ExpiresAt = &share.ExpiresAt.Time
+		expiresAt := timeutil.From(share.ExpiresAt.Time)
+		response.ExpiresAt = &expiresAt
+		response.ExpiryDefaulted = expiryDefaulted
 	}
 
 	return response, nil
@@ -176,8 +263,114 @@ func (s *Service) GetShare(ctx context.Context, shareID uuid.UUID) (*models.Shar
 
 // AccessShare accesses a share using a token
 func (s *Service) AccessShare(ctx context.Context, req *models.AccessShareRequest, ipAddress, userAgent string) (*models.AccessShareResponse, error) {
-	// Get share by token
-	share, err := s.repo.GetShareByToken(ctx, req.ShareToken)
+	share, err := s.openShare(ctx, req.ShareToken, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	accessLog, err := s.recordAccess(ctx, share, ipAddress, userAgent, "view")
+	if err != nil {
+		return nil, err
+	}
+
+	if share.NotifyOnAccess {
+		s.notifyAccess(ctx, share, accessLog)
+	}
+
+	// TODO: Get document name from document service
+	response := &models.AccessShareResponse{
+		DocumentID: share.DocumentID,
+		DocumentName: "Document", // Placeholder
+		Permission: share.Permission,
+		ExpiresAt:  timeutil.From(time.Now().Add(1 * time.Hour)), // Placeholder
+	}
+
+	if share.Permission == "download" {
+		response.DownloadURL = fmt.Sprintf("%s/%s/download", baseURL, req.ShareToken)
+	}
+
+	return response, nil
+}
+
+// DownloadShare resolves a download share to a short-lived URL of the
+// shared document's file. The download is counted and logged like an access,
+// and the file size is recorded as bandwidth of the share's tenant.
+func (s *Service) DownloadShare(ctx context.Context, token, password, ipAddress, userAgent string) (*client.DocumentDownload, error) {
+	share, err := s.openShare(ctx, token, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.Permission != "download" {
+		return nil, errors.Forbiddenf("share link does not allow downloads")
+	}
+
+	// Public access carries no tenant; storage and quota act on the share's
+	ctx = middleware.WithTenantID(ctx, share.TenantID.String())
+
+	// Resolve the file before claiming an access, so a missing file does not
+	// use up a limited share
+	download, err := s.storage.DocumentDownloadURL(ctx, share.DocumentID.String(), shareDownloadURLExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	// The presigned URL is fetched without passing through this service, so
+	// bandwidth is counted at issuance by file size
+	if err := s.chargeBandwidth(ctx, share, download.FileSize); err != nil {
+		return nil, err
+	}
+
+	accessLog, err := s.recordAccess(ctx, share, ipAddress, userAgent, "download")
+	if err != nil {
+		if s.enforceBW {
+			if err := s.quota.AdjustReservation(ctx, "bandwidth", download.FileSize, 0); err != nil {
+				logger.WarnContext(ctx, "failed to release share download bandwidth",
+					zap.String("share_id", share.ID.String()),
+					zap.Error(err),
+				)
+			}
+		}
+		return nil, err
+	}
+
+	if share.NotifyOnAccess {
+		s.notifyAccess(ctx, share, accessLog)
+	}
+
+	return download, nil
+}
+
+// chargeBandwidth adds a share download to the owning tenant's monthly
+// bandwidth. When enforced, a download that does not fit the tenant's quota is
+// refused; otherwise the usage is recorded best effort.
+func (s *Service) chargeBandwidth(ctx context.Context, share *models.Share, size int64) error {
+	if !s.enforceBW {
+		if err := s.quota.IncrementUsage(ctx, "bandwidth", size); err != nil {
+			logger.WarnContext(ctx, "failed to record share download bandwidth",
+				zap.String("share_id", share.ID.String()),
+				zap.Error(err),
+			)
+		}
+		return nil
+	}
+
+	err := s.quota.ReserveQuota(ctx, map[string]int64{"bandwidth": size})
+	if appErr := errors.FromError(err); appErr != nil && appErr.Code == errors.ErrCodeForbidden {
+		logger.InfoContext(ctx, "share download blocked by bandwidth quota",
+			zap.String("share_id", share.ID.String()),
+			zap.String("tenant_id", share.TenantID.String()),
+		)
+		return errors.Forbiddenf("bandwidth limit reached: downloads from this share are unavailable until next month").
+			WithMeta("reason", "bandwidth_limit_reached")
+	}
+	return err
+}
+
+// openShare loads the share behind token and checks that it can be accessed
+// with password
+func (s *Service) openShare(ctx context.Context, token, password string) (*models.Share, error) {
+	share, err := s.repo.GetShareByToken(ctx, token)
 	if err != nil {
 		return nil, errors.NotFoundf("share link not found")
 	}
@@ -192,24 +385,40 @@ func (s *Service) AccessShare(ctx context.Context, req *models.AccessShareReques
 		return nil, errors.Forbiddenf("share link has expired")
 	}
 
-	// Check max access limit
+	// Check inactivity expiration
+	if share.InactivityExpired(time.Now()) {
+		return nil, errors.Forbiddenf("share link has expired due to inactivity")
+	}
+
+	// Check max access limit (fast path; the increment below is authoritative)
 	if share.MaxAccess.Valid && share.AccessCount >= int(share.MaxAccess.Int64) {
 		return nil, errors.Forbiddenf("share link has reached maximum access limit")
 	}
 
 	// Verify password if required
 	if share.Password.Valid {
-		if req.Password == "" {
+		if password == "" {
 			return nil, errors.Unauthorizedf("password required")
 		}
-		if err := bcrypt.CompareHashAndPassword([]byte(share.Password.String), []byte(req.Password)); err != nil {
+		if err := bcrypt.CompareHashAndPassword([]byte(share.Password.String), []byte(password)); err != nil {
 			return nil, errors.Unauthorizedf("invalid password")
 		}
 	}
 
-	// Increment access count
-	if err := s.repo.IncrementAccessCount(ctx, share.ID); err != nil {
-		s.logger.Error("failed to increment access count", zap.Error(err))
+	return share, nil
+}
+
+// recordAccess claims an access of share and logs it under action
+func (s *Service) recordAccess(ctx context.Context, share *models.Share, ipAddress, userAgent, action string) (*models.ShareAccess, error) {
+	// Claim an access. The conditional increment closes the race where
+	// parallel requests all pass the check above, so a max_access of 1
+	// (one-time view) admits exactly one access.
+	counted, err := s.repo.IncrementAccessCount(ctx, share.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !counted {
+		return nil, errors.Forbiddenf("share link has reached maximum access limit")
 	}
 
 	// Log access
@@ -219,8 +428,8 @@ func (s *Service) AccessShare(ctx context.Context, req *models.AccessShareReques
 		ShareID:    share.ID,
 		IPAddress:  ipAddress,
 		UserAgent:  userAgent,
-		Action:     "view",
-		AccessedAt: time.Now(),
+		Action:     action,
+		AccessedAt: timeutil.Now(),
 	}
 	if userID != "" {
 		accessLog.AccessedBy.String = userID
@@ -231,19 +440,66 @@ func (s *Service) AccessShare(ctx context.Context, req *models.AccessShareReques
 		s.logger.Error("failed to log share access", zap.Error(err))
 	}
 
-	// TODO: Get document name and download URL from document service
-	response := &models.AccessShareResponse{
-		DocumentID: share.DocumentID,
-		DocumentName: "Document", // Placeholder
-		Permission: share.Permission,
-		ExpiresAt:  time.Now().Add(1 * time.Hour), // Placeholder
+	return accessLog, nil
+}
+
+// notifyAccess tells the share creator that their share was opened. It is
+// best effort: at most one notification per share per
+// shareAccessNotifyInterval, queued on the worker pool so it can neither
+// delay nor fail the access.
+func (s *Service) notifyAccess(ctx context.Context, share *models.Share, access *models.ShareAccess) {
+	if s.notifications == nil {
+		return
 	}
 
-	if share.Permission == "download" {
-		response.DownloadURL = "https://storage.docmanager.com/download/placeholder" // Placeholder
+	// Public access carries no tenant; the share's tenant scopes the throttle
+	// and the notification
+	key := cache.TenantKey(share.TenantID.String(), "share_access_notified", share.ID.String())
+	first, err := s.claimNotify(ctx, key, timeutil.Format(access.AccessedAt.Time), shareAccessNotifyInterval)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to throttle share access notification", zap.String("share_id", share.ID.String()), zap.Error(err))
+		return
+	}
+	if !first {
+		return
 	}
 
-	return response, nil
+	accessedAt := timeutil.Format(access.AccessedAt.Time)
+	notification := &client.Notification{
+		UserID: share.SharedBy,
+		Type:   "share.accessed",
+		Title:  "Your shared document was viewed",
+		Body:   fmt.Sprintf("A share of your document was opened at %s.", accessedAt),
+		Data: map[string]string{
+			"share_id":    share.ID.String(),
+			"document_id": share.DocumentID.String(),
+			"accessed_at": accessedAt,
+		},
+	}
+	if s.notifyIP && access.IPAddress != "" {
+		notification.Data["ip_address"] = access.IPAddress
+	}
+
+	if err := s.jobs.Submit(middleware.WithTenantID(ctx, share.TenantID.String()), accessNotificationJobType, notification); err != nil {
+		logger.WarnContext(ctx, "failed to queue share access notification",
+			zap.String("share_id", share.ID.String()),
+			zap.Error(err),
+		)
+	}
+}
+
+// handleAccessNotificationJob sends a queued share access notification. A
+// retry after a send that timed out may deliver it twice.
+func (s *Service) handleAccessNotificationJob(ctx context.Context, data json.RawMessage) error {
+	var notification client.Notification
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return fmt.Errorf("invalid access notification payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+
+	return s.notifications.Send(ctx, &notification)
 }
 
 // ListShares retrieves shares with filtering
@@ -260,45 +516,149 @@ func (s *Service) ListShares(ctx context.Context, params *models.ListSharesParam
 	return shares, total, nil
 }
 
-// UpdateShare updates a share
+// ListSharesByMe lists the shares the caller created
+func (s *Service) ListSharesByMe(ctx context.Context, params *models.ListSharesParams) ([]models.Share, int64, error) {
+	userID := middleware.GetUserID(ctx)
+	if userID == "" {
+		return nil, 0, errors.ErrUnauthorized
+	}
+
+	params.SharedBy = userID
+	params.SharedWithAnyOf = nil
+
+	return s.ListShares(ctx, params)
+}
+
+// ListSharesWithMe lists the shares targeted at the caller's user ID or
+// email. Share tokens are left out; recipients open these shares as
+// themselves, and a token would let them pass the link on.
+func (s *Service) ListSharesWithMe(ctx context.Context, params *models.ListSharesParams) ([]models.Share, int64, error) {
+	userID := middleware.GetUserID(ctx)
+	if userID == "" {
+		return nil, 0, errors.ErrUnauthorized
+	}
+
+	params.SharedBy = ""
+	params.SharedWith = ""
+	params.SharedWithAnyOf = []string{strings.ToLower(userID)}
+	if email := middleware.GetUserEmail(ctx); email != "" {
+		params.SharedWithAnyOf = append(params.SharedWithAnyOf, strings.ToLower(email))
+	}
+
+	shares, total, err := s.ListShares(ctx, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range shares {
+		shares[i].ShareToken = sql.NullString{}
+	}
+
+	return shares, total, nil
+}
+
+// UpdateShare replaces a share's settings; omitted limits are cleared
 func (s *Service) UpdateShare(ctx context.Context, shareID uuid.UUID, req *models.UpdateShareRequest) error {
-	tenantID := getTenantID(ctx)
+	expiresAt, err := parseShareExpiry(req.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	// A defaulted expiry is policy, not an advanced option the caller chose
+	explicitExpiry := expiresAt.Valid
+	if _, err := s.applyExpiryPolicy(ctx, &expiresAt); err != nil {
+		return err
+	}
 
-	// Verify share exists
-	if _, err := s.repo.GetShare(ctx, tenantID, shareID); err != nil {
+	window, err := parseInactivityWindow(req.ExpireAfterInactivity)
+	if err != nil {
 		return err
 	}
 
-	// Build updates map
+	maxAccess := sql.NullInt64{}
+	if req.MaxAccess != nil {
+		maxAccess = sql.NullInt64{Int64: int64(*req.MaxAccess), Valid: true}
+	}
+
+	updates := map[string]interface{}{
+		"permission":              req.Permission,
+		"expires_at":              expiresAt,
+		"max_access":              maxAccess,
+		"is_active":               *req.IsActive,
+		"expire_after_inactivity": inactivityValue(window),
+		"notify_on_access":        req.NotifyOnAccess,
+	}
+
+	advanced := explicitExpiry || maxAccess.Valid || window > 0
+	return s.applyShareUpdates(ctx, shareID, updates, advanced)
+}
+
+// PatchShare applies a partial update; only provided fields change
+func (s *Service) PatchShare(ctx context.Context, shareID uuid.UUID, req *models.PatchShareRequest) error {
 	updates := make(map[string]interface{})
+	advanced := false
 
-	if req.Permission != "" {
-		updates["permission"] = req.Permission
+	if req.Permission != nil {
+		updates["permission"] = *req.Permission
 	}
 
-	if req.ExpiresAt != "" {
-		parsed, err := time.Parse(time.RFC3339, req.ExpiresAt)
+	if req.ExpiresAt != nil {
+		expiresAt, err := parseShareExpiry(*req.ExpiresAt)
 		if err != nil {
-			return errors.Validationf("invalid expires_at format")
+			return err
 		}
-		if parsed.Before(time.Now()) {
-			return errors.Validationf("expires_at must be in the future")
+		advanced = advanced || expiresAt.Valid
+		if _, err := s.applyExpiryPolicy(ctx, &expiresAt); err != nil {
+			return err
 		}
-		updates["expires_at"] = parsed
+		updates["expires_at"] = expiresAt
 	}
 
 	if req.MaxAccess != nil {
 		updates["max_access"] = *req.MaxAccess
+		advanced = true
 	}
 
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
 
+	if req.ExpireAfterInactivity != nil {
+		window, err := parseInactivityWindow(*req.ExpireAfterInactivity)
+		if err != nil {
+			return err
+		}
+		updates["expire_after_inactivity"] = inactivityValue(window)
+		advanced = advanced || window > 0
+	}
+
+	if req.NotifyOnAccess != nil {
+		updates["notify_on_access"] = *req.NotifyOnAccess
+	}
+
 	if len(updates) == 0 {
 		return nil
 	}
 
+	return s.applyShareUpdates(ctx, shareID, updates, advanced)
+}
+
+// applyShareUpdates persists share changes, enforcing the advanced sharing
+// feature when expiry or access limits are being set
+func (s *Service) applyShareUpdates(ctx context.Context, shareID uuid.UUID, updates map[string]interface{}, advanced bool) error {
+	tenantID := getTenantID(ctx)
+
+	// Verify share exists
+	if _, err := s.repo.GetShare(ctx, tenantID, shareID); err != nil {
+		return err
+	}
+
+	// Advanced options require the advanced_sharing feature
+	if advanced {
+		if err := s.quota.CheckFeature(ctx, featureAdvancedSharing); err != nil {
+			return err
+		}
+	}
+
 	// Update share
 	if err := s.repo.UpdateShare(ctx, tenantID, shareID, updates); err != nil {
 		return err
@@ -335,6 +695,55 @@ func (s *Service) RevokeShare(ctx context.Context, shareID uuid.UUID) error {
 	return nil
 }
 
+// RotateShareToken replaces a public share's token, so the old link stops
+// working while settings and access history stay with the share. Only the
+// share's creator or a user with share:manage may rotate it.
+func (s *Service) RotateShareToken(ctx context.Context, shareID uuid.UUID) (*models.RotateShareTokenResponse, error) {
+	tenantID := getTenantID(ctx)
+	userID := middleware.GetUserID(ctx)
+
+	share, err := s.repo.GetShare(ctx, tenantID, shareID)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.SharedBy != userID {
+		allowed, err := s.rbac.CheckPermission(ctx, userID, "share", "manage")
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, errors.Forbiddenf("only the share creator or an admin can rotate its link")
+		}
+	}
+
+	if share.ShareType != "public" || !share.ShareToken.Valid {
+		return nil, errors.Validationf("only public shares have a link to rotate")
+	}
+
+	token, err := generateSecureToken(tokenLength)
+	if err != nil {
+		s.logger.Error("failed to generate share token", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to generate share token")
+	}
+
+	if err := s.repo.UpdateShare(ctx, tenantID, shareID, map[string]interface{}{"share_token": token}); err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	cacheKey := cache.TenantKey(tenantID.String(), "share", shareID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	logger.InfoContext(ctx, "share token rotated", zap.String("share_id", shareID.String()))
+
+	return &models.RotateShareTokenResponse{
+		ID:         shareID,
+		ShareToken: token,
+		ShareURL:   fmt.Sprintf("%s/%s", baseURL, token),
+	}, nil
+}
+
 // DeleteShare deletes a share
 func (s *Service) DeleteShare(ctx context.Context, shareID uuid.UUID) error {
 	tenantID := getTenantID(ctx)
@@ -352,8 +761,10 @@ func (s *Service) DeleteShare(ctx context.Context, shareID uuid.UUID) error {
 	return nil
 }
 
-// GetShareAccessLogs retrieves access logs for a share
-func (s *Service) GetShareAccessLogs(ctx context.Context, shareID uuid.UUID, limit int) ([]models.ShareAccess, error) {
+// GetShareAccessLogs retrieves a page of access logs for a share. The share
+// is resolved within the caller's tenant before any logs are read, so shares
+// from other tenants surface as not found.
+func (s *Service) GetShareAccessLogs(ctx context.Context, shareID uuid.UUID, params models.ListAccessLogsParams) (*models.AccessLogPage, error) {
 	tenantID := getTenantID(ctx)
 
 	// Verify share exists and belongs to tenant
@@ -361,16 +772,57 @@ func (s *Service) GetShareAccessLogs(ctx context.Context, shareID uuid.UUID, lim
 		return nil, err
 	}
 
-	if limit <= 0 || limit > 100 {
-		limit = 50
+	params.Normalize()
+
+	var cursor *models.AccessLogCursor
+	if params.Cursor != "" {
+		c, err := models.DecodeAccessLogCursor(params.Cursor)
+		if err != nil {
+			return nil, errors.Validationf("invalid cursor")
+		}
+		cursor = c
 	}
 
-	logs, err := s.repo.GetShareAccessLogs(ctx, shareID, limit)
+	from, err := parseAccessLogTime(params.From, "from")
 	if err != nil {
 		return nil, err
 	}
+	to, err := parseAccessLogTime(params.To, "to")
+	if err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && from.After(*to) {
+		return nil, errors.Validationf("from must not be after to")
+	}
 
-	return logs, nil
+	// Fetch one extra row to learn whether another page follows
+	logs, err := s.repo.GetShareAccessLogs(ctx, shareID, cursor, from, to, params.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.repo.CountShareAccessLogs(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.AccessLogPage{Logs: logs, Total: total}
+	if len(logs) > params.Limit {
+		page.Logs = logs[:params.Limit]
+		last := page.Logs[len(page.Logs)-1]
+		page.NextCursor = models.AccessLogCursor{AccessedAt: last.AccessedAt.Time, ID: last.ID}.Encode()
+	}
+
+	return page, nil
+}
+
+// parseAccessLogTime parses an optional RFC3339 access log bound
+func parseAccessLogTime(value, field string) (*time.Time, error) {
+	parsed, ok, err := validator.ParseTimeParam(field, value)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &parsed, nil
 }
 
 // GetShareStats retrieves share statistics
@@ -403,6 +855,11 @@ func (s *Service) VerifyShareToken(ctx context.Context, token string, password s
 		return &models.VerifyShareTokenResponse{Valid: false}, nil
 	}
 
+	// Check inactivity expiration
+	if share.InactivityExpired(time.Now()) {
+		return &models.VerifyShareTokenResponse{Valid: false}, nil
+	}
+
 	// Check max access
 	if share.MaxAccess.Valid && share.AccessCount >= int(share.MaxAccess.Int64) {
 		return &models.VerifyShareTokenResponse{Valid: false}, nil
@@ -426,12 +883,77 @@ func (s *Service) VerifyShareToken(ctx context.Context, token string, password s
 	}
 
 	if share.ExpiresAt.Valid {
-		response.ExpiresAt = &share.ExpiresAt.Time
+		expiresAt := timeutil.From(share.ExpiresAt.Time)
+		response.ExpiresAt = &expiresAt
 	}
 
 	return response, nil
 }
 
+// ReassignOwner reassigns or revokes every share a user created in the tenant
+// in ctx (internal use, called when a member is removed from the tenant)
+func (s *Service) ReassignOwner(ctx context.Context, req *models.ReassignOwnerRequest) (*models.ReassignOwnerResponse, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return nil, errors.Validationf("tenant is required")
+	}
+
+	var ids []uuid.UUID
+	var err error
+	switch req.Action {
+	case models.OwnerActionReassign:
+		if req.ToUserID == "" {
+			return nil, errors.Validationf("to_user_id is required to reassign shares").WithField("to_user_id", "required")
+		}
+		if req.ToUserID == req.FromUserID {
+			return nil, errors.Validationf("cannot reassign shares to the same user").WithField("to_user_id", "must differ from from_user_id")
+		}
+		ids, err = s.repo.ReassignShareOwner(ctx, tenantID, req.FromUserID, req.ToUserID)
+	case models.OwnerActionRevoke:
+		ids, err = s.repo.RevokeUserShares(ctx, tenantID, req.FromUserID)
+	default:
+		return nil, errors.Validationf("unknown action %q", req.Action).WithField("action", "must be reassign or revoke")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalidate cache
+	for _, id := range ids {
+		_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "share", id.String()))
+	}
+
+	logger.InfoContext(ctx, "user shares transferred",
+		zap.String("action", req.Action),
+		zap.String("from_user_id", req.FromUserID),
+		zap.String("to_user_id", req.ToUserID),
+		zap.Int("count", len(ids)),
+	)
+
+	return &models.ReassignOwnerResponse{Action: req.Action, Succeeded: int64(len(ids))}, nil
+}
+
+// PurgeTenant deletes all shares and access logs of the tenant in ctx (internal use,
+// called by tenant deletion); it is safe to call again after a partial run
+func (s *Service) PurgeTenant(ctx context.Context) (int64, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return 0, errors.Validationf("tenant is required")
+	}
+
+	deleted, err := s.repo.PurgeTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.InfoContext(ctx, "tenant shares purged",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int64("deleted", deleted),
+	)
+
+	return deleted, nil
+}
+
 // Helper functions
 
 func getTenantID(ctx context.Context) uuid.UUID {
@@ -440,6 +962,110 @@ func getTenantID(ctx context.Context) uuid.UUID {
 	return tenantID
 }
 
+// parseInactivityWindow parses an inactivity duration, returning zero when unset
+func parseInactivityWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Validationf("invalid expire_after_inactivity format")
+	}
+	if window < time.Second {
+		return 0, errors.Validationf("expire_after_inactivity must be positive")
+	}
+	return window, nil
+}
+
+// parseShareExpiry parses an RFC3339 expiry that must lie in the future; an
+// empty value is stored as NULL
+func parseShareExpiry(value string) (sql.NullTime, error) {
+	parsed, ok, err := validator.ParseTimeParam("expires_at", value)
+	if err != nil || !ok {
+		return sql.NullTime{}, err
+	}
+	if parsed.Before(time.Now()) {
+		return sql.NullTime{}, errors.Validationf("expires_at must be in the future").
+			WithField("expires_at", "expires_at must be in the future")
+	}
+	return sql.NullTime{Time: parsed, Valid: true}, nil
+}
+
+// expiryPolicy returns the share expiry policy of the tenant in ctx: the
+// global defaults with any tenant settings applied on top
+func (s *Service) expiryPolicy(ctx context.Context) (models.ExpiryPolicy, error) {
+	tenantID := getTenantID(ctx)
+	cacheKey := cache.TenantKey(tenantID.String(), "share_expiry_policy")
+
+	var policy models.ExpiryPolicy
+	if err := s.cache.Get(ctx, cacheKey, &policy); err == nil {
+		return policy, nil
+	}
+
+	defaultHours, maxHours, err := s.repo.GetTenantExpiryOverrides(ctx, tenantID)
+	if err != nil {
+		return models.ExpiryPolicy{}, err
+	}
+
+	policy = s.expiry
+	if defaultHours.Valid {
+		policy.DefaultExpiry = time.Duration(defaultHours.Int64) * time.Hour
+	}
+	if maxHours.Valid {
+		policy.MaxExpiry = time.Duration(maxHours.Int64) * time.Hour
+	}
+
+	_ = s.cache.Set(ctx, cacheKey, policy, expiryPolicyCacheTTL)
+
+	return policy, nil
+}
+
+// applyExpiryPolicy fills a missing expiry with the policy default (never
+// beyond the maximum) and rejects an explicit expiry beyond the maximum.
+// Reports whether the default was applied.
+func (s *Service) applyExpiryPolicy(ctx context.Context, expiresAt *sql.NullTime) (bool, error) {
+	policy, err := s.expiryPolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if !expiresAt.Valid {
+		expiry := policy.DefaultExpiry
+		if policy.MaxExpiry > 0 && (expiry <= 0 || expiry > policy.MaxExpiry) {
+			expiry = policy.MaxExpiry
+		}
+		if expiry <= 0 {
+			return false, nil
+		}
+		*expiresAt = sql.NullTime{Time: now.Add(expiry), Valid: true}
+		return true, nil
+	}
+
+	if policy.MaxExpiry > 0 && expiresAt.Time.After(now.Add(policy.MaxExpiry)) {
+		return false, errors.Validationf("expires_at must be within %s from now", humanizeDuration(policy.MaxExpiry)).
+			WithField("expires_at", "beyond maximum share lifetime")
+	}
+
+	return false, nil
+}
+
+// humanizeDuration formats whole days as "N days" and anything else as a Go duration
+func humanizeDuration(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// inactivityValue converts an inactivity window to stored seconds; zero is stored as NULL
+func inactivityValue(window time.Duration) sql.NullInt64 {
+	if window <= 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(window / time.Second), Valid: true}
+}
+
 func generateSecureToken(length int) (string, error) {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
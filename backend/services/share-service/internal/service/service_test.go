@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/database/dbtest"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
+	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/models"
+	"github.com/SidahmedSeg/document-manager/backend/services/share-service/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// sentNotification is a notification as received by the fake notification service
+type sentNotification struct {
+	userID       string
+	notification client.Notification
+}
+
+// newNotifyTestService returns a service that queues access notifications
+// on a real pool and sends them to a fake notification service behind the
+// services' middleware chain. The throttle is an in-memory SetNX.
+func newNotifyTestService(t *testing.T, notifyIP bool) (*Service, func() []sentNotification) {
+	t.Helper()
+	const secret = "test-secret"
+	log := &logger.Logger{Logger: zap.NewNop()}
+
+	var mu sync.Mutex
+	var sent []sentNotification
+	var handler http.Handler = middleware.InternalAuth(secret, time.Minute, log)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var n client.Notification
+			if err := response.DecodeJSON(r, &n); err != nil {
+				response.InvalidBody(w, err)
+				return
+			}
+			mu.Lock()
+			sent = append(sent, sentNotification{middleware.GetUserID(r.Context()), n})
+			mu.Unlock()
+			response.Success(w, nil)
+		}),
+	)
+	handler = middleware.ExtractAuthHeaders(log)(handler)
+	handler = middleware.VerifyInternalRequest(secret, time.Minute, log)(handler)
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	claimed := make(map[string]bool)
+	jobs := worker.New("share-service", config.WorkerConfig{Concurrency: 1, QueueSize: 8, MaxAttempts: 1}, nil, zap.NewNop())
+	s := &Service{
+		notifications: client.NewNotificationClient(client.New("notification-service", server.URL, zap.NewNop()).WithSigningSecret(secret)),
+		claimNotify: func(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if claimed[key] {
+				return false, nil
+			}
+			claimed[key] = true
+			return true, nil
+		},
+		notifyIP: notifyIP,
+		jobs:     jobs,
+		logger:   zap.NewNop(),
+	}
+	jobs.Register(accessNotificationJobType, s.handleAccessNotificationJob)
+
+	// Closing the pool waits for queued sends
+	return s, func() []sentNotification {
+		jobs.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		return sent
+	}
+}
+
+func TestNotifyAccess(t *testing.T) {
+	tenantID := uuid.New()
+	first := &models.Share{ID: uuid.New(), TenantID: tenantID, DocumentID: uuid.New(), SharedBy: "owner-1"}
+	second := &models.Share{ID: uuid.New(), TenantID: tenantID, DocumentID: uuid.New(), SharedBy: "owner-2"}
+	access := &models.ShareAccess{IPAddress: "203.0.113.7", AccessedAt: timeutil.Now()}
+
+	tests := []struct {
+		name     string
+		notifyIP bool
+		accesses []*models.Share
+		wantTo   []string
+	}{
+		{"first access is notified", false, []*models.Share{first}, []string{"owner-1"}},
+		{"rapid repeats are throttled", false, []*models.Share{first, first, first}, []string{"owner-1"}},
+		{"each share has its own throttle", false, []*models.Share{first, second, first}, []string{"owner-1", "owner-2"}},
+		{"visitor IP is included when enabled", true, []*models.Share{first}, []string{"owner-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sent := newNotifyTestService(t, tt.notifyIP)
+
+			// Public access carries no user or tenant
+			for _, share := range tt.accesses {
+				s.notifyAccess(context.Background(), share, access)
+			}
+
+			got := sent()
+			if len(got) != len(tt.wantTo) {
+				t.Fatalf("sent %d notifications, want %d", len(got), len(tt.wantTo))
+			}
+			for i, n := range got {
+				if n.notification.UserID != tt.wantTo[i] {
+					t.Errorf("notification %d went to %q, want %q", i, n.notification.UserID, tt.wantTo[i])
+				}
+				if n.userID != "service:share-service" {
+					t.Errorf("notification %d was sent as %q, want the share-service identity", i, n.userID)
+				}
+				if _, ok := n.notification.Data["ip_address"]; ok != tt.notifyIP {
+					t.Errorf("notification %d has ip_address %v, want %v", i, ok, tt.notifyIP)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyAccessWithoutNotificationService(t *testing.T) {
+	s := &Service{
+		claimNotify: func(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+			t.Error("throttle claimed with no notification service configured")
+			return true, nil
+		},
+		logger: zap.NewNop(),
+	}
+
+	share := &models.Share{ID: uuid.New(), TenantID: uuid.New(), SharedBy: "owner-1"}
+	s.notifyAccess(context.Background(), share, &models.ShareAccess{AccessedAt: timeutil.Now()})
+}
+
+func TestRecordAccessOneTimeShare(t *testing.T) {
+	share := &models.Share{ID: uuid.New(), TenantID: uuid.New(), MaxAccess: sql.NullInt64{Int64: 1, Valid: true}}
+
+	// Parallel opens of a one-time share all pass the fast path check; the
+	// database admits the first claim and returns no row for the others
+	tests := []struct {
+		name    string
+		claimed bool
+	}{
+		{"first access is recorded", true},
+		{"later access is rejected", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.New(t)
+			s := &Service{repo: repository.NewRepository(db, zap.NewNop()), logger: zap.NewNop()}
+
+			claim := mock.ExpectQuery("access_count < max_access").WithArgs(dbtest.Any, share.ID.String())
+			if tt.claimed {
+				claim.WillReturnRows([]string{"access_count"}, []driver.Value{int64(1)})
+				mock.ExpectExec("INSERT INTO share_access").WillReturnResult(1)
+			} else {
+				claim.WillReturnRows([]string{"access_count"})
+			}
+
+			access, err := s.recordAccess(context.Background(), share, "203.0.113.7", "test", "view")
+			if tt.claimed {
+				if err != nil || access == nil {
+					t.Fatalf("recordAccess() = %v, %v, want the recorded access", access, err)
+				}
+				return
+			}
+			if appErr := errors.FromError(err); appErr == nil || appErr.Code != errors.ErrCodeForbidden {
+				t.Errorf("recordAccess() error = %v, want forbidden", err)
+			}
+		})
+	}
+}
@@ -10,16 +10,24 @@ import (
 	"time"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/handler"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/repository"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/service"
 	"go.uber.org/zap"
 )
 
+// defaultPort is used when SERVER_PORT is not set
+const defaultPort = 10003
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -27,11 +35,15 @@ func main() {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// Override port for storage service
-	cfg.Server.Port = 10003
+	// Listen on defaultPort unless SERVER_PORT is set
+	if err := cfg.Server.UseDefaultPort(defaultPort); err != nil {
+		panic(fmt.Sprintf("invalid server config: %v", err))
+	}
 
 	// Initialize logger
-	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format)
+	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format,
+		logger.WithSampling(cfg.Logger.SamplingInitial, cfg.Logger.SamplingThereafter),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
@@ -42,50 +54,66 @@ func main() {
 		zap.String("environment", cfg.Environment),
 		zap.String("version", cfg.AppVersion),
 		zap.Int("port", cfg.Server.Port),
+		zap.Bool("log_sampling", log.SamplingEnabled()),
 	)
+	log.Info("effective configuration", zap.Any("config", cfg.Redacted()))
 
 	// Connect to database
-	db, err := database.NewPostgresDB(cfg.Database, log.Logger)
+	db, err := database.ConnectPostgresDB(cfg.Database, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
-
-	// Verify database health
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.HealthCheck(ctx); err != nil {
-		log.Fatal("database health check failed", zap.Error(err))
-	}
 	log.Info("database connection established")
 
 	// Connect to Redis cache
-	cacheClient, err := cache.NewRedisCache(cfg.Redis, log.Logger)
+	cacheClient, err := cache.ConnectRedisCache(cfg.Redis, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to cache", zap.Error(err))
 	}
 	defer cacheClient.Close()
-
-	// Verify cache health
-	if err := cacheClient.HealthCheck(ctx); err != nil {
-		log.Fatal("cache health check failed", zap.Error(err))
-	}
 	log.Info("cache connection established")
 
+	// Initialize internal service clients
+	tenantResolver := client.NewTenantClient(client.New("tenant-service", cfg.Services.TenantServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	quotaClient := client.NewQuotaClient(client.New("quota-service", cfg.Services.QuotaServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	documentClient := client.NewDocumentClient(client.New("document-service", cfg.Services.DocumentServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	rbacClient := client.NewRBACClient(client.New("rbac-service", cfg.Services.RBACServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+
+	// Background jobs; closed before the database so queued jobs can drain.
+	// Image processing is memory heavy, so the pool has its own size.
+	workerCfg := cfg.Worker
+	workerCfg.Concurrency = cfg.Processing.Concurrency
+	jobs := worker.New("storage-service", workerCfg, worker.NewStore(db, "storage-service", log.Logger), log.Logger)
+	defer jobs.Close()
+
 	// Initialize layers
 	repo := repository.NewRepository(db, log.Logger)
-	svc, err := service.NewService(repo, cacheClient, cfg.MinIO, log.Logger)
+	svc, err := service.NewService(repo, cacheClient, cfg.MinIO, cfg.Processing, quotaClient, documentClient, rbacClient, jobs, log.Logger)
 	if err != nil {
 		log.Fatal("failed to initialize storage service", zap.Error(err))
 	}
+	defer svc.Close()
 
 	// Ensure MinIO bucket exists
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	if err := svc.EnsureBucket(ctx); err != nil {
 		log.Fatal("failed to ensure MinIO bucket", zap.Error(err))
 	}
 	log.Info("MinIO connection established")
 
-	h := handler.NewHandler(svc, log.Logger)
+	readiness := health.NewChecker("storage-service", cfg.Health, log.Logger).WithVersion(cfg.AppVersion)
+	h := handler.NewHandler(svc, readiness, log.Logger)
+
+	// Sparse fieldsets (?fields=) on list endpoints
+	response.SetStrictFields(cfg.Server.StrictFieldSelection)
+
+	// Unknown request body fields
+	response.SetStrictJSON(cfg.Server.StrictJSON)
+
+	// Signed service-to-service requests only
+	internalAuth := middleware.InternalAuth(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -94,22 +122,66 @@ func main() {
 	mux.HandleFunc("GET /health", h.HealthCheck)
 	mux.HandleFunc("GET /health/ready", h.ReadyCheck)
 
+	// Effective configuration with secrets masked (internal use, operators)
+	mux.Handle("GET /api/config", internalAuth(http.HandlerFunc(config.NewHandler(cfg).Get)))
+
+	// Published metrics as JSON (internal use, scrapers)
+	mux.Handle("GET /api/metrics", internalAuth(metrics.Handler()))
+
+	// Tenant data purge (internal use, called by tenant deletion)
+	mux.Handle("DELETE /api/storage/tenant-data", internalAuth(http.HandlerFunc(h.PurgeTenant)))
+
+	// Object relocation after a document rename or re-folder (internal use)
+	mux.Handle("POST /api/files/{id}/relocate", internalAuth(http.HandlerFunc(h.RelocateFile)))
+
+	// Object copy (internal use, called by document version restore)
+	mux.Handle("POST /api/storage/objects/copy", internalAuth(http.HandlerFunc(h.CopyObject)))
+
+	// Download URL of a document's file (internal use, called by share downloads)
+	mux.Handle("GET /api/storage/documents/{documentId}/download", internalAuth(http.HandlerFunc(h.DownloadDocumentFile)))
+
+	// Dead-letter jobs (internal use, admin view and requeue)
+	jobsHandler := worker.NewHandler(jobs)
+	mux.Handle("GET /api/jobs/dead-letter", internalAuth(http.HandlerFunc(jobsHandler.ListDeadLetters)))
+	mux.Handle("POST /api/jobs/dead-letter/{id}/requeue", internalAuth(http.HandlerFunc(jobsHandler.RequeueDeadLetter)))
+
 	// Storage endpoints (auth required)
 	mux.HandleFunc("POST /api/storage/upload", h.UploadFile)
 	mux.HandleFunc("POST /api/storage/presigned-upload", h.GetPresignedUploadURL)
+	mux.HandleFunc("POST /api/storage/confirm-upload", h.ConfirmUpload)
 	mux.HandleFunc("GET /api/storage", h.ListFiles)
 	mux.HandleFunc("GET /api/storage/stats", h.GetStats)
+	mux.HandleFunc("GET /api/storage/stats/by-folder", h.GetStorageByFolder)
 	mux.HandleFunc("GET /api/storage/{id}/metadata", h.GetFileMetadata)
 	mux.HandleFunc("GET /api/storage/download/{id}", h.DownloadFile)
 	mux.HandleFunc("DELETE /api/storage/{id}", h.DeleteFile)
+	mux.HandleFunc("POST /api/storage/{id}/thumbnail/regenerate", h.RegenerateThumbnail)
+	mux.HandleFunc("POST /api/storage/thumbnails/backfill", h.BackfillThumbnails)
+	mux.HandleFunc("GET /api/storage/thumbnails/jobs/{id}", h.GetThumbnailJob)
+
+	// File metadata maintenance (auth required)
+	mux.HandleFunc("POST /api/files/bulk/update", h.BulkUpdateFiles)
 
 	// Apply middleware chain
+	middleware.SetSlowRequestThreshold(cfg.Logger.SlowRequestThreshold)
 	var httpHandler http.Handler = mux
+	httpHandler = middleware.Versioning(middleware.APIVersion1)(httpHandler)
 	httpHandler = middleware.RequestID()(httpHandler)
+	httpHandler = middleware.CountAPICalls(cacheClient.IncrementAPICalls, log)(httpHandler)
+	httpHandler = middleware.RateLimit(cfg.RateLimit, cacheClient.RateLimitResolver(quotaClient.RateLimit, cfg.RateLimit.CacheTTL), cacheClient.IncrementRateWindow, log)(httpHandler)
+	httpHandler = middleware.ResolveTenant(cfg.Tenancy, tenantResolver.ResolveSlug, tenantResolver.IsMember)(httpHandler)
 	httpHandler = middleware.ExtractAuthHeaders(log)(httpHandler)
+	httpHandler = middleware.VerifyInternalRequest(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)(httpHandler)
+	// CORS runs ahead of auth so browser preflights are answered without credentials
+	httpHandler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:      cfg.CORS.AllowedOrigins,
+		CredentialedOrigins: cfg.CORS.CredentialedOrigins,
+		MaxAge:              cfg.CORS.MaxAge,
+	})(httpHandler)
 	httpHandler = middleware.Logging(log)(httpHandler)
 	httpHandler = middleware.Recovery(log)(httpHandler)
 	httpHandler = middleware.Timeout(30 * time.Second)(httpHandler)
+	httpHandler = middleware.MaxBodySize(cfg.Server.MaxBodySize)(httpHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
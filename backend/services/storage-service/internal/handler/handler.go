@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/humanize"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/models"
@@ -19,15 +22,17 @@ const (
 
 // Handler handles HTTP requests for storage operations
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service   *service.Service
+	readiness *health.Checker
+	logger    *zap.Logger
 }
 
 // NewHandler creates a new storage handler
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
+func NewHandler(svc *service.Service, readiness *health.Checker, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: svc,
-		logger:  logger,
+		service:   svc,
+		readiness: readiness,
+		logger:    logger,
 	}
 }
 
@@ -81,8 +86,8 @@ func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 // GetPresignedUploadURL handles POST /api/storage/presigned-upload
 func (h *Handler) GetPresignedUploadURL(w http.ResponseWriter, r *http.Request) {
 	var req models.UploadFileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -101,6 +106,29 @@ func (h *Handler) GetPresignedUploadURL(w http.ResponseWriter, r *http.Request)
 	response.Success(w, presignedURL)
 }
 
+// ConfirmUpload handles POST /api/storage/confirm-upload
+func (h *Handler) ConfirmUpload(w http.ResponseWriter, r *http.Request) {
+	var req models.ConfirmUploadRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	metadata, err := h.service.ConfirmUpload(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, metadata)
+}
+
 // DownloadFile handles GET /api/storage/download/:id
 func (h *Handler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	fileIDStr := r.PathValue("id")
@@ -128,6 +156,30 @@ func (h *Handler) DownloadFile(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, downloadResp)
 }
 
+// DownloadDocumentFile handles GET /api/storage/documents/:documentId/download
+func (h *Handler) DownloadDocumentFile(w http.ResponseWriter, r *http.Request) {
+	documentID, err := uuid.Parse(r.PathValue("documentId"))
+	if err != nil {
+		response.BadRequest(w, "invalid document ID")
+		return
+	}
+
+	expiryTime := 0
+	if expiryStr := r.URL.Query().Get("expiry"); expiryStr != "" {
+		if expiry, err := strconv.Atoi(expiryStr); err == nil {
+			expiryTime = expiry
+		}
+	}
+
+	downloadResp, err := h.service.DownloadDocumentFile(r.Context(), documentID, expiryTime)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, downloadResp)
+}
+
 // DeleteFile handles DELETE /api/storage/:id
 func (h *Handler) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	fileIDStr := r.PathValue("id")
@@ -148,6 +200,58 @@ func (h *Handler) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "file deleted successfully"})
 }
 
+// RelocateFile handles POST /api/files/:id/relocate
+func (h *Handler) RelocateFile(w http.ResponseWriter, r *http.Request) {
+	fileID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid file ID")
+		return
+	}
+
+	var req models.RelocateFileRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	metadata, err := h.service.RelocateFile(r.Context(), fileID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, metadata)
+}
+
+// CopyObject handles POST /api/storage/objects/copy
+func (h *Handler) CopyObject(w http.ResponseWriter, r *http.Request) {
+	var req models.CopyObjectRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	copied, err := h.service.CopyObject(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, copied)
+}
+
 // GetFileMetadata handles GET /api/storage/:id/metadata
 func (h *Handler) GetFileMetadata(w http.ResponseWriter, r *http.Request) {
 	fileIDStr := r.PathValue("id")
@@ -157,12 +261,16 @@ func (h *Handler) GetFileMetadata(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metadata, err := h.service.GetFileMetadata(r.Context(), fileID)
+	metadata, err := h.service.GetFileMetadata(r.Context(), fileID, includes(r, models.IncludeTrashed))
 	if err != nil {
 		response.Error(w, err)
 		return
 	}
 
+	if humanize.Requested(r) {
+		metadata.Humanize()
+	}
+
 	response.Success(w, metadata)
 }
 
@@ -175,6 +283,7 @@ func (h *Handler) ListFiles(w http.ResponseWriter, r *http.Request) {
 		SortBy:     r.URL.Query().Get("sort_by"),
 		SortOrder:  r.URL.Query().Get("sort_order"),
 	}
+	params.IncludeTrashed = includes(r, models.IncludeTrashed)
 
 	// Parse page and limit
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
@@ -200,7 +309,41 @@ func (h *Handler) ListFiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Paginated(w, files, params.Page, params.Limit, total)
+	data, err := response.ProjectFields(r, files)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Paginated(w, data, params.Page, params.Limit, total)
+}
+
+// BulkUpdateFiles handles POST /api/files/bulk/update
+func (h *Handler) BulkUpdateFiles(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkUpdateFilesRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := bulk.CheckSize("file_ids", len(req.FileIDs), models.MaxFileUpdateBatchSize); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.BulkUpdateFiles(r.Context(), &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
 }
 
 // GetStats handles GET /api/storage/stats
@@ -211,22 +354,124 @@ func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if humanize.Requested(r) {
+		stats.Humanize()
+	}
+
 	response.Success(w, stats)
 }
 
+// GetStorageByFolder handles GET /api/storage/stats/by-folder
+func (h *Handler) GetStorageByFolder(w http.ResponseWriter, r *http.Request) {
+	var folderID *uuid.UUID
+	if folderIDStr := r.URL.Query().Get("folder_id"); folderIDStr != "" {
+		parsed, err := uuid.Parse(folderIDStr)
+		if err != nil {
+			response.BadRequest(w, "invalid folder ID")
+			return
+		}
+		folderID = &parsed
+	}
+
+	stats, err := h.service.GetStorageByFolder(r.Context(), folderID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, stats)
+}
+
+// RegenerateThumbnail handles POST /api/storage/:id/thumbnail/regenerate
+func (h *Handler) RegenerateThumbnail(w http.ResponseWriter, r *http.Request) {
+	fileIDStr := r.PathValue("id")
+	fileID, err := uuid.Parse(fileIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid file ID")
+		return
+	}
+
+	job, err := h.service.RegenerateThumbnail(r.Context(), fileID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	writeThumbnailJob(w, job)
+}
+
+// BackfillThumbnails handles POST /api/storage/thumbnails/backfill
+func (h *Handler) BackfillThumbnails(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.BackfillThumbnails(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	writeThumbnailJob(w, job)
+}
+
+// GetThumbnailJob handles GET /api/storage/thumbnails/jobs/:id
+func (h *Handler) GetThumbnailJob(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := r.PathValue("id")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid job ID")
+		return
+	}
+
+	job, err := h.service.GetThumbnailJob(r.Context(), jobID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, job)
+}
+
+// includes reports whether the comma-separated ?include= parameter lists what
+func includes(r *http.Request, what string) bool {
+	for _, name := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(name) == what {
+			return true
+		}
+	}
+	return false
+}
+
+// writeThumbnailJob responds 202 for queued jobs and 200 for jobs that finished immediately
+func writeThumbnailJob(w http.ResponseWriter, job *models.ThumbnailJob) {
+	if job.Status == models.ThumbnailJobQueued {
+		response.JSON(w, http.StatusAccepted, job)
+		return
+	}
+	response.Success(w, job)
+}
+
+// PurgeTenant handles DELETE /api/storage/tenant-data (internal use)
+func (h *Handler) PurgeTenant(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.service.PurgeTenant(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]int64{"deleted": deleted})
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.Success(w, map[string]string{
-		"status":  "healthy",
-		"service": "storage-service",
-	})
+	response.Success(w, h.readiness.Live())
 }
 
 // ReadyCheck handles GET /health/ready
 func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 	// TODO: Check database, cache, and MinIO connectivity
-	response.Success(w, map[string]string{
-		"status":  "ready",
-		"service": "storage-service",
-	})
+	report := h.readiness.Check(r.Context())
+	if !report.Ready() {
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	response.Success(w, report)
 }
@@ -2,31 +2,44 @@ package models
 
 import (
 	"database/sql"
-	"time"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/humanize"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 )
 
 // FileMetadata represents file metadata stored in database
 type FileMetadata struct {
-	ID            uuid.UUID      `json:"id" db:"id"`
-	TenantID      uuid.UUID      `json:"tenant_id" db:"tenant_id"`
-	DocumentID    uuid.UUID      `json:"document_id" db:"document_id"`
-	FileName      string         `json:"file_name" db:"file_name"`
-	OriginalName  string         `json:"original_name" db:"original_name"`
-	FileSize      int64          `json:"file_size" db:"file_size"`
-	MimeType      string         `json:"mime_type" db:"mime_type"`
-	FileType      string         `json:"file_type" db:"file_type"`
-	BucketName    string         `json:"-" db:"bucket_name"`
-	ObjectKey     string         `json:"-" db:"object_key"`
-	ThumbnailKey  sql.NullString `json:"-" db:"thumbnail_key"`
-	StoragePath   string         `json:"-" db:"storage_path"`
-	Checksum      string         `json:"checksum" db:"checksum"`
-	UploadedBy    string         `json:"uploaded_by" db:"uploaded_by"`
-	IsEncrypted   bool           `json:"is_encrypted" db:"is_encrypted"`
-	EncryptionKey sql.NullString `json:"-" db:"encryption_key"`
-	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
+	ID               uuid.UUID      `json:"id" db:"id"`
+	TenantID         uuid.UUID      `json:"tenant_id" db:"tenant_id"`
+	DocumentID       uuid.UUID      `json:"document_id" db:"document_id"`
+	FileName         string         `json:"file_name" db:"file_name"`
+	OriginalName     string         `json:"original_name" db:"original_name"`
+	FileSize         int64          `json:"file_size" db:"file_size"`
+	MimeType         string         `json:"mime_type" db:"mime_type"`
+	FileType         string         `json:"file_type" db:"file_type"`
+	BucketName       string         `json:"-" db:"bucket_name"`
+	ObjectKey        string         `json:"-" db:"object_key"`
+	ThumbnailKey     sql.NullString `json:"-" db:"thumbnail_key"`
+	ProcessingStatus sql.NullString `json:"processing_status,omitempty" db:"processing_status"` // set when processing skipped the file
+	ETag             sql.NullString `json:"-" db:"etag"`                                        // object ETag recorded at upload, checked before downloads
+	StoragePath      string         `json:"-" db:"storage_path"`
+	Checksum         string         `json:"checksum" db:"checksum"`
+	UploadedBy       string         `json:"uploaded_by" db:"uploaded_by"`
+	IsEncrypted      bool           `json:"is_encrypted" db:"is_encrypted"`
+	EncryptionKey    sql.NullString `json:"-" db:"encryption_key"`
+	CreatedAt        timeutil.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        timeutil.Time  `json:"updated_at" db:"updated_at"`
+
+	// FileSizeHuman is only set when the caller asks for ?humanize=true
+	FileSizeHuman string `json:"file_size_human,omitempty" db:"-"`
+}
+
+// Humanize fills the formatted size alongside the raw byte count
+func (m *FileMetadata) Humanize() {
+	m.FileSizeHuman = humanize.Bytes(m.FileSize)
 }
 
 // UploadFileRequest represents file upload request
@@ -40,13 +53,13 @@ type UploadFileRequest struct {
 
 // UploadFileResponse represents file upload response
 type UploadFileResponse struct {
-	FileID       uuid.UUID `json:"file_id"`
-	DocumentID   uuid.UUID `json:"document_id"`
-	UploadURL    string    `json:"upload_url"`
-	FileName     string    `json:"file_name"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	StoragePath  string    `json:"storage_path"`
-	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	FileID       uuid.UUID     `json:"file_id"`
+	DocumentID   uuid.UUID     `json:"document_id"`
+	UploadURL    string        `json:"upload_url"`
+	FileName     string        `json:"file_name"`
+	ExpiresAt    timeutil.Time `json:"expires_at"`
+	StoragePath  string        `json:"storage_path"`
+	ThumbnailURL string        `json:"thumbnail_url,omitempty"`
 }
 
 // DownloadFileRequest represents file download request
@@ -58,11 +71,15 @@ type DownloadFileRequest struct {
 
 // DownloadFileResponse represents file download response
 type DownloadFileResponse struct {
-	DownloadURL string    `json:"download_url"`
-	FileName    string    `json:"file_name"`
-	FileSize    int64     `json:"file_size"`
-	MimeType    string    `json:"mime_type"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	DownloadURL string        `json:"download_url"`
+	FileName    string        `json:"file_name"`
+	FileSize    int64         `json:"file_size"`
+	MimeType    string        `json:"mime_type"`
+	ExpiresAt   timeutil.Time `json:"expires_at"`
+
+	// IntegrityWarning is set when the stored object no longer matches the
+	// ETag recorded at upload and may be corrupted
+	IntegrityWarning string `json:"integrity_warning,omitempty"`
 }
 
 // PresignedURLRequest represents presigned URL generation request
@@ -74,8 +91,72 @@ type PresignedURLRequest struct {
 
 // PresignedURLResponse represents presigned URL response
 type PresignedURLResponse struct {
-	URL       string    `json:"url"`
-	ExpiresAt time.Time `json:"expires_at"`
+	FileID    uuid.UUID     `json:"file_id"`
+	URL       string        `json:"url"`
+	ExpiresAt timeutil.Time `json:"expires_at"`
+}
+
+// PendingUpload records a presigned upload until the client confirms it
+type PendingUpload struct {
+	FileID       uuid.UUID `json:"file_id"`
+	DocumentID   uuid.UUID `json:"document_id"`
+	ObjectKey    string    `json:"object_key"`
+	OriginalName string    `json:"original_name"`
+	MimeType     string    `json:"mime_type"`
+	FileSize     int64     `json:"file_size"`
+	IsEncrypted  bool      `json:"is_encrypted"`
+	UploadedBy   string    `json:"uploaded_by"`
+	Reserved     int64     `json:"reserved"` // storage reserved when the URL was issued
+}
+
+// UploadSizeLimits are a tenant's upload size caps in bytes, keyed by MIME
+// type ("application/pdf") or MIME category ("image/*")
+type UploadSizeLimits map[string]int64
+
+// Limit returns the most restrictive limit that applies to mimeType and the
+// key it was configured under; ok is false when none applies
+func (l UploadSizeLimits) Limit(mimeType string) (key string, limit int64, ok bool) {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if i := strings.IndexByte(mimeType, ';'); i >= 0 {
+		mimeType = strings.TrimSpace(mimeType[:i]) // drop parameters such as charset
+	}
+
+	candidates := []string{mimeType}
+	if i := strings.IndexByte(mimeType, '/'); i > 0 {
+		candidates = append(candidates, mimeType[:i]+"/*")
+	}
+
+	for _, candidate := range candidates {
+		if value, found := l[candidate]; found && (!ok || value < limit) {
+			key, limit, ok = candidate, value, true
+		}
+	}
+	return key, limit, ok
+}
+
+// ConfirmUploadRequest represents confirmation of a presigned upload
+type ConfirmUploadRequest struct {
+	FileID string `json:"file_id" validate:"required,uuid"`
+}
+
+// RelocateFileRequest moves a file's object to a key derived from the
+// document's new location, e.g. "Finance/2024/Q1" after a re-folder
+type RelocateFileRequest struct {
+	Path string `json:"path" validate:"required,max=1024"`
+}
+
+// CopyObjectRequest copies a stored object to a new key under a document,
+// e.g. to make an old document version current again
+type CopyObjectRequest struct {
+	SourceKey  string `json:"source_key" validate:"required,max=1024"`
+	DocumentID string `json:"document_id" validate:"required,uuid"`
+}
+
+// CopiedObject describes the object created by a copy
+type CopiedObject struct {
+	ObjectKey string `json:"object_key"`
+	Size      int64  `json:"size"`
+	MimeType  string `json:"mime_type"`
 }
 
 // DeleteFileRequest represents file deletion request
@@ -99,20 +180,87 @@ type ThumbnailResponse struct {
 	Height       int    `json:"height"`
 }
 
+// Processing statuses recorded on a file that was not processed
+const (
+	// ProcessingSkippedTooLarge marks a file over PROCESSING_MAX_FILE_SIZE or
+	// PROCESSING_MAX_PIXELS; backfills leave it alone, regenerating retries it
+	ProcessingSkippedTooLarge = "skipped_too_large"
+)
+
+// Thumbnail job statuses
+const (
+	ThumbnailJobQueued      = "queued"
+	ThumbnailJobRunning     = "running"
+	ThumbnailJobCompleted   = "completed"
+	ThumbnailJobFailed      = "failed"
+	ThumbnailJobUnsupported = "unsupported"
+)
+
+// ThumbnailJob tracks an asynchronous thumbnail (re)generation run for a
+// single file or a tenant-wide backfill
+type ThumbnailJob struct {
+	ID        uuid.UUID     `json:"id"`
+	FileID    *uuid.UUID    `json:"file_id,omitempty"` // nil for backfill jobs
+	Status    string        `json:"status"`
+	Message   string        `json:"message,omitempty"`
+	Total     int           `json:"total"`
+	Processed int           `json:"processed"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"` // over the processing size limits
+	CreatedAt timeutil.Time `json:"created_at"`
+	UpdatedAt timeutil.Time `json:"updated_at"`
+}
+
 // FileStats represents storage statistics
 type FileStats struct {
 	TotalFiles     int64 `json:"total_files"`
 	TotalSize      int64 `json:"total_size"`
+	TotalSizeHuman string `json:"total_size_human,omitempty"`
 	TotalDocuments int64 `json:"total_documents"`
 	ByFileType     map[string]FileTypeStats `json:"by_file_type"`
 }
 
+// Humanize fills formatted sizes alongside the raw byte counts
+func (s *FileStats) Humanize() {
+	s.TotalSizeHuman = humanize.Bytes(s.TotalSize)
+	for fileType, stats := range s.ByFileType {
+		stats.TotalSizeHuman = humanize.Bytes(stats.TotalSize)
+		s.ByFileType[fileType] = stats
+	}
+}
+
 // FileTypeStats represents statistics by file type
 type FileTypeStats struct {
-	Count     int64 `json:"count"`
-	TotalSize int64 `json:"total_size"`
+	Count          int64  `json:"count"`
+	TotalSize      int64  `json:"total_size"`
+	TotalSizeHuman string `json:"total_size_human,omitempty"`
+}
+
+// FolderStorageStats represents storage consumed by the files directly in a folder.
+// FolderID is nil for the root bucket (documents outside any folder).
+type FolderStorageStats struct {
+	FolderID   *uuid.UUID `json:"folder_id"`
+	FolderName string     `json:"folder_name"`
+	Path       string     `json:"path"`
+	FileCount  int64      `json:"file_count"`
+	TotalSize  int64      `json:"total_size"`
+}
+
+// MaxFileUpdateBatchSize is the maximum number of files per bulk update request
+const MaxFileUpdateBatchSize = 500
+
+// BulkUpdateFilesRequest reassigns files to another document of the tenant
+// and/or corrects their file type. At least one of DocumentID and FileType
+// must be set.
+type BulkUpdateFilesRequest struct {
+	FileIDs    []string `json:"file_ids" validate:"required,dive,uuid"`
+	DocumentID string   `json:"document_id,omitempty" validate:"omitempty,uuid"`
+	FileType   string   `json:"file_type,omitempty" validate:"omitempty,max=100"`
 }
 
+// BulkUpdateFilesResponse represents a bulk file metadata update result
+type BulkUpdateFilesResponse = bulk.Response[bulk.Result]
+
 // ListFilesParams represents query parameters for listing files
 type ListFilesParams struct {
 	DocumentID string `json:"document_id,omitempty" form:"document_id"`
@@ -122,8 +270,14 @@ type ListFilesParams struct {
 	Limit      int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
 	SortBy     string `json:"sort_by,omitempty" form:"sort_by"`
 	SortOrder  string `json:"sort_order,omitempty" form:"sort_order" validate:"omitempty,oneof=asc desc"`
+
+	// IncludeTrashed returns soft-deleted files too (?include=trashed)
+	IncludeTrashed bool `json:"-"`
 }
 
+// IncludeTrashed is the ?include= value that returns soft-deleted files too
+const IncludeTrashed = "trashed"
+
 // Normalize sets default values for list parameters
 func (p *ListFilesParams) Normalize() {
 	if p.Page < 1 {
@@ -150,8 +304,8 @@ func (p *ListFilesParams) GetOffset() int {
 
 // BucketInfo represents MinIO bucket information
 type BucketInfo struct {
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	Size      int64     `json:"size"`
-	FileCount int64     `json:"file_count"`
+	Name      string        `json:"name"`
+	CreatedAt timeutil.Time `json:"created_at"`
+	Size      int64         `json:"size"`
+	FileCount int64         `json:"file_count"`
 }
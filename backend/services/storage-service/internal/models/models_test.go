@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestUploadSizeLimitsLimit(t *testing.T) {
+	limits := UploadSizeLimits{
+		"application/pdf": 20 << 20,
+		"image/*":         5 << 20,
+		"image/png":       10 << 20,
+		"video/mp4":       1 << 30,
+		"video/*":         100 << 20,
+	}
+
+	tests := []struct {
+		name      string
+		mimeType  string
+		wantKey   string
+		wantLimit int64
+		wantOK    bool
+	}{
+		{"exact type", "application/pdf", "application/pdf", 20 << 20, true},
+		{"category only", "image/jpeg", "image/*", 5 << 20, true},
+		{"category stricter than exact type", "image/png", "image/*", 5 << 20, true},
+		{"exact type stricter than category", "video/mp4", "video/*", 100 << 20, true},
+		{"case and parameters are ignored", " Application/PDF; charset=binary", "application/pdf", 20 << 20, true},
+		{"no limit applies", "text/plain", "", 0, false},
+		{"malformed type", "pdf", "", 0, false},
+		{"empty type", "", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, limit, ok := limits.Limit(tt.mimeType)
+			if key != tt.wantKey || limit != tt.wantLimit || ok != tt.wantOK {
+				t.Errorf("Limit(%q) = (%q, %d, %v), want (%q, %d, %v)",
+					tt.mimeType, key, limit, ok, tt.wantKey, tt.wantLimit, tt.wantOK)
+			}
+		})
+	}
+
+	var none UploadSizeLimits
+	if _, _, ok := none.Limit("application/pdf"); ok {
+		t.Error("nil limits reported a limit")
+	}
+}
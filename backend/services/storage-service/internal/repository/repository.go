@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/models"
@@ -33,11 +35,11 @@ func (r *Repository) CreateFileMetadata(ctx context.Context, metadata *models.Fi
 		INSERT INTO file_metadata (
 			id, tenant_id, document_id, file_name, original_name,
 			file_size, mime_type, file_type, bucket_name, object_key,
-			thumbnail_key, storage_path, checksum, uploaded_by,
+			thumbnail_key, etag, storage_path, checksum, uploaded_by,
 			is_encrypted, encryption_key, created_at, updated_at
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
-			$11, $12, $13, $14, $15, $16, $17, $18
+			$11, $12, $13, $14, $15, $16, $17, $18, $19
 		)`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -52,6 +54,7 @@ func (r *Repository) CreateFileMetadata(ctx context.Context, metadata *models.Fi
 		metadata.BucketName,
 		metadata.ObjectKey,
 		metadata.ThumbnailKey,
+		metadata.ETag,
 		metadata.StoragePath,
 		metadata.Checksum,
 		metadata.UploadedBy,
@@ -63,21 +66,23 @@ func (r *Repository) CreateFileMetadata(ctx context.Context, metadata *models.Fi
 
 	if err != nil {
 		r.logger.Error("failed to create file metadata", zap.Error(err))
-		return errors.New(errors.ErrCodeInternal, "failed to create file metadata")
+		return database.WrapError("failed to create file metadata", err)
 	}
 
 	return nil
 }
 
-// GetFileMetadata retrieves file metadata by ID
-func (r *Repository) GetFileMetadata(ctx context.Context, tenantID, fileID uuid.UUID) (*models.FileMetadata, error) {
+// GetFileMetadata retrieves file metadata by ID; a soft-deleted file is only
+// found when includeTrashed is set
+func (r *Repository) GetFileMetadata(ctx context.Context, tenantID, fileID uuid.UUID, includeTrashed bool) (*models.FileMetadata, error) {
+	where := database.NotDeleted([]string{"id = $1", "tenant_id = $2"}, "", includeTrashed)
 	query := `
 		SELECT id, tenant_id, document_id, file_name, original_name,
 			file_size, mime_type, file_type, bucket_name, object_key,
-			thumbnail_key, storage_path, checksum, uploaded_by,
+			thumbnail_key, processing_status, etag, storage_path, checksum, uploaded_by,
 			is_encrypted, encryption_key, created_at, updated_at
 		FROM file_metadata
-		WHERE id = $1 AND tenant_id = $2`
+		WHERE ` + strings.Join(where, " AND ")
 
 	var metadata models.FileMetadata
 	err := r.db.QueryRowContext(ctx, query, fileID, tenantID).Scan(
@@ -92,6 +97,8 @@ func (r *Repository) GetFileMetadata(ctx context.Context, tenantID, fileID uuid.
 		&metadata.BucketName,
 		&metadata.ObjectKey,
 		&metadata.ThumbnailKey,
+		&metadata.ProcessingStatus,
+		&metadata.ETag,
 		&metadata.StoragePath,
 		&metadata.Checksum,
 		&metadata.UploadedBy,
@@ -112,15 +119,17 @@ func (r *Repository) GetFileMetadata(ctx context.Context, tenantID, fileID uuid.
 	return &metadata, nil
 }
 
-// GetFileMetadataByDocumentID retrieves file metadata by document ID
+// GetFileMetadataByDocumentID retrieves the latest file of a document that is
+// not soft-deleted
 func (r *Repository) GetFileMetadataByDocumentID(ctx context.Context, tenantID, documentID uuid.UUID) (*models.FileMetadata, error) {
+	where := database.NotDeleted([]string{"document_id = $1", "tenant_id = $2"}, "", false)
 	query := `
 		SELECT id, tenant_id, document_id, file_name, original_name,
 			file_size, mime_type, file_type, bucket_name, object_key,
-			thumbnail_key, storage_path, checksum, uploaded_by,
+			thumbnail_key, processing_status, etag, storage_path, checksum, uploaded_by,
 			is_encrypted, encryption_key, created_at, updated_at
 		FROM file_metadata
-		WHERE document_id = $1 AND tenant_id = $2
+		WHERE ` + strings.Join(where, " AND ") + `
 		ORDER BY created_at DESC
 		LIMIT 1`
 
@@ -137,6 +146,8 @@ func (r *Repository) GetFileMetadataByDocumentID(ctx context.Context, tenantID,
 		&metadata.BucketName,
 		&metadata.ObjectKey,
 		&metadata.ThumbnailKey,
+		&metadata.ProcessingStatus,
+		&metadata.ETag,
 		&metadata.StoragePath,
 		&metadata.Checksum,
 		&metadata.UploadedBy,
@@ -160,7 +171,7 @@ func (r *Repository) GetFileMetadataByDocumentID(ctx context.Context, tenantID,
 // ListFileMetadata retrieves files with filtering and pagination
 func (r *Repository) ListFileMetadata(ctx context.Context, tenantID uuid.UUID, params *models.ListFilesParams) ([]models.FileMetadata, int64, error) {
 	// Build WHERE clause
-	where := []string{"tenant_id = $1"}
+	where := database.NotDeleted([]string{"tenant_id = $1"}, "", params.IncludeTrashed)
 	args := []interface{}{tenantID}
 	argPos := 2
 
@@ -200,7 +211,7 @@ func (r *Repository) ListFileMetadata(ctx context.Context, tenantID uuid.UUID, p
 	query := fmt.Sprintf(`
 		SELECT id, tenant_id, document_id, file_name, original_name,
 			file_size, mime_type, file_type, bucket_name, object_key,
-			thumbnail_key, storage_path, checksum, uploaded_by,
+			thumbnail_key, processing_status, etag, storage_path, checksum, uploaded_by,
 			is_encrypted, encryption_key, created_at, updated_at
 		FROM file_metadata
 		WHERE %s
@@ -224,6 +235,10 @@ func (r *Repository) ListFileMetadata(ctx context.Context, tenantID uuid.UUID, p
 
 	var files []models.FileMetadata
 	for rows.Next() {
+		// Abort on client disconnect or timeout instead of scanning the rest
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
 		var metadata models.FileMetadata
 		err := rows.Scan(
 			&metadata.ID,
@@ -237,6 +252,8 @@ func (r *Repository) ListFileMetadata(ctx context.Context, tenantID uuid.UUID, p
 			&metadata.BucketName,
 			&metadata.ObjectKey,
 			&metadata.ThumbnailKey,
+			&metadata.ProcessingStatus,
+			&metadata.ETag,
 			&metadata.StoragePath,
 			&metadata.Checksum,
 			&metadata.UploadedBy,
@@ -251,12 +268,24 @@ func (r *Repository) ListFileMetadata(ctx context.Context, tenantID uuid.UUID, p
 		}
 		files = append(files, metadata)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
 	return files, total, nil
 }
 
+// execer is satisfied by both *database.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
 // UpdateFileMetadata updates file metadata
 func (r *Repository) UpdateFileMetadata(ctx context.Context, tenantID, fileID uuid.UUID, updates map[string]interface{}) error {
+	return r.updateFileMetadata(ctx, r.db, tenantID, fileID, updates)
+}
+
+func (r *Repository) updateFileMetadata(ctx context.Context, exec execer, tenantID, fileID uuid.UUID, updates map[string]interface{}) error {
 	if len(updates) == 0 {
 		return nil
 	}
@@ -287,7 +316,7 @@ func (r *Repository) UpdateFileMetadata(ctx context.Context, tenantID, fileID uu
 		argPos+1,
 	)
 
-	result, err := r.db.ExecContext(ctx, query, args...)
+	result, err := exec.ExecContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("failed to update file metadata", zap.Error(err))
 		return errors.New(errors.ErrCodeInternal,"failed to update file metadata")
@@ -301,6 +330,72 @@ func (r *Repository) UpdateFileMetadata(ctx context.Context, tenantID, fileID uu
 	return nil
 }
 
+// BulkUpdateFileMetadata applies the same updates to several files in one
+// transaction. When updates change document_id, the target document must
+// belong to the tenant; it is share-locked so it cannot be deleted while files
+// are moved onto it. Returns the IDs of the files that were found and updated.
+func (r *Repository) BulkUpdateFileMetadata(ctx context.Context, tenantID uuid.UUID, fileIDs []uuid.UUID, updates map[string]interface{}) (map[uuid.UUID]bool, error) {
+	updated := make(map[uuid.UUID]bool, len(fileIDs))
+
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if documentID, ok := updates["document_id"]; ok {
+			var exists int
+			err := tx.QueryRowContext(ctx,
+				`SELECT 1 FROM documents WHERE id = $1 AND tenant_id = $2 FOR SHARE`,
+				documentID, tenantID,
+			).Scan(&exists)
+			if err == sql.ErrNoRows {
+				return errors.NotFoundf("target document not found")
+			}
+			if err != nil {
+				r.logger.Error("failed to check target document", zap.Error(err))
+				return errors.New(errors.ErrCodeInternal, "failed to check target document")
+			}
+		}
+
+		for _, fileID := range fileIDs {
+			err := r.updateFileMetadata(ctx, tx, tenantID, fileID, updates)
+			if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			updated[fileID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// RelocateFileMetadata points a file at a new object key. The update only
+// applies while the file still has oldKey, so concurrent relocations of the
+// same file cannot both succeed; the loser gets a conflict. etag is the
+// ETag of the new object, which a server-side copy may change.
+func (r *Repository) RelocateFileMetadata(ctx context.Context, tenantID, fileID uuid.UUID, oldKey, newKey, etag string) error {
+	query := `
+		UPDATE file_metadata
+		SET object_key = $1, storage_path = $1, etag = NULLIF($5, ''), updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND object_key = $4`
+
+	result, err := r.db.ExecContext(ctx, query, newKey, fileID, tenantID, oldKey, etag)
+	if err != nil {
+		r.logger.Error("failed to relocate file metadata", zap.Error(err))
+		return database.WrapError("failed to update file location", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return errors.Conflictf("file was modified or moved concurrently")
+	}
+
+	return nil
+}
+
 // DeleteFileMetadata deletes file metadata
 func (r *Repository) DeleteFileMetadata(ctx context.Context, tenantID, fileID uuid.UUID) error {
 	query := `DELETE FROM file_metadata WHERE id = $1 AND tenant_id = $2`
@@ -362,6 +457,9 @@ func (r *Repository) GetFileStats(ctx context.Context, tenantID uuid.UUID) (*mod
 	defer rows.Close()
 
 	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		var fileType string
 		var typeStats models.FileTypeStats
 		if err := rows.Scan(&fileType, &typeStats.Count, &typeStats.TotalSize); err != nil {
@@ -369,15 +467,94 @@ func (r *Repository) GetFileStats(ctx context.Context, tenantID uuid.UUID) (*mod
 		}
 		stats.ByFileType[fileType] = typeStats
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetStorageByFolder aggregates file size and count per document folder. Files
+// whose document has no folder are grouped under the root bucket. When
+// folderID is set, only that folder and its subfolders are included.
+func (r *Repository) GetStorageByFolder(ctx context.Context, tenantID uuid.UUID, folderID *uuid.UUID) ([]models.FolderStorageStats, error) {
+	where := "fm.tenant_id = $1"
+	args := []interface{}{tenantID}
+
+	if folderID != nil {
+		var rootPath string
+		err := r.db.QueryRowContext(ctx,
+			`SELECT path FROM folders WHERE id = $1 AND tenant_id = $2`,
+			*folderID, tenantID,
+		).Scan(&rootPath)
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFoundf("folder not found")
+		}
+		if err != nil {
+			r.logger.Error("failed to get folder path", zap.Error(err))
+			return nil, errors.New(errors.ErrCodeInternal, "failed to get storage by folder")
+		}
+
+		// Match the folder itself and any path below it
+		where += " AND (f.path = $2 OR LEFT(f.path, LENGTH($2) + 1) = $2 || '/')"
+		args = append(args, rootPath)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT f.id, f.name, f.path,
+			COUNT(fm.id) AS file_count,
+			COALESCE(SUM(fm.file_size), 0) AS total_size
+		FROM file_metadata fm
+		LEFT JOIN documents d ON d.id = fm.document_id AND d.tenant_id = fm.tenant_id
+		LEFT JOIN folders f ON f.id = d.folder_id AND f.tenant_id = fm.tenant_id
+		WHERE %s
+		GROUP BY f.id, f.name, f.path
+		ORDER BY total_size DESC`,
+		where,
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to get storage by folder", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to get storage by folder")
+	}
+	defer rows.Close()
+
+	var stats []models.FolderStorageStats
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var folderStats models.FolderStorageStats
+		var id uuid.NullUUID
+		var name, path sql.NullString
+		if err := rows.Scan(&id, &name, &path, &folderStats.FileCount, &folderStats.TotalSize); err != nil {
+			r.logger.Error("failed to scan folder storage stats", zap.Error(err))
+			continue
+		}
+		if id.Valid {
+			folderStats.FolderID = &id.UUID
+			folderStats.FolderName = name.String
+			folderStats.Path = path.String
+		} else {
+			folderStats.FolderName = "root"
+			folderStats.Path = "/"
+		}
+		stats = append(stats, folderStats)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return stats, nil
 }
 
-// UpdateThumbnailKey updates the thumbnail key for a file
+// UpdateThumbnailKey updates the thumbnail key for a file and clears any
+// earlier processing status
 func (r *Repository) UpdateThumbnailKey(ctx context.Context, tenantID, fileID uuid.UUID, thumbnailKey string) error {
 	query := `
 		UPDATE file_metadata
-		SET thumbnail_key = $1, updated_at = NOW()
+		SET thumbnail_key = $1, processing_status = NULL, updated_at = NOW()
 		WHERE id = $2 AND tenant_id = $3`
 
 	result, err := r.db.ExecContext(ctx, query, thumbnailKey, fileID, tenantID)
@@ -393,3 +570,134 @@ func (r *Repository) UpdateThumbnailKey(ctx context.Context, tenantID, fileID uu
 
 	return nil
 }
+
+// UpdateProcessingStatus records why a file was not processed (see
+// models.ProcessingSkippedTooLarge)
+func (r *Repository) UpdateProcessingStatus(ctx context.Context, tenantID, fileID uuid.UUID, status string) error {
+	query := `
+		UPDATE file_metadata
+		SET processing_status = $1, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, status, fileID, tenantID)
+	if err != nil {
+		r.logger.Error("failed to update processing status", zap.Error(err))
+		return database.WrapError("failed to update processing status", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return errors.NotFoundf("file not found")
+	}
+
+	return nil
+}
+
+// ListFilesWithoutThumbnail retrieves files of the given MIME types that have
+// no thumbnail yet and were not skipped by earlier processing
+func (r *Repository) ListFilesWithoutThumbnail(ctx context.Context, tenantID uuid.UUID, mimeTypes []string, limit int) ([]models.FileMetadata, error) {
+	query := `
+		SELECT id, tenant_id, document_id, file_name, original_name,
+			file_size, mime_type, file_type, bucket_name, object_key,
+			thumbnail_key, processing_status, etag, storage_path, checksum, uploaded_by,
+			is_encrypted, encryption_key, created_at, updated_at
+		FROM file_metadata
+		WHERE tenant_id = $1 AND thumbnail_key IS NULL AND processing_status IS NULL AND mime_type = ANY($2)
+		ORDER BY created_at ASC
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, pq.Array(mimeTypes), limit)
+	if err != nil {
+		r.logger.Error("failed to list files without thumbnail", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to list files")
+	}
+	defer rows.Close()
+
+	var files []models.FileMetadata
+	for rows.Next() {
+		var metadata models.FileMetadata
+		err := rows.Scan(
+			&metadata.ID,
+			&metadata.TenantID,
+			&metadata.DocumentID,
+			&metadata.FileName,
+			&metadata.OriginalName,
+			&metadata.FileSize,
+			&metadata.MimeType,
+			&metadata.FileType,
+			&metadata.BucketName,
+			&metadata.ObjectKey,
+			&metadata.ThumbnailKey,
+			&metadata.ProcessingStatus,
+			&metadata.ETag,
+			&metadata.StoragePath,
+			&metadata.Checksum,
+			&metadata.UploadedBy,
+			&metadata.IsEncrypted,
+			&metadata.EncryptionKey,
+			&metadata.CreatedAt,
+			&metadata.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("failed to scan file metadata", zap.Error(err))
+			continue
+		}
+		files = append(files, metadata)
+	}
+
+	return files, nil
+}
+
+// GetTenantUploadSizeLimits reads the tenant's per-type upload size limits
+// from its upload_size_limits row in tenant_settings, an object of MIME type
+// or "type/*" to bytes. Keys are lowercased; non-numeric and non-positive
+// values are ignored, and a tenant without the setting has no limits.
+func (r *Repository) GetTenantUploadSizeLimits(ctx context.Context, tenantID uuid.UUID) (models.UploadSizeLimits, error) {
+	query := `
+		SELECT CASE WHEN jsonb_typeof(value) = 'object' THEN value END
+		FROM tenant_settings
+		WHERE tenant_id = $1 AND key = 'upload_size_limits'`
+
+	var raw []byte
+	err := r.db.QueryRowContext(ctx, query, tenantID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return models.UploadSizeLimits{}, nil
+	}
+	if err != nil {
+		r.logger.Error("failed to get tenant upload size limits", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to get upload size limits")
+	}
+
+	limits := models.UploadSizeLimits{}
+	if raw == nil {
+		return limits, nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		r.logger.Warn("invalid tenant upload size limits", zap.String("tenant_id", tenantID.String()), zap.Error(err))
+		return limits, nil
+	}
+	for key, value := range values {
+		var limit int64
+		if err := json.Unmarshal(value, &limit); err != nil || limit <= 0 {
+			continue
+		}
+		limits[strings.ToLower(strings.TrimSpace(key))] = limit
+	}
+
+	return limits, nil
+}
+
+// PurgeTenant deletes all file metadata of a tenant and returns the number of
+// rows removed
+func (r *Repository) PurgeTenant(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM file_metadata WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		r.logger.Error("failed to purge tenant files", zap.Error(err))
+		return 0, errors.New(errors.ErrCodeInternal, "failed to purge tenant files")
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
@@ -1,10 +1,19 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
 	"io"
+	"net/http"
 	"net/url"
 	"path/filepath"
 	"strings"
@@ -13,11 +22,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/bulk"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/models"
 	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/repository"
 	"go.uber.org/zap"
@@ -26,49 +39,132 @@ import (
 const (
 	fileCacheTTL         = 30 * time.Minute
 	presignedURLExpiry   = 1 * time.Hour
+	pendingUploadTTL     = 2 * presignedURLExpiry // leaves time to confirm after a late upload
 	defaultThumbnailSize = 300
 	maxFileSize          = 100 * 1024 * 1024 // 100MB
+
+	// sniffLen is how much content http.DetectContentType considers
+	sniffLen = 512
+
+	// uploadSizeLimitsCacheTTL bounds how long a tenant's per-type upload
+	// size limits are cached
+	uploadSizeLimitsCacheTTL = 5 * time.Minute
+
+	thumbnailJobTTL        = 24 * time.Hour
+	thumbnailJobTimeout    = 10 * time.Minute
+	thumbnailBackfillLimit = 500
+	thumbnailQuality       = 80
+
+	// thumbnailJobType is the worker job that generates a thumbnail job's files
+	thumbnailJobType = "storage.thumbnails"
+
+	// Pending uploads that outlive pendingUploadTTL unconfirmed have their
+	// reservation released and their object removed by a periodic sweep
+	pendingUploadSweepInterval = 10 * time.Minute
+	pendingUploadSweepTimeout  = 2 * time.Minute
+	pendingUploadSweepBatch    = 100
+
+	// serviceIdentity is who the sweep acts as in its calls to quota-service
+	serviceIdentity = "storage-service"
 )
 
+// pendingUploadsKey is the sorted set of pending uploads scored by the Unix
+// time they expire; it spans tenants so one sweep covers them all
+var pendingUploadsKey = cache.GlobalKey("storage", "pending_uploads")
+
+// thumbnailMimeTypes lists the formats thumbnails can be generated for
+var thumbnailMimeTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
 // Service handles storage business logic
 type Service struct {
 	repo        *repository.Repository
 	cache       *cache.Cache
 	minioClient *minio.Client
 	bucketName  string
+	region      string
+	quota       *client.QuotaClient
+	documents   *client.DocumentClient
+	rbac        *client.RBACClient
+	jobs        *worker.Pool
+	processing  config.ProcessingConfig
+	stopSweep   chan struct{}
+	sweepDone   chan struct{}
 	logger      *zap.Logger
 }
 
-// NewService creates a new storage service
-func NewService(repo *repository.Repository, cache *cache.Cache, cfg config.MinIOConfig, logger *zap.Logger) (*Service, error) {
+// NewService creates a new storage service and registers its background jobs on the pool
+func NewService(repo *repository.Repository, cache *cache.Cache, cfg config.MinIOConfig, processing config.ProcessingConfig, quota *client.QuotaClient, documents *client.DocumentClient, rbac *client.RBACClient, jobs *worker.Pool, logger *zap.Logger) (*Service, error) {
 	// Initialize MinIO client
-	minioClient, err := minio.New(cfg.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
-		Secure: cfg.UseSSL,
-		Region: cfg.Region,
-	})
+	minioClient, err := newMinIOClient(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
+		return nil, err
 	}
 
-	return &Service{
+	s := &Service{
 		repo:        repo,
 		cache:       cache,
 		minioClient: minioClient,
 		bucketName:  cfg.BucketName,
+		region:      cfg.Region,
+		quota:       quota,
+		documents:   documents,
+		rbac:        rbac,
+		jobs:        jobs,
+		processing:  processing,
+		stopSweep:   make(chan struct{}),
+		sweepDone:   make(chan struct{}),
 		logger:      logger,
-	}, nil
+	}
+	jobs.Register(thumbnailJobType, s.handleThumbnailJob)
+	go s.runPendingUploadSweep()
+
+	return s, nil
 }
 
-// EnsureBucket ensures the bucket exists, creates if not
+// Close stops the pending upload sweep and waits for a sweep in progress
+func (s *Service) Close() {
+	close(s.stopSweep)
+	<-s.sweepDone
+}
+
+// newMinIOClient validates the endpoint and builds a client for it. The
+// endpoint is host[:port]; MINIO_USE_SSL selects the scheme.
+func newMinIOClient(cfg config.MinIOConfig) (*minio.Client, error) {
+	endpoint, err := url.Parse("//" + cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || strings.Contains(cfg.Endpoint, "://") ||
+		(endpoint.Path != "" && endpoint.Path != "/") {
+		return nil, fmt.Errorf("invalid MINIO_ENDPOINT %q: expected host[:port] without scheme or path", cfg.Endpoint)
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	minioClient, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
+	}
+
+	return minioClient, nil
+}
+
+// EnsureBucket ensures the bucket exists, creates if not. It is also the
+// startup connectivity check, so failures name the endpoint and bucket.
 func (s *Service) EnsureBucket(ctx context.Context) error {
 	exists, err := s.minioClient.BucketExists(ctx, s.bucketName)
 	if err != nil {
-		return fmt.Errorf("failed to check bucket existence: %w", err)
+		return fmt.Errorf("cannot reach bucket %q at %s (check MINIO_ENDPOINT, MINIO_USE_SSL, MINIO_REGION, MINIO_PATH_STYLE and credentials): %w",
+			s.bucketName, s.minioClient.EndpointURL().String(), err)
 	}
 
 	if !exists {
-		err = s.minioClient.MakeBucket(ctx, s.bucketName, minio.MakeBucketOptions{})
+		err = s.minioClient.MakeBucket(ctx, s.bucketName, minio.MakeBucketOptions{Region: s.region})
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %w", err)
 		}
@@ -83,9 +179,19 @@ func (s *Service) UploadFile(ctx context.Context, req *models.UploadFileRequest,
 	tenantID := getTenantID(ctx)
 	userID := middleware.GetUserID(ctx)
 
+	// The declared mime_type is not trusted for size limits: the type
+	// sniffed from the content applies too, and the stricter limit wins
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, errors.Validationf("failed to read uploaded file")
+	}
+	head = head[:n]
+	file = io.MultiReader(bytes.NewReader(head), file)
+
 	// Validate file size
-	if req.FileSize > maxFileSize {
-		return nil, errors.Validationf("file size exceeds maximum allowed size of %d bytes", maxFileSize)
+	if err := s.checkFileSize(ctx, req.FileSize, req.MimeType, http.DetectContentType(head)); err != nil {
+		return nil, err
 	}
 
 	// Parse document ID
@@ -94,15 +200,21 @@ func (s *Service) UploadFile(ctx context.Context, req *models.UploadFileRequest,
 		return nil, errors.Validationf("invalid document_id")
 	}
 
+	// Files may only be attached to documents of the caller's tenant
+	if err := s.documents.RequireDocument(ctx, documentID.String()); err != nil {
+		return nil, err
+	}
+
 	// Generate unique file ID and object key
 	fileID := uuid.New()
 	ext := filepath.Ext(req.FileName)
 	fileType := getFileType(req.MimeType)
 	objectKey := fmt.Sprintf("%s/%s/%s%s", tenantID.String(), documentID.String(), fileID.String(), ext)
 
-	// Calculate checksum while uploading
+	// Calculate checksum and count the bytes actually streamed while uploading
 	hasher := sha256.New()
-	teeReader := io.TeeReader(file, hasher)
+	counter := &countingReader{r: file}
+	teeReader := io.TeeReader(counter, hasher)
 
 	// Upload to MinIO
 	uploadInfo, err := s.minioClient.PutObject(
@@ -121,10 +233,22 @@ func (s *Service) UploadFile(ctx context.Context, req *models.UploadFileRequest,
 		},
 	)
 	if err != nil {
+		if counter.n < req.FileSize {
+			_ = s.minioClient.RemoveObject(ctx, s.bucketName, objectKey, minio.RemoveObjectOptions{})
+			return nil, sizeMismatchError(req.FileSize, counter.n)
+		}
 		s.logger.Error("failed to upload file to MinIO", zap.Error(err))
 		return nil, errors.New(errors.ErrCodeInternal,"failed to upload file")
 	}
 
+	// MinIO stops after the declared size; any byte left over means the
+	// client under-reported it and the stored object is truncated
+	_, _ = io.CopyN(io.Discard, counter, 1)
+	if counter.n != req.FileSize || uploadInfo.Size != req.FileSize {
+		_ = s.minioClient.RemoveObject(ctx, s.bucketName, objectKey, minio.RemoveObjectOptions{})
+		return nil, sizeMismatchError(req.FileSize, counter.n)
+	}
+
 	// Calculate checksum
 	checksum := fmt.Sprintf("%x", hasher.Sum(nil))
 
@@ -135,17 +259,18 @@ func (s *Service) UploadFile(ctx context.Context, req *models.UploadFileRequest,
 		DocumentID:   documentID,
 		FileName:     fmt.Sprintf("%s%s", fileID.String(), ext),
 		OriginalName: req.FileName,
-		FileSize:     uploadInfo.Size,
+		FileSize:     counter.n,
 		MimeType:     req.MimeType,
 		FileType:     fileType,
 		BucketName:   s.bucketName,
 		ObjectKey:    objectKey,
 		StoragePath:  objectKey,
 		Checksum:     checksum,
+		ETag:         sql.NullString{String: uploadInfo.ETag, Valid: uploadInfo.ETag != ""},
 		UploadedBy:   userID,
 		IsEncrypted:  req.IsEncrypted,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		CreatedAt:    timeutil.Now(),
+		UpdatedAt:    timeutil.Now(),
 	}
 
 	if err := s.repo.CreateFileMetadata(ctx, metadata); err != nil {
@@ -169,7 +294,7 @@ func (s *Service) UploadFile(ctx context.Context, req *models.UploadFileRequest,
 	logger.InfoContext(ctx, "file uploaded",
 		zap.String("file_id", fileID.String()),
 		zap.String("document_id", documentID.String()),
-		zap.Int64("size", uploadInfo.Size),
+		zap.Int64("size", counter.n),
 	)
 
 	return &models.UploadFileResponse{
@@ -177,21 +302,119 @@ func (s *Service) UploadFile(ctx context.Context, req *models.UploadFileRequest,
 		DocumentID:  documentID,
 		UploadURL:   presignedURL.String(),
 		FileName:    metadata.FileName,
-		ExpiresAt:   time.Now().Add(presignedURLExpiry),
+		ExpiresAt:   timeutil.From(time.Now().Add(presignedURLExpiry)),
 		StoragePath: objectKey,
 	}, nil
 }
 
-// GetPresignedUploadURL generates a presigned URL for direct upload
+// checkFileSize enforces the most restrictive size limit that applies to an
+// upload: the global maximum or a tenant limit for any of its MIME types,
+// such as the declared type and the one detected from the content. The error
+// names the limit that was hit.
+func (s *Service) checkFileSize(ctx context.Context, size int64, mimeTypes ...string) error {
+	limits, err := s.uploadSizeLimits(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		key   string
+		limit int64
+		ok    bool
+	)
+	for _, mimeType := range mimeTypes {
+		if k, l, found := limits.Limit(mimeType); found && (!ok || l < limit) {
+			key, limit, ok = k, l, true
+		}
+	}
+	if !ok || limit >= maxFileSize {
+		if size > maxFileSize {
+			return errors.Validationf("file size exceeds maximum allowed size of %d bytes", maxFileSize).
+				WithMeta("limit", "max_file_size").
+				WithMeta("limit_bytes", int64(maxFileSize))
+		}
+		return nil
+	}
+
+	if size > limit {
+		return errors.Validationf("file size exceeds the %d byte limit for %s files", limit, key).
+			WithField("file_size", "exceeds the size limit for this file type").
+			WithMeta("limit", key).
+			WithMeta("limit_bytes", limit)
+	}
+	return nil
+}
+
+// uploadSizeLimits returns the per-type upload size limits of the tenant in ctx
+func (s *Service) uploadSizeLimits(ctx context.Context) (models.UploadSizeLimits, error) {
+	tenantID := getTenantID(ctx)
+	cacheKey := cache.TenantKey(tenantID.String(), "upload_size_limits")
+
+	var limits models.UploadSizeLimits
+	if err := s.cache.Get(ctx, cacheKey, &limits); err == nil {
+		return limits, nil
+	}
+
+	limits, err := s.repo.GetTenantUploadSizeLimits(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.Set(ctx, cacheKey, limits, uploadSizeLimitsCacheTTL)
+
+	return limits, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// sizeMismatchError rejects an upload whose stream did not match its declared
+// size. received is a lower bound when the stream was longer.
+func sizeMismatchError(declared, received int64) error {
+	if received > declared {
+		return errors.Validationf("file is larger than the declared file_size of %d bytes", declared).
+			WithField("file_size", "does not match the uploaded content").
+			WithMeta("declared_size", declared)
+	}
+	return errors.Validationf("file is %d bytes but file_size declared %d", received, declared).
+		WithField("file_size", "does not match the uploaded content").
+		WithMeta("declared_size", declared).
+		WithMeta("received_size", received)
+}
+
+// GetPresignedUploadURL generates a presigned URL for direct upload. The
+// upload is recorded as pending; metadata is only written once the client
+// calls ConfirmUpload. An upload left unconfirmed past pendingUploadTTL has
+// its reservation released by the pending upload sweep.
 func (s *Service) GetPresignedUploadURL(ctx context.Context, req *models.UploadFileRequest) (*models.PresignedURLResponse, error) {
 	tenantID := getTenantID(ctx)
 
+	// Validate file size against the declared type; ConfirmUpload checks
+	// again with the type detected from the stored content
+	if err := s.checkFileSize(ctx, req.FileSize, req.MimeType); err != nil {
+		return nil, err
+	}
+
 	// Parse document ID
 	documentID, err := uuid.Parse(req.DocumentID)
 	if err != nil {
 		return nil, errors.Validationf("invalid document_id")
 	}
 
+	// Files may only be attached to documents of the caller's tenant
+	if err := s.documents.RequireDocument(ctx, documentID.String()); err != nil {
+		return nil, err
+	}
+
 	// Generate object key
 	fileID := uuid.New()
 	ext := filepath.Ext(req.FileName)
@@ -209,18 +432,308 @@ func (s *Service) GetPresignedUploadURL(ctx context.Context, req *models.UploadF
 		return nil, errors.New(errors.ErrCodeInternal,"failed to generate upload URL")
 	}
 
+	// Hold the declared size until the upload is confirmed; ConfirmUpload
+	// settles the reservation at the real object size
+	if err := s.quota.ReserveQuota(ctx, map[string]int64{"storage": req.FileSize, "file_size": req.FileSize}); err != nil {
+		return nil, err
+	}
+
+	pending := &models.PendingUpload{
+		FileID:       fileID,
+		DocumentID:   documentID,
+		ObjectKey:    objectKey,
+		OriginalName: req.FileName,
+		MimeType:     req.MimeType,
+		FileSize:     req.FileSize,
+		IsEncrypted:  req.IsEncrypted,
+		UploadedBy:   middleware.GetUserID(ctx),
+		Reserved:     req.FileSize,
+	}
+	pendingKey := cache.TenantKey(tenantID.String(), "pending_upload", fileID.String())
+	if err := s.cache.Set(ctx, pendingKey, pending, pendingUploadTTL); err != nil {
+		s.logger.Error("failed to record pending upload", zap.Error(err))
+		s.releaseReservation(ctx, pending)
+		return nil, errors.New(errors.ErrCodeInternal, "failed to generate upload URL")
+	}
+	expiresAt := time.Now().Add(pendingUploadTTL)
+	if err := s.cache.ZAdd(ctx, pendingUploadsKey, float64(expiresAt.Unix()), pendingUploadMember(tenantID, pending)); err != nil {
+		s.logger.Error("failed to schedule pending upload expiry", zap.Error(err))
+		_ = s.cache.Delete(ctx, pendingKey)
+		s.releaseReservation(ctx, pending)
+		return nil, errors.New(errors.ErrCodeInternal, "failed to generate upload URL")
+	}
+
 	return &models.PresignedURLResponse{
+		FileID:    fileID,
 		URL:       presignedURL.String(),
-		ExpiresAt: time.Now().Add(presignedURLExpiry),
+		ExpiresAt: timeutil.From(time.Now().Add(presignedURLExpiry)),
 	}, nil
 }
 
+// ConfirmUpload persists metadata for a presigned upload once the object is
+// in storage. The object must exist, be no larger than the size declared
+// when the URL was issued and fit the size limit of the type detected from its
+// content; its checksum is computed server-side and the storage reserved for
+// the declared size is settled at the object's real size.
+func (s *Service) ConfirmUpload(ctx context.Context, req *models.ConfirmUploadRequest) (*models.FileMetadata, error) {
+	tenantID := getTenantID(ctx)
+
+	fileID, err := uuid.Parse(req.FileID)
+	if err != nil {
+		return nil, errors.Validationf("invalid file_id")
+	}
+
+	var pending models.PendingUpload
+	pendingKey := cache.TenantKey(tenantID.String(), "pending_upload", fileID.String())
+	if err := s.cache.Get(ctx, pendingKey, &pending); err != nil {
+		return nil, errors.NotFoundf("pending upload not found or expired")
+	}
+
+	info, err := s.minioClient.StatObject(ctx, s.bucketName, pending.ObjectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, errors.Validationf("object has not been uploaded")
+		}
+		s.logger.Error("failed to stat uploaded object", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to verify upload")
+	}
+	if info.Size > pending.FileSize {
+		return nil, errors.Validationf("uploaded object size %d exceeds declared size %d", info.Size, pending.FileSize)
+	}
+
+	checksum, detectedType, err := s.objectChecksum(ctx, pending.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkFileSize(ctx, info.Size, pending.MimeType, detectedType); err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(pending.OriginalName)
+	metadata := &models.FileMetadata{
+		ID:           fileID,
+		TenantID:     tenantID,
+		DocumentID:   pending.DocumentID,
+		FileName:     fmt.Sprintf("%s%s", fileID.String(), ext),
+		OriginalName: pending.OriginalName,
+		FileSize:     info.Size,
+		MimeType:     pending.MimeType,
+		FileType:     getFileType(pending.MimeType),
+		BucketName:   s.bucketName,
+		ObjectKey:    pending.ObjectKey,
+		StoragePath:  pending.ObjectKey,
+		Checksum:     checksum,
+		ETag:         sql.NullString{String: info.ETag, Valid: info.ETag != ""},
+		UploadedBy:   pending.UploadedBy,
+		IsEncrypted:  pending.IsEncrypted,
+		CreatedAt:    timeutil.Now(),
+		UpdatedAt:    timeutil.Now(),
+	}
+
+	// The file ID is the primary key, so a repeated confirm fails here
+	// before usage is counted twice
+	if err := s.repo.CreateFileMetadata(ctx, metadata); err != nil {
+		return nil, err
+	}
+
+	// Claim the reservation from the expiry sweep; once the sweep has it,
+	// the reservation is released and the object removed
+	member := pendingUploadMember(tenantID, &pending)
+	claimed, err := s.cache.ZRem(ctx, pendingUploadsKey, member)
+	if err != nil || !claimed {
+		_ = s.repo.DeleteFileMetadata(ctx, tenantID, fileID)
+		if err != nil {
+			s.logger.Error("failed to claim pending upload", zap.Error(err))
+			return nil, errors.New(errors.ErrCodeInternal, "failed to confirm upload")
+		}
+		return nil, errors.NotFoundf("pending upload not found or expired")
+	}
+
+	// StatObject is authoritative: usage is corrected from the declared size
+	// to what was actually stored
+	if err := s.quota.AdjustReservation(ctx, "storage", pending.Reserved, info.Size); err != nil {
+		s.logger.Error("failed to settle storage reservation", zap.Error(err))
+		_ = s.repo.DeleteFileMetadata(ctx, tenantID, fileID)
+		// Hand the reservation back to the sweep so a retry or expiry settles it
+		_ = s.cache.ZAdd(ctx, pendingUploadsKey, float64(time.Now().Add(pendingUploadTTL).Unix()), member)
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, pendingKey)
+
+	logger.InfoContext(ctx, "presigned upload confirmed",
+		zap.String("file_id", fileID.String()),
+		zap.String("document_id", pending.DocumentID.String()),
+		zap.Int64("size", info.Size),
+		zap.Int64("reserved", pending.Reserved),
+	)
+
+	return metadata, nil
+}
+
+// releaseReservation returns the storage held for a pending upload that will
+// not be confirmed. A failure leaves the tenant overcounted until usage is
+// reconciled, so it is only logged.
+func (s *Service) releaseReservation(ctx context.Context, pending *models.PendingUpload) {
+	if err := s.quota.AdjustReservation(ctx, "storage", pending.Reserved, 0); err != nil {
+		logger.WarnContext(ctx, "failed to release storage reservation",
+			zap.String("file_id", pending.FileID.String()),
+			zap.Int64("reserved", pending.Reserved),
+			zap.Error(err),
+		)
+	}
+}
+
+// pendingUploadEntry is a pending upload as tracked by the expiry sweep
+type pendingUploadEntry struct {
+	TenantID  string `json:"tenant_id"`
+	FileID    string `json:"file_id"`
+	ObjectKey string `json:"object_key"`
+	Reserved  int64  `json:"reserved"`
+}
+
+// pendingUploadMember encodes a pending upload as its sweep set member. The
+// encoding is deterministic so ConfirmUpload can rebuild it to claim the entry.
+func pendingUploadMember(tenantID uuid.UUID, pending *models.PendingUpload) string {
+	data, _ := json.Marshal(pendingUploadEntry{
+		TenantID:  tenantID.String(),
+		FileID:    pending.FileID.String(),
+		ObjectKey: pending.ObjectKey,
+		Reserved:  pending.Reserved,
+	})
+	return string(data)
+}
+
+// runPendingUploadSweep periodically expires unconfirmed uploads until Close
+// is called
+func (s *Service) runPendingUploadSweep() {
+	defer close(s.sweepDone)
+
+	ticker := time.NewTicker(pendingUploadSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sweepPendingUploads()
+		case <-s.stopSweep:
+			return
+		}
+	}
+}
+
+// sweepPendingUploads releases the reservation and removes the object of
+// every pending upload past its TTL. Each entry is claimed with ZRem first, so
+// concurrent sweeps and a late ConfirmUpload never settle it twice.
+func (s *Service) sweepPendingUploads() {
+	ctx, cancel := context.WithTimeout(context.Background(), pendingUploadSweepTimeout)
+	defer cancel()
+
+	for ctx.Err() == nil {
+		members, err := s.cache.ZRangeByScore(ctx, pendingUploadsKey, float64(time.Now().Unix()), pendingUploadSweepBatch)
+		if err != nil {
+			s.logger.Warn("failed to list expired pending uploads", zap.Error(err))
+			return
+		}
+
+		for _, member := range members {
+			claimed, err := s.cache.ZRem(ctx, pendingUploadsKey, member)
+			if err != nil {
+				s.logger.Warn("failed to claim expired pending upload", zap.Error(err))
+				return
+			}
+			if claimed {
+				s.expirePendingUpload(ctx, member)
+			}
+		}
+
+		if len(members) < pendingUploadSweepBatch {
+			return
+		}
+	}
+}
+
+// expirePendingUpload settles one claimed sweep entry
+func (s *Service) expirePendingUpload(ctx context.Context, member string) {
+	var entry pendingUploadEntry
+	if err := json.Unmarshal([]byte(member), &entry); err != nil {
+		s.logger.Warn("invalid pending upload entry", zap.String("member", member), zap.Error(err))
+		return
+	}
+	fileID, err := uuid.Parse(entry.FileID)
+	if err != nil {
+		s.logger.Warn("invalid pending upload entry", zap.String("member", member), zap.Error(err))
+		return
+	}
+
+	// The sweep has no user; it acts as the service for the entry's tenant
+	ctx = middleware.WithServiceIdentity(middleware.WithTenantID(ctx, entry.TenantID), serviceIdentity)
+	s.releaseReservation(ctx, &models.PendingUpload{FileID: fileID, ObjectKey: entry.ObjectKey, Reserved: entry.Reserved})
+
+	if err := s.minioClient.RemoveObject(ctx, s.bucketName, entry.ObjectKey, minio.RemoveObjectOptions{}); err != nil {
+		logger.WarnContext(ctx, "failed to remove unconfirmed upload object",
+			zap.String("file_id", entry.FileID),
+			zap.String("object_key", entry.ObjectKey),
+			zap.Error(err),
+		)
+	}
+}
+
+// objectChecksum streams an object from storage and returns its SHA-256 and
+// the content type detected from its first bytes
+func (s *Service) objectChecksum(ctx context.Context, objectKey string) (string, string, error) {
+	object, err := s.minioClient.GetObject(ctx, s.bucketName, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		s.logger.Error("failed to read uploaded object", zap.Error(err))
+		return "", "", errors.New(errors.ErrCodeInternal, "failed to verify upload")
+	}
+	defer object.Close()
+
+	hasher := sha256.New()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(object, head)
+	if err == nil {
+		_, err = io.Copy(hasher, io.MultiReader(bytes.NewReader(head), object))
+	} else if err == io.EOF || err == io.ErrUnexpectedEOF {
+		_, err = hasher.Write(head[:n])
+	}
+	if err != nil {
+		s.logger.Error("failed to checksum uploaded object", zap.Error(err))
+		return "", "", errors.New(errors.ErrCodeInternal, "failed to verify upload")
+	}
+
+	return fmt.Sprintf("%x", hasher.Sum(nil)), http.DetectContentType(head[:n]), nil
+}
+
 // DownloadFile generates a download URL for a file
 func (s *Service) DownloadFile(ctx context.Context, fileID uuid.UUID, inline bool, expiryTime int) (*models.DownloadFileResponse, error) {
 	tenantID := getTenantID(ctx)
 
 	// Get file metadata
-	metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID)
+	metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.downloadURL(ctx, metadata, inline, expiryTime)
+}
+
+// DownloadDocumentFile generates a download URL for the latest file of a
+// document (internal use, e.g. share downloads)
+func (s *Service) DownloadDocumentFile(ctx context.Context, documentID uuid.UUID, expiryTime int) (*models.DownloadFileResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	metadata, err := s.repo.GetFileMetadataByDocumentID(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.downloadURL(ctx, metadata, false, expiryTime)
+}
+
+// downloadURL presigns a GET for a file after checking its integrity
+func (s *Service) downloadURL(ctx context.Context, metadata *models.FileMetadata, inline bool, expiryTime int) (*models.DownloadFileResponse, error) {
+	integrityWarning, err := s.checkObjectIntegrity(ctx, metadata)
 	if err != nil {
 		return nil, err
 	}
@@ -256,16 +769,52 @@ func (s *Service) DownloadFile(ctx context.Context, fileID uuid.UUID, inline boo
 		FileName:    metadata.OriginalName,
 		FileSize:    metadata.FileSize,
 		MimeType:    metadata.MimeType,
-		ExpiresAt:   time.Now().Add(expiry),
+		ExpiresAt:   timeutil.From(time.Now().Add(expiry)),
+
+		IntegrityWarning: integrityWarning,
 	}, nil
 }
 
+// checkObjectIntegrity compares the object's current ETag with the one
+// recorded at upload. A mismatch is logged and returned as a warning rather
+// than an error, so the file can still be retrieved for inspection. Files
+// uploaded before ETags were recorded are not checked.
+func (s *Service) checkObjectIntegrity(ctx context.Context, metadata *models.FileMetadata) (string, error) {
+	if !metadata.ETag.Valid {
+		return "", nil
+	}
+
+	info, err := s.minioClient.StatObject(ctx, s.bucketName, metadata.ObjectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return "", errors.NotFoundf("file content not found in storage")
+		}
+		s.logger.Error("failed to stat object", zap.String("file_id", metadata.ID.String()), zap.Error(err))
+		return "", errors.New(errors.ErrCodeInternal, "failed to verify file")
+	}
+
+	if info.ETag == metadata.ETag.String {
+		return "", nil
+	}
+
+	logger.ErrorContext(ctx, "stored object does not match recorded etag, possible corruption",
+		zap.String("file_id", metadata.ID.String()),
+		zap.String("object_key", metadata.ObjectKey),
+		zap.String("expected_etag", metadata.ETag.String),
+		zap.String("etag", info.ETag),
+		zap.Int64("expected_size", metadata.FileSize),
+		zap.Int64("size", info.Size),
+	)
+
+	return "stored file content has changed since upload and may be corrupted", nil
+}
+
 // DeleteFile deletes a file
 func (s *Service) DeleteFile(ctx context.Context, fileID uuid.UUID, hardDelete bool) error {
 	tenantID := getTenantID(ctx)
 
 	// Get file metadata
-	metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID)
+	metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID, false)
 	if err != nil {
 		return err
 	}
@@ -301,11 +850,138 @@ func (s *Service) DeleteFile(ctx context.Context, fileID uuid.UUID, hardDelete b
 	return nil
 }
 
-// GetFileMetadata retrieves file metadata
-func (s *Service) GetFileMetadata(ctx context.Context, fileID uuid.UUID) (*models.FileMetadata, error) {
+// RelocateFile moves a file's object to a key under path. The object is
+// copied server-side first and the original is only removed once the copy
+// has been verified and the metadata points at it, so a failure at any step
+// leaves a readable file: a failed copy or metadata update keeps the original
+// (the copy is cleaned up), and a failed removal only leaves an orphan. Each
+// attempt copies to its own key, so when two relocations of the same file
+// race, the loser only ever removes its own copy.
+func (s *Service) RelocateFile(ctx context.Context, fileID uuid.UUID, req *models.RelocateFileRequest) (*models.FileMetadata, error) {
+	tenantID := getTenantID(ctx)
+
+	metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	oldKey := metadata.ObjectKey
+	if isRelocatedObjectKey(oldKey, tenantID, req.Path, metadata.FileName) {
+		return metadata, nil
+	}
+	newKey := relocatedObjectKey(tenantID, req.Path, uuid.New(), metadata.FileName)
+
+	_, err = s.minioClient.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucketName, Object: newKey},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: oldKey},
+	)
+	if err != nil {
+		s.logger.Error("failed to copy object", zap.String("file_id", fileID.String()), zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to copy file to new location")
+	}
+
+	// Verify the copy before anything refers to it
+	info, err := s.minioClient.StatObject(ctx, s.bucketName, newKey, minio.StatObjectOptions{})
+	if err != nil || info.Size != metadata.FileSize {
+		s.logger.Error("relocated object failed verification",
+			zap.String("file_id", fileID.String()),
+			zap.Int64("expected_size", metadata.FileSize),
+			zap.Int64("size", info.Size),
+			zap.Error(err),
+		)
+		s.removeRelocatedCopy(ctx, fileID, newKey)
+		return nil, errors.New(errors.ErrCodeInternal, "failed to verify file at new location")
+	}
+
+	if err := s.repo.RelocateFileMetadata(ctx, tenantID, fileID, oldKey, newKey, info.ETag); err != nil {
+		s.removeRelocatedCopy(ctx, fileID, newKey)
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "file", fileID.String()))
+
+	if err := s.minioClient.RemoveObject(ctx, s.bucketName, oldKey, minio.RemoveObjectOptions{}); err != nil {
+		logger.WarnContext(ctx, "failed to remove original object after relocation",
+			zap.String("file_id", fileID.String()),
+			zap.String("object_key", oldKey),
+			zap.Error(err),
+		)
+	}
+
+	logger.InfoContext(ctx, "file relocated",
+		zap.String("file_id", fileID.String()),
+		zap.String("from", oldKey),
+		zap.String("to", newKey),
+	)
+
+	metadata.ObjectKey = newKey
+	metadata.StoragePath = newKey
+	return metadata, nil
+}
+
+// CopyObject server-side copies one of the tenant's objects to a new key under
+// the target document. A source that no longer exists (purged) is reported as
+// not found.
+func (s *Service) CopyObject(ctx context.Context, req *models.CopyObjectRequest) (*models.CopiedObject, error) {
 	tenantID := getTenantID(ctx)
 
-	// Try cache first
+	documentID, err := uuid.Parse(req.DocumentID)
+	if err != nil {
+		return nil, errors.Validationf("invalid document_id")
+	}
+	if !strings.HasPrefix(req.SourceKey, tenantID.String()+"/") {
+		return nil, errors.NotFoundf("source object not found")
+	}
+
+	source, err := s.minioClient.StatObject(ctx, s.bucketName, req.SourceKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, errors.NotFoundf("source object not found").WithMeta("source_key", req.SourceKey)
+		}
+		s.logger.Error("failed to stat source object", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to read source object")
+	}
+
+	objectKey := fmt.Sprintf("%s/%s/%s%s", tenantID.String(), documentID.String(), uuid.New().String(), filepath.Ext(req.SourceKey))
+	_, err = s.minioClient.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucketName, Object: objectKey},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: req.SourceKey},
+	)
+	if err != nil {
+		s.logger.Error("failed to copy object", zap.String("source_key", req.SourceKey), zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to copy object")
+	}
+
+	logger.InfoContext(ctx, "object copied",
+		zap.String("document_id", documentID.String()),
+		zap.String("from", req.SourceKey),
+		zap.String("to", objectKey),
+	)
+
+	return &models.CopiedObject{
+		ObjectKey: objectKey,
+		Size:      source.Size,
+		MimeType:  source.ContentType,
+	}, nil
+}
+
+// removeRelocatedCopy discards the copy made by a relocation that did not complete
+func (s *Service) removeRelocatedCopy(ctx context.Context, fileID uuid.UUID, objectKey string) {
+	if err := s.minioClient.RemoveObject(ctx, s.bucketName, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		logger.WarnContext(ctx, "failed to remove copy of aborted relocation",
+			zap.String("file_id", fileID.String()),
+			zap.String("object_key", objectKey),
+			zap.Error(err),
+		)
+	}
+}
+
+// GetFileMetadata retrieves file metadata; a soft-deleted file is only found
+// when includeTrashed is set
+func (s *Service) GetFileMetadata(ctx context.Context, fileID uuid.UUID, includeTrashed bool) (*models.FileMetadata, error) {
+	tenantID := getTenantID(ctx)
+
+	// Try cache first; only live files are cached
 	cacheKey := cache.TenantKey(tenantID.String(), "file", fileID.String())
 	var metadata models.FileMetadata
 	if err := s.cache.Get(ctx, cacheKey, &metadata); err == nil {
@@ -313,17 +989,85 @@ func (s *Service) GetFileMetadata(ctx context.Context, fileID uuid.UUID) (*model
 	}
 
 	// Fetch from database
-	metadataPtr, err := s.repo.GetFileMetadata(ctx, tenantID, fileID)
+	metadataPtr, err := s.repo.GetFileMetadata(ctx, tenantID, fileID, includeTrashed)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache for future requests
-	_ = s.cache.Set(ctx, cacheKey, metadataPtr, fileCacheTTL)
+	if !includeTrashed {
+		_ = s.cache.Set(ctx, cacheKey, metadataPtr, fileCacheTTL)
+	}
 
 	return metadataPtr, nil
 }
 
+// BulkUpdateFiles moves files to another document of the tenant and/or
+// corrects their file type. All updates are applied in one transaction; files
+// that do not exist in the tenant are reported per file, while an unknown or
+// foreign target document rejects the whole request. Re-pointing files
+// bypasses the documents' own access checks, so it requires document:manage.
+func (s *Service) BulkUpdateFiles(ctx context.Context, req *models.BulkUpdateFilesRequest) (*models.BulkUpdateFilesResponse, error) {
+	tenantID := getTenantID(ctx)
+
+	allowed, err := s.rbac.CheckPermission(ctx, middleware.GetUserID(ctx), "document", "manage")
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errors.Forbiddenf("document:manage permission required")
+	}
+
+	updates := make(map[string]interface{}, 2)
+	if req.DocumentID != "" {
+		documentID, err := uuid.Parse(req.DocumentID)
+		if err != nil {
+			return nil, errors.Validationf("invalid document_id: %s", req.DocumentID)
+		}
+		updates["document_id"] = documentID
+	}
+	if req.FileType != "" {
+		updates["file_type"] = strings.ToLower(req.FileType)
+	}
+	if len(updates) == 0 {
+		return nil, errors.Validationf("document_id or file_type is required")
+	}
+
+	fileIDs := make([]uuid.UUID, 0, len(req.FileIDs))
+	for _, idStr := range req.FileIDs {
+		fileID, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Validationf("invalid file_id: %s", idStr)
+		}
+		fileIDs = append(fileIDs, fileID)
+	}
+
+	updated, err := s.repo.BulkUpdateFileMetadata(ctx, tenantID, fileIDs, updates)
+	if err != nil {
+		return nil, err
+	}
+
+	response := bulk.NewResponse[bulk.Result](len(fileIDs))
+	for _, fileID := range fileIDs {
+		result := bulk.Result{ID: fileID.String(), Success: updated[fileID]}
+		if result.Success {
+			_ = s.cache.Delete(ctx, cache.TenantKey(tenantID.String(), "file", fileID.String()))
+		} else {
+			result.Error = "file not found"
+		}
+		response.Add(result, result.Success)
+	}
+
+	logger.InfoContext(ctx, "bulk file metadata update completed",
+		zap.String("document_id", req.DocumentID),
+		zap.String("file_type", req.FileType),
+		zap.Int("succeeded", response.Succeeded),
+		zap.Int("failed", response.Failed),
+	)
+
+	return response, nil
+}
+
 // ListFiles retrieves files with filtering
 func (s *Service) ListFiles(ctx context.Context, params *models.ListFilesParams) ([]models.FileMetadata, int64, error) {
 	tenantID := getTenantID(ctx)
@@ -350,6 +1094,362 @@ func (s *Service) GetFileStats(ctx context.Context) (*models.FileStats, error) {
 	return stats, nil
 }
 
+// GetStorageByFolder retrieves storage usage per folder, optionally scoped to a folder subtree
+func (s *Service) GetStorageByFolder(ctx context.Context, folderID *uuid.UUID) ([]models.FolderStorageStats, error) {
+	tenantID := getTenantID(ctx)
+
+	stats, err := s.repo.GetStorageByFolder(ctx, tenantID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// RegenerateThumbnail queues thumbnail generation for a single file. Files whose
+// type has no thumbnail support get an "unsupported" job instead of an error.
+func (s *Service) RegenerateThumbnail(ctx context.Context, fileID uuid.UUID) (*models.ThumbnailJob, error) {
+	tenantID := getTenantID(ctx)
+
+	metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	job := newThumbnailJob(&fileID, 1)
+
+	if !thumbnailSupported(metadata.MimeType) {
+		job.Status = models.ThumbnailJobUnsupported
+		job.Message = fmt.Sprintf("thumbnails are not supported for %s files", metadata.MimeType)
+		return job, nil
+	}
+
+	s.startThumbnailJob(ctx, job, []models.FileMetadata{*metadata})
+
+	return job, nil
+}
+
+// BackfillThumbnails queues thumbnail generation for the tenant's files that lack one
+func (s *Service) BackfillThumbnails(ctx context.Context) (*models.ThumbnailJob, error) {
+	tenantID := getTenantID(ctx)
+
+	files, err := s.repo.ListFilesWithoutThumbnail(ctx, tenantID, thumbnailMimeTypes, thumbnailBackfillLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	job := newThumbnailJob(nil, len(files))
+
+	if len(files) == 0 {
+		job.Status = models.ThumbnailJobCompleted
+		job.Message = "no files are missing thumbnails"
+		return job, nil
+	}
+
+	s.startThumbnailJob(ctx, job, files)
+
+	return job, nil
+}
+
+// GetThumbnailJob retrieves the status of a thumbnail job
+func (s *Service) GetThumbnailJob(ctx context.Context, jobID uuid.UUID) (*models.ThumbnailJob, error) {
+	tenantID := getTenantID(ctx)
+
+	var job models.ThumbnailJob
+	cacheKey := cache.TenantKey(tenantID.String(), "thumbnail_job", jobID.String())
+	if err := s.cache.Get(ctx, cacheKey, &job); err != nil {
+		return nil, errors.NotFoundf("thumbnail job not found")
+	}
+
+	return &job, nil
+}
+
+// thumbnailJobPayload is the queued form of a thumbnail job. Files are
+// reloaded when the job runs so a retry sees their current thumbnail key.
+type thumbnailJobPayload struct {
+	Job     models.ThumbnailJob `json:"job"`
+	FileIDs []uuid.UUID         `json:"file_ids"`
+}
+
+// startThumbnailJob records the job and submits it to the worker pool, which
+// keeps the caller's tenant and request ID and retries failed runs. If the
+// queue rejects the job it is reported as failed.
+func (s *Service) startThumbnailJob(ctx context.Context, job *models.ThumbnailJob, files []models.FileMetadata) {
+	payload := thumbnailJobPayload{Job: *job, FileIDs: make([]uuid.UUID, len(files))}
+	for i := range files {
+		payload.FileIDs[i] = files[i].ID
+	}
+
+	if err := s.jobs.Submit(ctx, thumbnailJobType, payload); err != nil {
+		job.Status = models.ThumbnailJobFailed
+		job.Message = errors.FromError(err).Message
+	}
+	s.saveThumbnailJob(ctx, job)
+}
+
+// handleThumbnailJob runs a queued thumbnail job
+func (s *Service) handleThumbnailJob(ctx context.Context, data json.RawMessage) error {
+	var payload thumbnailJobPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("invalid thumbnail job payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, thumbnailJobTimeout)
+	defer cancel()
+
+	return s.runThumbnailJob(ctx, &payload.Job, payload.FileIDs)
+}
+
+// runThumbnailJob generates thumbnails for each file and tracks progress on
+// the job. Files that cannot be decoded or no longer exist are counted as
+// failed; any other failure is returned so the pool retries the job.
+func (s *Service) runThumbnailJob(ctx context.Context, job *models.ThumbnailJob, fileIDs []uuid.UUID) error {
+	tenantID := getTenantID(ctx)
+
+	job.Status = models.ThumbnailJobRunning
+	job.Processed, job.Failed, job.Skipped, job.Message = 0, 0, 0, ""
+	s.saveThumbnailJob(ctx, job)
+
+	retryable := 0
+	for _, fileID := range fileIDs {
+		skipped := false
+		metadata, err := s.repo.GetFileMetadata(ctx, tenantID, fileID, false)
+		if err == nil {
+			skipped, err = s.processThumbnail(ctx, metadata)
+		}
+		if skipped {
+			job.Skipped++
+			job.Message = fmt.Sprintf("%s exceeds the processing size limits", metadata.OriginalName)
+		}
+		if err != nil {
+			s.logger.Error("failed to generate thumbnail",
+				zap.String("job_id", job.ID.String()),
+				zap.String("file_id", fileID.String()),
+				zap.Error(err),
+			)
+			appErr := errors.FromError(err)
+			if appErr.Code != errors.ErrCodeValidation && appErr.Code != errors.ErrCodeNotFound {
+				retryable++
+			}
+			job.Failed++
+			job.Message = appErr.Message
+		}
+		job.Processed++
+		s.saveThumbnailJob(ctx, job)
+	}
+
+	job.Status = models.ThumbnailJobCompleted
+	if job.Total > 0 && job.Failed == job.Total {
+		job.Status = models.ThumbnailJobFailed
+	}
+	s.saveThumbnailJob(ctx, job)
+
+	logger.InfoContext(ctx, "thumbnail job finished",
+		zap.String("job_id", job.ID.String()),
+		zap.String("status", job.Status),
+		zap.Int("processed", job.Processed),
+		zap.Int("failed", job.Failed),
+		zap.Int("skipped", job.Skipped),
+	)
+
+	if retryable > 0 {
+		return fmt.Errorf("%d of %d thumbnails failed: %s", retryable, job.Total, job.Message)
+	}
+	return nil
+}
+
+// processThumbnail generates one file's thumbnail within PROCESSING_TIMEOUT.
+// A file over the processing limits is marked skipped_too_large on its
+// metadata and reported as skipped rather than failed.
+func (s *Service) processThumbnail(ctx context.Context, metadata *models.FileMetadata) (bool, error) {
+	if s.processing.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.processing.Timeout)
+		defer cancel()
+	}
+
+	err := s.generateThumbnail(ctx, metadata)
+	if err != errTooLargeToProcess {
+		return false, err
+	}
+
+	logger.WarnContext(ctx, "file skipped, exceeds processing limits",
+		zap.String("file_id", metadata.ID.String()),
+		zap.Int64("file_size", metadata.FileSize),
+		zap.Int64("max_file_size", s.processing.MaxFileSize),
+		zap.Int64("max_pixels", s.processing.MaxPixels),
+	)
+
+	if err := s.repo.UpdateProcessingStatus(ctx, metadata.TenantID, metadata.ID, models.ProcessingSkippedTooLarge); err != nil {
+		return true, err
+	}
+
+	cacheKey := cache.TenantKey(metadata.TenantID.String(), "file", metadata.ID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return true, nil
+}
+
+// errTooLargeToProcess is returned by generateThumbnail before decoding a
+// file over the processing limits
+var errTooLargeToProcess = errors.Validationf("file exceeds the processing size limits")
+
+// generateThumbnail renders a thumbnail for a file, stores it next to the file
+// and records its key. The size and pixel limits are checked before the
+// image is decoded, so a small file declaring huge dimensions is never
+// expanded in memory.
+func (s *Service) generateThumbnail(ctx context.Context, metadata *models.FileMetadata) error {
+	if s.processing.MaxFileSize > 0 && metadata.FileSize > s.processing.MaxFileSize {
+		return errTooLargeToProcess
+	}
+
+	object, err := s.minioClient.GetObject(ctx, s.bucketName, metadata.ObjectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return errors.New(errors.ErrCodeInternal, "failed to read file from storage")
+	}
+	defer object.Close()
+
+	src, err := s.decodeWithinLimits(object)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	thumb := scaleToFit(src, defaultThumbnailSize)
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return errors.New(errors.ErrCodeInternal, "failed to encode thumbnail")
+	}
+
+	thumbnailKey := fmt.Sprintf("%s/%s/thumbnails/%s.jpg", metadata.TenantID.String(), metadata.DocumentID.String(), metadata.ID.String())
+	_, err = s.minioClient.PutObject(ctx, s.bucketName, thumbnailKey, &buf, int64(buf.Len()), minio.PutObjectOptions{
+		ContentType: "image/jpeg",
+	})
+	if err != nil {
+		return errors.New(errors.ErrCodeInternal, "failed to upload thumbnail")
+	}
+
+	if err := s.repo.UpdateThumbnailKey(ctx, metadata.TenantID, metadata.ID, thumbnailKey); err != nil {
+		return err
+	}
+
+	// Remove a previous thumbnail stored under a different key
+	if metadata.ThumbnailKey.Valid && metadata.ThumbnailKey.String != thumbnailKey {
+		_ = s.minioClient.RemoveObject(ctx, s.bucketName, metadata.ThumbnailKey.String, minio.RemoveObjectOptions{})
+	}
+
+	// Invalidate cache
+	cacheKey := cache.TenantKey(metadata.TenantID.String(), "file", metadata.ID.String())
+	_ = s.cache.Delete(ctx, cacheKey)
+
+	return nil
+}
+
+// decodeWithinLimits reads the image header and only decodes the image when
+// its dimensions are within PROCESSING_MAX_PIXELS. Reads are capped at
+// PROCESSING_MAX_FILE_SIZE in case the stored object outgrew its metadata.
+func (s *Service) decodeWithinLimits(r io.ReadSeeker) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, errors.Validationf("file could not be decoded as an image")
+	}
+	if s.processing.MaxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > s.processing.MaxPixels {
+		return nil, errTooLargeToProcess
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.New(errors.ErrCodeInternal, "failed to read file from storage")
+	}
+
+	var src io.Reader = r
+	if s.processing.MaxFileSize > 0 {
+		src = io.LimitReader(r, s.processing.MaxFileSize)
+	}
+
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return nil, errors.Validationf("file could not be decoded as an image")
+	}
+
+	return img, nil
+}
+
+// saveThumbnailJob stores the job status so it can be polled
+func (s *Service) saveThumbnailJob(ctx context.Context, job *models.ThumbnailJob) {
+	job.UpdatedAt = timeutil.Now()
+	cacheKey := cache.TenantKey(getTenantID(ctx).String(), "thumbnail_job", job.ID.String())
+	if err := s.cache.Set(ctx, cacheKey, job, thumbnailJobTTL); err != nil {
+		s.logger.Error("failed to save thumbnail job", zap.String("job_id", job.ID.String()), zap.Error(err))
+	}
+}
+
+// PurgeTenant removes every object under the tenant's prefix, including
+// thumbnails, then its file metadata (internal use, called by tenant
+// deletion). Objects go first so a failed run never leaves objects without
+// a record pointing at them; rerunning finishes the job.
+func (s *Service) PurgeTenant(ctx context.Context) (int64, error) {
+	tenantID := getTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return 0, errors.Validationf("tenant is required")
+	}
+
+	removed, err := s.removeObjectsWithPrefix(ctx, tenantID.String()+"/")
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.repo.PurgeTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.InfoContext(ctx, "tenant files purged",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int64("objects", removed),
+		zap.Int64("records", deleted),
+	)
+
+	return removed + deleted, nil
+}
+
+// removeObjectsWithPrefix deletes all objects under a prefix in batches and
+// returns how many were removed
+func (s *Service) removeObjectsWithPrefix(ctx context.Context, prefix string) (int64, error) {
+	// The lister goroutine owns listErr and sent until it closes objects;
+	// RemoveObjects drains objects before closing its error channel
+	var listErr error
+	var sent, failed int64
+	objects := make(chan minio.ObjectInfo)
+	go func() {
+		defer close(objects)
+		for object := range s.minioClient.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+			if object.Err != nil {
+				listErr = object.Err
+				return
+			}
+			sent++
+			objects <- object
+		}
+	}()
+
+	for removeErr := range s.minioClient.RemoveObjects(ctx, s.bucketName, objects, minio.RemoveObjectsOptions{}) {
+		failed++
+		s.logger.Error("failed to remove object",
+			zap.String("object_key", removeErr.ObjectName),
+			zap.Error(removeErr.Err),
+		)
+	}
+
+	if listErr != nil {
+		s.logger.Error("failed to list objects", zap.String("prefix", prefix), zap.Error(listErr))
+		return 0, errors.New(errors.ErrCodeInternal, "failed to list stored files")
+	}
+	if failed > 0 {
+		return 0, errors.New(errors.ErrCodeInternal, fmt.Sprintf("failed to remove %d stored files", failed))
+	}
+
+	return sent - failed, nil
+}
+
 // Helper functions
 
 func getTenantID(ctx context.Context) uuid.UUID {
@@ -365,3 +1465,103 @@ func getFileType(mimeType string) string {
 	}
 	return "application"
 }
+
+// relocatedObjectPrefix builds "<tenant>/<path segments>". Empty, "." and
+// ".." segments are dropped so the prefix always stays under the tenant.
+func relocatedObjectPrefix(tenantID uuid.UUID, path string) string {
+	parts := []string{tenantID.String()}
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		parts = append(parts, segment)
+	}
+	return strings.Join(parts, "/")
+}
+
+// relocatedObjectKey builds "<tenant>/<path segments>/<attempt>/<file name>".
+// The attempt ID keeps concurrent relocations to the same path from writing
+// the same object.
+func relocatedObjectKey(tenantID uuid.UUID, path string, attempt uuid.UUID, fileName string) string {
+	return relocatedObjectPrefix(tenantID, path) + "/" + attempt.String() + "/" + fileName
+}
+
+// isRelocatedObjectKey reports whether key was built by relocatedObjectKey
+// for the same path and file name, i.e. the file already lives there
+func isRelocatedObjectKey(key string, tenantID uuid.UUID, path, fileName string) bool {
+	rest, ok := strings.CutPrefix(key, relocatedObjectPrefix(tenantID, path)+"/")
+	if !ok {
+		return false
+	}
+	attempt, name, ok := strings.Cut(rest, "/")
+	if !ok || name != fileName {
+		return false
+	}
+	_, err := uuid.Parse(attempt)
+	return err == nil
+}
+
+func newThumbnailJob(fileID *uuid.UUID, total int) *models.ThumbnailJob {
+	return &models.ThumbnailJob{
+		ID:        uuid.New(),
+		FileID:    fileID,
+		Status:    models.ThumbnailJobQueued,
+		Total:     total,
+		CreatedAt: timeutil.Now(),
+		UpdatedAt: timeutil.Now(),
+	}
+}
+
+func thumbnailSupported(mimeType string) bool {
+	for _, supported := range thumbnailMimeTypes {
+		if strings.EqualFold(mimeType, supported) {
+			return true
+		}
+	}
+	return false
+}
+
+// scaleToFit downsizes an image so its longest side is at most maxSize,
+// averaging the source pixels covered by each destination pixel
+func scaleToFit(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxSize && srcH <= maxSize {
+		return src
+	}
+
+	dstW, dstH := maxSize, maxSize
+	if srcW > srcH {
+		dstH = max(1, srcH*maxSize/srcW)
+	} else {
+		dstW = max(1, srcW*maxSize/srcH)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/dstW)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
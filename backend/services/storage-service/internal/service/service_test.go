@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/services/storage-service/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestRelocatedObjectKeyIsUniquePerAttempt(t *testing.T) {
+	tenantID := uuid.New()
+
+	first := relocatedObjectKey(tenantID, "reports/2025", uuid.New(), "q1.pdf")
+	second := relocatedObjectKey(tenantID, "reports/2025", uuid.New(), "q1.pdf")
+	if first == second {
+		t.Fatalf("two attempts built the same key %q", first)
+	}
+
+	prefix := tenantID.String() + "/reports/2025/"
+	for _, key := range []string{first, second} {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "/q1.pdf") {
+			t.Errorf("key %q is not <tenant>/reports/2025/<attempt>/q1.pdf", key)
+		}
+	}
+}
+
+func TestRelocatedObjectKeyStaysUnderTenant(t *testing.T) {
+	tenantID := uuid.New()
+
+	key := relocatedObjectKey(tenantID, "../../other-tenant/./ x /", uuid.New(), "a.txt")
+	if !strings.HasPrefix(key, tenantID.String()+"/other-tenant/x/") {
+		t.Errorf("key %q escaped the tenant prefix or kept dot segments", key)
+	}
+}
+
+func TestIsRelocatedObjectKey(t *testing.T) {
+	tenantID := uuid.New()
+	key := relocatedObjectKey(tenantID, "reports", uuid.New(), "q1.pdf")
+
+	tests := []struct {
+		name     string
+		key      string
+		path     string
+		fileName string
+		want     bool
+	}{
+		{"same path and name", key, "reports", "q1.pdf", true},
+		{"same path with extra slashes", key, "/reports/", "q1.pdf", true},
+		{"other path", key, "archive", "q1.pdf", false},
+		{"parent path", key, "", "q1.pdf", false},
+		{"other name", key, "reports", "q2.pdf", false},
+		{"upload key", tenantID.String() + "/reports/q1.pdf", "reports", "q1.pdf", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRelocatedObjectKey(tt.key, tenantID, tt.path, tt.fileName); got != tt.want {
+				t.Errorf("isRelocatedObjectKey(%q, %q, %q) = %v, want %v", tt.key, tt.path, tt.fileName, got, tt.want)
+			}
+		})
+	}
+}
+
+// adjustCall is a reservation adjustment as seen by the fake quota service
+type adjustCall struct {
+	userID   string
+	tenantID string
+	reserved int64
+	actual   int64
+}
+
+// newSweepTestService returns a service whose quota client and object store
+// both point at one fake server. Quota calls pass through the same
+// middleware chain as quota-service; removed object paths are recorded.
+func newSweepTestService(t *testing.T) (*Service, *[]adjustCall, *[]string) {
+	t.Helper()
+	const secret = "test-secret"
+	log := &logger.Logger{Logger: zap.NewNop()}
+
+	var adjusts []adjustCall
+	var removed []string
+	var mu sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/quotas/usage/adjust", middleware.InternalAuth(secret, time.Minute, log)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Reserved int64 `json:"reserved"`
+				Actual   int64 `json:"actual"`
+			}
+			if err := response.DecodeJSON(r, &req); err != nil {
+				response.InvalidBody(w, err)
+				return
+			}
+			mu.Lock()
+			adjusts = append(adjusts, adjustCall{middleware.GetUserID(r.Context()), middleware.GetTenantID(r.Context()), req.Reserved, req.Actual})
+			mu.Unlock()
+			response.Success(w, nil)
+		}),
+	))
+	var quota http.Handler = mux
+	quota = middleware.ExtractAuthHeaders(log)(quota)
+	quota = middleware.VerifyInternalRequest(secret, time.Minute, log)(quota)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			removed = append(removed, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		quota.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	minioClient, err := newMinIOClient(config.MinIOConfig{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		AccessKeyID:     "test",
+		SecretAccessKey: "test-secret",
+		Region:          "us-east-1",
+		PathStyle:       true,
+	})
+	if err != nil {
+		t.Fatalf("newMinIOClient() error = %v", err)
+	}
+
+	return &Service{
+		minioClient: minioClient,
+		bucketName:  "documents",
+		quota:       client.NewQuotaClient(client.New("quota-service", server.URL, zap.NewNop()).WithSigningSecret(secret)),
+		logger:      zap.NewNop(),
+	}, &adjusts, &removed
+}
+
+func TestExpirePendingUpload(t *testing.T) {
+	tenantID := uuid.New()
+	pending := &models.PendingUpload{
+		FileID:    uuid.New(),
+		ObjectKey: tenantID.String() + "/reports/q1.pdf",
+		Reserved:  4096,
+	}
+
+	tests := []struct {
+		name        string
+		member      string
+		wantAdjusts []adjustCall
+		wantRemoved []string
+	}{
+		{
+			name:        "releases the reservation and removes the object",
+			member:      pendingUploadMember(tenantID, pending),
+			wantAdjusts: []adjustCall{{"service:storage-service", tenantID.String(), 4096, 0}},
+			wantRemoved: []string{"/documents/" + pending.ObjectKey},
+		},
+		{
+			name:   "invalid entry is dropped",
+			member: `{"tenant_id":"` + tenantID.String() + `","file_id":"not-a-uuid"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, adjusts, removed := newSweepTestService(t)
+
+			// The sweep runs outside any request
+			s.expirePendingUpload(context.Background(), tt.member)
+
+			if !reflect.DeepEqual(*adjusts, tt.wantAdjusts) {
+				t.Errorf("quota adjustments = %+v, want %+v", *adjusts, tt.wantAdjusts)
+			}
+			if !reflect.DeepEqual(*removed, tt.wantRemoved) {
+				t.Errorf("removed objects = %q, want %q", *removed, tt.wantRemoved)
+			}
+		})
+	}
+}
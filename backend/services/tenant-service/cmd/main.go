@@ -10,16 +10,25 @@ import (
 	"time"
 
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/config"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/metrics"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/handler"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/repository"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/service"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// defaultPort is used when SERVER_PORT is not set
+const defaultPort = 10001
+
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -27,11 +36,15 @@ func main() {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// Override port for tenant service
-	cfg.Server.Port = 10001
+	// Listen on defaultPort unless SERVER_PORT is set
+	if err := cfg.Server.UseDefaultPort(defaultPort); err != nil {
+		panic(fmt.Sprintf("invalid server config: %v", err))
+	}
 
 	// Initialize logger
-	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format)
+	log, err := logger.New(cfg.Environment, cfg.Logger.Level, cfg.Logger.Format,
+		logger.WithSampling(cfg.Logger.SamplingInitial, cfg.Logger.SamplingThereafter),
+	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize logger: %v", err))
 	}
@@ -42,40 +55,58 @@ func main() {
 		zap.String("environment", cfg.Environment),
 		zap.String("version", cfg.AppVersion),
 		zap.Int("port", cfg.Server.Port),
+		zap.Bool("log_sampling", log.SamplingEnabled()),
 	)
+	log.Info("effective configuration", zap.Any("config", cfg.Redacted()))
 
 	// Connect to database
-	db, err := database.NewPostgresDB(cfg.Database, log.Logger)
+	db, err := database.ConnectPostgresDB(cfg.Database, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer db.Close()
-
-	// Verify database health
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := db.HealthCheck(ctx); err != nil {
-		log.Fatal("database health check failed", zap.Error(err))
-	}
 	log.Info("database connection established")
 
 	// Connect to Redis cache
-	cacheClient, err := cache.NewRedisCache(cfg.Redis, log.Logger)
+	cacheClient, err := cache.ConnectRedisCache(cfg.Redis, cfg.Startup, log.Logger)
 	if err != nil {
 		log.Fatal("failed to connect to cache", zap.Error(err))
 	}
 	defer cacheClient.Close()
+	log.Info("cache connection established")
+
+	// Initialize internal service clients
+	documentClient := client.NewDocumentClient(client.New("document-service", cfg.Services.DocumentServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	shareClient := client.NewShareClient(client.New("share-service", cfg.Services.ShareServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
+	quotaClient := client.NewQuotaClient(client.New("quota-service", cfg.Services.QuotaServiceURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret))
 
-	// Verify cache health
-	if err := cacheClient.HealthCheck(ctx); err != nil {
-		log.Fatal("cache health check failed", zap.Error(err))
+	// Tenant deletion purges each service in this order; purges can be slow
+	purgeClient := func(name, baseURL string) *client.Client {
+		return client.New(name, baseURL, log.Logger).WithSigningSecret(cfg.Auth.InternalAPISecret).WithTimeout(5 * time.Minute)
 	}
-	log.Info("cache connection established")
+	purgeSteps := []service.PurgeStep{
+		{Name: "shares", Purger: client.NewShareClient(purgeClient("share-service", cfg.Services.ShareServiceURL))},
+		{Name: "documents", Purger: client.NewDocumentClient(purgeClient("document-service", cfg.Services.DocumentServiceURL))},
+		{Name: "files", Purger: client.NewStorageClient(purgeClient("storage-service", cfg.Services.StorageServiceURL))},
+		{Name: "rbac", Purger: client.NewRBACClient(purgeClient("rbac-service", cfg.Services.RBACServiceURL))},
+		{Name: "quotas", Purger: client.NewQuotaClient(purgeClient("quota-service", cfg.Services.QuotaServiceURL))},
+	}
+
+	// Background jobs; closed before the database so queued jobs can drain
+	jobs := worker.New("tenant-service", cfg.Worker, worker.NewStore(db, "tenant-service", log.Logger), log.Logger)
+	defer jobs.Close()
 
 	// Initialize layers
 	repo := repository.NewRepository(db, log.Logger)
-	svc := service.NewService(repo, cacheClient, log.Logger)
-	h := handler.NewHandler(svc, log.Logger)
+	svc := service.NewService(repo, cacheClient, documentClient, shareClient, quotaClient, purgeSteps, jobs, log.Logger)
+	readiness := health.NewChecker("tenant-service", cfg.Health, log.Logger).WithVersion(cfg.AppVersion)
+	h := handler.NewHandler(svc, readiness, log.Logger)
+
+	// Unknown request body fields
+	response.SetStrictJSON(cfg.Server.StrictJSON)
+
+	// Signed service-to-service requests only
+	internalAuth := middleware.InternalAuth(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)
 
 	// Setup HTTP router
 	mux := http.NewServeMux()
@@ -84,23 +115,79 @@ func main() {
 	mux.HandleFunc("GET /health", h.HealthCheck)
 	mux.HandleFunc("GET /health/ready", h.ReadyCheck)
 
+	// Effective configuration with secrets masked (internal use, operators)
+	mux.Handle("GET /api/config", internalAuth(http.HandlerFunc(config.NewHandler(cfg).Get)))
+
+	// Published metrics as JSON (internal use, scrapers)
+	mux.Handle("GET /api/metrics", internalAuth(metrics.Handler()))
+
+	// Tenant slug resolution (internal use, called by middleware.ResolveTenant)
+	mux.Handle("GET /api/tenants/resolve", internalAuth(http.HandlerFunc(h.ResolveSlug)))
+
+	// Legal hold (internal use, operators; tenant admins cannot change it)
+	mux.Handle("PUT /api/tenants/{id}/legal-hold", internalAuth(http.HandlerFunc(h.SetLegalHold)))
+
+	// Dead-letter jobs (internal use, admin view and requeue)
+	jobsHandler := worker.NewHandler(jobs)
+	mux.Handle("GET /api/jobs/dead-letter", internalAuth(http.HandlerFunc(jobsHandler.ListDeadLetters)))
+	mux.Handle("POST /api/jobs/dead-letter/{id}/requeue", internalAuth(http.HandlerFunc(jobsHandler.RequeueDeadLetter)))
+
 	// API endpoints (auth required)
 	mux.HandleFunc("POST /api/tenants", h.CreateTenant)
 	mux.HandleFunc("GET /api/tenants/me", h.GetUserTenants)
+	mux.HandleFunc("GET /api/tenants/me/memberships", h.GetUserMemberships)
+	mux.HandleFunc("GET /api/tenants/me/overview", h.GetUserOverview)
 	mux.HandleFunc("GET /api/tenants/{id}", h.GetTenant)
 	mux.HandleFunc("PUT /api/tenants/{id}", h.UpdateTenant)
+	mux.HandleFunc("DELETE /api/tenants/{id}", h.DeleteTenant)
+	mux.HandleFunc("POST /api/tenants/{id}/deletion-token", h.RequestDeletionToken)
+	mux.HandleFunc("GET /api/tenants/{id}/deletion", h.GetDeletionJob)
 	mux.HandleFunc("GET /api/tenants/{id}/users", h.GetTenantUsers)
+	mux.HandleFunc("GET /api/tenants/{id}/users/inactive", h.GetInactiveUsers)
 	mux.HandleFunc("POST /api/tenants/{id}/users/invite", h.InviteUser)
 	mux.HandleFunc("DELETE /api/tenants/{id}/users/{userId}", h.RemoveUser)
-	mux.HandleFunc("GET /api/tenants/{id}/invitations", h.GetPendingInvitations)
+	mux.Handle("GET /api/tenants/{id}/users/{userId}/membership", internalAuth(http.HandlerFunc(h.CheckMembership)))
+	mux.Handle("POST /api/tenants/{id}/users/{userId}/activity", internalAuth(http.HandlerFunc(h.RecordActivity)))
+	mux.HandleFunc("GET /api/tenants/{id}/invitations", h.ListInvitations)
+	mux.HandleFunc("POST /api/tenants/{id}/invitations/resend", h.ResendInvitations)
 
 	// Apply middleware chain
+	middleware.SetSlowRequestThreshold(cfg.Logger.SlowRequestThreshold)
 	var httpHandler http.Handler = mux
+	httpHandler = middleware.Versioning(middleware.APIVersion1)(httpHandler)
 	httpHandler = middleware.RequestID()(httpHandler)
+	httpHandler = middleware.CountAPICalls(cacheClient.IncrementAPICalls, log)(httpHandler)
+	httpHandler = middleware.RateLimit(cfg.RateLimit, cacheClient.RateLimitResolver(quotaClient.RateLimit, cfg.RateLimit.CacheTTL), cacheClient.IncrementRateWindow, log)(httpHandler)
+	httpHandler = middleware.ResolveTenant(cfg.Tenancy, func(ctx context.Context, slug string) (string, error) {
+		// Resolved locally; other services ask this one
+		resolution, err := svc.ResolveSlug(ctx, slug)
+		if err != nil {
+			return "", err
+		}
+		return resolution.TenantID.String(), nil
+	}, func(ctx context.Context, tenantID, userID string) (bool, error) {
+		id, err := uuid.Parse(tenantID)
+		if err != nil {
+			return false, err
+		}
+		membership, err := svc.CheckMembership(ctx, id, userID)
+		if err != nil {
+			return false, err
+		}
+		return membership.IsMember, nil
+	})(httpHandler)
 	httpHandler = middleware.ExtractAuthHeaders(log)(httpHandler)
+	httpHandler = middleware.VerifyInternalRequest(cfg.Auth.InternalAPISecret, cfg.Auth.InternalMaxSkew, log)(httpHandler)
+	// CORS runs ahead of auth so browser preflights are answered without credentials
+	httpHandler = middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:      cfg.CORS.AllowedOrigins,
+		CredentialedOrigins: cfg.CORS.CredentialedOrigins,
+		MaxAge:              cfg.CORS.MaxAge,
+	})(httpHandler)
 	httpHandler = middleware.Logging(log)(httpHandler)
 	httpHandler = middleware.Recovery(log)(httpHandler)
 	httpHandler = middleware.Timeout(30 * time.Second)(httpHandler)
+	httpHandler = middleware.MaxBodySize(cfg.Server.MaxBodySize)(httpHandler)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -1,10 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/health"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/response"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/validator"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/models"
@@ -14,23 +15,25 @@ import (
 
 // Handler handles HTTP requests for tenant operations
 type Handler struct {
-	service *service.Service
-	logger  *zap.Logger
+	service   *service.Service
+	readiness *health.Checker
+	logger    *zap.Logger
 }
 
 // NewHandler creates a new tenant handler
-func NewHandler(svc *service.Service, logger *zap.Logger) *Handler {
+func NewHandler(svc *service.Service, readiness *health.Checker, logger *zap.Logger) *Handler {
 	return &Handler{
-		service: svc,
-		logger:  logger,
+		service:   svc,
+		readiness: readiness,
+		logger:    logger,
 	}
 }
 
 // CreateTenant handles POST /api/tenants
 func (h *Handler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTenantRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -87,8 +90,8 @@ func (h *Handler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateTenantRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -106,6 +109,99 @@ func (h *Handler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "tenant updated successfully"})
 }
 
+// SetLegalHold handles PUT /api/tenants/{id}/legal-hold (internal use, operators)
+func (h *Handler) SetLegalHold(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	var req models.SetLegalHoldRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	if err := h.service.SetLegalHold(r.Context(), tenantID, *req.LegalHold); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, map[string]bool{"legal_hold": *req.LegalHold})
+}
+
+// RequestDeletionToken handles POST /api/tenants/:id/deletion-token
+func (h *Handler) RequestDeletionToken(w http.ResponseWriter, r *http.Request) {
+	tenantIDStr := r.PathValue("id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	token, err := h.service.RequestDeletionToken(r.Context(), tenantID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Created(w, token)
+}
+
+// DeleteTenant handles DELETE /api/tenants/:id
+func (h *Handler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	tenantIDStr := r.PathValue("id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	var req models.DeleteTenantRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	job, err := h.service.DeleteTenant(r.Context(), tenantID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, job)
+}
+
+// GetDeletionJob handles GET /api/tenants/:id/deletion
+func (h *Handler) GetDeletionJob(w http.ResponseWriter, r *http.Request) {
+	tenantIDStr := r.PathValue("id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	job, err := h.service.GetDeletionJob(r.Context(), tenantID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, job)
+}
+
 // GetTenantUsers handles GET /api/tenants/:id/users
 func (h *Handler) GetTenantUsers(w http.ResponseWriter, r *http.Request) {
 	tenantIDStr := r.PathValue("id")
@@ -124,6 +220,48 @@ func (h *Handler) GetTenantUsers(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, users)
 }
 
+// GetInactiveUsers handles GET /api/tenants/{id}/users/inactive?since=
+func (h *Handler) GetInactiveUsers(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	since, _, err := validator.ParseTimeParam("since", r.URL.Query().Get("since"))
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	users, err := h.service.GetInactiveUsers(r.Context(), tenantID, since)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, users)
+}
+
+// RecordActivity handles POST /api/tenants/{id}/users/{userId}/activity (internal heartbeat)
+func (h *Handler) RecordActivity(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	userID := r.PathValue("userId")
+	if userID == "" {
+		response.BadRequest(w, "user ID is required")
+		return
+	}
+
+	recorded := h.service.RecordActivity(r.Context(), tenantID, userID)
+
+	response.JSON(w, http.StatusAccepted, map[string]bool{"recorded": recorded})
+}
+
 // InviteUser handles POST /api/tenants/:id/users/invite
 func (h *Handler) InviteUser(w http.ResponseWriter, r *http.Request) {
 	tenantIDStr := r.PathValue("id")
@@ -134,8 +272,8 @@ func (h *Handler) InviteUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.InviteUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
 		return
 	}
 
@@ -169,7 +307,10 @@ func (h *Handler) RemoveUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.RemoveUser(r.Context(), tenantID, userID); err != nil {
+	// Optional successor for the departing user's documents
+	transferTo := r.URL.Query().Get("transfer_to")
+
+	if err := h.service.RemoveUser(r.Context(), tenantID, userID, transferTo); err != nil {
 		response.Error(w, err)
 		return
 	}
@@ -177,6 +318,47 @@ func (h *Handler) RemoveUser(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, map[string]string{"message": "user removed successfully"})
 }
 
+// CheckMembership handles GET /api/tenants/:id/users/:userId/membership
+func (h *Handler) CheckMembership(w http.ResponseWriter, r *http.Request) {
+	tenantIDStr := r.PathValue("id")
+	tenantID, err := uuid.Parse(tenantIDStr)
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	userID := r.PathValue("userId")
+	if userID == "" {
+		response.BadRequest(w, "user ID is required")
+		return
+	}
+
+	membership, err := h.service.CheckMembership(r.Context(), tenantID, userID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, membership)
+}
+
+// ResolveSlug handles GET /api/tenants/resolve?slug= (internal use)
+func (h *Handler) ResolveSlug(w http.ResponseWriter, r *http.Request) {
+	slug := r.URL.Query().Get("slug")
+	if slug == "" {
+		response.BadRequest(w, "slug is required")
+		return
+	}
+
+	resolution, err := h.service.ResolveSlug(r.Context(), slug)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, resolution)
+}
+
 // GetUserTenants handles GET /api/tenants/me
 func (h *Handler) GetUserTenants(w http.ResponseWriter, r *http.Request) {
 	tenants, err := h.service.GetUserTenants(r.Context())
@@ -188,37 +370,109 @@ func (h *Handler) GetUserTenants(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, tenants)
 }
 
-// GetPendingInvitations handles GET /api/tenants/:id/invitations
-func (h *Handler) GetPendingInvitations(w http.ResponseWriter, r *http.Request) {
-	tenantIDStr := r.PathValue("id")
-	tenantID, err := uuid.Parse(tenantIDStr)
+// GetUserMemberships handles GET /api/tenants/me/memberships
+func (h *Handler) GetUserMemberships(w http.ResponseWriter, r *http.Request) {
+	memberships, err := h.service.GetUserMemberships(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, memberships)
+}
+
+// GetUserOverview handles GET /api/tenants/me/overview
+func (h *Handler) GetUserOverview(w http.ResponseWriter, r *http.Request) {
+	overview, err := h.service.GetUserOverview(r.Context())
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, overview)
+}
+
+// ListInvitations handles GET /api/tenants/:id/invitations
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
 		response.BadRequest(w, "invalid tenant ID")
 		return
 	}
 
-	invitations, err := h.service.GetPendingInvitations(r.Context(), tenantID)
+	params := &models.ListInvitationsParams{
+		Status: r.URL.Query().Get("status"),
+	}
+
+	// Parse page and limit
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil {
+			params.Page = page
+		}
+	}
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = limit
+		}
+	}
+
+	// Validate params
+	if err := validator.Validate(params); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	invitations, total, err := h.service.ListInvitations(r.Context(), tenantID, params)
 	if err != nil {
 		response.Error(w, err)
 		return
 	}
 
-	response.Success(w, invitations)
+	response.Paginated(w, invitations, params.Page, params.Limit, total)
+}
+
+// ResendInvitations handles POST /api/tenants/:id/invitations/resend
+func (h *Handler) ResendInvitations(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.BadRequest(w, "invalid tenant ID")
+		return
+	}
+
+	var req models.ResendInvitationsRequest
+	if err := response.DecodeJSON(r, &req); err != nil {
+		response.InvalidBody(w, err)
+		return
+	}
+
+	// Validate request
+	if err := validator.Validate(&req); err != nil {
+		response.ValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.ResendInvitations(r.Context(), tenantID, &req)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	response.Success(w, result)
 }
 
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	response.Success(w, map[string]string{
-		"status":  "healthy",
-		"service": "tenant-service",
-	})
+	response.Success(w, h.readiness.Live())
 }
 
 // ReadyCheck handles GET /health/ready
 func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 	// TODO: Check database and cache connectivity
-	response.Success(w, map[string]string{
-		"status":  "ready",
-		"service": "tenant-service",
-	})
+	report := h.readiness.Check(r.Context())
+	if !report.Ready() {
+		response.JSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	response.Success(w, report)
 }
@@ -5,20 +5,29 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
 )
 
 // Tenant represents a tenant in the system
 type Tenant struct {
-	ID               uuid.UUID      `json:"id" db:"id"`
-	Name             string         `json:"name" db:"name"`
-	Slug             string         `json:"slug" db:"slug"`
-	Domain           sql.NullString `json:"domain,omitempty" db:"domain"`
-	SubscriptionPlan string         `json:"subscription_plan" db:"subscription_plan"`
-	IsActive         bool           `json:"is_active" db:"is_active"`
-	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
+	ID                 uuid.UUID      `json:"id" db:"id"`
+	Name               string         `json:"name" db:"name"`
+	Slug               string         `json:"slug" db:"slug"`
+	Domain             sql.NullString `json:"domain,omitempty" db:"domain"`
+	SubscriptionPlan   string         `json:"subscription_plan" db:"subscription_plan"`
+	IsActive           bool           `json:"is_active" db:"is_active"`
+	LegalHold          bool           `json:"legal_hold" db:"legal_hold"`                     // blocks tenant deletion
+	DepartedUserShares string         `json:"departed_user_shares" db:"departed_user_shares"` // reassign or revoke a removed member's shares
+	CreatedAt          timeutil.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          timeutil.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// What happens to a removed member's shares (Tenant.DepartedUserShares)
+const (
+	DepartedSharesReassign = "reassign" // to the successor, else the removing admin
+	DepartedSharesRevoke   = "revoke"   // links are deactivated
+)
+
 // TenantUser represents a user's membership in a tenant
 type TenantUser struct {
 	ID        uuid.UUID      `json:"id" db:"id"`
@@ -27,29 +36,51 @@ type TenantUser struct {
 	UserEmail string         `json:"user_email" db:"user_email"`
 	Role      string         `json:"role" db:"role"`
 	IsOwner   bool           `json:"is_owner" db:"is_owner"`
-	JoinedAt  time.Time      `json:"joined_at" db:"joined_at"`
+	JoinedAt  timeutil.Time  `json:"joined_at" db:"joined_at"`
 	InvitedBy sql.NullString `json:"invited_by,omitempty" db:"invited_by"`
+
+	LastActiveAt timeutil.NullTime `json:"last_active_at" db:"last_active_at"` // null until the first recorded activity
 }
 
-// TenantInvitation represents a pending invitation to join a tenant
+// Invitation statuses, derived from accepted_at and expires_at
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusExpired  = "expired"
+)
+
+// TenantInvitation represents an invitation to join a tenant
 type TenantInvitation struct {
-	ID         uuid.UUID      `json:"id" db:"id"`
-	TenantID   uuid.UUID      `json:"tenant_id" db:"tenant_id"`
-	Email      string         `json:"email" db:"email"`
-	Role       string         `json:"role" db:"role"`
-	InvitedBy  string         `json:"invited_by" db:"invited_by"`
-	Token      string         `json:"-" db:"token"` // Don't expose in API
-	ExpiresAt  time.Time      `json:"expires_at" db:"expires_at"`
-	AcceptedAt sql.NullTime   `json:"accepted_at,omitempty" db:"accepted_at"`
-	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	ID         uuid.UUID         `json:"id" db:"id"`
+	TenantID   uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	Email      string            `json:"email" db:"email"`
+	Role       string            `json:"role" db:"role"`
+	InvitedBy  string            `json:"invited_by" db:"invited_by"`
+	Token      string            `json:"-" db:"token"` // Don't expose in API
+	ExpiresAt  timeutil.Time     `json:"expires_at" db:"expires_at"`
+	AcceptedAt timeutil.NullTime `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt  timeutil.Time     `json:"created_at" db:"created_at"`
+	Status     string            `json:"status,omitempty" db:"-"`
+}
+
+// SetStatus derives the invitation's status as of now
+func (i *TenantInvitation) SetStatus(now time.Time) {
+	switch {
+	case i.AcceptedAt.Valid:
+		i.Status = InvitationStatusAccepted
+	case !i.ExpiresAt.After(now):
+		i.Status = InvitationStatusExpired
+	default:
+		i.Status = InvitationStatusPending
+	}
 }
 
 // TenantSettings represents tenant-specific settings
 type TenantSettings struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
-	Settings  string    `json:"settings" db:"settings"` // JSONB stored as string
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        uuid.UUID     `json:"id" db:"id"`
+	TenantID  uuid.UUID     `json:"tenant_id" db:"tenant_id"`
+	Settings  string        `json:"settings" db:"settings"` // JSONB stored as string
+	UpdatedAt timeutil.Time `json:"updated_at" db:"updated_at"`
 }
 
 // CreateTenantRequest represents the request to create a new tenant
@@ -61,9 +92,56 @@ type CreateTenantRequest struct {
 
 // UpdateTenantRequest represents the request to update a tenant
 type UpdateTenantRequest struct {
-	Name     string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
-	Domain   string `json:"domain,omitempty" validate:"omitempty,url"`
-	IsActive *bool  `json:"is_active,omitempty"`
+	Name               string  `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Domain             string  `json:"domain,omitempty" validate:"omitempty,url"`
+	IsActive           *bool   `json:"is_active,omitempty"`
+	DepartedUserShares *string `json:"departed_user_shares,omitempty" validate:"omitempty,oneof=reassign revoke"`
+}
+
+// SetLegalHoldRequest places or lifts a legal hold (internal use, operators)
+type SetLegalHoldRequest struct {
+	LegalHold *bool `json:"legal_hold" validate:"required"`
+}
+
+// DeletionTokenResponse carries the single-use token that confirms a tenant deletion
+type DeletionTokenResponse struct {
+	Token     string        `json:"token"`
+	ExpiresAt timeutil.Time `json:"expires_at"`
+}
+
+// DeleteTenantRequest represents a confirmed tenant deletion request
+type DeleteTenantRequest struct {
+	ConfirmationToken string `json:"confirmation_token" validate:"required"`
+}
+
+// Tenant deletion job and step statuses
+const (
+	DeletionStatusPending   = "pending"
+	DeletionStatusRunning   = "running"
+	DeletionStatusCompleted = "completed"
+	DeletionStatusFailed    = "failed"
+)
+
+// TenantDeletionJob tracks a tenant deletion across services. Steps run in
+// order; when a failed job is resumed, completed steps are skipped.
+type TenantDeletionJob struct {
+	TenantID    uuid.UUID            `json:"tenant_id"`
+	Status      string               `json:"status"`
+	Steps       []TenantDeletionStep `json:"steps"`
+	Error       string               `json:"error,omitempty"`
+	RequestedBy string               `json:"requested_by"`
+	Attempts    int                  `json:"attempts"`
+	StartedAt   timeutil.Time        `json:"started_at"`
+	UpdatedAt   timeutil.Time        `json:"updated_at"`
+	CompletedAt timeutil.NullTime    `json:"completed_at,omitempty"`
+}
+
+// TenantDeletionStep is one service's part of a tenant deletion
+type TenantDeletionStep struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Deleted int64  `json:"deleted"`
+	Error   string `json:"error,omitempty"`
 }
 
 // InviteUserRequest represents the request to invite a user to a tenant
@@ -72,6 +150,78 @@ type InviteUserRequest struct {
 	Role  string `json:"role" validate:"required,oneof=admin user guest"`
 }
 
+// ListInvitationsParams represents query parameters for listing a tenant's invitations
+type ListInvitationsParams struct {
+	Status string `json:"status,omitempty" form:"status" validate:"omitempty,oneof=pending accepted expired"`
+	Page   int    `json:"page" form:"page" validate:"omitempty,gte=1"`
+	Limit  int    `json:"limit" form:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Normalize sets default values for invitation list parameters
+func (p *ListInvitationsParams) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = 20
+	}
+	if p.Limit > 100 {
+		p.Limit = 100
+	}
+}
+
+// GetOffset returns the database offset
+func (p *ListInvitationsParams) GetOffset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// ResendInvitationsRequest selects the invitations to resend; empty resends
+// every pending invitation of the tenant
+type ResendInvitationsRequest struct {
+	InvitationIDs []uuid.UUID `json:"invitation_ids,omitempty" validate:"omitempty,max=100"`
+}
+
+// ResendInvitationsResponse reports a bulk resend. Selected invitations that
+// are no longer pending (accepted, expired or unknown) are listed as skipped.
+type ResendInvitationsResponse struct {
+	Resent  []TenantInvitation `json:"resent"`
+	Skipped []uuid.UUID        `json:"skipped"`
+}
+
+// MembershipResponse represents a tenant membership lookup result
+type MembershipResponse struct {
+	IsMember bool   `json:"is_member"`
+	Role     string `json:"role,omitempty"`
+}
+
+// TenantResolution maps a tenant slug to its ID
+type TenantResolution struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	Slug     string    `json:"slug"`
+}
+
+// TenantMembership is a tenant together with the user's membership in it
+type TenantMembership struct {
+	Tenant
+	Role     string        `json:"role"`
+	IsOwner  bool          `json:"is_owner"`
+	JoinedAt timeutil.Time `json:"joined_at"`
+}
+
+// ReceivedInvitation is a pending invitation addressed to the caller, with the
+// inviting tenant's name
+type ReceivedInvitation struct {
+	TenantInvitation
+	TenantName string `json:"tenant_name"`
+	TenantSlug string `json:"tenant_slug"`
+}
+
+// UserOverview lists the caller's tenants and the invitations waiting for them
+type UserOverview struct {
+	Memberships []TenantMembership   `json:"memberships"`
+	Invitations []ReceivedInvitation `json:"invitations"`
+}
+
 // TenantWithStats includes tenant with additional statistics
 type TenantWithStats struct {
 	Tenant
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/database"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/models"
@@ -46,7 +47,7 @@ func (r *Repository) CreateTenant(ctx context.Context, tenant *models.Tenant) er
 
 	if err != nil {
 		r.logger.Error("failed to create tenant", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to create tenant", err)
+		return database.WrapError("failed to create tenant", err)
 	}
 
 	return nil
@@ -55,7 +56,7 @@ func (r *Repository) CreateTenant(ctx context.Context, tenant *models.Tenant) er
 // GetTenantByID retrieves a tenant by ID
 func (r *Repository) GetTenantByID(ctx context.Context, id uuid.UUID) (*models.Tenant, error) {
 	query := `
-		SELECT id, name, slug, domain, subscription_plan, is_active, created_at, updated_at
+		SELECT id, name, slug, domain, subscription_plan, is_active, legal_hold, departed_user_shares, created_at, updated_at
 		FROM tenants
 		WHERE id = $1
 	`
@@ -68,6 +69,8 @@ func (r *Repository) GetTenantByID(ctx context.Context, id uuid.UUID) (*models.T
 		&tenant.Domain,
 		&tenant.SubscriptionPlan,
 		&tenant.IsActive,
+		&tenant.LegalHold,
+		&tenant.DepartedUserShares,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
 	)
@@ -77,7 +80,7 @@ func (r *Repository) GetTenantByID(ctx context.Context, id uuid.UUID) (*models.T
 	}
 	if err != nil {
 		r.logger.Error("failed to get tenant", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get tenant", err)
+		return nil, database.WrapError("failed to get tenant", err)
 	}
 
 	return &tenant, nil
@@ -86,7 +89,7 @@ func (r *Repository) GetTenantByID(ctx context.Context, id uuid.UUID) (*models.T
 // GetTenantBySlug retrieves a tenant by slug
 func (r *Repository) GetTenantBySlug(ctx context.Context, slug string) (*models.Tenant, error) {
 	query := `
-		SELECT id, name, slug, domain, subscription_plan, is_active, created_at, updated_at
+		SELECT id, name, slug, domain, subscription_plan, is_active, legal_hold, departed_user_shares, created_at, updated_at
 		FROM tenants
 		WHERE slug = $1
 	`
@@ -99,6 +102,8 @@ func (r *Repository) GetTenantBySlug(ctx context.Context, slug string) (*models.
 		&tenant.Domain,
 		&tenant.SubscriptionPlan,
 		&tenant.IsActive,
+		&tenant.LegalHold,
+		&tenant.DepartedUserShares,
 		&tenant.CreatedAt,
 		&tenant.UpdatedAt,
 	)
@@ -108,7 +113,7 @@ func (r *Repository) GetTenantBySlug(ctx context.Context, slug string) (*models.
 	}
 	if err != nil {
 		r.logger.Error("failed to get tenant by slug", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get tenant", err)
+		return nil, database.WrapError("failed to get tenant", err)
 	}
 
 	return &tenant, nil
@@ -121,26 +126,46 @@ func (r *Repository) UpdateTenant(ctx context.Context, id uuid.UUID, req *models
 		SET name = COALESCE(NULLIF($1, ''), name),
 		    domain = COALESCE(NULLIF($2, ''), domain),
 		    is_active = COALESCE($3, is_active),
-		    updated_at = $4
-		WHERE id = $5
+		    departed_user_shares = COALESCE($4, departed_user_shares),
+		    updated_at = $5
+		WHERE id = $6
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
 		req.Name,
 		req.Domain,
 		req.IsActive,
+		req.DepartedUserShares,
 		time.Now(),
 		id,
 	)
 
 	if err != nil {
 		r.logger.Error("failed to update tenant", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to update tenant", err)
+		return database.WrapError("failed to update tenant", err)
 	}
 
 	return nil
 }
 
+
+// SetLegalHold places or lifts the legal hold of a tenant
+func (r *Repository) SetLegalHold(ctx context.Context, id uuid.UUID, hold bool) error {
+	query := `UPDATE tenants SET legal_hold = $1, updated_at = $2 WHERE id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, hold, time.Now(), id)
+	if err != nil {
+		r.logger.Error("failed to set tenant legal hold", zap.Error(err))
+		return database.WrapError("failed to set legal hold", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.NotFoundf("tenant not found")
+	}
+
+	return nil
+}
 // AddTenantUser adds a user to a tenant
 func (r *Repository) AddTenantUser(ctx context.Context, tu *models.TenantUser) error {
 	query := `
@@ -161,7 +186,7 @@ func (r *Repository) AddTenantUser(ctx context.Context, tu *models.TenantUser) e
 
 	if err != nil {
 		r.logger.Error("failed to add tenant user", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to add user to tenant", err)
+		return database.WrapError("failed to add user to tenant", err)
 	}
 
 	return nil
@@ -170,7 +195,7 @@ func (r *Repository) AddTenantUser(ctx context.Context, tu *models.TenantUser) e
 // GetTenantUsers retrieves all users in a tenant
 func (r *Repository) GetTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]models.TenantUser, error) {
 	query := `
-		SELECT id, tenant_id, user_id, user_email, role, is_owner, joined_at, invited_by
+		SELECT id, tenant_id, user_id, user_email, role, is_owner, joined_at, invited_by, last_active_at
 		FROM tenant_users
 		WHERE tenant_id = $1
 		ORDER BY joined_at DESC
@@ -179,10 +204,53 @@ func (r *Repository) GetTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]
 	rows, err := r.db.QueryContext(ctx, query, tenantID)
 	if err != nil {
 		r.logger.Error("failed to get tenant users", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get tenant users", err)
+		return nil, database.WrapError("failed to get tenant users", err)
+	}
+	defer rows.Close()
+
+	return r.scanTenantUsers(rows), nil
+}
+
+// GetInactiveTenantUsers retrieves users with no recorded activity since the
+// given time, least recently active first
+func (r *Repository) GetInactiveTenantUsers(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.TenantUser, error) {
+	query := `
+		SELECT id, tenant_id, user_id, user_email, role, is_owner, joined_at, invited_by, last_active_at
+		FROM tenant_users
+		WHERE tenant_id = $1 AND (last_active_at IS NULL OR last_active_at < $2)
+		ORDER BY last_active_at ASC NULLS FIRST, joined_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, since)
+	if err != nil {
+		r.logger.Error("failed to get inactive tenant users", zap.Error(err))
+		return nil, database.WrapError("failed to get inactive tenant users", err)
 	}
 	defer rows.Close()
 
+	return r.scanTenantUsers(rows), nil
+}
+
+// UpdateLastActive records a user's latest activity in a tenant; older
+// timestamps never overwrite newer ones
+func (r *Repository) UpdateLastActive(ctx context.Context, tenantID uuid.UUID, userID string, at time.Time) error {
+	query := `
+		UPDATE tenant_users
+		SET last_active_at = $3
+		WHERE tenant_id = $1 AND user_id = $2
+		AND (last_active_at IS NULL OR last_active_at < $3)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, tenantID, userID, at); err != nil {
+		r.logger.Error("failed to update last active", zap.Error(err))
+		return database.WrapError("failed to update last active", err)
+	}
+
+	return nil
+}
+
+// scanTenantUsers reads tenant user rows, skipping rows that fail to scan
+func (r *Repository) scanTenantUsers(rows *sql.Rows) []models.TenantUser {
 	var users []models.TenantUser
 	for rows.Next() {
 		var user models.TenantUser
@@ -195,6 +263,7 @@ func (r *Repository) GetTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]
 			&user.IsOwner,
 			&user.JoinedAt,
 			&user.InvitedBy,
+			&user.LastActiveAt,
 		)
 		if err != nil {
 			r.logger.Error("failed to scan tenant user", zap.Error(err))
@@ -203,7 +272,7 @@ func (r *Repository) GetTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]
 		users = append(users, user)
 	}
 
-	return users, nil
+	return users
 }
 
 // RemoveTenantUser removes a user from a tenant
@@ -216,7 +285,7 @@ func (r *Repository) RemoveTenantUser(ctx context.Context, tenantID uuid.UUID, u
 	result, err := r.db.ExecContext(ctx, query, tenantID, userID)
 	if err != nil {
 		r.logger.Error("failed to remove tenant user", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to remove user from tenant", err)
+		return database.WrapError("failed to remove user from tenant", err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
@@ -247,29 +316,94 @@ func (r *Repository) CreateInvitation(ctx context.Context, inv *models.TenantInv
 
 	if err != nil {
 		r.logger.Error("failed to create invitation", zap.Error(err))
-		return errors.Wrap(errors.ErrCodeDatabase, "failed to create invitation", err)
+		return database.WrapError("failed to create invitation", err)
 	}
 
 	return nil
 }
 
-// GetPendingInvitations retrieves pending invitations for a tenant
-func (r *Repository) GetPendingInvitations(ctx context.Context, tenantID uuid.UUID) ([]models.TenantInvitation, error) {
+// invitationStatusConditions maps an invitation status to its SQL condition
+var invitationStatusConditions = map[string]string{
+	models.InvitationStatusPending:  "accepted_at IS NULL AND expires_at > NOW()",
+	models.InvitationStatusAccepted: "accepted_at IS NOT NULL",
+	models.InvitationStatusExpired:  "accepted_at IS NULL AND expires_at <= NOW()",
+}
+
+// ListInvitations retrieves a page of a tenant's invitations, newest first,
+// optionally filtered by status
+func (r *Repository) ListInvitations(ctx context.Context, tenantID uuid.UUID, params *models.ListInvitationsParams) ([]models.TenantInvitation, int64, error) {
+	where := "tenant_id = $1"
+	if condition, ok := invitationStatusConditions[params.Status]; ok {
+		where += " AND " + condition
+	}
+
+	var total int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tenant_invitations WHERE "+where, tenantID).Scan(&total)
+	if err != nil {
+		r.logger.Error("failed to count invitations", zap.Error(err))
+		return nil, 0, database.WrapError("failed to count invitations", err)
+	}
+
 	query := `
-		SELECT id, tenant_id, email, role, invited_by, expires_at, created_at
+		SELECT id, tenant_id, email, role, invited_by, expires_at, accepted_at, created_at
 		FROM tenant_invitations
-		WHERE tenant_id = $1 AND accepted_at IS NULL AND expires_at > NOW()
-		ORDER BY created_at DESC
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, tenantID)
+	rows, err := r.db.QueryContext(ctx, query, tenantID, params.Limit, params.GetOffset())
 	if err != nil {
-		r.logger.Error("failed to get pending invitations", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get invitations", err)
+		r.logger.Error("failed to list invitations", zap.Error(err))
+		return nil, 0, database.WrapError("failed to get invitations", err)
 	}
 	defer rows.Close()
 
-	var invitations []models.TenantInvitation
+	invitations, err := scanInvitations(rows)
+	if err != nil {
+		r.logger.Error("failed to read invitations", zap.Error(err))
+		return nil, 0, database.WrapError("failed to get invitations", err)
+	}
+
+	return invitations, total, nil
+}
+
+// RenewPendingInvitations pushes the expiry of a tenant's pending invitations
+// to expiresAt and returns them. With ids set only those invitations are
+// renewed; accepted or already expired ones are left alone.
+func (r *Repository) RenewPendingInvitations(ctx context.Context, tenantID uuid.UUID, ids []uuid.UUID, expiresAt time.Time) ([]models.TenantInvitation, error) {
+	query := `
+		UPDATE tenant_invitations
+		SET expires_at = $2
+		WHERE tenant_id = $1 AND ` + invitationStatusConditions[models.InvitationStatusPending]
+	args := []interface{}{tenantID, expiresAt}
+	if len(ids) > 0 {
+		query += " AND id = ANY($3)"
+		args = append(args, pq.Array(ids))
+	}
+	query += `
+		RETURNING id, tenant_id, email, role, invited_by, expires_at, accepted_at, created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to renew invitations", zap.Error(err))
+		return nil, database.WrapError("failed to renew invitations", err)
+	}
+	defer rows.Close()
+
+	invitations, err := scanInvitations(rows)
+	if err != nil {
+		r.logger.Error("failed to read renewed invitations", zap.Error(err))
+		return nil, database.WrapError("failed to renew invitations", err)
+	}
+
+	return invitations, nil
+}
+
+// scanInvitations reads invitation rows selected as id, tenant_id, email,
+// role, invited_by, expires_at, accepted_at, created_at
+func scanInvitations(rows *sql.Rows) ([]models.TenantInvitation, error) {
+	invitations := []models.TenantInvitation{}
 	for rows.Next() {
 		var inv models.TenantInvitation
 		err := rows.Scan(
@@ -279,8 +413,51 @@ func (r *Repository) GetPendingInvitations(ctx context.Context, tenantID uuid.UU
 			&inv.Role,
 			&inv.InvitedBy,
 			&inv.ExpiresAt,
+			&inv.AcceptedAt,
 			&inv.CreatedAt,
 		)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, rows.Err()
+}
+
+// GetInvitationsByEmail retrieves the pending, unexpired invitations sent to an
+// email address across all active tenants, newest first
+func (r *Repository) GetInvitationsByEmail(ctx context.Context, email string) ([]models.ReceivedInvitation, error) {
+	query := `
+		SELECT i.id, i.tenant_id, i.email, i.role, i.invited_by, i.expires_at, i.created_at,
+			t.name, t.slug
+		FROM tenant_invitations i
+		INNER JOIN tenants t ON t.id = i.tenant_id
+		WHERE i.email = LOWER($1) AND i.accepted_at IS NULL AND i.expires_at > NOW() AND t.is_active = true
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, email)
+	if err != nil {
+		r.logger.Error("failed to get invitations by email", zap.Error(err))
+		return nil, database.WrapError("failed to get invitations", err)
+	}
+	defer rows.Close()
+
+	var invitations []models.ReceivedInvitation
+	for rows.Next() {
+		var inv models.ReceivedInvitation
+		err := rows.Scan(
+			&inv.ID,
+			&inv.TenantID,
+			&inv.Email,
+			&inv.Role,
+			&inv.InvitedBy,
+			&inv.ExpiresAt,
+			&inv.CreatedAt,
+			&inv.TenantName,
+			&inv.TenantSlug,
+		)
 		if err != nil {
 			r.logger.Error("failed to scan invitation", zap.Error(err))
 			continue
@@ -291,10 +468,12 @@ func (r *Repository) GetPendingInvitations(ctx context.Context, tenantID uuid.UU
 	return invitations, nil
 }
 
-// GetUserTenants retrieves all tenants a user belongs to
-func (r *Repository) GetUserTenants(ctx context.Context, userID string) ([]models.Tenant, error) {
+// GetUserTenants retrieves all tenants a user belongs to along with the
+// user's role in each, most recently joined first
+func (r *Repository) GetUserTenants(ctx context.Context, userID string) ([]models.TenantMembership, error) {
 	query := `
-		SELECT t.id, t.name, t.slug, t.domain, t.subscription_plan, t.is_active, t.created_at, t.updated_at
+		SELECT t.id, t.name, t.slug, t.domain, t.subscription_plan, t.is_active, t.legal_hold, t.departed_user_shares, t.created_at, t.updated_at,
+			tu.role, tu.is_owner, tu.joined_at
 		FROM tenants t
 		INNER JOIN tenant_users tu ON t.id = tu.tenant_id
 		WHERE tu.user_id = $1 AND t.is_active = true
@@ -304,31 +483,36 @@ func (r *Repository) GetUserTenants(ctx context.Context, userID string) ([]model
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		r.logger.Error("failed to get user tenants", zap.Error(err))
-		return nil, errors.Wrap(errors.ErrCodeDatabase, "failed to get user tenants", err)
+		return nil, database.WrapError("failed to get user tenants", err)
 	}
 	defer rows.Close()
 
-	var tenants []models.Tenant
+	var memberships []models.TenantMembership
 	for rows.Next() {
-		var tenant models.Tenant
+		var membership models.TenantMembership
 		err := rows.Scan(
-			&tenant.ID,
-			&tenant.Name,
-			&tenant.Slug,
-			&tenant.Domain,
-			&tenant.SubscriptionPlan,
-			&tenant.IsActive,
-			&tenant.CreatedAt,
-			&tenant.UpdatedAt,
+			&membership.ID,
+			&membership.Name,
+			&membership.Slug,
+			&membership.Domain,
+			&membership.SubscriptionPlan,
+			&membership.IsActive,
+			&membership.LegalHold,
+			&membership.DepartedUserShares,
+			&membership.CreatedAt,
+			&membership.UpdatedAt,
+			&membership.Role,
+			&membership.IsOwner,
+			&membership.JoinedAt,
 		)
 		if err != nil {
 			r.logger.Error("failed to scan tenant", zap.Error(err))
 			continue
 		}
-		tenants = append(tenants, tenant)
+		memberships = append(memberships, membership)
 	}
 
-	return tenants, nil
+	return memberships, nil
 }
 
 // IsUserInTenant checks if a user belongs to a tenant
@@ -338,7 +522,7 @@ func (r *Repository) IsUserInTenant(ctx context.Context, tenantID uuid.UUID, use
 	var exists bool
 	err := r.db.QueryRowContext(ctx, query, tenantID, userID).Scan(&exists)
 	if err != nil {
-		return false, errors.Wrap(errors.ErrCodeDatabase, "failed to check user membership", err)
+		return false, database.WrapError("failed to check user membership", err)
 	}
 
 	return exists, nil
@@ -354,8 +538,51 @@ func (r *Repository) GetUserRole(ctx context.Context, tenantID uuid.UUID, userID
 		return "", errors.NotFoundf("user not found in tenant")
 	}
 	if err != nil {
-		return "", errors.Wrap(errors.ErrCodeDatabase, "failed to get user role", err)
+		return "", database.WrapError("failed to get user role", err)
 	}
 
 	return role, nil
 }
+
+// IsTenantOwner reports whether a user owns a tenant
+func (r *Repository) IsTenantOwner(ctx context.Context, tenantID uuid.UUID, userID string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM tenant_users WHERE tenant_id = $1 AND user_id = $2 AND is_owner = true)`
+
+	var owner bool
+	err := r.db.QueryRowContext(ctx, query, tenantID, userID).Scan(&owner)
+	if err != nil {
+		return false, database.WrapError("failed to check tenant ownership", err)
+	}
+
+	return owner, nil
+}
+
+// DeleteTenant removes a tenant with its invitations and memberships in a
+// single transaction and returns the number of rows removed. Deleting a
+// tenant that no longer exists is not an error.
+func (r *Repository) DeleteTenant(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	statements := []string{
+		`DELETE FROM tenant_invitations WHERE tenant_id = $1`,
+		`DELETE FROM tenant_users WHERE tenant_id = $1`,
+		`DELETE FROM tenants WHERE id = $1`,
+	}
+
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, statement := range statements {
+			result, err := tx.ExecContext(ctx, statement, tenantID)
+			if err != nil {
+				r.logger.Error("failed to delete tenant", zap.Error(err))
+				return database.WrapError("failed to delete tenant", err)
+			}
+			rows, _ := result.RowsAffected()
+			deleted += rows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
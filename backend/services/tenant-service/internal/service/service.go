@@ -3,15 +3,21 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/cache"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/client"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/errors"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/logger"
 	"github.com/SidahmedSeg/document-manager/backend/pkg/middleware"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/timeutil"
+	"github.com/SidahmedSeg/document-manager/backend/pkg/worker"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/models"
 	"github.com/SidahmedSeg/document-manager/backend/services/tenant-service/internal/repository"
 	"go.uber.org/zap"
@@ -21,22 +27,71 @@ const (
 	invitationTokenLength = 32
 	invitationExpiry      = 7 * 24 * time.Hour // 7 days
 	tenantCacheTTL        = 1 * time.Hour
+
+	// membershipCacheTTL bounds how long a cached membership (or its absence)
+	// is trusted; adds and removals invalidate the entry immediately
+	membershipCacheTTL = 2 * time.Minute
+
+	// activityThrottle is the minimum interval between last_active_at writes per member
+	activityThrottle = 5 * time.Minute
+	// defaultInactivePeriod applies when the inactive listing has no since parameter
+	defaultInactivePeriod = 30 * 24 * time.Hour
+
+	deletionTokenLength = 32
+	deletionTokenTTL    = 10 * time.Minute
+	deletionJobTTL      = 7 * 24 * time.Hour
+	deletionJobTimeout  = 30 * time.Minute
+
+	// tenantRecordsStep is the final deletion step, run locally
+	tenantRecordsStep = "tenant"
+
+	// activityJobType is the worker job that writes a member's last_active_at
+	activityJobType = "tenant.activity"
+	// activityWriteTimeout bounds a single last_active_at write
+	activityWriteTimeout = 5 * time.Second
 )
 
+// DataPurger deletes one service's data for the tenant in ctx. Purges must be
+// idempotent so a failed deletion can be resumed.
+type DataPurger interface {
+	PurgeTenant(ctx context.Context) (int64, error)
+}
+
+// PurgeStep names a service whose tenant data is removed during tenant deletion
+type PurgeStep struct {
+	Name   string
+	Purger DataPurger
+}
+
 // Service handles tenant business logic
 type Service struct {
-	repo   *repository.Repository
-	cache  *cache.Cache
-	logger *zap.Logger
+	repo       *repository.Repository
+	cache      *cache.Cache
+	documents  *client.DocumentClient
+	shares     *client.ShareClient
+	quotas     *client.QuotaClient
+	purgeSteps []PurgeStep
+	jobs       *worker.Pool
+	logger     *zap.Logger
 }
 
-// NewService creates a new tenant service
-func NewService(repo *repository.Repository, cache *cache.Cache, logger *zap.Logger) *Service {
-	return &Service{
-		repo:   repo,
-		cache:  cache,
-		logger: logger,
+// NewService creates a new tenant service and registers its background jobs
+// on the pool. purgeSteps run in order when a tenant is deleted, before the
+// tenant's own records are removed.
+func NewService(repo *repository.Repository, cache *cache.Cache, documents *client.DocumentClient, shares *client.ShareClient, quotas *client.QuotaClient, purgeSteps []PurgeStep, jobs *worker.Pool, logger *zap.Logger) *Service {
+	s := &Service{
+		repo:       repo,
+		cache:      cache,
+		documents:  documents,
+		shares:     shares,
+		quotas:     quotas,
+		purgeSteps: purgeSteps,
+		jobs:       jobs,
+		logger:     logger,
 	}
+	jobs.Register(activityJobType, s.handleActivityJob)
+
+	return s
 }
 
 // CreateTenant creates a new tenant
@@ -61,8 +116,8 @@ func (s *Service) CreateTenant(ctx context.Context, req *models.CreateTenantRequ
 		Slug:             strings.ToLower(req.Slug),
 		SubscriptionPlan: "free", // Default to free plan
 		IsActive:         true,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
+		CreatedAt:        timeutil.Now(),
+		UpdatedAt:        timeutil.Now(),
 	}
 
 	if req.Domain != "" {
@@ -83,13 +138,26 @@ func (s *Service) CreateTenant(ctx context.Context, req *models.CreateTenantRequ
 		UserEmail: userEmail,
 		Role:      "admin",
 		IsOwner:   true,
-		JoinedAt:  time.Now(),
+		JoinedAt:  timeutil.Now(),
 	}
 
 	if err := s.repo.AddTenantUser(ctx, tenantUser); err != nil {
 		s.logger.Error("failed to add tenant owner", zap.Error(err))
 		return nil, err
 	}
+	s.invalidateMembership(ctx, tenant.ID, userID)
+
+	// Default quota for the plan. A failure leaves the tenant without limits
+	// until the quota is provisioned again, which is idempotent, so it does
+	// not fail the creation.
+	quotaCtx := middleware.WithTenantID(ctx, tenant.ID.String())
+	if err := s.quotas.ProvisionQuota(quotaCtx, tenant.SubscriptionPlan); err != nil {
+		logger.ErrorContext(ctx, "failed to provision default quota; tenant has no quota until reprovisioned",
+			zap.String("tenant_id", tenant.ID.String()),
+			zap.String("plan", tenant.SubscriptionPlan),
+			zap.Error(err),
+		)
+	}
 
 	// Cache tenant
 	cacheKey := cache.BuildKey("tenant", tenant.ID.String())
@@ -109,7 +177,7 @@ func (s *Service) GetTenant(ctx context.Context, tenantID uuid.UUID) (*models.Te
 	userID := middleware.GetUserID(ctx)
 
 	// Check if user has access to this tenant
-	hasAccess, err := s.repo.IsUserInTenant(ctx, tenantID, userID)
+	hasAccess, err := s.isUserInTenant(ctx, tenantID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +185,44 @@ func (s *Service) GetTenant(ctx context.Context, tenantID uuid.UUID) (*models.Te
 		return nil, errors.ErrForbidden
 	}
 
+	return s.getTenantCached(ctx, tenantID)
+}
+
+// ResolveSlug maps a tenant slug to its ID for per-request tenant resolution
+// (internal use). Slugs never change, so the mapping is cached on its own while
+// the active flag is read through the tenant cache, which updates invalidate.
+func (s *Service) ResolveSlug(ctx context.Context, slug string) (*models.TenantResolution, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	slugKey := cache.GlobalKey("tenant_slug", slug)
+
+	var tenant *models.Tenant
+	if cachedID, err := s.cache.GetString(ctx, slugKey); err == nil {
+		if tenantID, err := uuid.Parse(cachedID); err == nil {
+			if tenant, err = s.getTenantCached(ctx, tenantID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if tenant == nil {
+		var err error
+		tenant, err = s.repo.GetTenantBySlug(ctx, slug)
+		if err != nil {
+			return nil, err
+		}
+		_ = s.cache.SetString(ctx, slugKey, tenant.ID.String(), tenantCacheTTL)
+		_ = s.cache.Set(ctx, cache.BuildKey("tenant", tenant.ID.String()), tenant, tenantCacheTTL)
+	}
+
+	if !tenant.IsActive {
+		return nil, errors.Forbiddenf("tenant is suspended")
+	}
+
+	return &models.TenantResolution{TenantID: tenant.ID, Slug: tenant.Slug}, nil
+}
+
+// getTenantCached reads a tenant through the cache
+func (s *Service) getTenantCached(ctx context.Context, tenantID uuid.UUID) (*models.Tenant, error) {
 	// Try cache first
 	cacheKey := cache.BuildKey("tenant", tenantID.String())
 	var tenant models.Tenant
@@ -141,7 +247,7 @@ func (s *Service) UpdateTenant(ctx context.Context, tenantID uuid.UUID, req *mod
 	userID := middleware.GetUserID(ctx)
 
 	// Check if user is admin or owner
-	role, err := s.repo.GetUserRole(ctx, tenantID, userID)
+	role, err := s.getUserRole(ctx, tenantID, userID)
 	if err != nil {
 		return err
 	}
@@ -163,12 +269,30 @@ func (s *Service) UpdateTenant(ctx context.Context, tenantID uuid.UUID, req *mod
 	return nil
 }
 
+// SetLegalHold places or lifts a tenant's legal hold. It is reachable only
+// through a signed internal request, so tenant admins cannot lift a hold
+// and then delete the tenant.
+func (s *Service) SetLegalHold(ctx context.Context, tenantID uuid.UUID, hold bool) error {
+	if err := s.repo.SetLegalHold(ctx, tenantID, hold); err != nil {
+		return err
+	}
+
+	_ = s.cache.Delete(ctx, cache.BuildKey("tenant", tenantID.String()))
+
+	logger.InfoContext(ctx, "tenant legal hold changed",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Bool("legal_hold", hold),
+	)
+
+	return nil
+}
+
 // GetTenantUsers retrieves all users in a tenant
 func (s *Service) GetTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]models.TenantUser, error) {
 	userID := middleware.GetUserID(ctx)
 
 	// Check if user has access to this tenant
-	hasAccess, err := s.repo.IsUserInTenant(ctx, tenantID, userID)
+	hasAccess, err := s.isUserInTenant(ctx, tenantID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -184,12 +308,88 @@ func (s *Service) GetTenantUsers(ctx context.Context, tenantID uuid.UUID) ([]mod
 	return users, nil
 }
 
+// GetInactiveUsers retrieves members with no activity since the given time;
+// a zero time means the default inactivity period
+func (s *Service) GetInactiveUsers(ctx context.Context, tenantID uuid.UUID, since time.Time) ([]models.TenantUser, error) {
+	userID := middleware.GetUserID(ctx)
+
+	// Check if user has access to this tenant
+	hasAccess, err := s.isUserInTenant(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasAccess {
+		return nil, errors.ErrForbidden
+	}
+
+	if since.IsZero() {
+		since = time.Now().Add(-defaultInactivePeriod)
+	}
+
+	users, err := s.repo.GetInactiveTenantUsers(ctx, tenantID, since)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []models.TenantUser{}
+	}
+
+	return users, nil
+}
+
+// RecordActivity marks a member as active (internal use). Writes are throttled
+// per member through the cache and run on the worker pool so callers never
+// wait on the database. It reports whether a write was scheduled.
+func (s *Service) RecordActivity(ctx context.Context, tenantID uuid.UUID, userID string) bool {
+	throttleKey := cache.TenantKey(tenantID.String(), "user_activity", userID)
+	first, err := s.cache.SetNX(ctx, throttleKey, "1", activityThrottle)
+	if err == nil && !first {
+		return false
+	}
+
+	err = s.jobs.Submit(ctx, activityJobType, activityJob{
+		TenantID: tenantID,
+		UserID:   userID,
+		At:       time.Now(),
+	})
+	if err != nil {
+		logger.WarnContext(ctx, "failed to queue member activity",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	return true
+}
+
+// activityJob is the queued last_active_at write of RecordActivity
+type activityJob struct {
+	TenantID uuid.UUID `json:"tenant_id"`
+	UserID   string    `json:"user_id"`
+	At       time.Time `json:"at"`
+}
+
+// handleActivityJob writes a queued member activity
+func (s *Service) handleActivityJob(ctx context.Context, data json.RawMessage) error {
+	var job activityJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return fmt.Errorf("invalid activity job payload: %w", err)
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, activityWriteTimeout)
+	defer cancel()
+
+	return s.repo.UpdateLastActive(writeCtx, job.TenantID, job.UserID, job.At)
+}
+
 // InviteUser invites a user to join a tenant
 func (s *Service) InviteUser(ctx context.Context, tenantID uuid.UUID, req *models.InviteUserRequest) (*models.TenantInvitation, error) {
 	userID := middleware.GetUserID(ctx)
 
 	// Check if inviter is admin
-	role, err := s.repo.GetUserRole(ctx, tenantID, userID)
+	role, err := s.getUserRole(ctx, tenantID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -222,8 +422,8 @@ func (s *Service) InviteUser(ctx context.Context, tenantID uuid.UUID, req *model
 		Role:      req.Role,
 		InvitedBy: userID,
 		Token:     token,
-		ExpiresAt: time.Now().Add(invitationExpiry),
-		CreatedAt: time.Now(),
+		ExpiresAt: timeutil.From(time.Now().Add(invitationExpiry)),
+		CreatedAt: timeutil.Now(),
 	}
 
 	if err := s.repo.CreateInvitation(ctx, invitation); err != nil {
@@ -241,12 +441,13 @@ func (s *Service) InviteUser(ctx context.Context, tenantID uuid.UUID, req *model
 	return invitation, nil
 }
 
-// RemoveUser removes a user from a tenant
-func (s *Service) RemoveUser(ctx context.Context, tenantID uuid.UUID, targetUserID string) error {
+// RemoveUser removes a user from a tenant. When transferTo is set, the departing
+// user's documents are reassigned to that user before the membership is removed.
+func (s *Service) RemoveUser(ctx context.Context, tenantID uuid.UUID, targetUserID, transferTo string) error {
 	userID := middleware.GetUserID(ctx)
 
 	// Check if remover is admin
-	role, err := s.repo.GetUserRole(ctx, tenantID, userID)
+	role, err := s.getUserRole(ctx, tenantID, userID)
 	if err != nil {
 		return err
 	}
@@ -259,9 +460,34 @@ func (s *Service) RemoveUser(ctx context.Context, tenantID uuid.UUID, targetUser
 		return errors.Forbiddenf("cannot remove yourself from the tenant")
 	}
 
+	// Transfer documents to the successor before removal
+	if transferTo != "" {
+		if transferTo == targetUserID {
+			return errors.Validationf("cannot transfer documents to the user being removed")
+		}
+
+		reassigned, err := s.documents.ReassignDocuments(middleware.WithTenantID(ctx, tenantID.String()), targetUserID, transferTo)
+		if err != nil {
+			return err
+		}
+
+		logger.InfoContext(ctx, "documents transferred from departing user",
+			zap.String("tenant_id", tenantID.String()),
+			zap.String("from_user_id", targetUserID),
+			zap.String("to_user_id", transferTo),
+			zap.Int64("count", reassigned),
+		)
+	}
+
+	if err := s.transferShares(ctx, tenantID, targetUserID, transferTo); err != nil {
+		return err
+	}
+
 	if err := s.repo.RemoveTenantUser(ctx, tenantID, targetUserID); err != nil {
 		return err
 	}
+	// Revoke access now rather than when the cached membership expires
+	s.invalidateMembership(ctx, tenantID, targetUserID)
 
 	logger.InfoContext(ctx, "user removed from tenant",
 		zap.String("tenant_id", tenantID.String()),
@@ -271,37 +497,503 @@ func (s *Service) RemoveUser(ctx context.Context, tenantID uuid.UUID, targetUser
 	return nil
 }
 
+// transferShares applies the tenant's departed member policy to the shares
+// the removed user created: they are reassigned to the successor (else the
+// removing admin) or revoked
+func (s *Service) transferShares(ctx context.Context, tenantID uuid.UUID, targetUserID, transferTo string) error {
+	tenant, err := s.repo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	action := models.DepartedSharesReassign
+	successor := ""
+	if tenant.DepartedUserShares == models.DepartedSharesRevoke {
+		action = models.DepartedSharesRevoke
+	} else {
+		successor = transferTo
+		if successor == "" {
+			successor = middleware.GetUserID(ctx)
+		}
+	}
+
+	count, err := s.shares.ReassignOwner(middleware.WithTenantID(ctx, tenantID.String()), targetUserID, successor, action)
+	if err != nil {
+		return err
+	}
+
+	logger.InfoContext(ctx, "shares of departing user transferred",
+		zap.String("tenant_id", tenantID.String()),
+		zap.String("from_user_id", targetUserID),
+		zap.String("to_user_id", successor),
+		zap.String("action", action),
+		zap.Int64("count", count),
+	)
+
+	return nil
+}
+
+// CheckMembership reports whether a user belongs to a tenant (internal use)
+func (s *Service) CheckMembership(ctx context.Context, tenantID uuid.UUID, userID string) (*models.MembershipResponse, error) {
+	member, err := s.getMembership(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MembershipResponse{IsMember: member.IsMember, Role: member.Role}, nil
+}
+
 // GetUserTenants retrieves all tenants a user belongs to
 func (s *Service) GetUserTenants(ctx context.Context) ([]models.Tenant, error) {
 	userID := middleware.GetUserID(ctx)
 
-	tenants, err := s.repo.GetUserTenants(ctx, userID)
+	memberships, err := s.repo.GetUserTenants(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	tenants := make([]models.Tenant, len(memberships))
+	for i, membership := range memberships {
+		tenants[i] = membership.Tenant
+	}
+
 	return tenants, nil
 }
 
-// GetPendingInvitations retrieves pending invitations for a tenant
-func (s *Service) GetPendingInvitations(ctx context.Context, tenantID uuid.UUID) ([]models.TenantInvitation, error) {
+// GetUserMemberships retrieves all tenants a user belongs to with the user's role in each
+func (s *Service) GetUserMemberships(ctx context.Context) ([]models.TenantMembership, error) {
 	userID := middleware.GetUserID(ctx)
 
-	// Check if user is admin
-	role, err := s.repo.GetUserRole(ctx, tenantID, userID)
+	memberships, err := s.repo.GetUserTenants(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+
+	return memberships, nil
+}
+
+// GetUserOverview returns the caller's memberships together with the pending
+// invitations addressed to their email. Invitations to tenants the caller
+// already belongs to are left out.
+func (s *Service) GetUserOverview(ctx context.Context) (*models.UserOverview, error) {
+	userID := middleware.GetUserID(ctx)
+	if userID == "" {
+		return nil, errors.ErrUnauthorized
+	}
+
+	memberships, err := s.repo.GetUserTenants(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	overview := &models.UserOverview{
+		Memberships: make([]models.TenantMembership, 0, len(memberships)),
+		Invitations: []models.ReceivedInvitation{},
+	}
+	overview.Memberships = append(overview.Memberships, memberships...)
+
+	email := middleware.GetUserEmail(ctx)
+	if email == "" {
+		return overview, nil
+	}
+
+	invitations, err := s.repo.GetInvitationsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	joined := make(map[uuid.UUID]bool, len(memberships))
+	for _, m := range memberships {
+		joined[m.ID] = true
+	}
+	for _, inv := range invitations {
+		if !joined[inv.TenantID] {
+			overview.Invitations = append(overview.Invitations, inv)
+		}
+	}
+
+	return overview, nil
+}
+
+// ListInvitations retrieves a page of a tenant's invitations, optionally
+// filtered by status (admin only)
+func (s *Service) ListInvitations(ctx context.Context, tenantID uuid.UUID, params *models.ListInvitationsParams) ([]models.TenantInvitation, int64, error) {
+	if err := s.requireAdmin(ctx, tenantID, "only admins can view invitations"); err != nil {
+		return nil, 0, err
+	}
+
+	params.Normalize()
+
+	invitations, total, err := s.repo.ListInvitations(ctx, tenantID, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].SetStatus(now)
+	}
+
+	return invitations, total, nil
+}
+
+// ResendInvitations renews the expiry of a tenant's pending invitations, or
+// of the selected ones, and sends them again (admin only). Accepted and
+// expired invitations are never resent.
+func (s *Service) ResendInvitations(ctx context.Context, tenantID uuid.UUID, req *models.ResendInvitationsRequest) (*models.ResendInvitationsResponse, error) {
+	if err := s.requireAdmin(ctx, tenantID, "only admins can resend invitations"); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	resent, err := s.repo.RenewPendingInvitations(ctx, tenantID, req.InvitationIDs, now.Add(invitationExpiry))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ResendInvitationsResponse{
+		Resent:  resent,
+		Skipped: []uuid.UUID{},
+	}
+
+	renewed := make(map[uuid.UUID]bool, len(resent))
+	for i := range resent {
+		resent[i].SetStatus(now)
+		renewed[resent[i].ID] = true
+	}
+	for _, id := range req.InvitationIDs {
+		if !renewed[id] {
+			result.Skipped = append(result.Skipped, id)
+		}
+	}
+
+	logger.InfoContext(ctx, "invitations resent",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int("resent", len(result.Resent)),
+		zap.Int("skipped", len(result.Skipped)),
+	)
+
+	// TODO: Send invitation emails via notification service
+
+	return result, nil
+}
+
+// requireAdmin rejects callers that are not admins of the tenant with message
+func (s *Service) requireAdmin(ctx context.Context, tenantID uuid.UUID, message string) error {
+	role, err := s.getUserRole(ctx, tenantID, middleware.GetUserID(ctx))
+	if err != nil {
+		return err
+	}
 	if role != "admin" {
-		return nil, errors.Forbiddenf("only admins can view invitations")
+		return errors.Forbiddenf("%s", message)
+	}
+	return nil
+}
+
+// RequestDeletionToken issues the short-lived, single-use token that
+// DeleteTenant requires (owner only)
+func (s *Service) RequestDeletionToken(ctx context.Context, tenantID uuid.UUID) (*models.DeletionTokenResponse, error) {
+	if _, err := s.deletableTenant(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken(deletionTokenLength)
+	if err != nil {
+		return nil, errors.Internalf(err, "failed to generate deletion token")
+	}
+
+	tokenKey := cache.GlobalKey("tenant_deletion_token", tenantID.String())
+	if err := s.cache.SetString(ctx, tokenKey, token, deletionTokenTTL); err != nil {
+		return nil, err
+	}
+
+	return &models.DeletionTokenResponse{
+		Token:     token,
+		ExpiresAt: timeutil.From(time.Now().Add(deletionTokenTTL)),
+	}, nil
+}
+
+// DeleteTenant starts, or resumes after a failure, the deletion of a tenant
+// and all its data across services (owner only). The tenant is deactivated
+// immediately and the purge runs in the background; progress is polled with
+// GetDeletionJob.
+func (s *Service) DeleteTenant(ctx context.Context, tenantID uuid.UUID, req *models.DeleteTenantRequest) (*models.TenantDeletionJob, error) {
+	userID := middleware.GetUserID(ctx)
+
+	if _, err := s.deletableTenant(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	// Tokens are single use; a wrong token also burns the issued one
+	tokenKey := cache.GlobalKey("tenant_deletion_token", tenantID.String())
+	expected, err := s.cache.GetString(ctx, tokenKey)
+	_ = s.cache.Delete(ctx, tokenKey)
+	if err != nil || subtle.ConstantTimeCompare([]byte(expected), []byte(req.ConfirmationToken)) != 1 {
+		return nil, errors.Forbiddenf("invalid or expired confirmation token")
+	}
+
+	// Only one run at a time; the lock expires with the job timeout so a
+	// crashed run does not block resumption forever
+	lockKey := cache.GlobalKey("tenant_deletion_lock", tenantID.String())
+	acquired, err := s.cache.SetNX(ctx, lockKey, userID, deletionJobTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, errors.Conflictf("tenant deletion is already in progress")
+	}
+
+	job := s.prepareDeletionJob(ctx, tenantID, userID)
+
+	inactive := false
+	if err := s.repo.UpdateTenant(ctx, tenantID, &models.UpdateTenantRequest{IsActive: &inactive}); err != nil {
+		_ = s.cache.Delete(ctx, lockKey)
+		return nil, err
+	}
+	_ = s.cache.Delete(ctx, cache.BuildKey("tenant", tenantID.String()))
+
+	s.saveDeletionJob(ctx, job)
+
+	logger.InfoContext(ctx, "tenant deletion started",
+		zap.String("tenant_id", tenantID.String()),
+		zap.Int("attempt", job.Attempts),
+	)
+
+	// The worker updates its own copy; the caller gets the starting snapshot
+	running := *job
+	running.Steps = append([]models.TenantDeletionStep(nil), job.Steps...)
+	go func() {
+		jobCtx, cancel := context.WithTimeout(middleware.WithTenantID(context.WithoutCancel(ctx), tenantID.String()), deletionJobTimeout)
+		defer cancel()
+		defer func() { _ = s.cache.Delete(jobCtx, lockKey) }()
+
+		s.runDeletionJob(jobCtx, &running)
+	}()
+
+	return job, nil
+}
+
+// GetDeletionJob returns the progress of a tenant deletion. The requester can
+// poll it after the tenant and its memberships are gone.
+func (s *Service) GetDeletionJob(ctx context.Context, tenantID uuid.UUID) (*models.TenantDeletionJob, error) {
+	userID := middleware.GetUserID(ctx)
+
+	var job models.TenantDeletionJob
+	if err := s.cache.Get(ctx, cache.GlobalKey("tenant_deletion", tenantID.String()), &job); err != nil {
+		return nil, errors.NotFoundf("tenant deletion not found")
+	}
+
+	if job.RequestedBy != userID {
+		isMember, err := s.isUserInTenant(ctx, tenantID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, errors.NotFoundf("tenant deletion not found")
+		}
+	}
+
+	return &job, nil
+}
+
+// deletableTenant checks that the caller owns the tenant and that no legal
+// hold prevents deleting it
+func (s *Service) deletableTenant(ctx context.Context, tenantID uuid.UUID) (*models.Tenant, error) {
+	userID := middleware.GetUserID(ctx)
+
+	isOwner, err := s.repo.IsTenantOwner(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isOwner {
+		return nil, errors.Forbiddenf("only the tenant owner can delete the tenant")
 	}
 
-	invitations, err := s.repo.GetPendingInvitations(ctx, tenantID)
+	tenant, err := s.repo.GetTenantByID(ctx, tenantID)
 	if err != nil {
 		return nil, err
 	}
+	if tenant.LegalHold {
+		return nil, errors.Conflictf("tenant is under legal hold and cannot be deleted")
+	}
+
+	return tenant, nil
+}
+
+// prepareDeletionJob builds the step list for a deletion, carrying over steps
+// completed by an earlier attempt
+func (s *Service) prepareDeletionJob(ctx context.Context, tenantID uuid.UUID, userID string) *models.TenantDeletionJob {
+	var previous models.TenantDeletionJob
+	completed := make(map[string]models.TenantDeletionStep)
+	if err := s.cache.Get(ctx, cache.GlobalKey("tenant_deletion", tenantID.String()), &previous); err == nil {
+		for _, step := range previous.Steps {
+			if step.Status == models.DeletionStatusCompleted {
+				completed[step.Name] = step
+			}
+		}
+	}
+
+	names := make([]string, 0, len(s.purgeSteps)+1)
+	for _, step := range s.purgeSteps {
+		names = append(names, step.Name)
+	}
+	names = append(names, tenantRecordsStep)
+
+	job := &models.TenantDeletionJob{
+		TenantID:    tenantID,
+		Status:      models.DeletionStatusRunning,
+		RequestedBy: userID,
+		Attempts:    previous.Attempts + 1,
+		StartedAt:   timeutil.Now(),
+		UpdatedAt:   timeutil.Now(),
+	}
+	for _, name := range names {
+		if step, ok := completed[name]; ok {
+			job.Steps = append(job.Steps, step)
+			continue
+		}
+		job.Steps = append(job.Steps, models.TenantDeletionStep{Name: name, Status: models.DeletionStatusPending})
+	}
+
+	return job
+}
+
+// runDeletionJob runs the pending steps in order and stops at the first
+// failure, leaving the job resumable
+func (s *Service) runDeletionJob(ctx context.Context, job *models.TenantDeletionJob) {
+	purgers := make(map[string]DataPurger, len(s.purgeSteps))
+	for _, step := range s.purgeSteps {
+		purgers[step.Name] = step.Purger
+	}
+
+	for i := range job.Steps {
+		step := &job.Steps[i]
+		if step.Status == models.DeletionStatusCompleted {
+			continue
+		}
+
+		step.Status = models.DeletionStatusRunning
+		step.Error = ""
+		s.saveDeletionJob(ctx, job)
+
+		var deleted int64
+		var err error
+		if step.Name == tenantRecordsStep {
+			deleted, err = s.deleteTenantRecords(ctx, job.TenantID)
+		} else {
+			deleted, err = purgers[step.Name].PurgeTenant(ctx)
+		}
+
+		if err != nil {
+			step.Status = models.DeletionStatusFailed
+			step.Error = errors.FromError(err).Message
+			job.Status = models.DeletionStatusFailed
+			job.Error = fmt.Sprintf("step %s failed", step.Name)
+			s.saveDeletionJob(ctx, job)
+
+			logger.ErrorContext(ctx, "tenant deletion step failed",
+				zap.String("tenant_id", job.TenantID.String()),
+				zap.String("step", step.Name),
+				zap.Error(err),
+			)
+			return
+		}
+
+		step.Status = models.DeletionStatusCompleted
+		step.Deleted = deleted
+		s.saveDeletionJob(ctx, job)
+	}
+
+	job.Status = models.DeletionStatusCompleted
+	job.Error = ""
+	job.CompletedAt = timeutil.NullFrom(time.Now())
+	s.saveDeletionJob(ctx, job)
+
+	logger.InfoContext(ctx, "tenant deleted", zap.String("tenant_id", job.TenantID.String()))
+}
+
+// deleteTenantRecords removes the tenant, its memberships and invitations, and
+// every tenant-scoped cache entry
+func (s *Service) deleteTenantRecords(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	deleted, err := s.repo.DeleteTenant(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+
+	_ = s.cache.Delete(ctx, cache.BuildKey("tenant", tenantID.String()))
+	if _, err := s.cache.DeleteByPattern(ctx, cache.TenantKey(tenantID.String(), "*")); err != nil {
+		logger.WarnContext(ctx, "failed to clear tenant cache", zap.Error(err))
+	}
+
+	return deleted, nil
+}
+
+// saveDeletionJob stores job progress for polling
+func (s *Service) saveDeletionJob(ctx context.Context, job *models.TenantDeletionJob) {
+	job.UpdatedAt = timeutil.Now()
+	cacheKey := cache.GlobalKey("tenant_deletion", job.TenantID.String())
+	if err := s.cache.Set(ctx, cacheKey, job, deletionJobTTL); err != nil {
+		logger.WarnContext(ctx, "failed to save tenant deletion job", zap.Error(err))
+	}
+}
+
+// membership is the cached result of a membership lookup
+type membership struct {
+	IsMember bool   `json:"is_member"`
+	Role     string `json:"role,omitempty"`
+}
+
+func membershipKey(tenantID uuid.UUID, userID string) string {
+	return cache.TenantKey(tenantID.String(), "membership", userID)
+}
+
+// getMembership looks up a user's membership through the cache. Non-members
+// are cached too, so repeated denied requests don't reach the database.
+func (s *Service) getMembership(ctx context.Context, tenantID uuid.UUID, userID string) (*membership, error) {
+	cacheKey := membershipKey(tenantID, userID)
+	var member membership
+	if err := s.cache.Get(ctx, cacheKey, &member); err == nil {
+		return &member, nil
+	}
+
+	role, err := s.repo.GetUserRole(ctx, tenantID, userID)
+	if err != nil {
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+			return nil, err
+		}
+	} else {
+		member = membership{IsMember: true, Role: role}
+	}
+
+	_ = s.cache.Set(ctx, cacheKey, member, membershipCacheTTL)
+
+	return &member, nil
+}
+
+// isUserInTenant is the cached equivalent of repository.IsUserInTenant
+func (s *Service) isUserInTenant(ctx context.Context, tenantID uuid.UUID, userID string) (bool, error) {
+	member, err := s.getMembership(ctx, tenantID, userID)
+	if err != nil {
+		return false, err
+	}
+	return member.IsMember, nil
+}
+
+// getUserRole is the cached equivalent of repository.GetUserRole
+func (s *Service) getUserRole(ctx context.Context, tenantID uuid.UUID, userID string) (string, error) {
+	member, err := s.getMembership(ctx, tenantID, userID)
+	if err != nil {
+		return "", err
+	}
+	if !member.IsMember {
+		return "", errors.NotFoundf("user not found in tenant")
+	}
+	return member.Role, nil
+}
 
-	return invitations, nil
+// invalidateMembership drops a user's cached membership after it changes
+func (s *Service) invalidateMembership(ctx context.Context, tenantID uuid.UUID, userID string) {
+	_ = s.cache.Delete(ctx, membershipKey(tenantID, userID))
 }
 
 // generateToken generates a random token